@@ -7,15 +7,23 @@ import (
 	"github.com/labstack/gommon/log"
 )
 
-func InitS3(region, id, secret string) *session.Session {
-	var ses, err = session.NewSession(
-		&aws.Config{
-			Region: aws.String(region),
-			Credentials: credentials.NewStaticCredentials(
-				id, secret, "",
-			),
-		},
-	)
+// InitS3 connects to aws, or with an endpoint to an s3 compatible server
+// like minio, which takes the bucket in the path
+
+func InitS3(region, id, secret, endpoint string) *session.Session {
+	var config = &aws.Config{
+		Region: aws.String(region),
+		Credentials: credentials.NewStaticCredentials(
+			id, secret, "",
+		),
+	}
+
+	if endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	var ses, err = session.NewSession(config)
 
 	if err != nil {
 		log.Warn(err)
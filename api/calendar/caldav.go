@@ -0,0 +1,324 @@
+package calendar
+
+import (
+	"be/utils/busy"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+var (
+	errExists       = errors.New("caldav: resource exists")
+	errUnauthorized = errors.New("caldav: unauthorized")
+	errPrivate      = errors.New("caldav: address is not public")
+)
+
+const utc = "20060102T150405Z"
+
+// CalDav keeps the events in a calendar collection of a caldav server (rfc
+// 4791), e.g. nextcloud or radicale, one resource named after the visit for
+// every event
+
+type CalDav struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+func NewCalDav(collection, username, password string) *CalDav {
+	return &CalDav{
+		url:      strings.TrimSuffix(collection, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewPublicCalDav is the caldav calendar at the address a doctor gave, it
+// has to be https on a public address. Loopback, private and link-local
+// addresses are refused when the name is resolved, and again on every
+// connection so a name resolving to them later is refused too
+func NewPublicCalDav(collection, username, password string) (*CalDav, error) {
+	if err := Public(collection); err != nil {
+		return nil, err
+	}
+
+	var c = NewCalDav(collection, username, password)
+	c.client = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: dialPublic}).DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" || len(via) >= 10 {
+				return errPrivate
+			}
+			return nil
+		},
+	}
+
+	return c, nil
+}
+
+// Public checks the url is https and its host resolves to public addresses only
+func Public(collection string) error {
+	var u, err = url.Parse(collection)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("caldav: url must be https")
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return errors.New("caldav: host is not found")
+	}
+
+	for _, ip := range ips {
+		if !public(ip) {
+			return errPrivate
+		}
+	}
+
+	return nil
+}
+
+func public(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	var host, _, err = net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !public(ip) {
+		return errPrivate
+	}
+
+	return nil
+}
+
+func (c *CalDav) resource(id string) string {
+	return c.url + "/" + url.PathEscape(id) + ".ics"
+}
+
+func (c *CalDav) do(method, id string, body []byte, header map[string]string) (*http.Response, error) {
+	var req, err = http.NewRequest(method, c.resource(id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		log.Warn(err)
+		return nil, err
+	}
+	res.Body.Close()
+
+	return res, nil
+}
+
+func (c *CalDav) put(id string, e Event, header map[string]string) (Ref, error) {
+	header["Content-Type"] = "text/calendar; charset=utf-8"
+
+	var res, err = c.do(http.MethodPut, id, ics("", "", []Event{e}, time.Now()), header)
+	if err != nil {
+		return Ref{}, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusPreconditionFailed:
+		return Ref{}, errExists
+	case http.StatusUnauthorized:
+		return Ref{}, errUnauthorized
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return Ref{}, fmt.Errorf("caldav: %v", res.Status)
+	}
+
+	return Ref{Id: id}, nil
+}
+
+// Insert adds the resource of the visit, a resource already there, e.g. of
+// a retried sync, is replaced
+func (c *CalDav) Insert(e Event) (Ref, error) {
+	var ref, err = c.put(e.Uid, e, map[string]string{"If-None-Match": "*"})
+	if errors.Is(err, errExists) {
+		return c.Update(e.Uid, e)
+	}
+
+	return ref, err
+}
+
+func (c *CalDav) Update(id string, e Event) (Ref, error) {
+	if id == "" {
+		id = e.Uid
+	}
+
+	return c.put(id, e, map[string]string{})
+}
+
+// Delete removes the resource, one already gone is fine
+func (c *CalDav) Delete(id, owner string) error {
+	var res, err = c.do(http.MethodDelete, id, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNotFound && res.StatusCode != http.StatusGone && (res.StatusCode < 200 || res.StatusCode > 299) {
+		return fmt.Errorf("caldav: %v", res.Status)
+	}
+
+	return nil
+}
+
+// Busy asks the server for the free/busy of the collection (rfc 4791 7.10)
+func (c *CalDav) Busy(owner string, from, to time.Time) ([]busy.Interval, error) {
+	var body = fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<C:free-busy-query xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <C:time-range start="%v" end="%v"/>
+</C:free-busy-query>`, from.UTC().Format(utc), to.UTC().Format(utc))
+
+	var req, err = http.NewRequest("REPORT", c.url+"/", strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		log.Warn(err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return nil, errUnauthorized
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("caldav: %v", res.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	return freeBusy(string(data))
+}
+
+// freeBusy reads the busy periods of a VFREEBUSY, a period ends at a time or
+// after a duration, the free ones are left out
+func freeBusy(data string) ([]busy.Interval, error) {
+	var unfolded = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(data)
+
+	var intervals = []busy.Interval{}
+	for _, line := range strings.Split(unfolded, "\n") {
+		var parts = strings.SplitN(strings.TrimRight(line, "\r"), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		var params = strings.Split(strings.ToUpper(parts[0]), ";")
+		if params[0] != "FREEBUSY" || contains(params[1:], "FBTYPE=FREE") {
+			continue
+		}
+
+		for _, period := range strings.Split(parts[1], ",") {
+			var bounds = strings.SplitN(strings.TrimSpace(period), "/", 2)
+			if len(bounds) != 2 {
+				return nil, fmt.Errorf("caldav: invalid period %v", period)
+			}
+
+			var start, err = time.Parse(utc, bounds[0])
+			if err != nil {
+				return nil, err
+			}
+
+			var end time.Time
+			if strings.HasPrefix(bounds[1], "P") {
+				length, err := duration(bounds[1])
+				if err != nil {
+					return nil, err
+				}
+				end = start.Add(length)
+			} else if end, err = time.Parse(utc, bounds[1]); err != nil {
+				return nil, err
+			}
+
+			intervals = append(intervals, busy.Interval{Start: start, End: end})
+		}
+	}
+
+	return intervals, nil
+}
+
+// duration of icalendar, e.g. PT1H30M or P1D
+func duration(value string) (time.Duration, error) {
+	var res time.Duration
+	var n int
+	var clock bool
+
+	for _, r := range strings.TrimPrefix(value, "P") {
+		var unit time.Duration
+		switch {
+		case r >= '0' && r <= '9':
+			n = n*10 + int(r-'0')
+			continue
+		case r == 'T':
+			clock = true
+			continue
+		case r == 'W' && !clock:
+			unit = 7 * 24 * time.Hour
+		case r == 'D' && !clock:
+			unit = 24 * time.Hour
+		case r == 'H' && clock:
+			unit = time.Hour
+		case r == 'M' && clock:
+			unit = time.Minute
+		case r == 'S' && clock:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("caldav: invalid duration %v", value)
+		}
+
+		res += time.Duration(n) * unit
+		n = 0
+	}
+
+	return res, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,399 @@
+package calendar
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"be/utils/busy"
+	"be/utils/crypt"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+)
+
+var visitCalendar = visit.VisitCalendar{
+	Visit_uid:    "visit",
+	Status:       "pending",
+	Address:      "jl. sudirman 1, jakarta",
+	Complaint:    "fever; cough",
+	Date:         "10-03-2022",
+	DoctorName:   "dr. budi",
+	PatientName:  "siti",
+	DoctorEmail:  "budi@mail.com",
+	PatientEmail: "siti@mail.com",
+}
+
+func TestFromVisit(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var res, err = FromVisit(visitCalendar)
+		assert.Nil(t, err)
+		assert.Equal(t, "visit", res.Uid)
+		assert.Equal(t, "Appointment with dr. budi", res.Summary)
+		assert.Equal(t, time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC), res.Date)
+		assert.False(t, res.Cancelled)
+		assert.Equal(t, 2, len(res.Attendees))
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		var _, err = FromVisit(visit.VisitCalendar{})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestIcs(t *testing.T) {
+	var event, _ = FromVisit(visitCalendar)
+	var cancelled = event
+	cancelled.Uid = "cancelled"
+	cancelled.Cancelled = true
+	cancelled.Description = strings.Repeat("sakit kepala é ", 10)
+
+	var res = string(ics("dr. budi", "PUBLISH", []Event{event, cancelled}, time.Date(2022, 3, 1, 8, 0, 0, 0, time.UTC)))
+
+	t.Run("calendar", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(res, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		assert.True(t, strings.HasSuffix(res, "END:VCALENDAR\r\n"))
+		assert.Contains(t, res, "METHOD:PUBLISH\r\n")
+		assert.Contains(t, res, "X-WR-CALNAME:dr. budi\r\n")
+		assert.Equal(t, 2, strings.Count(res, "BEGIN:VEVENT"))
+	})
+
+	t.Run("event", func(t *testing.T) {
+		assert.Contains(t, res, "UID:visit@mr-clinic\r\n")
+		assert.Contains(t, res, "DTSTAMP:20220301T080000Z\r\n")
+		assert.Contains(t, res, "DTSTART;VALUE=DATE:20220310\r\nDTEND;VALUE=DATE:20220311\r\n")
+		assert.Contains(t, res, "LOCATION:jl. sudirman 1\\, jakarta\r\n")
+		assert.Contains(t, res, "DESCRIPTION:fever\\; cough\r\n")
+		assert.Contains(t, res, `ATTENDEE;CN="siti":mailto:siti@mail.com`)
+		assert.Contains(t, res, "TRIGGER:-PT1440M\r\n")
+		assert.Contains(t, res, "STATUS:CONFIRMED\r\n")
+		assert.Contains(t, res, "STATUS:CANCELLED\r\n")
+		assert.Contains(t, res, "TRANSP:TRANSPARENT\r\n")
+	})
+
+	t.Run("folded lines", func(t *testing.T) {
+		for _, line := range strings.Split(res, "\r\n") {
+			assert.LessOrEqual(t, len(line), 75)
+		}
+		assert.Contains(t, strings.ReplaceAll(res, "\r\n ", ""), "DESCRIPTION:"+cancelled.Description+"\r\n")
+	})
+
+	t.Run("caldav resource without method", func(t *testing.T) {
+		assert.NotContains(t, string(ics("", "", []Event{event}, time.Now())), "METHOD")
+	})
+}
+
+func TestCalDav(t *testing.T) {
+	var resources = map[string]string{}
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "clinic" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			if _, ok := resources[r.URL.Path]; ok && r.Header.Get("If-None-Match") == "*" {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			var body, _ = io.ReadAll(r.Body)
+			resources[r.URL.Path] = string(body)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			if _, ok := resources[r.URL.Path]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(resources, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		case "REPORT":
+			var body, _ = io.ReadAll(r.Body)
+			if r.URL.Path != "/calendars/clinic/visits/" || !strings.Contains(string(body), `<C:time-range start="20220310T000000Z" end="20220311T000000Z"/>`) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/calendar")
+			w.Write([]byte("BEGIN:VCALENDAR\r\nBEGIN:VFREEBUSY\r\nFREEBUSY;FBTYPE=BUSY:20220310T090000Z/20220310T100000Z,20220310T\r\n 130000Z/PT1H30M\r\nFREEBUSY;FBTYPE=FREE:20220310T150000Z/PT1H\r\nEND:VFREEBUSY\r\nEND:VCALENDAR\r\n"))
+		}
+	}))
+	defer server.Close()
+
+	var c = NewCalDav(server.URL+"/calendars/clinic/visits/", "clinic", "secret")
+	var event, _ = FromVisit(visitCalendar)
+
+	t.Run("success insert, update and delete", func(t *testing.T) {
+		var res, err = c.Insert(event)
+		assert.Nil(t, err)
+		assert.Equal(t, "visit", res.Id)
+		assert.Contains(t, resources["/calendars/clinic/visits/visit.ics"], "STATUS:CONFIRMED")
+
+		event.Cancelled = true
+		_, err = c.Update(res.Id, event)
+		assert.Nil(t, err)
+		assert.Contains(t, resources["/calendars/clinic/visits/visit.ics"], "STATUS:CANCELLED")
+
+		assert.Nil(t, c.Delete(res.Id, ""))
+		assert.Equal(t, 0, len(resources))
+
+		// already gone
+		assert.Nil(t, c.Delete(res.Id, ""))
+	})
+
+	t.Run("insert twice", func(t *testing.T) {
+		event.Cancelled = false
+		c.Insert(event)
+		event.Cancelled = true
+		var res, err = c.Insert(event)
+		assert.Nil(t, err)
+		assert.Equal(t, "visit", res.Id)
+		assert.Contains(t, resources["/calendars/clinic/visits/visit.ics"], "STATUS:CANCELLED")
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		var _, err = NewCalDav(server.URL, "clinic", "wrong").Insert(event)
+		assert.Equal(t, errUnauthorized, err)
+	})
+
+	t.Run("busy", func(t *testing.T) {
+		var from = time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC)
+		var res, err = c.Busy("", from, from.AddDate(0, 0, 1))
+		assert.Nil(t, err)
+		assert.Equal(t, []busy.Interval{
+			{Start: from.Add(9 * time.Hour), End: from.Add(10 * time.Hour)},
+			{Start: from.Add(13 * time.Hour), End: from.Add(14*time.Hour + 30*time.Minute)},
+		}, res)
+
+		_, err = NewCalDav(server.URL+"/calendars/clinic/visits/", "clinic", "wrong").Busy("", from, from.AddDate(0, 0, 1))
+		assert.Equal(t, errUnauthorized, err)
+	})
+}
+
+func TestPublic(t *testing.T) {
+	for _, collection := range []string{"http://93.184.216.34/dav", "ftp://93.184.216.34/dav", "https://127.0.0.1/dav", "https://[::1]/dav", "https://localhost/dav", "https://10.0.0.5/dav", "https://192.168.1.2/dav", "https://169.254.169.254/latest", "https://0.0.0.0/dav"} {
+		assert.NotNil(t, Public(collection), collection)
+	}
+	assert.Nil(t, Public("https://93.184.216.34/dav"))
+
+	var _, err = NewPublicCalDav("https://127.0.0.1/dav", "budi", "secret")
+	assert.NotNil(t, err)
+
+	// a name resolving to a private address after the check is refused on connecting
+
+	assert.NotNil(t, dialPublic("tcp", "127.0.0.1:443", nil))
+	assert.NotNil(t, dialPublic("tcp", "[fe80::1]:443", nil))
+	assert.Nil(t, dialPublic("tcp", "93.184.216.34:443", nil))
+}
+
+func TestDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"P1DT2H":  26 * time.Hour,
+		"PT45S":   45 * time.Second,
+	} {
+		var res, err = duration(value)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, res, value)
+	}
+
+	for _, value := range []string{"P1H", "PT1D", "1H"} {
+		var _, err = duration(value)
+		assert.NotNil(t, err, value)
+	}
+}
+
+type mockConnections struct {
+	conns   map[string]entities.CalendarConnection
+	expired []string
+}
+
+func (m *mockConnections) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+	var conn, ok = m.conns[doctor_uid]
+	if !ok {
+		return entities.CalendarConnection{}, errors.New("record not found")
+	}
+	return conn, nil
+}
+
+func (m *mockConnections) ExpireConnection(doctor_uid string) error {
+	m.expired = append(m.expired, doctor_uid)
+	return nil
+}
+
+type mockProvider struct {
+	calls []string
+}
+
+func (m *mockProvider) Insert(event Event) (Ref, error) {
+	m.calls = append(m.calls, "insert")
+	return Ref{Id: "shared"}, nil
+}
+
+func (m *mockProvider) Update(id string, event Event) (Ref, error) {
+	m.calls = append(m.calls, "update")
+	return Ref{Id: id}, nil
+}
+
+func (m *mockProvider) Delete(id, owner string) error {
+	m.calls = append(m.calls, "delete")
+	return nil
+}
+
+func TestDoctors(t *testing.T) {
+	var events = map[string]bool{}
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+		case r.Header.Get("Authorization") != "Bearer access":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/freeBusy":
+			w.Write([]byte(`{"calendars":{"primary":{"busy":[{"start":"2022-03-10T09:00:00Z","end":"2022-03-10T10:00:00Z"}]}}}`))
+		case r.URL.Path == "/calendars/primary/events/watch":
+			w.Write([]byte(`{"id":"channel","resourceId":"resource","expiration":"1647500000000"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/calendars/primary/events":
+			events["own"] = true
+			w.Write([]byte(`{"id":"own","htmlLink":"https://calendar/own"}`))
+		case strings.HasPrefix(r.URL.Path, "/calendars/primary/events/"):
+			var id = strings.TrimPrefix(r.URL.Path, "/calendars/primary/events/")
+			if !events[id] {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"code":404,"message":"Not Found"}}`))
+				return
+			}
+			if r.Method == http.MethodDelete {
+				delete(events, id)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Write([]byte(`{"id":"` + id + `"}`))
+		}
+	}))
+	defer server.Close()
+
+	var caldav = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "budi" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("BEGIN:VCALENDAR\r\nBEGIN:VFREEBUSY\r\nFREEBUSY:20220310T130000Z/PT1H\r\nEND:VFREEBUSY\r\nEND:VCALENDAR\r\n"))
+	}))
+	defer caldav.Close()
+
+	var cipher, _ = crypt.New("secret")
+	var seal = func(token interface{}) string {
+		var plain, _ = json.Marshal(token)
+		var sealed, _ = cipher.Encrypt(plain)
+		return sealed
+	}
+
+	var r = &mockConnections{conns: map[string]entities.CalendarConnection{
+		"connected": {Doctor_uid: "connected", Calendar_id: "primary", Status: "connected", Token: seal(oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)})},
+		"refused":   {Doctor_uid: "refused", Calendar_id: "primary", Status: "connected", Token: seal(oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)})},
+		"expired":   {Doctor_uid: "expired", Calendar_id: "primary", Status: "expired"},
+		"caldav":    {Doctor_uid: "caldav", Provider: "caldav", Calendar_id: caldav.URL, Status: "connected", Token: seal(Credential{Username: "budi", Password: "secret"})},
+		"wrong":     {Doctor_uid: "wrong", Provider: "caldav", Calendar_id: caldav.URL, Status: "connected", Token: seal(Credential{Username: "budi", Password: "wrong"})},
+	}}
+	var conf = &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: server.URL + "/token"}}
+	var event, _ = FromVisit(visitCalendar)
+
+	var doctors = func(fallback Provider) *Doctors {
+		var d = NewDoctors(r, conf, cipher, fallback)
+		d.options = []option.ClientOption{option.WithEndpoint(server.URL + "/")}
+		d.caldav = func(collection, username, password string) (*CalDav, error) {
+			return NewCalDav(collection, username, password), nil
+		}
+		return d
+	}
+
+	t.Run("calendar of the doctor", func(t *testing.T) {
+		var fallback = &mockProvider{}
+		event.Owner = "connected"
+
+		var res, err = doctors(fallback).Insert(event)
+		assert.Nil(t, err)
+		assert.Equal(t, Ref{Id: "own", Link: "https://calendar/own"}, res)
+
+		res, err = doctors(fallback).Update("own", event)
+		assert.Nil(t, err)
+		assert.Equal(t, "own", res.Id)
+
+		assert.Nil(t, doctors(fallback).Delete("own", "connected"))
+		assert.Equal(t, 0, len(fallback.calls))
+	})
+
+	t.Run("moved from the shared calendar", func(t *testing.T) {
+		var fallback = &mockProvider{}
+		event.Owner = "connected"
+
+		var res, err = doctors(fallback).Update("shared", event)
+		assert.Nil(t, err)
+		assert.Equal(t, "own", res.Id)
+		assert.Equal(t, []string{"delete"}, fallback.calls)
+	})
+
+	t.Run("not connected", func(t *testing.T) {
+		var fallback = &mockProvider{}
+		event.Owner = "other"
+
+		var res, _ = doctors(fallback).Insert(event)
+		assert.Equal(t, "shared", res.Id)
+
+		event.Owner = "expired"
+		doctors(fallback).Insert(event)
+		assert.Equal(t, []string{"insert", "insert"}, fallback.calls)
+	})
+
+	t.Run("token refused", func(t *testing.T) {
+		var fallback = &mockProvider{}
+		event.Owner = "refused"
+
+		var res, err = doctors(fallback).Insert(event)
+		assert.Nil(t, err)
+		assert.Equal(t, "shared", res.Id)
+		assert.Equal(t, []string{"refused"}, r.expired)
+	})
+
+	t.Run("busy", func(t *testing.T) {
+		var from = time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC)
+
+		var res, err = doctors(&mockProvider{}).Busy("connected", from, from.AddDate(0, 0, 1))
+		assert.Nil(t, err)
+		assert.Equal(t, []busy.Interval{{Start: from.Add(9 * time.Hour), End: from.Add(10 * time.Hour)}}, res)
+
+		res, err = doctors(&mockProvider{}).Busy("caldav", from, from.AddDate(0, 0, 1))
+		assert.Nil(t, err)
+		assert.Equal(t, []busy.Interval{{Start: from.Add(13 * time.Hour), End: from.Add(14 * time.Hour)}}, res)
+
+		_, err = doctors(&mockProvider{}).Busy("other", from, from.AddDate(0, 0, 1))
+		assert.Equal(t, ErrNotConnected, err)
+	})
+
+	t.Run("caldav password refused", func(t *testing.T) {
+		r.expired = nil
+		var _, err = doctors(&mockProvider{}).Busy("wrong", time.Now(), time.Now().Add(time.Hour))
+		assert.NotNil(t, err)
+		assert.Equal(t, []string{"wrong"}, r.expired)
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		var res, err = doctors(&mockProvider{}).Watch("connected", Channel{Id: "channel", Token: "token", Address: "https://clinic/calendar/push"})
+		assert.Nil(t, err)
+		assert.Equal(t, "resource", res.Resource)
+		assert.Equal(t, time.UnixMilli(1647500000000), res.Expires)
+
+		_, err = doctors(&mockProvider{}).Watch("caldav", Channel{Id: "channel"})
+		assert.Equal(t, ErrNoPush, err)
+	})
+}
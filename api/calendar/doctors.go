@@ -0,0 +1,224 @@
+package calendar
+
+import (
+	"be/entities"
+	"be/utils/busy"
+	"be/utils/crypt"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+type Connections interface {
+	GetConnection(doctor_uid string) (entities.CalendarConnection, error)
+	ExpireConnection(doctor_uid string) error
+}
+
+var (
+	ErrNotConnected = errors.New("calendar is not connected")
+	ErrNoPush       = errors.New("calendar has no push channel")
+)
+
+// Doctors keeps the events in the google or caldav calendar each doctor
+// connected. The visits of a doctor without a connection, or whose token
+// the provider refused, go to the fallback, e.g. the shared calendar
+
+type Doctors struct {
+	r        Connections
+	conf     *oauth2.Config
+	cipher   crypt.Cipher
+	fallback Provider
+	options  []option.ClientOption
+	caldav   func(collection, username, password string) (*CalDav, error)
+}
+
+func NewDoctors(r Connections, conf *oauth2.Config, cipher crypt.Cipher, fallback Provider) *Doctors {
+	return &Doctors{
+		r:        r,
+		conf:     conf,
+		cipher:   cipher,
+		fallback: fallback,
+		caldav:   NewPublicCalDav,
+	}
+}
+
+// calendar of the doctor, nil when not connected
+func (d *Doctors) calendar(doctor_uid string) Provider {
+	if doctor_uid == "" {
+		return nil
+	}
+
+	var conn, err = d.r.GetConnection(doctor_uid)
+	if err != nil || conn.Status != "connected" {
+		return nil
+	}
+
+	// a token sealed with another key can't be used anymore
+
+	plain, err := d.cipher.Decrypt(conn.Token)
+	if err != nil {
+		log.Warn(err)
+		if err := d.r.ExpireConnection(doctor_uid); err != nil {
+			log.Warn(err)
+		}
+		return nil
+	}
+
+	if conn.Provider == "caldav" {
+		var credential Credential
+		if err := json.Unmarshal(plain, &credential); err != nil {
+			log.Warn(err)
+			return nil
+		}
+
+		c, err := d.caldav(conn.Calendar_id, credential.Username, credential.Password)
+		if err != nil {
+			log.Warn(err)
+			return nil
+		}
+
+		return c
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(plain, &token); err != nil {
+		log.Warn(err)
+		return nil
+	}
+
+	var ctx = context.Background()
+	srv, err := calendar.NewService(ctx, append([]option.ClientOption{option.WithTokenSource(d.conf.TokenSource(ctx, &token))}, d.options...)...)
+	if err != nil {
+		log.Warn(err)
+		return nil
+	}
+
+	return NewGoogle(srv, conn.Calendar_id)
+}
+
+// expired marks the connection when google refused to refresh the token or
+// the caldav server the password, the event then goes to the fallback
+func (d *Doctors) expired(doctor_uid string, err error) bool {
+	var retrieve *oauth2.RetrieveError
+	if !errors.As(err, &retrieve) && !errors.Is(err, errUnauthorized) {
+		return false
+	}
+
+	log.Warn("calendar token of doctor ", doctor_uid, " expired")
+	if err := d.r.ExpireConnection(doctor_uid); err != nil {
+		log.Warn(err)
+	}
+
+	return true
+}
+
+func (d *Doctors) Insert(e Event) (Ref, error) {
+	if g := d.calendar(e.Owner); g != nil {
+		var ref, err = g.Insert(e)
+		if !d.expired(e.Owner, err) {
+			return ref, err
+		}
+	}
+
+	return d.fallback.Insert(e)
+}
+
+// Update moves an event added before the doctor connected from the fallback
+// to the calendar of the doctor, and back after a disconnect, the ref then
+// has the new id
+func (d *Doctors) Update(id string, e Event) (Ref, error) {
+	if g := d.calendar(e.Owner); g != nil {
+		var ref, err = g.Update(id, e)
+		if notFound(err) {
+			if err := d.fallback.Delete(id, e.Owner); err != nil {
+				log.Warn(err)
+			}
+			return g.Insert(e)
+		}
+		if !d.expired(e.Owner, err) {
+			return ref, err
+		}
+	}
+
+	var ref, err = d.fallback.Update(id, e)
+	if notFound(err) {
+		return d.fallback.Insert(e)
+	}
+
+	return ref, err
+}
+
+func (d *Doctors) Delete(id, owner string) error {
+	if g := d.calendar(owner); g != nil {
+		var err = g.Delete(id, owner)
+		if err == nil {
+			return nil
+		}
+		if !notFound(err) && !d.expired(owner, err) {
+			return err
+		}
+	}
+
+	if err := d.fallback.Delete(id, owner); err != nil && !notFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Busy is the free/busy of the calendar of the doctor, the shared calendar
+// tells nothing about a doctor
+func (d *Doctors) Busy(owner string, from, to time.Time) ([]busy.Interval, error) {
+	var p, ok = d.calendar(owner).(Busy)
+	if !ok {
+		return nil, ErrNotConnected
+	}
+
+	var res, err = p.Busy(owner, from, to)
+	if err != nil {
+		d.expired(owner, err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Watch opens a push channel of the google calendar of the doctor, a caldav
+// calendar is only read on the import
+func (d *Doctors) Watch(owner string, channel Channel) (Channel, error) {
+	var p, ok = d.calendar(owner).(Watcher)
+	if !ok {
+		return Channel{}, ErrNoPush
+	}
+
+	var res, err = p.Watch(owner, channel)
+	if err != nil {
+		d.expired(owner, err)
+		return Channel{}, err
+	}
+
+	return res, nil
+}
+
+func (d *Doctors) Stop(owner string, channel Channel) error {
+	var p, ok = d.calendar(owner).(Watcher)
+	if !ok {
+		return ErrNoPush
+	}
+
+	return p.Stop(owner, channel)
+}
+
+// notFound is an event google doesn't have in the calendar
+func notFound(err error) bool {
+	var res *googleapi.Error
+	return errors.As(err, &res) && (res.Code == http.StatusNotFound || res.Code == http.StatusGone)
+}
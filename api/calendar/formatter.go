@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"be/repository/visit"
+	"time"
+)
+
+// Event is a visit in a calendar, a visit takes the whole day of its date
+
+type Event struct {
+	// the visit_uid, the same event in every calendar
+	Uid string
+	// the doctor_uid, whose calendar may have the event
+	Owner       string
+	Summary     string
+	Description string
+	Location    string
+	Date        time.Time
+	Cancelled   bool
+	Attendees   []Attendee
+	Reminders   []time.Duration
+}
+
+type Attendee struct {
+	Name  string
+	Email string
+}
+
+// Ref is the event in the calendar of the provider, the link is only known
+// by google
+
+type Ref struct {
+	Id   string
+	Link string
+}
+
+// Channel is a push channel of google, the token is sent back with every
+// push and the resource is the id google gives the watched calendar
+
+type Channel struct {
+	Id       string
+	Token    string
+	Address  string
+	Resource string
+	Expires  time.Time
+}
+
+// Credential is the sealed login of the caldav calendar a doctor connected
+
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// reminders are mailed by google before the visit
+
+var reminders = []time.Duration{24 * time.Hour, 2 * time.Hour, time.Hour, 30 * time.Minute, 15 * time.Minute}
+
+func FromVisit(res visit.VisitCalendar) (Event, error) {
+	var date, err = time.Parse("02-01-2006", res.Date)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Uid:         res.Visit_uid,
+		Owner:       res.Doctor_uid,
+		Summary:     "Appointment with " + res.DoctorName,
+		Description: res.Complaint,
+		Location:    res.Address,
+		Date:        date,
+		Cancelled:   res.Status == "cancelled",
+		Attendees: []Attendee{
+			{Name: res.DoctorName, Email: res.DoctorEmail},
+			{Name: res.PatientName, Email: res.PatientEmail},
+		},
+		Reminders: reminders,
+	}, nil
+}
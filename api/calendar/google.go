@@ -0,0 +1,183 @@
+package calendar
+
+import (
+	"be/utils/busy"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Google keeps the events in a calendar of the google account of the token
+
+type Google struct {
+	srv        *calendar.Service
+	calendarId string
+}
+
+func NewGoogle(srv *calendar.Service, calendarId string) *Google {
+	if calendarId == "" {
+		calendarId = "primary"
+	}
+
+	return &Google{
+		srv:        srv,
+		calendarId: calendarId,
+	}
+}
+
+func (g *Google) event(e Event) *calendar.Event {
+	var event = &calendar.Event{
+		Summary:     e.Summary,
+		Location:    e.Location,
+		Description: e.Description,
+		Start: &calendar.EventDateTime{
+			Date: e.Date.Format("2006-01-02"),
+		},
+		End: &calendar.EventDateTime{
+			Date: e.Date.AddDate(0, 0, 1).Format("2006-01-02"),
+		},
+		Reminders: &calendar.EventReminders{
+			UseDefault:      false,
+			ForceSendFields: []string{"UseDefault"},
+		},
+		// the visit doesn't make the doctor busy, the busy import would
+		// block the day of every visit
+		Transparency: "transparent",
+	}
+
+	for _, attendee := range e.Attendees {
+		event.Attendees = append(event.Attendees, &calendar.EventAttendee{DisplayName: attendee.Name, Email: attendee.Email})
+	}
+
+	for _, reminder := range e.Reminders {
+		event.Reminders.Overrides = append(event.Reminders.Overrides, &calendar.EventReminder{Method: "email", Minutes: int64(reminder.Minutes())})
+	}
+
+	if e.Cancelled {
+		event.Status = "cancelled"
+	}
+
+	return event
+}
+
+// Insert gives the event an id made of the visit, inserting it again, e.g.
+// a retried sync, updates the event
+func (g *Google) Insert(e Event) (Ref, error) {
+	var event = g.event(e)
+	event.Id = EventId(e.Uid)
+
+	var res, err = g.srv.Events.Insert(g.calendarId, event).Do()
+	if conflict(err) {
+		return g.Update(event.Id, e)
+	}
+	if err != nil {
+		log.Warn(err)
+		return Ref{}, err
+	}
+
+	return Ref{Id: res.Id, Link: res.HtmlLink}, nil
+}
+
+func (g *Google) Update(id string, e Event) (Ref, error) {
+	var res, err = g.srv.Events.Update(g.calendarId, id, g.event(e)).SendUpdates("all").SendNotifications(true).Do()
+	if err != nil {
+		log.Warn(err)
+		return Ref{}, err
+	}
+
+	return Ref{Id: res.Id, Link: res.HtmlLink}, nil
+}
+
+func (g *Google) Delete(id, owner string) error {
+	if err := g.srv.Events.Delete(g.calendarId, id).SendUpdates("all").SendNotifications(true).Do(); err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+// Busy is the free/busy of the calendar
+func (g *Google) Busy(owner string, from, to time.Time) ([]busy.Interval, error) {
+	var res, err = g.srv.Freebusy.Query(&calendar.FreeBusyRequest{
+		TimeMin: from.Format(time.RFC3339),
+		TimeMax: to.Format(time.RFC3339),
+		Items:   []*calendar.FreeBusyRequestItem{{Id: g.calendarId}},
+	}).Do()
+	if err != nil {
+		log.Warn(err)
+		return nil, err
+	}
+
+	var cal, ok = res.Calendars[g.calendarId]
+	if !ok {
+		return nil, fmt.Errorf("google freebusy: no calendar %v", g.calendarId)
+	}
+	if len(cal.Errors) != 0 {
+		return nil, fmt.Errorf("google freebusy: %v", cal.Errors[0].Reason)
+	}
+
+	var intervals = []busy.Interval{}
+	for _, period := range cal.Busy {
+		var start, err = time.Parse(time.RFC3339, period.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.RFC3339, period.End)
+		if err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, busy.Interval{Start: start, End: end})
+	}
+
+	return intervals, nil
+}
+
+// Watch opens a channel pushing the changes of the events to the address,
+// google keeps a channel for a week at most
+func (g *Google) Watch(owner string, channel Channel) (Channel, error) {
+	var res, err = g.srv.Events.Watch(g.calendarId, &calendar.Channel{
+		Id:         channel.Id,
+		Type:       "web_hook",
+		Address:    channel.Address,
+		Token:      channel.Token,
+		Expiration: channel.Expires.UnixMilli(),
+	}).Do()
+	if err != nil {
+		log.Warn(err)
+		return Channel{}, err
+	}
+
+	channel.Resource = res.ResourceId
+	channel.Expires = time.UnixMilli(res.Expiration)
+
+	return channel, nil
+}
+
+func (g *Google) Stop(owner string, channel Channel) error {
+	if err := g.srv.Channels.Stop(&calendar.Channel{Id: channel.Id, ResourceId: channel.Resource}).Do(); err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+// EventId is the google event id of the visit, google only takes the
+// characters of base32hex
+func EventId(visit_uid string) string {
+	var sum = sha256.Sum256([]byte(visit_uid + "@" + Domain))
+	return hex.EncodeToString(sum[:])
+}
+
+func conflict(err error) bool {
+	var res *googleapi.Error
+	return errors.As(err, &res) && res.Code == http.StatusConflict
+}
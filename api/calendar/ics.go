@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Domain makes the uid of the visits unique outside of the app
+
+const Domain = "mr-clinic"
+
+// Ics is an iCalendar (rfc 5545) of the events, for a download or a feed
+func Ics(name string, events []Event) []byte {
+	return ics(name, "PUBLISH", events, time.Now())
+}
+
+// ics writes the calendar, a caldav resource is written without a method
+func ics(name, method string, events []Event, now time.Time) []byte {
+	var buf bytes.Buffer
+	var line = func(format string, args ...interface{}) {
+		fold(&buf, fmt.Sprintf(format, args...))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//MR Clinic//Visits//EN")
+	line("CALSCALE:GREGORIAN")
+	if method != "" {
+		line("METHOD:%v", method)
+	}
+	if name != "" {
+		line("X-WR-CALNAME:%v", text(name))
+	}
+
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:%v@%v", e.Uid, Domain)
+		line("DTSTAMP:%v", now.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:%v", e.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:%v", e.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:%v", text(e.Summary))
+		if e.Location != "" {
+			line("LOCATION:%v", text(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION:%v", text(e.Description))
+		}
+		if e.Cancelled {
+			line("STATUS:CANCELLED")
+		} else {
+			line("STATUS:CONFIRMED")
+		}
+		line("TRANSP:TRANSPARENT")
+		for _, attendee := range e.Attendees {
+			if attendee.Email == "" {
+				continue
+			}
+			line(`ATTENDEE;CN="%v":mailto:%v`, strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(attendee.Name), attendee.Email)
+		}
+		for _, reminder := range e.Reminders {
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line("DESCRIPTION:%v", text(e.Summary))
+			line("TRIGGER:-PT%vM", int(reminder.Minutes()))
+			line("END:VALARM")
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+
+	return buf.Bytes()
+}
+
+// text escapes a text value
+func text(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(value)
+}
+
+// fold ends the line with crlf, a line longer than 75 bytes goes on in the
+// next lines starting with a space, never splitting a character
+func fold(buf *bytes.Buffer, line string) {
+	var limit = 75
+
+	for len(line) > limit {
+		var cut = limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+
+		// the space takes a byte of the next lines
+		limit = 74
+	}
+
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,32 @@
+package calendar
+
+import (
+	"be/utils/busy"
+	"time"
+)
+
+// Provider keeps the events of the visits in a calendar: google, a caldav
+// server or none. The id of the provider is saved as the event_uid of the
+// visit, the owner is the doctor of the visit
+
+type Provider interface {
+	Insert(event Event) (Ref, error)
+	Update(id string, event Event) (Ref, error)
+	Delete(id, owner string) error
+}
+
+// Busy reads the busy times of the calendar of the owner, e.g. the personal
+// time a doctor blocked, the events of the visits are transparent and left
+// out
+
+type Busy interface {
+	Busy(owner string, from, to time.Time) ([]busy.Interval, error)
+}
+
+// Watcher opens a push channel telling the address about the changes in the
+// calendar of the owner, and stops it
+
+type Watcher interface {
+	Watch(owner string, channel Channel) (Channel, error)
+	Stop(owner string, channel Channel) error
+}
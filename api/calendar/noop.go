@@ -0,0 +1,21 @@
+package calendar
+
+// Noop keeps no calendar, the visits are only in the ics downloads and feeds
+
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Insert(event Event) (Ref, error) {
+	return Ref{}, nil
+}
+
+func (n *Noop) Update(id string, event Event) (Ref, error) {
+	return Ref{}, nil
+}
+
+func (n *Noop) Delete(id, owner string) error {
+	return nil
+}
@@ -2,6 +2,7 @@ package google
 
 import (
 	"context"
+	"errors"
 
 	"github.com/labstack/gommon/log"
 	"golang.org/x/oauth2"
@@ -43,16 +44,24 @@ func SetupConfig(db_username, clientId, clientScret string) *oauth2.Config {
 	return conf
 }
 
-func InitCalendar(b []byte, token *oauth2.Token) *calendar.Service {
-	config, err := google.ConfigFromJSON(b, calendar.CalendarReadonlyScope)
+// InitCalendar is the calendar service of the token, an error when the
+// credential or token file is missing
+func InitCalendar(b []byte, token *oauth2.Token) (*calendar.Service, error) {
+	if token == nil {
+		return nil, errors.New("no google calendar token")
+	}
+
+	config, err := google.ConfigFromJSON(b, calendar.CalendarScope)
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		log.Warnf("Unable to parse client secret file to config: %v", err)
+		return nil, err
 	}
 
 	ctx := context.Background()
 	srv, err := calendar.NewService(ctx, option.WithTokenSource(config.TokenSource(ctx, token)))
 	if err != nil {
 		log.Warn(err)
+		return nil, err
 	}
-	return srv
+	return srv, nil
 }
@@ -0,0 +1,14 @@
+package mail
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Body        []byte
+}
+
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
@@ -0,0 +1,8 @@
+package mail
+
+// Mailer delivers the messages, Smtp in production and Log when no smtp
+// server is configured
+
+type Mailer interface {
+	Send(msg Message) error
+}
@@ -0,0 +1,25 @@
+package mail
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Log only writes the mail to the log, for local runs without an smtp server
+
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("no recipient")
+	}
+
+	log.Info("mail to ", strings.Join(msg.To, ", "), ": ", msg.Subject, "\n", msg.Body)
+	return nil
+}
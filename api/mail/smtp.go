@@ -0,0 +1,112 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+type Smtp struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// New sends through the smtp server with plain auth, the server must offer
+// STARTTLS for the auth to be sent
+
+func New(host, port, username, password, from string) *Smtp {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &Smtp{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *Smtp) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("no recipient")
+	}
+
+	var body, err = Build(s.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(s.addr, s.auth, s.from, msg.To, body); err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+// Build writes the message as a multipart mime mail, the text body first and
+// every attachment base64 encoded after it
+
+func Build(from string, msg Message, date time.Time) ([]byte, error) {
+	var buf = new(bytes.Buffer)
+	var writer = multipart.NewWriter(buf)
+
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	for _, to := range msg.To {
+		fmt.Fprintf(buf, "To: %s\r\n", to)
+	}
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var text = quotedprintable.NewWriter(part)
+	if _, err := text.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	text.Close()
+
+	for _, attachment := range msg.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var encoded = base64.StdEncoding.EncodeToString(attachment.Body)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
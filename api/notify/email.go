@@ -0,0 +1,19 @@
+package notify
+
+import "be/api/mail"
+
+// Email sends the message with the mailer, smtp or the log
+
+type Email struct {
+	mailer mail.Mailer
+}
+
+func NewEmail(mailer mail.Mailer) *Email {
+	return &Email{
+		mailer: mailer,
+	}
+}
+
+func (e *Email) Send(msg Message) error {
+	return e.mailer.Send(mail.Message{To: []string{msg.To}, Subject: msg.Subject, Body: msg.Body})
+}
@@ -0,0 +1,27 @@
+package notify
+
+import "sync"
+
+// Fake keeps the messages sent, for the tests, and fails them with Err
+
+type Fake struct {
+	mu   sync.Mutex
+	Sent []Message
+	Err  error
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Send(msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+
+	f.Sent = append(f.Sent, msg)
+	return nil
+}
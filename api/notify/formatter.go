@@ -0,0 +1,22 @@
+package notify
+
+// Message is a rendered notification, whatsapp sends the approved template
+// named after the kind with the params instead of the body
+
+type Message struct {
+	Kind    string
+	To      string
+	Subject string
+	Body    string
+	Params  []string
+}
+
+// Data fills the templates, other is the doctor for a patient and the
+// patient for a doctor
+
+type Data struct {
+	Name  string
+	Other string
+	Date  string
+	Visit string
+}
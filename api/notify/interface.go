@@ -0,0 +1,8 @@
+package notify
+
+// Channel delivers a message to the address in To: an email, or the phone of
+// an sms or whatsapp message
+
+type Channel interface {
+	Send(msg Message) error
+}
@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"errors"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Log only writes the message to the log, for a channel not configured
+
+type Log struct {
+	name string
+}
+
+func NewLog(name string) *Log {
+	return &Log{
+		name: name,
+	}
+}
+
+func (l *Log) Send(msg Message) error {
+	if msg.To == "" {
+		return errors.New("no recipient")
+	}
+
+	log.Info(l.name, " to ", msg.To, ": ", msg.Body)
+	return nil
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"be/api/mail"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var data = Data{Name: "siti", Other: "dr. budi", Date: "10-03-2022", Visit: "patient-1"}
+
+func TestRender(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		for _, kind := range []string{BookingConfirmed, Reminder, Cancelled, ResultsReady} {
+			var res, err = Render(kind, data)
+			assert.Nil(t, err)
+			assert.Equal(t, kind, res.Kind)
+			assert.NotEqual(t, "", res.Subject)
+			assert.Contains(t, res.Body, "Hi siti")
+			assert.Contains(t, res.Body, "dr. budi")
+			assert.Equal(t, []string{"siti", "dr. budi", "10-03-2022", "patient-1"}, res.Params)
+		}
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		var _, err = Render("promo", data)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestPhone(t *testing.T) {
+	assert.Equal(t, "628123456789", Phone("08123456789"))
+	assert.Equal(t, "628123456789", Phone("+628123456789"))
+	assert.Equal(t, "6591234567", Phone(" 6591234567 "))
+}
+
+type mockMailer struct {
+	sent []mail.Message
+}
+
+func (m *mockMailer) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestEmail(t *testing.T) {
+	var mailer = &mockMailer{}
+	var msg, _ = Render(Reminder, data)
+	msg.To = "siti@mail.com"
+
+	assert.Nil(t, NewEmail(mailer).Send(msg))
+	assert.Equal(t, []mail.Message{{To: []string{"siti@mail.com"}, Subject: "Appointment reminder", Body: msg.Body}}, mailer.sent)
+}
+
+func server(t *testing.T, status int, got *map[string]interface{}, auth *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = io.ReadAll(r.Body)
+		json.Unmarshal(body, got)
+		*auth = r.Header.Get("Authorization")
+		w.WriteHeader(status)
+	}))
+}
+
+func TestSms(t *testing.T) {
+	var got map[string]interface{}
+	var auth string
+	var msg, _ = Render(BookingConfirmed, data)
+	msg.To = "08123456789"
+
+	t.Run("success", func(t *testing.T) {
+		var s = server(t, http.StatusOK, &got, &auth)
+		defer s.Close()
+
+		assert.Nil(t, NewSms(s.URL, "key", "CLINIC").Send(msg))
+		assert.Equal(t, "Bearer key", auth)
+		assert.Equal(t, map[string]interface{}{"from": "CLINIC", "to": "628123456789", "text": msg.Body}, got)
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		var s = server(t, http.StatusUnauthorized, &got, &auth)
+		defer s.Close()
+
+		assert.Equal(t, "sms: 401 Unauthorized", NewSms(s.URL, "wrong", "CLINIC").Send(msg).Error())
+	})
+}
+
+func TestWhatsApp(t *testing.T) {
+	var got map[string]interface{}
+	var auth string
+	var msg, _ = Render(Cancelled, data)
+	msg.To = "+628123456789"
+
+	t.Run("success", func(t *testing.T) {
+		var path string
+		var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			var body, _ = io.ReadAll(r.Body)
+			json.Unmarshal(body, &got)
+			auth = r.Header.Get("Authorization")
+		}))
+		defer s.Close()
+
+		assert.Nil(t, NewWhatsApp(s.URL+"/v17.0/1234/", "token", "").Send(msg))
+		assert.Equal(t, "/v17.0/1234/messages", path)
+		assert.Equal(t, "Bearer token", auth)
+		assert.Equal(t, "628123456789", got["to"])
+		assert.Equal(t, "template", got["type"])
+
+		var template = got["template"].(map[string]interface{})
+		assert.Equal(t, "cancelled", template["name"])
+		assert.Equal(t, "id", template["language"].(map[string]interface{})["code"])
+
+		var params = template["components"].([]interface{})[0].(map[string]interface{})["parameters"].([]interface{})
+		assert.Equal(t, 4, len(params))
+		assert.Equal(t, "dr. budi", params[1].(map[string]interface{})["text"])
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		var s = server(t, http.StatusBadRequest, &got, &auth)
+		defer s.Close()
+
+		assert.NotNil(t, NewWhatsApp(s.URL, "token", "en").Send(msg))
+	})
+}
+
+func TestFake(t *testing.T) {
+	var fake = NewFake()
+	assert.Nil(t, fake.Send(Message{To: "siti@mail.com"}))
+	assert.Equal(t, 1, len(fake.Sent))
+
+	fake.Err = errors.New("down")
+	assert.NotNil(t, fake.Send(Message{To: "siti@mail.com"}))
+	assert.Equal(t, 1, len(fake.Sent))
+}
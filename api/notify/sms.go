@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Sms posts the message to an http sms gateway as json, with the api key as
+// the bearer token
+
+type Sms struct {
+	url    string
+	key    string
+	from   string
+	client *http.Client
+}
+
+func NewSms(url, key, from string) *Sms {
+	return &Sms{
+		url:    url,
+		key:    key,
+		from:   from,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *Sms) Send(msg Message) error {
+	var body, _ = json.Marshal(map[string]string{
+		"from": s.from,
+		"to":   Phone(msg.To),
+		"text": msg.Body,
+	})
+
+	return post(s.client, s.url, s.key, body, "sms")
+}
+
+// Phone is the number in international form without the plus, a local
+// number starting with 0 is indonesian
+func Phone(phone string) string {
+	phone = strings.TrimPrefix(strings.TrimSpace(phone), "+")
+	if strings.HasPrefix(phone, "0") {
+		phone = "62" + strings.TrimPrefix(phone, "0")
+	}
+
+	return phone
+}
+
+func post(client *http.Client, url, token string, body []byte, name string) error {
+	var req, err = http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%v: %v", name, res.Status)
+	}
+
+	return nil
+}
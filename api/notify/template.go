@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"be/utils/notice"
+	"bytes"
+	"errors"
+	"text/template"
+)
+
+const (
+	BookingConfirmed = notice.BookingConfirmed
+	Reminder         = notice.Reminder
+	Cancelled        = notice.Cancelled
+	ResultsReady     = notice.ResultsReady
+)
+
+var templates = map[string]struct {
+	subject string
+	body    *template.Template
+}{
+	BookingConfirmed: {"Appointment confirmed", template.Must(template.New(BookingConfirmed).Parse(
+		"Hi {{.Name}}, your appointment with {{.Other}} on {{.Date}} is confirmed. Visit number {{.Visit}}.",
+	))},
+	Reminder: {"Appointment reminder", template.Must(template.New(Reminder).Parse(
+		"Hi {{.Name}}, a reminder of your appointment with {{.Other}} tomorrow, {{.Date}}. Visit number {{.Visit}}.",
+	))},
+	Cancelled: {"Appointment cancelled", template.Must(template.New(Cancelled).Parse(
+		"Hi {{.Name}}, your appointment with {{.Other}} on {{.Date}} is cancelled. Visit number {{.Visit}}.",
+	))},
+	ResultsReady: {"Lab results ready", template.Must(template.New(ResultsReady).Parse(
+		"Hi {{.Name}}, the lab results of your visit with {{.Other}} on {{.Date}} are ready. Visit number {{.Visit}}.",
+	))},
+}
+
+// Render writes the message of the kind, the params are the fields of the
+// whatsapp template in the same order
+func Render(kind string, data Data) (Message, error) {
+	var t, ok = templates[kind]
+	if !ok {
+		return Message{}, errors.New("unknown notification " + kind)
+	}
+
+	var body bytes.Buffer
+	if err := t.body.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Kind:    kind,
+		Subject: t.subject,
+		Body:    body.String(),
+		Params:  []string{data.Name, data.Other, data.Date, data.Visit},
+	}, nil
+}
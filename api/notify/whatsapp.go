@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WhatsApp sends the message with the whatsapp business cloud api. A message
+// to a user who didn't write in the last 24 hours must be an approved
+// template, so the template named after the kind is sent with the params
+
+type WhatsApp struct {
+	url      string
+	token    string
+	language string
+	client   *http.Client
+}
+
+// NewWhatsApp takes the url of the api with the phone number id, e.g.
+// https://graph.facebook.com/v17.0/<phone number id>
+func NewWhatsApp(url, token, language string) *WhatsApp {
+	if language == "" {
+		language = "id"
+	}
+
+	return &WhatsApp{
+		url:      strings.TrimSuffix(url, "/") + "/messages",
+		token:    token,
+		language: language,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (w *WhatsApp) Send(msg Message) error {
+	var params = []map[string]string{}
+	for _, param := range msg.Params {
+		params = append(params, map[string]string{"type": "text", "text": param})
+	}
+
+	var body, _ = json.Marshal(map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                Phone(msg.To),
+		"type":              "template",
+		"template": map[string]interface{}{
+			"name":     msg.Kind,
+			"language": map[string]string{"code": w.language},
+			"components": []map[string]interface{}{
+				{"type": "body", "parameters": params},
+			},
+		},
+	})
+
+	return post(w.client, w.url, w.token, body, "whatsapp")
+}
@@ -0,0 +1,102 @@
+package scan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// chunk is the size of the INSTREAM chunks, below the StreamMaxLength of
+// clamd
+
+const chunk = 64 << 10
+
+// Clamd scans through a clamav daemon with the INSTREAM command
+
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamd connects to the daemon at address, "tcp://host:3310" or
+// "unix:///run/clamav/clamd.ctl"
+func NewClamd(address string, timeout time.Duration) *Clamd {
+	var network = "tcp"
+	if strings.HasPrefix(address, "unix://") {
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+	}
+
+	return &Clamd{
+		network: network,
+		address: strings.TrimPrefix(address, "tcp://"),
+		timeout: timeout,
+	}
+}
+
+func (c *Clamd) Scan(body io.Reader) (Result, error) {
+	var conn, err = net.DialTimeout(c.network, c.address, c.timeout)
+	if err != nil {
+		log.Warn(err)
+		return Result{}, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, err
+	}
+
+	var buf = make([]byte, chunk)
+	var size = make([]byte, 4)
+	for {
+		var n, err = body.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(append(size, buf[:n]...)); err != nil {
+				return Result{}, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	// a zero length chunk ends the stream
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		log.Warn(err)
+		return Result{}, err
+	}
+
+	return parse(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parse reads the reply, "stream: OK", "stream: <signature> FOUND" or an
+// error like "INSTREAM size limit exceeded. ERROR"
+func parse(reply string) (Result, error) {
+	reply = strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case reply == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	}
+
+	return Result{}, errors.New("clamd: " + reply)
+}
@@ -0,0 +1,92 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClamd answers INSTREAM like clamd, finding the eicar file
+func fakeClamd(t *testing.T) string {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			var conn, err = listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				var command = make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+
+				var data []byte
+				var size = make([]byte, 4)
+				for {
+					if _, err := io.ReadFull(conn, size); err != nil {
+						return
+					}
+					var n = binary.BigEndian.Uint32(size)
+					if n == 0 {
+						break
+					}
+					var part = make([]byte, n)
+					if _, err := io.ReadFull(conn, part); err != nil {
+						return
+					}
+					data = append(data, part...)
+				}
+
+				if bytes.Contains(data, []byte(eicar)) {
+					conn.Write([]byte("stream: Win.Test.EICAR_HDB-1 FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+
+	return "tcp://" + listener.Addr().String()
+}
+
+func TestClamd(t *testing.T) {
+	var clamd = NewClamd(fakeClamd(t), time.Second)
+
+	t.Run("success clean", func(t *testing.T) {
+		var res, err = clamd.Scan(bytes.NewReader(make([]byte, 3*chunk+1)))
+		assert.Nil(t, err)
+		assert.False(t, res.Infected)
+	})
+
+	t.Run("success infected", func(t *testing.T) {
+		var res, err = clamd.Scan(strings.NewReader("head " + eicar + " tail"))
+		assert.Nil(t, err)
+		assert.Equal(t, Result{Infected: true, Signature: "Win.Test.EICAR_HDB-1"}, res)
+	})
+
+	t.Run("error reply", func(t *testing.T) {
+		var _, err = parse("INSTREAM size limit exceeded. ERROR")
+		assert.Equal(t, "clamd: INSTREAM size limit exceeded. ERROR", err.Error())
+	})
+
+	t.Run("no daemon", func(t *testing.T) {
+		var _, err = NewClamd("tcp://127.0.0.1:1", time.Second).Scan(strings.NewReader("file"))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,88 @@
+package scan
+
+import (
+	"be/api/storage"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// Guard scans the uploads before they are kept. An infected upload is moved
+// to quarantine, private files nobody gets a url of, and refused
+
+type Guard struct {
+	scanner    Scanner
+	quarantine storage.Private
+}
+
+func NewGuard(scanner Scanner, quarantine storage.Private) *Guard {
+	return &Guard{
+		scanner:    scanner,
+		quarantine: quarantine,
+	}
+}
+
+// Check returns "file is infected with <signature>" for malware, and the
+// error of the scanner when it failed, the upload is refused either way
+func (g *Guard) Check(name string, data []byte) error {
+	var res, err = g.scanner.Scan(bytes.NewReader(data))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if !res.Infected {
+		return nil
+	}
+
+	return g.refuse(name, res.Signature, bytes.NewReader(data))
+}
+
+// CheckReader is Check for an upload too large to be in memory, e.g. a
+// resumable one. It's copied to a temporary file while scanned, the copy is
+// what is quarantined
+func (g *Guard) CheckReader(name string, body io.Reader) error {
+	var tmp, err = os.CreateTemp("", "scan-*")
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	res, err := g.scanner.Scan(io.TeeReader(body, tmp))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if !res.Infected {
+		return nil
+	}
+
+	// the scanner may stop before the end
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		log.Error(err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		log.Error(err)
+	}
+
+	return g.refuse(name, res.Signature, tmp)
+}
+
+func (g *Guard) refuse(name, signature string, body io.Reader) error {
+	var key = "quarantine/" + time.Now().Format("2006-01-02") + "/" + shortuuid.New()
+	if _, err := g.quarantine.UploadPrivateReader(key, "application/octet-stream", body); err != nil {
+		log.Error(err)
+	}
+
+	log.Warn("upload ", name, " is infected with ", signature, ", quarantined as ", key)
+	return errors.New("file is infected with " + signature)
+}
@@ -0,0 +1,16 @@
+package scan
+
+import "io"
+
+// Scanner looks for malware in an upload, Clamd in production, Local as a
+// stand-in in development and None refusing the uploads without either
+
+type Scanner interface {
+	Scan(body io.Reader) (Result, error)
+}
+
+type Result struct {
+	Infected bool
+	// the name of the malware found
+	Signature string
+}
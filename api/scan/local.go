@@ -0,0 +1,32 @@
+package scan
+
+import (
+	"bytes"
+	"io"
+)
+
+// eicar is the test file every scanner reports
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Local only finds the eicar test file, it stands in for clamd in tests and
+// local runs. It's no protection against real malware
+
+type Local struct{}
+
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (l *Local) Scan(body io.Reader) (Result, error) {
+	var data, err = io.ReadAll(body)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if bytes.Contains(data, []byte(eicar)) {
+		return Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+
+	return Result{}, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultImage is the url of an empty image key
+
+const DefaultImage = "https://www.teralogistics.com/wp-content/uploads/2020/12/default.png"
+
+type PrivateFile struct {
+	Key         string
+	ContentType string
+	Size        int64
+	Checksum    string
+}
+
+// MinPart is the smallest part of a resumable upload but the last, the
+// smallest part of a multipart upload of s3
+
+const MinPart = 5 << 20
+
+type Part struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// Object is a stored file, on s3 the public and private files share the
+// bucket so Private is only known by the local driver
+
+type Object struct {
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+	Private    bool      `json:"private"`
+}
+
+type Config struct {
+	// "s3", "minio" or "local", s3 when empty
+	Driver string
+	Bucket string
+	Region string
+	Id     string
+	Secret string
+	// the api of minio or another s3 compatible server
+	Endpoint string
+	// the base of the public urls, by default the url of the bucket or for
+	// local the files route of the app
+	PublicUrl string
+	// the acl of the public files, public-read on s3 when empty and left out
+	// on minio, where a bucket policy makes them public
+	Acl string
+	// the directory of the local files
+	Dir string
+	// signs the private urls of the local files
+	SignKey string
+}
+
+// publicUrl is the url of the key under base. Full urls are kept, the images
+// saved before the keys were
+func publicUrl(base, key string) string {
+	switch {
+	case key == "":
+		return DefaultImage
+	case strings.HasPrefix(key, "http://"), strings.HasPrefix(key, "https://"):
+		return key
+	}
+
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
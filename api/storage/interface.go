@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"io"
+	"mime/multipart"
+	"os"
+	"time"
+)
+
+// Public keeps the files everyone may see, e.g. profile images. Only the key
+// is saved in the database, the url is built from it when the file is sent
+
+type Public interface {
+	Put(key, contentType string, body io.Reader) error
+	Delete(key string) error
+	Url(key string) string
+}
+
+// Private keeps the files only reached through a signed url that expires
+
+type Private interface {
+	UploadPrivateFile(key string, fileHeader multipart.FileHeader) (PrivateFile, error)
+	UploadPrivateReader(key, contentType string, body io.Reader) (PrivateFile, error)
+	SignedUrl(key string, expire time.Duration) (string, error)
+	DeletePrivateFile(key string) error
+}
+
+// Resumable puts a private file together from parts sent one by one, so a
+// large file never has to be in memory and a broken upload goes on from the
+// last part. The parts are kept until the upload is completed or aborted,
+// every part but the last has at least MinPart bytes. The completed file is
+// read back with OpenUpload, e.g. to scan it
+
+type Resumable interface {
+	StartUpload(key, contentType string) (string, error)
+	UploadPart(key, uploadId string, number int, body io.ReadSeeker) (Part, error)
+	CompleteUpload(key, uploadId string, parts []Part) error
+	AbortUpload(key, uploadId string) error
+	OpenUpload(key string) (io.ReadCloser, error)
+}
+
+// Lister goes through every stored file, public and private, to find the
+// ones nothing refers to anymore
+
+type Lister interface {
+	List(fn func(Object) error) error
+	Remove(object Object) error
+}
+
+// Storage is a driver, S3 for aws and minio or Local for a directory
+
+type Storage interface {
+	Public
+	Private
+	Resumable
+	Lister
+}
+
+// Server is a driver whose urls are served by the app itself, only Local
+
+type Server interface {
+	Open(key string) (*os.File, error)
+	OpenSigned(key, expires, signature string) (*os.File, error)
+}
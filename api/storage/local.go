@@ -0,0 +1,366 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Local keeps the files in a directory, the public ones under public/ and the
+// private ones under private/. The app serves them under /files. The parts of
+// the resumable uploads wait under uploads/
+
+type Local struct {
+	dir     string
+	url     string
+	signKey []byte
+}
+
+// NewLocal serves the files under url, the base of the files route. Without a
+// sign key a random one is made, the signed urls don't outlive a restart
+func NewLocal(dir, url, signKey string) (*Local, error) {
+	for _, sub := range []string{"public", "private", "uploads"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			log.Warn(err)
+			return nil, err
+		}
+	}
+
+	var key = []byte(signKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		log.Warn("no storage sign key, the signed urls of local files end with a restart")
+	}
+
+	return &Local{
+		dir:     dir,
+		url:     strings.TrimSuffix(url, "/"),
+		signKey: key,
+	}, nil
+}
+
+// path is where the key is kept, keys leaving the directory are refused
+func (l *Local) path(sub, key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || path.Clean("/" + key)[1:] != key {
+		return "", errors.New("invalid key")
+	}
+
+	return filepath.Join(l.dir, sub, filepath.FromSlash(key)), nil
+}
+
+// write saves the body through a temporary file, a failed write leaves the
+// old file
+func (l *Local) write(sub, key string, body io.Reader) (int64, string, error) {
+	var name, err = l.path(sub, key)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		log.Warn(err)
+		return 0, "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		log.Warn(err)
+		return 0, "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	var hash = sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), body)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		log.Warn(err)
+		return 0, "", err
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		log.Warn(err)
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (l *Local) remove(sub, key string) error {
+	var name, err = l.path(sub, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+func (l *Local) open(sub, key string) (*os.File, error) {
+	var name, err = l.path(sub, key)
+	if err != nil {
+		return nil, errors.New("file is not found")
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn(err)
+			return nil, err
+		}
+		return nil, errors.New("file is not found")
+	}
+
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		file.Close()
+		return nil, errors.New("file is not found")
+	}
+
+	return file, nil
+}
+
+func (l *Local) Put(key, contentType string, body io.Reader) error {
+	var _, _, err = l.write("public", key, body)
+	return err
+}
+
+func (l *Local) Delete(key string) error {
+	return l.remove("public", key)
+}
+
+func (l *Local) Url(key string) string {
+	return publicUrl(l.url, key)
+}
+
+func (l *Local) UploadPrivateFile(key string, fileHeader multipart.FileHeader) (PrivateFile, error) {
+	var src, err = fileHeader.Open()
+	if err != nil {
+		log.Warn(err)
+		return PrivateFile{}, err
+	}
+	defer src.Close()
+
+	var reader = bufio.NewReader(src)
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF {
+		log.Warn(err)
+		return PrivateFile{}, err
+	}
+
+	return l.UploadPrivateReader(key, http.DetectContentType(head), reader)
+}
+
+func (l *Local) UploadPrivateReader(key, contentType string, body io.Reader) (PrivateFile, error) {
+	var size, checksum, err = l.write("private", key, body)
+	if err != nil {
+		return PrivateFile{}, err
+	}
+
+	return PrivateFile{
+		Key:         key,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    checksum,
+	}, nil
+}
+
+// SignedUrl is the private route of the files with the expiry and an hmac of
+// the key and the expiry
+func (l *Local) SignedUrl(key string, expire time.Duration) (string, error) {
+	if _, err := l.path("private", key); err != nil {
+		return "", err
+	}
+
+	var expires = strconv.FormatInt(time.Now().Add(expire).Unix(), 10)
+
+	var query = url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", l.sign(key, expires))
+
+	return fmt.Sprintf("%v/private/%v?%v", l.url, (&url.URL{Path: key}).EscapedPath(), query.Encode()), nil
+}
+
+func (l *Local) DeletePrivateFile(key string) error {
+	return l.remove("private", key)
+}
+
+func (l *Local) Open(key string) (*os.File, error) {
+	return l.open("public", key)
+}
+
+// OpenSigned opens the private file of a signed url that has not expired
+func (l *Local) OpenSigned(key, expires, signature string) (*os.File, error) {
+	var unix, err = strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(l.sign(key, expires))) {
+		return nil, errors.New("invalid signature")
+	}
+
+	if time.Now().Unix() > unix {
+		return nil, errors.New("url is expired")
+	}
+
+	return l.open("private", key)
+}
+
+// List walks the public and private files, leaving out the temporary files of
+// writes going on
+
+func (l *Local) List(fn func(Object) error) error {
+	for _, sub := range []string{"public", "private"} {
+		var root = filepath.Join(l.dir, sub)
+
+		var err = filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(root, name)
+			if err != nil {
+				return err
+			}
+
+			return fn(Object{
+				Key:        filepath.ToSlash(rel),
+				Size:       info.Size(),
+				ModifiedAt: info.ModTime(),
+				Private:    sub == "private",
+			})
+		})
+		if err != nil {
+			log.Warn(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Local) Remove(object Object) error {
+	if object.Private {
+		return l.remove("private", object.Key)
+	}
+
+	return l.remove("public", object.Key)
+}
+
+// StartUpload makes the directory of the parts, the upload id is its name
+
+func (l *Local) StartUpload(key, contentType string) (string, error) {
+	if _, err := l.path("private", key); err != nil {
+		return "", err
+	}
+
+	var id = make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	var uploadId = hex.EncodeToString(id)
+
+	if err := os.Mkdir(filepath.Join(l.dir, "uploads", uploadId), 0o750); err != nil {
+		log.Warn(err)
+		return "", err
+	}
+
+	return uploadId, nil
+}
+
+func (l *Local) UploadPart(key, uploadId string, number int, body io.ReadSeeker) (Part, error) {
+	if number < 1 {
+		return Part{}, errors.New("invalid part number")
+	}
+
+	if dir, err := l.path("uploads", uploadId); err != nil || strings.Contains(uploadId, "/") {
+		return Part{}, errors.New("invalid key")
+	} else if _, err := os.Stat(dir); err != nil {
+		return Part{}, errors.New("upload is not found")
+	}
+
+	var _, checksum, err = l.write("uploads", fmt.Sprintf("%v/%v", uploadId, number), body)
+	if err != nil {
+		return Part{}, err
+	}
+
+	return Part{Number: number, ETag: checksum}, nil
+}
+
+// CompleteUpload joins the parts into the private file and removes them
+
+func (l *Local) CompleteUpload(key, uploadId string, parts []Part) error {
+	var files []io.Reader
+	for _, part := range parts {
+		var file, err = l.open("uploads", fmt.Sprintf("%v/%v", uploadId, part.Number))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		files = append(files, file)
+	}
+
+	if _, _, err := l.write("private", key, io.MultiReader(files...)); err != nil {
+		return err
+	}
+
+	return l.AbortUpload(key, uploadId)
+}
+
+// OpenUpload opens the completed private file
+
+func (l *Local) OpenUpload(key string) (io.ReadCloser, error) {
+	return l.open("private", key)
+}
+
+// AbortUpload removes the parts
+
+func (l *Local) AbortUpload(key, uploadId string) error {
+	var dir, err = l.path("uploads", uploadId)
+	if err != nil || strings.Contains(uploadId, "/") {
+		return errors.New("invalid key")
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+func (l *Local) sign(key, expires string) string {
+	var mac = hmac.New(sha256.New, l.signKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/labstack/gommon/log"
+)
+
+// S3 keeps the files in a bucket of aws s3 or of an s3 compatible server
+// like minio
+
+type S3 struct {
+	ses    *session.Session
+	bucket string
+	url    string
+	acl    string
+	// the server side encryption of the private files, minio only has it
+	// with a kms
+	encryption string
+}
+
+func NewS3(ses *session.Session, bucket, url, acl, encryption string) *S3 {
+	return &S3{
+		ses:        ses,
+		bucket:     bucket,
+		url:        url,
+		acl:        acl,
+		encryption: encryption,
+	}
+}
+
+func (s *S3) Put(key, contentType string, body io.Reader) error {
+	var input = &s3manager.UploadInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		Body:         body,
+		ContentType:  aws.String(contentType),
+		StorageClass: aws.String("STANDARD"),
+	}
+	if s.acl != "" {
+		input.ACL = aws.String(s.acl)
+	}
+
+	if _, err := s3manager.NewUploader(s.ses).Upload(input); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *S3) Delete(key string) error {
+	return s.DeletePrivateFile(key)
+}
+
+func (s *S3) Url(key string) string {
+	return publicUrl(s.url, key)
+}
+
+// UploadPrivateFile streams the file to the bucket without any public ACL and
+// returns the sha256 checksum computed while uploading
+func (s *S3) UploadPrivateFile(key string, fileHeader multipart.FileHeader) (PrivateFile, error) {
+	var src, err = fileHeader.Open()
+	if err != nil {
+		log.Warn(err)
+		return PrivateFile{}, err
+	}
+	defer src.Close()
+
+	var reader = bufio.NewReader(src)
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF {
+		log.Warn(err)
+		return PrivateFile{}, err
+	}
+
+	return s.UploadPrivateReader(key, http.DetectContentType(head), reader)
+}
+
+// UploadPrivateReader is UploadPrivateFile for content made by the app, e.g.
+// generated documents, with the content type known by the caller
+func (s *S3) UploadPrivateReader(key, contentType string, body io.Reader) (PrivateFile, error) {
+	var hash = sha256.New()
+	var counter = &countWriter{}
+
+	var input = &s3manager.UploadInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		ACL:          aws.String("private"),
+		Body:         io.TeeReader(body, io.MultiWriter(hash, counter)),
+		ContentType:  aws.String(contentType),
+		StorageClass: aws.String("STANDARD"),
+	}
+	if s.encryption != "" {
+		input.ServerSideEncryption = aws.String(s.encryption)
+	}
+
+	if _, err := s3manager.NewUploader(s.ses).Upload(input); err != nil {
+		log.Error(err)
+		return PrivateFile{}, err
+	}
+
+	return PrivateFile{
+		Key:         key,
+		ContentType: contentType,
+		Size:        counter.n,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func (s *S3) SignedUrl(key string, expire time.Duration) (string, error) {
+	var svc = s3.New(s.ses)
+
+	var req, _ = svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+
+	var url, err = req.Presign(expire)
+	if err != nil {
+		log.Warn(err)
+		return "", err
+	}
+
+	return url, nil
+}
+
+func (s *S3) DeletePrivateFile(key string) error {
+	var svc = s3.New(s.ses)
+
+	var _, err = svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+// StartUpload starts a multipart upload of a private file
+
+func (s *S3) StartUpload(key, contentType string) (string, error) {
+	var input = &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		ACL:          aws.String("private"),
+		ContentType:  aws.String(contentType),
+		StorageClass: aws.String("STANDARD"),
+	}
+	if s.encryption != "" {
+		input.ServerSideEncryption = aws.String(s.encryption)
+	}
+
+	var res, err = s3.New(s.ses).CreateMultipartUpload(input)
+	if err != nil {
+		log.Warn(err)
+		return "", err
+	}
+
+	return aws.StringValue(res.UploadId), nil
+}
+
+func (s *S3) UploadPart(key, uploadId string, number int, body io.ReadSeeker) (Part, error) {
+	var res, err = s3.New(s.ses).UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadId),
+		PartNumber: aws.Int64(int64(number)),
+		Body:       body,
+	})
+	if err != nil {
+		log.Warn(err)
+		return Part{}, err
+	}
+
+	return Part{Number: number, ETag: aws.StringValue(res.ETag)}, nil
+}
+
+func (s *S3) CompleteUpload(key, uploadId string, parts []Part) error {
+	var completed = make([]*s3.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int64(int64(part.Number)),
+		})
+	}
+
+	var _, err = s3.New(s.ses).CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadId),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *S3) AbortUpload(key, uploadId string) error {
+	var _, err = s3.New(s.ses).AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadId),
+	})
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+// OpenUpload streams the completed private file
+
+func (s *S3) OpenUpload(key string) (io.ReadCloser, error) {
+	var res, err = s3.New(s.ses).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		log.Warn(err)
+		return nil, err
+	}
+
+	return res.Body, nil
+}
+
+// List pages through the whole bucket
+
+func (s *S3) List(fn func(Object) error) error {
+	var failed error
+
+	var err = s3.New(s.ses).ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, object := range page.Contents {
+			if failed = fn(Object{
+				Key:        aws.StringValue(object.Key),
+				Size:       aws.Int64Value(object.Size),
+				ModifiedAt: aws.TimeValue(object.LastModified),
+			}); failed != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return failed
+}
+
+func (s *S3) Remove(object Object) error {
+	return s.DeletePrivateFile(object.Key)
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"be/api/aws"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a small s3 compatible server keeping the objects in memory, it
+// knows the calls of the driver: put, get, delete, list and the multipart
+// uploads
+
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	next    int
+	// the parts of every finished multipart upload
+	completed [][]int
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// the path is /bucket/key
+	var path = strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	var query = r.URL.Query()
+	var body, _ = io.ReadAll(r.Body)
+
+	if len(path) == 1 && r.Method == http.MethodGet && query.Get("list-type") == "2" {
+		var keys []string
+		for key := range f.objects {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprint(w, `<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>`)
+		for _, key := range keys {
+			fmt.Fprintf(w, `<Contents><Key>%v</Key><Size>%v</Size><LastModified>2022-03-01T10:00:00.000Z</LastModified></Contents>`, key, len(f.objects[key]))
+		}
+		fmt.Fprint(w, `</ListBucketResult>`)
+		return
+	}
+
+	var key = path[1]
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.next++
+		var id = fmt.Sprintf("upload-%v", f.next)
+		f.uploads[id] = map[int][]byte{}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%v</Key><UploadId>%v</UploadId></InitiateMultipartUploadResult>`, key, id)
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		var parts, ok = f.uploads[query.Get("uploadId")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchUpload</Code></Error>`)
+			return
+		}
+		var number, _ = strconv.Atoi(query.Get("partNumber"))
+		parts[number] = body
+		w.Header().Set("ETag", etag(body))
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		var parts, ok = f.uploads[query.Get("uploadId")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchUpload</Code></Error>`)
+			return
+		}
+
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		xml.Unmarshal(body, &complete)
+
+		var object []byte
+		var numbers []int
+		for _, part := range complete.Parts {
+			if etag(parts[part.PartNumber]) != part.ETag {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<Error><Code>InvalidPart</Code></Error>`)
+				return
+			}
+			object = append(object, parts[part.PartNumber]...)
+			numbers = append(numbers, part.PartNumber)
+		}
+
+		f.objects[key] = object
+		f.completed = append(f.completed, numbers)
+		delete(f.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Key>%v</Key></CompleteMultipartUploadResult>`, key)
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+		w.Header().Set("ETag", etag(body))
+
+	case r.Method == http.MethodGet:
+		var object, ok = f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Write(object)
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func etag(body []byte) string {
+	var sum = md5.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func newTestS3(t *testing.T) (*S3, *fakeS3) {
+	var fake = newFakeS3()
+	var server = httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return NewS3(aws.InitS3("us-east-1", "id", "secret", server.URL), "bucket", server.URL+"/bucket", "", ""), fake
+}
+
+func TestS3(t *testing.T) {
+	t.Run("success put and delete", func(t *testing.T) {
+		var s, fake = newTestS3(t)
+
+		assert.Nil(t, s.Put("photos/a/small.jpg", "image/jpeg", strings.NewReader("jpeg")))
+		assert.Equal(t, "jpeg", string(fake.objects["photos/a/small.jpg"]))
+		assert.True(t, strings.HasSuffix(s.Url("photos/a/small.jpg"), "/bucket/photos/a/small.jpg"))
+
+		assert.Nil(t, s.Delete("photos/a/small.jpg"))
+		assert.Equal(t, 0, len(fake.objects))
+	})
+
+	t.Run("success large private file is streamed in parts", func(t *testing.T) {
+		var s, fake = newTestS3(t)
+		var content = bytes.Repeat([]byte("0123456789abcdef"), (12<<20)/16)
+
+		// a reader without a length, like the body of a request
+		var res, err = s.UploadPrivateReader("attachments/scan", "application/pdf", io.MultiReader(bytes.NewReader(content)))
+		assert.Nil(t, err)
+
+		var sum = sha256.Sum256(content)
+		assert.Equal(t, hex.EncodeToString(sum[:]), res.Checksum)
+		assert.Equal(t, int64(len(content)), res.Size)
+		assert.Equal(t, [][]int{{1, 2, 3}}, fake.completed)
+		assert.True(t, bytes.Equal(content, fake.objects["attachments/scan"]))
+	})
+
+	t.Run("success resumable upload", func(t *testing.T) {
+		var s, fake = newTestS3(t)
+
+		var id, err = s.StartUpload("attachments/mri", "application/pdf")
+		assert.Nil(t, err)
+
+		var first = bytes.Repeat([]byte("a"), MinPart)
+		var parts []Part
+		for i, chunk := range [][]byte{first, []byte("end")} {
+			var part, err = s.UploadPart("attachments/mri", id, i+1, bytes.NewReader(chunk))
+			assert.Nil(t, err)
+			parts = append(parts, part)
+		}
+		assert.Equal(t, etag([]byte("end")), parts[1].ETag)
+
+		assert.Nil(t, s.CompleteUpload("attachments/mri", id, parts))
+		assert.Equal(t, len(first)+3, len(fake.objects["attachments/mri"]))
+		assert.Equal(t, 0, len(fake.uploads))
+	})
+
+	t.Run("success abort", func(t *testing.T) {
+		var s, fake = newTestS3(t)
+
+		var id, _ = s.StartUpload("attachments/mri", "application/pdf")
+		s.UploadPart("attachments/mri", id, 1, strings.NewReader("part"))
+
+		assert.Nil(t, s.AbortUpload("attachments/mri", id))
+		assert.Equal(t, 0, len(fake.uploads))
+		assert.NotNil(t, s.CompleteUpload("attachments/mri", id, nil))
+	})
+
+	t.Run("success list and remove", func(t *testing.T) {
+		var s, fake = newTestS3(t)
+		s.Put("photos/a/small.jpg", "image/jpeg", strings.NewReader("jpeg"))
+		s.UploadPrivateReader("attachments/visit/scan", "application/pdf", strings.NewReader("%PDF"))
+
+		var objects []Object
+		assert.Nil(t, s.List(func(object Object) error {
+			objects = append(objects, object)
+			return nil
+		}))
+		assert.Equal(t, 2, len(objects))
+		assert.Equal(t, "attachments/visit/scan", objects[0].Key)
+		assert.Equal(t, int64(4), objects[0].Size)
+		assert.Equal(t, 2022, objects[0].ModifiedAt.Year())
+
+		assert.Nil(t, s.Remove(objects[0]))
+		assert.Equal(t, 1, len(fake.objects))
+	})
+
+	t.Run("wrong part", func(t *testing.T) {
+		var s, _ = newTestS3(t)
+
+		var id, _ = s.StartUpload("attachments/mri", "application/pdf")
+		s.UploadPart("attachments/mri", id, 1, strings.NewReader("part"))
+
+		assert.NotNil(t, s.CompleteUpload("attachments/mri", id, []Part{{Number: 1, ETag: `"other"`}}))
+	})
+}
+
+func TestLocalResumable(t *testing.T) {
+	var l, err = NewLocal(t.TempDir(), "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var id, err = l.StartUpload("attachments/mri", "application/pdf")
+		assert.Nil(t, err)
+
+		var chunks = []string{"first ", "second ", "last"}
+		var parts []Part
+		for i, chunk := range chunks {
+			var part, err = l.UploadPart("attachments/mri", id, i+1, strings.NewReader(chunk))
+			assert.Nil(t, err)
+			parts = append(parts, part)
+		}
+
+		// sent again after a broken request, the part is replaced
+		var part, _ = l.UploadPart("attachments/mri", id, 2, strings.NewReader("again "))
+		parts[1] = part
+		sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+
+		assert.Nil(t, l.CompleteUpload("attachments/mri", id, parts))
+
+		var file, _ = l.open("private", "attachments/mri")
+		defer file.Close()
+		var content, _ = io.ReadAll(file)
+		assert.Equal(t, "first again last", string(content))
+
+		var _, err1 = l.UploadPart("attachments/mri", id, 1, strings.NewReader("late"))
+		assert.Equal(t, "upload is not found", err1.Error())
+	})
+
+	t.Run("invalid upload id", func(t *testing.T) {
+		var _, err = l.UploadPart("attachments/mri", "../private", 1, strings.NewReader("x"))
+		assert.Equal(t, "invalid key", err.Error())
+		assert.NotNil(t, l.AbortUpload("attachments/mri", "../private"))
+	})
+}
+
+func TestLocalList(t *testing.T) {
+	var l, err = NewLocal(t.TempDir(), "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Put("photos/a/small.jpg", "image/jpeg", strings.NewReader("jpeg"))
+	l.UploadPrivateReader("photos/a/small.jpg", "application/pdf", strings.NewReader("%PDF"))
+
+	var objects []Object
+	assert.Nil(t, l.List(func(object Object) error {
+		objects = append(objects, object)
+		return nil
+	}))
+	assert.Equal(t, 2, len(objects))
+	assert.False(t, objects[0].Private)
+	assert.True(t, objects[1].Private)
+	assert.Equal(t, "photos/a/small.jpg", objects[1].Key)
+
+	// the same key, only the private file is removed
+	assert.Nil(t, l.Remove(objects[1]))
+	var file, err1 = l.Open("photos/a/small.jpg")
+	assert.Nil(t, err1)
+	file.Close()
+	var _, err2 = l.open("private", "photos/a/small.jpg")
+	assert.NotNil(t, err2)
+}
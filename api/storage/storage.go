@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"be/api/aws"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// New makes the driver chosen by conf.Driver
+
+func New(conf Config) (Storage, error) {
+	switch conf.Driver {
+	case "", "s3":
+		if conf.Bucket == "" {
+			return nil, errors.New("no bucket for the s3 storage")
+		}
+
+		var url = conf.PublicUrl
+		if url == "" {
+			url = fmt.Sprintf("https://%v.s3.%v.amazonaws.com", conf.Bucket, conf.Region)
+		}
+
+		var acl = conf.Acl
+		if acl == "" {
+			acl = "public-read"
+		}
+
+		return NewS3(aws.InitS3(conf.Region, conf.Id, conf.Secret, ""), conf.Bucket, url, acl, "AES256"), nil
+
+	case "minio":
+		if conf.Bucket == "" || conf.Endpoint == "" {
+			return nil, errors.New("no bucket or endpoint for the minio storage")
+		}
+
+		var url = conf.PublicUrl
+		if url == "" {
+			url = strings.TrimSuffix(conf.Endpoint, "/") + "/" + conf.Bucket
+		}
+
+		var region = conf.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+
+		return NewS3(aws.InitS3(region, conf.Id, conf.Secret, conf.Endpoint), conf.Bucket, url, conf.Acl, ""), nil
+
+	case "local":
+		if conf.Dir == "" {
+			return nil, errors.New("no directory for the local storage")
+		}
+
+		return NewLocal(conf.Dir, conf.PublicUrl, conf.SignKey)
+	}
+
+	return nil, errors.New("unknown storage driver " + conf.Driver)
+}
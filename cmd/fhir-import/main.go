@@ -0,0 +1,62 @@
+// Command fhir-import imports a FHIR R4 bundle exported by another clinic
+// into the database of the app and prints the report as json.
+//
+//	go run ./cmd/fhir-import -doctor <doctor_uid> -file bundle.json
+package main
+
+import (
+	"be/configs"
+	fhirRepo "be/repository/fhir"
+	"be/utils"
+	"be/utils/fhir"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	var file = flag.String("file", "", "path of the bundle json, - reads stdin")
+	var doctor = flag.String("doctor", "", "doctor_uid the imported visits are assigned to")
+	flag.Parse()
+
+	if *file == "" || *doctor == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var body []byte
+	var err error
+	if *file == "-" {
+		body, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		body, err = ioutil.ReadFile(*file)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	bundle, err := fhir.ParseBundle(body)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var db = utils.InitDB(configs.GetConfig())
+
+	report, err := fhirRepo.New(db).Import(*doctor, bundle)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var encoder = json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if report.Rejected != 0 {
+		os.Exit(3)
+	}
+}
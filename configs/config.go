@@ -21,6 +21,15 @@ type AppConfig struct {
 	S3_REGION                   string
 	S3_ID                       string
 	S3_SECRET                   string
+	S3_BUCKET                   string
+	S3_ENDPOINT                 string
+	S3_ACL                      string
+	STORAGE_DRIVER              string
+	STORAGE_PUBLIC_URL          string
+	STORAGE_DIR                 string
+	STORAGE_SIGN_KEY            string
+	STORAGE_GC                  string
+	STORAGE_GC_GRACE            string
 	CLIENT_ID                   string
 	CLIENT_SECRET               string
 	PROJECT_ID                  string
@@ -30,6 +39,32 @@ type AppConfig struct {
 	Access_token                string
 	Token_type                  string
 	Refresh_token               string
+	LAB_API_KEY                 string
+	FHIR_API_KEY                string
+	BASE_URL                    string
+	HL7_API_KEY                 string
+	HL7_PORT                    int
+	HL7_BIND                    string
+	HL7_ALLOW                   string
+	SMTP_HOST                   string
+	SMTP_PORT                   string
+	SMTP_USERNAME               string
+	SMTP_PASSWORD               string
+	MAIL_FROM                   string
+	CLAMD_ADDRESS               string
+	SCAN                        string
+	CALENDAR_PROVIDER           string
+	GOOGLE_CALENDAR_ID          string
+	CALDAV_URL                  string
+	CALDAV_USERNAME             string
+	CALDAV_PASSWORD             string
+	CALENDAR_TOKEN_KEY          string
+	SMS_URL                     string
+	SMS_API_KEY                 string
+	SMS_FROM                    string
+	WHATSAPP_URL                string
+	WHATSAPP_TOKEN              string
+	WHATSAPP_LANGUAGE           string
 }
 
 var synchronizer = &sync.Mutex{}
@@ -66,6 +101,15 @@ func initConfig() *AppConfig {
 	exConfig.S3_REGION = os.Getenv("S3_REGION")
 	exConfig.S3_ID = os.Getenv("S3_ID")
 	exConfig.S3_SECRET = os.Getenv("S3_SECRET")
+	exConfig.S3_BUCKET = os.Getenv("S3_BUCKET")
+	exConfig.S3_ENDPOINT = os.Getenv("S3_ENDPOINT")
+	exConfig.S3_ACL = os.Getenv("S3_ACL")
+	exConfig.STORAGE_DRIVER = os.Getenv("STORAGE_DRIVER")
+	exConfig.STORAGE_PUBLIC_URL = os.Getenv("STORAGE_PUBLIC_URL")
+	exConfig.STORAGE_DIR = os.Getenv("STORAGE_DIR")
+	exConfig.STORAGE_SIGN_KEY = os.Getenv("STORAGE_SIGN_KEY")
+	exConfig.STORAGE_GC = os.Getenv("STORAGE_GC")
+	exConfig.STORAGE_GC_GRACE = os.Getenv("STORAGE_GC_GRACE")
 	exConfig.CLIENT_ID = os.Getenv("CLIENT_ID")
 	exConfig.CLIENT_SECRET = os.Getenv("CLIENT_SECRET")
 	exConfig.PROJECT_ID = os.Getenv("PROJECT_ID")
@@ -75,6 +119,48 @@ func initConfig() *AppConfig {
 	exConfig.Access_token = os.Getenv("access_token")
 	exConfig.Token_type = os.Getenv("token_type")
 	exConfig.Refresh_token = os.Getenv("refresh_token")
+	exConfig.LAB_API_KEY = os.Getenv("LAB_API_KEY")
+	exConfig.FHIR_API_KEY = os.Getenv("FHIR_API_KEY")
+	exConfig.BASE_URL = os.Getenv("BASE_URL")
+	exConfig.HL7_API_KEY = os.Getenv("HL7_API_KEY")
+	exConfig.HL7_BIND = os.Getenv("HL7_BIND")
+	exConfig.HL7_ALLOW = os.Getenv("HL7_ALLOW")
+	exConfig.SMTP_HOST = os.Getenv("SMTP_HOST")
+	exConfig.SMTP_PORT = os.Getenv("SMTP_PORT")
+	exConfig.SMTP_USERNAME = os.Getenv("SMTP_USERNAME")
+	exConfig.SMTP_PASSWORD = os.Getenv("SMTP_PASSWORD")
+	exConfig.MAIL_FROM = os.Getenv("MAIL_FROM")
+	exConfig.CLAMD_ADDRESS = os.Getenv("CLAMD_ADDRESS")
+	exConfig.SCAN = os.Getenv("SCAN")
+	exConfig.CALENDAR_PROVIDER = os.Getenv("CALENDAR_PROVIDER")
+	exConfig.GOOGLE_CALENDAR_ID = os.Getenv("GOOGLE_CALENDAR_ID")
+	exConfig.CALDAV_URL = os.Getenv("CALDAV_URL")
+	exConfig.CALDAV_USERNAME = os.Getenv("CALDAV_USERNAME")
+	exConfig.CALDAV_PASSWORD = os.Getenv("CALDAV_PASSWORD")
+	exConfig.CALENDAR_TOKEN_KEY = os.Getenv("CALENDAR_TOKEN_KEY")
+	exConfig.SMS_URL = os.Getenv("SMS_URL")
+	exConfig.SMS_API_KEY = os.Getenv("SMS_API_KEY")
+	exConfig.SMS_FROM = os.Getenv("SMS_FROM")
+	exConfig.WHATSAPP_URL = os.Getenv("WHATSAPP_URL")
+	exConfig.WHATSAPP_TOKEN = os.Getenv("WHATSAPP_TOKEN")
+	exConfig.WHATSAPP_LANGUAGE = os.Getenv("WHATSAPP_LANGUAGE")
+
+	// the mllp listener is off without a port, and only on this host without
+	// a bind address
+
+	if port := os.Getenv("HL7_PORT"); port != "" {
+		res, err = strconv.Atoi(port)
+
+		if err != nil {
+			log.Warn(err)
+		}
+
+		exConfig.HL7_PORT = res
+	}
+
+	if exConfig.HL7_BIND == "" {
+		exConfig.HL7_BIND = "127.0.0.1"
+	}
 
 	return &exConfig
 }
@@ -113,6 +199,15 @@ func defaultConfig() *AppConfig {
 	defaultConfig.S3_REGION = os.Getenv("S3_REGION")
 	defaultConfig.S3_ID = os.Getenv("S3_ID")
 	defaultConfig.S3_SECRET = os.Getenv("S3_SECRET")
+	defaultConfig.S3_BUCKET = os.Getenv("S3_BUCKET")
+	defaultConfig.S3_ENDPOINT = os.Getenv("S3_ENDPOINT")
+	defaultConfig.S3_ACL = os.Getenv("S3_ACL")
+	defaultConfig.STORAGE_DRIVER = os.Getenv("STORAGE_DRIVER")
+	defaultConfig.STORAGE_PUBLIC_URL = os.Getenv("STORAGE_PUBLIC_URL")
+	defaultConfig.STORAGE_DIR = os.Getenv("STORAGE_DIR")
+	defaultConfig.STORAGE_SIGN_KEY = os.Getenv("STORAGE_SIGN_KEY")
+	defaultConfig.STORAGE_GC = os.Getenv("STORAGE_GC")
+	defaultConfig.STORAGE_GC_GRACE = os.Getenv("STORAGE_GC_GRACE")
 	defaultConfig.CLIENT_ID = os.Getenv("CLIENT_ID")
 	defaultConfig.CLIENT_SECRET = os.Getenv("CLIENT_SECRET")
 	defaultConfig.PROJECT_ID = os.Getenv("PROJECT_ID")
@@ -122,6 +217,48 @@ func defaultConfig() *AppConfig {
 	defaultConfig.Access_token = os.Getenv("access_token")
 	defaultConfig.Token_type = os.Getenv("token_type")
 	defaultConfig.Refresh_token = os.Getenv("refresh_token")
+	defaultConfig.LAB_API_KEY = os.Getenv("LAB_API_KEY")
+	defaultConfig.FHIR_API_KEY = os.Getenv("FHIR_API_KEY")
+	defaultConfig.BASE_URL = os.Getenv("BASE_URL")
+	defaultConfig.HL7_API_KEY = os.Getenv("HL7_API_KEY")
+	defaultConfig.HL7_BIND = os.Getenv("HL7_BIND")
+	defaultConfig.HL7_ALLOW = os.Getenv("HL7_ALLOW")
+	defaultConfig.SMTP_HOST = os.Getenv("SMTP_HOST")
+	defaultConfig.SMTP_PORT = os.Getenv("SMTP_PORT")
+	defaultConfig.SMTP_USERNAME = os.Getenv("SMTP_USERNAME")
+	defaultConfig.SMTP_PASSWORD = os.Getenv("SMTP_PASSWORD")
+	defaultConfig.MAIL_FROM = os.Getenv("MAIL_FROM")
+	defaultConfig.CLAMD_ADDRESS = os.Getenv("CLAMD_ADDRESS")
+	defaultConfig.SCAN = os.Getenv("SCAN")
+	defaultConfig.CALENDAR_PROVIDER = os.Getenv("CALENDAR_PROVIDER")
+	defaultConfig.GOOGLE_CALENDAR_ID = os.Getenv("GOOGLE_CALENDAR_ID")
+	defaultConfig.CALDAV_URL = os.Getenv("CALDAV_URL")
+	defaultConfig.CALDAV_USERNAME = os.Getenv("CALDAV_USERNAME")
+	defaultConfig.CALDAV_PASSWORD = os.Getenv("CALDAV_PASSWORD")
+	defaultConfig.CALENDAR_TOKEN_KEY = os.Getenv("CALENDAR_TOKEN_KEY")
+	defaultConfig.SMS_URL = os.Getenv("SMS_URL")
+	defaultConfig.SMS_API_KEY = os.Getenv("SMS_API_KEY")
+	defaultConfig.SMS_FROM = os.Getenv("SMS_FROM")
+	defaultConfig.WHATSAPP_URL = os.Getenv("WHATSAPP_URL")
+	defaultConfig.WHATSAPP_TOKEN = os.Getenv("WHATSAPP_TOKEN")
+	defaultConfig.WHATSAPP_LANGUAGE = os.Getenv("WHATSAPP_LANGUAGE")
+
+	// the mllp listener is off without a port, and only on this host without
+	// a bind address
+
+	if port := os.Getenv("HL7_PORT"); port != "" {
+		res, err = strconv.Atoi(port)
+
+		if err != nil {
+			log.Warn(err)
+		}
+
+		defaultConfig.HL7_PORT = res
+	}
+
+	if defaultConfig.HL7_BIND == "" {
+		defaultConfig.HL7_BIND = "127.0.0.1"
+	}
 
 	return &defaultConfig
 }
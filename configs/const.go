@@ -2,6 +2,7 @@ package configs
 
 const JWT_SECRET = ""
 const OauthGoogleUrlAPI = "https://www.googleapis.com/oauth2/v3/userinfo?access_token="
+const OauthGoogleRevokeUrl = "https://oauth2.googleapis.com/revoke"
 
 const (
 	CredentialPath = "./credential/credential.json"
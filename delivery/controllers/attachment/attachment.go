@@ -0,0 +1,242 @@
+package attachment
+
+import (
+	"be/api/scan"
+	"be/api/storage"
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/attachment"
+	"be/delivery/middlewares"
+	"be/repository/attachment"
+	"be/utils/list"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// signed urls are only valid for a short time so a leaked link expires quickly
+const urlExpire = 15 * time.Minute
+
+type Controller struct {
+	r     attachment.Attachment
+	store storage.Private
+	guard *scan.Guard
+	l     logic.Attachment
+}
+
+func New(r attachment.Attachment, store storage.Private, guard *scan.Guard, l logic.Attachment) *Controller {
+	return &Controller{
+		r:     r,
+		store: store,
+		guard: guard,
+		l:     l,
+	}
+}
+
+func (cont *Controller) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can add attachment", nil))
+		}
+
+		if err := cont.r.CheckDoctor(visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "file is required", nil))
+		}
+
+		if err := cont.l.ValidationFile(*file); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// malware, an infected file is quarantined and refused
+
+		if err := cont.scan(*file); err != nil {
+			return scanError(c, err)
+		}
+
+		// aws s3
+
+		var key = "attachments/" + visit_uid + "/" + shortuuid.New()
+
+		resS3, err := cont.store.UploadPrivateFile(key, *file)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+
+		// database
+
+		var entity = req.ToAttachment()
+		entity.Visit_uid = visit_uid
+		entity.Uploader_uid = uid
+		entity.FileName = file.Filename
+		entity.ContentType = resS3.ContentType
+		entity.Size = resS3.Size
+		entity.Checksum = resS3.Checksum
+		entity.Object_key = resS3.Key
+
+		res, err := cont.r.Create(*entity)
+		if err != nil {
+			log.Warn(err)
+			if err := cont.store.DeletePrivateFile(key); err != nil {
+				log.Warn(err)
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add attachment", map[string]interface{}{
+			"attachment_uid": res.Attachment_uid,
+			"checksum":       res.Checksum,
+		}))
+	}
+}
+
+func (cont *Controller) scan(file multipart.FileHeader) error {
+	var src, err = file.Open()
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+	defer src.Close()
+
+	return cont.guard.CheckReader(file.Filename, src)
+}
+
+// scanError answers malware with 400 and a failed scan with 500
+
+func scanError(c echo.Context, err error) error {
+	log.Warn(err)
+	if strings.HasPrefix(err.Error(), "file is infected with ") {
+		return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+	}
+	return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+}
+
+func (cont *Controller) GetAttachments() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+		var q, err = list.Parse(attachment.List, c.QueryParams())
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		if err := cont.r.CheckAccess(visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+		}
+
+		// database
+
+		res, page, err := cont.r.GetAttachments(visit_uid, q)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get attachments", res, page))
+	}
+}
+
+func (cont *Controller) Download() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var attachment_uid = c.Param("attachment_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		if err := cont.r.CheckAccess(visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+		}
+
+		// database
+
+		res, err := cont.r.GetAttachment(visit_uid, attachment_uid)
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("attachment is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		// aws s3
+
+		url, err := cont.store.SignedUrl(res.Object_key, urlExpire)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get attachment link", map[string]interface{}{
+			"url":       url,
+			"fileName":  res.FileName,
+			"checksum":  res.Checksum,
+			"expiredAt": time.Now().Add(urlExpire).Format(time.RFC3339),
+		}))
+	}
+}
+
+func (cont *Controller) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var attachment_uid = c.Param("attachment_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		if err := cont.r.CheckAccess(visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+		}
+
+		// database
+
+		res, err := cont.r.Delete(visit_uid, attachment_uid, uid)
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("attachment is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		// aws s3
+
+		if err := cont.store.DeletePrivateFile(res.Object_key); err != nil {
+			log.Warn(err)
+		}
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success delete attachment", res.DeletedAt))
+	}
+}
@@ -0,0 +1,334 @@
+package attachment
+
+import (
+	"be/api/scan"
+	"be/api/storage"
+	"be/configs"
+	logic "be/delivery/logic/attachment"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/attachment"
+	"be/utils/list"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct {
+	deleter string
+}
+
+func (m *mockSuccess) CheckAccess(visit_uid, user_uid string) error {
+	return nil
+}
+
+func (m *mockSuccess) CheckDoctor(visit_uid, doctor_uid string) error {
+	return nil
+}
+
+func (m *mockSuccess) Create(req entities.Attachment) (entities.Attachment, error) {
+	return entities.Attachment{Attachment_uid: "attachment"}, nil
+}
+
+func (m *mockSuccess) Delete(visit_uid, attachment_uid, user_uid string) (entities.Attachment, error) {
+	m.deleter = user_uid
+	return entities.Attachment{Object_key: "key"}, nil
+}
+
+func (m *mockSuccess) GetAttachment(visit_uid, attachment_uid string) (entities.Attachment, error) {
+	return entities.Attachment{Object_key: "key"}, nil
+}
+
+func (m *mockSuccess) GetAttachments(visit_uid string, q list.Query) (attachment.Attachments, list.Page, error) {
+	return attachment.Attachments{}, list.Page{}, nil
+}
+
+type mockNoAccess struct{}
+
+func (m *mockNoAccess) CheckAccess(visit_uid, user_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockNoAccess) CheckDoctor(visit_uid, doctor_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockNoAccess) Create(req entities.Attachment) (entities.Attachment, error) {
+	return entities.Attachment{}, nil
+}
+
+func (m *mockNoAccess) Delete(visit_uid, attachment_uid, user_uid string) (entities.Attachment, error) {
+	return entities.Attachment{}, nil
+}
+
+func (m *mockNoAccess) GetAttachment(visit_uid, attachment_uid string) (entities.Attachment, error) {
+	return entities.Attachment{}, nil
+}
+
+func (m *mockNoAccess) GetAttachments(visit_uid string, q list.Query) (attachment.Attachments, list.Page, error) {
+	return attachment.Attachments{}, list.Page{}, nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) CheckAccess(visit_uid, user_uid string) error {
+	return nil
+}
+
+func (m *mockFail) CheckDoctor(visit_uid, doctor_uid string) error {
+	return nil
+}
+
+func (m *mockFail) Create(req entities.Attachment) (entities.Attachment, error) {
+	return entities.Attachment{}, errors.New("")
+}
+
+func (m *mockFail) Delete(visit_uid, attachment_uid, user_uid string) (entities.Attachment, error) {
+	return entities.Attachment{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetAttachment(visit_uid, attachment_uid string) (entities.Attachment, error) {
+	return entities.Attachment{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetAttachments(visit_uid string, q list.Query) (attachment.Attachments, list.Page, error) {
+	return attachment.Attachments{}, list.Page{}, errors.New("")
+}
+
+type mockS3 struct {
+	deleted     []string
+	quarantined []string
+}
+
+func (m *mockS3) UploadPrivateFile(key string, fileHeader multipart.FileHeader) (storage.PrivateFile, error) {
+	return storage.PrivateFile{Key: key, Checksum: "checksum"}, nil
+}
+
+func (m *mockS3) UploadPrivateReader(key, contentType string, body io.Reader) (storage.PrivateFile, error) {
+	m.quarantined = append(m.quarantined, key)
+	return storage.PrivateFile{Key: key, ContentType: contentType, Checksum: "checksum"}, nil
+}
+
+func (m *mockS3) SignedUrl(key string, expire time.Duration) (string, error) {
+	return "https://bucket/" + key + "?X-Amz-Signature=abc", nil
+}
+
+func (m *mockS3) DeletePrivateFile(key string) error {
+	m.deleted = append(m.deleted, key)
+	return nil
+}
+
+type failS3 struct{}
+
+func (m *failS3) UploadPrivateFile(key string, fileHeader multipart.FileHeader) (storage.PrivateFile, error) {
+	return storage.PrivateFile{}, errors.New("")
+}
+
+func (m *failS3) UploadPrivateReader(key, contentType string, body io.Reader) (storage.PrivateFile, error) {
+	return storage.PrivateFile{}, errors.New("")
+}
+
+func (m *failS3) SignedUrl(key string, expire time.Duration) (string, error) {
+	return "", errors.New("")
+}
+
+func (m *failS3) DeletePrivateFile(key string) error {
+	return errors.New("")
+}
+
+func request(t *testing.T, method string, body io.Reader, contentType string, handler echo.HandlerFunc) ResponseFormat {
+	return requestTarget(t, method, "/", body, contentType, handler)
+}
+
+func requestTarget(t *testing.T, method, target string, body io.Reader, contentType string, handler echo.HandlerFunc) ResponseFormat {
+	return requestAs(t, "doctor", method, target, body, contentType, handler)
+}
+
+func requestAs(t *testing.T, kind, method, target string, body io.Reader, contentType string, handler echo.HandlerFunc) ResponseFormat {
+	var token, err = middlewares.GenerateToken(kind, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, target, body)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetPath("/visit/:visit_uid/attachments/:attachment_uid")
+	context.SetParamNames("visit_uid", "attachment_uid")
+	context.SetParamValues("visit", "attachment")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func form(t *testing.T, kind string, content []byte) (*bytes.Buffer, string) {
+	var body = new(bytes.Buffer)
+	var writer = multipart.NewWriter(body)
+	writer.WriteField("type", kind)
+	writer.WriteField("description", "blood test")
+	if content != nil {
+		var part, err = writer.CreateFormFile("file", "lab.pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+var pdf = []byte("%PDF-1.4\n%test")
+
+var guard = scan.NewGuard(scan.NewLocal(), &mockS3{})
+
+func TestCreate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var body, contentType = form(t, "lab", pdf)
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 201, response.Code)
+	})
+
+	t.Run("no access", func(t *testing.T) {
+		var body, contentType = form(t, "lab", pdf)
+		var controller = New(&mockNoAccess{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 404, response.Code)
+		assert.Equal(t, "visit is not found", response.Message)
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var body, contentType = form(t, "lab", pdf)
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = requestAs(t, "patient", http.MethodPost, "/", body, contentType, controller.Create())
+		assert.Equal(t, 401, response.Code)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		var body, contentType = form(t, "selfie", pdf)
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var body, contentType = form(t, "lab", nil)
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("file is infected", func(t *testing.T) {
+		var body, contentType = form(t, "lab", append(pdf, `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`...))
+		var quarantine = &mockS3{}
+		var controller = New(&mockSuccess{}, &failS3{}, scan.NewGuard(scan.NewLocal(), quarantine), logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "file is infected with Eicar-Test-Signature", response.Message)
+		assert.Equal(t, 1, len(quarantine.quarantined))
+	})
+
+	t.Run("file not allowed", func(t *testing.T) {
+		var body, contentType = form(t, "lab", []byte("MZ\x90\x00binary"))
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("error s3", func(t *testing.T) {
+		var body, contentType = form(t, "lab", pdf)
+		var controller = New(&mockSuccess{}, &failS3{}, guard, logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 500, response.Code)
+	})
+
+	t.Run("error database remove object", func(t *testing.T) {
+		var body, contentType = form(t, "lab", pdf)
+		var storage = &mockS3{}
+		var controller = New(&mockFail{}, storage, guard, logic.New())
+		var response = request(t, http.MethodPost, body, contentType, controller.Create())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, 1, len(storage.deleted))
+	})
+}
+
+func TestGetAttachments(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodGet, nil, echo.MIMEApplicationJSON, controller.GetAttachments())
+		assert.Equal(t, 200, response.Code)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = requestTarget(t, http.MethodGet, "/?type=selfie", nil, echo.MIMEApplicationJSON, controller.GetAttachments())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodGet, nil, echo.MIMEApplicationJSON, controller.GetAttachments())
+		assert.Equal(t, 500, response.Code)
+	})
+}
+
+func TestDownload(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodGet, nil, echo.MIMEApplicationJSON, controller.Download())
+		assert.Equal(t, 200, response.Code)
+		assert.Contains(t, response.Data.(map[string]interface{})["url"], "X-Amz-Signature")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodGet, nil, echo.MIMEApplicationJSON, controller.Download())
+		assert.Equal(t, "attachment is not found", response.Message)
+	})
+
+	t.Run("error sign", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &failS3{}, guard, logic.New())
+		var response = request(t, http.MethodGet, nil, echo.MIMEApplicationJSON, controller.Download())
+		assert.Equal(t, 500, response.Code)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var storage = &mockS3{}
+		var r = &mockSuccess{}
+		var controller = New(r, storage, guard, logic.New())
+		var response = request(t, http.MethodDelete, nil, echo.MIMEApplicationJSON, controller.Delete())
+		assert.Equal(t, 202, response.Code)
+		assert.Equal(t, []string{"key"}, storage.deleted)
+		assert.Equal(t, "doctor", r.deleter)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockS3{}, guard, logic.New())
+		var response = request(t, http.MethodDelete, nil, echo.MIMEApplicationJSON, controller.Delete())
+		assert.Equal(t, "attachment is not found", response.Message)
+	})
+}
@@ -0,0 +1,7 @@
+package attachment
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
@@ -0,0 +1,214 @@
+package bulk
+
+import (
+	"be/delivery/controllers/templates"
+	job "be/delivery/jobs/bulk"
+	logic "be/delivery/logic/bulk"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/bulk"
+	"be/utils/sheet"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const maxFileSize = 5 << 20
+
+type Controller struct {
+	r bulk.Bulk
+	q job.Queue
+	l logic.Bulk
+}
+
+func New(r bulk.Bulk, q job.Queue, l logic.Bulk) *Controller {
+	return &Controller{
+		r: r,
+		q: q,
+		l: l,
+	}
+}
+
+// Preview validates the file without registering anyone, kind is patient or doctor
+
+func (cont *Controller) Preview(kind string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, role = middlewares.ExtractTokenUid(c)
+
+		if role != "doctor" && role != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only clinic staff can import", nil))
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "file is required", nil))
+		}
+
+		if file.Size > maxFileSize {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "file is too large", nil))
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid file", nil))
+		}
+		defer src.Close()
+
+		records, err := sheet.Read(file.Filename, src)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		rows, err := cont.l.Parse(kind, records)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Create(entities.Bulk{Kind: kind, Requester_uid: uid, FileName: file.Filename, Rows: ToBulkRows(rows)})
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		var errs = []bulk.RowResp{}
+		for _, row := range res.Rows {
+			if row.Status == "invalid" {
+				errs = append(errs, bulk.RowResp{Row: row.Line, Status: row.Status, Error: row.Error})
+			}
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success preview import", bulk.BulkResp{
+			Bulk_uid: res.Bulk_uid,
+			Kind:     res.Kind,
+			FileName: res.FileName,
+			Status:   res.Status,
+			Total:    res.Total,
+			Valid:    res.Valid,
+			Invalid:  res.Invalid,
+			Errors:   errs,
+		}))
+	}
+}
+
+func (cont *Controller) GetBulk() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var bulk_uid = c.Param("bulk_uid")
+		var uid, role = middlewares.ExtractTokenUid(c)
+
+		if role != "doctor" && role != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only clinic staff can import", nil))
+		}
+
+		// database
+
+		res, err := cont.r.GetBulk(bulk_uid)
+
+		if err == nil && res.Requester_uid != uid {
+			err = errors.New("record not found")
+		}
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("import is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get import", res))
+	}
+}
+
+// Commit leaves the valid rows to the job, the progress is shown by GetBulk
+
+func (cont *Controller) Commit() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var bulk_uid = c.Param("bulk_uid")
+		var uid, role = middlewares.ExtractTokenUid(c)
+
+		if role != "doctor" && role != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only clinic staff can import", nil))
+		}
+
+		// database
+
+		res, err := cont.r.Claim(bulk_uid, uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("import is not found")
+			case "import is already committing", "import is already done":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		cont.q.Enqueue(bulk_uid)
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "import is being committed", map[string]interface{}{
+			"bulk_uid": bulk_uid,
+			"rows":     res.Valid,
+		}))
+	}
+}
+
+func (cont *Controller) Report() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var bulk_uid = c.Param("bulk_uid")
+		var uid, role = middlewares.ExtractTokenUid(c)
+
+		if role != "doctor" && role != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only clinic staff can import", nil))
+		}
+
+		// database
+
+		res, err := cont.r.GetBulk(bulk_uid)
+
+		if err == nil && res.Requester_uid != uid {
+			err = errors.New("record not found")
+		}
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("import is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		rows, err := cont.r.GetRows(bulk_uid)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		report, err := sheet.Csv(ToReport(res.Kind, rows))
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "import-"+bulk_uid+".csv"))
+		return c.Blob(http.StatusOK, "text/csv", report)
+	}
+}
@@ -0,0 +1,243 @@
+package bulk
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/bulk"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/bulk"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct{}
+
+func (m *mockSuccess) Create(req entities.Bulk) (entities.Bulk, error) {
+	req.Bulk_uid = "bulk"
+	req.Status = "preview"
+	for _, row := range req.Rows {
+		if row.Status == "valid" {
+			req.Valid++
+		} else {
+			req.Invalid++
+		}
+	}
+	req.Total = len(req.Rows)
+	return req, nil
+}
+
+func (m *mockSuccess) GetBulk(bulk_uid string) (bulk.BulkResp, error) {
+	return bulk.BulkResp{Bulk_uid: bulk_uid, Requester_uid: "admin", Kind: "patient", Status: "done"}, nil
+}
+
+func (m *mockSuccess) GetRows(bulk_uid string) ([]entities.BulkRow, error) {
+	return []entities.BulkRow{
+		{Line: 2, Status: "valid", Data: `{"nik":"1234567890123456","name":"siti rahayu","gender":"wanita","address":"jl. melati 5 jakarta","placeBirth":"bandung","dob":"05-05-1990","job":"guru","status":"kawin","religion":"islam","password":"secret"}`},
+		{Line: 3, Status: "invalid", Error: "invalid length nik", Data: `{"nik":"12345"}`},
+	}, nil
+}
+
+func (m *mockSuccess) GetCommitting() ([]entities.Bulk, error) {
+	return nil, nil
+}
+
+func (m *mockSuccess) Claim(bulk_uid, requester_uid string) (entities.Bulk, error) {
+	return entities.Bulk{Bulk_uid: bulk_uid, Kind: "patient", Status: "committing", Valid: 1}, nil
+}
+
+func (m *mockSuccess) Commit(bulk_uid string, records []bulk.Record) error {
+	return nil
+}
+
+func (m *mockSuccess) Finish(bulk_uid string) (entities.Bulk, error) {
+	return entities.Bulk{}, nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) Create(req entities.Bulk) (entities.Bulk, error) {
+	return entities.Bulk{}, errors.New("")
+}
+
+func (m *mockFail) GetBulk(bulk_uid string) (bulk.BulkResp, error) {
+	return bulk.BulkResp{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetRows(bulk_uid string) ([]entities.BulkRow, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) GetCommitting() ([]entities.Bulk, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) Claim(bulk_uid, requester_uid string) (entities.Bulk, error) {
+	return entities.Bulk{}, errors.New("import is already done")
+}
+
+func (m *mockFail) Commit(bulk_uid string, records []bulk.Record) error {
+	return errors.New("")
+}
+
+func (m *mockFail) Finish(bulk_uid string) (entities.Bulk, error) {
+	return entities.Bulk{}, errors.New("")
+}
+
+type mockQueue struct {
+	queued []string
+}
+
+func (m *mockQueue) Enqueue(bulk_uid string) {
+	m.queued = append(m.queued, bulk_uid)
+}
+
+func request(t *testing.T, method string, body io.Reader, contentType, uid, kind string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/", body)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("bulk_uid")
+	context.SetParamValues("bulk")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func form(t *testing.T, name, content string) (*bytes.Buffer, string) {
+	var body = new(bytes.Buffer)
+	var writer = multipart.NewWriter(body)
+	var part, err = writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+var patients = "nik,name,gender,address,placeBirth,dob,job,status,religion\n" +
+	"1234567890123456,siti rahayu,wanita,jl. melati 5 jakarta,bandung,05-05-1990,guru,kawin,islam\n" +
+	"12345,andi wijaya,pria,jl. melati 5 jakarta,bandung,05-05-1990,guru,kawin,islam\n"
+
+func TestPreview(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var body, contentType = form(t, "patients.csv", patients)
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		var res = response(request(t, http.MethodPost, body, contentType, "admin", "admin", controller.Preview("patient")))
+		assert.Equal(t, 201, res.Code)
+
+		var data = res.Data.(map[string]interface{})
+		assert.Equal(t, float64(1), data["valid"])
+		assert.Equal(t, float64(3), data["errors"].([]interface{})[0].(map[string]interface{})["row"])
+	})
+
+	t.Run("patient", func(t *testing.T) {
+		var body, contentType = form(t, "patients.csv", patients)
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		assert.Equal(t, 401, request(t, http.MethodPost, body, contentType, "patient", "patient", controller.Preview("patient")).Code)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		var body, contentType = form(t, "patients.txt", patients)
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		assert.Equal(t, "file must be csv or xlsx", response(request(t, http.MethodPost, body, contentType, "admin", "admin", controller.Preview("patient"))).Message)
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		var body, contentType = form(t, "patients.csv", "nik,name\n1,2\n")
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		assert.Equal(t, "column gender is missing", response(request(t, http.MethodPost, body, contentType, "admin", "admin", controller.Preview("patient"))).Message)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var body, contentType = form(t, "patients.csv", patients)
+		var controller = New(&mockFail{}, &mockQueue{}, logic.New())
+		assert.Equal(t, 500, request(t, http.MethodPost, body, contentType, "admin", "admin", controller.Preview("patient")).Code)
+	})
+}
+
+func TestCommit(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var q = &mockQueue{}
+		var controller = New(&mockSuccess{}, q, logic.New())
+		assert.Equal(t, 202, request(t, http.MethodPost, nil, "", "admin", "admin", controller.Commit()).Code)
+		assert.Equal(t, []string{"bulk"}, q.queued)
+	})
+
+	t.Run("patient", func(t *testing.T) {
+		var q = &mockQueue{}
+		var controller = New(&mockSuccess{}, q, logic.New())
+		assert.Equal(t, 401, request(t, http.MethodPost, nil, "", "admin", "patient", controller.Commit()).Code)
+		assert.Equal(t, 0, len(q.queued))
+	})
+
+	t.Run("already done", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockQueue{}, logic.New())
+		assert.Equal(t, "import is already done", response(request(t, http.MethodPost, nil, "", "admin", "admin", controller.Commit())).Message)
+	})
+}
+
+func TestGetBulk(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		assert.Equal(t, 200, request(t, http.MethodGet, nil, "", "admin", "admin", controller.GetBulk()).Code)
+	})
+
+	t.Run("other requester", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		assert.Equal(t, "import is not found", response(request(t, http.MethodGet, nil, "", "other", "admin", controller.GetBulk())).Message)
+	})
+
+	t.Run("patient", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		assert.Equal(t, 401, request(t, http.MethodGet, nil, "", "admin", "patient", controller.GetBulk()).Code)
+		assert.Equal(t, 401, request(t, http.MethodGet, nil, "", "admin", "patient", controller.Report()).Code)
+	})
+}
+
+func TestReport(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockQueue{}, logic.New())
+		var res = request(t, http.MethodGet, nil, "", "admin", "admin", controller.Report())
+		assert.Equal(t, 200, res.Code)
+		assert.True(t, strings.HasPrefix(res.Body.String(), "row,status,error,uid,userName,email,nik"))
+		assert.Contains(t, res.Body.String(), "3,invalid,invalid length nik")
+		assert.NotContains(t, res.Body.String(), "secret")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockQueue{}, logic.New())
+		assert.Equal(t, "import is not found", response(request(t, http.MethodGet, nil, "", "admin", "admin", controller.Report())).Message)
+	})
+}
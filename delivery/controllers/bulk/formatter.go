@@ -0,0 +1,58 @@
+package bulk
+
+import (
+	logic "be/delivery/logic/bulk"
+	"be/entities"
+	"encoding/json"
+	"strconv"
+)
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+func ToBulkRows(rows []logic.Row) []entities.BulkRow {
+	var res []entities.BulkRow
+
+	for _, row := range rows {
+		var data, _ = json.Marshal(row.Data)
+		var status = "valid"
+		if row.Error != "" {
+			status = "invalid"
+		}
+		res = append(res, entities.BulkRow{Line: row.Row, Data: string(data), Status: status, Error: row.Error})
+	}
+
+	return res
+}
+
+// ToReport is the error report, every row with its status next to the values
+// of the file. Passwords are never in the report
+
+func ToReport(kind string, rows []entities.BulkRow) [][]string {
+	var header = []string{"row", "status", "error", "uid"}
+	var columns []string
+
+	for _, column := range logic.Columns[kind] {
+		if column != "password" {
+			columns = append(columns, column)
+		}
+	}
+
+	var res = [][]string{append(header, columns...)}
+
+	for _, row := range rows {
+		var data map[string]string
+		json.Unmarshal([]byte(row.Data), &data)
+
+		var line = []string{strconv.Itoa(row.Line), row.Status, row.Error, row.Target_uid}
+		for _, column := range columns {
+			line = append(line, data[column])
+		}
+		res = append(res, line)
+	}
+
+	return res
+}
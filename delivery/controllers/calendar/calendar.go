@@ -0,0 +1,279 @@
+package calendar
+
+import (
+	"be/api/calendar"
+	"be/delivery/controllers/templates"
+	job "be/delivery/jobs/calendar"
+	"be/delivery/middlewares"
+	repo "be/repository/calendar"
+	"be/repository/visit"
+	"be/utils/list"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// a feed starts with the visits of the last days, so a calendar app keeps
+// the recent ones
+
+const feedDays = 30
+
+type Controller struct {
+	r       repo.Calendar
+	sync    job.Notifier
+	baseUrl string
+}
+
+func New(r repo.Calendar, sync job.Notifier, baseUrl string) *Controller {
+	return &Controller{
+		r:       r,
+		sync:    sync,
+		baseUrl: baseUrl,
+	}
+}
+
+// Download is the ics of a visit, for the doctor and patient of the visit
+func (cont *Controller) Download() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetEvent(visit_uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+			default:
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+		}
+
+		if uid != res.Doctor_uid && uid != res.Patient_uid {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "access denied", nil))
+		}
+
+		event, err := calendar.FromVisit(res)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "visit-"+visit_uid+".ics"))
+		return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", calendar.Ics("", []calendar.Event{event}))
+	}
+}
+
+// CreateFeed gives the doctor or patient the url of a feed of their visits,
+// an earlier url stops working
+func (cont *Controller) CreateFeed() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" && kind != "patient" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor or patient have a calendar feed", nil))
+		}
+
+		var token, err = feedToken()
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		// database
+
+		if err := cont.r.SetFeed(uid, kind, hash(token)); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success create calendar feed", map[string]interface{}{
+			"url": cont.baseUrl + "/calendar/feed/" + token + ".ics",
+		}))
+	}
+}
+
+func (cont *Controller) DeleteFeed() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		if err := cont.r.DeleteFeed(uid); err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "calendar feed is not found", nil))
+			default:
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success delete calendar feed", nil))
+	}
+}
+
+// Feed is the ics subscription of the token, without jwt so calendar apps
+// can read it
+func (cont *Controller) Feed() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var token = strings.TrimSuffix(c.Param("token"), ".ics")
+
+		// database
+
+		feed, err := cont.r.GetFeed(hash(token))
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "calendar feed is not found", nil))
+			default:
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+		}
+
+		res, err := cont.r.GetEvents(feed.Kind, feed.Owner_uid, time.Now().AddDate(0, 0, -feedDays))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		var events = make([]calendar.Event, 0, len(res))
+		for _, visit := range res {
+			event, err := calendar.FromVisit(visit)
+			if err != nil {
+				log.Warn(err)
+				continue
+			}
+			events = append(events, event)
+		}
+
+		c.Response().Header().Set(echo.HeaderCacheControl, "private, max-age=900")
+		return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", calendar.Ics(name(feed.Kind, res), events))
+	}
+}
+
+// GetDead is the calendar syncs given up after the last attempt, for the
+// admin
+func (cont *Controller) GetDead() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can see the calendar syncs", nil))
+		}
+
+		q, err := list.Parse(repo.Dead, c.QueryParams())
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		clinic, err := cont.clinic(uid)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		res, page, err := cont.r.GetDead(clinic, q)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		var syncs = make([]SyncResp, 0, len(res))
+		for _, sync := range res {
+			syncs = append(syncs, ToSyncResp(sync.CalendarSync))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get dead calendar syncs", syncs, page))
+	}
+}
+
+// Resync brings the event of the visit in line again, e.g. after a dead sync
+func (cont *Controller) Resync() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can sync the calendar", nil))
+		}
+
+		// database
+
+		clinic, err := cont.clinic(uid)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		if err := cont.r.Resync(clinic, visit_uid); err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+			default:
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+		}
+
+		cont.sync.Notify()
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success queue calendar sync", nil))
+	}
+}
+
+// clinic is the doctor the admin belongs to, the admin only sees its visits
+func (cont *Controller) clinic(admin_uid string) (string, error) {
+
+	var clinic, err = cont.r.GetClinic(admin_uid)
+	if err != nil {
+		log.Warn(err)
+		switch err.Error() {
+		case "record not found":
+			return "", errors.New("clinic is not found")
+		default:
+			return "", errors.New("there's problem in server")
+		}
+	}
+
+	return clinic, nil
+}
+
+// name of the feed in the calendar app
+func name(kind string, res []visit.VisitCalendar) string {
+	if len(res) == 0 {
+		return "MR Clinic"
+	}
+
+	if kind == "doctor" {
+		return "MR Clinic - " + res[0].DoctorName
+	}
+	return "MR Clinic - " + res[0].PatientName
+}
+
+// feedToken is 256 random bits, the url can't be guessed
+func feedToken() (string, error) {
+	var b = make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hash(token string) string {
+	var sum = sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
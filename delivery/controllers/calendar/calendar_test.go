@@ -0,0 +1,412 @@
+package calendar
+
+import (
+	"be/configs"
+	"be/delivery/middlewares"
+	"be/entities"
+	repo "be/repository/calendar"
+	"be/repository/visit"
+	"be/utils/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var event = visit.VisitCalendar{Visit_uid: "visit", Doctor_uid: "doctor", Patient_uid: "patient", Status: "pending", Date: "10-03-2022", DoctorName: "dr. budi", PatientName: "siti", PatientEmail: "siti@mail.com"}
+
+type mockSuccess struct {
+	hashes map[string]string
+}
+
+func (m *mockSuccess) SetFeed(owner_uid, kind, token_hash string) error {
+	m.hashes[owner_uid] = token_hash
+	return nil
+}
+
+func (m *mockSuccess) DeleteFeed(owner_uid string) error {
+	return nil
+}
+
+func (m *mockSuccess) GetFeed(token_hash string) (entities.CalendarFeed, error) {
+	if token_hash != hash("token") {
+		return entities.CalendarFeed{}, gorm.ErrRecordNotFound
+	}
+	return entities.CalendarFeed{Owner_uid: "doctor", Kind: "doctor", Token_hash: token_hash}, nil
+}
+
+func (m *mockSuccess) GetEvent(visit_uid string) (visit.VisitCalendar, error) {
+	return event, nil
+}
+
+func (m *mockSuccess) GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error) {
+	return []visit.VisitCalendar{event, {Visit_uid: "invalid"}}, nil
+}
+
+func (m *mockSuccess) CreateState(state entities.OauthState) error {
+	return nil
+}
+
+func (m *mockSuccess) TakeState(state_hash string) (entities.OauthState, error) {
+	return entities.OauthState{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockSuccess) SetConnection(conn entities.CalendarConnection) error {
+	return nil
+}
+
+func (m *mockSuccess) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockSuccess) ExpireConnection(doctor_uid string) error {
+	return nil
+}
+
+func (m *mockSuccess) DeleteConnection(doctor_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockSuccess) GetDue(now time.Time, limit int) ([]entities.CalendarSync, error) {
+	return nil, nil
+}
+
+func (m *mockSuccess) Claim(id uint, now time.Time) (entities.CalendarSync, error) {
+	return entities.CalendarSync{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockSuccess) Finish(id uint) error {
+	return nil
+}
+
+func (m *mockSuccess) Retry(id uint, next_at time.Time, message string) error {
+	return nil
+}
+
+func (m *mockSuccess) Bury(id uint, message string) error {
+	return nil
+}
+
+func (m *mockSuccess) GetClinic(admin_uid string) (string, error) {
+	switch admin_uid {
+	case "admin":
+		return "doctor", nil
+	case "other":
+		return "other", nil
+	}
+	return "", gorm.ErrRecordNotFound
+}
+
+func (m *mockSuccess) GetDead(doctor_uid string, q list.Query) ([]repo.DeadSync, list.Page, error) {
+	if doctor_uid != event.Doctor_uid {
+		return nil, list.Page{Limit: q.Limit}, nil
+	}
+	return []repo.DeadSync{{CalendarSync: entities.CalendarSync{Visit_uid: "visit", Status: "dead", Attempts: 10, Last_error: "google: 500"}}}, list.Page{Total: 1, Limit: q.Limit}, nil
+}
+
+func (m *mockSuccess) Resync(doctor_uid, visit_uid string) error {
+	if doctor_uid != event.Doctor_uid {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (m *mockSuccess) GetSyncVisit(visit_uid string) (repo.SyncVisit, error) {
+	return repo.SyncVisit{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockSuccess) SetEvent(visit_uid, event_uid string) error {
+	return nil
+}
+
+func (m *mockSuccess) GetConnections() ([]entities.CalendarConnection, error) {
+	return nil, nil
+}
+
+func (m *mockSuccess) SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error {
+	return nil
+}
+
+func (m *mockSuccess) GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error) {
+	return nil, nil
+}
+
+func (m *mockSuccess) SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error {
+	return nil
+}
+
+func (m *mockSuccess) GetChannel(channel_id string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+type mockFail struct{}
+
+func (m *mockFail) SetFeed(owner_uid, kind, token_hash string) error {
+	return errors.New("")
+}
+
+func (m *mockFail) DeleteFeed(owner_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetFeed(token_hash string) (entities.CalendarFeed, error) {
+	return entities.CalendarFeed{}, errors.New("")
+}
+
+func (m *mockFail) GetEvent(visit_uid string) (visit.VisitCalendar, error) {
+	return visit.VisitCalendar{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) CreateState(state entities.OauthState) error {
+	return nil
+}
+
+func (m *mockFail) TakeState(state_hash string) (entities.OauthState, error) {
+	return entities.OauthState{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) SetConnection(conn entities.CalendarConnection) error {
+	return nil
+}
+
+func (m *mockFail) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) ExpireConnection(doctor_uid string) error {
+	return nil
+}
+
+func (m *mockFail) DeleteConnection(doctor_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetDue(now time.Time, limit int) ([]entities.CalendarSync, error) {
+	return nil, nil
+}
+
+func (m *mockFail) Claim(id uint, now time.Time) (entities.CalendarSync, error) {
+	return entities.CalendarSync{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) Finish(id uint) error {
+	return nil
+}
+
+func (m *mockFail) Retry(id uint, next_at time.Time, message string) error {
+	return nil
+}
+
+func (m *mockFail) Bury(id uint, message string) error {
+	return nil
+}
+
+func (m *mockFail) GetClinic(admin_uid string) (string, error) {
+	return "doctor", nil
+}
+
+func (m *mockFail) GetDead(doctor_uid string, q list.Query) ([]repo.DeadSync, list.Page, error) {
+	return nil, list.Page{}, errors.New("")
+}
+
+func (m *mockFail) Resync(doctor_uid, visit_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetSyncVisit(visit_uid string) (repo.SyncVisit, error) {
+	return repo.SyncVisit{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) SetEvent(visit_uid, event_uid string) error {
+	return nil
+}
+
+func (m *mockFail) GetConnections() ([]entities.CalendarConnection, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error {
+	return errors.New("")
+}
+
+func (m *mockFail) GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error {
+	return errors.New("")
+}
+
+func (m *mockFail) GetChannel(channel_id string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+type mockSync struct {
+	notified int
+}
+
+func (m *mockSync) Notify() {
+	m.notified++
+}
+
+func request(t *testing.T, uid, kind, param string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodGet, "/", nil)
+	var res = httptest.NewRecorder()
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("visit_uid", "token")
+	context.SetParamValues("visit", param)
+
+	if uid == "" {
+		if err := handler(context); err != nil {
+			log.Fatal(err)
+		}
+		return res
+	}
+
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestDownload(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockSync{}, "http://localhost")
+		var res = request(t, "patient", "patient", "", controller.Download())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", res.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, res.Body.String(), "UID:visit@mr-clinic")
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockSync{}, "http://localhost")
+		assert.Equal(t, 401, request(t, "other", "patient", "", controller.Download()).Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockSync{}, "http://localhost")
+		assert.Equal(t, 404, request(t, "patient", "patient", "", controller.Download()).Code)
+	})
+}
+
+func TestFeed(t *testing.T) {
+	t.Run("success create", func(t *testing.T) {
+		var m = &mockSuccess{hashes: map[string]string{}}
+		var controller = New(m, &mockSync{}, "http://localhost")
+		var res = response(request(t, "doctor", "doctor", "", controller.CreateFeed()))
+		assert.Equal(t, 201, res.Code)
+
+		var url = res.Data.(map[string]interface{})["url"].(string)
+		assert.True(t, strings.HasPrefix(url, "http://localhost/calendar/feed/"))
+
+		// only the hash of the token is kept
+
+		var token = strings.TrimSuffix(strings.TrimPrefix(url, "http://localhost/calendar/feed/"), ".ics")
+		assert.Equal(t, 43, len(token))
+		assert.Equal(t, hash(token), m.hashes["doctor"])
+	})
+
+	t.Run("error create", func(t *testing.T) {
+		assert.Equal(t, 401, request(t, "admin", "admin", "", New(&mockSuccess{}, &mockSync{}, "").CreateFeed()).Code)
+		assert.Equal(t, 500, request(t, "doctor", "doctor", "", New(&mockFail{}, &mockSync{}, "").CreateFeed()).Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, 200, request(t, "doctor", "doctor", "", New(&mockSuccess{}, &mockSync{}, "").DeleteFeed()).Code)
+		assert.Equal(t, 404, request(t, "doctor", "doctor", "", New(&mockFail{}, &mockSync{}, "").DeleteFeed()).Code)
+	})
+
+	t.Run("success feed", func(t *testing.T) {
+		var res = request(t, "", "", "token.ics", New(&mockSuccess{}, &mockSync{}, "").Feed())
+		assert.Equal(t, 200, res.Code)
+		assert.Contains(t, res.Body.String(), "X-WR-CALNAME:MR Clinic - dr. budi")
+		assert.Equal(t, 1, strings.Count(res.Body.String(), "BEGIN:VEVENT"))
+	})
+
+	t.Run("error feed", func(t *testing.T) {
+		assert.Equal(t, 404, request(t, "", "", "guess.ics", New(&mockSuccess{}, &mockSync{}, "").Feed()).Code)
+		assert.Equal(t, 500, request(t, "", "", "token.ics", New(&mockFail{}, &mockSync{}, "").Feed()).Code)
+	})
+}
+
+func TestSync(t *testing.T) {
+	t.Run("success dead", func(t *testing.T) {
+		var res = response(request(t, "admin", "admin", "", New(&mockSuccess{}, &mockSync{}, "").GetDead()))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "google: 500", res.Data.([]interface{})[0].(map[string]interface{})["last_error"])
+	})
+
+	t.Run("error dead", func(t *testing.T) {
+		assert.Equal(t, 401, request(t, "doctor", "doctor", "", New(&mockSuccess{}, &mockSync{}, "").GetDead()).Code)
+		assert.Equal(t, 500, request(t, "admin", "admin", "", New(&mockFail{}, &mockSync{}, "").GetDead()).Code)
+		assert.Equal(t, 500, request(t, "unknown", "admin", "", New(&mockSuccess{}, &mockSync{}, "").GetDead()).Code)
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		var e = echo.New()
+		var req = httptest.NewRequest(http.MethodGet, "/?sort=visit_uid", nil)
+		var res = httptest.NewRecorder()
+
+		var token, _ = middlewares.GenerateToken("admin", "admin")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+		if err := middleware.JWT([]byte(configs.JWT_SECRET))(New(&mockSuccess{}, &mockSync{}, "").GetDead())(e.NewContext(req, res)); err != nil {
+			log.Fatal(err)
+		}
+
+		assert.Equal(t, 400, res.Code)
+	})
+
+	t.Run("dead syncs of another clinic", func(t *testing.T) {
+		var res = response(request(t, "other", "admin", "", New(&mockSuccess{}, &mockSync{}, "").GetDead()))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, 0, len(res.Data.([]interface{})))
+	})
+
+	t.Run("success resync", func(t *testing.T) {
+		var sync = &mockSync{}
+		assert.Equal(t, 202, request(t, "admin", "admin", "", New(&mockSuccess{}, sync, "").Resync()).Code)
+		assert.Equal(t, 1, sync.notified)
+	})
+
+	t.Run("error resync", func(t *testing.T) {
+		assert.Equal(t, 401, request(t, "doctor", "doctor", "", New(&mockSuccess{}, &mockSync{}, "").Resync()).Code)
+		assert.Equal(t, 404, request(t, "admin", "admin", "", New(&mockFail{}, &mockSync{}, "").Resync()).Code)
+		assert.Equal(t, 500, request(t, "unknown", "admin", "", New(&mockSuccess{}, &mockSync{}, "").Resync()).Code)
+	})
+
+	t.Run("resync visit of another clinic", func(t *testing.T) {
+		var sync = &mockSync{}
+		assert.Equal(t, 404, request(t, "other", "admin", "", New(&mockSuccess{}, sync, "").Resync()).Code)
+		assert.Equal(t, 0, sync.notified)
+	})
+}
@@ -0,0 +1,34 @@
+package calendar
+
+import "be/entities"
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+type SyncResp struct {
+	Visit_uid  string `json:"visit_uid"`
+	Status     string `json:"status"`
+	Attempts   int    `json:"attempts"`
+	Last_error string `json:"last_error"`
+	CreatedAt  string `json:"createdAt"`
+	FinishedAt string `json:"finishedAt"`
+}
+
+func ToSyncResp(sync entities.CalendarSync) SyncResp {
+	var res = SyncResp{
+		Visit_uid:  sync.Visit_uid,
+		Status:     sync.Status,
+		Attempts:   sync.Attempts,
+		Last_error: sync.Last_error,
+		CreatedAt:  sync.CreatedAt.Format("02-01-2006 15:04"),
+	}
+
+	if sync.Finished_at != nil {
+		res.FinishedAt = sync.Finished_at.Format("02-01-2006 15:04")
+	}
+
+	return res
+}
@@ -6,6 +6,8 @@ import (
 	logic "be/delivery/logic/doctor"
 	"be/entities"
 	"be/repository/doctor"
+	"be/utils/list"
+	"be/utils/photo"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -49,32 +51,38 @@ func (m *errorLogicRequest) ValidationStruct(req logic.Req) error {
 	return nil
 }
 
-type mockTaskS3M struct{}
+type mockPhoto struct{}
 
-func (m *mockTaskS3M) UploadFileToS3(fileHeader multipart.FileHeader) (string, error) {
-	return "", nil
+func (m *mockPhoto) Upload(fileHeader multipart.FileHeader) (string, error) {
+	return "photos/key", nil
 }
 
-func (m *mockTaskS3M) UpdateFileS3(name string, fileHeader multipart.FileHeader) string {
-	return "success"
+func (m *mockPhoto) Delete(key string) error {
+	return nil
 }
 
-func (m *mockTaskS3M) DeleteFileS3(name string) string {
-	return "success"
+func (m *mockPhoto) Urls(key string) photo.Urls {
+	return photo.Urls{Large: "https://files.example.com/" + key + "/large.jpg", Medium: "https://files.example.com/" + key + "/medium.jpg", Small: "https://files.example.com/" + key + "/small.jpg"}
 }
 
-type failTaskS3M struct{}
+type failPhoto struct {
+	mockPhoto
+}
 
-func (m *failTaskS3M) UploadFileToS3(fileHeader multipart.FileHeader) (string, error) {
+func (m *failPhoto) Upload(fileHeader multipart.FileHeader) (string, error) {
 	return "", errors.New("")
 }
 
-func (m *failTaskS3M) UpdateFileS3(name string, fileHeader multipart.FileHeader) string {
-	return "error"
+func (m *failPhoto) Delete(key string) error {
+	return errors.New("")
 }
 
-func (m *failTaskS3M) DeleteFileS3(name string) string {
-	return "error"
+type notImage struct {
+	mockPhoto
+}
+
+func (m *notImage) Upload(fileHeader multipart.FileHeader) (string, error) {
+	return "", errors.New("file is not an image")
 }
 
 type mockSuccess struct{}
@@ -92,11 +100,11 @@ func (m *mockSuccess) Delete(Doctor_uid string) (entities.Doctor, error) {
 }
 
 func (m *mockSuccess) GetProfile(doctor_uid, userName, email string) (doctor.ProfileResp, error) {
-	return doctor.ProfileResp{Image: "https://karen-givi-bucket.s3.ap-southeast-1.amazonaws.com/testing"}, nil
+	return doctor.ProfileResp{Image: "testing"}, nil
 }
 
-func (m *mockSuccess) GetAll() (doctor.All, error) {
-	return doctor.All{}, nil
+func (m *mockSuccess) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{Total: 1, Limit: q.Limit}, nil
 }
 
 type mockFail struct{}
@@ -117,8 +125,8 @@ func (m *mockFail) GetProfile(doctor_uid, userName, email string) (doctor.Profil
 	return doctor.ProfileResp{}, errors.New("")
 }
 
-func (m *mockFail) GetAll() (doctor.All, error) {
-	return doctor.All{}, errors.New("")
+func (m *mockFail) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, errors.New("")
 }
 
 type createCapacity struct{}
@@ -136,11 +144,11 @@ func (m *createCapacity) Delete(Doctor_uid string) (entities.Doctor, error) {
 }
 
 func (m *createCapacity) GetProfile(doctor_uid, userName, email string) (doctor.ProfileResp, error) {
-	return doctor.ProfileResp{Image: "https://www.teralogistics.com/wp-content/uploads/2020/12/default.png"}, nil
+	return doctor.ProfileResp{Image: ""}, nil
 }
 
-func (m *createCapacity) GetAll() (doctor.All, error) {
-	return doctor.All{}, errors.New("")
+func (m *createCapacity) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, errors.New("")
 }
 
 type createUserName struct{}
@@ -161,8 +169,8 @@ func (m *createUserName) GetProfile(doctor_uid, userName, email string) (doctor.
 	return doctor.ProfileResp{}, errors.New("")
 }
 
-func (m *createUserName) GetAll() (doctor.All, error) {
-	return doctor.All{}, errors.New("")
+func (m *createUserName) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, errors.New("")
 }
 
 type createEmail struct{}
@@ -183,8 +191,8 @@ func (m *createEmail) GetProfile(doctor_uid, userName, email string) (doctor.Pro
 	return doctor.ProfileResp{}, errors.New("")
 }
 
-func (m *createEmail) GetAll() (doctor.All, error) {
-	return doctor.All{}, errors.New("")
+func (m *createEmail) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, errors.New("")
 }
 
 type recordNotFound struct{}
@@ -205,8 +213,8 @@ func (m *recordNotFound) GetProfile(doctor_uid, userName, email string) (doctor.
 	return doctor.ProfileResp{}, gorm.ErrRecordNotFound
 }
 
-func (m *recordNotFound) GetAll() (doctor.All, error) {
-	return doctor.All{}, gorm.ErrRecordNotFound
+func (m *recordNotFound) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 type statusEnum struct{}
@@ -227,8 +235,8 @@ func (m *statusEnum) GetProfile(doctor_uid, userName, email string) (doctor.Prof
 	return doctor.ProfileResp{}, gorm.ErrRecordNotFound
 }
 
-func (m *statusEnum) GetAll() (doctor.All, error) {
-	return doctor.All{}, gorm.ErrRecordNotFound
+func (m *statusEnum) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 type openDayEnum struct{}
@@ -249,8 +257,8 @@ func (m *openDayEnum) GetProfile(doctor_uid, userName, email string) (doctor.Pro
 	return doctor.ProfileResp{}, gorm.ErrRecordNotFound
 }
 
-func (m *openDayEnum) GetAll() (doctor.All, error) {
-	return doctor.All{}, gorm.ErrRecordNotFound
+func (m *openDayEnum) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 type closeDayEnum struct{}
@@ -271,8 +279,8 @@ func (m *closeDayEnum) GetProfile(doctor_uid, userName, email string) (doctor.Pr
 	return doctor.ProfileResp{}, gorm.ErrRecordNotFound
 }
 
-func (m *closeDayEnum) GetAll() (doctor.All, error) {
-	return doctor.All{}, gorm.ErrRecordNotFound
+func (m *closeDayEnum) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 type updateFile struct{}
@@ -290,11 +298,11 @@ func (m *updateFile) Delete(Doctor_uid string) (entities.Doctor, error) {
 }
 
 func (m *updateFile) GetProfile(doctor_uid, userName, email string) (doctor.ProfileResp, error) {
-	return doctor.ProfileResp{Image: "https://www.teralogistics.com/wp-content/uploads/2020/12/default.png"}, nil
+	return doctor.ProfileResp{Image: ""}, nil
 }
 
-func (m *updateFile) GetAll() (doctor.All, error) {
-	return doctor.All{}, nil
+func (m *updateFile) GetAll(q list.Query) (doctor.All, list.Page, error) {
+	return doctor.All{}, list.Page{}, nil
 }
 
 type MockAuthLib struct{}
@@ -331,7 +339,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -362,7 +370,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -393,7 +401,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -424,7 +432,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -455,7 +463,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -486,7 +494,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -517,7 +525,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -548,7 +556,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -579,7 +587,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -610,7 +618,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -644,7 +652,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -676,7 +684,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &errorLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &errorLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -708,7 +716,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &errorLogicRequest{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &errorLogicRequest{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -749,7 +757,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -791,7 +799,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &failPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -824,7 +832,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -858,7 +866,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&createUserName{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&createUserName{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -892,7 +900,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&createEmail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&createEmail{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -926,7 +934,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&statusEnum{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&statusEnum{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -960,7 +968,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&openDayEnum{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&openDayEnum{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -994,7 +1002,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&closeDayEnum{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&closeDayEnum{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -1058,7 +1066,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1094,7 +1102,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1130,7 +1138,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1166,7 +1174,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1202,7 +1210,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1238,7 +1246,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1274,7 +1282,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1310,7 +1318,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1346,7 +1354,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1382,7 +1390,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1418,7 +1426,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &errorLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &errorLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1460,7 +1468,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1501,7 +1509,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1543,7 +1551,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &failPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1585,7 +1593,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&updateFile{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&updateFile{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1627,7 +1635,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&createCapacity{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&createCapacity{}, &failPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1660,7 +1668,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1693,7 +1701,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&createUserName{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&createUserName{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1726,7 +1734,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&createEmail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&createEmail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1759,7 +1767,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&createCapacity{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&createCapacity{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1792,7 +1800,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1825,7 +1833,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&statusEnum{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&statusEnum{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1858,7 +1866,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&openDayEnum{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&openDayEnum{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1891,7 +1899,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&closeDayEnum{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&closeDayEnum{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1944,7 +1952,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1983,7 +1991,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &failPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2008,7 +2016,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2032,7 +2040,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2085,7 +2093,7 @@ func TestGetProfile(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetProfile())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2096,6 +2104,8 @@ func TestGetProfile(t *testing.T) {
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
 		assert.Equal(t, 200, response.Code)
+		assert.Equal(t, "https://files.example.com/testing/large.jpg", response.Data.(map[string]interface{})["image"])
+		assert.Equal(t, "https://files.example.com/testing/small.jpg", response.Data.(map[string]interface{})["images"].(map[string]interface{})["small"])
 	})
 
 	t.Run("internal server", func(t *testing.T) {
@@ -2109,7 +2119,7 @@ func TestGetProfile(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor/profile")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetProfile())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2133,7 +2143,7 @@ func TestGetProfile(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor/profile")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetProfile())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2186,7 +2196,7 @@ func TestGetCheck(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetCheck())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2210,7 +2220,7 @@ func TestGetCheck(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor/profile")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetCheck())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2234,7 +2244,7 @@ func TestGetCheck(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor/profile")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetCheck())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2287,7 +2297,7 @@ func TestGetAll(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetAll())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -2298,6 +2308,32 @@ func TestGetAll(t *testing.T) {
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
 		assert.Equal(t, 200, response.Code)
+		assert.Equal(t, map[string]interface{}{"total": float64(1), "limit": float64(20), "offset": float64(0), "next_cursor": ""}, response.Meta)
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		var e = echo.New()
+
+		var req = httptest.NewRequest(http.MethodGet, "/?sort=password", bytes.NewBuffer(nil))
+		var res = httptest.NewRecorder()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/doctor")
+
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
+		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetAll())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		var response = ResponseFormat{}
+
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "invalid sort password", response.Message)
 	})
 
 	t.Run("internal server", func(t *testing.T) {
@@ -2311,7 +2347,7 @@ func TestGetAll(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor/profile")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetAll())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -33,4 +33,5 @@ type ResponseFormat struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+	Meta    interface{} `json:"meta"`
 }
@@ -0,0 +1,161 @@
+package document
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/document"
+	"be/delivery/middlewares"
+	"be/repository/document"
+	"be/utils/list"
+	"be/utils/pdf"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	r   document.Document
+	pdf pdf.Pdf
+	l   logic.Document
+}
+
+func New(r document.Document, pdf pdf.Pdf, l logic.Document) *Controller {
+	return &Controller{
+		r:   r,
+		pdf: pdf,
+		l:   l,
+	}
+}
+
+func (cont *Controller) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can issue document", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		var doc, _ = req.ToDocument()
+
+		// database
+
+		res, err := cont.r.Create(visit_uid, uid, *doc)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("visit is not found")
+			case "visit is not completed yet", "start date is before visit date":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add document", map[string]interface{}{
+			"document_uid": res.Document_uid,
+			"code":         res.Code,
+		}))
+	}
+}
+
+func (cont *Controller) GetDocuments() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+		var q, err = list.Parse(document.List, c.QueryParams())
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, page, err := cont.r.GetDocuments(visit_uid, uid, q)
+
+		if err != nil {
+			log.Warn(err)
+			if err.Error() == "record not found" {
+				return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get documents", res, page))
+	}
+}
+
+func (cont *Controller) Download() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var document_uid = c.Param("document_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetDocument(document_uid)
+
+		// only the issuing doctor and the patient can download the letter
+
+		if err == nil && uid != res.Doctor_uid && uid != res.Patient_uid {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "access denied", nil))
+		}
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("document is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		file, err := cont.pdf.Generate(ToLetter(res))
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", res.Kind+"-"+res.Code+".pdf"))
+		return c.Blob(http.StatusOK, "application/pdf", file)
+	}
+}
+
+func (cont *Controller) Verify() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var code = c.Param("code")
+
+		// database
+
+		res, err := cont.r.Verify(code)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "document is not valid", nil))
+			default:
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "document is valid", res))
+	}
+}
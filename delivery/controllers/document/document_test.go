@@ -0,0 +1,177 @@
+package document
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/document"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/document"
+	"be/utils/list"
+	"be/utils/pdf"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct{}
+
+func (m *mockSuccess) Create(visit_uid, doctor_uid string, req entities.Document) (entities.Document, error) {
+	return entities.Document{Document_uid: "document", Code: "ABCDE-23456"}, nil
+}
+
+func (m *mockSuccess) GetDocument(document_uid string) (entities.Document, error) {
+	return entities.Document{Document_uid: document_uid, Code: "ABCDE-23456", Kind: "sickLeave", Doctor_uid: "doctor", Patient_uid: "patient"}, nil
+}
+
+func (m *mockSuccess) GetDocuments(visit_uid, user_uid string, q list.Query) (document.Documents, list.Page, error) {
+	return document.Documents{}, list.Page{}, nil
+}
+
+func (m *mockSuccess) Verify(code string) (document.VerifyResp, error) {
+	return document.VerifyResp{Code: code}, nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) Create(visit_uid, doctor_uid string, req entities.Document) (entities.Document, error) {
+	return entities.Document{}, errors.New("visit is not completed yet")
+}
+
+func (m *mockFail) GetDocument(document_uid string) (entities.Document, error) {
+	return entities.Document{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetDocuments(visit_uid, user_uid string, q list.Query) (document.Documents, list.Page, error) {
+	return document.Documents{}, list.Page{}, errors.New("")
+}
+
+func (m *mockFail) Verify(code string) (document.VerifyResp, error) {
+	return document.VerifyResp{}, gorm.ErrRecordNotFound
+}
+
+type mockPdf struct{}
+
+func (m *mockPdf) Generate(letter pdf.Letter) ([]byte, error) {
+	return []byte("%PDF-1.3"), nil
+}
+
+func (m *mockPdf) Record(record pdf.Record) ([]byte, error) {
+	return []byte("%PDF-1.3"), nil
+}
+
+func request(t *testing.T, method string, body interface{}, uid, kind string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reqBody, _ = json.Marshal(body)
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/", bytes.NewBuffer(reqBody))
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("visit_uid", "document_uid", "code")
+	context.SetParamValues("visit", "document", "ABCDE-23456")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestCreate(t *testing.T) {
+	var body = map[string]interface{}{"kind": "sickLeave", "startDate": "01-06-2022", "endDate": "03-06-2022"}
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockPdf{}, logic.New())
+		assert.Equal(t, 201, response(request(t, http.MethodPost, body, "doctor", "doctor", controller.Create())).Code)
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockPdf{}, logic.New())
+		assert.Equal(t, 401, response(request(t, http.MethodPost, body, "patient", "patient", controller.Create())).Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockPdf{}, logic.New())
+		assert.Equal(t, 400, response(request(t, http.MethodPost, map[string]interface{}{"kind": "sickLeave"}, "doctor", "doctor", controller.Create())).Code)
+	})
+
+	t.Run("visit not completed", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockPdf{}, logic.New())
+		assert.Equal(t, "visit is not completed yet", response(request(t, http.MethodPost, body, "doctor", "doctor", controller.Create())).Message)
+	})
+}
+
+func TestDownload(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockPdf{}, logic.New())
+		var res = request(t, http.MethodGet, nil, "patient", "patient", controller.Download())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "application/pdf", res.Header().Get(echo.HeaderContentType))
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockPdf{}, logic.New())
+		assert.Equal(t, 401, request(t, http.MethodGet, nil, "other", "patient", controller.Download()).Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockPdf{}, logic.New())
+		assert.Equal(t, "document is not found", response(request(t, http.MethodGet, nil, "patient", "patient", controller.Download())).Message)
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockPdf{}, logic.New())
+		assert.Equal(t, 200, request(t, http.MethodGet, nil, "patient", "patient", controller.GetDocuments()).Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockPdf{}, logic.New())
+		assert.Equal(t, 500, request(t, http.MethodGet, nil, "patient", "patient", controller.GetDocuments()).Code)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	var verify = func(controller *Controller) *httptest.ResponseRecorder {
+		var e = echo.New()
+		var req = httptest.NewRequest(http.MethodGet, "/", nil)
+		var res = httptest.NewRecorder()
+		context := e.NewContext(req, res)
+		context.SetParamNames("code")
+		context.SetParamValues("ABCDE-23456")
+		controller.Verify()(context)
+		return res
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.Equal(t, 200, verify(New(&mockSuccess{}, &mockPdf{}, logic.New())).Code)
+	})
+
+	t.Run("not valid", func(t *testing.T) {
+		assert.Equal(t, 404, verify(New(&mockFail{}, &mockPdf{}, logic.New())).Code)
+	})
+}
@@ -0,0 +1,35 @@
+package document
+
+import (
+	logic "be/delivery/logic/document"
+	"be/entities"
+	"be/utils/pdf"
+	"time"
+)
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+func ToLetter(document entities.Document) pdf.Letter {
+	var layout = "02-01-2006"
+
+	return pdf.Letter{
+		Kind:          document.Kind,
+		Code:          document.Code,
+		VerifyPath:    "/documents/verify/" + document.Code,
+		PatientName:   document.PatientName,
+		Nik:           document.Nik,
+		Dob:           time.Time(document.Dob).Format(layout),
+		DoctorName:    document.DoctorName,
+		DoctorAddress: document.DoctorAddress,
+		VisitDate:     time.Time(document.VisitDate).Format(layout),
+		StartDate:     time.Time(document.StartDate).Format(layout),
+		EndDate:       time.Time(document.EndDate).Format(layout),
+		Days:          logic.Days(time.Time(document.StartDate), time.Time(document.EndDate)),
+		Note:          document.Note,
+		IssuedAt:      document.CreatedAt.Format(layout),
+	}
+}
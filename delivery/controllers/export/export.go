@@ -0,0 +1,152 @@
+package export
+
+import (
+	"be/api/storage"
+	"be/delivery/controllers/templates"
+	job "be/delivery/jobs/export"
+	logic "be/delivery/logic/export"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/export"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const urlExpire = 15 * time.Minute
+
+type Controller struct {
+	r     export.Export
+	store storage.Private
+	queue job.Queue
+	l     logic.Export
+}
+
+func New(r export.Export, store storage.Private, queue job.Queue, l logic.Export) *Controller {
+	return &Controller{
+		r:     r,
+		store: store,
+		queue: queue,
+		l:     l,
+	}
+}
+
+func (cont *Controller) Export() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var patient_uid = c.Param("uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+		var format = c.QueryParam("format")
+
+		if format == "" {
+			format = "pdf"
+		}
+
+		if err := cont.l.ValidationFormat(format); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		if err := cont.r.CheckAccess(patient_uid, uid); err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "patient is not found", nil))
+			case "access denied":
+				return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "access denied", nil))
+			default:
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+		}
+
+		count, err := cont.r.CountVisits(patient_uid)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		// long histories are built in the background
+
+		if count > logic.SyncLimit {
+			res, err := cont.r.Create(entities.Export{Patient_uid: patient_uid, Requester_uid: uid, Format: format})
+
+			if err != nil {
+				log.Warn(err)
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+
+			cont.queue.Enqueue(res.Export_uid)
+
+			return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "export is being prepared", map[string]interface{}{
+				"export_uid": res.Export_uid,
+				"status":     res.Status,
+			}))
+		}
+
+		record, err := cont.r.GetRecord(patient_uid)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		file, err := cont.l.Build(format, record)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", file.Name))
+		return c.Blob(http.StatusOK, file.ContentType, file.Body)
+	}
+}
+
+func (cont *Controller) Status() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var export_uid = c.Param("export_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetExport(export_uid)
+
+		// only the one who asked for the export gets the link
+
+		if err == nil && uid != res.Requester_uid {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "access denied", nil))
+		}
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("export is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		var resp = ToStatusResp(res)
+
+		if res.Status == "done" {
+			url, err := cont.store.SignedUrl(res.Object_key, urlExpire)
+
+			if err != nil {
+				log.Warn(err)
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+
+			resp.Url = url
+			resp.ExpiredAt = expiredAt(urlExpire)
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get export", resp))
+	}
+}
@@ -0,0 +1,225 @@
+package export
+
+import (
+	"be/api/storage"
+	"be/configs"
+	logic "be/delivery/logic/export"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/export"
+	"be/utils/pdf"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct {
+	visits int64
+}
+
+func (m *mockSuccess) CheckAccess(patient_uid, user_uid string) error {
+	if user_uid == "other" {
+		return errors.New("access denied")
+	}
+	return nil
+}
+
+func (m *mockSuccess) CountVisits(patient_uid string) (int64, error) {
+	return m.visits, nil
+}
+
+func (m *mockSuccess) GetRecord(patient_uid string) (export.Record, error) {
+	return export.Record{Patient: entities.Patient{Patient_uid: patient_uid, Name: "siti"}}, nil
+}
+
+func (m *mockSuccess) Create(req entities.Export) (entities.Export, error) {
+	req.Export_uid = "export"
+	req.Status = "queued"
+	return req, nil
+}
+
+func (m *mockSuccess) Claim(export_uid string) (entities.Export, error) {
+	return entities.Export{}, nil
+}
+
+func (m *mockSuccess) Finish(export_uid string, res entities.Export) error {
+	return nil
+}
+
+func (m *mockSuccess) Fail(export_uid, message string) error {
+	return nil
+}
+
+func (m *mockSuccess) GetExport(export_uid string) (entities.Export, error) {
+	return entities.Export{Export_uid: export_uid, Patient_uid: "patient", Requester_uid: "patient", Format: "pdf", Status: "done", Object_key: "exports/patient/export.pdf"}, nil
+}
+
+func (m *mockSuccess) GetPending() ([]entities.Export, error) {
+	return nil, nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) CheckAccess(patient_uid, user_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) CountVisits(patient_uid string) (int64, error) {
+	return 0, errors.New("")
+}
+
+func (m *mockFail) GetRecord(patient_uid string) (export.Record, error) {
+	return export.Record{}, errors.New("")
+}
+
+func (m *mockFail) Create(req entities.Export) (entities.Export, error) {
+	return entities.Export{}, errors.New("")
+}
+
+func (m *mockFail) Claim(export_uid string) (entities.Export, error) {
+	return entities.Export{}, errors.New("")
+}
+
+func (m *mockFail) Finish(export_uid string, res entities.Export) error {
+	return errors.New("")
+}
+
+func (m *mockFail) Fail(export_uid, message string) error {
+	return errors.New("")
+}
+
+func (m *mockFail) GetExport(export_uid string) (entities.Export, error) {
+	return entities.Export{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetPending() ([]entities.Export, error) {
+	return nil, errors.New("")
+}
+
+type mockS3 struct{}
+
+func (m *mockS3) UploadPrivateFile(key string, fileHeader multipart.FileHeader) (storage.PrivateFile, error) {
+	return storage.PrivateFile{}, nil
+}
+
+func (m *mockS3) UploadPrivateReader(key, contentType string, body io.Reader) (storage.PrivateFile, error) {
+	return storage.PrivateFile{Key: key}, nil
+}
+
+func (m *mockS3) SignedUrl(key string, expire time.Duration) (string, error) {
+	return "https://bucket/" + key, nil
+}
+
+func (m *mockS3) DeletePrivateFile(key string) error {
+	return nil
+}
+
+type mockQueue struct {
+	queued []string
+}
+
+func (m *mockQueue) Enqueue(export_uid string) {
+	m.queued = append(m.queued, export_uid)
+}
+
+func request(t *testing.T, query, uid, kind string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("uid", "export_uid")
+	context.SetParamValues("patient", "export")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestExport(t *testing.T) {
+	var l = logic.New(pdf.New(), "http://localhost/fhir")
+
+	t.Run("success pdf", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, &mockQueue{}, l)
+		var res = request(t, "", "patient", "patient", controller.Export())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "application/pdf", res.Header().Get(echo.HeaderContentType))
+	})
+
+	t.Run("success fhir", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, &mockQueue{}, l)
+		var res = request(t, "format=fhir", "doctor", "doctor", controller.Export())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "application/fhir+json", res.Header().Get(echo.HeaderContentType))
+	})
+
+	t.Run("background", func(t *testing.T) {
+		var queue = &mockQueue{}
+		var controller = New(&mockSuccess{visits: logic.SyncLimit + 1}, &mockS3{}, queue, l)
+		var res = request(t, "format=json", "patient", "patient", controller.Export())
+		assert.Equal(t, 202, res.Code)
+		assert.Equal(t, []string{"export"}, queue.queued)
+	})
+
+	t.Run("format", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, &mockQueue{}, l)
+		assert.Equal(t, 400, request(t, "format=xml", "patient", "patient", controller.Export()).Code)
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, &mockQueue{}, l)
+		assert.Equal(t, 401, request(t, "", "other", "doctor", controller.Export()).Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockS3{}, &mockQueue{}, l)
+		assert.Equal(t, 404, request(t, "", "patient", "patient", controller.Export()).Code)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	var l = logic.New(pdf.New(), "http://localhost/fhir")
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, &mockQueue{}, l)
+		var res = response(request(t, "", "patient", "patient", controller.Status()))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "https://bucket/exports/patient/export.pdf", res.Data.(map[string]interface{})["url"])
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockS3{}, &mockQueue{}, l)
+		assert.Equal(t, 401, request(t, "", "doctor", "doctor", controller.Status()).Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockS3{}, &mockQueue{}, l)
+		assert.Equal(t, "export is not found", response(request(t, "", "patient", "patient", controller.Status())).Message)
+	})
+}
@@ -0,0 +1,42 @@
+package export
+
+import (
+	"be/entities"
+	"time"
+)
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+type StatusResp struct {
+	Export_uid  string `json:"export_uid"`
+	Patient_uid string `json:"patient_uid"`
+	Format      string `json:"format"`
+	Status      string `json:"status"`
+	FileName    string `json:"fileName"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	Error       string `json:"error"`
+	Url         string `json:"url"`
+	ExpiredAt   string `json:"expiredAt"`
+}
+
+func ToStatusResp(export entities.Export) StatusResp {
+	return StatusResp{
+		Export_uid:  export.Export_uid,
+		Patient_uid: export.Patient_uid,
+		Format:      export.Format,
+		Status:      export.Status,
+		FileName:    export.FileName,
+		Size:        export.Size,
+		Checksum:    export.Checksum,
+		Error:       export.Error,
+	}
+}
+
+func expiredAt(expire time.Duration) string {
+	return time.Now().Add(expire).Format(time.RFC3339)
+}
@@ -0,0 +1,192 @@
+package fhir
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/fhir"
+	"be/delivery/middlewares"
+	repo "be/repository/fhir"
+	"be/utils/fhir"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const MIMEFhirJson = "application/fhir+json; charset=UTF-8"
+
+// largest bundle accepted by the import
+const MaxBundleSize = 10 << 20
+
+type Controller struct {
+	r repo.Fhir
+	l logic.Fhir
+}
+
+func New(r repo.Fhir, l logic.Fhir) *Controller {
+	return &Controller{
+		r: r,
+		l: l,
+	}
+}
+
+func respond(c echo.Context, code int, body interface{}) error {
+	c.Response().Header().Set(echo.HeaderContentType, MIMEFhirJson)
+	return c.JSON(code, body)
+}
+
+func (cont *Controller) read(resourceType string, get func(id string) (fhir.Resource, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var id = c.Param("id")
+
+		// database
+
+		res, err := get(id)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				return respond(c, http.StatusNotFound, fhir.Outcome("error", "not-found", resourceType+"/"+id+" is not found"))
+			default:
+				return respond(c, http.StatusInternalServerError, fhir.Outcome("fatal", "exception", "there's problem in server"))
+			}
+		}
+
+		return respond(c, http.StatusOK, res)
+	}
+}
+
+func (cont *Controller) search(resourceType string, find func(search repo.Search) ([]fhir.Resource, int64, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var query = c.QueryParams()
+
+		var search, page, err = cont.l.ParseSearch(resourceType, query)
+		if err != nil {
+			return respond(c, http.StatusBadRequest, fhir.Outcome("error", "invalid", err.Error()))
+		}
+
+		// database
+
+		res, total, err := find(search)
+
+		if err != nil {
+			log.Warn(err)
+			return respond(c, http.StatusInternalServerError, fhir.Outcome("fatal", "exception", "there's problem in server"))
+		}
+
+		var base = c.Scheme() + "://" + c.Request().Host + "/fhir"
+
+		return respond(c, http.StatusOK, fhir.SearchSet(base, resourceType, query, page, total, res))
+	}
+}
+
+func (cont *Controller) ReadPatient() echo.HandlerFunc {
+	return cont.read("Patient", func(id string) (fhir.Resource, error) {
+		var res, err = cont.r.GetPatient(id)
+		return fhir.FromPatient(res), err
+	})
+}
+
+func (cont *Controller) SearchPatient() echo.HandlerFunc {
+	return cont.search("Patient", func(search repo.Search) ([]fhir.Resource, int64, error) {
+		var res, total, err = cont.r.SearchPatients(search)
+		var resources = []fhir.Resource{}
+		for _, v := range res {
+			resources = append(resources, fhir.FromPatient(v))
+		}
+		return resources, total, err
+	})
+}
+
+func (cont *Controller) ReadPractitioner() echo.HandlerFunc {
+	return cont.read("Practitioner", func(id string) (fhir.Resource, error) {
+		var res, err = cont.r.GetPractitioner(id)
+		return fhir.FromDoctor(res), err
+	})
+}
+
+func (cont *Controller) SearchPractitioner() echo.HandlerFunc {
+	return cont.search("Practitioner", func(search repo.Search) ([]fhir.Resource, int64, error) {
+		var res, total, err = cont.r.SearchPractitioners(search)
+		var resources = []fhir.Resource{}
+		for _, v := range res {
+			resources = append(resources, fhir.FromDoctor(v))
+		}
+		return resources, total, err
+	})
+}
+
+func (cont *Controller) ReadEncounter() echo.HandlerFunc {
+	return cont.read("Encounter", func(id string) (fhir.Resource, error) {
+		var res, err = cont.r.GetEncounter(id)
+		return fhir.FromVisit(res), err
+	})
+}
+
+func (cont *Controller) SearchEncounter() echo.HandlerFunc {
+	return cont.search("Encounter", func(search repo.Search) ([]fhir.Resource, int64, error) {
+		var res, total, err = cont.r.SearchEncounters(search)
+		var resources = []fhir.Resource{}
+		for _, v := range res {
+			resources = append(resources, fhir.FromVisit(v))
+		}
+		return resources, total, err
+	})
+}
+
+func (cont *Controller) ReadCondition() echo.HandlerFunc {
+	return cont.read("Condition", func(id string) (fhir.Resource, error) {
+		var res, err = cont.r.GetCondition(id)
+		return fhir.FromDiagnose(res), err
+	})
+}
+
+func (cont *Controller) SearchCondition() echo.HandlerFunc {
+	return cont.search("Condition", func(search repo.Search) ([]fhir.Resource, int64, error) {
+		var res, total, err = cont.r.SearchConditions(search)
+		var resources = []fhir.Resource{}
+		for _, v := range res {
+			resources = append(resources, fhir.FromDiagnose(v))
+		}
+		return resources, total, err
+	})
+}
+
+func (cont *Controller) Import() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can import patient records", nil))
+		}
+
+		var body, err = ioutil.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, MaxBundleSize))
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "bundle is too large", nil))
+		}
+
+		bundle, err := fhir.ParseBundle(body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Import(uid, bundle)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "doctor is not found":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success import bundle", res))
+	}
+}
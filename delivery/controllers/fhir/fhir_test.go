@@ -0,0 +1,235 @@
+package fhir
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/fhir"
+	"be/delivery/middlewares"
+	"be/entities"
+	repo "be/repository/fhir"
+	"be/utils/fhir"
+	"be/utils/fhir/fhirtest"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var visit = entities.Visit{Visit_uid: "patient-1", UpdatedAt: time.Now(), Doctor_uid: "doctor", Patient_uid: "patient", Date: datatypes.Date(time.Now()), Status: "completed", Complaint: "fever", MainDiagnose: "influenza"}
+
+type mockSuccess struct{}
+
+func (m *mockSuccess) GetPatient(id string) (entities.Patient, error) {
+	return entities.Patient{Patient_uid: id, Name: "siti", Nik: "1234567890123456", Gender: "wanita"}, nil
+}
+
+func (m *mockSuccess) SearchPatients(search repo.Search) ([]entities.Patient, int64, error) {
+	return []entities.Patient{{Patient_uid: "patient", Name: "siti"}}, 3, nil
+}
+
+func (m *mockSuccess) GetPractitioner(id string) (entities.Doctor, error) {
+	return entities.Doctor{Doctor_uid: id, Name: "andi"}, nil
+}
+
+func (m *mockSuccess) SearchPractitioners(search repo.Search) ([]entities.Doctor, int64, error) {
+	return []entities.Doctor{{Doctor_uid: "doctor", Name: "andi"}}, 1, nil
+}
+
+func (m *mockSuccess) GetEncounter(id string) (entities.Visit, error) {
+	return visit, nil
+}
+
+func (m *mockSuccess) SearchEncounters(search repo.Search) ([]entities.Visit, int64, error) {
+	return []entities.Visit{visit}, 1, nil
+}
+
+func (m *mockSuccess) GetCondition(id string) (entities.Visit, error) {
+	return visit, nil
+}
+
+func (m *mockSuccess) SearchConditions(search repo.Search) ([]entities.Visit, int64, error) {
+	return []entities.Visit{visit}, 1, nil
+}
+
+func (m *mockSuccess) Import(doctor_uid string, bundle fhir.Import) (fhir.Report, error) {
+	var report = fhir.Report{}
+	for range bundle.Patients {
+		report.Add(fhir.ReportEntry{ResourceType: "Patient", Action: "created"})
+	}
+	return report, nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) GetPatient(id string) (entities.Patient, error) {
+	return entities.Patient{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) SearchPatients(search repo.Search) ([]entities.Patient, int64, error) {
+	return nil, 0, errors.New("")
+}
+
+func (m *mockFail) GetPractitioner(id string) (entities.Doctor, error) {
+	return entities.Doctor{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) SearchPractitioners(search repo.Search) ([]entities.Doctor, int64, error) {
+	return nil, 0, errors.New("")
+}
+
+func (m *mockFail) GetEncounter(id string) (entities.Visit, error) {
+	return entities.Visit{}, errors.New("")
+}
+
+func (m *mockFail) SearchEncounters(search repo.Search) ([]entities.Visit, int64, error) {
+	return nil, 0, errors.New("")
+}
+
+func (m *mockFail) GetCondition(id string) (entities.Visit, error) {
+	return entities.Visit{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) SearchConditions(search repo.Search) ([]entities.Visit, int64, error) {
+	return nil, 0, errors.New("")
+}
+
+func (m *mockFail) Import(doctor_uid string, bundle fhir.Import) (fhir.Report, error) {
+	return fhir.Report{}, errors.New("doctor is not found")
+}
+
+func request(t *testing.T, query string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodGet, "/"+query, nil)
+	var res = httptest.NewRecorder()
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("id")
+	context.SetParamValues("patient")
+
+	if err := handler(context); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, MIMEFhirJson, res.Header().Get(echo.HeaderContentType))
+	fhirtest.Validate(t, res.Body.Bytes())
+	return res
+}
+
+func TestRead(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		for _, handler := range []echo.HandlerFunc{controller.ReadPatient(), controller.ReadPractitioner(), controller.ReadEncounter(), controller.ReadCondition()} {
+			assert.Equal(t, 200, request(t, "", handler).Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, 404, request(t, "", controller.ReadPatient()).Code)
+		assert.Equal(t, 404, request(t, "", controller.ReadCondition()).Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, 500, request(t, "", controller.ReadEncounter()).Code)
+	})
+}
+
+func TestSearch(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		for _, handler := range []echo.HandlerFunc{controller.SearchPatient(), controller.SearchPractitioner(), controller.SearchEncounter(), controller.SearchCondition()} {
+			assert.Equal(t, 200, request(t, "?_count=1", handler).Code)
+		}
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var res = request(t, "?name=siti&_count=1", controller.SearchPatient())
+
+		var bundle map[string]interface{}
+		json.Unmarshal(res.Body.Bytes(), &bundle)
+		assert.Equal(t, float64(3), bundle["total"])
+		assert.Equal(t, 2, len(bundle["link"].([]interface{})))
+	})
+
+	t.Run("invalid parameter", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 400, request(t, "?birthdate=05-05-1990", controller.SearchPatient()).Code)
+		assert.Equal(t, 400, request(t, "?identifier=1", controller.SearchPractitioner()).Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, 500, request(t, "", controller.SearchEncounter()).Code)
+	})
+}
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+func importRequest(t *testing.T, body []byte, kind string, handler echo.HandlerFunc) ResponseFormat {
+	var token, err = middlewares.GenerateToken("doctor", kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(body))
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", "application/fhir+json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		t.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestImport(t *testing.T) {
+	var body, err = ioutil.ReadFile("../../../utils/fhir/testdata/bundle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = importRequest(t, body, "doctor", controller.Import())
+		assert.Equal(t, 200, response.Code)
+		assert.Equal(t, float64(1), response.Data.(map[string]interface{})["created"])
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 401, importRequest(t, body, "patient", controller.Import()).Code)
+	})
+
+	t.Run("not a bundle", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = importRequest(t, []byte(`{"resourceType": "Patient"}`), "doctor", controller.Import())
+		assert.Equal(t, "resource is not a bundle", response.Message)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, "doctor is not found", importRequest(t, body, "doctor", controller.Import()).Message)
+	})
+}
@@ -0,0 +1,92 @@
+package files
+
+import (
+	"be/api/storage"
+	"be/delivery/controllers/templates"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// Controller serves the files of a storage driver without urls of its own,
+// with no driver every file is not found
+
+type Controller struct {
+	s storage.Server
+}
+
+func New(s storage.Server) *Controller {
+	return &Controller{
+		s: s,
+	}
+}
+
+func (cont *Controller) Public() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if cont.s == nil {
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "file is not found", nil))
+		}
+
+		key, err := url.PathUnescape(c.Param("*"))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "file is not found", nil))
+		}
+
+		// storage
+
+		file, err := cont.s.Open(key)
+		return serve(c, file, err)
+	}
+}
+
+// Private serves the signed urls of the private files
+
+func (cont *Controller) Private() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if cont.s == nil {
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "file is not found", nil))
+		}
+
+		key, err := url.PathUnescape(c.Param("*"))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "file is not found", nil))
+		}
+
+		// storage
+
+		file, err := cont.s.OpenSigned(key, c.QueryParam("expires"), c.QueryParam("signature"))
+		return serve(c, file, err)
+	}
+}
+
+func serve(c echo.Context, file *os.File, err error) error {
+	if err != nil {
+		switch err.Error() {
+		case "file is not found":
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, err.Error(), nil))
+		case "invalid signature", "url is expired":
+			return c.JSON(http.StatusForbidden, templates.BadRequest(http.StatusForbidden, err.Error(), nil))
+		default:
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		log.Warn(err)
+		return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+	}
+
+	// the files are uploaded by the users, they are never run as a page of the app
+
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	c.Response().Header().Set("Content-Security-Policy", "sandbox")
+
+	http.ServeContent(c.Response(), c.Request(), info.Name(), info.ModTime(), file)
+	return nil
+}
@@ -0,0 +1,88 @@
+package files
+
+import (
+	"be/api/storage"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func request(target string, cont *Controller) *httptest.ResponseRecorder {
+	var e = echo.New()
+	e.GET("/files/private/*", cont.Private())
+	e.GET("/files/*", cont.Public())
+
+	var res = httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, target, nil))
+	return res
+}
+
+func message(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestFiles(t *testing.T) {
+	var local, err = storage.NewLocal(t.TempDir(), "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := local.UploadPrivateReader("attachments/visit/report.pdf", "application/pdf", bytes.NewReader([]byte("%PDF-1.4"))); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("success public", func(t *testing.T) {
+		var key = "photos/key/large.jpg"
+		assert.Nil(t, local.Put(key, "image/jpeg", strings.NewReader("image")))
+		assert.Equal(t, "http://localhost:8080/files/"+key, local.Url(key))
+
+		var res = request("/files/"+key, New(local))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "image", res.Body.String())
+	})
+
+	t.Run("success private", func(t *testing.T) {
+		var signed, _ = local.SignedUrl("attachments/visit/report.pdf", time.Minute)
+		var res = request(strings.TrimPrefix(signed, "http://localhost:8080"), New(local))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "%PDF-1.4", res.Body.String())
+		assert.Equal(t, "sandbox", res.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		var signed, _ = local.SignedUrl("attachments/visit/report.pdf", time.Minute)
+		var res = request(strings.TrimPrefix(strings.Replace(signed, "signature=", "signature=0", 1), "http://localhost:8080"), New(local))
+		assert.Equal(t, 403, message(res).Code)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		var signed, _ = local.SignedUrl("attachments/visit/report.pdf", -time.Minute)
+		var res = request(strings.TrimPrefix(signed, "http://localhost:8080"), New(local))
+		assert.Equal(t, "url is expired", message(res).Message)
+	})
+
+	t.Run("private file is not public", func(t *testing.T) {
+		var res = request("/files/attachments/visit/report.pdf", New(local))
+		assert.Equal(t, 404, message(res).Code)
+	})
+
+	t.Run("leaving the directory", func(t *testing.T) {
+		var res = request("/files/"+url.PathEscape("../private/attachments/visit/report.pdf"), New(local))
+		assert.Equal(t, 404, message(res).Code)
+	})
+
+	t.Run("no local storage", func(t *testing.T) {
+		var res = request("/files/key", New(nil))
+		assert.Equal(t, "file is not found", message(res).Message)
+	})
+}
@@ -0,0 +1,24 @@
+package freebusy
+
+import "be/utils/busy"
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+type CalDavReq struct {
+	Url      string `json:"url" form:"url" validate:"required,url"`
+	Username string `json:"username" form:"username" validate:"required"`
+	Password string `json:"password" form:"password" validate:"required"`
+}
+
+// Day is the availability of the doctor on a day, busy is the busy times in
+// the clinic hours
+
+type Day struct {
+	Date      string          `json:"date"`
+	Available bool            `json:"available"`
+	Busy      []busy.Interval `json:"busy"`
+}
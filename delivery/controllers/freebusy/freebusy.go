@@ -0,0 +1,218 @@
+package freebusy
+
+import (
+	"be/api/calendar"
+	"be/delivery/controllers/templates"
+	job "be/delivery/jobs/freebusy"
+	"be/delivery/middlewares"
+	"be/entities"
+	repo "be/repository/calendar"
+	"be/utils/busy"
+	"be/utils/crypt"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const layout = "02-01-2006"
+
+// the availability shows two weeks unless asked, two months at most
+
+const (
+	defaultDays = 14
+	maxDays     = 62
+)
+
+type Controller struct {
+	r      repo.Calendar
+	busy   job.Refresher
+	cipher crypt.Cipher
+	caldav func(collection, username, password string) (*calendar.CalDav, error)
+}
+
+func New(r repo.Calendar, busy job.Refresher, cipher crypt.Cipher) *Controller {
+	return &Controller{
+		r:      r,
+		busy:   busy,
+		cipher: cipher,
+		caldav: calendar.NewPublicCalDav,
+	}
+}
+
+// Availability is the days the doctor can be booked, a day is not available
+// when the busy times imported from the calendar of the doctor cover the
+// clinic hours
+func (cont *Controller) Availability() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var doctor_uid = c.Param("doctor_uid")
+
+		var now = time.Now()
+		var from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		var to = from.AddDate(0, 0, defaultDays-1)
+		var err error
+
+		if value := c.QueryParam("from"); value != "" {
+			if from, err = time.ParseInLocation(layout, value, time.Local); err != nil {
+				return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid from", nil))
+			}
+			to = from.AddDate(0, 0, defaultDays-1)
+		}
+
+		if value := c.QueryParam("to"); value != "" {
+			if to, err = time.ParseInLocation(layout, value, time.Local); err != nil {
+				return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid to", nil))
+			}
+		}
+
+		if to.Before(from) || to.After(from.AddDate(0, 0, maxDays-1)) {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "to must be after from and at most 62 days later", nil))
+		}
+
+		// database
+
+		var start, _ = busy.Hours(from)
+		var _, end = busy.Hours(to)
+
+		res, err := cont.r.GetBusy(doctor_uid, start, end)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		var intervals = busy.Merge(busy.FromTimes(res))
+		var days = []Day{}
+
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			var open, close = busy.Hours(date)
+			var day = Day{Date: date.Format(layout), Busy: []busy.Interval{}}
+
+			for _, interval := range intervals {
+				if !interval.Start.Before(close) || !interval.End.After(open) {
+					continue
+				}
+				if interval.Start.Before(open) {
+					interval.Start = open
+				}
+				if interval.End.After(close) {
+					interval.End = close
+				}
+				day.Busy = append(day.Busy, interval)
+			}
+
+			day.Available = !busy.Covers(day.Busy, open, close)
+			days = append(days, day)
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get availability", days))
+	}
+}
+
+// Push is the receiver of the push channels of google, a change in the
+// calendar of the doctor imports the busy times again
+func (cont *Controller) Push() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var header = c.Request().Header
+
+		// database
+
+		res, err := cont.r.GetChannel(header.Get("X-Goog-Channel-ID"))
+		if err != nil || subtle.ConstantTimeCompare([]byte(res.Channel_token), []byte(job.Hash(header.Get("X-Goog-Channel-Token")))) != 1 {
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "channel is not found", nil))
+		}
+
+		// the first push only tells the channel is open
+
+		if header.Get("X-Goog-Resource-State") != "sync" {
+			cont.busy.Refresh(res.Doctor_uid)
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success receive push", nil))
+	}
+}
+
+// Refresh imports the busy times of the doctor now, e.g. after blocking a
+// day in a caldav calendar
+func (cont *Controller) Refresh() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can refresh the busy times", nil))
+		}
+
+		// database
+
+		res, err := cont.r.GetConnection(uid)
+		if err != nil || res.Status != "connected" {
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "calendar is not connected", nil))
+		}
+
+		cont.busy.Refresh(uid)
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success refresh busy times", nil))
+	}
+}
+
+// ConnectCalDav connects the caldav calendar of the doctor, the login is
+// tried on a free/busy query before it is sealed
+func (cont *Controller) ConnectCalDav() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can connect caldav calendar", nil))
+		}
+
+		var req CalDavReq
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		// caldav
+
+		caldav, err := cont.caldav(req.Url, req.Username, req.Password)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid url", nil))
+		}
+
+		var now = time.Now()
+		if _, err := caldav.Busy(uid, now, now.Add(24*time.Hour)); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "caldav calendar can't be read", nil))
+		}
+
+		plain, _ := json.Marshal(calendar.Credential{Username: req.Username, Password: req.Password})
+		sealed, err := cont.cipher.Encrypt(plain)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		// database
+
+		if err := cont.r.SetConnection(entities.CalendarConnection{Doctor_uid: uid, Provider: "caldav", Email: req.Username, Calendar_id: req.Url, Token: sealed}); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		cont.busy.Refresh(uid)
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success connect caldav calendar", map[string]interface{}{
+			"url":      req.Url,
+			"username": req.Username,
+		}))
+	}
+}
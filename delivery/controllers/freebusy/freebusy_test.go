@@ -0,0 +1,360 @@
+package freebusy
+
+import (
+	api "be/api/calendar"
+	"be/configs"
+	"be/delivery/jobs/freebusy"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/calendar"
+	"be/repository/visit"
+	"be/utils/crypt"
+	"be/utils/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockRepo struct {
+	conns map[string]entities.CalendarConnection
+	busy  []entities.BusyTime
+	fail  bool
+}
+
+func (m *mockRepo) SetFeed(owner_uid, kind, token_hash string) error {
+	return nil
+}
+
+func (m *mockRepo) DeleteFeed(owner_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetFeed(token_hash string) (entities.CalendarFeed, error) {
+	return entities.CalendarFeed{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvent(visit_uid string) (visit.VisitCalendar, error) {
+	return visit.VisitCalendar{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) CreateState(state entities.OauthState) error {
+	return nil
+}
+
+func (m *mockRepo) TakeState(state_hash string) (entities.OauthState, error) {
+	return entities.OauthState{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) SetConnection(conn entities.CalendarConnection) error {
+	conn.Status = "connected"
+	m.conns[conn.Doctor_uid] = conn
+	return nil
+}
+
+func (m *mockRepo) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+	var conn, ok = m.conns[doctor_uid]
+	if !ok {
+		return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+	}
+	return conn, nil
+}
+
+func (m *mockRepo) ExpireConnection(doctor_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) DeleteConnection(doctor_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetDue(now time.Time, limit int) ([]entities.CalendarSync, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Claim(id uint, now time.Time) (entities.CalendarSync, error) {
+	return entities.CalendarSync{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) Finish(id uint) error {
+	return nil
+}
+
+func (m *mockRepo) Retry(id uint, next_at time.Time, message string) error {
+	return nil
+}
+
+func (m *mockRepo) Bury(id uint, message string) error {
+	return nil
+}
+
+func (m *mockRepo) GetClinic(admin_uid string) (string, error) {
+	return "", nil
+}
+
+func (m *mockRepo) GetDead(doctor_uid string, q list.Query) ([]calendar.DeadSync, list.Page, error) {
+	return nil, list.Page{}, nil
+}
+
+func (m *mockRepo) Resync(doctor_uid, visit_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetSyncVisit(visit_uid string) (calendar.SyncVisit, error) {
+	return calendar.SyncVisit{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) SetEvent(visit_uid, event_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetConnections() ([]entities.CalendarConnection, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error {
+	return nil
+}
+
+func (m *mockRepo) GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error) {
+	if m.fail {
+		return nil, errors.New("")
+	}
+	return m.busy, nil
+}
+
+func (m *mockRepo) SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error {
+	return nil
+}
+
+func (m *mockRepo) GetChannel(channel_id string) (entities.CalendarConnection, error) {
+	for _, conn := range m.conns {
+		if conn.Channel_id == channel_id {
+			return conn, nil
+		}
+	}
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+type mockBusy struct {
+	refreshed []string
+}
+
+func (m *mockBusy) Refresh(doctor_uid string) {
+	m.refreshed = append(m.refreshed, doctor_uid)
+}
+
+func request(t *testing.T, uid, kind, method, target, body string, header map[string]string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var e = echo.New()
+	var req = httptest.NewRequest(method, target, strings.NewReader(body))
+	var res = httptest.NewRecorder()
+
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("doctor_uid")
+	context.SetParamValues("doctor")
+
+	if uid == "" {
+		if err := handler(context); err != nil {
+			log.Fatal(err)
+		}
+		return res
+	}
+
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestAvailability(t *testing.T) {
+	var day = time.Date(2022, 3, 10, 0, 0, 0, 0, time.Local)
+	var r = &mockRepo{busy: []entities.BusyTime{
+		{StartAt: day.Add(6 * time.Hour), EndAt: day.Add(12 * time.Hour)},
+		{StartAt: day.Add(12 * time.Hour), EndAt: day.Add(20 * time.Hour)},
+		{StartAt: day.Add(33 * time.Hour), EndAt: day.Add(34 * time.Hour)},
+	}}
+
+	t.Run("success", func(t *testing.T) {
+		var res = response(request(t, "patient", "patient", http.MethodGet, "/?from=10-03-2022&to=11-03-2022", "", nil, New(r, &mockBusy{}, nil).Availability()))
+		assert.Equal(t, 200, res.Code)
+
+		var days = res.Data.([]interface{})
+		assert.Equal(t, 2, len(days))
+
+		// the whole clinic hours are busy
+
+		assert.Equal(t, "10-03-2022", days[0].(map[string]interface{})["date"])
+		assert.Equal(t, false, days[0].(map[string]interface{})["available"])
+		assert.Equal(t, 1, len(days[0].(map[string]interface{})["busy"].([]interface{})))
+
+		assert.Equal(t, true, days[1].(map[string]interface{})["available"])
+		assert.Equal(t, 1, len(days[1].(map[string]interface{})["busy"].([]interface{})))
+	})
+
+	t.Run("error range", func(t *testing.T) {
+		assert.Equal(t, 400, request(t, "patient", "patient", http.MethodGet, "/?from=2022-03-10", "", nil, New(r, &mockBusy{}, nil).Availability()).Code)
+		assert.Equal(t, 400, request(t, "patient", "patient", http.MethodGet, "/?from=10-03-2022&to=09-03-2022", "", nil, New(r, &mockBusy{}, nil).Availability()).Code)
+		assert.Equal(t, 400, request(t, "patient", "patient", http.MethodGet, "/?from=10-03-2022&to=10-06-2022", "", nil, New(r, &mockBusy{}, nil).Availability()).Code)
+	})
+
+	t.Run("error database", func(t *testing.T) {
+		assert.Equal(t, 500, request(t, "patient", "patient", http.MethodGet, "/", "", nil, New(&mockRepo{fail: true}, &mockBusy{}, nil).Availability()).Code)
+	})
+}
+
+func TestPush(t *testing.T) {
+	var r = &mockRepo{conns: map[string]entities.CalendarConnection{
+		"doctor": {Doctor_uid: "doctor", Channel_id: "channel", Channel_token: freebusy.Hash("token")},
+	}}
+
+	var push = func(busy *mockBusy, id, token, state string) int {
+		return request(t, "", "", http.MethodPost, "/", "", map[string]string{
+			"X-Goog-Channel-ID":     id,
+			"X-Goog-Channel-Token":  token,
+			"X-Goog-Resource-State": state,
+		}, New(r, busy, nil).Push()).Code
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var busy = &mockBusy{}
+		assert.Equal(t, 200, push(busy, "channel", "token", "sync"))
+		assert.Equal(t, 0, len(busy.refreshed))
+
+		assert.Equal(t, 200, push(busy, "channel", "token", "exists"))
+		assert.Equal(t, []string{"doctor"}, busy.refreshed)
+	})
+
+	t.Run("error channel", func(t *testing.T) {
+		var busy = &mockBusy{}
+		assert.Equal(t, 404, push(busy, "channel", "guess", "exists"))
+		assert.Equal(t, 404, push(busy, "old", "token", "exists"))
+		assert.Equal(t, 404, push(busy, "", "", "exists"))
+		assert.Equal(t, 0, len(busy.refreshed))
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	var r = &mockRepo{conns: map[string]entities.CalendarConnection{
+		"doctor":  {Doctor_uid: "doctor", Status: "connected"},
+		"expired": {Doctor_uid: "expired", Status: "expired"},
+	}}
+
+	t.Run("success", func(t *testing.T) {
+		var busy = &mockBusy{}
+		assert.Equal(t, 202, request(t, "doctor", "doctor", http.MethodPost, "/", "", nil, New(r, busy, nil).Refresh()).Code)
+		assert.Equal(t, []string{"doctor"}, busy.refreshed)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		assert.Equal(t, 401, request(t, "patient", "patient", http.MethodPost, "/", "", nil, New(r, &mockBusy{}, nil).Refresh()).Code)
+		assert.Equal(t, 404, request(t, "expired", "doctor", http.MethodPost, "/", "", nil, New(r, &mockBusy{}, nil).Refresh()).Code)
+		assert.Equal(t, 404, request(t, "other", "doctor", http.MethodPost, "/", "", nil, New(r, &mockBusy{}, nil).Refresh()).Code)
+	})
+}
+
+func TestConnectCalDav(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "budi" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body, _ = io.ReadAll(r.Body)
+		if r.Method != "REPORT" || !strings.Contains(string(body), "free-busy-query") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write([]byte("BEGIN:VCALENDAR\r\nBEGIN:VFREEBUSY\r\nEND:VFREEBUSY\r\nEND:VCALENDAR\r\n"))
+	}))
+	defer server.Close()
+
+	var cipher, _ = crypt.New("secret")
+	var body = func(url, password string) string {
+		return `{"url":"` + url + `","username":"budi","password":"` + password + `"}`
+	}
+
+	// the test server is on loopback, which a doctor can't connect
+
+	var local = func(cont *Controller) *Controller {
+		cont.caldav = func(collection, username, password string) (*api.CalDav, error) {
+			return api.NewCalDav(collection, username, password), nil
+		}
+		return cont
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var r = &mockRepo{conns: map[string]entities.CalendarConnection{}}
+		var busy = &mockBusy{}
+
+		var res = request(t, "doctor", "doctor", http.MethodPost, "/", body(server.URL+"/calendars/budi/personal/", "secret"), nil, local(New(r, busy, cipher)).ConnectCalDav())
+		assert.Equal(t, 201, res.Code)
+		assert.Equal(t, []string{"doctor"}, busy.refreshed)
+
+		// the password is sealed
+
+		var conn = r.conns["doctor"]
+		assert.Equal(t, "caldav", conn.Provider)
+		assert.Equal(t, server.URL+"/calendars/budi/personal/", conn.Calendar_id)
+		assert.NotContains(t, conn.Token, "secret")
+		var plain, _ = cipher.Decrypt(conn.Token)
+		assert.Contains(t, string(plain), `"password":"secret"`)
+	})
+
+	t.Run("error login", func(t *testing.T) {
+		var r = &mockRepo{conns: map[string]entities.CalendarConnection{}}
+		var res = response(request(t, "doctor", "doctor", http.MethodPost, "/", body(server.URL, "wrong"), nil, local(New(r, &mockBusy{}, cipher)).ConnectCalDav()))
+		assert.Equal(t, "caldav calendar can't be read", res.Message)
+		assert.Equal(t, 0, len(r.conns))
+	})
+
+	t.Run("error input", func(t *testing.T) {
+		var r = &mockRepo{conns: map[string]entities.CalendarConnection{}}
+		assert.Equal(t, 401, request(t, "patient", "patient", http.MethodPost, "/", body(server.URL, "secret"), nil, New(r, &mockBusy{}, cipher).ConnectCalDav()).Code)
+		assert.Equal(t, 400, request(t, "doctor", "doctor", http.MethodPost, "/", body("ftp://calendar", "secret"), nil, New(r, &mockBusy{}, cipher).ConnectCalDav()).Code)
+		assert.Equal(t, 400, request(t, "doctor", "doctor", http.MethodPost, "/", `{"url":"`+server.URL+`"}`, nil, New(r, &mockBusy{}, cipher).ConnectCalDav()).Code)
+	})
+
+	t.Run("error address not public", func(t *testing.T) {
+		var r = &mockRepo{conns: map[string]entities.CalendarConnection{}}
+		for _, url := range []string{server.URL, "https://127.0.0.1/dav", "https://localhost/dav", "https://10.0.0.5/dav", "https://169.254.169.254/latest"} {
+			var res = response(request(t, "doctor", "doctor", http.MethodPost, "/", body(url, "secret"), nil, New(r, &mockBusy{}, cipher).ConnectCalDav()))
+			assert.Equal(t, "invalid url", res.Message, url)
+		}
+		assert.Equal(t, 0, len(r.conns))
+	})
+}
@@ -11,3 +11,9 @@ type UserInfo struct {
 	EmailVerified bool   `json:"email_verified"`
 	Gender        string `json:"gender"`
 }
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
@@ -1,108 +1,282 @@
 package google
 
 import (
-	"be/api"
 	"be/configs"
 	"be/delivery/controllers/templates"
-	"be/repository/visit"
+	"be/delivery/jobs/freebusy"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/calendar"
+	"be/utils/crypt"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
 	"golang.org/x/oauth2"
 )
 
+// a started login has to come back to the callback in time
+
+const stateTTL = 10 * time.Minute
+
 type Controller struct {
-	r    visit.Visit
-	conf *oauth2.Config
+	r         calendar.Calendar
+	conf      *oauth2.Config
+	cipher    crypt.Cipher
+	busy      freebusy.Refresher
+	userInfo  string
+	revokeUrl string
 }
 
-func New(conf *oauth2.Config, r visit.Visit) *Controller {
+func New(conf *oauth2.Config, r calendar.Calendar, cipher crypt.Cipher, busy freebusy.Refresher) *Controller {
 	return &Controller{
-		conf: conf,
-		r:    r,
+		conf:      conf,
+		r:         r,
+		cipher:    cipher,
+		busy:      busy,
+		userInfo:  configs.OauthGoogleUrlAPI,
+		revokeUrl: configs.OauthGoogleRevokeUrl,
 	}
 }
 
+// GoogleLogin gives the doctor the url of the google consent, the state and
+// pkce verifier of the login are kept until the callback
 func (cont *Controller) GoogleLogin() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// var oauthState = api.GenerateStateOauthCookie(c.Response().Writer)
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can connect google calendar", nil))
+		}
+
+		var state, err = random()
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		verifier, err := random()
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
 
-		// var oauthState, err = api.GenerateCookie(c)
-		// if err != nil {
-		// 	return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "error get cookie "+ err.Error(), nil))
-		// }
+		sealed, err := cont.cipher.Encrypt([]byte(verifier))
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
 
-		// log.Info(oauthState)
-		// var url = cont.conf.AuthCodeURL("randomstate", oauth2.AccessTypeOffline)
-		// var url = cont.conf.AuthCodeURL( /* oauthState */ "randomstate")
-		var url = cont.conf.AuthCodeURL("randomstate", oauth2.AccessTypeOffline)
+		// database
 
-		// log.Info(url)
-		res := c.Redirect(http.StatusSeeOther, url)
-		// log.Info(res)
-		if res != nil {
-			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "error Redirect to sign in "+res.Error(), nil))
+		if err := cont.r.CreateState(entities.OauthState{State_hash: hash(state), Doctor_uid: uid, Verifier: sealed, ExpiresAt: time.Now().Add(stateTTL)}); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
 		}
 
-		return c.JSON(http.StatusOK, templates.Success(nil, "success redirect to sign in", nil))
+		var challenge = sha256.Sum256([]byte(verifier))
+
+		// the consent is asked every time, so google gives a refresh token
+
+		var url = cont.conf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce,
+			oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+			oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+		)
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get google sign in url", map[string]interface{}{
+			"url": url,
+		}))
 	}
 }
 
+// GoogleCalendar is the callback of the google consent, the state tells the
+// doctor of the login
 func (cont *Controller) GoogleCalendar() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// var ctx = context.Background()
-		// state := c.Request().URL.Query()["state"][0]
-		// if state != "state" {
-		// 	return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "error cookie "+state, nil))
-		// }
-
-		// var code = c.FormValue("code")
-
-		// _, token, err := api.GetUserDataFromGoogle(code, cont.conf)
-		// if err != nil {
-		// 	return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "error get user profile info "+err.Error(), nil))
-		// }
-
-		// api.CacheToken(token, "./secret/token.json")
-
-		// token, err = api.TokenFromFile("./secret/token.json")
-		// if err != nil {
-		// 	log.Warn(err)
-		// }
-		
-		token, err := api.TokenFromFile(configs.TokenPath, cont.conf)
+		var state, code = c.QueryParam("state"), c.QueryParam("code")
+
+		if c.QueryParam("error") != "" {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(http.StatusBadRequest, "google sign in is cancelled", nil))
+		}
+
+		// database
+
+		res, err := cont.r.TakeState(hash(state))
+		if err != nil || code == "" {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(http.StatusBadRequest, "invalid or expired state", nil))
+		}
+
+		verifier, err := cont.cipher.Decrypt(res.Verifier)
 		if err != nil {
 			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(http.StatusBadRequest, "invalid or expired state", nil))
 		}
 
-		// var conf = cont.conf.Client(context.Background(), tokenNew)
+		// google
+
+		token, err := cont.conf.Exchange(context.Background(), code, oauth2.SetAuthURLParam("code_verifier", string(verifier)))
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(http.StatusBadRequest, "google sign in is refused", nil))
+		}
+
+		if token.RefreshToken == "" {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(http.StatusBadRequest, "google gave no offline access, connect again", nil))
+		}
 
-		response, err := http.Get(configs.OauthGoogleUrlAPI + token.AccessToken)
+		user, err := cont.user(token)
 		if err != nil {
 			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "error get google account", nil))
 		}
-		// defer response.Body.Close()
-		contents, err := ioutil.ReadAll(response.Body)
+
+		plain, _ := json.Marshal(token)
+		sealed, err := cont.cipher.Encrypt(plain)
 		if err != nil {
 			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
 		}
 
-		var user UserInfo
+		// database
 
-		if err := json.Unmarshal(contents, &user); err != nil {
-			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "error convert to struct "+err.Error(), nil))
+		if err := cont.r.SetConnection(entities.CalendarConnection{Doctor_uid: res.Doctor_uid, Provider: "google", Email: user.Email, Calendar_id: "primary", Token: sealed}); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
 		}
-		// api.CacheToken(token)
-		log.Info(user)
 
-		var res = api.Calendar(configs.TokenPath, cont.conf)
+		cont.busy.Refresh(res.Doctor_uid)
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success connect google calendar", map[string]interface{}{
+			"email": user.Email,
+		}))
+	}
+}
+
+// Status is the google account or caldav calendar of the doctor, expired
+// when the token was refused and the doctor has to connect again
+func (cont *Controller) Status() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetConnection(uid)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "google calendar is not connected", nil))
+		}
 
-		return c.JSON(http.StatusOK, templates.Success(nil, "success run calendar", map[string]interface{}{
-			"user":     user,
-			"calendar": res,
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get google calendar", map[string]interface{}{
+			"provider":  res.Provider,
+			"email":     res.Email,
+			"status":    res.Status,
+			"expiredAt": res.ExpiredAt,
 		}))
 	}
 }
+
+// Disconnect revokes the token at google and removes it with the busy times,
+// the next visits go to the shared calendar
+func (cont *Controller) Disconnect() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetConnection(uid)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "google calendar is not connected", nil))
+		}
+
+		// google, a token already refused needs no revoke
+
+		if res.Provider == "google" {
+			if err := cont.revoke(res.Token); err != nil {
+				log.Warn(err)
+			}
+		}
+
+		if err := cont.r.DeleteConnection(uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success disconnect google calendar", nil))
+	}
+}
+
+func (cont *Controller) user(token *oauth2.Token) (UserInfo, error) {
+	var res, err = http.Get(cont.userInfo + url.QueryEscape(token.AccessToken))
+	if err != nil {
+		return UserInfo{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return UserInfo{}, fmt.Errorf("google user info: %v", res.Status)
+	}
+
+	contents, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	var user UserInfo
+	if err := json.Unmarshal(contents, &user); err != nil {
+		return UserInfo{}, err
+	}
+
+	return user, nil
+}
+
+func (cont *Controller) revoke(sealed string) error {
+	var plain, err = cont.cipher.Decrypt(sealed)
+	if err != nil {
+		return err
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(plain, &token); err != nil {
+		return err
+	}
+
+	res, err := http.Post(cont.revokeUrl, "application/x-www-form-urlencoded", strings.NewReader(url.Values{"token": {token.RefreshToken}}.Encode()))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("google revoke: %v", res.Status)
+	}
+
+	return nil
+}
+
+// random is 256 bits for the state and the pkce verifier
+func random() (string, error) {
+	var b = make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hash(state string) string {
+	var sum = sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
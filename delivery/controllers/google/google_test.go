@@ -0,0 +1,298 @@
+package google
+
+import (
+	"be/configs"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/calendar"
+	"be/repository/visit"
+	"be/utils/crypt"
+	"be/utils/list"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+type mockRepo struct {
+	states  map[string]entities.OauthState
+	conns   map[string]entities.CalendarConnection
+	revoked []string
+}
+
+func (m *mockRepo) SetFeed(owner_uid, kind, token_hash string) error {
+	return nil
+}
+
+func (m *mockRepo) DeleteFeed(owner_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetFeed(token_hash string) (entities.CalendarFeed, error) {
+	return entities.CalendarFeed{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvent(visit_uid string) (visit.VisitCalendar, error) {
+	return visit.VisitCalendar{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) CreateState(state entities.OauthState) error {
+	m.states[state.State_hash] = state
+	return nil
+}
+
+func (m *mockRepo) TakeState(state_hash string) (entities.OauthState, error) {
+	var state, ok = m.states[state_hash]
+	if !ok || state.ExpiresAt.Before(time.Now()) {
+		return entities.OauthState{}, gorm.ErrRecordNotFound
+	}
+	delete(m.states, state_hash)
+	return state, nil
+}
+
+func (m *mockRepo) SetConnection(conn entities.CalendarConnection) error {
+	conn.Status = "connected"
+	m.conns[conn.Doctor_uid] = conn
+	return nil
+}
+
+func (m *mockRepo) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+	var conn, ok = m.conns[doctor_uid]
+	if !ok {
+		return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+	}
+	return conn, nil
+}
+
+func (m *mockRepo) ExpireConnection(doctor_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) DeleteConnection(doctor_uid string) error {
+	if _, ok := m.conns[doctor_uid]; !ok {
+		return errors.New("record not found")
+	}
+	delete(m.conns, doctor_uid)
+	return nil
+}
+
+func (m *mockRepo) GetDue(now time.Time, limit int) ([]entities.CalendarSync, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Claim(id uint, now time.Time) (entities.CalendarSync, error) {
+	return entities.CalendarSync{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) Finish(id uint) error {
+	return nil
+}
+
+func (m *mockRepo) Retry(id uint, next_at time.Time, message string) error {
+	return nil
+}
+
+func (m *mockRepo) Bury(id uint, message string) error {
+	return nil
+}
+
+func (m *mockRepo) GetClinic(admin_uid string) (string, error) {
+	return "", nil
+}
+
+func (m *mockRepo) GetDead(doctor_uid string, q list.Query) ([]calendar.DeadSync, list.Page, error) {
+	return nil, list.Page{}, nil
+}
+
+func (m *mockRepo) Resync(doctor_uid, visit_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetSyncVisit(visit_uid string) (calendar.SyncVisit, error) {
+	return calendar.SyncVisit{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) SetEvent(visit_uid, event_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetConnections() ([]entities.CalendarConnection, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error {
+	return nil
+}
+
+func (m *mockRepo) GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error {
+	return nil
+}
+
+func (m *mockRepo) GetChannel(channel_id string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+type mockBusy struct {
+	refreshed []string
+}
+
+func (m *mockBusy) Refresh(doctor_uid string) {
+	m.refreshed = append(m.refreshed, doctor_uid)
+}
+
+func request(t *testing.T, uid, kind, query string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	var res = httptest.NewRecorder()
+	var context = e.NewContext(req, res)
+
+	if uid == "" {
+		if err := handler(context); err != nil {
+			log.Fatal(err)
+		}
+		return res
+	}
+
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestGoogle(t *testing.T) {
+	var r = &mockRepo{states: map[string]entities.OauthState{}, conns: map[string]entities.CalendarConnection{}}
+	var cipher, _ = crypt.New("secret")
+
+	var verifier string
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r1 *http.Request) {
+		r1.ParseForm()
+		switch r1.URL.Path {
+		case "/token":
+			verifier = r1.PostForm.Get("code_verifier")
+			w.Header().Set("Content-Type", "application/json")
+			if r1.PostForm.Get("code") == "nooffline" {
+				w.Write([]byte(`{"access_token":"access","token_type":"Bearer","expires_in":3600}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`))
+		case "/userinfo":
+			w.Write([]byte(`{"email":"budi@gmail.com"}`))
+		case "/revoke":
+			r.revoked = append(r.revoked, r1.PostForm.Get("token"))
+		}
+	}))
+	defer server.Close()
+
+	var conf = &oauth2.Config{ClientID: "client", RedirectURL: "http://localhost/google/callback", Endpoint: oauth2.Endpoint{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token"}}
+	var busy = &mockBusy{}
+	var controller = New(conf, r, cipher, busy)
+	controller.userInfo = server.URL + "/userinfo?access_token="
+	controller.revokeUrl = server.URL + "/revoke"
+
+	var login = func() url.Values {
+		var res = response(request(t, "doctor", "doctor", "", controller.GoogleLogin()))
+		assert.Equal(t, 200, res.Code)
+		var u, _ = url.Parse(res.Data.(map[string]interface{})["url"].(string))
+		return u.Query()
+	}
+
+	t.Run("success connect", func(t *testing.T) {
+		var query = login()
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		assert.Equal(t, "offline", query.Get("access_type"))
+
+		var res = response(request(t, "", "", "state="+query.Get("state")+"&code=code", controller.GoogleCalendar()))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "budi@gmail.com", res.Data.(map[string]interface{})["email"])
+
+		// the verifier matches the challenge of the login
+
+		var sum = sha256.Sum256([]byte(verifier))
+		assert.Equal(t, query.Get("code_challenge"), base64.RawURLEncoding.EncodeToString(sum[:]))
+
+		// the token is sealed
+
+		var conn = r.conns["doctor"]
+		assert.NotContains(t, conn.Token, "refresh")
+		var plain, _ = cipher.Decrypt(conn.Token)
+		assert.Contains(t, string(plain), `"refresh_token":"refresh"`)
+
+		// the busy times are imported right away
+
+		assert.Equal(t, []string{"doctor"}, busy.refreshed)
+	})
+
+	t.Run("error state", func(t *testing.T) {
+		var query = login()
+		assert.Equal(t, 400, request(t, "", "", "state=randomstate&code=code", controller.GoogleCalendar()).Code)
+
+		// only once
+
+		assert.Equal(t, 200, request(t, "", "", "state="+query.Get("state")+"&code=code", controller.GoogleCalendar()).Code)
+		assert.Equal(t, 400, request(t, "", "", "state="+query.Get("state")+"&code=code", controller.GoogleCalendar()).Code)
+	})
+
+	t.Run("error cancelled and no offline access", func(t *testing.T) {
+		var query = login()
+		assert.Equal(t, 400, request(t, "", "", "state="+query.Get("state")+"&error=access_denied", controller.GoogleCalendar()).Code)
+		assert.Equal(t, "google gave no offline access, connect again", response(request(t, "", "", "state="+query.Get("state")+"&code=nooffline", controller.GoogleCalendar())).Message)
+	})
+
+	t.Run("error not doctor", func(t *testing.T) {
+		assert.Equal(t, 401, request(t, "patient", "patient", "", controller.GoogleLogin()).Code)
+	})
+
+	t.Run("status and disconnect", func(t *testing.T) {
+		var res = response(request(t, "doctor", "doctor", "", controller.Status()))
+		assert.Equal(t, "connected", res.Data.(map[string]interface{})["status"])
+
+		assert.Equal(t, 200, request(t, "doctor", "doctor", "", controller.Disconnect()).Code)
+		assert.Equal(t, []string{"refresh"}, r.revoked)
+
+		assert.Equal(t, 404, request(t, "doctor", "doctor", "", controller.Status()).Code)
+		assert.Equal(t, 404, request(t, "doctor", "doctor", "", controller.Disconnect()).Code)
+	})
+
+	t.Run("disconnect caldav needs no revoke", func(t *testing.T) {
+		r.revoked = nil
+		r.conns["doctor"] = entities.CalendarConnection{Doctor_uid: "doctor", Provider: "caldav", Status: "connected"}
+
+		assert.Equal(t, "caldav", response(request(t, "doctor", "doctor", "", controller.Status())).Data.(map[string]interface{})["provider"])
+		assert.Equal(t, 200, request(t, "doctor", "doctor", "", controller.Disconnect()).Code)
+		assert.Equal(t, 0, len(r.revoked))
+	})
+}
@@ -0,0 +1,94 @@
+package hl7
+
+import (
+	"be/delivery/controllers/templates"
+	ingest "be/delivery/hl7"
+	"be/repository/hl7"
+	"be/utils/list"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const MIMEHl7 = "x-application/hl7-v2+er7"
+
+type Controller struct {
+	r      hl7.Hl7
+	ingest ingest.Ingest
+}
+
+func New(r hl7.Hl7, ingest ingest.Ingest) *Controller {
+	return &Controller{
+		r:      r,
+		ingest: ingest,
+	}
+}
+
+// Receive is the http fallback of the mllp listener, the body is the message
+// and the response body is the ACK
+
+func (cont *Controller) Receive() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var body, err = ioutil.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, 1<<20))
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		var ack = cont.ingest.Handle(string(body), "http")
+
+		return c.Blob(http.StatusOK, MIMEHl7, []byte(ack))
+	}
+}
+
+func (cont *Controller) GetMessages() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var q, err = list.Parse(hl7.List, c.QueryParams())
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, page, err := cont.r.GetMessages(q)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get hl7 messages", res, page))
+	}
+}
+
+func (cont *Controller) Replay() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var message_uid = c.Param("message_uid")
+
+		// database
+
+		ack, err := cont.ingest.Replay(message_uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("message is not found")
+			case "message is already processed":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success replay hl7 message", map[string]interface{}{
+			"message_uid": message_uid,
+			"ack":         ack,
+		}))
+	}
+}
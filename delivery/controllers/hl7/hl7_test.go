@@ -0,0 +1,141 @@
+package hl7
+
+import (
+	"be/entities"
+	"be/repository/hl7"
+	"be/utils/list"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	utils "be/utils/hl7"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct{}
+
+func (m *mockSuccess) Store(req entities.Hl7Message) (entities.Hl7Message, error) {
+	return req, nil
+}
+
+func (m *mockSuccess) FindProcessed(sender, control_id string) (entities.Hl7Message, error) {
+	return entities.Hl7Message{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockSuccess) Processed(message_uid, target string) error {
+	return nil
+}
+
+func (m *mockSuccess) Failed(message_uid, reason string) error {
+	return nil
+}
+
+func (m *mockSuccess) GetMessage(message_uid string) (entities.Hl7Message, error) {
+	return entities.Hl7Message{}, nil
+}
+
+func (m *mockSuccess) GetMessages(q list.Query) (hl7.Messages, list.Page, error) {
+	return hl7.Messages{Messages: []hl7.MessageResp{{Message_uid: "message", Status: "failed"}}}, list.Page{Total: 1, Limit: q.Limit}, nil
+}
+
+func (m *mockSuccess) Adt(req entities.Patient) (string, error) {
+	return "patient", nil
+}
+
+func (m *mockSuccess) Oru(orders []utils.Oru) ([]string, error) {
+	return []string{"order"}, nil
+}
+
+type mockFail struct {
+	mockSuccess
+}
+
+func (m *mockFail) GetMessages(q list.Query) (hl7.Messages, list.Page, error) {
+	return hl7.Messages{}, list.Page{}, errors.New("")
+}
+
+type mockIngest struct{}
+
+func (m *mockIngest) Handle(raw, source string) string {
+	return "MSH|^~\\&|||||||ACK^A04^ACK|ACK1|P|2.5\rMSA|AA|1\r"
+}
+
+func (m *mockIngest) Replay(message_uid string) (string, error) {
+	switch message_uid {
+	case "processed":
+		return "", errors.New("message is already processed")
+	case "unknown":
+		return "", gorm.ErrRecordNotFound
+	}
+	return "MSH|^~\\&|||||||ACK^A04^ACK|ACK1|P|2.5\rMSA|AA|1\r", nil
+}
+
+func request(method, body, query, message_uid string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/?"+query, strings.NewReader(body))
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", MIMEHl7)
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("message_uid")
+	context.SetParamValues(message_uid)
+
+	handler(context)
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestReceive(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockIngest{})
+		var res = request(http.MethodPost, "MSH|^~\\&|", "", "", controller.Receive())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, MIMEHl7, res.Header().Get(echo.HeaderContentType))
+		assert.True(t, strings.Contains(res.Body.String(), "MSA|AA"))
+	})
+}
+
+func TestGetMessages(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockIngest{})
+		assert.Equal(t, 200, request(http.MethodGet, "", "status=failed", "", controller.GetMessages()).Code)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockIngest{})
+		assert.Equal(t, 400, request(http.MethodGet, "", "status=unknown", "", controller.GetMessages()).Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, &mockIngest{})
+		assert.Equal(t, 500, request(http.MethodGet, "", "", "", controller.GetMessages()).Code)
+	})
+}
+
+func TestReplay(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockIngest{})
+		assert.Equal(t, 200, request(http.MethodPost, "", "", "message", controller.Replay()).Code)
+	})
+
+	t.Run("processed", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockIngest{})
+		assert.Equal(t, "message is already processed", response(request(http.MethodPost, "", "", "processed", controller.Replay())).Message)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, &mockIngest{})
+		assert.Equal(t, "message is not found", response(request(http.MethodPost, "", "", "unknown", controller.Replay())).Message)
+	})
+}
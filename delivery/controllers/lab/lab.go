@@ -0,0 +1,266 @@
+package lab
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/lab"
+	"be/delivery/middlewares"
+	"be/repository/lab"
+	"be/utils/list"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	r lab.Lab
+	l logic.Lab
+}
+
+func New(r lab.Lab, l logic.Lab) *Controller {
+	return &Controller{
+		r: r,
+		l: l,
+	}
+}
+
+func (cont *Controller) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can order lab test", nil))
+		}
+
+		var req logic.OrderReq
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationOrder(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Create(visit_uid, uid, *req.ToLabOrder())
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("visit is not found")
+			case "visit is cancelled":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add lab order", map[string]interface{}{
+			"order_uid": res.Order_uid,
+		}))
+	}
+}
+
+func (cont *Controller) GetOrders() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		if err := cont.r.CheckAccess(visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+		}
+
+		// database
+
+		res, err := cont.r.GetOrders(visit_uid)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get lab orders", res))
+	}
+}
+
+func (cont *Controller) GetUnseen() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, _ = middlewares.ExtractTokenUid(c)
+		var q, err = list.Parse(lab.List, c.QueryParams())
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, page, err := cont.r.GetUnseen(uid, q)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get new lab results", res, page))
+	}
+}
+
+func (cont *Controller) GetOrder() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var order_uid = c.Param("order_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetOrder(order_uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("order is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		// only the doctor and the patient of the visit read the order
+
+		if err := cont.r.CheckAccess(res.Visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "order is not found", nil))
+		}
+
+		// opening the result clears the notification of the ordering doctor
+
+		if res.Doctor_uid == uid && res.Status == "resulted" && !res.Seen {
+			if err := cont.r.MarkSeen(order_uid, uid); err != nil {
+				log.Warn(err)
+			}
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get lab order", res))
+	}
+}
+
+func (cont *Controller) GetByStatus() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var status = c.QueryParam("status")
+
+		switch status {
+		case "":
+			status = "ordered"
+		case "ordered", "collected", "resulted", "cancelled":
+		default:
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid status input", nil))
+		}
+
+		var q, err = list.Parse(lab.List, c.QueryParams())
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, page, err := cont.r.GetByStatus(status, q)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get lab orders", res, page))
+	}
+}
+
+func (cont *Controller) Collect() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var order_uid = c.Param("order_uid")
+
+		// database
+
+		res, err := cont.r.Collect(order_uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch {
+			case err.Error() == "record not found":
+				err = errors.New("order is not found")
+			case strings.Contains(err.Error(), "order is already"):
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success collect specimen", res.Status))
+	}
+}
+
+func (cont *Controller) AddResults() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var order_uid = c.Param("order_uid")
+
+		var req logic.ResultsReq
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationResults(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		order, err := cont.r.GetOrder(order_uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("order is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		// results only go to an order whose doctor still has the visit
+
+		if err := cont.r.CheckAccess(order.Visit_uid, order.Doctor_uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "order is not found", nil))
+		}
+
+		res, err := cont.r.AddResults(order_uid, req.ToLabResults())
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("order is not found")
+			case "order is already cancelled":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add lab results", map[string]interface{}{
+			"order_uid": res.Order_uid,
+			"results":   len(res.Results),
+		}))
+	}
+}
@@ -0,0 +1,279 @@
+package lab
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/lab"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/lab"
+	"be/utils/list"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct {
+	seen    []string
+	results []entities.LabResult
+	other   bool
+}
+
+// other is a visit of another doctor and patient
+func (m *mockSuccess) CheckAccess(visit_uid, user_uid string) error {
+	if m.other {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (m *mockSuccess) Create(visit_uid, doctor_uid string, req entities.LabOrder) (entities.LabOrder, error) {
+	return entities.LabOrder{Order_uid: "order"}, nil
+}
+
+func (m *mockSuccess) Collect(order_uid string) (entities.LabOrder, error) {
+	return entities.LabOrder{Status: "collected"}, nil
+}
+
+func (m *mockSuccess) AddResults(order_uid string, results []entities.LabResult) (entities.LabOrder, error) {
+	m.results = append(m.results, results...)
+	return entities.LabOrder{Order_uid: order_uid, Results: results}, nil
+}
+
+func (m *mockSuccess) GetOrder(order_uid string) (lab.OrderResp, error) {
+	return lab.OrderResp{Order_uid: order_uid, Doctor_uid: "doctor", Status: "resulted"}, nil
+}
+
+func (m *mockSuccess) GetOrders(visit_uid string) (lab.Orders, error) {
+	return lab.Orders{}, nil
+}
+
+func (m *mockSuccess) GetByStatus(status string, q list.Query) (lab.Orders, list.Page, error) {
+	return lab.Orders{}, list.Page{}, nil
+}
+
+func (m *mockSuccess) GetUnseen(doctor_uid string, q list.Query) (lab.Orders, list.Page, error) {
+	return lab.Orders{}, list.Page{}, nil
+}
+
+func (m *mockSuccess) MarkSeen(order_uid, doctor_uid string) error {
+	m.seen = append(m.seen, order_uid)
+	return nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) CheckAccess(visit_uid, user_uid string) error {
+	return nil
+}
+
+func (m *mockFail) Create(visit_uid, doctor_uid string, req entities.LabOrder) (entities.LabOrder, error) {
+	return entities.LabOrder{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) Collect(order_uid string) (entities.LabOrder, error) {
+	return entities.LabOrder{}, errors.New("order is already resulted")
+}
+
+func (m *mockFail) AddResults(order_uid string, results []entities.LabResult) (entities.LabOrder, error) {
+	return entities.LabOrder{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetOrder(order_uid string) (lab.OrderResp, error) {
+	return lab.OrderResp{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetOrders(visit_uid string) (lab.Orders, error) {
+	return lab.Orders{}, errors.New("")
+}
+
+func (m *mockFail) GetByStatus(status string, q list.Query) (lab.Orders, list.Page, error) {
+	return lab.Orders{}, list.Page{}, errors.New("")
+}
+
+func (m *mockFail) GetUnseen(doctor_uid string, q list.Query) (lab.Orders, list.Page, error) {
+	return lab.Orders{}, list.Page{}, errors.New("")
+}
+
+func (m *mockFail) MarkSeen(order_uid, doctor_uid string) error {
+	return errors.New("")
+}
+
+func request(t *testing.T, method, query string, body interface{}, kind string, handler echo.HandlerFunc) ResponseFormat {
+	var token, err = middlewares.GenerateToken("doctor", kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reqBody, _ = json.Marshal(body)
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/"+query, bytes.NewBuffer(reqBody))
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("visit_uid", "order_uid")
+	context.SetParamValues("visit", "order")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestCreate(t *testing.T) {
+	var body = map[string]interface{}{"tests": []string{"hemoglobin"}}
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, "", body, "doctor", controller.Create())
+		assert.Equal(t, 201, response.Code)
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, "", body, "patient", controller.Create())
+		assert.Equal(t, 401, response.Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, "", map[string]interface{}{"tests": []string{}}, "doctor", controller.Create())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("visit not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		var response = request(t, http.MethodPost, "", body, "doctor", controller.Create())
+		assert.Equal(t, "visit is not found", response.Message)
+	})
+}
+
+func TestGetOrder(t *testing.T) {
+	t.Run("success mark seen by ordering doctor", func(t *testing.T) {
+		var repo = &mockSuccess{}
+		var controller = New(repo, logic.New())
+		var response = request(t, http.MethodGet, "", nil, "doctor", controller.GetOrder())
+		assert.Equal(t, 200, response.Code)
+		assert.Equal(t, []string{"order"}, repo.seen)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		var response = request(t, http.MethodGet, "", nil, "doctor", controller.GetOrder())
+		assert.Equal(t, "order is not found", response.Message)
+	})
+
+	t.Run("doctor or patient of another visit", func(t *testing.T) {
+		var repo = &mockSuccess{other: true}
+		var controller = New(repo, logic.New())
+		assert.Equal(t, 404, request(t, http.MethodGet, "", nil, "patient", controller.GetOrder()).Code)
+		assert.Equal(t, 404, request(t, http.MethodGet, "", nil, "doctor", controller.GetOrders()).Code)
+		assert.Equal(t, 0, len(repo.seen))
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 200, request(t, http.MethodGet, "", nil, "doctor", controller.GetOrders()).Code)
+		assert.Equal(t, 200, request(t, http.MethodGet, "", nil, "doctor", controller.GetUnseen()).Code)
+		assert.Equal(t, 200, request(t, http.MethodGet, "?status=collected", nil, "doctor", controller.GetByStatus()).Code)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 400, request(t, http.MethodGet, "?status=lost", nil, "doctor", controller.GetByStatus()).Code)
+	})
+
+	t.Run("invalid list query", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 400, request(t, http.MethodGet, "?sort=name", nil, "doctor", controller.GetByStatus()).Code)
+		assert.Equal(t, 400, request(t, http.MethodGet, "?limit=0", nil, "doctor", controller.GetUnseen()).Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, 500, request(t, http.MethodGet, "", nil, "doctor", controller.GetOrders()).Code)
+		assert.Equal(t, 500, request(t, http.MethodGet, "", nil, "doctor", controller.GetUnseen()).Code)
+		assert.Equal(t, 500, request(t, http.MethodGet, "", nil, "doctor", controller.GetByStatus()).Code)
+	})
+}
+
+func TestCollect(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 202, request(t, http.MethodPut, "", nil, "", controller.Collect()).Code)
+	})
+
+	t.Run("already resulted", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		var response = request(t, http.MethodPut, "", nil, "", controller.Collect())
+		assert.Equal(t, "order is already resulted", response.Message)
+	})
+}
+
+func TestAddResults(t *testing.T) {
+	var body = map[string]interface{}{"results": []map[string]string{{"analyte": "glucose", "value": "160", "unit": "mg/dL", "referenceRange": "70-100"}}}
+
+	t.Run("success", func(t *testing.T) {
+		var repo = &mockSuccess{}
+		var controller = New(repo, logic.New())
+		var response = request(t, http.MethodPost, "", body, "", controller.AddResults())
+		assert.Equal(t, 201, response.Code)
+		assert.Equal(t, 1, len(repo.results))
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, "", map[string]interface{}{"results": []string{}}, "", controller.AddResults())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		var response = request(t, http.MethodPost, "", body, "", controller.AddResults())
+		assert.Equal(t, "order is not found", response.Message)
+	})
+
+	t.Run("order of a visit the doctor no longer has", func(t *testing.T) {
+		var repo = &mockSuccess{other: true}
+		var controller = New(repo, logic.New())
+		assert.Equal(t, 404, request(t, http.MethodPost, "", body, "", controller.AddResults()).Code)
+		assert.Equal(t, 0, len(repo.results))
+	})
+}
+
+func TestLabKey(t *testing.T) {
+	var handler = middlewares.LabKeyMiddleware("secret")(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Run("valid key", func(t *testing.T) {
+		var req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Lab-Key", "secret")
+		var res = httptest.NewRecorder()
+		assert.Nil(t, handler(echo.New().NewContext(req, res)))
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		var req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Lab-Key", "wrong")
+		var res = httptest.NewRecorder()
+		assert.NotNil(t, handler(echo.New().NewContext(req, res)))
+	})
+}
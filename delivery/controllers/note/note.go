@@ -0,0 +1,215 @@
+package note
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/note"
+	"be/delivery/middlewares"
+	"be/repository/note"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	r note.Note
+	l logic.Note
+}
+
+func New(r note.Note, l logic.Note) *Controller {
+	return &Controller{
+		r: r,
+		l: l,
+	}
+}
+
+func (cont *Controller) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can write clinical note", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Create(visit_uid, uid, *req.ToNote())
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("visit is not found")
+			case "note is locked", "visit is cancelled", "note is already exist":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add note", map[string]interface{}{
+			"note_uid": res.Note_uid,
+			"version":  res.Version,
+		}))
+	}
+}
+
+func (cont *Controller) Update() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can write clinical note", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Update(visit_uid, uid, *req.ToRevision())
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("note is not found")
+			case "note is locked":
+				err = errors.New("note is locked, add an amendment instead")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success update note", map[string]interface{}{
+			"version": res.Version,
+		}))
+	}
+}
+
+func (cont *Controller) Sign() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can sign clinical note", nil))
+		}
+
+		// database
+
+		res, err := cont.r.Sign(visit_uid, uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("note is not found")
+			case "note is already signed", "note can only signed by the author":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success sign note", map[string]interface{}{
+			"version":  res.Version,
+			"signedAt": res.SignedAt,
+		}))
+	}
+}
+
+func (cont *Controller) Amend() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can amend clinical note", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationAmendment(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Amend(visit_uid, uid, *req.ToRevision())
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("note is not found")
+			case "note is not signed yet":
+				err = errors.New("note is not signed yet, update the draft instead")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add amendment", map[string]interface{}{
+			"version": res.Version,
+		}))
+	}
+}
+
+func (cont *Controller) GetNote() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		if err := cont.r.CheckAccess(visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+		}
+
+		// database
+
+		res, err := cont.r.GetNote(visit_uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("note is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get note", res))
+	}
+}
@@ -0,0 +1,285 @@
+package note
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/note"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/note"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct {
+	revision note.Revision
+}
+
+func (m *mockSuccess) CheckAccess(visit_uid, user_uid string) error {
+	return nil
+}
+
+func (m *mockSuccess) Create(visit_uid, author_uid string, req entities.Note) (entities.Note, error) {
+	return entities.Note{Note_uid: "note", Version: 1}, nil
+}
+
+func (m *mockSuccess) Update(visit_uid, author_uid string, req note.Revision) (entities.Note, error) {
+	return entities.Note{Version: 2}, nil
+}
+
+func (m *mockSuccess) Sign(visit_uid, author_uid string) (entities.Note, error) {
+	return entities.Note{Version: 2, Status: "signed"}, nil
+}
+
+func (m *mockSuccess) Amend(visit_uid, author_uid string, req note.Revision) (entities.Note, error) {
+	m.revision = req
+	return entities.Note{Version: 3, Kind: "amendment"}, nil
+}
+
+func (m *mockSuccess) GetNote(visit_uid string) (note.NoteResp, error) {
+	return note.NoteResp{Note_uid: "note"}, nil
+}
+
+type mockLocked struct{}
+
+func (m *mockLocked) CheckAccess(visit_uid, user_uid string) error {
+	return nil
+}
+
+func (m *mockLocked) Create(visit_uid, author_uid string, req entities.Note) (entities.Note, error) {
+	return entities.Note{}, errors.New("note is locked")
+}
+
+func (m *mockLocked) Update(visit_uid, author_uid string, req note.Revision) (entities.Note, error) {
+	return entities.Note{}, errors.New("note is locked")
+}
+
+func (m *mockLocked) Sign(visit_uid, author_uid string) (entities.Note, error) {
+	return entities.Note{}, errors.New("note is already signed")
+}
+
+func (m *mockLocked) Amend(visit_uid, author_uid string, req note.Revision) (entities.Note, error) {
+	return entities.Note{}, errors.New("note is not signed yet")
+}
+
+func (m *mockLocked) GetNote(visit_uid string) (note.NoteResp, error) {
+	return note.NoteResp{}, gorm.ErrRecordNotFound
+}
+
+// mockOther is a visit of another doctor and patient
+
+type mockOther struct{}
+
+func (m *mockOther) CheckAccess(visit_uid, user_uid string) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockOther) Create(visit_uid, author_uid string, req entities.Note) (entities.Note, error) {
+	return entities.Note{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockOther) Update(visit_uid, author_uid string, req note.Revision) (entities.Note, error) {
+	return entities.Note{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockOther) Sign(visit_uid, author_uid string) (entities.Note, error) {
+	return entities.Note{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockOther) Amend(visit_uid, author_uid string, req note.Revision) (entities.Note, error) {
+	return entities.Note{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockOther) GetNote(visit_uid string) (note.NoteResp, error) {
+	return note.NoteResp{Note_uid: "note"}, nil
+}
+
+func request(t *testing.T, method string, body interface{}, kind string, handler echo.HandlerFunc) ResponseFormat {
+	var token, err = middlewares.GenerateToken("doctor", kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reqBody, _ = json.Marshal(body)
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/", bytes.NewBuffer(reqBody))
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetPath("/visit/:visit_uid/note")
+	context.SetParamNames("visit_uid")
+	context.SetParamValues("visit")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestCreate(t *testing.T) {
+	var body = map[string]interface{}{"subjective": "headache"}
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, body, "doctor", controller.Create())
+		assert.Equal(t, 201, response.Code)
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, body, "patient", controller.Create())
+		assert.Equal(t, 401, response.Code)
+	})
+
+	t.Run("binding", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, map[string]interface{}{"subjective": 1}, "doctor", controller.Create())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, map[string]interface{}{}, "doctor", controller.Create())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		var controller = New(&mockLocked{}, logic.New())
+		var response = request(t, http.MethodPost, body, "doctor", controller.Create())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "note is locked", response.Message)
+	})
+
+	t.Run("doctor of another visit", func(t *testing.T) {
+		var controller = New(&mockOther{}, logic.New())
+		var response = request(t, http.MethodPost, body, "doctor", controller.Create())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "visit is not found", response.Message)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	var body = map[string]interface{}{"plan": "rest"}
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPut, body, "doctor", controller.Update())
+		assert.Equal(t, 202, response.Code)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		var controller = New(&mockLocked{}, logic.New())
+		var response = request(t, http.MethodPut, body, "doctor", controller.Update())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "note is locked, add an amendment instead", response.Message)
+	})
+
+	t.Run("doctor of another visit", func(t *testing.T) {
+		var controller = New(&mockOther{}, logic.New())
+		var response = request(t, http.MethodPut, body, "doctor", controller.Update())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "note is not found", response.Message)
+	})
+}
+
+func TestSign(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, nil, "doctor", controller.Sign())
+		assert.Equal(t, 202, response.Code)
+	})
+
+	t.Run("already signed", func(t *testing.T) {
+		var controller = New(&mockLocked{}, logic.New())
+		var response = request(t, http.MethodPost, nil, "doctor", controller.Sign())
+		assert.Equal(t, 500, response.Code)
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, nil, "admin", controller.Sign())
+		assert.Equal(t, 401, response.Code)
+	})
+
+	t.Run("doctor of another visit", func(t *testing.T) {
+		var controller = New(&mockOther{}, logic.New())
+		var response = request(t, http.MethodPost, nil, "doctor", controller.Sign())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "note is not found", response.Message)
+	})
+}
+
+func TestAmend(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, map[string]interface{}{"assessment": "migraine", "reason": "lab result"}, "doctor", controller.Amend())
+		assert.Equal(t, 201, response.Code)
+	})
+
+	t.Run("missing reason", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, map[string]interface{}{"assessment": "migraine"}, "doctor", controller.Amend())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("clear a section", func(t *testing.T) {
+		var mock = &mockSuccess{}
+		var controller = New(mock, logic.New())
+		var response = request(t, http.MethodPost, map[string]interface{}{"plan": "", "reason": "plan is not needed"}, "doctor", controller.Amend())
+		assert.Equal(t, 201, response.Code)
+		assert.Nil(t, mock.revision.Assessment)
+		if assert.NotNil(t, mock.revision.Plan) {
+			assert.Equal(t, "", *mock.revision.Plan)
+		}
+	})
+
+	t.Run("not signed", func(t *testing.T) {
+		var controller = New(&mockLocked{}, logic.New())
+		var response = request(t, http.MethodPost, map[string]interface{}{"assessment": "migraine", "reason": "lab result"}, "doctor", controller.Amend())
+		assert.Equal(t, 500, response.Code)
+	})
+
+	t.Run("doctor of another visit", func(t *testing.T) {
+		var controller = New(&mockOther{}, logic.New())
+		var response = request(t, http.MethodPost, map[string]interface{}{"assessment": "migraine", "reason": "lab result"}, "doctor", controller.Amend())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "note is not found", response.Message)
+	})
+}
+
+func TestGetNote(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodGet, nil, "patient", controller.GetNote())
+		assert.Equal(t, 200, response.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockLocked{}, logic.New())
+		var response = request(t, http.MethodGet, nil, "patient", controller.GetNote())
+		assert.Equal(t, 500, response.Code)
+		assert.Equal(t, "note is not found", response.Message)
+	})
+
+	t.Run("patient or doctor of another visit", func(t *testing.T) {
+		var controller = New(&mockOther{}, logic.New())
+		assert.Equal(t, 404, request(t, http.MethodGet, nil, "patient", controller.GetNote()).Code)
+		assert.Equal(t, 404, request(t, http.MethodGet, nil, "doctor", controller.GetNote()).Code)
+	})
+}
@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"be/entities"
+	"be/repository/notification"
+	"time"
+)
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+// PreferenceReq replaces the channels of the user, the phone is needed for
+// sms and whatsapp unless the patient has one
+
+type PreferenceReq struct {
+	Email    bool   `json:"email" form:"email"`
+	Sms      bool   `json:"sms" form:"sms"`
+	Whatsapp bool   `json:"whatsapp" form:"whatsapp"`
+	Phone    string `json:"phone" form:"phone"`
+}
+
+type PreferenceResponse struct {
+	Email    bool   `json:"email"`
+	Sms      bool   `json:"sms"`
+	Whatsapp bool   `json:"whatsapp"`
+	Phone    string `json:"phone"`
+}
+
+func ToPreference(pref entities.NotificationPreference) PreferenceResponse {
+	return PreferenceResponse{
+		Email:    pref.Email,
+		Sms:      pref.Sms,
+		Whatsapp: pref.Whatsapp,
+		Phone:    pref.Phone,
+	}
+}
+
+type DeliveryResponse struct {
+	ID              uint      `json:"id"`
+	Notification_id uint      `json:"notification_id"`
+	Kind            string    `json:"kind"`
+	Channel         string    `json:"channel"`
+	Recipient       string    `json:"recipient"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func ToDeliveries(deliveries []notification.Delivery) []DeliveryResponse {
+	var res = make([]DeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		res = append(res, DeliveryResponse{
+			ID:              d.ID,
+			Notification_id: d.Notification_id,
+			Kind:            d.Kind,
+			Channel:         d.Channel,
+			Recipient:       d.Recipient,
+			Status:          d.Status,
+			Error:           d.Error,
+			CreatedAt:       d.CreatedAt,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,136 @@
+package notification
+
+import (
+	"be/delivery/controllers/templates"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/notification"
+	"be/utils"
+	"be/utils/list"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	r notification.Notification
+}
+
+func New(r notification.Notification) *Controller {
+	return &Controller{
+		r: r,
+	}
+}
+
+// GetPreference is the channels the user gets the notifications on
+func (cont *Controller) GetPreference() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetPreference(uid)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get notification preference", ToPreference(res)))
+	}
+}
+
+// SetPreference replaces the channels of the user, sms and whatsapp need a
+// phone
+func (cont *Controller) SetPreference() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, _ = middlewares.ExtractTokenUid(c)
+		var req PreferenceReq
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if req.Phone != "" {
+			if err := utils.PhoneValid(req.Phone); err != nil {
+				return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+			}
+		}
+
+		// database
+
+		recipient, err := cont.r.GetRecipient(uid)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "user is not found", nil))
+		}
+
+		if (req.Sms || req.Whatsapp) && req.Phone == "" && recipient.Phone == "" {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "phone is needed for sms and whatsapp", nil))
+		}
+
+		res, err := cont.r.SetPreference(entities.NotificationPreference{User_uid: uid, Email: req.Email, Sms: req.Sms, Whatsapp: req.Whatsapp, Phone: req.Phone})
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success update notification preference", ToPreference(res)))
+	}
+}
+
+// GetDeliveries is the last attempts to notify the user, an admin reads the
+// ones of the user in user_uid, the doctor of the clinic or one of its
+// patients
+func (cont *Controller) GetDeliveries() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		q, err := list.Parse(notification.Deliveries, c.QueryParams())
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		if user_uid := c.QueryParam("user_uid"); user_uid != "" && user_uid != uid {
+			if kind != "admin" {
+				return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only admin can read the deliveries of another user", nil))
+			}
+
+			// database
+
+			clinic, err := cont.r.GetClinic(uid)
+			if err != nil {
+				log.Warn(err)
+				switch err.Error() {
+				case "record not found":
+					return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "clinic is not found", nil))
+				default:
+					return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+				}
+			}
+
+			if err := cont.r.InClinic(clinic, user_uid); err != nil {
+				log.Warn(err)
+				switch err.Error() {
+				case "record not found":
+					return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "the user is not of the clinic", nil))
+				default:
+					return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+				}
+			}
+
+			uid = user_uid
+		}
+
+		// database
+
+		res, page, err := cont.r.GetDeliveries(uid, q)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get notification deliveries", ToDeliveries(res), page))
+	}
+}
@@ -0,0 +1,223 @@
+package notification
+
+import (
+	"be/configs"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/notification"
+	"be/utils/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockRepo struct {
+	prefs map[string]entities.NotificationPreference
+	read  string
+	fail  bool
+}
+
+func (m *mockRepo) GetPreference(user_uid string) (entities.NotificationPreference, error) {
+	if m.fail {
+		return entities.NotificationPreference{}, errors.New("")
+	}
+	if pref, ok := m.prefs[user_uid]; ok {
+		return pref, nil
+	}
+	return entities.NotificationPreference{User_uid: user_uid, Email: true}, nil
+}
+
+func (m *mockRepo) SetPreference(pref entities.NotificationPreference) (entities.NotificationPreference, error) {
+	if m.fail {
+		return entities.NotificationPreference{}, errors.New("")
+	}
+	m.prefs[pref.User_uid] = pref
+	return pref, nil
+}
+
+func (m *mockRepo) GetRecipient(user_uid string) (notification.Recipient, error) {
+	switch user_uid {
+	case "patient":
+		return notification.Recipient{Kind: "patient", Name: "siti", Phone: "08123456789"}, nil
+	case "doctor":
+		return notification.Recipient{Kind: "doctor", Name: "dr. budi"}, nil
+	}
+	return notification.Recipient{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetVisit(visit_uid string) (notification.Visit, error) {
+	return notification.Visit{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) EnqueueReminders(date, next_at time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *mockRepo) GetDue(now time.Time, limit int) ([]entities.Notification, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Claim(id uint, now time.Time) (entities.Notification, error) {
+	return entities.Notification{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) Finish(id uint) error {
+	return nil
+}
+
+func (m *mockRepo) Retry(id uint, next_at time.Time, message string) error {
+	return nil
+}
+
+func (m *mockRepo) Bury(id uint, message string) error {
+	return nil
+}
+
+func (m *mockRepo) GetDone(notification_id uint) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) LogDelivery(delivery entities.NotificationDelivery) error {
+	return nil
+}
+
+func (m *mockRepo) GetClinic(admin_uid string) (string, error) {
+	if admin_uid != "admin" {
+		return "", gorm.ErrRecordNotFound
+	}
+	return "doctor", nil
+}
+
+func (m *mockRepo) InClinic(clinic_uid, user_uid string) error {
+	if user_uid != clinic_uid && user_uid != "patient" {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (m *mockRepo) GetDeliveries(user_uid string, q list.Query) ([]notification.Delivery, list.Page, error) {
+	if m.fail {
+		return nil, list.Page{}, errors.New("")
+	}
+	m.read = user_uid
+	return []notification.Delivery{{NotificationDelivery: entities.NotificationDelivery{ID: 1, Notification_id: 1, User_uid: user_uid, Kind: "reminder", Channel: "email", Recipient: "siti@mail.com", Status: "sent"}}}, list.Page{Total: 1, Limit: q.Limit}, nil
+}
+
+func request(t *testing.T, uid, kind, method, target, body string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var e = echo.New()
+	var req = httptest.NewRequest(method, target, strings.NewReader(body))
+	var res = httptest.NewRecorder()
+
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(e.NewContext(req, res)); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestGetPreference(t *testing.T) {
+	t.Run("success email by default", func(t *testing.T) {
+		var res = response(request(t, "patient", "patient", http.MethodGet, "/", "", New(&mockRepo{}).GetPreference()))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, map[string]interface{}{"email": true, "sms": false, "whatsapp": false, "phone": ""}, res.Data)
+	})
+
+	t.Run("error database", func(t *testing.T) {
+		assert.Equal(t, 500, request(t, "patient", "patient", http.MethodGet, "/", "", New(&mockRepo{fail: true}).GetPreference()).Code)
+	})
+}
+
+func TestSetPreference(t *testing.T) {
+	t.Run("success sms to the phone of the patient", func(t *testing.T) {
+		var r = &mockRepo{prefs: map[string]entities.NotificationPreference{}}
+		var res = response(request(t, "patient", "patient", http.MethodPut, "/", `{"email": false, "sms": true}`, New(r).SetPreference()))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, entities.NotificationPreference{User_uid: "patient", Sms: true}, r.prefs["patient"])
+	})
+
+	t.Run("success whatsapp of the doctor", func(t *testing.T) {
+		var r = &mockRepo{prefs: map[string]entities.NotificationPreference{}}
+		assert.Equal(t, 200, request(t, "doctor", "doctor", http.MethodPut, "/", `{"whatsapp": true, "phone": "+628123456789"}`, New(r).SetPreference()).Code)
+		assert.Equal(t, "+628123456789", r.prefs["doctor"].Phone)
+	})
+
+	t.Run("error phone", func(t *testing.T) {
+		var r = &mockRepo{prefs: map[string]entities.NotificationPreference{}}
+		assert.Equal(t, 400, request(t, "doctor", "doctor", http.MethodPut, "/", `{"sms": true}`, New(r).SetPreference()).Code)
+		assert.Equal(t, 400, request(t, "doctor", "doctor", http.MethodPut, "/", `{"sms": true, "phone": "08-123"}`, New(r).SetPreference()).Code)
+		assert.Equal(t, 0, len(r.prefs))
+	})
+
+	t.Run("error input", func(t *testing.T) {
+		assert.Equal(t, 400, request(t, "patient", "patient", http.MethodPut, "/", `{"email": "yes"}`, New(&mockRepo{}).SetPreference()).Code)
+	})
+
+	t.Run("error user", func(t *testing.T) {
+		assert.Equal(t, 404, request(t, "gone", "patient", http.MethodPut, "/", `{"email": true}`, New(&mockRepo{}).SetPreference()).Code)
+	})
+
+	t.Run("error database", func(t *testing.T) {
+		assert.Equal(t, 500, request(t, "patient", "patient", http.MethodPut, "/", `{"email": true}`, New(&mockRepo{fail: true}).SetPreference()).Code)
+	})
+}
+
+func TestGetDeliveries(t *testing.T) {
+	t.Run("success own", func(t *testing.T) {
+		var r = &mockRepo{}
+		var res = response(request(t, "patient", "patient", http.MethodGet, "/", "", New(r).GetDeliveries()))
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "patient", r.read)
+		assert.Equal(t, "sent", res.Data.([]interface{})[0].(map[string]interface{})["status"])
+	})
+
+	t.Run("success admin", func(t *testing.T) {
+		var r = &mockRepo{}
+		assert.Equal(t, 200, request(t, "admin", "admin", http.MethodGet, "/?user_uid=patient", "", New(r).GetDeliveries()).Code)
+		assert.Equal(t, "patient", r.read)
+	})
+
+	t.Run("error another user", func(t *testing.T) {
+		assert.Equal(t, 401, request(t, "doctor", "doctor", http.MethodGet, "/?user_uid=patient", "", New(&mockRepo{}).GetDeliveries()).Code)
+	})
+
+	t.Run("error user of another clinic", func(t *testing.T) {
+		var r = &mockRepo{}
+		assert.Equal(t, 401, request(t, "admin", "admin", http.MethodGet, "/?user_uid=other", "", New(r).GetDeliveries()).Code)
+		assert.Equal(t, "", r.read)
+		assert.Equal(t, 500, request(t, "unknown", "admin", http.MethodGet, "/?user_uid=patient", "", New(r).GetDeliveries()).Code)
+	})
+
+	t.Run("error query", func(t *testing.T) {
+		assert.Equal(t, 400, request(t, "patient", "patient", http.MethodGet, "/?channel=fax", "", New(&mockRepo{}).GetDeliveries()).Code)
+		assert.Equal(t, 400, request(t, "patient", "patient", http.MethodGet, "/?limit=0", "", New(&mockRepo{}).GetDeliveries()).Code)
+	})
+
+	t.Run("error database", func(t *testing.T) {
+		assert.Equal(t, 500, request(t, "patient", "patient", http.MethodGet, "/", "", New(&mockRepo{fail: true}).GetDeliveries()).Code)
+	})
+}
@@ -1,12 +1,14 @@
 package patient
 
 import (
-	"be/api/aws/s3"
 	"be/delivery/controllers/templates"
 	logic "be/delivery/logic/patient"
 	"be/delivery/middlewares"
 	"be/repository/patient"
+	"be/utils/list"
+	"be/utils/photo"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -15,16 +17,16 @@ import (
 )
 
 type Controller struct {
-	r      patient.Patient
-	taskS3 s3.TaskS3M
-	l      logic.Patient
+	r     patient.Patient
+	photo photo.Photo
+	l     logic.Patient
 }
 
-func New(r patient.Patient, taskS3 s3.TaskS3M, l logic.Patient) *Controller {
+func New(r patient.Patient, photo photo.Photo, l logic.Patient) *Controller {
 	return &Controller{
-		r:      r,
-		taskS3: taskS3,
-		l:      l,
+		r:     r,
+		photo: photo,
+		l:     l,
 	}
 }
 
@@ -78,20 +80,21 @@ func (cont *Controller) Create() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
 		}
 
-		// aws s3
+		// storage
+
+		req.Image = ""
 
 		file, err := c.FormFile("file")
 		if err != nil {
 			log.Warn(err)
 		}
 		if err == nil {
-			link, err := cont.taskS3.UploadFileToS3(*file)
+			key, err := cont.photo.Upload(*file)
 			if err != nil {
-				log.Warn(err)
-				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+				return photoError(c, err)
 			}
 
-			req.Image = link
+			req.Image = key
 		}
 
 		// database
@@ -99,6 +102,7 @@ func (cont *Controller) Create() echo.HandlerFunc {
 		entity, err := req.ToPatient()
 		if err != nil {
 			log.Warn(err)
+			cont.dropPhoto(req.Image)
 			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
 		}
 
@@ -106,6 +110,7 @@ func (cont *Controller) Create() echo.HandlerFunc {
 
 		if err != nil {
 			log.Warn(err)
+			cont.dropPhoto(req.Image)
 			switch err.Error() {
 			case errors.New("user name is already exist").Error():
 				err = errors.New("user name is already exist")
@@ -145,7 +150,11 @@ func (cont *Controller) Update() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
 		}
 
-		// aws s3
+		// storage
+
+		req.Image = ""
+
+		var oldImage string
 
 		file, err := c.FormFile("file")
 		if err != nil {
@@ -156,28 +165,15 @@ func (cont *Controller) Update() echo.HandlerFunc {
 			if err != nil {
 				log.Warn(err)
 				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, errors.New("there's some problem is server"), nil))
-
 			}
-			if res1.Image != "https://www.teralogistics.com/wp-content/uploads/2020/12/default.png" {
-				var nameFile = res1.Image
-
-				nameFile = strings.Replace(nameFile, "https://karen-givi-bucket.s3.ap-southeast-1.amazonaws.com/", "", -1)
-
-				var res = cont.taskS3.UpdateFileS3(nameFile, *file)
-				log.Info(res)
-				if res != "success" {
-					log.Warn(res)
-					return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, errors.New("there's some problem is server"), nil))
-				}
-			} else {
-				var link, err = cont.taskS3.UploadFileToS3(*file)
-				if err != nil {
-					log.Warn(err)
-					return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, errors.New("there's some problem is server"), nil))
-				}
-
-				req.Image = link
+			oldImage = res1.Image
+
+			key, err := cont.photo.Upload(*file)
+			if err != nil {
+				return photoError(c, err)
 			}
+
+			req.Image = key
 		}
 
 		// database
@@ -185,6 +181,7 @@ func (cont *Controller) Update() echo.HandlerFunc {
 		entity, err := req.ToPatient()
 		if err != nil {
 			log.Warn(err)
+			cont.dropPhoto(req.Image)
 			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
 		}
 
@@ -192,6 +189,7 @@ func (cont *Controller) Update() echo.HandlerFunc {
 
 		if err != nil {
 			log.Warn(err)
+			cont.dropPhoto(req.Image)
 			switch err.Error() {
 			case errors.New("user name is already exist").Error():
 				err = errors.New("user name is already exist")
@@ -205,30 +203,54 @@ func (cont *Controller) Update() echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
 		}
 
+		if req.Image != "" {
+			cont.dropPhoto(oldImage)
+		}
+
 		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success update patient", res.Name))
 	}
 }
 
+// photoError answers a photo that is refused, also for malware, with 400 and
+// the other errors of the scanner and the storage with 500
+
+func photoError(c echo.Context, err error) error {
+	log.Warn(err)
+	switch {
+	case err.Error() == "file is not an image", err.Error() == "image has too many pixels", err.Error() == fmt.Sprintf("image is larger than %d MB", photo.MaxBytes>>20):
+		return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+	case strings.HasPrefix(err.Error(), "file is infected with "):
+		return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+	}
+	return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+}
+
+// dropPhoto removes a photo when the request failed after uploading it, or
+// the one replaced by a new photo
+
+func (cont *Controller) dropPhoto(key string) {
+	if key == "" {
+		return
+	}
+	if err := cont.photo.Delete(key); err != nil {
+		log.Warn(err)
+	}
+}
+
 func (cont *Controller) Delete() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var uid, _ = middlewares.ExtractTokenUid(c)
 
-		// aws s3
+		// storage
 
 		res1, err := cont.r.GetProfile(uid, "", "")
 		if err != nil {
 			log.Error(err)
 		}
 
-		if res1.Image != "https://www.teralogistics.com/wp-content/uploads/2020/12/default.png" {
-
-			var nameFile = res1.Image
-
-			nameFile = strings.Replace(nameFile, "https://karen-givi-bucket.s3.ap-southeast-1.amazonaws.com/", "", -1)
-			res := cont.taskS3.DeleteFileS3(nameFile)
-			log.Info(res)
-			if res != "success" {
-				log.Warn(res)
+		if res1.Image != "" {
+			if err := cont.photo.Delete(res1.Image); err != nil {
+				log.Warn(err)
 			}
 		}
 
@@ -257,13 +279,18 @@ func (cont *Controller) GetProfile() echo.HandlerFunc {
 		var all = c.QueryParam("all")
 
 		if all == "all" {
-			res, err := cont.r.GetAll()
+			q, err := list.Parse(patient.List, c.QueryParams())
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+			}
+
+			res, page, err := cont.r.GetAll(q)
 			if err != nil {
 				log.Warn(err)
 				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem in server", nil))
 			}
 
-			return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get all patient", res))
+			return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get all patient", res, page))
 		}
 
 		var userName = c.QueryParam("userName")
@@ -290,6 +317,9 @@ func (cont *Controller) GetProfile() echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
 		}
 
+		res.Images = cont.photo.Urls(res.Image)
+		res.Image = res.Images.Large
+
 		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get profile patient", res))
 	}
 }
@@ -6,6 +6,8 @@ import (
 	logic "be/delivery/logic/patient"
 	"be/entities"
 	"be/repository/patient"
+	"be/utils/list"
+	"be/utils/photo"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -52,32 +54,46 @@ func (m *errorLogicStruct) ValidationStruct(req logic.Req) error {
 	return nil
 }
 
-type mockTaskS3M struct{}
+type mockPhoto struct{}
 
-func (m *mockTaskS3M) UploadFileToS3(fileHeader multipart.FileHeader) (string, error) {
-	return "", nil
+func (m *mockPhoto) Upload(fileHeader multipart.FileHeader) (string, error) {
+	return "photos/key", nil
 }
 
-func (m *mockTaskS3M) UpdateFileS3(name string, fileHeader multipart.FileHeader) string {
-	return "success"
+func (m *mockPhoto) Delete(key string) error {
+	return nil
 }
 
-func (m *mockTaskS3M) DeleteFileS3(name string) string {
-	return "success"
+func (m *mockPhoto) Urls(key string) photo.Urls {
+	return photo.Urls{Large: "https://files.example.com/" + key + "/large.jpg", Medium: "https://files.example.com/" + key + "/medium.jpg", Small: "https://files.example.com/" + key + "/small.jpg"}
 }
 
-type failTaskS3M struct{}
+type failPhoto struct {
+	mockPhoto
+}
 
-func (m *failTaskS3M) UploadFileToS3(fileHeader multipart.FileHeader) (string, error) {
+func (m *failPhoto) Upload(fileHeader multipart.FileHeader) (string, error) {
 	return "", errors.New("")
 }
 
-func (m *failTaskS3M) UpdateFileS3(name string, fileHeader multipart.FileHeader) string {
-	return "error"
+func (m *failPhoto) Delete(key string) error {
+	return errors.New("")
+}
+
+type notImage struct {
+	mockPhoto
+}
+
+func (m *notImage) Upload(fileHeader multipart.FileHeader) (string, error) {
+	return "", errors.New("file is not an image")
 }
 
-func (m *failTaskS3M) DeleteFileS3(name string) string {
-	return "error"
+type infected struct {
+	mockPhoto
+}
+
+func (m *infected) Upload(fileHeader multipart.FileHeader) (string, error) {
+	return "", errors.New("file is infected with Eicar-Test-Signature")
 }
 
 type mockSuccess struct{}
@@ -95,11 +111,11 @@ func (m *mockSuccess) Delete(patient_uid string) (entities.Patient, error) {
 }
 
 func (m *mockSuccess) GetProfile(patient_uid, userName, email string) (patient.Profile, error) {
-	return patient.Profile{Image: "https://karen-givi-bucket.s3.ap-southeast-1.amazonaws.com/testing"}, nil
+	return patient.Profile{Image: "testing"}, nil
 }
 
-func (m *mockSuccess) GetAll() (patient.All, error) {
-	return patient.All{}, nil
+func (m *mockSuccess) GetAll(q list.Query) (patient.All, list.Page, error) {
+	return patient.All{}, list.Page{}, nil
 }
 
 type defaultImage struct{}
@@ -117,11 +133,11 @@ func (m *defaultImage) Delete(patient_uid string) (entities.Patient, error) {
 }
 
 func (m *defaultImage) GetProfile(patient_uid, userName, email string) (patient.Profile, error) {
-	return patient.Profile{Image: "https://www.teralogistics.com/wp-content/uploads/2020/12/default.png"}, nil
+	return patient.Profile{Image: ""}, nil
 }
 
-func (m *defaultImage) GetAll() (patient.All, error) {
-	return patient.All{}, nil
+func (m *defaultImage) GetAll(q list.Query) (patient.All, list.Page, error) {
+	return patient.All{}, list.Page{}, nil
 }
 
 type mockFail struct{}
@@ -142,8 +158,8 @@ func (m *mockFail) GetProfile(patient_uid, userName, email string) (patient.Prof
 	return patient.Profile{}, errors.New("")
 }
 
-func (m *mockFail) GetAll() (patient.All, error) {
-	return patient.All{}, errors.New("")
+func (m *mockFail) GetAll(q list.Query) (patient.All, list.Page, error) {
+	return patient.All{}, list.Page{}, errors.New("")
 }
 
 type recordNotFound struct{}
@@ -164,8 +180,8 @@ func (m *recordNotFound) GetProfile(patient_uid, userName, email string) (patien
 	return patient.Profile{}, gorm.ErrRecordNotFound
 }
 
-func (m *recordNotFound) GetAll() (patient.All, error) {
-	return patient.All{}, nil
+func (m *recordNotFound) GetAll(q list.Query) (patient.All, list.Page, error) {
+	return patient.All{}, list.Page{}, nil
 }
 
 type userNameCheck struct{}
@@ -186,8 +202,8 @@ func (m *userNameCheck) GetProfile(patient_uid, userName, email string) (patient
 	return patient.Profile{}, errors.New("user name is already exist")
 }
 
-func (m *userNameCheck) GetAll() (patient.All, error) {
-	return patient.All{}, nil
+func (m *userNameCheck) GetAll(q list.Query) (patient.All, list.Page, error) {
+	return patient.All{}, list.Page{}, nil
 }
 
 type emailCheck struct{}
@@ -208,8 +224,8 @@ func (m *emailCheck) GetProfile(patient_uid, userName, email string) (patient.Pr
 	return patient.Profile{}, errors.New("user name is already exist")
 }
 
-func (m *emailCheck) GetAll() (patient.All, error) {
-	return patient.All{}, nil
+func (m *emailCheck) GetAll(q list.Query) (patient.All, list.Page, error) {
+	return patient.All{}, list.Page{}, nil
 }
 
 type MockAuthLib struct{}
@@ -249,7 +265,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -283,7 +299,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -317,7 +333,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -352,7 +368,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -387,7 +403,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -422,7 +438,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -457,7 +473,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -492,7 +508,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -527,7 +543,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -562,7 +578,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -597,7 +613,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -632,7 +648,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -667,7 +683,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -702,7 +718,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -737,7 +753,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &errorLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &errorLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -772,7 +788,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &errorLogicStruct{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &errorLogicStruct{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -807,7 +823,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -852,7 +868,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -897,7 +913,7 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &failPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -932,7 +948,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&userNameCheck{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&userNameCheck{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -968,7 +984,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&emailCheck{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&emailCheck{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -1004,7 +1020,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		controller.Create()(context)
 
 		var response = ResponseFormat{}
@@ -1068,7 +1084,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1103,7 +1119,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1139,7 +1155,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &errorLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &errorLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1175,7 +1191,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1220,7 +1236,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1265,7 +1281,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &failPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1310,7 +1326,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &failPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1323,6 +1339,98 @@ func TestUpdate(t *testing.T) {
 		assert.Equal(t, 500, response.Code)
 	})
 
+	t.Run("file is not an image", func(t *testing.T) {
+
+		var reqBody = new(bytes.Buffer)
+
+		var writer = multipart.NewWriter(reqBody)
+		writer.WriteField("nik", "1234567891234567")
+		writer.WriteField("name", "name")
+		writer.WriteField("gender", "pria")
+		writer.WriteField("address", "123456789123456")
+		writer.WriteField("placeBirth", "placeBirth")
+		writer.WriteField("dob", "05-05-2002")
+		writer.WriteField("job", "lainnya")
+		writer.WriteField("status", "lainnya")
+		writer.WriteField("religion", "religion")
+
+		part, err := writer.CreateFormFile("file", "photo.jpg")
+		if err != nil {
+			log.Warn(err)
+		}
+		part.Write([]byte(`sample`))
+		writer.Close()
+
+		var e = echo.New()
+
+		var req = httptest.NewRequest(http.MethodPost, "/", reqBody)
+		var res = httptest.NewRecorder()
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/doctor")
+
+		var controller = New(&mockSuccess{}, &notImage{}, &successLogic{})
+		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		var response = ResponseFormat{}
+
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "file is not an image", response.Message)
+	})
+
+	t.Run("file is infected", func(t *testing.T) {
+
+		var reqBody = new(bytes.Buffer)
+
+		var writer = multipart.NewWriter(reqBody)
+		writer.WriteField("nik", "1234567891234567")
+		writer.WriteField("name", "name")
+		writer.WriteField("gender", "pria")
+		writer.WriteField("address", "123456789123456")
+		writer.WriteField("placeBirth", "placeBirth")
+		writer.WriteField("dob", "05-05-2002")
+		writer.WriteField("job", "lainnya")
+		writer.WriteField("status", "lainnya")
+		writer.WriteField("religion", "religion")
+
+		part, err := writer.CreateFormFile("file", "photo.jpg")
+		if err != nil {
+			log.Warn(err)
+		}
+		part.Write([]byte(`sample`))
+		writer.Close()
+
+		var e = echo.New()
+
+		var req = httptest.NewRequest(http.MethodPost, "/", reqBody)
+		var res = httptest.NewRecorder()
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/doctor")
+
+		var controller = New(&mockSuccess{}, &infected{}, &successLogic{})
+		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		var response = ResponseFormat{}
+
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "file is infected with Eicar-Test-Signature", response.Message)
+	})
+
 	t.Run("succeess upload file", func(t *testing.T) {
 
 		var reqBody = new(bytes.Buffer)
@@ -1355,7 +1463,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&defaultImage{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&defaultImage{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1400,7 +1508,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&defaultImage{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&defaultImage{}, &failPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1436,7 +1544,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1472,7 +1580,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&userNameCheck{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&userNameCheck{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1508,7 +1616,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&emailCheck{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&emailCheck{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1544,7 +1652,7 @@ func TestUpdate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Update())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1597,7 +1705,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1622,7 +1730,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &failTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &failPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1646,7 +1754,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1670,7 +1778,7 @@ func TestDelete(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Delete())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1723,7 +1831,7 @@ func TestGetProfile(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetProfile())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1734,6 +1842,8 @@ func TestGetProfile(t *testing.T) {
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
 		assert.Equal(t, 200, response.Code)
+		assert.Equal(t, "https://files.example.com/testing/large.jpg", response.Data.(map[string]interface{})["image"])
+		assert.Equal(t, "https://files.example.com/testing/small.jpg", response.Data.(map[string]interface{})["images"].(map[string]interface{})["small"])
 	})
 
 	t.Run("success query param all", func(t *testing.T) {
@@ -1749,7 +1859,7 @@ func TestGetProfile(t *testing.T) {
 		context.QueryParams().Add("all", "all")
 		// log.Info(context.QueryString())
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.GetProfile()(context)
 
 		var response = ResponseFormat{}
@@ -1772,7 +1882,7 @@ func TestGetProfile(t *testing.T) {
 		context.QueryParams().Add("all", "all")
 		// log.Info(context.QueryString())
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		controller.GetProfile()(context)
 
 		var response = ResponseFormat{}
@@ -1782,6 +1892,28 @@ func TestGetProfile(t *testing.T) {
 		assert.Equal(t, 500, response.Code)
 	})
 
+	t.Run("invalid gender query param all", func(t *testing.T) {
+		var e = echo.New()
+
+		var req = httptest.NewRequest(http.MethodGet, "/?all=all&gender=pria,laki", bytes.NewBuffer(nil))
+		var res = httptest.NewRecorder()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
+
+		context := e.NewContext(req, res)
+		context.SetPath("/patient/profile")
+
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
+		controller.GetProfile()(context)
+
+		var response = ResponseFormat{}
+
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "invalid gender input", response.Message)
+	})
+
 	t.Run("success query param", func(t *testing.T) {
 		var e = echo.New()
 
@@ -1795,7 +1927,7 @@ func TestGetProfile(t *testing.T) {
 		context.QueryParams().Add("patient_uid", "ini_query")
 		// log.Info(context.QueryString())
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		controller.GetProfile()(context)
 
 		var response = ResponseFormat{}
@@ -1816,7 +1948,7 @@ func TestGetProfile(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetProfile())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1840,7 +1972,7 @@ func TestGetProfile(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetProfile())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1893,7 +2025,7 @@ func TestGetCheck(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetCheck())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1917,7 +2049,7 @@ func TestGetCheck(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&recordNotFound{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&recordNotFound{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetCheck())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1941,7 +2073,7 @@ func TestGetCheck(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockFail{}, &mockTaskS3M{}, &successLogic{})
+		var controller = New(&mockFail{}, &mockPhoto{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetCheck())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -0,0 +1,43 @@
+package reconcile
+
+import (
+	"be/delivery/controllers/templates"
+	"be/delivery/jobs/reconcile"
+	"be/delivery/middlewares"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	j reconcile.Reconciler
+}
+
+func New(j reconcile.Reconciler) *Controller {
+	return &Controller{
+		j: j,
+	}
+}
+
+// Orphans reports the stored files nothing refers to without removing them
+func (cont *Controller) Orphans() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var _, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can see the storage", nil))
+		}
+
+		// storage
+
+		res, err := cont.j.Run(time.Now(), true)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get orphaned files", res))
+	}
+}
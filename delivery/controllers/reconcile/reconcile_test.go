@@ -0,0 +1,72 @@
+package reconcile
+
+import (
+	"be/configs"
+	"be/delivery/jobs/reconcile"
+	"be/delivery/middlewares"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockJob struct {
+	dryRun bool
+	err    error
+}
+
+func (m *mockJob) Run(now time.Time, dryRun bool) (reconcile.Report, error) {
+	m.dryRun = dryRun
+	return reconcile.Report{DryRun: dryRun, Orphans: 2}, m.err
+}
+
+func request(t *testing.T, kind string, handler echo.HandlerFunc) ResponseFormat {
+	var token, err = middlewares.GenerateToken(kind, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodGet, "/", nil)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetPath("/storage/orphans")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestOrphans(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var job = &mockJob{}
+		var response = request(t, "admin", New(job).Orphans())
+		assert.Equal(t, 200, response.Code)
+		assert.True(t, job.dryRun)
+		assert.Equal(t, float64(2), response.Data.(map[string]interface{})["orphans"])
+	})
+
+	t.Run("not admin", func(t *testing.T) {
+		var response = request(t, "doctor", New(&mockJob{}).Orphans())
+		assert.Equal(t, 401, response.Code)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		var response = request(t, "admin", New(&mockJob{err: errors.New("")}).Orphans())
+		assert.Equal(t, 500, response.Code)
+	})
+}
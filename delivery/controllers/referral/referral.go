@@ -0,0 +1,220 @@
+package referral
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/referral"
+	"be/delivery/middlewares"
+	"be/repository/referral"
+	"be/utils/list"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	r referral.Referral
+	l logic.Referral
+}
+
+func New(r referral.Referral, l logic.Referral) *Controller {
+	return &Controller{
+		r: r,
+		l: l,
+	}
+}
+
+func (cont *Controller) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can refer patient", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Create(visit_uid, uid, *req.ToReferral())
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("visit is not found")
+			case "visit is cancelled", "can't refer to yourself", "target doctor is not found", "target doctor is unavailable", "referral is already exist":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add referral", map[string]interface{}{
+			"referral_uid": res.Referral_uid,
+		}))
+	}
+}
+
+func (cont *Controller) Accept() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var referral_uid = c.Param("referral_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can accept referral", nil))
+		}
+
+		var req logic.AnswerReq
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationAccept(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		var visit, _ = req.ToVisit()
+
+		// database
+
+		res, err := cont.r.Accept(referral_uid, uid, *visit)
+
+		if err != nil {
+			log.Warn(err)
+			switch {
+			case err.Error() == "record not found":
+				err = errors.New("referral is not found")
+			case strings.Contains(err.Error(), "referral is already"), err.Error() == "there's another appoinment in pending", err.Error() == "doctor is busy on the date":
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success accept referral", map[string]interface{}{
+			"referral_uid": res.Referral_uid,
+			"visit_uid":    res.Target_visit_uid,
+		}))
+	}
+}
+
+func (cont *Controller) Decline() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var referral_uid = c.Param("referral_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can decline referral", nil))
+		}
+
+		var req logic.AnswerReq
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationDecline(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, err := cont.r.Decline(referral_uid, uid, req.Response)
+
+		if err != nil {
+			log.Warn(err)
+			switch {
+			case err.Error() == "record not found":
+				err = errors.New("referral is not found")
+			case strings.Contains(err.Error(), "referral is already"):
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success decline referral", map[string]interface{}{
+			"referral_uid": res.Referral_uid,
+		}))
+	}
+}
+
+func (cont *Controller) GetReferral() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var referral_uid = c.Param("referral_uid")
+		var uid, _ = middlewares.ExtractTokenUid(c)
+
+		// database
+
+		res, err := cont.r.GetReferral(referral_uid)
+
+		// only both doctors and the patient can see the referral
+
+		if err == nil && uid != res.From_doctor_uid && uid != res.To_doctor_uid && uid != res.Patient_uid {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "access denied", nil))
+		}
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("referral is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get referral", res))
+	}
+}
+
+func (cont *Controller) GetReferrals() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+		var box = c.QueryParam("kind")
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can see referrals", nil))
+		}
+
+		switch box {
+		case "", "sent", "received":
+		default:
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid kind input", nil))
+		}
+
+		q, err := list.Parse(referral.List, c.QueryParams())
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		res, page, err := cont.r.GetReferrals(box, uid, q)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get referrals", res, page))
+	}
+}
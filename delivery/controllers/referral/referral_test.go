@@ -0,0 +1,172 @@
+package referral
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/referral"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/referral"
+	"be/utils/list"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct{}
+
+func (m *mockSuccess) Create(visit_uid, doctor_uid string, req entities.Referral) (entities.Referral, error) {
+	return entities.Referral{Referral_uid: "referral"}, nil
+}
+
+func (m *mockSuccess) Accept(referral_uid, doctor_uid string, req entities.Visit) (entities.Referral, error) {
+	return entities.Referral{Referral_uid: referral_uid, Target_visit_uid: "patient-2"}, nil
+}
+
+func (m *mockSuccess) Decline(referral_uid, doctor_uid, response string) (entities.Referral, error) {
+	return entities.Referral{Referral_uid: referral_uid}, nil
+}
+
+func (m *mockSuccess) GetReferral(referral_uid string) (referral.ReferralResp, error) {
+	return referral.ReferralResp{Referral_uid: referral_uid, From_doctor_uid: "doctor", To_doctor_uid: "target", Patient_uid: "patient"}, nil
+}
+
+func (m *mockSuccess) GetReferrals(kind, doctor_uid string, q list.Query) (referral.Referrals, list.Page, error) {
+	return referral.Referrals{}, list.Page{}, nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) Create(visit_uid, doctor_uid string, req entities.Referral) (entities.Referral, error) {
+	return entities.Referral{}, errors.New("can't refer to yourself")
+}
+
+func (m *mockFail) Accept(referral_uid, doctor_uid string, req entities.Visit) (entities.Referral, error) {
+	return entities.Referral{}, errors.New("referral is already declined")
+}
+
+func (m *mockFail) Decline(referral_uid, doctor_uid, response string) (entities.Referral, error) {
+	return entities.Referral{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetReferral(referral_uid string) (referral.ReferralResp, error) {
+	return referral.ReferralResp{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetReferrals(kind, doctor_uid string, q list.Query) (referral.Referrals, list.Page, error) {
+	return referral.Referrals{}, list.Page{}, errors.New("")
+}
+
+func request(t *testing.T, method, query string, body interface{}, uid, kind string, handler echo.HandlerFunc) ResponseFormat {
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reqBody, _ = json.Marshal(body)
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/"+query, bytes.NewBuffer(reqBody))
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("visit_uid", "referral_uid")
+	context.SetParamValues("visit", "referral")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestCreate(t *testing.T) {
+	var body = map[string]interface{}{"to_doctor_uid": "target", "reason": "suspected fracture", "urgency": "urgent"}
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, "", body, "doctor", "doctor", controller.Create())
+		assert.Equal(t, 201, response.Code)
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, "", body, "patient", "patient", controller.Create())
+		assert.Equal(t, 401, response.Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var response = request(t, http.MethodPost, "", map[string]interface{}{"to_doctor_uid": "target"}, "doctor", "doctor", controller.Create())
+		assert.Equal(t, 400, response.Code)
+	})
+
+	t.Run("self referral", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		var response = request(t, http.MethodPost, "", body, "doctor", "doctor", controller.Create())
+		assert.Equal(t, "can't refer to yourself", response.Message)
+	})
+}
+
+func TestAnswer(t *testing.T) {
+	var accept = map[string]interface{}{"date": time.Now().AddDate(0, 0, 1).Format("02-01-2006")}
+	var decline = map[string]interface{}{"response": "fully booked this month"}
+
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 202, request(t, http.MethodPut, "", accept, "target", "doctor", controller.Accept()).Code)
+		assert.Equal(t, 202, request(t, http.MethodPut, "", decline, "target", "doctor", controller.Decline()).Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 400, request(t, http.MethodPut, "", map[string]interface{}{}, "target", "doctor", controller.Accept()).Code)
+		assert.Equal(t, 400, request(t, http.MethodPut, "", map[string]interface{}{}, "target", "doctor", controller.Decline()).Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, "referral is already declined", request(t, http.MethodPut, "", accept, "target", "doctor", controller.Accept()).Message)
+		assert.Equal(t, "referral is not found", request(t, http.MethodPut, "", decline, "target", "doctor", controller.Decline()).Message)
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 200, request(t, http.MethodGet, "", nil, "patient", "patient", controller.GetReferral()).Code)
+		assert.Equal(t, 200, request(t, http.MethodGet, "?kind=received&status=sent", nil, "target", "doctor", controller.GetReferrals()).Code)
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 401, request(t, http.MethodGet, "", nil, "other", "doctor", controller.GetReferral()).Code)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 400, request(t, http.MethodGet, "?kind=all", nil, "doctor", "doctor", controller.GetReferrals()).Code)
+		assert.Equal(t, 400, request(t, http.MethodGet, "?status=lost", nil, "doctor", "doctor", controller.GetReferrals()).Code)
+		assert.Equal(t, "invalid sort name", request(t, http.MethodGet, "?sort=name", nil, "doctor", "doctor", controller.GetReferrals()).Message)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, "referral is not found", request(t, http.MethodGet, "", nil, "doctor", "doctor", controller.GetReferral()).Message)
+		assert.Equal(t, 500, request(t, http.MethodGet, "", nil, "doctor", "doctor", controller.GetReferrals()).Code)
+	})
+}
@@ -0,0 +1,138 @@
+package report
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/report"
+	"be/delivery/middlewares"
+	"be/repository/report"
+	"be/utils/sheet"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// table is a report that can be downloaded as csv
+
+type table interface {
+	Rows() [][]string
+}
+
+type Controller struct {
+	r report.Report
+	l logic.Report
+}
+
+func New(r report.Report, l logic.Report) *Controller {
+	return &Controller{
+		r: r,
+		l: l,
+	}
+}
+
+func (cont *Controller) Visits() echo.HandlerFunc {
+	return cont.report("visits", func(q report.Query) (table, error) {
+		var rows, err = cont.r.GetVisits(q)
+		if err != nil {
+			return nil, err
+		}
+		return cont.l.Visits(q, rows), nil
+	})
+}
+
+func (cont *Controller) Patients() echo.HandlerFunc {
+	return cont.report("patients", func(q report.Query) (table, error) {
+		var rows, err = cont.r.GetPatients(q)
+		if err != nil {
+			return nil, err
+		}
+		return cont.l.Patients(q, rows), nil
+	})
+}
+
+func (cont *Controller) Diagnoses() echo.HandlerFunc {
+	return cont.report("diagnoses", func(q report.Query) (table, error) {
+		var rows, err = cont.r.GetDiagnoses(q)
+		if err != nil {
+			return nil, err
+		}
+		return cont.l.Diagnoses(q, rows), nil
+	})
+}
+
+func (cont *Controller) Demographics() echo.HandlerFunc {
+	return cont.report("demographics", func(q report.Query) (table, error) {
+		var rows, err = cont.r.GetDemographics(q)
+		if err != nil {
+			return nil, err
+		}
+		return cont.l.Demographics(q, rows), nil
+	})
+}
+
+// report answers with the report of the admin's clinic as json, or as csv with ?format=csv
+
+func (cont *Controller) report(name string, build func(q report.Query) (table, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, role = middlewares.ExtractTokenUid(c)
+
+		if role != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can see reports", nil))
+		}
+
+		var format = c.QueryParam("format")
+
+		if err := cont.l.ValidationFormat(format); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		q, err := cont.l.ValidationQuery(logic.Req{
+			From:   c.QueryParam("from"),
+			To:     c.QueryParam("to"),
+			Period: c.QueryParam("period"),
+			Limit:  c.QueryParam("limit"),
+		})
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		q.Doctor_uid, err = cont.r.GetClinic(uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("clinic is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		res, err := build(q)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		if format != "csv" {
+			return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get "+name+" report", res))
+		}
+
+		body, err := sheet.Csv(res.Rows())
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("report-%s-%s-%s.csv", name, q.From.Format("20060102"), q.To.Format("20060102"))))
+		return c.Blob(http.StatusOK, "text/csv", body)
+	}
+}
@@ -0,0 +1,149 @@
+package report
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/report"
+	"be/delivery/middlewares"
+	"be/repository/report"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct{}
+
+func (m *mockSuccess) GetClinic(admin_uid string) (string, error) {
+	return "doctor", nil
+}
+
+func (m *mockSuccess) GetVisits(q report.Query) ([]report.VisitRow, error) {
+	return []report.VisitRow{{Period: "2022-03", Status: "completed", Total: 3}, {Period: "2022-03", Status: "cancelled", Total: 1}}, nil
+}
+
+func (m *mockSuccess) GetPatients(q report.Query) ([]report.PatientRow, error) {
+	return []report.PatientRow{{Period: "2022-03", Total: 2, New: 1}}, nil
+}
+
+func (m *mockSuccess) GetDiagnoses(q report.Query) ([]report.DiagnoseRow, error) {
+	return []report.DiagnoseRow{{Period: "2022-03", Diagnose: "flu", Total: 3}}, nil
+}
+
+func (m *mockSuccess) GetDemographics(q report.Query) ([]report.DemographicRow, error) {
+	return []report.DemographicRow{{Period: "2022-03", Gender: "wanita", AgeGroup: "30-44", Total: 2}}, nil
+}
+
+type mockFail struct{}
+
+func (m *mockFail) GetClinic(admin_uid string) (string, error) {
+	return "", gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) GetVisits(q report.Query) ([]report.VisitRow, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) GetPatients(q report.Query) ([]report.PatientRow, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) GetDiagnoses(q report.Query) ([]report.DiagnoseRow, error) {
+	return nil, errors.New("")
+}
+
+func (m *mockFail) GetDemographics(q report.Query) ([]report.DemographicRow, error) {
+	return nil, errors.New("")
+}
+
+func request(t *testing.T, query, kind string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var token, err = middlewares.GenerateToken("admin", kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+var query = "from=01-03-2022&to=31-03-2022&period=month"
+
+func TestVisits(t *testing.T) {
+	t.Run("success json", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var res = response(request(t, query, "admin", controller.Visits()))
+		assert.Equal(t, 200, res.Code)
+
+		var summary = res.Data.(map[string]interface{})["summary"].(map[string]interface{})
+		assert.Equal(t, float64(4), summary["total"])
+		assert.Equal(t, float64(25), summary["cancellationRate"])
+	})
+
+	t.Run("success csv", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var res = request(t, query+"&format=csv", "admin", controller.Visits())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, "text/csv", res.Header().Get(echo.HeaderContentType))
+		assert.True(t, strings.Contains(res.Body.String(), "2022-03,4,0,0,3,1,0,25.00,0.00"))
+	})
+
+	t.Run("doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 401, request(t, query, "doctor", controller.Visits()).Code)
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, "invalid period input", response(request(t, "period=year", "admin", controller.Visits())).Message)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, "invalid format input", response(request(t, "format=pdf", "admin", controller.Visits())).Message)
+	})
+
+	t.Run("clinic not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, "clinic is not found", response(request(t, query, "admin", controller.Visits())).Message)
+	})
+}
+
+func TestReports(t *testing.T) {
+	var controller = New(&mockSuccess{}, logic.New())
+
+	t.Run("success patients", func(t *testing.T) {
+		assert.True(t, strings.Contains(request(t, query+"&format=csv", "admin", controller.Patients()).Body.String(), "2022-03,2,1,1"))
+	})
+
+	t.Run("success diagnoses", func(t *testing.T) {
+		assert.True(t, strings.Contains(request(t, query+"&format=csv", "admin", controller.Diagnoses()).Body.String(), "2022-03,1,flu,3"))
+	})
+
+	t.Run("success demographics", func(t *testing.T) {
+		assert.Equal(t, 200, response(request(t, query, "admin", controller.Demographics())).Code)
+	})
+}
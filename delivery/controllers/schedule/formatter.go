@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"be/entities"
+	"be/repository/schedule"
+	"time"
+)
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+type ScheduleResp struct {
+	Schedule_uid string `json:"schedule_uid"`
+	Kind         string `json:"kind"`
+	Cron         string `json:"cron"`
+	Timezone     string `json:"timezone"`
+	Email        string `json:"email"`
+	Active       bool   `json:"active"`
+	NextRunAt    string `json:"nextRunAt"`
+	LastRunAt    string `json:"lastRunAt"`
+}
+
+type RunResp struct {
+	Run_uid     string `json:"run_uid"`
+	ScheduledAt string `json:"scheduledAt"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	Error       string `json:"error"`
+	NextRetryAt string `json:"nextRetryAt"`
+	FinishedAt  string `json:"finishedAt"`
+}
+
+// the times are shown in the timezone of the schedule
+
+const layout = "02-01-2006 15:04"
+
+func format(t *time.Time, location *time.Location) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.In(location).Format(layout)
+}
+
+func location(timezone string) *time.Location {
+	if location, err := time.LoadLocation(timezone); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+func ToScheduleResp(s entities.ReportSchedule) ScheduleResp {
+	var loc = location(s.Timezone)
+
+	return ScheduleResp{
+		Schedule_uid: s.Schedule_uid,
+		Kind:         s.Kind,
+		Cron:         s.Cron,
+		Timezone:     s.Timezone,
+		Email:        s.Email,
+		Active:       s.Active,
+		NextRunAt:    format(&s.NextRunAt, loc),
+		LastRunAt:    format(s.LastRunAt, loc),
+	}
+}
+
+func ToRunResp(runs []schedule.Run, timezone string) []RunResp {
+	var loc = location(timezone)
+	var res = []RunResp{}
+
+	for _, run := range runs {
+		res = append(res, RunResp{
+			Run_uid:     run.Run_uid,
+			ScheduledAt: format(&run.ScheduledAt, loc),
+			Status:      run.Status,
+			Attempts:    run.Attempts,
+			Error:       run.Error,
+			NextRetryAt: format(run.NextRetryAt, loc),
+			FinishedAt:  format(run.FinishedAt, loc),
+		})
+	}
+
+	return res
+}
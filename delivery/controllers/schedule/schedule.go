@@ -0,0 +1,256 @@
+package schedule
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/schedule"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/schedule"
+	"be/utils/list"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	r schedule.Schedule
+	l logic.Schedule
+}
+
+func New(r schedule.Schedule, l logic.Schedule) *Controller {
+	return &Controller{
+		r: r,
+		l: l,
+	}
+}
+
+func (cont *Controller) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can schedule reports", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		clinic, err := cont.r.GetClinic(uid)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("clinic is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		var s = req.ToReportSchedule()
+		s.Admin_uid = uid
+		s.Doctor_uid = clinic.Doctor_uid
+		s.NextRunAt, _ = cont.l.Next(s.Cron, s.Timezone, time.Now())
+
+		res, err := cont.r.Create(*s)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add schedule", ToScheduleResp(res)))
+	}
+}
+
+func (cont *Controller) GetSchedules() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can see schedules", nil))
+		}
+
+		// database
+
+		res, err := cont.r.GetSchedules(uid)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		var schedules = []ScheduleResp{}
+		for _, s := range res {
+			schedules = append(schedules, ToScheduleResp(s))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get schedules", map[string]interface{}{
+			"schedules": schedules,
+		}))
+	}
+}
+
+// Update changes the cron, timezone, email or pauses the schedule, the kind is kept
+
+func (cont *Controller) Update() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var schedule_uid = c.Param("schedule_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can update schedules", nil))
+		}
+
+		var req logic.Req
+
+		if err := c.Bind(&req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		// database
+
+		old, err := cont.find(schedule_uid, uid)
+
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		req.Kind = old.Kind
+		if req.Cron == "" {
+			req.Cron = old.Cron
+		}
+		if req.Timezone == "" {
+			req.Timezone = old.Timezone
+		}
+		if req.Email == "" {
+			req.Email = old.Email
+		}
+		if req.Active == nil {
+			req.Active = &old.Active
+		}
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		var s = req.ToReportSchedule()
+		s.NextRunAt, _ = cont.l.Next(s.Cron, s.Timezone, time.Now())
+
+		res, err := cont.r.Update(schedule_uid, uid, *s)
+
+		if err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("schedule is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success update schedule", ToScheduleResp(res)))
+	}
+}
+
+func (cont *Controller) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var schedule_uid = c.Param("schedule_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can delete schedules", nil))
+		}
+
+		// database
+
+		if err := cont.r.Delete(schedule_uid, uid); err != nil {
+			log.Warn(err)
+			switch err.Error() {
+			case "record not found":
+				err = errors.New("schedule is not found")
+			default:
+				err = errors.New("there's problem in server")
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success delete schedule", nil))
+	}
+}
+
+// GetRuns is the run log of the schedule with the error of the failed runs
+
+func (cont *Controller) GetRuns() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var schedule_uid = c.Param("schedule_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		if kind != "admin" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only the clinic admin can see schedules", nil))
+		}
+
+		q, err := list.Parse(schedule.Runs, c.QueryParams())
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
+
+		s, err := cont.find(schedule_uid, uid)
+
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+		}
+
+		runs, page, err := cont.r.GetRuns(schedule_uid, q)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get runs", map[string]interface{}{
+			"runs": ToRunResp(runs, s.Timezone),
+		}, page))
+	}
+}
+
+// find returns the schedule of the admin, the schedules of others are not found
+
+func (cont *Controller) find(schedule_uid, admin_uid string) (entities.ReportSchedule, error) {
+	var s, err = cont.r.GetSchedule(schedule_uid)
+
+	if err == nil && s.Admin_uid != admin_uid {
+		err = errors.New("record not found")
+	}
+
+	if err != nil {
+		log.Warn(err)
+		switch err.Error() {
+		case "record not found":
+			return entities.ReportSchedule{}, errors.New("schedule is not found")
+		default:
+			return entities.ReportSchedule{}, errors.New("there's problem in server")
+		}
+	}
+
+	return s, nil
+}
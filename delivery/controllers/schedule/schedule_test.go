@@ -0,0 +1,205 @@
+package schedule
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/schedule"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/schedule"
+	"be/utils/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockSuccess struct {
+	updated entities.ReportSchedule
+}
+
+func (m *mockSuccess) GetClinic(admin_uid string) (schedule.Clinic, error) {
+	return schedule.Clinic{Doctor_uid: "doctor", Name: "klinik sehat"}, nil
+}
+
+func (m *mockSuccess) Create(req entities.ReportSchedule) (entities.ReportSchedule, error) {
+	req.Schedule_uid = "schedule"
+	return req, nil
+}
+
+func (m *mockSuccess) Update(schedule_uid, admin_uid string, req entities.ReportSchedule) (entities.ReportSchedule, error) {
+	m.updated = req
+	return req, nil
+}
+
+func (m *mockSuccess) Delete(schedule_uid, admin_uid string) error { return nil }
+
+func (m *mockSuccess) GetSchedule(schedule_uid string) (entities.ReportSchedule, error) {
+	return entities.ReportSchedule{Schedule_uid: schedule_uid, Admin_uid: "admin", Kind: "monthly", Cron: "0 7 1 * *", Timezone: "Asia/Jakarta", Email: "owner@mail.com", Active: true}, nil
+}
+
+func (m *mockSuccess) GetSchedules(admin_uid string) ([]entities.ReportSchedule, error) {
+	return []entities.ReportSchedule{{Schedule_uid: "schedule", Kind: "monthly", Timezone: "Asia/Jakarta"}}, nil
+}
+
+func (m *mockSuccess) GetRuns(schedule_uid string, q list.Query) ([]schedule.Run, list.Page, error) {
+	var retry = time.Date(2022, 3, 1, 0, 5, 0, 0, time.UTC)
+	return []schedule.Run{{ReportRun: entities.ReportRun{Run_uid: "run", ScheduledAt: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), Status: "failed", Attempts: 1, Error: "connection refused", NextRetryAt: &retry}}}, list.Page{Total: 1, Limit: q.Limit}, nil
+}
+
+func (m *mockSuccess) GetAppointments(doctor_uid string, date time.Time) ([]schedule.Appointment, error) {
+	return nil, nil
+}
+
+func (m *mockSuccess) Lease(name, holder string, ttl time.Duration) (bool, error) { return true, nil }
+
+func (m *mockSuccess) GetDue(now time.Time) ([]entities.ReportSchedule, error) { return nil, nil }
+
+func (m *mockSuccess) CreateRun(s entities.ReportSchedule, next time.Time) (entities.ReportRun, error) {
+	return entities.ReportRun{}, nil
+}
+
+func (m *mockSuccess) GetRetries(now time.Time) ([]entities.ReportRun, error) { return nil, nil }
+
+func (m *mockSuccess) Claim(run_uid string, now time.Time) (entities.ReportRun, error) {
+	return entities.ReportRun{}, nil
+}
+
+func (m *mockSuccess) Sent(run_uid string) error { return nil }
+
+func (m *mockSuccess) Fail(run_uid, message string, retry *time.Time) error { return nil }
+
+type mockFail struct {
+	mockSuccess
+}
+
+func (m *mockFail) GetClinic(admin_uid string) (schedule.Clinic, error) {
+	return schedule.Clinic{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockFail) Delete(schedule_uid, admin_uid string) error {
+	return errors.New("record not found")
+}
+
+func (m *mockFail) GetSchedule(schedule_uid string) (entities.ReportSchedule, error) {
+	return entities.ReportSchedule{}, gorm.ErrRecordNotFound
+}
+
+func request(t *testing.T, method string, body io.Reader, uid, kind string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/", body)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+	context.SetParamNames("schedule_uid")
+	context.SetParamValues("schedule")
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	return res
+}
+
+func response(res *httptest.ResponseRecorder) ResponseFormat {
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func TestCreate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var res = response(request(t, http.MethodPost, strings.NewReader(`{"kind":"appointments","email":"owner@mail.com"}`), "admin", "admin", controller.Create()))
+		assert.Equal(t, 201, res.Code)
+
+		var data = res.Data.(map[string]interface{})
+		assert.Equal(t, "0 18 * * *", data["cron"])
+		assert.True(t, strings.HasSuffix(data["nextRunAt"].(string), "18:00"))
+	})
+
+	t.Run("doctor", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 401, request(t, http.MethodPost, strings.NewReader(`{"kind":"appointments","email":"owner@mail.com"}`), "doctor", "doctor", controller.Create()).Code)
+	})
+
+	t.Run("invalid cron", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, "invalid cron", response(request(t, http.MethodPost, strings.NewReader(`{"kind":"monthly","cron":"every day","email":"owner@mail.com"}`), "admin", "admin", controller.Create())).Message)
+	})
+
+	t.Run("clinic not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, "clinic is not found", response(request(t, http.MethodPost, strings.NewReader(`{"kind":"monthly","email":"owner@mail.com"}`), "admin", "admin", controller.Create())).Message)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("success pause", func(t *testing.T) {
+		var r = &mockSuccess{}
+		var controller = New(r, logic.New())
+		assert.Equal(t, 200, request(t, http.MethodPut, strings.NewReader(`{"active":false}`), "admin", "admin", controller.Update()).Code)
+		assert.False(t, r.updated.Active)
+		assert.Equal(t, "0 7 1 * *", r.updated.Cron)
+		assert.Equal(t, "owner@mail.com", r.updated.Email)
+	})
+
+	t.Run("other admin", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, "schedule is not found", response(request(t, http.MethodPut, strings.NewReader(`{"active":false}`), "other", "admin", controller.Update())).Message)
+	})
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, "invalid timezone", response(request(t, http.MethodPut, strings.NewReader(`{"timezone":"Mars/Base"}`), "admin", "admin", controller.Update())).Message)
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("success schedules", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 200, request(t, http.MethodGet, nil, "admin", "admin", controller.GetSchedules()).Code)
+	})
+
+	t.Run("success runs", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		var res = response(request(t, http.MethodGet, nil, "admin", "admin", controller.GetRuns()))
+		var run = res.Data.(map[string]interface{})["runs"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "01-03-2022 07:05", run["nextRetryAt"])
+		assert.Equal(t, "connection refused", run["error"])
+	})
+
+	t.Run("runs not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, "schedule is not found", response(request(t, http.MethodGet, nil, "admin", "admin", controller.GetRuns())).Message)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var controller = New(&mockSuccess{}, logic.New())
+		assert.Equal(t, 200, request(t, http.MethodDelete, nil, "admin", "admin", controller.Delete()).Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var controller = New(&mockFail{}, logic.New())
+		assert.Equal(t, "schedule is not found", response(request(t, http.MethodDelete, nil, "admin", "admin", controller.Delete())).Message)
+	})
+}
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/search"
+	"be/delivery/middlewares"
+	"be/repository/search"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type Controller struct {
+	r search.Search
+	l logic.Search
+}
+
+func New(r search.Search, l logic.Search) *Controller {
+	return &Controller{
+		r: r,
+		l: l,
+	}
+}
+
+// Search finds patients or visits, most relevant first. Patients only search
+// their visits, doctors their visits and the patients visiting them and admins
+// all patients and the visits of their clinic
+
+func (cont *Controller) Search() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		q, err := cont.l.ValidationQuery(logic.Req{
+			Q:      c.QueryParam("q"),
+			Type:   c.QueryParam("type"),
+			Limit:  c.QueryParam("limit"),
+			Offset: c.QueryParam("offset"),
+		})
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		var target = c.QueryParam("type")
+
+		switch kind {
+		case "patient":
+			if target == "patient" {
+				return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "patient can only search their visits", nil))
+			}
+			q.Patient_uid = uid
+		case "doctor":
+			q.Doctor_uid = uid
+		case "admin":
+			if target == "visit" {
+
+				// database
+
+				q.Doctor_uid, err = cont.r.GetClinic(uid)
+
+				if err != nil {
+					log.Warn(err)
+					switch err.Error() {
+					case "record not found":
+						err = errors.New("clinic is not found")
+					default:
+						err = errors.New("there's problem in server")
+					}
+					return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+				}
+			}
+		default:
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "invalid token", nil))
+		}
+
+		// database
+
+		if target == "patient" {
+			rows, err := cont.r.Patients(q)
+
+			if err != nil {
+				log.Warn(err)
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+			}
+
+			return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success search patients", map[string]interface{}{
+				"patients": cont.l.Patients(q, rows),
+			}))
+		}
+
+		rows, err := cont.r.Visits(q)
+
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's problem in server", nil))
+		}
+
+		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success search visits", map[string]interface{}{
+			"visits": cont.l.Visits(q, rows),
+		}))
+	}
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"be/configs"
+	logic "be/delivery/logic/search"
+	"be/delivery/middlewares"
+	"be/repository/search"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockFail struct {
+	*search.Memory
+}
+
+func (m *mockFail) Patients(q search.Query) ([]search.Patient, error) {
+	return nil, errors.New("")
+}
+
+func memory() *search.Memory {
+	var m = search.NewMemory()
+	m.AddClinic("admin", "doctor")
+	m.AddPatient(search.Patient{Patient_uid: "budi", Name: "Budi Santoso", Phone: "081234567890", Address: "Jalan Mawar"})
+	m.AddPatient(search.Patient{Patient_uid: "siti", Name: "Siti", Address: "Jalan Budi Utomo"})
+	m.AddVisit(search.Visit{Visit_uid: "budi-visit", Doctor_uid: "doctor", Patient_uid: "budi", Complaint: "demam"})
+	m.AddVisit(search.Visit{Visit_uid: "siti-visit", Doctor_uid: "other", Patient_uid: "siti", Complaint: "demam tinggi"})
+	return m
+}
+
+func request(t *testing.T, query, uid, kind string, handler echo.HandlerFunc) ResponseFormat {
+	var token, err = middlewares.GenerateToken(uid, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	context := e.NewContext(req, res)
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return response
+}
+
+func uids(res ResponseFormat, name, key string) []string {
+	var uids = []string{}
+	for _, row := range res.Data.(map[string]interface{})[name].([]interface{}) {
+		uids = append(uids, row.(map[string]interface{})[key].(string))
+	}
+	return uids
+}
+
+func TestSearch(t *testing.T) {
+	t.Run("success patients of admin", func(t *testing.T) {
+		var controller = New(memory(), logic.New())
+		var res = request(t, "type=patient&q=budi", "admin", "admin", controller.Search())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, []string{"budi", "siti"}, uids(res, "patients", "patient_uid"))
+
+		var highlight = res.Data.(map[string]interface{})["patients"].([]interface{})[0].(map[string]interface{})["highlight"]
+		assert.Equal(t, map[string]interface{}{"name": "<em>Budi</em> Santoso"}, highlight)
+	})
+
+	t.Run("success patients of doctor", func(t *testing.T) {
+		var controller = New(memory(), logic.New())
+		var res = request(t, "type=patient&q=jalan", "doctor", "doctor", controller.Search())
+		assert.Equal(t, []string{"budi"}, uids(res, "patients", "patient_uid"))
+	})
+
+	t.Run("success visits of patient", func(t *testing.T) {
+		var controller = New(memory(), logic.New())
+		var res = request(t, "type=visit&q=demam", "siti", "patient", controller.Search())
+		assert.Equal(t, []string{"siti-visit"}, uids(res, "visits", "visit_uid"))
+	})
+
+	t.Run("success visits of clinic", func(t *testing.T) {
+		var controller = New(memory(), logic.New())
+		var res = request(t, "type=visit&q=demam", "admin", "admin", controller.Search())
+		assert.Equal(t, []string{"budi-visit"}, uids(res, "visits", "visit_uid"))
+	})
+
+	t.Run("patient searching patients", func(t *testing.T) {
+		var controller = New(memory(), logic.New())
+		assert.Equal(t, 401, request(t, "type=patient&q=budi", "siti", "patient", controller.Search()).Code)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		var controller = New(memory(), logic.New())
+		assert.Equal(t, "invalid type input", request(t, "type=doctor&q=budi", "admin", "admin", controller.Search()).Message)
+	})
+
+	t.Run("clinic not found", func(t *testing.T) {
+		var controller = New(memory(), logic.New())
+		assert.Equal(t, "clinic is not found", request(t, "type=visit&q=demam", "other", "admin", controller.Search()).Message)
+	})
+
+	t.Run("internal server", func(t *testing.T) {
+		var controller = New(&mockFail{search.NewMemory()}, logic.New())
+		assert.Equal(t, 500, request(t, "type=patient&q=budi", "admin", "admin", controller.Search()).Code)
+	})
+}
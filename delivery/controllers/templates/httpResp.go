@@ -8,6 +8,7 @@ type Response struct {
 	Code    interface{} `json:"code"`
 	Message interface{} `json:"message"`
 	Data    interface{} `json:"data"`
+	Meta    interface{} `json:"meta,omitempty"`
 }
 
 func Success(code interface{}, msg interface{}, data interface{}) Response {
@@ -27,6 +28,14 @@ func Success(code interface{}, msg interface{}, data interface{}) Response {
 	}
 }
 
+// List is the success of a list endpoint, meta holds the total and the cursor of the next page
+
+func List(code interface{}, msg interface{}, data interface{}, meta interface{}) Response {
+	var res = Success(code, msg, data)
+	res.Meta = meta
+	return res
+}
+
 func InternalServerError(code interface{}, msg interface{}, data interface{}) Response {
 	if code == nil {
 		code = http.StatusInternalServerError
@@ -0,0 +1,51 @@
+package upload
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+type ResponseFormat struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+// the headers of the tus protocol, https://tus.io/protocols/resumable-upload
+
+const (
+	tusVersion     = "1.0.0"
+	offsetType     = "application/offset+octet-stream"
+	headerResume   = "Tus-Resumable"
+	headerLength   = "Upload-Length"
+	headerOffset   = "Upload-Offset"
+	headerMeta     = "Upload-Metadata"
+	headerChecksum = "Upload-Checksum"
+)
+
+// metadata reads the Upload-Metadata header, pairs of a key and a base64
+// value split by commas
+func metadata(header string) (map[string]string, error) {
+	var res = map[string]string{}
+
+	for _, pair := range strings.Split(header, ",") {
+		var fields = strings.Fields(pair)
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			res[fields[0]] = ""
+		case 2:
+			var value, err = base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, errors.New("invalid metadata")
+			}
+			res[fields[0]] = string(value)
+		default:
+			return nil, errors.New("invalid metadata")
+		}
+	}
+
+	return res, nil
+}
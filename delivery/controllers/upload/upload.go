@@ -0,0 +1,470 @@
+package upload
+
+import (
+	"be/api/scan"
+	"be/api/storage"
+	"be/delivery/controllers/templates"
+	logic "be/delivery/logic/attachment"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/attachment"
+	"be/repository/upload"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// Controller takes large attachments, e.g. scans, in parts with the tus
+// protocol: the upload is made with its length, the parts are sent in order
+// after the received bytes and a broken part is sent again from the offset
+// the server has. The sha256 of the file is kept between the parts and
+// checked with the checksum of the client when the last part arrives
+
+type Controller struct {
+	r     upload.Upload
+	a     attachment.Attachment
+	store storage.Storage
+	guard *scan.Guard
+	l     logic.Attachment
+}
+
+func New(r upload.Upload, a attachment.Attachment, store storage.Storage, guard *scan.Guard, l logic.Attachment) *Controller {
+	return &Controller{
+		r:     r,
+		a:     a,
+		store: store,
+		guard: guard,
+		l:     l,
+	}
+}
+
+// Create starts an upload, the metadata has the filename, type, description
+// and optionally the sha256 checksum in hex of the file
+func (cont *Controller) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visit_uid = c.Param("visit_uid")
+		var uid, kind = middlewares.ExtractTokenUid(c)
+
+		c.Response().Header().Set(headerResume, tusVersion)
+
+		if kind != "doctor" {
+			return c.JSON(http.StatusUnauthorized, templates.BadRequest(http.StatusUnauthorized, "only doctor can add attachment", nil))
+		}
+
+		if err := cont.a.CheckDoctor(visit_uid, uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, "visit is not found", nil))
+		}
+
+		length, err := strconv.ParseInt(c.Request().Header.Get(headerLength), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid upload length", nil))
+		}
+
+		meta, err := metadata(c.Request().Header.Get(headerMeta))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		var req = logic.Req{Type: meta["type"], Description: meta["description"]}
+		var checksum = strings.ToLower(meta["checksum"])
+
+		if err := cont.l.ValidationRequest(req); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		if err := cont.l.ValidationUpload(length, checksum); err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		if len(meta["filename"]) > 255 {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid length filename", nil))
+		}
+
+		// database
+
+		res, err := cont.r.Create(entities.Upload{
+			Visit_uid:    visit_uid,
+			Uploader_uid: uid,
+			Type:         req.Type,
+			Description:  req.Description,
+			FileName:     meta["filename"],
+			Length:       length,
+			Checksum:     checksum,
+			Object_key:   "attachments/" + visit_uid + "/" + shortuuid.New(),
+		})
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+
+		c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/visit/%v/uploads/%v", visit_uid, res.Upload_uid))
+
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success create upload", map[string]interface{}{
+			"upload_uid": res.Upload_uid,
+			"offset":     0,
+			"minPart":    storage.MinPart,
+			"maxPart":    logic.MaxPart,
+		}))
+	}
+}
+
+// Status tells the received bytes, where the next part starts
+func (cont *Controller) Status() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(headerResume, tusVersion)
+
+		res, err := cont.find(c)
+		if err != nil {
+			return cont.notFound(c, err)
+		}
+
+		c.Response().Header().Set(headerOffset, strconv.FormatInt(res.Received, 10))
+		c.Response().Header().Set(headerLength, strconv.FormatInt(res.Length, 10))
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// Patch takes the next part, written to a temporary file so it is never whole
+// in memory and sent to the storage as a part. The last part makes the
+// attachment
+func (cont *Controller) Patch() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(headerResume, tusVersion)
+
+		res, err := cont.find(c)
+		if err != nil {
+			return cont.notFound(c, err)
+		}
+
+		if c.Request().Header.Get(echo.HeaderContentType) != offsetType {
+			return c.JSON(http.StatusUnsupportedMediaType, templates.BadRequest(http.StatusUnsupportedMediaType, "invalid content type", nil))
+		}
+
+		if res.Status != "uploading" {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "upload is "+res.Status, nil))
+		}
+
+		offset, err := strconv.ParseInt(c.Request().Header.Get(headerOffset), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid upload offset", nil))
+		}
+
+		if offset != res.Received {
+			c.Response().Header().Set(headerOffset, strconv.FormatInt(res.Received, 10))
+			return c.JSON(http.StatusConflict, templates.BadRequest(http.StatusConflict, "upload offset is not the received length", nil))
+		}
+
+		partHash, expected, err := partChecksum(c.Request().Header.Get(headerChecksum))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		var fileHash = sha256.New()
+		if res.Received > 0 {
+			if err := fileHash.(encoding.BinaryUnmarshaler).UnmarshalBinary(res.Hash); err != nil {
+				log.Warn(err)
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+			}
+		}
+
+		// the part
+
+		tmp, err := os.CreateTemp("", "upload-*")
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		var writers = []io.Writer{tmp, fileHash}
+		if partHash != nil {
+			writers = append(writers, partHash)
+		}
+
+		size, err := io.Copy(io.MultiWriter(writers...), io.LimitReader(c.Request().Body, logic.MaxPart+1))
+		if err != nil {
+			// a broken request, the part is sent again from the same offset
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "part is broken", nil))
+		}
+
+		switch {
+		case size == 0:
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "part is empty", nil))
+		case size > logic.MaxPart:
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "part is too large", nil))
+		case offset+size > res.Length:
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "part is past the upload length", nil))
+		case offset+size < res.Length && size < storage.MinPart:
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, fmt.Sprintf("part is smaller than %d MB", storage.MinPart>>20), nil))
+		case partHash != nil && !bytes.Equal(partHash.Sum(nil), expected):
+			// the status of a checksum mismatch in tus
+			return c.JSON(460, templates.BadRequest(460, "checksum mismatch", nil))
+		}
+
+		// storage
+
+		if offset == 0 {
+			var head = make([]byte, 512)
+			n, _ := tmp.ReadAt(head, 0)
+
+			contentType, err := cont.l.ContentType(head[:n])
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+			}
+
+			id, err := cont.store.StartUpload(res.Object_key, contentType)
+			if err != nil {
+				log.Warn(err)
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+			}
+
+			res.ContentType = contentType
+			res.Storage_upload_id = id
+		}
+
+		var parts []storage.Part
+		if res.Parts != "" {
+			if err := json.Unmarshal([]byte(res.Parts), &parts); err != nil {
+				log.Warn(err)
+				return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+			}
+		}
+
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+
+		part, err := cont.store.UploadPart(res.Object_key, res.Storage_upload_id, len(parts)+1, tmp)
+		if err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+		parts = append(parts, part)
+
+		// database
+
+		var received = offset + size
+		var state, _ = fileHash.(encoding.BinaryMarshaler).MarshalBinary()
+		var partsJson, _ = json.Marshal(parts)
+
+		if err := cont.r.Update(res.Upload_uid, offset, entities.Upload{
+			Received:          received,
+			ContentType:       res.ContentType,
+			Storage_upload_id: res.Storage_upload_id,
+			Parts:             string(partsJson),
+			Hash:              state,
+		}); err != nil {
+			if offset == 0 {
+				if err := cont.store.AbortUpload(res.Object_key, res.Storage_upload_id); err != nil {
+					log.Warn(err)
+				}
+			}
+			if err.Error() == "upload is changed" {
+				return c.JSON(http.StatusConflict, templates.BadRequest(http.StatusConflict, err.Error(), nil))
+			}
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+
+		c.Response().Header().Set(headerOffset, strconv.FormatInt(received, 10))
+
+		if received < res.Length {
+			return c.NoContent(http.StatusNoContent)
+		}
+
+		return cont.complete(c, res, parts, hex.EncodeToString(fileHash.Sum(nil)))
+	}
+}
+
+// complete joins the parts and makes the attachment, a file that isn't the
+// one the client sent is removed
+func (cont *Controller) complete(c echo.Context, res entities.Upload, parts []storage.Part, checksum string) error {
+
+	// storage
+
+	if err := cont.store.CompleteUpload(res.Object_key, res.Storage_upload_id, parts); err != nil {
+		log.Warn(err)
+		cont.fail(res, false)
+		return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+	}
+
+	if res.Checksum != "" && res.Checksum != checksum {
+		cont.fail(res, true)
+		return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "checksum does not match", nil))
+	}
+
+	// malware, an infected file is quarantined and removed
+
+	if err := cont.scan(res); err != nil {
+		log.Warn(err)
+		cont.fail(res, true)
+		if strings.HasPrefix(err.Error(), "file is infected with ") {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+		return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+	}
+
+	// database
+
+	attachment, err := cont.a.Create(entities.Attachment{
+		Visit_uid:    res.Visit_uid,
+		Uploader_uid: res.Uploader_uid,
+		Type:         res.Type,
+		Description:  res.Description,
+		FileName:     res.FileName,
+		ContentType:  res.ContentType,
+		Size:         res.Length,
+		Checksum:     checksum,
+		Object_key:   res.Object_key,
+	})
+	if err != nil {
+		log.Warn(err)
+		cont.fail(res, true)
+		return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+	}
+
+	if err := cont.r.Finish(res.Upload_uid, attachment.Attachment_uid); err != nil {
+		log.Warn(err)
+	}
+
+	return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add attachment", map[string]interface{}{
+		"attachment_uid": attachment.Attachment_uid,
+		"checksum":       attachment.Checksum,
+	}))
+}
+
+func (cont *Controller) scan(res entities.Upload) error {
+	var file, err = cont.store.OpenUpload(res.Object_key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return cont.guard.CheckReader(res.FileName, file)
+}
+
+// Delete stops the upload and removes the parts sent
+func (cont *Controller) Delete() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(headerResume, tusVersion)
+
+		res, err := cont.find(c)
+		if err != nil {
+			return cont.notFound(c, err)
+		}
+
+		// storage
+
+		if res.Status == "uploading" && res.Storage_upload_id != "" {
+			if err := cont.store.AbortUpload(res.Object_key, res.Storage_upload_id); err != nil {
+				log.Warn(err)
+			}
+		}
+
+		// database
+
+		if err := cont.r.Delete(res.Upload_uid); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, "there's some problem is server", nil))
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// find is the upload of the url, only for its uploader while they are the
+// doctor of the visit
+func (cont *Controller) find(c echo.Context) (entities.Upload, error) {
+	var visit_uid = c.Param("visit_uid")
+	var upload_uid = c.Param("upload_uid")
+	var uid, _ = middlewares.ExtractTokenUid(c)
+
+	if err := cont.a.CheckDoctor(visit_uid, uid); err != nil {
+		log.Warn(err)
+		return entities.Upload{}, errors.New("visit is not found")
+	}
+
+	res, err := cont.r.GetUpload(visit_uid, upload_uid)
+	if err != nil || res.Uploader_uid != uid {
+		return entities.Upload{}, errors.New("upload is not found")
+	}
+
+	return res, nil
+}
+
+// notFound answers a missing upload or visit with 404, a tus client starts
+// over then
+func (cont *Controller) notFound(c echo.Context, err error) error {
+	switch err.Error() {
+	case "upload is not found", "visit is not found":
+		return c.JSON(http.StatusNotFound, templates.BadRequest(http.StatusNotFound, err.Error(), nil))
+	}
+
+	return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
+}
+
+// fail marks the upload failed, removing the joined file when it was made
+func (cont *Controller) fail(res entities.Upload, completed bool) {
+	if completed {
+		if err := cont.store.DeletePrivateFile(res.Object_key); err != nil {
+			log.Warn(err)
+		}
+	} else if err := cont.store.AbortUpload(res.Object_key, res.Storage_upload_id); err != nil {
+		log.Warn(err)
+	}
+
+	if err := cont.r.Fail(res.Upload_uid); err != nil {
+		log.Warn(err)
+	}
+}
+
+// partChecksum reads the Upload-Checksum header of a part, the algorithm and
+// the base64 digest
+func partChecksum(header string) (hash.Hash, []byte, error) {
+	if header == "" {
+		return nil, nil, nil
+	}
+
+	var fields = strings.Fields(header)
+	if len(fields) != 2 {
+		return nil, nil, errors.New("invalid checksum")
+	}
+
+	var digest, err = base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, nil, errors.New("invalid checksum")
+	}
+
+	switch fields[0] {
+	case "sha256":
+		return sha256.New(), digest, nil
+	case "sha1":
+		return sha1.New(), digest, nil
+	case "md5":
+		return md5.New(), digest, nil
+	}
+
+	return nil, nil, errors.New("checksum algorithm is not supported")
+}
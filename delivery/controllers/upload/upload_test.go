@@ -0,0 +1,434 @@
+package upload
+
+import (
+	"be/api/scan"
+	"be/api/storage"
+	"be/configs"
+	logic "be/delivery/logic/attachment"
+	"be/delivery/middlewares"
+	"be/entities"
+	"be/repository/attachment"
+	"be/utils/list"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// mockUpload keeps the uploads in memory like the database
+
+type mockUpload struct {
+	uploads map[string]entities.Upload
+}
+
+func (m *mockUpload) Create(req entities.Upload) (entities.Upload, error) {
+	req.Upload_uid = fmt.Sprintf("upload%v", len(m.uploads)+1)
+	req.Status = "uploading"
+	m.uploads[req.Upload_uid] = req
+	return req, nil
+}
+
+func (m *mockUpload) GetUpload(visit_uid, upload_uid string) (entities.Upload, error) {
+	var res, ok = m.uploads[upload_uid]
+	if !ok || res.Visit_uid != visit_uid {
+		return entities.Upload{}, gorm.ErrRecordNotFound
+	}
+	return res, nil
+}
+
+func (m *mockUpload) Update(upload_uid string, received int64, req entities.Upload) error {
+	var res = m.uploads[upload_uid]
+	if res.Received != received || res.Status != "uploading" {
+		return errors.New("upload is changed")
+	}
+	res.Received = req.Received
+	res.ContentType = req.ContentType
+	res.Storage_upload_id = req.Storage_upload_id
+	res.Parts = req.Parts
+	res.Hash = req.Hash
+	m.uploads[upload_uid] = res
+	return nil
+}
+
+func (m *mockUpload) Finish(upload_uid, attachment_uid string) error {
+	var res = m.uploads[upload_uid]
+	res.Status = "done"
+	res.Attachment_uid = attachment_uid
+	m.uploads[upload_uid] = res
+	return nil
+}
+
+func (m *mockUpload) Fail(upload_uid string) error {
+	var res = m.uploads[upload_uid]
+	res.Status = "failed"
+	m.uploads[upload_uid] = res
+	return nil
+}
+
+func (m *mockUpload) Delete(upload_uid string) error {
+	if _, ok := m.uploads[upload_uid]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(m.uploads, upload_uid)
+	return nil
+}
+
+type mockAttachment struct {
+	access  error
+	created []entities.Attachment
+}
+
+func (m *mockAttachment) CheckAccess(visit_uid, user_uid string) error {
+	return m.access
+}
+
+func (m *mockAttachment) CheckDoctor(visit_uid, doctor_uid string) error {
+	return m.access
+}
+
+func (m *mockAttachment) Create(req entities.Attachment) (entities.Attachment, error) {
+	req.Attachment_uid = "attachment"
+	m.created = append(m.created, req)
+	return req, nil
+}
+
+func (m *mockAttachment) Delete(visit_uid, attachment_uid, user_uid string) (entities.Attachment, error) {
+	return entities.Attachment{}, nil
+}
+
+func (m *mockAttachment) GetAttachment(visit_uid, attachment_uid string) (entities.Attachment, error) {
+	return entities.Attachment{}, nil
+}
+
+func (m *mockAttachment) GetAttachments(visit_uid string, q list.Query) (attachment.Attachments, list.Page, error) {
+	return attachment.Attachments{}, list.Page{}, nil
+}
+
+type setup struct {
+	cont    *Controller
+	uploads *mockUpload
+	a       *mockAttachment
+	dir     string
+}
+
+func newSetup(t *testing.T) setup {
+	var dir = t.TempDir()
+	var local, err = storage.NewLocal(dir, "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uploads = &mockUpload{uploads: map[string]entities.Upload{}}
+	var a = &mockAttachment{}
+
+	return setup{cont: New(uploads, a, local, scan.NewGuard(scan.NewLocal(), local), logic.New()), uploads: uploads, a: a, dir: dir}
+}
+
+func request(t *testing.T, method, upload_uid string, headers map[string]string, body io.Reader, handler echo.HandlerFunc) (*httptest.ResponseRecorder, ResponseFormat) {
+	return requestAs(t, "doctor", method, upload_uid, headers, body, handler)
+}
+
+func requestAs(t *testing.T, kind, method, upload_uid string, headers map[string]string, body io.Reader, handler echo.HandlerFunc) (*httptest.ResponseRecorder, ResponseFormat) {
+	var token, err = middlewares.GenerateToken(kind, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e = echo.New()
+	var req = httptest.NewRequest(method, "/", body)
+	var res = httptest.NewRecorder()
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	context := e.NewContext(req, res)
+	context.SetPath("/visit/:visit_uid/uploads/:upload_uid")
+	context.SetParamNames("visit_uid", "upload_uid")
+	context.SetParamValues("visit", upload_uid)
+
+	if err := middleware.JWT([]byte(configs.JWT_SECRET))(handler)(context); err != nil {
+		log.Fatal(err)
+	}
+
+	var response = ResponseFormat{}
+	json.Unmarshal(res.Body.Bytes(), &response)
+	return res, response
+}
+
+func b64(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+// mriScan is a pdf a bit larger than one part
+func mriScan() []byte {
+	return append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("scan "), storage.MinPart/5+100)...)
+}
+
+func create(t *testing.T, s setup, content []byte, checksum string) string {
+	var _, response = request(t, http.MethodPost, "", map[string]string{
+		headerLength: strconv.Itoa(len(content)),
+		headerMeta:   "filename " + b64("mri.pdf") + ",type " + b64("imaging") + ",checksum " + b64(checksum),
+	}, nil, s.cont.Create())
+	if response.Code != 201 {
+		t.Fatal(response.Message)
+	}
+
+	return response.Data.(map[string]interface{})["upload_uid"].(string)
+}
+
+func patch(t *testing.T, s setup, upload_uid string, offset int, part []byte, headers map[string]string) (*httptest.ResponseRecorder, ResponseFormat) {
+	var all = map[string]string{
+		echo.HeaderContentType: offsetType,
+		headerOffset:           strconv.Itoa(offset),
+	}
+	for key, value := range headers {
+		all[key] = value
+	}
+
+	return request(t, http.MethodPatch, upload_uid, all, bytes.NewReader(part), s.cont.Patch())
+}
+
+func sha(content []byte) string {
+	var sum = sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCreate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var s = newSetup(t)
+		var res, response = request(t, http.MethodPost, "", map[string]string{
+			headerLength: "1000",
+			headerMeta:   "filename " + b64("mri.pdf") + ",type " + b64("imaging") + ",description",
+		}, nil, s.cont.Create())
+
+		assert.Equal(t, 201, response.Code)
+		assert.Equal(t, "/visit/visit/uploads/upload1", res.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, tusVersion, res.Header().Get(headerResume))
+		assert.Equal(t, "mri.pdf", s.uploads.uploads["upload1"].FileName)
+	})
+
+	t.Run("no access", func(t *testing.T) {
+		var s = newSetup(t)
+		s.a.access = gorm.ErrRecordNotFound
+		var _, response = request(t, http.MethodPost, "", map[string]string{headerLength: "1000", headerMeta: "type " + b64("lab")}, nil, s.cont.Create())
+		assert.Equal(t, 404, response.Code)
+		assert.Equal(t, "visit is not found", response.Message)
+	})
+
+	t.Run("not doctor", func(t *testing.T) {
+		var s = newSetup(t)
+		var _, response = requestAs(t, "patient", http.MethodPost, "", map[string]string{headerLength: "1000", headerMeta: "type " + b64("lab")}, nil, s.cont.Create())
+		assert.Equal(t, 401, response.Code)
+		assert.Equal(t, 0, len(s.uploads.uploads))
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		var s = newSetup(t)
+		var _, response = request(t, http.MethodPost, "", map[string]string{headerMeta: "type " + b64("lab")}, nil, s.cont.Create())
+		assert.Equal(t, 400, response.Code)
+
+		_, response = request(t, http.MethodPost, "", map[string]string{headerLength: strconv.Itoa(logic.MaxUploadSize + 1), headerMeta: "type " + b64("lab")}, nil, s.cont.Create())
+		assert.Equal(t, "file is too large", response.Message)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		var s = newSetup(t)
+		var _, response = request(t, http.MethodPost, "", map[string]string{headerLength: "1000", headerMeta: "type !!"}, nil, s.cont.Create())
+		assert.Equal(t, "invalid metadata", response.Message)
+
+		_, response = request(t, http.MethodPost, "", map[string]string{headerLength: "1000", headerMeta: "type " + b64("selfie")}, nil, s.cont.Create())
+		assert.Equal(t, 400, response.Code)
+
+		_, response = request(t, http.MethodPost, "", map[string]string{headerLength: "1000", headerMeta: "type " + b64("lab") + ",checksum " + b64("abc")}, nil, s.cont.Create())
+		assert.Equal(t, "invalid checksum", response.Message)
+	})
+}
+
+func TestPatch(t *testing.T) {
+	t.Run("success resumed after a broken part", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = mriScan()
+		var upload_uid = create(t, s, content, sha(content))
+
+		var res, _ = patch(t, s, upload_uid, 0, content[:storage.MinPart], nil)
+		assert.Equal(t, 204, res.Code)
+		assert.Equal(t, strconv.Itoa(storage.MinPart), res.Header().Get(headerOffset))
+
+		// the client lost the answer and asks where to go on
+		res, _ = request(t, http.MethodHead, upload_uid, nil, nil, s.cont.Status())
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, strconv.Itoa(storage.MinPart), res.Header().Get(headerOffset))
+		assert.Equal(t, strconv.Itoa(len(content)), res.Header().Get(headerLength))
+
+		// the first part sent again is refused
+		var _, response = patch(t, s, upload_uid, 0, content[:storage.MinPart], nil)
+		assert.Equal(t, 409, response.Code)
+
+		var rest = content[storage.MinPart:]
+		var sum = md5.Sum(rest)
+		res, response = patch(t, s, upload_uid, storage.MinPart, rest, map[string]string{headerChecksum: "md5 " + base64.StdEncoding.EncodeToString(sum[:])})
+		assert.Equal(t, 201, response.Code)
+		assert.Equal(t, "attachment", response.Data.(map[string]interface{})["attachment_uid"])
+		assert.Equal(t, sha(content), response.Data.(map[string]interface{})["checksum"])
+
+		var created = s.a.created[0]
+		assert.Equal(t, "application/pdf", created.ContentType)
+		assert.Equal(t, int64(len(content)), created.Size)
+		assert.Equal(t, "mri.pdf", created.FileName)
+		assert.Equal(t, "imaging", created.Type)
+		assert.Equal(t, "done", s.uploads.uploads[upload_uid].Status)
+
+		var file, err = os.ReadFile(filepath.Join(s.dir, "private", created.Object_key))
+		assert.Nil(t, err)
+		assert.True(t, bytes.Equal(content, file))
+
+		var parts, _ = filepath.Glob(filepath.Join(s.dir, "uploads", "*"))
+		assert.Equal(t, 0, len(parts))
+	})
+
+	t.Run("success one part without checksum", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = []byte("%PDF-1.4\nsmall")
+		var upload_uid = create(t, s, content, "")
+
+		var _, response = patch(t, s, upload_uid, 0, content, nil)
+		assert.Equal(t, 201, response.Code)
+	})
+
+	t.Run("checksum does not match", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = []byte("%PDF-1.4\nsmall")
+		var upload_uid = create(t, s, content, sha([]byte("other")))
+
+		var _, response = patch(t, s, upload_uid, 0, content, nil)
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "checksum does not match", response.Message)
+		assert.Equal(t, "failed", s.uploads.uploads[upload_uid].Status)
+		assert.Equal(t, 0, len(s.a.created))
+
+		var files, _ = filepath.Glob(filepath.Join(s.dir, "private", "attachments", "visit", "*"))
+		assert.Equal(t, 0, len(files))
+
+		_, response = patch(t, s, upload_uid, 0, content, nil)
+		assert.Equal(t, "upload is failed", response.Message)
+	})
+
+	t.Run("file is infected", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = []byte("%PDF-1.4\n" + `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+		var upload_uid = create(t, s, content, "")
+
+		var _, response = patch(t, s, upload_uid, 0, content, nil)
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "file is infected with Eicar-Test-Signature", response.Message)
+		assert.Equal(t, "failed", s.uploads.uploads[upload_uid].Status)
+		assert.Equal(t, 0, len(s.a.created))
+
+		var files, _ = filepath.Glob(filepath.Join(s.dir, "private", "attachments", "visit", "*"))
+		assert.Equal(t, 0, len(files))
+
+		quarantined, _ := filepath.Glob(filepath.Join(s.dir, "private", "quarantine", "*", "*"))
+		assert.Equal(t, 1, len(quarantined))
+	})
+
+	t.Run("part checksum mismatch", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = []byte("%PDF-1.4\nsmall")
+		var upload_uid = create(t, s, content, "")
+
+		var sum = sha256.Sum256([]byte("other"))
+		var _, response = patch(t, s, upload_uid, 0, content, map[string]string{headerChecksum: "sha256 " + base64.StdEncoding.EncodeToString(sum[:])})
+		assert.Equal(t, 460, response.Code)
+		assert.Equal(t, int64(0), s.uploads.uploads[upload_uid].Received)
+
+		_, response = patch(t, s, upload_uid, 0, content, map[string]string{headerChecksum: "crc32 AAAA"})
+		assert.Equal(t, "checksum algorithm is not supported", response.Message)
+	})
+
+	t.Run("invalid parts", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = mriScan()
+		var upload_uid = create(t, s, content, "")
+
+		var _, response = patch(t, s, upload_uid, 0, content[:1000], nil)
+		assert.Equal(t, "part is smaller than 5 MB", response.Message)
+
+		_, response = patch(t, s, upload_uid, 0, append(content, 'x'), nil)
+		assert.Equal(t, "part is past the upload length", response.Message)
+
+		_, response = patch(t, s, upload_uid, 0, []byte{}, nil)
+		assert.Equal(t, "part is empty", response.Message)
+
+		_, response = request(t, http.MethodPatch, upload_uid, map[string]string{headerOffset: "0"}, bytes.NewReader(content), s.cont.Patch())
+		assert.Equal(t, 415, response.Code)
+	})
+
+	t.Run("file type is not allowed", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = []byte("#!/bin/sh\necho hello")
+		var upload_uid = create(t, s, content, "")
+
+		var _, response = patch(t, s, upload_uid, 0, content, nil)
+		assert.Equal(t, "file type is not allowed", response.Message)
+	})
+
+	t.Run("upload is not found", func(t *testing.T) {
+		var s = newSetup(t)
+		var _, response = patch(t, s, "other", 0, []byte("%PDF"), nil)
+		assert.Equal(t, 404, response.Code)
+
+		// only the uploader goes on
+		s.uploads.uploads["upload1"] = entities.Upload{Upload_uid: "upload1", Visit_uid: "visit", Uploader_uid: "patient", Status: "uploading"}
+		_, response = patch(t, s, "upload1", 0, []byte("%PDF"), nil)
+		assert.Equal(t, 404, response.Code)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var s = newSetup(t)
+		var content = mriScan()
+		var upload_uid = create(t, s, content, "")
+		patch(t, s, upload_uid, 0, content[:storage.MinPart], nil)
+
+		var res, _ = request(t, http.MethodDelete, upload_uid, nil, nil, s.cont.Delete())
+		assert.Equal(t, 204, res.Code)
+		assert.Equal(t, 0, len(s.uploads.uploads))
+
+		var parts, _ = filepath.Glob(filepath.Join(s.dir, "uploads", "*"))
+		assert.Equal(t, 0, len(parts))
+	})
+
+	t.Run("upload is not found", func(t *testing.T) {
+		var s = newSetup(t)
+		var _, response = request(t, http.MethodDelete, "other", nil, nil, s.cont.Delete())
+		assert.Equal(t, 404, response.Code)
+	})
+}
+
+func TestMetadata(t *testing.T) {
+	var res, err = metadata("filename " + b64("scan 1.pdf") + ", type " + b64("imaging") + ",empty")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"filename": "scan 1.pdf", "type": "imaging", "empty": ""}, res)
+
+	_, err = metadata("filename a b")
+	assert.NotNil(t, err)
+}
@@ -1,12 +1,12 @@
 package visit
 
 import (
-	"be/api/google/calendar"
 	"be/delivery/controllers/templates"
+	"be/delivery/jobs/calendar"
 	logic "be/delivery/logic/visit"
 	"be/delivery/middlewares"
-	"be/entities"
 	"be/repository/visit"
+	"be/utils/list"
 	"errors"
 	"net/http"
 	"strings"
@@ -14,20 +14,19 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
-	calGoogle "google.golang.org/api/calendar/v3"
 )
 
 type Controller struct {
-	r   visit.Visit
-	cal calendar.Calendar
-	l   logic.Visit
+	r    visit.Visit
+	sync calendar.Notifier
+	l    logic.Visit
 }
 
-func New(r visit.Visit, cal calendar.Calendar, l logic.Visit) *Controller {
+func New(r visit.Visit, sync calendar.Notifier, l logic.Visit) *Controller {
 	return &Controller{
-		r:   r,
-		cal: cal,
-		l:   l,
+		r:    r,
+		sync: sync,
+		l:    l,
 	}
 }
 
@@ -94,6 +93,8 @@ func (cont *Controller) Create() echo.HandlerFunc {
 				err = errors.New("invalid patient_uid")
 			case err.Error() == errors.New("there's another appoinment in pending").Error():
 				err = errors.New("there's another appoinment in pending")
+			case err.Error() == errors.New("doctor is busy on the date").Error():
+				err = errors.New("doctor is busy on the date")
 			case err.Error() == errors.New("left capacity can't below zero").Error():
 				err = errors.New("left capacity can't below zero")
 			default:
@@ -102,30 +103,11 @@ func (cont *Controller) Create() echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
 		}
 
-		resCal, err := cont.r.GetVisitList(res.Visit_uid)
-		if err != nil {
-			log.Warn(err)
-		}
+		// calendar, the event is added by the sync saved with the visit
 
-		var res1 *calGoogle.Event
-		// for {
-		res1, err = cont.cal.CreateEvent(resCal)
-		if err != nil {
-			log.Warn(err)
-			return c.JSON(http.StatusCreated, templates.Success(nil, "success add visit", res.Complaint))
-		}
-		res1, err = cont.cal.InsertEvent(res1)
-		if err != nil {
-			log.Warn(err)
-			return c.JSON(http.StatusCreated, templates.Success(nil, "success add visit", res.Complaint))
-		}
-
-		_, err = cont.r.Update(res.Visit_uid, entities.Visit{Event_uid: res1.Id})
-		if err != nil {
-			log.Warn(err)
-		}
+		cont.sync.Notify()
 
-		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add visit and attach to google calendar", res1.HtmlLink))
+		return c.JSON(http.StatusCreated, templates.Success(http.StatusCreated, "success add visit", res.Complaint))
 	}
 }
 
@@ -164,33 +146,11 @@ func (cont *Controller) Update() echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
 		}
 
-		// google calendar
-		resCal, err := cont.r.GetVisitList(uid)
-		if err != nil {
-			log.Warn(err)
-		}
-
-		var res1 *calGoogle.Event
-		// for {
-		res1, err = cont.cal.CreateEvent(resCal)
-		if err != nil {
-			log.Warn(err)
-			return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success update visit", res.Complaint))
-		}
-		res1, err = cont.cal.UpdateEvent(res1, resCal.Event_uid)
-		if err != nil {
-			log.Warn(err)
-			return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success update visit", res.Complaint))
-		}
+		// calendar
 
-		if req.Status == "cancelled" {
-			err = cont.cal.DeleteEvent(resCal.Event_uid)
-			if err != nil {
-				log.Warn(err)
-			}
-		}
+		cont.sync.Notify()
 
-		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success update visit", res1.HtmlLink))
+		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success update visit", res.Complaint))
 	}
 }
 
@@ -198,11 +158,6 @@ func (cont *Controller) Delete() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var uid = c.Param("visit_uid")
 
-		resCal, err := cont.r.GetVisitList(uid)
-		if err != nil {
-			log.Warn(err)
-		}
-
 		res, err := cont.r.Delete(uid)
 
 		if err != nil {
@@ -215,13 +170,9 @@ func (cont *Controller) Delete() echo.HandlerFunc {
 			}
 			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
 		}
-		// google calendar
+		// calendar
 
-		err = cont.cal.DeleteEvent(resCal.Event_uid)
-		if err != nil {
-			log.Warn(err)
-			return c.JSON(http.StatusCreated, templates.Success(nil, "success delete visit", res.Complaint))
-		}
+		cont.sync.Notify()
 
 		return c.JSON(http.StatusAccepted, templates.Success(http.StatusAccepted, "success delete visit", res.DeletedAt))
 	}
@@ -229,15 +180,32 @@ func (cont *Controller) Delete() echo.HandlerFunc {
 
 func (cont *Controller) GetVisits() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		var kind = c.QueryParam("kind")
-		var uid = c.QueryParam("uid")
-		var status = c.QueryParam("status")
-		var date = c.QueryParam("date")
-		var grouped = c.QueryParam("grouped")
+		var req logic.Search
+
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &req); err != nil {
+			log.Warn(err)
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, "invalid input", nil))
+		}
+
+		var search, err = req.ToSearch()
 
-		// log.Info(uid, status)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		q, err := list.Parse(visit.List, c.QueryParams())
+
+		if err == nil && q.Cursor != nil && search.Grouped != "" {
+			err = errors.New("cursor can't be used with grouped, use offset")
+		}
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, templates.BadRequest(nil, err.Error(), nil))
+		}
+
+		// database
 
-		var res, err = cont.r.GetVisitsVer1(kind, uid, status, date, grouped)
+		res, page, err := cont.r.GetVisitsVer1(search, q)
 
 		if err != nil {
 			log.Warn(err)
@@ -248,6 +216,6 @@ func (cont *Controller) GetVisits() echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, templates.InternalServerError(nil, err.Error(), nil))
 		}
 
-		return c.JSON(http.StatusOK, templates.Success(http.StatusOK, "success get list visit", res))
+		return c.JSON(http.StatusOK, templates.List(http.StatusOK, "success get list visit", res, page))
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"be/delivery/controllers/auth"
 	"be/entities"
 	"be/repository/visit"
+	"be/utils/list"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -13,13 +14,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	logic "be/delivery/logic/visit"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/stretchr/testify/assert"
-	"google.golang.org/api/calendar/v3"
 	"gorm.io/gorm"
 )
 
@@ -49,56 +50,12 @@ func (m *mockSuccess) Delete(visit_uid string) (entities.Visit, error) {
 	return entities.Visit{}, nil
 }
 
-func (m *mockSuccess) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, nil
+func (m *mockSuccess) GetVisitsVer1(s visit.Search, q list.Query) (visit.Visits, list.Page, error) {
+	return visit.Visits{}, list.Page{}, nil
 }
 
 func (m *mockSuccess) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
-	return visit.VisitCalendar{}, nil
-}
-
-type errorVisitList struct{}
-
-func (m *errorVisitList) CreateVal(doctor_uid, patient_uid string, req entities.Visit) (entities.Visit, error) {
-	return entities.Visit{}, nil
-}
-
-func (m *errorVisitList) Update(visit_uid string, req entities.Visit) (entities.Visit, error) {
-	return entities.Visit{}, nil
-}
-
-func (m *errorVisitList) Delete(visit_uid string) (entities.Visit, error) {
-	return entities.Visit{}, nil
-}
-
-func (m *errorVisitList) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, nil
-}
-
-func (m *errorVisitList) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
-	return visit.VisitCalendar{}, errors.New("")
-}
-
-type errorUpdateEventId struct{}
-
-func (m *errorUpdateEventId) CreateVal(doctor_uid, patient_uid string, req entities.Visit) (entities.Visit, error) {
-	return entities.Visit{}, nil
-}
-
-func (m *errorUpdateEventId) Update(visit_uid string, req entities.Visit) (entities.Visit, error) {
-	return entities.Visit{}, errors.New("")
-}
-
-func (m *errorUpdateEventId) Delete(visit_uid string) (entities.Visit, error) {
-	return entities.Visit{}, nil
-}
-
-func (m *errorUpdateEventId) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, nil
-}
-
-func (m *errorUpdateEventId) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
-	return visit.VisitCalendar{}, nil
+	return visit.VisitCalendar{Visit_uid: "visit", Date: "05-05-2022", Event_uid: "event"}, nil
 }
 
 type mockFail struct{}
@@ -115,8 +72,8 @@ func (m *mockFail) Delete(visit_uid string) (entities.Visit, error) {
 	return entities.Visit{}, errors.New("")
 }
 
-func (m *mockFail) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, errors.New("")
+func (m *mockFail) GetVisitsVer1(s visit.Search, q list.Query) (visit.Visits, list.Page, error) {
+	return visit.Visits{}, list.Page{}, errors.New("")
 }
 
 func (m *mockFail) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
@@ -137,8 +94,8 @@ func (m *spesificError) Delete(visit_uid string) (entities.Visit, error) {
 	return entities.Visit{}, gorm.ErrRecordNotFound
 }
 
-func (m *spesificError) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, gorm.ErrRecordNotFound
+func (m *spesificError) GetVisitsVer1(s visit.Search, q list.Query) (visit.Visits, list.Page, error) {
+	return visit.Visits{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 func (m *spesificError) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
@@ -159,8 +116,8 @@ func (m *leftCapacity) Delete(visit_uid string) (entities.Visit, error) {
 	return entities.Visit{}, gorm.ErrRecordNotFound
 }
 
-func (m *leftCapacity) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, gorm.ErrRecordNotFound
+func (m *leftCapacity) GetVisitsVer1(s visit.Search, q list.Query) (visit.Visits, list.Page, error) {
+	return visit.Visits{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 func (m *leftCapacity) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
@@ -181,8 +138,8 @@ func (m *invalidDoctorUid) Delete(visit_uid string) (entities.Visit, error) {
 	return entities.Visit{}, gorm.ErrRecordNotFound
 }
 
-func (m *invalidDoctorUid) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, gorm.ErrRecordNotFound
+func (m *invalidDoctorUid) GetVisitsVer1(s visit.Search, q list.Query) (visit.Visits, list.Page, error) {
+	return visit.Visits{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 func (m *invalidDoctorUid) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
@@ -203,14 +160,24 @@ func (m *invalidPatientUid) Delete(visit_uid string) (entities.Visit, error) {
 	return entities.Visit{}, gorm.ErrRecordNotFound
 }
 
-func (m *invalidPatientUid) GetVisitsVer1(kind, uid, status, date, grouped string) (visit.Visits, error) {
-	return visit.Visits{}, gorm.ErrRecordNotFound
+func (m *invalidPatientUid) GetVisitsVer1(s visit.Search, q list.Query) (visit.Visits, list.Page, error) {
+	return visit.Visits{}, list.Page{}, gorm.ErrRecordNotFound
 }
 
 func (m *invalidPatientUid) GetVisitList(visit_uid string) (visit.VisitCalendar, error) {
 	return visit.VisitCalendar{}, gorm.ErrRecordNotFound
 }
 
+type searchVisit struct {
+	mockSuccess
+	search visit.Search
+}
+
+func (m *searchVisit) GetVisitsVer1(s visit.Search, q list.Query) (visit.Visits, list.Page, error) {
+	m.search = s
+	return visit.Visits{}, list.Page{}, nil
+}
+
 type MockAuthLib struct{}
 
 func (m *MockAuthLib) Login(userName string, password string) (map[string]interface{}, error) {
@@ -221,76 +188,12 @@ func (m *MockAuthLib) Login(userName string, password string) (map[string]interf
 	}, nil
 }
 
-type MockCal struct{}
-
-func (m *MockCal) CreateEvent(res visit.VisitCalendar) (*calendar.Event, error) {
-	return &calendar.Event{}, nil
-}
-
-func (m *MockCal) InsertEvent(event *calendar.Event) (*calendar.Event, error) {
-	return &calendar.Event{}, nil
-}
-
-func (m *MockCal) UpdateEvent(event *calendar.Event, event_uid string) (*calendar.Event, error) {
-	return &calendar.Event{}, nil
+type MockSync struct {
+	notified int
 }
 
-func (m *MockCal) DeleteEvent(event_uid string) error {
-	return nil
-}
-
-type errorCreateEvent struct{}
-
-func (m *errorCreateEvent) CreateEvent(res visit.VisitCalendar) (*calendar.Event, error) {
-	return &calendar.Event{}, errors.New("")
-}
-
-func (m *errorCreateEvent) InsertEvent(event *calendar.Event) (*calendar.Event, error) {
-	return &calendar.Event{}, errors.New("")
-}
-
-func (m *errorCreateEvent) UpdateEvent(event *calendar.Event, event_uid string) (*calendar.Event, error) {
-	return &calendar.Event{}, errors.New("")
-}
-
-func (m *errorCreateEvent) DeleteEvent(event_uid string) error {
-	return errors.New("")
-}
-
-type errorInsertEvent struct{}
-
-func (m *errorInsertEvent) CreateEvent(res visit.VisitCalendar) (*calendar.Event, error) {
-	return &calendar.Event{}, nil
-}
-
-func (m *errorInsertEvent) InsertEvent(event *calendar.Event) (*calendar.Event, error) {
-	return &calendar.Event{}, errors.New("")
-}
-
-func (m *errorInsertEvent) UpdateEvent(event *calendar.Event, event_uid string) (*calendar.Event, error) {
-	return &calendar.Event{}, errors.New("")
-}
-
-func (m *errorInsertEvent) DeleteEvent(event_uid string) error {
-	return errors.New("")
-}
-
-type errorCancelEvent struct{}
-
-func (m *errorCancelEvent) CreateEvent(res visit.VisitCalendar) (*calendar.Event, error) {
-	return &calendar.Event{}, nil
-}
-
-func (m *errorCancelEvent) InsertEvent(event *calendar.Event) (*calendar.Event, error) {
-	return &calendar.Event{}, nil
-}
-
-func (m *errorCancelEvent) UpdateEvent(event *calendar.Event, event_uid string) (*calendar.Event, error) {
-	return &calendar.Event{}, nil
-}
-
-func (m *errorCancelEvent) DeleteEvent(event_uid string) error {
-	return errors.New("")
+func (m *MockSync) Notify() {
+	m.notified++
 }
 
 func TestCreate(t *testing.T) {
@@ -338,7 +241,8 @@ func TestCreate(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.SetPath("/doctor")
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var sync = &MockSync{}
+		var controller = New(&mockSuccess{}, sync, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -349,6 +253,7 @@ func TestCreate(t *testing.T) {
 		json.Unmarshal([]byte(res.Body.Bytes()), &response)
 
 		assert.Equal(t, 201, response.Code)
+		assert.Equal(t, 1, sync.notified)
 	})
 
 	t.Run("binding", func(t *testing.T) {
@@ -367,7 +272,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -396,7 +301,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -426,7 +331,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -456,7 +361,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -486,7 +391,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &errorLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &errorLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -516,7 +421,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -547,7 +452,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&spesificError{}, &MockCal{}, &successLogic{})
+		var controller = New(&spesificError{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -579,7 +484,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&leftCapacity{}, &MockCal{}, &successLogic{})
+		var controller = New(&leftCapacity{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -611,7 +516,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&invalidDoctorUid{}, &MockCal{}, &successLogic{})
+		var controller = New(&invalidDoctorUid{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -643,7 +548,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&invalidPatientUid{}, &MockCal{}, &successLogic{})
+		var controller = New(&invalidPatientUid{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -675,7 +580,7 @@ func TestCreate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockFail{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockFail{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -690,134 +595,6 @@ func TestCreate(t *testing.T) {
 		// log.Info(response.Message)
 	})
 
-	t.Run("error visit list", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"doctor_uid":  "doctor",
-			"patient_uid": "patient",
-			"date":        "05-05-2022",
-			"complaint":   "sick",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&errorVisitList{}, &errorCreateEvent{}, &successLogic{})
-		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
-			log.Fatal(err)
-			return
-		}
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 200, response.Code)
-		// log.Info(response.Message)
-	})
-
-	t.Run("error create event", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"doctor_uid":  "doctor",
-			"patient_uid": "patient",
-			"date":        "05-05-2022",
-			"complaint":   "sick",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&mockSuccess{}, &errorCreateEvent{}, &successLogic{})
-		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
-			log.Fatal(err)
-			return
-		}
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 200, response.Code)
-		// log.Info(response.Message)
-	})
-
-	t.Run("error Insert event", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"doctor_uid":  "doctor",
-			"patient_uid": "patient",
-			"date":        "05-05-2022",
-			"complaint":   "sick",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&mockSuccess{}, &errorInsertEvent{}, &successLogic{})
-		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
-			log.Fatal(err)
-			return
-		}
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 200, response.Code)
-		// log.Info(response.Message)
-	})
-
-	t.Run("error update event id", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"doctor_uid":  "doctor",
-			"patient_uid": "patient",
-			"date":        "05-05-2022",
-			"complaint":   "sick",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&errorUpdateEventId{}, &MockCal{}, &successLogic{})
-		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.Create())(context); err != nil {
-			log.Fatal(err)
-			return
-		}
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 201, response.Code)
-		// log.Info(response.Message)
-	})
-
 }
 
 func TestUpdate(t *testing.T) {
@@ -840,7 +617,7 @@ func TestUpdate(t *testing.T) {
 		context.SetParamValues("visit 123")
 		// log.Info(context.ParamNames())
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		controller.Update()(context)
 
 		var response = ResponseFormat{}
@@ -863,7 +640,7 @@ func TestUpdate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		controller.Update()(context)
 
 		var response = ResponseFormat{}
@@ -886,7 +663,7 @@ func TestUpdate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &errorLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &errorLogic{})
 		controller.Update()(context)
 
 		var response = ResponseFormat{}
@@ -909,7 +686,7 @@ func TestUpdate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		controller.Update()(context)
 
 		var response = ResponseFormat{}
@@ -932,7 +709,7 @@ func TestUpdate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&spesificError{}, &MockCal{}, &successLogic{})
+		var controller = New(&spesificError{}, &MockSync{}, &successLogic{})
 		controller.Update()(context)
 
 		var response = ResponseFormat{}
@@ -957,7 +734,7 @@ func TestUpdate(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockFail{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockFail{}, &MockSync{}, &successLogic{})
 		controller.Update()(context)
 
 		var response = ResponseFormat{}
@@ -969,107 +746,6 @@ func TestUpdate(t *testing.T) {
 		// log.Info(response.Message)
 	})
 
-	t.Run("error visit list", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"complaint": "sick",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&errorVisitList{}, &MockCal{}, &successLogic{})
-		controller.Update()(context)
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 202, response.Code)
-		// log.Info(response.Message)
-	})
-
-	t.Run("error create event", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"complaint": "sick",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&mockSuccess{}, &errorCreateEvent{}, &successLogic{})
-		controller.Update()(context)
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 202, response.Code)
-		// log.Info(response.Message)
-	})
-
-	t.Run("error update event", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"complaint": "sick",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&mockSuccess{}, &errorInsertEvent{}, &successLogic{})
-		controller.Update()(context)
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 202, response.Code)
-		// log.Info(response.Message)
-	})
-
-	t.Run("error delete cancel event event", func(t *testing.T) {
-		var e = echo.New()
-
-		var reqBody, _ = json.Marshal(map[string]interface{}{
-			"complaint": "sick",
-			"status":    "cancelled",
-		})
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&mockSuccess{}, &errorCancelEvent{}, &successLogic{})
-		controller.Update()(context)
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 202, response.Code)
-		// log.Info(response.Message)
-	})
-
 }
 
 func TestDelete(t *testing.T) {
@@ -1088,7 +764,7 @@ func TestDelete(t *testing.T) {
 		context.SetParamValues("visit 123")
 		// log.Info(context.ParamNames())
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		controller.Delete()(context)
 
 		var response = ResponseFormat{}
@@ -1107,7 +783,7 @@ func TestDelete(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&spesificError{}, &MockCal{}, &successLogic{})
+		var controller = New(&spesificError{}, &MockSync{}, &successLogic{})
 		controller.Delete()(context)
 
 		var response = ResponseFormat{}
@@ -1128,7 +804,7 @@ func TestDelete(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockFail{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockFail{}, &MockSync{}, &successLogic{})
 		controller.Delete()(context)
 
 		var response = ResponseFormat{}
@@ -1140,27 +816,6 @@ func TestDelete(t *testing.T) {
 		// log.Info(response.Message)
 	})
 
-	t.Run("delete event", func(t *testing.T) {
-		var e = echo.New()
-
-		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(nil))
-		var res = httptest.NewRecorder()
-		req.Header.Set("Content-Type", "application/json")
-
-		context := e.NewContext(req, res)
-
-		var controller = New(&mockSuccess{}, &errorInsertEvent{}, &successLogic{})
-		controller.Delete()(context)
-
-		var response = ResponseFormat{}
-
-		json.Unmarshal([]byte(res.Body.Bytes()), &response)
-
-		// log.Info(response)
-		assert.Equal(t, 200, response.Code)
-		// log.Info(response.Message)
-	})
-
 }
 
 func TestGetVisits(t *testing.T) {
@@ -1202,7 +857,7 @@ func TestGetVisits(t *testing.T) {
 		context := e.NewContext(req, res)
 		context.QueryParams().Add("status", "pending")
 
-		var controller = New(&mockSuccess{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetVisits())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -1215,6 +870,74 @@ func TestGetVisits(t *testing.T) {
 		assert.Equal(t, 200, response.Code)
 	})
 
+	t.Run("success search", func(t *testing.T) {
+		var e = echo.New()
+
+		var req = httptest.NewRequest(http.MethodGet, "/?kind=doctor&uid=doctor&status=pending,ready&date_from=01-03-2022&complaint=%27%20or%201=1%20--", bytes.NewBuffer(nil))
+		var res = httptest.NewRecorder()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
+
+		context := e.NewContext(req, res)
+
+		var r = &searchVisit{}
+		var controller = New(r, &MockSync{}, &successLogic{})
+		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetVisits())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		assert.Equal(t, 200, res.Code)
+		assert.Equal(t, visit.Search{Doctor_uid: "doctor", Statuses: []string{"pending", "ready"}, From: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), Complaint: "' or 1=1 --"}, r.search)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		var e = echo.New()
+
+		var req = httptest.NewRequest(http.MethodGet, "/?status=pending,'lost'", bytes.NewBuffer(nil))
+		var res = httptest.NewRecorder()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
+
+		context := e.NewContext(req, res)
+
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
+		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetVisits())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		var response = ResponseFormat{}
+
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "invalid status input", response.Message)
+	})
+
+	t.Run("cursor with grouped", func(t *testing.T) {
+		var e = echo.New()
+		var date = "2022-03-01"
+
+		var req = httptest.NewRequest(http.MethodGet, "/?grouped=patient&cursor="+list.Encode(list.Cursor{Sort: "-date", Value: &date, Key: "1"}), bytes.NewBuffer(nil))
+		var res = httptest.NewRecorder()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", jwt))
+
+		context := e.NewContext(req, res)
+
+		var controller = New(&mockSuccess{}, &MockSync{}, &successLogic{})
+		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetVisits())(context); err != nil {
+			log.Fatal(err)
+			return
+		}
+
+		var response = ResponseFormat{}
+
+		json.Unmarshal([]byte(res.Body.Bytes()), &response)
+		assert.Equal(t, 400, response.Code)
+		assert.Equal(t, "cursor can't be used with grouped, use offset", response.Message)
+	})
+
 	t.Run("internal server", func(t *testing.T) {
 		var e = echo.New()
 
@@ -1225,7 +948,7 @@ func TestGetVisits(t *testing.T) {
 
 		context := e.NewContext(req, res)
 
-		var controller = New(&mockFail{}, &MockCal{}, &successLogic{})
+		var controller = New(&mockFail{}, &MockSync{}, &successLogic{})
 		if err := middleware.JWT([]byte(configs.JWT_SECRET))(controller.GetVisits())(context); err != nil {
 			log.Fatal(err)
 			return
@@ -0,0 +1,146 @@
+package hl7
+
+import (
+	logicLab "be/delivery/logic/lab"
+	"be/entities"
+	repo "be/repository/hl7"
+	"be/utils/hl7"
+	"errors"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Handler processes the HL7 v2 messages coming over MLLP and http the same way,
+// every message is stored raw first and answered with an ACK
+
+type Handler struct {
+	r repo.Hl7
+}
+
+func New(r repo.Hl7) *Handler {
+	return &Handler{
+		r: r,
+	}
+}
+
+func (h *Handler) Handle(raw, source string) string {
+	var msg, err = hl7.Parse(raw)
+
+	// a message resent because its ACK got lost is not applied twice
+
+	if err == nil {
+		if _, err := h.r.FindProcessed(msg.Sender(), msg.ControlId()); err == nil {
+			return hl7.Ack(msg, hl7.Accept, "message is already processed")
+		}
+	}
+
+	stored, errStore := h.r.Store(entities.Hl7Message{Control_id: msg.ControlId(), Sender: msg.Sender(), Type: msg.Type(), Event: msg.Event(), Source: source, Raw: raw})
+
+	if errStore != nil {
+		log.Warn(errStore)
+		return hl7.Ack(msg, hl7.Error, "there's problem in server")
+	}
+
+	if err != nil {
+		h.failed(stored.Message_uid, err.Error())
+		return hl7.Ack(msg, hl7.Reject, err.Error())
+	}
+
+	return h.process(stored.Message_uid, msg)
+}
+
+// Replay processes a stored message that failed before again
+
+func (h *Handler) Replay(message_uid string) (string, error) {
+	var stored, err = h.r.GetMessage(message_uid)
+	if err != nil {
+		return "", err
+	}
+
+	if stored.Status == "processed" {
+		return "", errors.New("message is already processed")
+	}
+
+	msg, err := hl7.Parse(stored.Raw)
+	if err != nil {
+		h.failed(message_uid, err.Error())
+		return hl7.Ack(msg, hl7.Reject, err.Error()), nil
+	}
+
+	return h.process(message_uid, msg), nil
+}
+
+func (h *Handler) process(message_uid string, msg hl7.Message) string {
+	var target, code, err = h.apply(msg)
+
+	if err != nil {
+		h.failed(message_uid, err.Error())
+		return hl7.Ack(msg, code, err.Error())
+	}
+
+	if err := h.r.Processed(message_uid, target); err != nil {
+		log.Warn(err)
+	}
+
+	return hl7.Ack(msg, hl7.Accept, "")
+}
+
+// apply returns what the message was applied to, or the ACK code for the error.
+// Message types and events that are not handled are rejected, messages that
+// can't be applied are answered with an application error so they are resent
+
+func (h *Handler) apply(msg hl7.Message) (string, string, error) {
+	switch msg.Type() {
+	case "ADT":
+		if !hl7.AdtEvents[msg.Event()] {
+			return "", hl7.Reject, errors.New("unsupported event ADT^" + msg.Event())
+		}
+
+		var patient, err = hl7.ToPatient(msg)
+		if err != nil {
+			return "", hl7.Error, err
+		}
+
+		patient_uid, err := h.r.Adt(patient)
+		if err != nil {
+			log.Warn(err)
+			return "", hl7.Error, errors.New("failed to save patient")
+		}
+
+		return patient_uid, "", nil
+	case "ORU":
+		if msg.Event() != "R01" {
+			return "", hl7.Reject, errors.New("unsupported event ORU^" + msg.Event())
+		}
+
+		var orders, err = hl7.ToResults(msg)
+		if err != nil {
+			return "", hl7.Error, err
+		}
+
+		for i := range orders {
+			for j, result := range orders[i].Results {
+				if result.Flag == "" {
+					orders[i].Results[j].Flag = logicLab.Flag(result.Value, result.ReferenceRange)
+				}
+			}
+		}
+
+		uids, err := h.r.Oru(orders)
+		if err != nil {
+			log.Warn(err)
+			return strings.Join(uids, ","), hl7.Error, err
+		}
+
+		return strings.Join(uids, ","), "", nil
+	}
+
+	return "", hl7.Reject, errors.New("unsupported message type " + msg.Type())
+}
+
+func (h *Handler) failed(message_uid, reason string) {
+	if err := h.r.Failed(message_uid, reason); err != nil {
+		log.Warn(err)
+	}
+}
@@ -0,0 +1,233 @@
+package hl7
+
+import (
+	"be/entities"
+	repo "be/repository/hl7"
+	"be/utils/hl7"
+	"be/utils/list"
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockRepo struct {
+	stored    []entities.Hl7Message
+	processed map[string]string
+	failed    map[string]string
+	patients  []entities.Patient
+	orders    []hl7.Oru
+}
+
+func newMock() *mockRepo {
+	return &mockRepo{processed: map[string]string{}, failed: map[string]string{}}
+}
+
+func (m *mockRepo) Store(req entities.Hl7Message) (entities.Hl7Message, error) {
+	req.Message_uid = "message"
+	m.stored = append(m.stored, req)
+	return req, nil
+}
+
+func (m *mockRepo) FindProcessed(sender, control_id string) (entities.Hl7Message, error) {
+	if control_id == "DUPLICATE" {
+		return entities.Hl7Message{}, nil
+	}
+	return entities.Hl7Message{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) Processed(message_uid, target string) error {
+	m.processed[message_uid] = target
+	return nil
+}
+
+func (m *mockRepo) Failed(message_uid, reason string) error {
+	m.failed[message_uid] = reason
+	return nil
+}
+
+func (m *mockRepo) GetMessage(message_uid string) (entities.Hl7Message, error) {
+	switch message_uid {
+	case "processed":
+		return entities.Hl7Message{Message_uid: message_uid, Status: "processed"}, nil
+	case "failed":
+		return entities.Hl7Message{Message_uid: message_uid, Status: "failed", Raw: adt}, nil
+	}
+	return entities.Hl7Message{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetMessages(q list.Query) (repo.Messages, list.Page, error) {
+	return repo.Messages{}, list.Page{}, nil
+}
+
+func (m *mockRepo) Adt(req entities.Patient) (string, error) {
+	m.patients = append(m.patients, req)
+	return "patient", nil
+}
+
+func (m *mockRepo) Oru(orders []hl7.Oru) ([]string, error) {
+	if orders[0].Order_uid == "unknown" {
+		return nil, errors.New("order unknown and visit  are not found")
+	}
+	m.orders = orders
+	return []string{"order-1"}, nil
+}
+
+var adt = strings.Join([]string{
+	`MSH|^~\&|SIMRS|RSUD|MRCLINIC|CLINIC|20220601101500||ADT^A04|MSG0001|P|2.5`,
+	`PID|1||3171234567890123^^^^NIK||Rahayu^Siti||19900505|F`,
+}, "\r")
+
+var oru = strings.Join([]string{
+	`MSH|^~\&|ANALYZER|LAB|MRCLINIC|CLINIC|20220601120000||ORU^R01|MSG0002|P|2.5.1`,
+	`OBR|1|order-1||GLU^Glucose`,
+	`OBX|1|NM|GLU^^LN||250|mg/dL|70-140||||F`,
+}, "\r")
+
+func code(t *testing.T, ack string) string {
+	var msg, err = hl7.Parse(ack)
+	assert.Nil(t, err)
+	return msg.Get("MSA", 1, 1)
+}
+
+func TestHandle(t *testing.T) {
+	t.Run("success adt", func(t *testing.T) {
+		var r = newMock()
+		assert.Equal(t, "AA", code(t, New(r).Handle(adt, "http")))
+		assert.Equal(t, "3171234567890123", r.patients[0].Nik)
+		assert.Equal(t, "patient", r.processed["message"])
+		assert.Equal(t, "ADT", r.stored[0].Type)
+	})
+
+	t.Run("success oru", func(t *testing.T) {
+		var r = newMock()
+		assert.Equal(t, "AA", code(t, New(r).Handle(oru, "mllp")))
+		assert.Equal(t, "H", r.orders[0].Results[0].Flag)
+		assert.Equal(t, "order-1", r.processed["message"])
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		var r = newMock()
+		assert.Equal(t, "AA", code(t, New(r).Handle(strings.Replace(adt, "MSG0001", "DUPLICATE", 1), "http")))
+		assert.Equal(t, 0, len(r.stored))
+	})
+
+	t.Run("reject unparsed", func(t *testing.T) {
+		var r = newMock()
+		assert.Equal(t, "AR", code(t, New(r).Handle("PID|1", "http")))
+		assert.Equal(t, 1, len(r.stored))
+		assert.NotEqual(t, "", r.failed["message"])
+	})
+
+	t.Run("reject type", func(t *testing.T) {
+		var r = newMock()
+		assert.Equal(t, "AR", code(t, New(r).Handle(strings.Replace(adt, "ADT^A04", "SIU^S12", 1), "http")))
+	})
+
+	t.Run("error nik", func(t *testing.T) {
+		var r = newMock()
+		assert.Equal(t, "AE", code(t, New(r).Handle(strings.Replace(adt, "3171234567890123", "123", 1), "http")))
+		assert.Equal(t, "patient has no valid nik", r.failed["message"])
+	})
+
+	t.Run("error order", func(t *testing.T) {
+		var r = newMock()
+		assert.Equal(t, "AE", code(t, New(r).Handle(strings.Replace(oru, "order-1", "unknown", 1), "http")))
+	})
+}
+
+func TestReplay(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var r = newMock()
+		var ack, err = New(r).Replay("failed")
+		assert.Nil(t, err)
+		assert.Equal(t, "AA", code(t, ack))
+		assert.Equal(t, "patient", r.processed["failed"])
+	})
+
+	t.Run("processed", func(t *testing.T) {
+		var _, err = New(newMock()).Replay("processed")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var _, err = New(newMock()).Replay("unknown")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestServer(t *testing.T) {
+	var ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go NewServer(New(newMock()), nil).Serve(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var r = bufio.NewReader(conn)
+
+	for _, message := range []string{adt, oru} {
+		assert.Nil(t, hl7.WriteFrame(conn, message))
+
+		var ack, err = hl7.ReadFrame(r)
+		assert.Nil(t, err)
+		assert.Equal(t, "AA", code(t, ack))
+	}
+}
+
+func TestAllow(t *testing.T) {
+	t.Run("success parse", func(t *testing.T) {
+		var allow, err = ParseAllow("10.1.2.3, 192.168.0.0/16")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(allow))
+
+		var s = NewServer(New(newMock()), allow)
+		assert.True(t, s.allowed(&net.TCPAddr{IP: net.ParseIP("10.1.2.3")}))
+		assert.True(t, s.allowed(&net.TCPAddr{IP: net.ParseIP("192.168.4.5")}))
+		assert.False(t, s.allowed(&net.TCPAddr{IP: net.ParseIP("10.1.2.4")}))
+		assert.False(t, s.allowed(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")}))
+	})
+
+	t.Run("only loopback without list", func(t *testing.T) {
+		var s = NewServer(New(newMock()), nil)
+		assert.True(t, s.allowed(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")}))
+		assert.False(t, s.allowed(&net.TCPAddr{IP: net.ParseIP("203.0.113.7")}))
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		var _, err = ParseAllow("10.1.2.3,lab-host")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("refused connection", func(t *testing.T) {
+		var ln, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		var allow, _ = ParseAllow("10.0.0.0/8")
+		go NewServer(New(newMock()), allow).Serve(ln)
+
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		hl7.WriteFrame(conn, adt)
+		_, err = hl7.ReadFrame(bufio.NewReader(conn))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,6 @@
+package hl7
+
+type Ingest interface {
+	Handle(raw, source string) string
+	Replay(message_uid string) (string, error)
+}
@@ -0,0 +1,144 @@
+package hl7
+
+import (
+	"be/utils/hl7"
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// a connection without any message for this long is closed, senders reconnect
+
+const idleTimeout = 10 * time.Minute
+
+// Server takes messages from the senders of the allowed addresses, mllp has
+// no authentication of its own. Without any allowed address only the senders
+// on the same host are taken
+
+type Server struct {
+	ingest Ingest
+	allow  []*net.IPNet
+}
+
+func NewServer(ingest Ingest, allow []*net.IPNet) *Server {
+	return &Server{
+		ingest: ingest,
+		allow:  allow,
+	}
+}
+
+// ParseAllow reads the comma separated addresses and cidr ranges of HL7_ALLOW
+
+func ParseAllow(list string) ([]*net.IPNet, error) {
+	var res []*net.IPNet
+
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			var ip = net.ParseIP(value)
+			if ip == nil {
+				return nil, errors.New("invalid hl7 allowed address " + value)
+			}
+			res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		var _, network, err = net.ParseCIDR(value)
+		if err != nil {
+			return nil, errors.New("invalid hl7 allowed address " + value)
+		}
+		res = append(res, network)
+	}
+
+	return res, nil
+}
+
+func (s *Server) allowed(addr net.Addr) bool {
+	var tcp, ok = addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	if len(s.allow) == 0 {
+		return tcp.IP.IsLoopback()
+	}
+
+	for _, network := range s.allow {
+		if network.Contains(tcp.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) Listen(addr string) error {
+	var ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	log.Info("hl7 mllp listener on ", addr)
+	return s.Serve(ln)
+}
+
+func (s *Server) Serve(ln net.Listener) error {
+	for {
+		var conn, err = ln.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		if !s.allowed(conn.RemoteAddr()) {
+			log.Warn("hl7 mllp connection refused from ", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		go s.serve(conn)
+	}
+}
+
+// messages of one connection are answered in order, the sender waits for the
+// ACK before it sends the next one
+
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var r = bufio.NewReader(conn)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+		var raw, err = hl7.ReadFrame(r)
+		if err != nil {
+			if err != io.EOF {
+				log.Warn(conn.RemoteAddr(), " ", err)
+			}
+			return
+		}
+
+		var ack = s.ingest.Handle(raw, "mllp")
+
+		conn.SetWriteDeadline(time.Now().Add(time.Minute))
+
+		if err := hl7.WriteFrame(conn, ack); err != nil {
+			log.Warn(conn.RemoteAddr(), " ", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package bulk
+
+import (
+	logic "be/delivery/logic/bulk"
+	"be/entities"
+	"be/repository/bulk"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// the holder of the lease commits the claimed imports, the lease is renewed
+// before every batch. A replica that stops in the middle of an import loses
+// the lease and the next holder goes on with the rows not created yet
+
+const (
+	leaseName = "bulk-import"
+	leaseTtl  = 10 * time.Minute
+	tickEvery = 30 * time.Second
+)
+
+// Job registers the valid rows of the imports claimed by their requester, the
+// imports table is the source of truth so nothing is lost on a restart
+
+type Job struct {
+	r      bulk.Bulk
+	l      logic.Bulk
+	lease  Lease
+	holder string
+	wake   chan struct{}
+}
+
+func New(r bulk.Bulk, l logic.Bulk, lease Lease) *Job {
+	var host, _ = os.Hostname()
+
+	return &Job{
+		r:      r,
+		l:      l,
+		lease:  lease,
+		holder: host + "-" + shortuuid.New(),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Start ticks every 30 seconds, or right away when an import is claimed
+
+func (j *Job) Start() {
+	go func() {
+		for {
+			j.Tick()
+			select {
+			case <-j.wake:
+			case <-time.After(tickEvery):
+			}
+		}
+	}()
+}
+
+// Enqueue does not block the request, the import is committed by the replica
+// holding the lease
+func (j *Job) Enqueue(bulk_uid string) {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Tick commits the claimed imports when this replica holds the lease
+
+func (j *Job) Tick() {
+	if !j.leader() {
+		return
+	}
+
+	var bulks, err = j.r.GetCommitting()
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	for _, b := range bulks {
+		if err := j.Run(b); err != nil {
+			log.Warn("import ", b.Bulk_uid, ": ", err)
+		}
+	}
+}
+
+// Run registers the valid rows of the import batch by batch and marks it done
+
+func (j *Job) Run(b entities.Bulk) error {
+	var rows, err = j.r.GetRows(b.Bulk_uid)
+	if err != nil {
+		return err
+	}
+
+	var records = j.records(b.Kind, rows)
+
+	for start := 0; start < len(records); start += bulk.BatchSize {
+		var end = start + bulk.BatchSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		if !j.leader() {
+			return errors.New("lease is lost")
+		}
+
+		if err := j.r.Commit(b.Bulk_uid, records[start:end]); err != nil {
+			return err
+		}
+	}
+
+	_, err = j.r.Finish(b.Bulk_uid)
+	return err
+}
+
+func (j *Job) leader() bool {
+	var leader, err = j.lease.Lease(leaseName, j.holder, leaseTtl)
+	if err != nil {
+		log.Warn(err)
+	}
+	return err == nil && leader
+}
+
+// records are the valid rows not created yet, as the patients or doctors to
+// register
+
+func (j *Job) records(kind string, rows []entities.BulkRow) []bulk.Record {
+	var records []bulk.Record
+
+	for _, row := range rows {
+		if row.Status != "valid" {
+			continue
+		}
+
+		var data map[string]string
+		if err := json.Unmarshal([]byte(row.Data), &data); err != nil {
+			log.Warn(err)
+			continue
+		}
+
+		var record = bulk.Record{Row: row.Line}
+
+		switch kind {
+		case "patient":
+			var patient, err = j.l.ToPatient(data)
+			if err != nil {
+				continue
+			}
+			record.Patient = &patient
+		case "doctor":
+			var doctor, err = j.l.ToDoctor(data)
+			if err != nil {
+				continue
+			}
+			record.Doctor = &doctor
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
@@ -0,0 +1,82 @@
+package bulk
+
+import (
+	logic "be/delivery/logic/bulk"
+	"be/entities"
+	"be/repository/bulk"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepo struct {
+	committed []bulk.Record
+	finished  []string
+}
+
+func (m *mockRepo) Create(req entities.Bulk) (entities.Bulk, error) { return req, nil }
+
+func (m *mockRepo) GetBulk(bulk_uid string) (bulk.BulkResp, error) { return bulk.BulkResp{}, nil }
+
+func (m *mockRepo) GetRows(bulk_uid string) ([]entities.BulkRow, error) {
+	return []entities.BulkRow{
+		{Line: 2, Status: "valid", Data: `{"nik":"1234567890123456","name":"siti rahayu","gender":"wanita","address":"jl. melati 5 jakarta","placeBirth":"bandung","dob":"05-05-1990","job":"guru","status":"kawin","religion":"islam"}`},
+		{Line: 3, Status: "created", Data: `{"nik":"6543210987654321","name":"andi wijaya","gender":"pria","address":"jl. melati 5 jakarta","placeBirth":"bandung","dob":"05-05-1990","job":"guru","status":"kawin","religion":"islam"}`},
+		{Line: 4, Status: "invalid", Error: "invalid length nik", Data: `{"nik":"12345"}`},
+	}, nil
+}
+
+func (m *mockRepo) GetCommitting() ([]entities.Bulk, error) {
+	return []entities.Bulk{{Bulk_uid: "bulk", Kind: "patient", Status: "committing"}}, nil
+}
+
+func (m *mockRepo) Claim(bulk_uid, requester_uid string) (entities.Bulk, error) {
+	return entities.Bulk{}, nil
+}
+
+func (m *mockRepo) Commit(bulk_uid string, records []bulk.Record) error {
+	m.committed = append(m.committed, records...)
+	return nil
+}
+
+func (m *mockRepo) Finish(bulk_uid string) (entities.Bulk, error) {
+	m.finished = append(m.finished, bulk_uid)
+	return entities.Bulk{}, nil
+}
+
+type mockLease struct {
+	leader bool
+}
+
+func (m *mockLease) Lease(name, holder string, ttl time.Duration) (bool, error) {
+	if !m.leader {
+		return false, errors.New("lease is held by another replica")
+	}
+	return true, nil
+}
+
+func TestTick(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var r = &mockRepo{}
+		New(r, logic.New(), &mockLease{leader: true}).Tick()
+		assert.Equal(t, 1, len(r.committed))
+		assert.Equal(t, "1234567890123456", r.committed[0].Patient.Nik)
+		assert.Equal(t, []string{"bulk"}, r.finished)
+	})
+
+	t.Run("not leader", func(t *testing.T) {
+		var r = &mockRepo{}
+		New(r, logic.New(), &mockLease{}).Tick()
+		assert.Equal(t, 0, len(r.committed))
+		assert.Equal(t, 0, len(r.finished))
+	})
+}
+
+func TestEnqueue(t *testing.T) {
+	var j = New(&mockRepo{}, logic.New(), &mockLease{})
+	j.Enqueue("bulk")
+	j.Enqueue("bulk")
+	assert.Equal(t, 1, len(j.wake))
+}
@@ -0,0 +1,15 @@
+package bulk
+
+import "time"
+
+// Lease is the lease of the scheduler, so one replica commits the imports
+
+type Lease interface {
+	Lease(name, holder string, ttl time.Duration) (bool, error)
+}
+
+// Queue wakes the job for an import claimed by a request
+
+type Queue interface {
+	Enqueue(bulk_uid string)
+}
@@ -0,0 +1,163 @@
+package calendar
+
+import (
+	provider "be/api/calendar"
+	"be/entities"
+	"be/repository/calendar"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// a failed sync is tried again after 30s, 1m, 2m... at most 2h apart, and
+// dead after the last attempt
+
+const (
+	pollEvery   = 5 * time.Second
+	batch       = 50
+	maxAttempts = 10
+	backoffBase = 30 * time.Second
+	backoffMax  = 2 * time.Hour
+)
+
+// Job runs the calendar syncs the visit changes left in the outbox. A sync
+// is claimed before it runs, so every replica can run the job
+
+type Job struct {
+	r    calendar.Calendar
+	cal  provider.Provider
+	wake chan struct{}
+}
+
+func New(r calendar.Calendar, cal provider.Provider) *Job {
+	return &Job{
+		r:    r,
+		cal:  cal,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Start polls the outbox in the background
+
+func (j *Job) Start() {
+	go func() {
+		for {
+			j.Tick(time.Now())
+
+			select {
+			case <-j.wake:
+			case <-time.After(pollEvery):
+			}
+		}
+	}()
+}
+
+func (j *Job) Notify() {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Tick runs the syncs due now
+
+func (j *Job) Tick(now time.Time) {
+	var due, err = j.r.GetDue(now, batch)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	for _, sync := range due {
+		sync, err := j.r.Claim(sync.ID, now)
+		if err != nil {
+			continue
+		}
+
+		j.Run(sync, now)
+	}
+}
+
+func (j *Job) Run(sync entities.CalendarSync, now time.Time) {
+	if err := j.sync(sync.Visit_uid); err != nil {
+		log.Warn("calendar sync of visit ", sync.Visit_uid, ": ", err)
+
+		if sync.Attempts >= maxAttempts {
+			err = j.r.Bury(sync.ID, err.Error())
+		} else {
+			err = j.r.Retry(sync.ID, now.Add(Backoff(sync.Attempts)), err.Error())
+		}
+		if err != nil {
+			log.Warn(err)
+		}
+		return
+	}
+
+	if err := j.r.Finish(sync.ID); err != nil {
+		log.Warn(err)
+	}
+}
+
+// sync brings the event in line with the visit. An insert of a retried sync
+// finds the event it added before, the providers name the event after the
+// visit
+func (j *Job) sync(visit_uid string) error {
+	var res, err = j.r.GetSyncVisit(visit_uid)
+	if err != nil {
+		return err
+	}
+
+	// a cancelled or deleted visit is taken out of the calendar, the
+	// attendees are told
+
+	if res.Deleted || res.Status == "cancelled" {
+		if res.Event_uid == "" {
+			return nil
+		}
+
+		if err := j.cal.Delete(res.Event_uid, res.Doctor_uid); err != nil {
+			return err
+		}
+
+		if res.Deleted {
+			return nil
+		}
+		return j.r.SetEvent(visit_uid, "")
+	}
+
+	event, err := provider.FromVisit(res.VisitCalendar)
+	if err != nil {
+		return err
+	}
+
+	var ref provider.Ref
+	if res.Event_uid == "" {
+		ref, err = j.cal.Insert(event)
+	} else {
+		ref, err = j.cal.Update(res.Event_uid, event)
+	}
+	if err != nil {
+		return err
+	}
+
+	// a new event, or one moved to another calendar
+
+	if ref.Id != "" && ref.Id != res.Event_uid {
+		return j.r.SetEvent(visit_uid, ref.Id)
+	}
+
+	return nil
+}
+
+// Backoff is the wait after the attempts
+func Backoff(attempts int) time.Duration {
+	var wait = backoffBase
+	for i := 1; i < attempts && wait < backoffMax; i++ {
+		wait *= 2
+	}
+
+	if wait > backoffMax {
+		return backoffMax
+	}
+	return wait
+}
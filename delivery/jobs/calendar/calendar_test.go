@@ -0,0 +1,237 @@
+package calendar
+
+import (
+	provider "be/api/calendar"
+	"be/entities"
+	"be/repository/calendar"
+	"be/repository/visit"
+	"be/utils/list"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockRepo struct {
+	visit    calendar.SyncVisit
+	syncs    []entities.CalendarSync
+	event    *string
+	finished []uint
+	retried  map[uint]time.Time
+	buried   []uint
+}
+
+func (m *mockRepo) SetFeed(owner_uid, kind, token_hash string) error { return nil }
+
+func (m *mockRepo) DeleteFeed(owner_uid string) error { return nil }
+
+func (m *mockRepo) GetFeed(token_hash string) (entities.CalendarFeed, error) {
+	return entities.CalendarFeed{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvent(visit_uid string) (visit.VisitCalendar, error) {
+	return visit.VisitCalendar{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) CreateState(state entities.OauthState) error { return nil }
+
+func (m *mockRepo) TakeState(state_hash string) (entities.OauthState, error) {
+	return entities.OauthState{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) SetConnection(conn entities.CalendarConnection) error { return nil }
+
+func (m *mockRepo) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) ExpireConnection(doctor_uid string) error { return nil }
+
+func (m *mockRepo) DeleteConnection(doctor_uid string) error { return nil }
+
+func (m *mockRepo) GetDue(now time.Time, limit int) ([]entities.CalendarSync, error) {
+	return m.syncs, nil
+}
+
+// a sync is claimed once
+func (m *mockRepo) Claim(id uint, now time.Time) (entities.CalendarSync, error) {
+	for i, sync := range m.syncs {
+		if sync.ID == id && sync.Status == "pending" {
+			m.syncs[i].Status = "running"
+			m.syncs[i].Attempts++
+			return m.syncs[i], nil
+		}
+	}
+	return entities.CalendarSync{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) Finish(id uint) error {
+	m.finished = append(m.finished, id)
+	return nil
+}
+
+func (m *mockRepo) Retry(id uint, next_at time.Time, message string) error {
+	m.retried[id] = next_at
+	return nil
+}
+
+func (m *mockRepo) Bury(id uint, message string) error {
+	m.buried = append(m.buried, id)
+	return nil
+}
+
+func (m *mockRepo) GetClinic(admin_uid string) (string, error) { return "", nil }
+
+func (m *mockRepo) GetDead(doctor_uid string, q list.Query) ([]calendar.DeadSync, list.Page, error) {
+	return nil, list.Page{}, nil
+}
+
+func (m *mockRepo) Resync(doctor_uid, visit_uid string) error { return nil }
+
+func (m *mockRepo) GetSyncVisit(visit_uid string) (calendar.SyncVisit, error) {
+	return m.visit, nil
+}
+
+func (m *mockRepo) SetEvent(visit_uid, event_uid string) error {
+	m.event = &event_uid
+	return nil
+}
+
+func (m *mockRepo) GetConnections() ([]entities.CalendarConnection, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error {
+	return nil
+}
+
+func (m *mockRepo) GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error {
+	return nil
+}
+
+func (m *mockRepo) GetChannel(channel_id string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+type mockProvider struct {
+	calls []string
+	err   error
+}
+
+func (m *mockProvider) Insert(event provider.Event) (provider.Ref, error) {
+	m.calls = append(m.calls, "insert")
+	return provider.Ref{Id: "event"}, m.err
+}
+
+func (m *mockProvider) Update(id string, event provider.Event) (provider.Ref, error) {
+	m.calls = append(m.calls, "update")
+	return provider.Ref{Id: id}, m.err
+}
+
+func (m *mockProvider) Delete(id, owner string) error {
+	m.calls = append(m.calls, "delete")
+	return m.err
+}
+
+func repo(res calendar.SyncVisit, syncs ...entities.CalendarSync) *mockRepo {
+	return &mockRepo{visit: res, syncs: syncs, retried: map[uint]time.Time{}}
+}
+
+var now = time.Date(2022, 3, 1, 8, 0, 0, 0, time.UTC)
+
+func TestTick(t *testing.T) {
+	var pending = visit.VisitCalendar{Visit_uid: "visit", Doctor_uid: "doctor", Status: "pending", Date: "10-03-2022"}
+
+	t.Run("success insert", func(t *testing.T) {
+		var r = repo(calendar.SyncVisit{VisitCalendar: pending}, entities.CalendarSync{ID: 1, Visit_uid: "visit", Status: "pending"})
+		var cal = &mockProvider{}
+		New(r, cal).Tick(now)
+
+		assert.Equal(t, []string{"insert"}, cal.calls)
+		assert.Equal(t, "event", *r.event)
+		assert.Equal(t, []uint{1}, r.finished)
+	})
+
+	t.Run("success update once", func(t *testing.T) {
+		var res = pending
+		res.Event_uid = "event"
+
+		var r = repo(calendar.SyncVisit{VisitCalendar: res}, entities.CalendarSync{ID: 1, Visit_uid: "visit", Status: "pending"})
+		var cal = &mockProvider{}
+		var job = New(r, cal)
+		job.Tick(now)
+		job.Tick(now)
+
+		assert.Equal(t, []string{"update"}, cal.calls)
+		assert.Nil(t, r.event)
+	})
+
+	t.Run("success cancelled", func(t *testing.T) {
+		var res = pending
+		res.Event_uid = "event"
+		res.Status = "cancelled"
+
+		var r = repo(calendar.SyncVisit{VisitCalendar: res}, entities.CalendarSync{ID: 1, Visit_uid: "visit", Status: "pending"})
+		var cal = &mockProvider{}
+		New(r, cal).Tick(now)
+
+		assert.Equal(t, []string{"delete"}, cal.calls)
+		assert.Equal(t, "", *r.event)
+	})
+
+	t.Run("success deleted", func(t *testing.T) {
+		var res = pending
+		res.Event_uid = "event"
+
+		var r = repo(calendar.SyncVisit{VisitCalendar: res, Deleted: true}, entities.CalendarSync{ID: 1, Visit_uid: "visit", Status: "pending"})
+		var cal = &mockProvider{}
+		New(r, cal).Tick(now)
+
+		assert.Equal(t, []string{"delete"}, cal.calls)
+		assert.Nil(t, r.event)
+		assert.Equal(t, []uint{1}, r.finished)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var r = repo(calendar.SyncVisit{VisitCalendar: pending}, entities.CalendarSync{ID: 1, Visit_uid: "visit", Status: "pending", Attempts: 2})
+		New(r, &mockProvider{err: errors.New("google: 503")}).Tick(now)
+
+		assert.Equal(t, now.Add(2*time.Minute), r.retried[1])
+		assert.Equal(t, 0, len(r.finished))
+	})
+
+	t.Run("dead", func(t *testing.T) {
+		var r = repo(calendar.SyncVisit{VisitCalendar: pending}, entities.CalendarSync{ID: 1, Visit_uid: "visit", Status: "pending", Attempts: maxAttempts - 1})
+		New(r, &mockProvider{err: errors.New("google: 503")}).Tick(now)
+
+		assert.Equal(t, []uint{1}, r.buried)
+		assert.Equal(t, 0, len(r.retried))
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, backoffMax, Backoff(20))
+}
+
+func TestNotify(t *testing.T) {
+	var job = New(repo(calendar.SyncVisit{}), &mockProvider{})
+
+	// never blocks
+
+	job.Notify()
+	job.Notify()
+	assert.Equal(t, 1, len(job.wake))
+}
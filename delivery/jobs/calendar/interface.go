@@ -0,0 +1,8 @@
+package calendar
+
+// Notifier wakes the calendar sync after a visit change, the sync of the
+// change is already saved so a missed wake up only delays it
+
+type Notifier interface {
+	Notify()
+}
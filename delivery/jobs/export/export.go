@@ -0,0 +1,122 @@
+package export
+
+import (
+	"be/api/storage"
+	logic "be/delivery/logic/export"
+	"be/repository/export"
+	"bytes"
+	"path"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// the queue only carries export uids, the exports table is the source of truth
+// so exports queued on a replica that went down are picked up by the sweep
+
+const sweepEvery = time.Minute
+
+type Job struct {
+	r     export.Export
+	l     logic.Export
+	store storage.Private
+	queue chan string
+}
+
+func New(r export.Export, l logic.Export, store storage.Private) *Job {
+	return &Job{
+		r:     r,
+		l:     l,
+		store: store,
+		queue: make(chan string, 100),
+	}
+}
+
+// Start runs the workers and the sweep in the background
+
+func (j *Job) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for export_uid := range j.queue {
+				j.Run(export_uid)
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			j.sweep()
+			time.Sleep(sweepEvery)
+		}
+	}()
+}
+
+// Enqueue does not block the request, a full queue is left to the sweep
+
+func (j *Job) Enqueue(export_uid string) {
+	select {
+	case j.queue <- export_uid:
+	default:
+		log.Warn("export queue is full, ", export_uid, " waits for the sweep")
+	}
+}
+
+func (j *Job) sweep() {
+	var pending, err = j.r.GetPending()
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	for _, export := range pending {
+		j.Enqueue(export.Export_uid)
+	}
+}
+
+// Run builds one export, an export claimed by another replica is skipped
+
+func (j *Job) Run(export_uid string) {
+	var export, err = j.r.Claim(export_uid)
+	if err != nil {
+		return
+	}
+
+	record, err := j.r.GetRecord(export.Patient_uid)
+	if err != nil {
+		log.Warn(err)
+		j.fail(export_uid, "patient is not found")
+		return
+	}
+
+	file, err := j.l.Build(export.Format, record)
+	if err != nil {
+		log.Warn(err)
+		j.fail(export_uid, "failed to build export")
+		return
+	}
+
+	var key = "exports/" + export.Patient_uid + "/" + export.Export_uid + path.Ext(file.Name)
+
+	res, err := j.store.UploadPrivateReader(key, file.ContentType, bytes.NewReader(file.Body))
+	if err != nil {
+		log.Warn(err)
+		j.fail(export_uid, "failed to upload export")
+		return
+	}
+
+	export.FileName = file.Name
+	export.ContentType = file.ContentType
+	export.Size = res.Size
+	export.Checksum = res.Checksum
+	export.Object_key = res.Key
+
+	if err := j.r.Finish(export_uid, export); err != nil {
+		log.Warn(err)
+	}
+}
+
+func (j *Job) fail(export_uid, message string) {
+	if err := j.r.Fail(export_uid, message); err != nil {
+		log.Warn(err)
+	}
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"be/api/storage"
+	logic "be/delivery/logic/export"
+	"be/entities"
+	"be/repository/export"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepo struct {
+	claimed  bool
+	finished entities.Export
+	failed   string
+}
+
+func (m *mockRepo) CheckAccess(patient_uid, user_uid string) error { return nil }
+
+func (m *mockRepo) CountVisits(patient_uid string) (int64, error) { return 0, nil }
+
+func (m *mockRepo) GetRecord(patient_uid string) (export.Record, error) {
+	if patient_uid == "missing" {
+		return export.Record{}, errors.New("record not found")
+	}
+	return export.Record{Patient: entities.Patient{Patient_uid: patient_uid}}, nil
+}
+
+func (m *mockRepo) Create(req entities.Export) (entities.Export, error) { return req, nil }
+
+func (m *mockRepo) Claim(export_uid string) (entities.Export, error) {
+	if m.claimed {
+		return entities.Export{}, errors.New("export is already claimed")
+	}
+	m.claimed = true
+	var patient_uid = "patient"
+	if export_uid == "missing" {
+		patient_uid = "missing"
+	}
+	return entities.Export{Export_uid: export_uid, Patient_uid: patient_uid, Format: "json", Status: "running"}, nil
+}
+
+func (m *mockRepo) Finish(export_uid string, res entities.Export) error {
+	m.finished = res
+	return nil
+}
+
+func (m *mockRepo) Fail(export_uid, message string) error {
+	m.failed = message
+	return nil
+}
+
+func (m *mockRepo) GetExport(export_uid string) (entities.Export, error) {
+	return entities.Export{}, nil
+}
+
+func (m *mockRepo) GetPending() ([]entities.Export, error) {
+	return []entities.Export{{Export_uid: "export"}}, nil
+}
+
+type mockS3 struct{}
+
+func (m *mockS3) UploadPrivateFile(key string, fileHeader multipart.FileHeader) (storage.PrivateFile, error) {
+	return storage.PrivateFile{}, nil
+}
+
+func (m *mockS3) UploadPrivateReader(key, contentType string, body io.Reader) (storage.PrivateFile, error) {
+	var b, _ = ioutil.ReadAll(body)
+	return storage.PrivateFile{Key: key, ContentType: contentType, Size: int64(len(b)), Checksum: "checksum"}, nil
+}
+
+func (m *mockS3) SignedUrl(key string, expire time.Duration) (string, error) {
+	return "https://bucket/" + key, nil
+}
+
+func (m *mockS3) DeletePrivateFile(key string) error {
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	var l = logic.New(nil, "http://localhost/fhir")
+
+	t.Run("success", func(t *testing.T) {
+		var r = &mockRepo{}
+		New(r, l, &mockS3{}).Run("export")
+		assert.Equal(t, "exports/patient/export.json", r.finished.Object_key)
+		assert.Equal(t, "checksum", r.finished.Checksum)
+		assert.Equal(t, "", r.failed)
+	})
+
+	t.Run("skip claimed", func(t *testing.T) {
+		var r = &mockRepo{claimed: true}
+		New(r, l, &mockS3{}).Run("export")
+		assert.Equal(t, "", r.finished.Object_key)
+		assert.Equal(t, "", r.failed)
+	})
+
+	t.Run("error record", func(t *testing.T) {
+		var r = &mockRepo{}
+		New(r, l, &mockS3{}).Run("missing")
+		assert.Equal(t, "patient is not found", r.failed)
+	})
+}
+
+func TestSweep(t *testing.T) {
+	var j = New(&mockRepo{}, logic.New(nil, ""), &mockS3{})
+	j.sweep()
+	assert.Equal(t, "export", <-j.queue)
+}
@@ -0,0 +1,5 @@
+package export
+
+type Queue interface {
+	Enqueue(export_uid string)
+}
@@ -0,0 +1,184 @@
+package freebusy
+
+import (
+	provider "be/api/calendar"
+	"be/entities"
+	"be/repository/calendar"
+	"be/utils/busy"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// the holder of the lease imports the busy times of the next 60 days every
+// 15 minutes, a push channel of google is opened again a day before google
+// closes it
+
+const (
+	leaseName   = "calendar-busy"
+	leaseTtl    = 30 * time.Minute
+	tickEvery   = 15 * time.Minute
+	horizon     = 60 * 24 * time.Hour
+	channelTtl  = 7 * 24 * time.Hour
+	renewBefore = 24 * time.Hour
+	maxRefresh  = 64
+)
+
+// Job imports the busy times of the calendars the doctors connected, the
+// booking can't take a day they blocked. A google calendar pushes its
+// changes, so the busy times of the doctor are imported again right away
+
+type Job struct {
+	r       calendar.Calendar
+	cal     Calendar
+	lease   Lease
+	address string
+	holder  string
+	refresh chan string
+}
+
+// New takes the base url of the app, google only pushes to https
+func New(r calendar.Calendar, cal Calendar, lease Lease, baseUrl string) *Job {
+	var host, _ = os.Hostname()
+
+	var address string
+	if strings.HasPrefix(baseUrl, "https://") {
+		address = strings.TrimSuffix(baseUrl, "/") + "/calendar/push"
+	}
+
+	return &Job{
+		r:       r,
+		cal:     cal,
+		lease:   lease,
+		address: address,
+		holder:  host + "-" + shortuuid.New(),
+		refresh: make(chan string, maxRefresh),
+	}
+}
+
+// Start ticks every 15 minutes and imports the refreshed doctors in the
+// background
+
+func (j *Job) Start() {
+	go func() {
+		for {
+			j.Tick(time.Now())
+			time.Sleep(tickEvery)
+		}
+	}()
+
+	go func() {
+		for doctor_uid := range j.refresh {
+			if err := j.Import(doctor_uid, time.Now()); err != nil {
+				log.Warn("busy import of doctor ", doctor_uid, ": ", err)
+			}
+		}
+	}()
+}
+
+// Refresh imports the doctor soon, a refresh over the limit waits for the
+// next tick
+func (j *Job) Refresh(doctor_uid string) {
+	select {
+	case j.refresh <- doctor_uid:
+	default:
+	}
+}
+
+// Tick imports every connected calendar when this replica holds the lease
+
+func (j *Job) Tick(now time.Time) {
+	var leader, err = j.lease.Lease(leaseName, j.holder, leaseTtl)
+	if err != nil || !leader {
+		return
+	}
+
+	conns, err := j.r.GetConnections()
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	for _, conn := range conns {
+		if err := j.Import(conn.Doctor_uid, now); err != nil {
+			log.Warn("busy import of doctor ", conn.Doctor_uid, ": ", err)
+			continue
+		}
+
+		j.renew(conn, now)
+	}
+}
+
+// Import replaces the busy times of the doctor from the start of today
+func (j *Job) Import(doctor_uid string, now time.Time) error {
+	var from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var to = from.Add(horizon)
+
+	var intervals, err = j.cal.Busy(doctor_uid, from, to)
+	if err != nil {
+		return err
+	}
+
+	var times = []entities.BusyTime{}
+	for _, interval := range busy.Merge(intervals) {
+		times = append(times, entities.BusyTime{StartAt: interval.Start, EndAt: interval.End})
+	}
+
+	return j.r.SetBusy(doctor_uid, from, to, times)
+}
+
+// renew opens the push channel of a google calendar and stops the old one
+func (j *Job) renew(conn entities.CalendarConnection, now time.Time) {
+	if j.address == "" || conn.Provider != "google" {
+		return
+	}
+	if conn.Channel_expires_at != nil && conn.Channel_expires_at.Sub(now) > renewBefore {
+		return
+	}
+
+	var token, err = random()
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	channel, err := j.cal.Watch(conn.Doctor_uid, provider.Channel{Id: shortuuid.New(), Token: token, Address: j.address, Expires: now.Add(channelTtl)})
+	if err != nil {
+		log.Warn("push channel of doctor ", conn.Doctor_uid, ": ", err)
+		return
+	}
+
+	if err := j.r.SetChannel(conn.Doctor_uid, channel.Id, Hash(token), channel.Resource, channel.Expires); err != nil {
+		log.Warn(err)
+		return
+	}
+
+	if conn.Channel_id != "" {
+		if err := j.cal.Stop(conn.Doctor_uid, provider.Channel{Id: conn.Channel_id, Resource: conn.Channel_resource}); err != nil {
+			log.Warn(err)
+		}
+	}
+}
+
+func random() (string, error) {
+	var b = make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash is the channel token kept in the database, google sends the token
+// with every push
+func Hash(token string) string {
+	var sum = sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
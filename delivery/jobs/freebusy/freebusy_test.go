@@ -0,0 +1,251 @@
+package freebusy
+
+import (
+	provider "be/api/calendar"
+	"be/entities"
+	"be/repository/calendar"
+	"be/repository/visit"
+	"be/utils/busy"
+	"be/utils/list"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockRepo struct {
+	conns    []entities.CalendarConnection
+	busy     map[string][]entities.BusyTime
+	channels map[string]entities.CalendarConnection
+	from, to time.Time
+}
+
+func (m *mockRepo) SetFeed(owner_uid, kind, token_hash string) error {
+	return nil
+}
+
+func (m *mockRepo) DeleteFeed(owner_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetFeed(token_hash string) (entities.CalendarFeed, error) {
+	return entities.CalendarFeed{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvent(visit_uid string) (visit.VisitCalendar, error) {
+	return visit.VisitCalendar{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) CreateState(state entities.OauthState) error {
+	return nil
+}
+
+func (m *mockRepo) TakeState(state_hash string) (entities.OauthState, error) {
+	return entities.OauthState{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) SetConnection(conn entities.CalendarConnection) error {
+	return nil
+}
+
+func (m *mockRepo) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) ExpireConnection(doctor_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) DeleteConnection(doctor_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetDue(now time.Time, limit int) ([]entities.CalendarSync, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Claim(id uint, now time.Time) (entities.CalendarSync, error) {
+	return entities.CalendarSync{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) Finish(id uint) error {
+	return nil
+}
+
+func (m *mockRepo) Retry(id uint, next_at time.Time, message string) error {
+	return nil
+}
+
+func (m *mockRepo) Bury(id uint, message string) error {
+	return nil
+}
+
+func (m *mockRepo) GetClinic(admin_uid string) (string, error) {
+	return "", nil
+}
+
+func (m *mockRepo) GetDead(doctor_uid string, q list.Query) ([]calendar.DeadSync, list.Page, error) {
+	return nil, list.Page{}, nil
+}
+
+func (m *mockRepo) Resync(doctor_uid, visit_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetSyncVisit(visit_uid string) (calendar.SyncVisit, error) {
+	return calendar.SyncVisit{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) SetEvent(visit_uid, event_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetConnections() ([]entities.CalendarConnection, error) {
+	return m.conns, nil
+}
+
+func (m *mockRepo) SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error {
+	m.from, m.to = from, to
+	m.busy[doctor_uid] = times
+	return nil
+}
+
+func (m *mockRepo) GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error) {
+	return m.busy[doctor_uid], nil
+}
+
+func (m *mockRepo) SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error {
+	m.channels[doctor_uid] = entities.CalendarConnection{Doctor_uid: doctor_uid, Channel_id: channel_id, Channel_token: token_hash, Channel_resource: resource, Channel_expires_at: &expires}
+	return nil
+}
+
+func (m *mockRepo) GetChannel(channel_id string) (entities.CalendarConnection, error) {
+	return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+}
+
+type mockCalendar struct {
+	watched []provider.Channel
+	stopped []provider.Channel
+}
+
+func (m *mockCalendar) Busy(owner string, from, to time.Time) ([]busy.Interval, error) {
+	if owner == "refused" {
+		return nil, errors.New("caldav: unauthorized")
+	}
+
+	return []busy.Interval{
+		{Start: from.Add(33 * time.Hour), End: from.Add(34 * time.Hour)},
+		{Start: from.Add(9 * time.Hour), End: from.Add(11 * time.Hour)},
+		{Start: from.Add(10 * time.Hour), End: from.Add(12 * time.Hour)},
+	}, nil
+}
+
+func (m *mockCalendar) Watch(owner string, channel provider.Channel) (provider.Channel, error) {
+	m.watched = append(m.watched, channel)
+	channel.Resource = "resource"
+	return channel, nil
+}
+
+func (m *mockCalendar) Stop(owner string, channel provider.Channel) error {
+	m.stopped = append(m.stopped, channel)
+	return nil
+}
+
+type mockLease struct {
+	leader bool
+}
+
+func (m *mockLease) Lease(name, holder string, ttl time.Duration) (bool, error) {
+	return m.leader, nil
+}
+
+func TestTick(t *testing.T) {
+	var now = time.Date(2022, 3, 10, 14, 30, 0, 0, time.UTC)
+	var later = now.Add(5 * 24 * time.Hour)
+	var soon = now.Add(time.Hour)
+
+	var repo = func() *mockRepo {
+		return &mockRepo{
+			conns: []entities.CalendarConnection{
+				{Doctor_uid: "google", Provider: "google"},
+				{Doctor_uid: "caldav", Provider: "caldav"},
+				{Doctor_uid: "refused", Provider: "google"},
+				{Doctor_uid: "watched", Provider: "google", Channel_id: "fresh", Channel_expires_at: &later},
+				{Doctor_uid: "expiring", Provider: "google", Channel_id: "old", Channel_resource: "resource", Channel_expires_at: &soon},
+			},
+			busy:     map[string][]entities.BusyTime{},
+			channels: map[string]entities.CalendarConnection{},
+		}
+	}
+
+	t.Run("success import", func(t *testing.T) {
+		var r = repo()
+		New(r, &mockCalendar{}, &mockLease{leader: true}, "http://localhost").Tick(now)
+
+		// from the start of the day, the overlapping busy times merged
+
+		var from = time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, from, r.from)
+		assert.Equal(t, from.AddDate(0, 0, 60), r.to)
+		assert.Equal(t, []entities.BusyTime{
+			{StartAt: from.Add(9 * time.Hour), EndAt: from.Add(12 * time.Hour)},
+			{StartAt: from.Add(33 * time.Hour), EndAt: from.Add(34 * time.Hour)},
+		}, r.busy["google"])
+		assert.Equal(t, 2, len(r.busy["caldav"]))
+
+		// the busy times of a refused calendar are kept
+
+		_, ok := r.busy["refused"]
+		assert.False(t, ok)
+	})
+
+	t.Run("success push channels", func(t *testing.T) {
+		var r = repo()
+		var cal = &mockCalendar{}
+		New(r, cal, &mockLease{leader: true}, "https://clinic.example").Tick(now)
+
+		assert.Equal(t, 2, len(cal.watched))
+		assert.Equal(t, "https://clinic.example/calendar/push", cal.watched[0].Address)
+		assert.Equal(t, now.Add(channelTtl), cal.watched[0].Expires)
+
+		// only the token hash is kept
+
+		var channel = r.channels["google"]
+		assert.Equal(t, cal.watched[0].Id, channel.Channel_id)
+		assert.Equal(t, Hash(cal.watched[0].Token), channel.Channel_token)
+		assert.Equal(t, "resource", channel.Channel_resource)
+
+		// the channel about to close is opened again and the old one stopped
+
+		assert.NotEqual(t, "old", r.channels["expiring"].Channel_id)
+		assert.Equal(t, []provider.Channel{{Id: "old", Resource: "resource"}}, cal.stopped)
+
+		_, ok := r.channels["caldav"]
+		assert.False(t, ok)
+		_, ok = r.channels["watched"]
+		assert.False(t, ok)
+	})
+
+	t.Run("not leader", func(t *testing.T) {
+		var r = repo()
+		New(r, &mockCalendar{}, &mockLease{}, "https://clinic.example").Tick(now)
+		assert.Equal(t, 0, len(r.busy))
+		assert.Equal(t, 0, len(r.channels))
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	t.Run("full queue doesn't block", func(t *testing.T) {
+		var j = New(&mockRepo{}, &mockCalendar{}, &mockLease{}, "")
+		for i := 0; i <= maxRefresh; i++ {
+			j.Refresh("doctor")
+		}
+		assert.Equal(t, maxRefresh, len(j.refresh))
+	})
+}
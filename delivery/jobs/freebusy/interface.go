@@ -0,0 +1,28 @@
+package freebusy
+
+import (
+	provider "be/api/calendar"
+	"be/utils/busy"
+	"time"
+)
+
+// Lease is the lease of the scheduler, so one replica runs the import
+
+type Lease interface {
+	Lease(name, holder string, ttl time.Duration) (bool, error)
+}
+
+// Calendar reads the calendars the doctors connected
+
+type Calendar interface {
+	Busy(owner string, from, to time.Time) ([]busy.Interval, error)
+	Watch(owner string, channel provider.Channel) (provider.Channel, error)
+	Stop(owner string, channel provider.Channel) error
+}
+
+// Refresher imports the busy times of the doctor again, e.g. on a push of
+// the calendar
+
+type Refresher interface {
+	Refresh(doctor_uid string)
+}
@@ -0,0 +1,9 @@
+package notification
+
+import "time"
+
+// Lease is the lease of the scheduler, so one replica adds the reminders
+
+type Lease interface {
+	Lease(name, holder string, ttl time.Duration) (bool, error)
+}
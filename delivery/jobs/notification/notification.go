@@ -0,0 +1,241 @@
+package notification
+
+import (
+	"be/api/notify"
+	"be/entities"
+	"be/repository/notification"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// a failed notification is sent again after 1m, 2m, 4m... at most 1h apart,
+// and dead after the last attempt. The reminders of the visits of tomorrow
+// are added every hour and sent from 09:00
+
+const (
+	pollEvery    = 10 * time.Second
+	batch        = 50
+	maxAttempts  = 8
+	backoffBase  = time.Minute
+	backoffMax   = time.Hour
+	leaseName    = "notification-reminder"
+	leaseTtl     = 2 * time.Hour
+	remindEvery  = time.Hour
+	remindAt     = 9 * time.Hour
+	emailChannel = "email"
+	smsChannel   = "sms"
+	waChannel    = "whatsapp"
+)
+
+// Job sends the notifications the visit and lab changes left in the outbox,
+// on each channel the user chose. A notification is claimed before it runs,
+// so every replica can run the job
+
+type Job struct {
+	r        notification.Notification
+	channels map[string]notify.Channel
+	lease    Lease
+	holder   string
+	reminded time.Time
+}
+
+// New takes the channels by name: email, sms and whatsapp
+func New(r notification.Notification, channels map[string]notify.Channel, lease Lease) *Job {
+	var host, _ = os.Hostname()
+
+	return &Job{
+		r:        r,
+		channels: channels,
+		lease:    lease,
+		holder:   host + "-" + shortuuid.New(),
+	}
+}
+
+// Start polls the outbox in the background
+
+func (j *Job) Start() {
+	go func() {
+		for {
+			j.Tick(time.Now())
+			time.Sleep(pollEvery)
+		}
+	}()
+}
+
+// Tick adds the reminders once an hour and sends the notifications due now
+
+func (j *Job) Tick(now time.Time) {
+	if now.Sub(j.reminded) >= remindEvery {
+		j.reminded = now
+		j.Remind(now)
+	}
+
+	var due, err = j.r.GetDue(now, batch)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	for _, n := range due {
+		n, err := j.r.Claim(n.ID, now)
+		if err != nil {
+			continue
+		}
+
+		j.Run(n, now)
+	}
+}
+
+// Remind adds the reminders of the visits of tomorrow when this replica
+// holds the lease
+func (j *Job) Remind(now time.Time) {
+	var leader, err = j.lease.Lease(leaseName, j.holder, leaseTtl)
+	if err != nil || !leader {
+		return
+	}
+
+	var today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var next_at = today.Add(remindAt)
+	if now.After(next_at) {
+		next_at = now
+	}
+
+	if _, err := j.r.EnqueueReminders(today.AddDate(0, 0, 1), next_at); err != nil {
+		log.Warn(err)
+	}
+}
+
+func (j *Job) Run(n entities.Notification, now time.Time) {
+	if err := j.send(n); err != nil {
+		log.Warn("notification ", n.ID, " to ", n.User_uid, ": ", err)
+
+		if n.Attempts >= maxAttempts {
+			err = j.r.Bury(n.ID, err.Error())
+		} else {
+			err = j.r.Retry(n.ID, now.Add(Backoff(n.Attempts)), err.Error())
+		}
+		if err != nil {
+			log.Warn(err)
+		}
+		return
+	}
+
+	if err := j.r.Finish(n.ID); err != nil {
+		log.Warn(err)
+	}
+}
+
+// send renders the message and sends it on the channels of the user not
+// done before, the error tells the channels that failed
+func (j *Job) send(n entities.Notification) error {
+	var visit, err = j.r.GetVisit(n.Visit_uid)
+	if err != nil {
+		return err
+	}
+
+	// a visit cancelled after the reminder was added needs none
+
+	if n.Kind == notify.Reminder && (visit.Deleted || (visit.Status != "pending" && visit.Status != "ready")) {
+		return nil
+	}
+
+	recipient, err := j.r.GetRecipient(n.User_uid)
+	if err != nil {
+		return err
+	}
+
+	pref, err := j.r.GetPreference(n.User_uid)
+	if err != nil {
+		return err
+	}
+
+	var data = notify.Data{Name: recipient.Name, Other: visit.DoctorName, Date: visit.Date, Visit: visit.Visit_uid}
+	if recipient.Kind == "doctor" {
+		data.Other = visit.PatientName
+	}
+
+	msg, err := notify.Render(n.Kind, data)
+	if err != nil {
+		return err
+	}
+
+	done, err := j.r.GetDone(n.ID)
+	if err != nil {
+		return err
+	}
+
+	var phone = pref.Phone
+	if phone == "" {
+		phone = recipient.Phone
+	}
+
+	var failed = []string{}
+	for _, c := range []struct {
+		name    string
+		enabled bool
+		to      string
+	}{
+		{emailChannel, pref.Email, recipient.Email},
+		{smsChannel, pref.Sms, phone},
+		{waChannel, pref.Whatsapp, phone},
+	} {
+		if !c.enabled || contains(done, c.name) {
+			continue
+		}
+
+		var delivery = entities.NotificationDelivery{Notification_id: n.ID, User_uid: n.User_uid, Kind: n.Kind, Channel: c.name, Recipient: c.to, Status: "sent"}
+
+		var channel, ok = j.channels[c.name]
+		switch {
+		case c.to == "":
+			delivery.Status, delivery.Error = "skipped", "no "+c.name+" address"
+		case !ok:
+			delivery.Status, delivery.Error = "skipped", c.name+" is not configured"
+		default:
+			msg.To = c.to
+			if err := channel.Send(msg); err != nil {
+				delivery.Status, delivery.Error = "failed", err.Error()
+				failed = append(failed, c.name+": "+err.Error())
+			}
+		}
+
+		if err := j.r.LogDelivery(delivery); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	if len(failed) != 0 {
+		return errors.New(strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// Backoff is the wait after the attempts
+func Backoff(attempts int) time.Duration {
+	var wait = backoffBase
+	for i := 1; i < attempts && wait < backoffMax; i++ {
+		wait *= 2
+	}
+
+	if wait > backoffMax {
+		return backoffMax
+	}
+	return wait
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,259 @@
+package notification
+
+import (
+	"be/api/notify"
+	"be/entities"
+	"be/repository/notification"
+	"be/repository/visit"
+	"be/utils/list"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockRepo struct {
+	visit         notification.Visit
+	prefs         map[string]entities.NotificationPreference
+	notifications []entities.Notification
+	deliveries    []entities.NotificationDelivery
+	reminders     []time.Time
+	finished      []uint
+	retried       map[uint]time.Time
+	buried        []uint
+}
+
+func (m *mockRepo) GetPreference(user_uid string) (entities.NotificationPreference, error) {
+	if pref, ok := m.prefs[user_uid]; ok {
+		return pref, nil
+	}
+	return entities.NotificationPreference{User_uid: user_uid, Email: true}, nil
+}
+
+func (m *mockRepo) SetPreference(pref entities.NotificationPreference) (entities.NotificationPreference, error) {
+	return pref, nil
+}
+
+func (m *mockRepo) GetRecipient(user_uid string) (notification.Recipient, error) {
+	switch user_uid {
+	case "patient":
+		return notification.Recipient{Kind: "patient", Name: "siti", Email: "siti@mail.com", Phone: "08123456789"}, nil
+	case "doctor":
+		return notification.Recipient{Kind: "doctor", Name: "dr. budi", Email: "budi@mail.com"}, nil
+	}
+	return notification.Recipient{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) GetVisit(visit_uid string) (notification.Visit, error) {
+	return m.visit, nil
+}
+
+func (m *mockRepo) EnqueueReminders(date, next_at time.Time) (int, error) {
+	m.reminders = append(m.reminders, date, next_at)
+	return 0, nil
+}
+
+func (m *mockRepo) GetDue(now time.Time, limit int) ([]entities.Notification, error) {
+	return m.notifications, nil
+}
+
+// a notification is claimed once
+func (m *mockRepo) Claim(id uint, now time.Time) (entities.Notification, error) {
+	for i, n := range m.notifications {
+		if n.ID == id && n.Status == "pending" {
+			m.notifications[i].Status = "running"
+			m.notifications[i].Attempts++
+			return m.notifications[i], nil
+		}
+	}
+	return entities.Notification{}, gorm.ErrRecordNotFound
+}
+
+func (m *mockRepo) Finish(id uint) error {
+	m.finished = append(m.finished, id)
+	return nil
+}
+
+func (m *mockRepo) Retry(id uint, next_at time.Time, message string) error {
+	m.retried[id] = next_at
+	return nil
+}
+
+func (m *mockRepo) Bury(id uint, message string) error {
+	m.buried = append(m.buried, id)
+	return nil
+}
+
+func (m *mockRepo) GetDone(notification_id uint) ([]string, error) {
+	var res = []string{}
+	for _, d := range m.deliveries {
+		if d.Notification_id == notification_id && d.Status != "failed" {
+			res = append(res, d.Channel)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepo) LogDelivery(delivery entities.NotificationDelivery) error {
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *mockRepo) GetClinic(admin_uid string) (string, error) {
+	return "", nil
+}
+
+func (m *mockRepo) InClinic(clinic_uid, user_uid string) error {
+	return nil
+}
+
+func (m *mockRepo) GetDeliveries(user_uid string, q list.Query) ([]notification.Delivery, list.Page, error) {
+	return nil, list.Page{}, nil
+}
+
+type mockLease struct {
+	leader bool
+}
+
+func (m *mockLease) Lease(name, holder string, ttl time.Duration) (bool, error) {
+	return m.leader, nil
+}
+
+func TestTick(t *testing.T) {
+	var now = time.Date(2022, 3, 10, 14, 30, 0, 0, time.UTC)
+
+	var repo = func(notifications ...entities.Notification) *mockRepo {
+		return &mockRepo{
+			visit:         notification.Visit{VisitCalendar: visit.VisitCalendar{Visit_uid: "patient-1", Status: "pending", Date: "11-03-2022", DoctorName: "dr. budi", PatientName: "siti"}},
+			prefs:         map[string]entities.NotificationPreference{},
+			notifications: notifications,
+			retried:       map[uint]time.Time{},
+		}
+	}
+
+	var channels = func() (map[string]notify.Channel, *notify.Fake, *notify.Fake) {
+		var email, sms = notify.NewFake(), notify.NewFake()
+		return map[string]notify.Channel{emailChannel: email, smsChannel: sms, waChannel: notify.NewFake()}, email, sms
+	}
+
+	t.Run("success email by default", func(t *testing.T) {
+		var r = repo(entities.Notification{ID: 1, Kind: notify.BookingConfirmed, User_uid: "patient", Visit_uid: "patient-1", Status: "pending"})
+		var c, email, _ = channels()
+		New(r, c, &mockLease{}).Tick(now)
+
+		assert.Equal(t, []uint{1}, r.finished)
+		assert.Equal(t, 1, len(email.Sent))
+		assert.Equal(t, "siti@mail.com", email.Sent[0].To)
+		assert.Contains(t, email.Sent[0].Body, "dr. budi")
+		assert.Equal(t, []entities.NotificationDelivery{{Notification_id: 1, User_uid: "patient", Kind: notify.BookingConfirmed, Channel: "email", Recipient: "siti@mail.com", Status: "sent"}}, r.deliveries)
+	})
+
+	t.Run("the doctor is told of the patient", func(t *testing.T) {
+		var r = repo(entities.Notification{ID: 1, Kind: notify.Cancelled, User_uid: "doctor", Visit_uid: "patient-1", Status: "pending"})
+		var c, email, _ = channels()
+		New(r, c, &mockLease{}).Tick(now)
+
+		assert.Contains(t, email.Sent[0].Body, "Hi dr. budi")
+		assert.Contains(t, email.Sent[0].Body, "with siti")
+	})
+
+	t.Run("sms to the phone of the patient, whatsapp skipped for the doctor", func(t *testing.T) {
+		var r = repo(
+			entities.Notification{ID: 1, Kind: notify.BookingConfirmed, User_uid: "patient", Visit_uid: "patient-1", Status: "pending"},
+			entities.Notification{ID: 2, Kind: notify.BookingConfirmed, User_uid: "doctor", Visit_uid: "patient-1", Status: "pending"},
+		)
+		r.prefs["patient"] = entities.NotificationPreference{User_uid: "patient", Sms: true}
+		r.prefs["doctor"] = entities.NotificationPreference{User_uid: "doctor", Whatsapp: true}
+		var c, email, sms = channels()
+		New(r, c, &mockLease{}).Tick(now)
+
+		assert.Equal(t, []uint{1, 2}, r.finished)
+		assert.Equal(t, 0, len(email.Sent))
+		assert.Equal(t, "08123456789", sms.Sent[0].To)
+		assert.Equal(t, "skipped", r.deliveries[1].Status)
+		assert.Equal(t, "whatsapp", r.deliveries[1].Channel)
+	})
+
+	t.Run("retry sends only the failed channel", func(t *testing.T) {
+		var r = repo(entities.Notification{ID: 1, Kind: notify.ResultsReady, User_uid: "patient", Visit_uid: "patient-1", Status: "pending"})
+		r.prefs["patient"] = entities.NotificationPreference{User_uid: "patient", Email: true, Sms: true}
+		var c, email, sms = channels()
+		sms.Err = errors.New("gateway down")
+
+		var j = New(r, c, &mockLease{})
+		j.Tick(now)
+
+		assert.Equal(t, now.Add(time.Minute), r.retried[1])
+		assert.Equal(t, 0, len(r.finished))
+		assert.Equal(t, "failed", r.deliveries[1].Status)
+		assert.Equal(t, "gateway down", r.deliveries[1].Error)
+
+		sms.Err = nil
+		r.notifications[0].Status = "pending"
+		j.Tick(now.Add(time.Minute))
+
+		assert.Equal(t, []uint{1}, r.finished)
+		assert.Equal(t, 1, len(email.Sent))
+		assert.Equal(t, 1, len(sms.Sent))
+	})
+
+	t.Run("dead", func(t *testing.T) {
+		var r = repo(entities.Notification{ID: 1, Kind: notify.BookingConfirmed, User_uid: "patient", Visit_uid: "patient-1", Status: "pending", Attempts: maxAttempts - 1})
+		var c, email, _ = channels()
+		email.Err = errors.New("smtp down")
+		New(r, c, &mockLease{}).Tick(now)
+
+		assert.Equal(t, []uint{1}, r.buried)
+	})
+
+	t.Run("reminder of a cancelled visit is not sent", func(t *testing.T) {
+		var r = repo(entities.Notification{ID: 1, Kind: notify.Reminder, User_uid: "patient", Visit_uid: "patient-1", Status: "pending"})
+		r.visit.Status = "cancelled"
+		var c, email, _ = channels()
+		New(r, c, &mockLease{}).Tick(now)
+
+		assert.Equal(t, []uint{1}, r.finished)
+		assert.Equal(t, 0, len(email.Sent))
+		assert.Equal(t, 0, len(r.deliveries))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		var r = repo(entities.Notification{ID: 1, Kind: notify.BookingConfirmed, User_uid: "gone", Visit_uid: "patient-1", Status: "pending"})
+		var c, _, _ = channels()
+		New(r, c, &mockLease{}).Tick(now)
+
+		assert.Equal(t, 1, len(r.retried))
+	})
+}
+
+func TestRemind(t *testing.T) {
+	t.Run("leader adds the reminders of tomorrow from 09:00", func(t *testing.T) {
+		var r = &mockRepo{}
+		var j = New(r, nil, &mockLease{leader: true})
+
+		j.Tick(time.Date(2022, 3, 10, 2, 0, 0, 0, time.UTC))
+		assert.Equal(t, []time.Time{time.Date(2022, 3, 11, 0, 0, 0, 0, time.UTC), time.Date(2022, 3, 10, 9, 0, 0, 0, time.UTC)}, r.reminders)
+
+		// once an hour, sent right away after 09:00
+
+		j.Tick(time.Date(2022, 3, 10, 2, 30, 0, 0, time.UTC))
+		assert.Equal(t, 2, len(r.reminders))
+
+		j.Tick(time.Date(2022, 3, 10, 10, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 3, 10, 10, 0, 0, 0, time.UTC), r.reminders[3])
+	})
+
+	t.Run("not leader", func(t *testing.T) {
+		var r = &mockRepo{}
+		New(r, nil, &mockLease{}).Tick(time.Date(2022, 3, 10, 2, 0, 0, 0, time.UTC))
+		assert.Equal(t, 0, len(r.reminders))
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, Backoff(1))
+	assert.Equal(t, 4*time.Minute, Backoff(3))
+	assert.Equal(t, time.Hour, Backoff(maxAttempts))
+}
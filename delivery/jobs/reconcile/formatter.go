@@ -0,0 +1,26 @@
+package reconcile
+
+import (
+	"be/api/storage"
+	"time"
+)
+
+type Report struct {
+	DryRun    bool      `json:"dryRun"`
+	StartedAt time.Time `json:"startedAt"`
+	Grace     string    `json:"grace"`
+	Scanned   int       `json:"scanned"`
+	// referred to by a doctor, patient, attachment, export or upload
+	Referenced int `json:"referenced"`
+	// nothing refers to them but they are younger than the grace period
+	Recent      int   `json:"recent"`
+	Quarantined int   `json:"quarantined"`
+	Orphans     int   `json:"orphans"`
+	OrphanBytes int64 `json:"orphanBytes"`
+	Deleted     int   `json:"deleted"`
+	Failed      int   `json:"failed"`
+	// uploads without a part for the grace period, stopped
+	StaleUploads int `json:"staleUploads"`
+	// the first orphans found
+	Objects []storage.Object `json:"objects"`
+}
@@ -0,0 +1,16 @@
+package reconcile
+
+import "time"
+
+// Lease is the lease of the scheduler, so one replica runs the job
+
+type Lease interface {
+	Lease(name, holder string, ttl time.Duration) (bool, error)
+}
+
+// Reconciler goes through the storage once, the admin reports use it as a
+// dry run
+
+type Reconciler interface {
+	Run(now time.Time, dryRun bool) (Report, error)
+}
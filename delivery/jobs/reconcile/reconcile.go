@@ -0,0 +1,165 @@
+package reconcile
+
+import (
+	"be/api/storage"
+	"be/repository/reconcile"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// a replica holds the lease as long as it ticks, the storage is gone through
+// once a day by the holder
+
+const (
+	leaseName = "storage-reconcile"
+	leaseTtl  = 3 * time.Hour
+	tickEvery = time.Hour
+	runEvery  = 24 * time.Hour
+	maxListed = 1000
+)
+
+// Job removes the stored files nothing refers to anymore: the images and
+// attachments left when a request failed halfway and the images of deleted
+// accounts. A file is only removed once it is older than the grace period,
+// the file of an upload is stored before its row is saved
+
+type Job struct {
+	r      reconcile.Reconcile
+	store  storage.Storage
+	lease  Lease
+	grace  time.Duration
+	dryRun bool
+	holder string
+	last   time.Time
+}
+
+func New(r reconcile.Reconcile, store storage.Storage, lease Lease, grace time.Duration, dryRun bool) *Job {
+	var host, _ = os.Hostname()
+
+	return &Job{
+		r:      r,
+		store:  store,
+		lease:  lease,
+		grace:  grace,
+		dryRun: dryRun,
+		holder: host + "-" + shortuuid.New(),
+	}
+}
+
+// Start ticks every hour in the background
+
+func (j *Job) Start() {
+	go func() {
+		for {
+			j.Tick(time.Now())
+			time.Sleep(tickEvery)
+		}
+	}()
+}
+
+// Tick goes through the storage when this replica holds the lease and the
+// last run is a day ago
+
+func (j *Job) Tick(now time.Time) {
+	var leader, err = j.lease.Lease(leaseName, j.holder, leaseTtl)
+	if err != nil || !leader || now.Sub(j.last) < runEvery {
+		return
+	}
+	j.last = now
+
+	report, err := j.Run(now, j.dryRun)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	log.Infof("storage reconcile dry run %v: %v files, %v orphans of %v bytes, %v deleted, %v failed, %v stale uploads", report.DryRun, report.Scanned, report.Orphans, report.OrphanBytes, report.Deleted, report.Failed, report.StaleUploads)
+}
+
+// Run lists the stored files and removes the orphans older than the grace
+// period, a dry run only reports them
+func (j *Job) Run(now time.Time, dryRun bool) (Report, error) {
+	var report = Report{DryRun: dryRun, StartedAt: now, Grace: j.grace.String(), Objects: []storage.Object{}}
+
+	// the keys are read before the listing, a file saved in between is
+	// younger than the grace period
+
+	var keys, err = j.r.GetKeys()
+	if err != nil {
+		return Report{}, err
+	}
+
+	err = j.store.List(func(object storage.Object) error {
+		report.Scanned++
+
+		switch {
+		case referenced(keys, object.Key):
+			report.Referenced++
+			return nil
+		case strings.HasPrefix(object.Key, "quarantine/"):
+			report.Quarantined++
+			return nil
+		case now.Sub(object.ModifiedAt) < j.grace:
+			report.Recent++
+			return nil
+		}
+
+		report.Orphans++
+		report.OrphanBytes += object.Size
+		if len(report.Objects) < maxListed {
+			report.Objects = append(report.Objects, object)
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		if err := j.store.Remove(object); err != nil {
+			log.Warn(err)
+			report.Failed++
+			return nil
+		}
+		report.Deleted++
+
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	// the parts of uploads given up are not listed, they are removed with
+	// the upload
+
+	uploads, err := j.r.GetStaleUploads(now.Add(-j.grace))
+	if err != nil {
+		return Report{}, err
+	}
+	report.StaleUploads = len(uploads)
+
+	if !dryRun {
+		for _, upload := range uploads {
+			if upload.Storage_upload_id != "" {
+				if err := j.store.AbortUpload(upload.Object_key, upload.Storage_upload_id); err != nil {
+					log.Warn(err)
+				}
+			}
+
+			if err := j.r.FailUpload(upload.Upload_uid); err != nil {
+				log.Warn(err)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// referenced tells whether the key or, for the sizes of a photo, the
+// directory above it is referred to
+func referenced(keys map[string]bool, key string) bool {
+	return keys[key] || keys[path.Dir(key)]
+}
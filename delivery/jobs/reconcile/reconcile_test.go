@@ -0,0 +1,158 @@
+package reconcile
+
+import (
+	"be/api/storage"
+	"be/entities"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepo struct {
+	keys   map[string]bool
+	stale  []entities.Upload
+	failed []string
+}
+
+func (m *mockRepo) GetKeys() (map[string]bool, error) {
+	return m.keys, nil
+}
+
+func (m *mockRepo) GetStaleUploads(before time.Time) ([]entities.Upload, error) {
+	return m.stale, nil
+}
+
+func (m *mockRepo) FailUpload(upload_uid string) error {
+	m.failed = append(m.failed, upload_uid)
+	return nil
+}
+
+type mockLease struct {
+	leader bool
+}
+
+func (m *mockLease) Lease(name, holder string, ttl time.Duration) (bool, error) {
+	return m.leader, nil
+}
+
+var now = time.Date(2022, 3, 10, 2, 0, 0, 0, time.UTC)
+
+type setup struct {
+	job  *Job
+	repo *mockRepo
+	dir  string
+}
+
+// newSetup stores a referenced photo and attachment, an orphaned photo and
+// attachment, a recent orphan and a quarantined file
+func newSetup(t *testing.T, leader bool) setup {
+	var dir = t.TempDir()
+	var local, err = storage.NewLocal(dir, "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var old = now.Add(-7 * 24 * time.Hour)
+	var put = func(key string, private bool, modified time.Time) {
+		var sub = "public"
+		if private {
+			sub = "private"
+			local.UploadPrivateReader(key, "application/pdf", strings.NewReader("%PDF"))
+		} else {
+			local.Put(key, "image/jpeg", strings.NewReader("jpeg"))
+		}
+		os.Chtimes(filepath.Join(dir, sub, key), modified, modified)
+	}
+
+	put("photos/kept/large.jpg", false, old)
+	put("photos/kept/small.jpg", false, old)
+	put("photos/deleted/large.jpg", false, old)
+	put("attachments/visit/kept", true, old)
+	put("attachments/visit/orphan", true, old)
+	put("attachments/visit/new", true, now.Add(-time.Hour))
+	put("quarantine/2022-03-01/virus", true, old)
+
+	var repo = &mockRepo{
+		keys:  map[string]bool{"photos/kept": true, "attachments/visit/kept": true},
+		stale: []entities.Upload{{Upload_uid: "upload", Object_key: "attachments/visit/big", Storage_upload_id: "missing"}},
+	}
+
+	return setup{job: New(repo, local, &mockLease{leader: leader}, 72*time.Hour, false), repo: repo, dir: dir}
+}
+
+func exists(s setup, sub, key string) bool {
+	var _, err = os.Stat(filepath.Join(s.dir, sub, key))
+	return err == nil
+}
+
+func TestRun(t *testing.T) {
+	t.Run("success dry run", func(t *testing.T) {
+		var s = newSetup(t, true)
+
+		var report, err = s.job.Run(now, true)
+		assert.Nil(t, err)
+		assert.Equal(t, 7, report.Scanned)
+		assert.Equal(t, 3, report.Referenced)
+		assert.Equal(t, 1, report.Recent)
+		assert.Equal(t, 1, report.Quarantined)
+		assert.Equal(t, 2, report.Orphans)
+		assert.Equal(t, int64(8), report.OrphanBytes)
+		assert.Equal(t, 0, report.Deleted)
+		assert.Equal(t, 1, report.StaleUploads)
+		assert.Equal(t, "72h0m0s", report.Grace)
+
+		var keys []string
+		for _, object := range report.Objects {
+			keys = append(keys, object.Key)
+		}
+		assert.ElementsMatch(t, []string{"photos/deleted/large.jpg", "attachments/visit/orphan"}, keys)
+
+		assert.True(t, exists(s, "public", "photos/deleted/large.jpg"))
+		assert.True(t, exists(s, "private", "attachments/visit/orphan"))
+		assert.Equal(t, 0, len(s.repo.failed))
+	})
+
+	t.Run("success delete", func(t *testing.T) {
+		var s = newSetup(t, true)
+
+		var report, err = s.job.Run(now, false)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, report.Deleted)
+
+		assert.False(t, exists(s, "public", "photos/deleted/large.jpg"))
+		assert.False(t, exists(s, "private", "attachments/visit/orphan"))
+		assert.True(t, exists(s, "public", "photos/kept/small.jpg"))
+		assert.True(t, exists(s, "private", "attachments/visit/kept"))
+		assert.True(t, exists(s, "private", "attachments/visit/new"))
+		assert.True(t, exists(s, "private", "quarantine/2022-03-01/virus"))
+
+		assert.Equal(t, []string{"upload"}, s.repo.failed)
+	})
+}
+
+func TestTick(t *testing.T) {
+	t.Run("success once a day", func(t *testing.T) {
+		var s = newSetup(t, true)
+
+		s.job.Tick(now)
+		assert.False(t, exists(s, "private", "attachments/visit/orphan"))
+		assert.Equal(t, 1, len(s.repo.failed))
+
+		s.job.Tick(now.Add(time.Hour))
+		assert.Equal(t, 1, len(s.repo.failed))
+
+		s.job.Tick(now.Add(25 * time.Hour))
+		assert.Equal(t, 2, len(s.repo.failed))
+	})
+
+	t.Run("not leader", func(t *testing.T) {
+		var s = newSetup(t, false)
+
+		s.job.Tick(now)
+		assert.True(t, exists(s, "private", "attachments/visit/orphan"))
+	})
+}
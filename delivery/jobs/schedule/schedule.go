@@ -0,0 +1,208 @@
+package schedule
+
+import (
+	"be/api/mail"
+	logicReport "be/delivery/logic/report"
+	logic "be/delivery/logic/schedule"
+	"be/entities"
+	"be/repository/report"
+	"be/repository/schedule"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// every replica ticks, only the one holding the lease runs the schedules. The
+// lease outlives a few ticks so a slow tick does not hand it to another replica
+
+const (
+	leaseName = "report-scheduler"
+	leaseTtl  = 3 * time.Minute
+	tickEvery = time.Minute
+)
+
+type Job struct {
+	r      schedule.Schedule
+	report report.Report
+	l      logic.Schedule
+	lr     logicReport.Report
+	mailer mail.Mailer
+	holder string
+}
+
+func New(r schedule.Schedule, report report.Report, l logic.Schedule, lr logicReport.Report, mailer mail.Mailer) *Job {
+	var host, _ = os.Hostname()
+
+	return &Job{
+		r:      r,
+		report: report,
+		l:      l,
+		lr:     lr,
+		mailer: mailer,
+		holder: host + "-" + shortuuid.New(),
+	}
+}
+
+// Start ticks every minute in the background
+
+func (j *Job) Start() {
+	go func() {
+		for {
+			j.Tick(time.Now())
+			time.Sleep(tickEvery)
+		}
+	}()
+}
+
+// Tick runs the due schedules and retries the failed runs, when this replica holds the lease
+
+func (j *Job) Tick(now time.Time) {
+	var leader, err = j.r.Lease(leaseName, j.holder, leaseTtl)
+	if err != nil || !leader {
+		return
+	}
+
+	due, err := j.r.GetDue(now)
+	if err != nil {
+		return
+	}
+
+	// slots missed while no replica was up are sent once, the schedule moves to the next slot after now
+
+	for _, s := range due {
+		var next, err = j.l.Next(s.Cron, s.Timezone, now)
+		if err != nil {
+			log.Warn(err)
+			continue
+		}
+
+		run, err := j.r.CreateRun(s, next)
+		if err != nil {
+			continue
+		}
+
+		j.Run(s, run, now)
+	}
+
+	retries, err := j.r.GetRetries(now)
+	if err != nil {
+		return
+	}
+
+	for _, run := range retries {
+		var claimed, err = j.r.Claim(run.Run_uid, now)
+		if err != nil {
+			continue
+		}
+
+		s, err := j.r.GetSchedule(run.Schedule_uid)
+		if err != nil || !s.Active {
+			j.fail(run.Run_uid, "schedule is deleted or paused", nil)
+			continue
+		}
+
+		j.Run(s, claimed, now)
+	}
+}
+
+// Run renders the report of the slot of the run and mails it to the schedule's email
+
+func (j *Job) Run(s entities.ReportSchedule, run entities.ReportRun, now time.Time) {
+	var msg, err = j.render(s, run.ScheduledAt)
+
+	if err == nil {
+		msg.To = []string{s.Email}
+		err = j.mailer.Send(msg)
+	}
+
+	if err != nil {
+		log.Warn(err)
+		j.fail(run.Run_uid, err.Error(), j.l.Retry(run.Attempts, now))
+		return
+	}
+
+	if err := j.r.Sent(run.Run_uid); err != nil {
+		log.Warn(err)
+	}
+}
+
+func (j *Job) fail(run_uid, message string, retry *time.Time) {
+	if err := j.r.Fail(run_uid, message, retry); err != nil {
+		log.Warn(err)
+	}
+}
+
+// render builds the report of the slot, the appointments of the next day or
+// the statistics of the previous month in the clinic timezone
+
+func (j *Job) render(s entities.ReportSchedule, slot time.Time) (mail.Message, error) {
+	var location, err = time.LoadLocation(s.Timezone)
+	if err != nil {
+		return mail.Message{}, errors.New("invalid timezone")
+	}
+
+	clinic, err := j.r.GetClinic(s.Admin_uid)
+	if err != nil {
+		return mail.Message{}, errors.New("clinic is not found")
+	}
+
+	var local = slot.In(location)
+	var today = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch s.Kind {
+	case "appointments":
+		var tomorrow = today.AddDate(0, 0, 1)
+
+		appointments, err := j.r.GetAppointments(clinic.Doctor_uid, tomorrow)
+		if err != nil {
+			return mail.Message{}, errors.New("failed to get appointments")
+		}
+
+		return j.l.Appointments(clinic, tomorrow, appointments), nil
+	case "monthly":
+		var month = time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		var q = report.Query{Doctor_uid: clinic.Doctor_uid, From: month, To: month.AddDate(0, 1, -1), Period: "month", Limit: 10}
+
+		monthly, err := j.monthly(q)
+		if err != nil {
+			return mail.Message{}, errors.New("failed to get statistics")
+		}
+
+		return j.l.Monthly(clinic, month, monthly)
+	}
+
+	return mail.Message{}, errors.New("invalid kind")
+}
+
+func (j *Job) monthly(q report.Query) (logic.Monthly, error) {
+	var res logic.Monthly
+
+	visits, err := j.report.GetVisits(q)
+	if err != nil {
+		return res, err
+	}
+	res.Visits = j.lr.Visits(q, visits)
+
+	patients, err := j.report.GetPatients(q)
+	if err != nil {
+		return res, err
+	}
+	res.Patients = j.lr.Patients(q, patients)
+
+	diagnoses, err := j.report.GetDiagnoses(q)
+	if err != nil {
+		return res, err
+	}
+	res.Diagnoses = j.lr.Diagnoses(q, diagnoses)
+
+	demographics, err := j.report.GetDemographics(q)
+	if err != nil {
+		return res, err
+	}
+	res.Demographics = j.lr.Demographics(q, demographics)
+
+	return res, nil
+}
@@ -0,0 +1,186 @@
+package schedule
+
+import (
+	"be/api/mail"
+	logicReport "be/delivery/logic/report"
+	logic "be/delivery/logic/schedule"
+	"be/entities"
+	"be/repository/report"
+	"be/repository/schedule"
+	"be/utils/list"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepo struct {
+	leader  bool
+	due     []entities.ReportSchedule
+	next    time.Time
+	sent    []string
+	failed  map[string]*time.Time
+	retries []entities.ReportRun
+}
+
+func (m *mockRepo) GetClinic(admin_uid string) (schedule.Clinic, error) {
+	return schedule.Clinic{Doctor_uid: "doctor", Name: "klinik sehat"}, nil
+}
+
+func (m *mockRepo) Create(req entities.ReportSchedule) (entities.ReportSchedule, error) {
+	return req, nil
+}
+
+func (m *mockRepo) Update(schedule_uid, admin_uid string, req entities.ReportSchedule) (entities.ReportSchedule, error) {
+	return req, nil
+}
+
+func (m *mockRepo) Delete(schedule_uid, admin_uid string) error { return nil }
+
+func (m *mockRepo) GetSchedule(schedule_uid string) (entities.ReportSchedule, error) {
+	if schedule_uid == "deleted" {
+		return entities.ReportSchedule{}, errors.New("record not found")
+	}
+	return entities.ReportSchedule{Schedule_uid: schedule_uid, Kind: "monthly", Timezone: "Asia/Jakarta", Email: "owner@mail.com", Active: true}, nil
+}
+
+func (m *mockRepo) GetSchedules(admin_uid string) ([]entities.ReportSchedule, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) GetRuns(schedule_uid string, q list.Query) ([]schedule.Run, list.Page, error) {
+	return nil, list.Page{}, nil
+}
+
+func (m *mockRepo) GetAppointments(doctor_uid string, date time.Time) ([]schedule.Appointment, error) {
+	return []schedule.Appointment{{PatientName: "siti", Status: "pending", Complaint: "fever"}}, nil
+}
+
+func (m *mockRepo) Lease(name, holder string, ttl time.Duration) (bool, error) {
+	return m.leader, nil
+}
+
+func (m *mockRepo) GetDue(now time.Time) ([]entities.ReportSchedule, error) { return m.due, nil }
+
+func (m *mockRepo) CreateRun(s entities.ReportSchedule, next time.Time) (entities.ReportRun, error) {
+	m.next = next
+	return entities.ReportRun{Run_uid: "run-" + s.Schedule_uid, Schedule_uid: s.Schedule_uid, ScheduledAt: s.NextRunAt, Attempts: 1}, nil
+}
+
+func (m *mockRepo) GetRetries(now time.Time) ([]entities.ReportRun, error) { return m.retries, nil }
+
+func (m *mockRepo) Claim(run_uid string, now time.Time) (entities.ReportRun, error) {
+	for _, run := range m.retries {
+		if run.Run_uid == run_uid {
+			run.Attempts++
+			return run, nil
+		}
+	}
+	return entities.ReportRun{}, errors.New("run is already claimed")
+}
+
+func (m *mockRepo) Sent(run_uid string) error {
+	m.sent = append(m.sent, run_uid)
+	return nil
+}
+
+func (m *mockRepo) Fail(run_uid, message string, retry *time.Time) error {
+	m.failed[run_uid] = retry
+	return nil
+}
+
+type mockReport struct{}
+
+func (m *mockReport) GetClinic(admin_uid string) (string, error) { return "doctor", nil }
+
+func (m *mockReport) GetVisits(q report.Query) ([]report.VisitRow, error) {
+	return []report.VisitRow{{Period: q.From.Format("2006-01"), Status: "completed", Total: 4}}, nil
+}
+
+func (m *mockReport) GetPatients(q report.Query) ([]report.PatientRow, error) { return nil, nil }
+
+func (m *mockReport) GetDiagnoses(q report.Query) ([]report.DiagnoseRow, error) { return nil, nil }
+
+func (m *mockReport) GetDemographics(q report.Query) ([]report.DemographicRow, error) {
+	return nil, nil
+}
+
+type mockMailer struct {
+	fail bool
+	sent []mail.Message
+}
+
+func (m *mockMailer) Send(msg mail.Message) error {
+	if m.fail {
+		return errors.New("connection refused")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var now = time.Date(2022, 3, 1, 11, 0, 0, 0, time.UTC)
+
+func TestTick(t *testing.T) {
+	var appointments = entities.ReportSchedule{Schedule_uid: "daily", Kind: "appointments", Cron: "0 18 * * *", Timezone: "Asia/Jakarta", Email: "owner@mail.com", Active: true, NextRunAt: now}
+	var monthly = entities.ReportSchedule{Schedule_uid: "monthly", Kind: "monthly", Cron: "0 7 1 * *", Timezone: "Asia/Jakarta", Email: "owner@mail.com", Active: true, NextRunAt: now}
+
+	t.Run("success", func(t *testing.T) {
+		var r = &mockRepo{leader: true, due: []entities.ReportSchedule{appointments, monthly}, failed: map[string]*time.Time{}}
+		var mailer = &mockMailer{}
+		New(r, &mockReport{}, logic.New(), logicReport.New(), mailer).Tick(now)
+
+		assert.Equal(t, []string{"run-daily", "run-monthly"}, r.sent)
+		assert.Equal(t, 2, len(mailer.sent))
+		assert.Equal(t, []string{"owner@mail.com"}, mailer.sent[0].To)
+
+		// 18:00 of the slot day in jakarta is the report of the next day
+
+		assert.Equal(t, "klinik sehat: 1 appointments on 02-03-2022", mailer.sent[0].Subject)
+		assert.Equal(t, "klinik sehat: statistics of February 2022", mailer.sent[1].Subject)
+		assert.Equal(t, 4, len(mailer.sent[1].Attachments))
+		assert.True(t, strings.Contains(mailer.sent[1].Body, "Visits: 4"))
+	})
+
+	t.Run("not leader", func(t *testing.T) {
+		var r = &mockRepo{due: []entities.ReportSchedule{appointments}, failed: map[string]*time.Time{}}
+		var mailer = &mockMailer{}
+		New(r, &mockReport{}, logic.New(), logicReport.New(), mailer).Tick(now)
+
+		assert.Equal(t, 0, len(mailer.sent))
+		assert.Equal(t, 0, len(r.sent))
+	})
+
+	t.Run("error mailer is retried", func(t *testing.T) {
+		var r = &mockRepo{leader: true, due: []entities.ReportSchedule{appointments}, failed: map[string]*time.Time{}}
+		New(r, &mockReport{}, logic.New(), logicReport.New(), &mockMailer{fail: true}).Tick(now)
+
+		assert.Equal(t, now.Add(5*time.Minute), *r.failed["run-daily"])
+	})
+
+	t.Run("success retry", func(t *testing.T) {
+		var r = &mockRepo{leader: true, retries: []entities.ReportRun{{Run_uid: "retry", Schedule_uid: "monthly", ScheduledAt: now, Attempts: 2}}, failed: map[string]*time.Time{}}
+		New(r, &mockReport{}, logic.New(), logicReport.New(), &mockMailer{}).Tick(now)
+
+		assert.Equal(t, []string{"retry"}, r.sent)
+	})
+
+	t.Run("error last attempt", func(t *testing.T) {
+		var r = &mockRepo{leader: true, retries: []entities.ReportRun{{Run_uid: "retry", Schedule_uid: "monthly", ScheduledAt: now, Attempts: logic.MaxAttempts - 1}}, failed: map[string]*time.Time{}}
+		New(r, &mockReport{}, logic.New(), logicReport.New(), &mockMailer{fail: true}).Tick(now)
+
+		var retry, ok = r.failed["retry"]
+		assert.True(t, ok)
+		assert.Nil(t, retry)
+	})
+
+	t.Run("error deleted schedule", func(t *testing.T) {
+		var r = &mockRepo{leader: true, retries: []entities.ReportRun{{Run_uid: "retry", Schedule_uid: "deleted", ScheduledAt: now, Attempts: 1}}, failed: map[string]*time.Time{}}
+		New(r, &mockReport{}, logic.New(), logicReport.New(), &mockMailer{}).Tick(now)
+
+		var retry, ok = r.failed["retry"]
+		assert.True(t, ok)
+		assert.Nil(t, retry)
+	})
+}
@@ -0,0 +1,113 @@
+package attachment
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+)
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+const MaxSize = 25 << 20
+
+// MaxUploadSize is the largest file of a resumable upload, e.g. a scan, and
+// MaxPart the largest part of it
+
+const MaxUploadSize = 5 << 30
+const MaxPart = 100 << 20
+
+func (l *Logic) ValidationRequest(req Req) error {
+
+	if _, ok := types[req.Type]; !ok {
+		return errors.New("invalid type input")
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("invalid length description")
+	}
+
+	return nil
+}
+
+func (l *Logic) ValidationFile(fileHeader multipart.FileHeader) error {
+
+	if fileHeader.Size == 0 {
+		return errors.New("file is empty")
+	}
+
+	if fileHeader.Size > MaxSize {
+		return errors.New("file is too large")
+	}
+
+	var src, err = fileHeader.Open()
+	if err != nil {
+		return errors.New("invalid file")
+	}
+	defer src.Close()
+
+	var head = make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return errors.New("invalid file")
+	}
+
+	if _, ok := contentTypes[http.DetectContentType(head[:n])]; !ok {
+		return errors.New("file type is not allowed")
+	}
+
+	return nil
+}
+
+func (l *Logic) ValidationUpload(length int64, checksum string) error {
+
+	if length <= 0 {
+		return errors.New("file is empty")
+	}
+
+	if length > MaxUploadSize {
+		return errors.New("file is too large")
+	}
+
+	if checksum != "" && !regexChecksum.MatchString(checksum) {
+		return errors.New("invalid checksum")
+	}
+
+	return nil
+}
+
+// ContentType is the type of the file from its first bytes, only the types
+// allowed for an attachment
+func (l *Logic) ContentType(head []byte) (string, error) {
+	if len(head) > 512 {
+		head = head[:512]
+	}
+
+	var contentType = http.DetectContentType(head)
+	if _, ok := contentTypes[contentType]; !ok {
+		return "", errors.New("file type is not allowed")
+	}
+
+	return contentType, nil
+}
+
+var regexChecksum = regexp.MustCompile("^[0-9a-f]{64}$")
+
+var types = map[string]int{
+	"lab":      0,
+	"imaging":  1,
+	"referral": 2,
+	"other":    3,
+}
+
+var contentTypes = map[string]int{
+	"application/pdf": 0,
+	"image/jpeg":      1,
+	"image/png":       2,
+	"image/webp":      3,
+}
@@ -0,0 +1,115 @@
+package attachment
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func fileHeader(t *testing.T, content []byte) multipart.FileHeader {
+	var body = new(bytes.Buffer)
+	var writer = multipart.NewWriter(body)
+	var part, err = writer.CreateFormFile("file", "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	var req = httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(MaxSize); err != nil {
+		t.Fatal(err)
+	}
+
+	return *req.MultipartForm.File["file"][0]
+}
+
+func TestValidationRequest(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{Type: "lab", Description: "blood test"})
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error type", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{Type: "selfie"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+}
+
+func TestValidationFile(t *testing.T) {
+	t.Run("success pdf", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationFile(fileHeader(t, []byte("%PDF-1.4\n%test")))
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("success png", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationFile(fileHeader(t, []byte("\x89PNG\x0D\x0A\x1A\x0Arest")))
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error content type", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationFile(fileHeader(t, []byte("#!/bin/sh\necho hello")))
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error empty", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationFile(fileHeader(t, []byte{}))
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+}
+
+func TestValidationUpload(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationUpload(1<<30, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error too large", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationUpload(MaxUploadSize+1, "")
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error checksum", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationUpload(10, "md5:abc")
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+}
+
+func TestContentType(t *testing.T) {
+	t.Run("success pdf", func(t *testing.T) {
+		var l = New()
+		res, err := l.ContentType([]byte("%PDF-1.4\n%test"))
+		assert.Nil(t, err)
+		assert.Equal(t, "application/pdf", res)
+	})
+
+	t.Run("error content type", func(t *testing.T) {
+		var l = New()
+		_, err := l.ContentType([]byte("#!/bin/sh\necho hello"))
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+}
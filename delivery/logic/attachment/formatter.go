@@ -0,0 +1,15 @@
+package attachment
+
+import "be/entities"
+
+type Req struct {
+	Type        string `json:"type" form:"type" validate:"required"`
+	Description string `json:"description" form:"description"`
+}
+
+func (r *Req) ToAttachment() *entities.Attachment {
+	return &entities.Attachment{
+		Type:        r.Type,
+		Description: r.Description,
+	}
+}
@@ -0,0 +1,10 @@
+package attachment
+
+import "mime/multipart"
+
+type Attachment interface {
+	ValidationRequest(req Req) error
+	ValidationFile(fileHeader multipart.FileHeader) error
+	ValidationUpload(length int64, checksum string) error
+	ContentType(head []byte) (string, error)
+}
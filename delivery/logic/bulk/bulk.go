@@ -0,0 +1,206 @@
+package bulk
+
+import (
+	logicDoctor "be/delivery/logic/doctor"
+	logicPatient "be/delivery/logic/patient"
+	"be/entities"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const layout = "02-01-2006"
+
+// Columns are the request fields of POST /patient and POST /doctor
+
+var Columns = map[string][]string{
+	"patient": {"userName", "email", "password", "nik", "name", "gender", "address", "placeBirth", "dob", "job", "status", "religion"},
+	"doctor":  {"userName", "email", "password", "name", "address", "status", "openDay", "closeDay", "capacity"},
+}
+
+var required = map[string][]string{
+	"patient": {"nik", "name", "gender", "address", "placeBirth", "dob", "job", "status", "religion"},
+	"doctor":  {"userName", "email", "password", "name", "address", "status", "openDay", "closeDay", "capacity"},
+}
+
+// columns that have to be unique within the file
+
+var unique = map[string][]string{
+	"patient": {"nik", "userName", "email"},
+	"doctor":  {"userName", "email"},
+}
+
+type Logic struct {
+	patient logicPatient.Patient
+	doctor  logicDoctor.Doctor
+}
+
+func New() *Logic {
+	return &Logic{
+		patient: logicPatient.New(),
+		doctor:  logicDoctor.New(),
+	}
+}
+
+func (l *Logic) ValidationKind(kind string) error {
+	if _, ok := Columns[kind]; !ok {
+		return errors.New("invalid kind input")
+	}
+	return nil
+}
+
+// Parse maps the rows by the header and validates every row the same way a
+// single registration is validated
+
+func (l *Logic) Parse(kind string, rows [][]string) ([]Row, error) {
+	if err := l.ValidationKind(kind); err != nil {
+		return nil, err
+	}
+
+	if len(rows) < 2 {
+		return nil, errors.New("file has no data row")
+	}
+
+	var names = map[string]string{}
+	for _, column := range Columns[kind] {
+		names[strings.ToLower(column)] = column
+	}
+
+	var header = make([]string, len(rows[0]))
+	var found = map[string]bool{}
+
+	for i, cell := range rows[0] {
+		var column, ok = names[strings.ToLower(strings.ReplaceAll(cell, " ", ""))]
+		if !ok {
+			return nil, errors.New("unknown column " + cell)
+		}
+		if found[column] {
+			return nil, errors.New("column " + column + " is duplicated")
+		}
+		header[i] = column
+		found[column] = true
+	}
+
+	for _, column := range required[kind] {
+		if !found[column] {
+			return nil, errors.New("column " + column + " is missing")
+		}
+	}
+
+	var res []Row
+	var seen = map[string]int{}
+
+	for i, cells := range rows[1:] {
+		var row = Row{Row: i + 2, Data: map[string]string{}}
+
+		for j, column := range header {
+			if j < len(cells) {
+				row.Data[column] = cells[j]
+			}
+		}
+
+		var err error
+		switch kind {
+		case "patient":
+			if dob, ok := date(row.Data["dob"]); ok {
+				row.Data["dob"] = dob
+			}
+			_, err = l.ToPatient(row.Data)
+		case "doctor":
+			_, err = l.ToDoctor(row.Data)
+		}
+
+		if err != nil {
+			row.Error = err.Error()
+		}
+
+		for _, column := range unique[kind] {
+			var value = strings.ToLower(row.Data[column])
+			if value == "" {
+				continue
+			}
+			if first, ok := seen[column+":"+value]; ok && row.Error == "" {
+				row.Error = column + " is the same as row " + strconv.Itoa(first)
+			} else if !ok {
+				seen[column+":"+value] = row.Row
+			}
+		}
+
+		res = append(res, row)
+	}
+
+	return res, nil
+}
+
+func (l *Logic) ToPatient(data map[string]string) (entities.Patient, error) {
+	var req = logicPatient.Req{
+		UserName:   data["userName"],
+		Email:      data["email"],
+		Password:   data["password"],
+		Nik:        data["nik"],
+		Name:       data["name"],
+		Gender:     data["gender"],
+		Address:    data["address"],
+		PlaceBirth: data["placeBirth"],
+		Dob:        data["dob"],
+		Job:        data["job"],
+		Status:     data["status"],
+		Religion:   data["religion"],
+	}
+
+	if err := l.patient.ValidationStruct(req); err != nil {
+		return entities.Patient{}, err
+	}
+
+	if err := l.patient.ValidationRequest(req); err != nil {
+		return entities.Patient{}, err
+	}
+
+	var patient, err = req.ToPatient()
+	if err != nil {
+		return entities.Patient{}, err
+	}
+
+	return *patient, nil
+}
+
+func (l *Logic) ToDoctor(data map[string]string) (entities.Doctor, error) {
+	var capacity, err = strconv.Atoi(data["capacity"])
+	if err != nil && data["capacity"] != "" {
+		return entities.Doctor{}, errors.New("invalid capacity")
+	}
+
+	var req = logicDoctor.Req{
+		UserName: data["userName"],
+		Email:    data["email"],
+		Password: data["password"],
+		Name:     data["name"],
+		Address:  data["address"],
+		Status:   data["status"],
+		OpenDay:  data["openDay"],
+		CloseDay: data["closeDay"],
+		Capacity: capacity,
+	}
+
+	if err := l.doctor.ValidationStruct(req); err != nil {
+		return entities.Doctor{}, err
+	}
+
+	if err := l.doctor.ValidationRequest(req); err != nil {
+		return entities.Doctor{}, err
+	}
+
+	return *req.ToDoctor(), nil
+}
+
+// date turns the serial number of a spreadsheet date cell into the date layout of the api
+
+func date(value string) (string, bool) {
+	var serial, err = strconv.ParseFloat(value, 64)
+	if err != nil || serial < 1 || serial > 100000 {
+		return "", false
+	}
+
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format(layout), true
+}
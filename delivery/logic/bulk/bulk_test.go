@@ -0,0 +1,70 @@
+package bulk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var patientHeader = []string{"NIK", "Name", "Gender", "Address", "Place Birth", "Dob", "Job", "Status", "Religion", "Email"}
+
+func TestParse(t *testing.T) {
+	t.Run("success patient", func(t *testing.T) {
+		var l = New()
+		var rows, err = l.Parse("patient", [][]string{
+			patientHeader,
+			{"1234567890123456", "siti rahayu", "wanita", "jl. melati 5 jakarta", "bandung", "05-05-1990", "guru", "kawin", "islam"},
+			{"1234567890123457", "andi wijaya", "pria", "jl. melati 5 jakarta", "bandung", "32998", "guru", "kawin", "islam", "andi@mail.com"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(rows))
+		assert.Equal(t, 2, rows[0].Row)
+		assert.Equal(t, "", rows[0].Error)
+		assert.Equal(t, "", rows[1].Error)
+		assert.Equal(t, "05-05-1990", rows[1].Data["dob"])
+		assert.Equal(t, "andi@mail.com", rows[1].Data["email"])
+	})
+
+	t.Run("row errors", func(t *testing.T) {
+		var l = New()
+		var rows, err = l.Parse("patient", [][]string{
+			patientHeader,
+			{"12345", "siti rahayu", "wanita", "jl. melati 5 jakarta", "bandung", "05-05-1990", "guru", "kawin", "islam"},
+			{"1234567890123456", "siti rahayu", "wanita", "jl. melati 5 jakarta", "bandung", "05-05-1990", "guru", "kawin", "islam"},
+			{"1234567890123456", "andi wijaya", "pria", "jl. melati 5 jakarta", "bandung", "05-05-1990", "guru", "kawin", "islam"},
+			{"1234567890123458", "andi wijaya", "pria", "jl. melati 5 jakarta", "bandung", "1990-05-05", "guru", "kawin", "islam"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "invalid length nik", rows[0].Error)
+		assert.Equal(t, "", rows[1].Error)
+		assert.Equal(t, "nik is the same as row 3", rows[2].Error)
+		assert.Equal(t, "invalid date format", rows[3].Error)
+	})
+
+	t.Run("success doctor", func(t *testing.T) {
+		var l = New()
+		var rows, err = l.Parse("doctor", [][]string{
+			{"userName", "email", "password", "name", "address", "status", "openDay", "closeDay", "capacity"},
+			{"andi123", "andi@mail.com", "andi123", "andi wijaya", "jl. melati 5 jakarta", "available", "senin", "jumat", "20"},
+			{"budi123", "budi@mail.com", "budi123", "budi santoso", "jl. melati 5 jakarta", "available", "senin", "jumat", "many"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "", rows[0].Error)
+		assert.Equal(t, "invalid capacity", rows[1].Error)
+	})
+
+	t.Run("error header", func(t *testing.T) {
+		var l = New()
+		var _, err = l.Parse("patient", [][]string{{"nik", "name", "unknown"}, {"1", "2", "3"}})
+		assert.Equal(t, "unknown column unknown", err.Error())
+
+		_, err = l.Parse("patient", [][]string{{"nik", "name"}, {"1", "2"}})
+		assert.Equal(t, "column gender is missing", err.Error())
+
+		_, err = l.Parse("patient", [][]string{patientHeader})
+		assert.NotNil(t, err)
+
+		_, err = l.Parse("admin", [][]string{patientHeader})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,10 @@
+package bulk
+
+// Row is a row of the file, its number is the one shown by the spreadsheet
+// so the header is row 1
+
+type Row struct {
+	Row   int
+	Data  map[string]string
+	Error string
+}
@@ -0,0 +1,10 @@
+package bulk
+
+import "be/entities"
+
+type Bulk interface {
+	ValidationKind(kind string) error
+	Parse(kind string, rows [][]string) ([]Row, error)
+	ToPatient(data map[string]string) (entities.Patient, error)
+	ToDoctor(data map[string]string) (entities.Doctor, error)
+}
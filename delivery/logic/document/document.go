@@ -0,0 +1,54 @@
+package document
+
+import (
+	"errors"
+	"time"
+)
+
+// longest sick leave a single letter may cover
+
+const MaxDays = 30
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+func (l *Logic) ValidationRequest(req Req) error {
+
+	switch req.Kind {
+	case "sickLeave", "fitness":
+	default:
+		return errors.New("invalid kind input")
+	}
+
+	if len(req.Note) > 255 {
+		return errors.New("invalid length note")
+	}
+
+	var document, err = req.ToDocument()
+	if err != nil {
+		return err
+	}
+
+	if req.Kind == "sickLeave" {
+		var start, end = time.Time(document.StartDate), time.Time(document.EndDate)
+
+		if end.Before(start) {
+			return errors.New("end date is before start date")
+		}
+
+		if Days(start, end) > MaxDays {
+			return errors.New("sick leave is too long")
+		}
+	}
+
+	return nil
+}
+
+// Days counts both the start and the end date
+
+func Days(start, end time.Time) int {
+	return int(end.Sub(start).Hours()/24) + 1
+}
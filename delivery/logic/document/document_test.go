@@ -0,0 +1,45 @@
+package document
+
+import (
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationRequest(t *testing.T) {
+	t.Run("success sick leave", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{Kind: "sickLeave", StartDate: "01-06-2022", EndDate: "03-06-2022"})
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("success fitness", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{Kind: "fitness", Note: "job application"})
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error kind", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{Kind: "death"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error date", func(t *testing.T) {
+		var l = New()
+		assert.NotNil(t, l.ValidationRequest(Req{Kind: "sickLeave", StartDate: "2022-06-01", EndDate: "03-06-2022"}))
+		assert.NotNil(t, l.ValidationRequest(Req{Kind: "sickLeave", StartDate: "03-06-2022", EndDate: "01-06-2022"}))
+		assert.NotNil(t, l.ValidationRequest(Req{Kind: "sickLeave", StartDate: "01-06-2022", EndDate: "01-08-2022"}))
+	})
+}
+
+func TestDays(t *testing.T) {
+	var start = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 1, Days(start, start))
+	assert.Equal(t, 3, Days(start, start.AddDate(0, 0, 2)))
+}
@@ -0,0 +1,44 @@
+package document
+
+import (
+	"be/entities"
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type Req struct {
+	Kind      string `json:"kind" form:"kind"`
+	StartDate string `json:"startDate" form:"startDate"`
+	EndDate   string `json:"endDate" form:"endDate"`
+	Note      string `json:"note" form:"note"`
+}
+
+func (r *Req) ToDocument() (*entities.Document, error) {
+	var document = entities.Document{
+		Kind: r.Kind,
+		Note: r.Note,
+	}
+
+	if r.Kind != "sickLeave" {
+		return &document, nil
+	}
+
+	var layout = "02-01-2006"
+
+	var start, err = time.Parse(layout, r.StartDate)
+	if err != nil {
+		return &entities.Document{}, errors.New("invalid start date format")
+	}
+
+	end, err := time.Parse(layout, r.EndDate)
+	if err != nil {
+		return &entities.Document{}, errors.New("invalid end date format")
+	}
+
+	document.StartDate = datatypes.Date(start)
+	document.EndDate = datatypes.Date(end)
+
+	return &document, nil
+}
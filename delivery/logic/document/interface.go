@@ -0,0 +1,5 @@
+package document
+
+type Document interface {
+	ValidationRequest(req Req) error
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"be/repository/export"
+	"be/utils/fhir"
+	"be/utils/pdf"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const layout = "02-01-2006"
+
+// histories up to this many visits are built within the request, longer ones go to the background job
+
+const SyncLimit = 20
+
+type Logic struct {
+	pdf  pdf.Pdf
+	base string
+}
+
+// New takes the base url of the fhir api, used for the fullUrl of the bundle entries
+
+func New(pdf pdf.Pdf, base string) *Logic {
+	return &Logic{
+		pdf:  pdf,
+		base: base,
+	}
+}
+
+func (l *Logic) ValidationFormat(format string) error {
+
+	switch format {
+	case "pdf", "json", "fhir":
+	default:
+		return errors.New("invalid format input")
+	}
+
+	return nil
+}
+
+func (l *Logic) Build(format string, record export.Record) (File, error) {
+
+	var name = "record-" + record.Patient.Patient_uid + "-" + time.Now().Format("20060102")
+
+	switch format {
+	case "pdf":
+		var body, err = l.pdf.Record(ToPdfRecord(record))
+		if err != nil {
+			return File{}, err
+		}
+		return File{Name: name + ".pdf", ContentType: "application/pdf", Body: body}, nil
+	case "json":
+		var body, err = json.MarshalIndent(ToRecordResp(record), "", "  ")
+		if err != nil {
+			return File{}, err
+		}
+		return File{Name: name + ".json", ContentType: "application/json", Body: body}, nil
+	case "fhir":
+		var body, err = json.MarshalIndent(ToBundle(l.base, record), "", "  ")
+		if err != nil {
+			return File{}, err
+		}
+		return File{Name: name + ".fhir.json", ContentType: "application/fhir+json", Body: body}, nil
+	}
+
+	return File{}, errors.New("invalid format input")
+}
+
+func ToRecordResp(record export.Record) RecordResp {
+	var patient = record.Patient
+
+	var res = RecordResp{
+		Patient_uid: patient.Patient_uid,
+		Nik:         patient.Nik,
+		Name:        patient.Name,
+		Gender:      patient.Gender,
+		Address:     patient.Address,
+		PlaceBirth:  patient.PlaceBirth,
+		Dob:         time.Time(patient.Dob).Format(layout),
+		Job:         patient.Job,
+		Status:      patient.Status,
+		Religion:    patient.Religion,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Visits:      []VisitResp{},
+	}
+
+	for _, visit := range record.Visits {
+		res.Visits = append(res.Visits, ToVisitResp(visit, record.Doctors[visit.Doctor_uid].Name))
+	}
+
+	return res
+}
+
+func ToPdfRecord(record export.Record) pdf.Record {
+	var patient = record.Patient
+
+	var res = pdf.Record{
+		PatientName: patient.Name,
+		Nik:         patient.Nik,
+		Gender:      patient.Gender,
+		PlaceBirth:  patient.PlaceBirth,
+		Dob:         time.Time(patient.Dob).Format(layout),
+		Address:     patient.Address,
+		Job:         patient.Job,
+		Religion:    patient.Religion,
+		GeneratedAt: time.Now().Format("02-01-2006 15:04"),
+	}
+
+	for _, visit := range record.Visits {
+		var vitals = []string{}
+		for _, vital := range [][2]string{
+			{"blood pressure", visit.BloodPressure},
+			{"heart rate", visit.HeartRate},
+			{"respiratory rate", visit.RespiratoryRate},
+			{"o2 saturation", visit.O2Saturate},
+			{"weight", visit.Weight},
+			{"height", visit.Height},
+			{"bmi", visit.Bmi},
+		} {
+			if vital[1] != "" {
+				vitals = append(vitals, vital[0]+" "+vital[1])
+			}
+		}
+
+		res.Visits = append(res.Visits, pdf.RecordVisit{
+			Date:             time.Time(visit.Date).Format(layout),
+			DoctorName:       record.Doctors[visit.Doctor_uid].Name,
+			Status:           visit.Status,
+			Complaint:        visit.Complaint,
+			MainDiagnose:     visit.MainDiagnose,
+			AdditionDiagnose: visit.AdditionDiagnose,
+			Action:           visit.Action,
+			Recipe:           visit.Recipe,
+			Vitals:           strings.Join(vitals, ", "),
+		})
+	}
+
+	return res
+}
+
+// ToBundle puts the record in a fhir collection bundle, every doctor appears once
+
+func ToBundle(base string, record export.Record) fhir.Bundle {
+	var resources = []fhir.Resource{fhir.FromPatient(record.Patient)}
+	var seen = map[string]bool{}
+
+	for _, visit := range record.Visits {
+		if doctor, ok := record.Doctors[visit.Doctor_uid]; ok && !seen[visit.Doctor_uid] {
+			seen[visit.Doctor_uid] = true
+			resources = append(resources, fhir.FromDoctor(doctor))
+		}
+	}
+
+	for _, visit := range record.Visits {
+		resources = append(resources, fhir.FromVisit(visit))
+		if visit.MainDiagnose != "" {
+			resources = append(resources, fhir.FromDiagnose(visit))
+		}
+		if visit.Recipe != "" {
+			resources = append(resources, fhir.FromRecipe(visit))
+		}
+	}
+
+	return fhir.Collection(base, resources)
+}
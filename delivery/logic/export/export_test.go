@@ -0,0 +1,70 @@
+package export
+
+import (
+	"be/entities"
+	"be/repository/export"
+	"be/utils/fhir/fhirtest"
+	"be/utils/pdf"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+var record = export.Record{
+	Patient: entities.Patient{Patient_uid: "patient", Nik: "1234567890123456", Name: "siti", Gender: "wanita", Dob: datatypes.Date(time.Date(1990, 5, 5, 0, 0, 0, 0, time.UTC))},
+	Visits: []entities.Visit{
+		{Visit_uid: "patient-1", Doctor_uid: "doctor", Patient_uid: "patient", Status: "completed", Date: datatypes.Date(time.Now()), Complaint: "demam", MainDiagnose: "influenza", Recipe: "paracetamol 3x500mg", BloodPressure: "120/80"},
+		{Visit_uid: "patient-2", Doctor_uid: "doctor", Patient_uid: "patient", Status: "pending", Date: datatypes.Date(time.Now()), Complaint: "batuk"},
+	},
+	Doctors: map[string]entities.Doctor{"doctor": {Doctor_uid: "doctor", Name: "andi"}},
+}
+
+func TestValidationFormat(t *testing.T) {
+	var l = New(pdf.New(), "http://localhost/fhir")
+
+	assert.Nil(t, l.ValidationFormat("pdf"))
+	assert.Nil(t, l.ValidationFormat("json"))
+	assert.Nil(t, l.ValidationFormat("fhir"))
+	assert.NotNil(t, l.ValidationFormat("xml"))
+}
+
+func TestBuild(t *testing.T) {
+	var l = New(pdf.New(), "http://localhost/fhir")
+
+	t.Run("success pdf", func(t *testing.T) {
+		var res, err = l.Build("pdf", record)
+		assert.Nil(t, err)
+		assert.Equal(t, "application/pdf", res.ContentType)
+		assert.True(t, bytes.HasPrefix(res.Body, []byte("%PDF")))
+	})
+
+	t.Run("success json", func(t *testing.T) {
+		var res, err = l.Build("json", record)
+		assert.Nil(t, err)
+
+		var resp RecordResp
+		assert.Nil(t, json.Unmarshal(res.Body, &resp))
+		assert.Equal(t, 2, len(resp.Visits))
+		assert.Equal(t, "andi", resp.Visits[0].DoctorName)
+		assert.Equal(t, "05-05-1990", resp.Dob)
+	})
+
+	t.Run("success fhir", func(t *testing.T) {
+		var res, err = l.Build("fhir", record)
+		assert.Nil(t, err)
+		fhirtest.Validate(t, res.Body)
+
+		// patient, doctor, two encounters, condition and medication request
+		var bundle = ToBundle("http://localhost/fhir", record)
+		assert.Equal(t, 6, len(bundle.Entry))
+	})
+
+	t.Run("error format", func(t *testing.T) {
+		var _, err = l.Build("xml", record)
+		assert.NotNil(t, err)
+	})
+}
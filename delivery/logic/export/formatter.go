@@ -0,0 +1,71 @@
+package export
+
+import (
+	"be/entities"
+	"time"
+)
+
+type File struct {
+	Name        string
+	ContentType string
+	Body        []byte
+}
+
+// RecordResp is the json export, the patient profile without the account data
+
+type RecordResp struct {
+	Patient_uid string      `json:"patient_uid"`
+	Nik         string      `json:"nik"`
+	Name        string      `json:"name"`
+	Gender      string      `json:"gender"`
+	Address     string      `json:"address"`
+	PlaceBirth  string      `json:"placeBirth"`
+	Dob         string      `json:"dob"`
+	Job         string      `json:"job"`
+	Status      string      `json:"status"`
+	Religion    string      `json:"religion"`
+	GeneratedAt string      `json:"generatedAt"`
+	Visits      []VisitResp `json:"visits"`
+}
+
+type VisitResp struct {
+	Visit_uid        string `json:"visit_uid"`
+	Doctor_uid       string `json:"doctor_uid"`
+	DoctorName       string `json:"doctorName"`
+	Date             string `json:"date"`
+	Status           string `json:"status"`
+	Complaint        string `json:"complaint"`
+	MainDiagnose     string `json:"mainDiagnose"`
+	AdditionDiagnose string `json:"additionDiagnose"`
+	Action           string `json:"action"`
+	Recipe           string `json:"recipe"`
+	BloodPressure    string `json:"bloodPressure"`
+	HeartRate        string `json:"heartRate"`
+	RespiratoryRate  string `json:"respiratoryRate"`
+	O2Saturate       string `json:"o2Saturate"`
+	Weight           string `json:"weight"`
+	Height           string `json:"height"`
+	Bmi              string `json:"bmi"`
+}
+
+func ToVisitResp(visit entities.Visit, doctorName string) VisitResp {
+	return VisitResp{
+		Visit_uid:        visit.Visit_uid,
+		Doctor_uid:       visit.Doctor_uid,
+		DoctorName:       doctorName,
+		Date:             time.Time(visit.Date).Format(layout),
+		Status:           visit.Status,
+		Complaint:        visit.Complaint,
+		MainDiagnose:     visit.MainDiagnose,
+		AdditionDiagnose: visit.AdditionDiagnose,
+		Action:           visit.Action,
+		Recipe:           visit.Recipe,
+		BloodPressure:    visit.BloodPressure,
+		HeartRate:        visit.HeartRate,
+		RespiratoryRate:  visit.RespiratoryRate,
+		O2Saturate:       visit.O2Saturate,
+		Weight:           visit.Weight,
+		Height:           visit.Height,
+		Bmi:              visit.Bmi,
+	}
+}
@@ -0,0 +1,8 @@
+package export
+
+import "be/repository/export"
+
+type Export interface {
+	ValidationFormat(format string) error
+	Build(format string, record export.Record) (File, error)
+}
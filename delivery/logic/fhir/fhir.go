@@ -0,0 +1,160 @@
+package fhir
+
+import (
+	repo "be/repository/fhir"
+	"be/utils/fhir"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCount = 20
+	MaxCount     = 100
+)
+
+// search parameters supported by each resource, next to _count and _offset
+
+var parameters = map[string][]string{
+	"Patient":      {"_id", "identifier", "name", "gender", "birthdate"},
+	"Practitioner": {"_id", "name"},
+	"Encounter":    {"_id", "patient", "subject", "practitioner", "participant", "date", "status"},
+	"Condition":    {"_id", "patient", "subject", "encounter"},
+}
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+func (l *Logic) ParseSearch(resourceType string, query url.Values) (repo.Search, fhir.Page, error) {
+	var search = repo.Search{}
+	var page = fhir.Page{Offset: 0, Count: DefaultCount}
+
+	var supported, ok = parameters[resourceType]
+	if !ok {
+		return repo.Search{}, fhir.Page{}, errors.New("resource type " + resourceType + " is not supported")
+	}
+
+	for key, values := range query {
+		var value = values[0]
+		var err error
+
+		switch {
+		case key == "_count":
+			page.Count, err = strconv.Atoi(value)
+			if err != nil || page.Count < 1 || page.Count > MaxCount {
+				return repo.Search{}, fhir.Page{}, errors.New("_count must be between 1 and " + strconv.Itoa(MaxCount))
+			}
+		case key == "_offset":
+			page.Offset, err = strconv.Atoi(value)
+			if err != nil || page.Offset < 0 {
+				return repo.Search{}, fhir.Page{}, errors.New("invalid _offset")
+			}
+		case key == "_format":
+		case !contains(supported, key):
+			return repo.Search{}, fhir.Page{}, errors.New("unknown search parameter " + key + " for " + resourceType)
+		case key == "_id":
+			search.Id = value
+		case key == "identifier":
+			search.Identifier, err = identifier(value)
+		case key == "name":
+			search.Name = value
+		case key == "gender":
+			if search.Gender = fhir.Gender(value); search.Gender == "" {
+				err = errors.New("invalid gender " + value)
+			}
+		case key == "birthdate":
+			search.BirthDate, err = dates(values)
+		case key == "patient", key == "subject":
+			search.Patient, err = reference("Patient", value)
+		case key == "practitioner", key == "participant":
+			search.Practitioner, err = reference("Practitioner", value)
+		case key == "encounter":
+			search.Encounter, err = reference("Encounter", value)
+		case key == "date":
+			search.Date, err = dates(values)
+		case key == "status":
+			if search.Status = fhir.VisitStatus(value); search.Status == "" {
+				err = errors.New("invalid status " + value)
+			}
+		}
+
+		if err != nil {
+			return repo.Search{}, fhir.Page{}, err
+		}
+	}
+
+	search.Offset = page.Offset
+	search.Count = page.Count
+
+	return search, page, nil
+}
+
+// identifier accepts a nik with or without the nik system
+
+func identifier(value string) (string, error) {
+	var parts = strings.SplitN(value, "|", 2)
+	if len(parts) == 1 {
+		return value, nil
+	}
+
+	if parts[0] != "" && parts[0] != fhir.NikSystem {
+		return "", errors.New("identifier system " + parts[0] + " is not supported")
+	}
+
+	return parts[1], nil
+}
+
+// reference accepts either the id or the relative reference, e.g. Patient/123
+
+func reference(resourceType, value string) (string, error) {
+	if !strings.Contains(value, "/") {
+		return value, nil
+	}
+
+	var parts = strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] != resourceType || parts[1] == "" {
+		return "", errors.New("invalid reference " + value)
+	}
+
+	return parts[1], nil
+}
+
+func dates(values []string) ([]repo.DateParam, error) {
+	var params = []repo.DateParam{}
+
+	for _, value := range values {
+		var prefix = "eq"
+		if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+			prefix, value = value[:2], value[2:]
+		}
+
+		switch prefix {
+		case "eq", "lt", "le", "gt", "ge":
+		default:
+			return nil, errors.New("date prefix " + prefix + " is not supported")
+		}
+
+		var date, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, errors.New("invalid date " + value)
+		}
+
+		params = append(params, repo.DateParam{Prefix: prefix, Value: date})
+	}
+
+	return params, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package fhir
+
+import (
+	"be/utils/fhir"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearch(t *testing.T) {
+	t.Run("success patient", func(t *testing.T) {
+		var l = New()
+		var query, _ = url.ParseQuery("identifier=" + url.QueryEscape(fhir.NikSystem+"|1234567890123456") + "&gender=female&birthdate=ge1990-01-01&birthdate=lt2000-01-01&_count=5&_offset=10")
+		var search, page, err = l.ParseSearch("Patient", query)
+		assert.Nil(t, err)
+		assert.Equal(t, "1234567890123456", search.Identifier)
+		assert.Equal(t, "wanita", search.Gender)
+		assert.Equal(t, 2, len(search.BirthDate))
+		assert.Equal(t, fhir.Page{Offset: 10, Count: 5}, page)
+	})
+
+	t.Run("success encounter", func(t *testing.T) {
+		var l = New()
+		var query, _ = url.ParseQuery("subject=Patient/abc&participant=doctor&status=finished&date=2022-06-01")
+		var search, page, err = l.ParseSearch("Encounter", query)
+		assert.Nil(t, err)
+		assert.Equal(t, "abc", search.Patient)
+		assert.Equal(t, "doctor", search.Practitioner)
+		assert.Equal(t, "completed", search.Status)
+		assert.Equal(t, "eq", search.Date[0].Prefix)
+		assert.Equal(t, DefaultCount, page.Count)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var l = New()
+		for _, q := range []string{"_count=0", "_count=1000", "_offset=-1", "address=jakarta", "gender=pria", "birthdate=05-05-1990", "birthdate=sa1990-01-01", "identifier=http://other|1"} {
+			var query, _ = url.ParseQuery(q)
+			var _, _, err = l.ParseSearch("Patient", query)
+			assert.NotNil(t, err, q)
+			log.Info(err)
+		}
+
+		var query, _ = url.ParseQuery("subject=Practitioner/abc")
+		var _, _, err = l.ParseSearch("Condition", query)
+		assert.NotNil(t, err)
+
+		_, _, err = l.ParseSearch("Observation", url.Values{})
+		assert.NotNil(t, err)
+	})
+}
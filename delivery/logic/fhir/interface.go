@@ -0,0 +1,11 @@
+package fhir
+
+import (
+	repo "be/repository/fhir"
+	"be/utils/fhir"
+	"net/url"
+)
+
+type Fhir interface {
+	ParseSearch(resourceType string, query url.Values) (repo.Search, fhir.Page, error)
+}
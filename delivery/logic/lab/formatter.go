@@ -0,0 +1,82 @@
+package lab
+
+import (
+	"be/entities"
+	"strconv"
+	"strings"
+)
+
+type OrderReq struct {
+	Tests []string `json:"tests" form:"tests"`
+	Note  string   `json:"note" form:"note"`
+}
+
+func (r *OrderReq) ToLabOrder() *entities.LabOrder {
+	var tests []string
+	for _, test := range r.Tests {
+		tests = append(tests, strings.TrimSpace(test))
+	}
+
+	return &entities.LabOrder{
+		Tests: strings.Join(tests, ","),
+		Note:  r.Note,
+	}
+}
+
+type ResultReq struct {
+	Analyte        string `json:"analyte"`
+	Value          string `json:"value"`
+	Unit           string `json:"unit"`
+	ReferenceRange string `json:"referenceRange"`
+	Flag           string `json:"flag"`
+}
+
+type ResultsReq struct {
+	Results []ResultReq `json:"results"`
+}
+
+func (r *ResultsReq) ToLabResults() []entities.LabResult {
+	var results []entities.LabResult
+
+	for _, result := range r.Results {
+		var flag = result.Flag
+		if flag == "" {
+			flag = Flag(result.Value, result.ReferenceRange)
+		}
+
+		results = append(results, entities.LabResult{
+			Analyte:        result.Analyte,
+			Value:          result.Value,
+			Unit:           result.Unit,
+			ReferenceRange: result.ReferenceRange,
+			Flag:           flag,
+		})
+	}
+
+	return results
+}
+
+// Flag derives the abnormal flag from a numeric value and a "low-high"
+// reference range, anything it can't read is reported as normal
+func Flag(value, referenceRange string) string {
+	var bounds = strings.SplitN(referenceRange, "-", 2)
+	if len(bounds) != 2 {
+		return "N"
+	}
+
+	var v, errV = strconv.ParseFloat(strings.TrimSpace(value), 64)
+	var low, errL = strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+	var high, errH = strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+	if errV != nil || errL != nil || errH != nil {
+		return "N"
+	}
+
+	switch {
+	case v < low:
+		return "L"
+	case v > high:
+		return "H"
+	}
+
+	return "N"
+}
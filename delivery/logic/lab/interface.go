@@ -0,0 +1,6 @@
+package lab
+
+type Lab interface {
+	ValidationOrder(req OrderReq) error
+	ValidationResults(req ResultsReq) error
+}
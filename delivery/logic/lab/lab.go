@@ -0,0 +1,63 @@
+package lab
+
+import (
+	"errors"
+	"strings"
+)
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+func (l *Logic) ValidationOrder(req OrderReq) error {
+
+	if len(req.Tests) == 0 {
+		return errors.New("tests is empty")
+	}
+
+	for _, test := range req.Tests {
+		if test = strings.TrimSpace(test); test == "" || len(test) > 50 || strings.Contains(test, ",") {
+			return errors.New("invalid test name")
+		}
+	}
+
+	if len(req.Note) > 255 {
+		return errors.New("invalid length note")
+	}
+
+	return nil
+}
+
+func (l *Logic) ValidationResults(req ResultsReq) error {
+
+	if len(req.Results) == 0 {
+		return errors.New("results is empty")
+	}
+
+	for _, result := range req.Results {
+		if result.Analyte == "" || len(result.Analyte) > 100 {
+			return errors.New("invalid analyte")
+		}
+
+		if result.Value == "" || len(result.Value) > 100 {
+			return errors.New("invalid value")
+		}
+
+		if _, ok := flags[result.Flag]; !ok && result.Flag != "" {
+			return errors.New("invalid flag input")
+		}
+	}
+
+	return nil
+}
+
+var flags = map[string]int{
+	"N":  0,
+	"L":  1,
+	"H":  2,
+	"LL": 3,
+	"HH": 4,
+	"A":  5,
+}
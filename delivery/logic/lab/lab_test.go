@@ -0,0 +1,78 @@
+package lab
+
+import (
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationOrder(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationOrder(OrderReq{Tests: []string{"hemoglobin", "glucose"}})
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error empty", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationOrder(OrderReq{Note: "fasting"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error test name", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationOrder(OrderReq{Tests: []string{"a,b"}})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("succeess to entity", func(t *testing.T) {
+		var req = OrderReq{Tests: []string{" hemoglobin", "glucose "}}
+		assert.Equal(t, "hemoglobin,glucose", req.ToLabOrder().Tests)
+	})
+}
+
+func TestValidationResults(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationResults(ResultsReq{Results: []ResultReq{{Analyte: "glucose", Value: "90", Unit: "mg/dL", ReferenceRange: "70-100"}}})
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error empty", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationResults(ResultsReq{})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error analyte", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationResults(ResultsReq{Results: []ResultReq{{Value: "90"}}})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error flag", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationResults(ResultsReq{Results: []ResultReq{{Analyte: "glucose", Value: "90", Flag: "X"}}})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+}
+
+func TestFlag(t *testing.T) {
+	assert.Equal(t, "N", Flag("90", "70-100"))
+	assert.Equal(t, "L", Flag("60", "70-100"))
+	assert.Equal(t, "H", Flag("13.5", "4.5 - 11"))
+	assert.Equal(t, "N", Flag("positive", "negative"))
+
+	var req = ResultsReq{Results: []ResultReq{{Analyte: "glucose", Value: "160", ReferenceRange: "70-100"}, {Analyte: "hbsag", Value: "reactive", Flag: "A"}}}
+	var res = req.ToLabResults()
+	assert.Equal(t, "H", res[0].Flag)
+	assert.Equal(t, "A", res[1].Flag)
+}
@@ -0,0 +1,43 @@
+package note
+
+import (
+	"be/entities"
+	"be/repository/note"
+)
+
+// Req keeps the sections as pointers, a section left out of the body is
+// nil and a section sent empty is cleared
+type Req struct {
+	Subjective *string `json:"subjective" form:"subjective"`
+	Objective  *string `json:"objective" form:"objective"`
+	Assessment *string `json:"assessment" form:"assessment"`
+	Plan       *string `json:"plan" form:"plan"`
+	Reason     string  `json:"reason" form:"reason"`
+}
+
+func value(section *string) string {
+	if section == nil {
+		return ""
+	}
+	return *section
+}
+
+func (r *Req) ToNote() *entities.Note {
+	return &entities.Note{
+		Subjective: value(r.Subjective),
+		Objective:  value(r.Objective),
+		Assessment: value(r.Assessment),
+		Plan:       value(r.Plan),
+		Reason:     r.Reason,
+	}
+}
+
+func (r *Req) ToRevision() *note.Revision {
+	return &note.Revision{
+		Subjective: r.Subjective,
+		Objective:  r.Objective,
+		Assessment: r.Assessment,
+		Plan:       r.Plan,
+		Reason:     r.Reason,
+	}
+}
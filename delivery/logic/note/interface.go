@@ -0,0 +1,6 @@
+package note
+
+type Note interface {
+	ValidationRequest(req Req) error
+	ValidationAmendment(req Req) error
+}
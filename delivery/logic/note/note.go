@@ -0,0 +1,49 @@
+package note
+
+import "errors"
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+const maxSection = 10000
+
+func (l *Logic) ValidationRequest(req Req) error {
+
+	if req.Subjective == nil && req.Objective == nil && req.Assessment == nil && req.Plan == nil {
+		return errors.New("data is empty")
+	}
+
+	if len(value(req.Subjective)) > maxSection {
+		return errors.New("invalid length subjective")
+	}
+
+	if len(value(req.Objective)) > maxSection {
+		return errors.New("invalid length objective")
+	}
+
+	if len(value(req.Assessment)) > maxSection {
+		return errors.New("invalid length assessment")
+	}
+
+	if len(value(req.Plan)) > maxSection {
+		return errors.New("invalid length plan")
+	}
+
+	return nil
+}
+
+func (l *Logic) ValidationAmendment(req Req) error {
+
+	if err := l.ValidationRequest(req); err != nil {
+		return err
+	}
+
+	if len(req.Reason) < 5 || len(req.Reason) > 255 {
+		return errors.New("invalid length reason")
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package note
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func text(s string) *string {
+	return &s
+}
+
+func TestValidationRequest(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var req Req
+		req.Subjective = text("headache since two days")
+		var l = New()
+		err := l.ValidationRequest(req)
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error empty", func(t *testing.T) {
+		var req Req
+		req.Reason = "typo"
+		var l = New()
+		err := l.ValidationRequest(req)
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error length plan", func(t *testing.T) {
+		var req Req
+		req.Plan = text(strings.Repeat("a", maxSection+1))
+		var l = New()
+		err := l.ValidationRequest(req)
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+}
+
+func TestValidationAmendment(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var req Req
+		req.Assessment = text("tension headache")
+		req.Reason = "wrong diagnosis"
+		var l = New()
+		err := l.ValidationAmendment(req)
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error reason", func(t *testing.T) {
+		var req Req
+		req.Assessment = text("tension headache")
+		var l = New()
+		err := l.ValidationAmendment(req)
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error empty", func(t *testing.T) {
+		var req Req
+		req.Reason = "wrong diagnosis"
+		var l = New()
+		err := l.ValidationAmendment(req)
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("succeess to entity", func(t *testing.T) {
+		var req = Req{Plan: text("rest"), Reason: "update"}
+		res := req.ToNote()
+		assert.Equal(t, "rest", res.Plan)
+		assert.Equal(t, "update", res.Reason)
+	})
+
+	t.Run("success to revision", func(t *testing.T) {
+		var req = Req{Plan: text(""), Reason: "plan is not needed"}
+		res := req.ToRevision()
+		assert.Nil(t, res.Subjective)
+		assert.Equal(t, "", *res.Plan)
+	})
+}
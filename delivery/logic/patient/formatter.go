@@ -14,6 +14,7 @@ type Req struct {
 	Password   string `json:"password" form:"password"`
 	Nik        string `json:"nik" form:"nik" validate:"required"`
 	Name       string `json:"name" form:"name"  validate:"required"`
+	Phone      string `json:"phone" form:"phone"`
 	Image      string `json:"image" form:"image"`
 	Gender     string `json:"gender" form:"gender"  validate:"required"`
 	Address    string `json:"address" form:"address"  validate:"required"`
@@ -44,6 +45,7 @@ func (r *Req) ToPatient() (*entities.Patient, error) {
 		Password:   r.Password,
 		Nik:        r.Nik,
 		Name:       r.Name,
+		Phone:      r.Phone,
 		Image:      r.Image,
 		Gender:     r.Gender,
 		Address:    r.Address,
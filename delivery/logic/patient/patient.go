@@ -68,6 +68,10 @@ func (l *Logic) ValidationRequest(req Req) error {
 		return err
 	}
 
+	if err := utils.PhoneValid(req.Phone); err != nil && req.Phone != "" {
+		return err
+	}
+
 	if _, ok := genders[req.Gender]; !ok && req.Gender != "" {
 		return errors.New("invalid gender input")
 	}
@@ -0,0 +1,45 @@
+package referral
+
+import (
+	"be/entities"
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type Req struct {
+	To_doctor_uid string `json:"to_doctor_uid" form:"to_doctor_uid" validate:"required"`
+	Reason        string `json:"reason" form:"reason" validate:"required"`
+	Urgency       string `json:"urgency" form:"urgency"`
+}
+
+func (r *Req) ToReferral() *entities.Referral {
+	var urgency = r.Urgency
+	if urgency == "" {
+		urgency = "routine"
+	}
+
+	return &entities.Referral{
+		To_doctor_uid: r.To_doctor_uid,
+		Reason:        r.Reason,
+		Urgency:       urgency,
+	}
+}
+
+type AnswerReq struct {
+	Date     string `json:"date" form:"date"`
+	Response string `json:"response" form:"response"`
+}
+
+func (r *AnswerReq) ToVisit() (*entities.Visit, error) {
+	var layout = "02-01-2006"
+	var dateConv, err = time.Parse(layout, r.Date)
+	if err != nil {
+		return &entities.Visit{}, errors.New("invalid date format")
+	}
+
+	return &entities.Visit{
+		Date: datatypes.Date(dateConv),
+	}, nil
+}
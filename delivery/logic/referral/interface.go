@@ -0,0 +1,7 @@
+package referral
+
+type Referral interface {
+	ValidationRequest(req Req) error
+	ValidationAccept(req AnswerReq) error
+	ValidationDecline(req AnswerReq) error
+}
@@ -0,0 +1,69 @@
+package referral
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/gommon/log"
+)
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+func (l *Logic) ValidationRequest(req Req) error {
+	var v = validator.New()
+	if err := v.Struct(req); err != nil {
+		log.Warn(err)
+		switch {
+		case strings.Contains(err.Error(), "To_doctor_uid"):
+			err = errors.New("invalid to_doctor_uid")
+		case strings.Contains(err.Error(), "Reason"):
+			err = errors.New("invalid reason")
+		default:
+			err = errors.New("invalid input")
+		}
+		return err
+	}
+
+	if len(req.Reason) > 255 {
+		return errors.New("invalid length reason")
+	}
+
+	if _, ok := urgencies[req.Urgency]; !ok && req.Urgency != "" {
+		return errors.New("invalid urgency input")
+	}
+
+	return nil
+}
+
+func (l *Logic) ValidationAccept(req AnswerReq) error {
+
+	if req.Date == "" {
+		return errors.New("invalid date")
+	}
+
+	if _, err := req.ToVisit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *Logic) ValidationDecline(req AnswerReq) error {
+
+	if len(req.Response) < 5 || len(req.Response) > 255 {
+		return errors.New("invalid length response")
+	}
+
+	return nil
+}
+
+var urgencies = map[string]int{
+	"routine":   0,
+	"urgent":    1,
+	"emergency": 2,
+}
@@ -0,0 +1,65 @@
+package referral
+
+import (
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationRequest(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{To_doctor_uid: "doctor", Reason: "suspected fracture", Urgency: "urgent"})
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error doctor", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{Reason: "suspected fracture"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error reason", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{To_doctor_uid: "doctor"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error urgency", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationRequest(Req{To_doctor_uid: "doctor", Reason: "suspected fracture", Urgency: "asap"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("default urgency", func(t *testing.T) {
+		var req = Req{To_doctor_uid: "doctor", Reason: "suspected fracture"}
+		assert.Equal(t, "routine", req.ToReferral().Urgency)
+	})
+}
+
+func TestValidationAnswer(t *testing.T) {
+	t.Run("success accept", func(t *testing.T) {
+		var l = New()
+		err := l.ValidationAccept(AnswerReq{Date: time.Now().Format("02-01-2006")})
+		assert.Nil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error accept date", func(t *testing.T) {
+		var l = New()
+		assert.NotNil(t, l.ValidationAccept(AnswerReq{}))
+		assert.NotNil(t, l.ValidationAccept(AnswerReq{Date: "2022-05-05"}))
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		var l = New()
+		assert.Nil(t, l.ValidationDecline(AnswerReq{Response: "fully booked this month"}))
+		assert.NotNil(t, l.ValidationDecline(AnswerReq{}))
+	})
+}
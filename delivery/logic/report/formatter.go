@@ -0,0 +1,149 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type Req struct {
+	From   string
+	To     string
+	Period string
+	Limit  string
+}
+
+// the order of the breakdown columns in the csv
+
+var Genders = []string{"pria", "wanita", "lainnya"}
+
+var AgeGroups = []string{"0-17", "18-29", "30-44", "45-59", "60+", "unknown"}
+
+type VisitPeriod struct {
+	Period           string  `json:"period"`
+	Total            int     `json:"total"`
+	Pending          int     `json:"pending"`
+	Ready            int     `json:"ready"`
+	Completed        int     `json:"completed"`
+	Cancelled        int     `json:"cancelled"`
+	NoShow           int     `json:"noShow"`
+	CancellationRate float64 `json:"cancellationRate"`
+	NoShowRate       float64 `json:"noShowRate"`
+}
+
+type VisitsResp struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Period  string        `json:"period"`
+	Periods []VisitPeriod `json:"periods"`
+	Summary VisitPeriod   `json:"summary"`
+}
+
+func (r VisitsResp) Rows() [][]string {
+	var rows = [][]string{{"period", "total", "pending", "ready", "completed", "cancelled", "no_show", "cancellation_rate", "no_show_rate"}}
+
+	for _, p := range append(r.Periods, r.Summary) {
+		rows = append(rows, []string{p.Period, itoa(p.Total), itoa(p.Pending), itoa(p.Ready), itoa(p.Completed), itoa(p.Cancelled), itoa(p.NoShow), ftoa(p.CancellationRate), ftoa(p.NoShowRate)})
+	}
+
+	return rows
+}
+
+type PatientPeriod struct {
+	Period    string `json:"period"`
+	Total     int    `json:"total"`
+	New       int    `json:"new"`
+	Returning int    `json:"returning"`
+}
+
+type PatientsResp struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Period  string          `json:"period"`
+	Periods []PatientPeriod `json:"periods"`
+}
+
+func (r PatientsResp) Rows() [][]string {
+	var rows = [][]string{{"period", "total", "new", "returning"}}
+
+	for _, p := range r.Periods {
+		rows = append(rows, []string{p.Period, itoa(p.Total), itoa(p.New), itoa(p.Returning)})
+	}
+
+	return rows
+}
+
+type Diagnose struct {
+	Diagnose string `json:"diagnose"`
+	Total    int    `json:"total"`
+}
+
+type DiagnosePeriod struct {
+	Period    string     `json:"period"`
+	Diagnoses []Diagnose `json:"diagnoses"`
+}
+
+type DiagnosesResp struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Period  string           `json:"period"`
+	Periods []DiagnosePeriod `json:"periods"`
+}
+
+func (r DiagnosesResp) Rows() [][]string {
+	var rows = [][]string{{"period", "rank", "diagnose", "total"}}
+
+	for _, p := range r.Periods {
+		for i, d := range p.Diagnoses {
+			rows = append(rows, []string{p.Period, itoa(i + 1), d.Diagnose, itoa(d.Total)})
+		}
+	}
+
+	return rows
+}
+
+type DemographicPeriod struct {
+	Period string         `json:"period"`
+	Total  int            `json:"total"`
+	Gender map[string]int `json:"gender"`
+	Age    map[string]int `json:"age"`
+}
+
+type DemographicsResp struct {
+	From    string              `json:"from"`
+	To      string              `json:"to"`
+	Period  string              `json:"period"`
+	Periods []DemographicPeriod `json:"periods"`
+}
+
+func (r DemographicsResp) Rows() [][]string {
+	var header = []string{"period", "total"}
+	for _, gender := range Genders {
+		header = append(header, "gender_"+gender)
+	}
+	for _, age := range AgeGroups {
+		header = append(header, "age_"+age)
+	}
+
+	var rows = [][]string{header}
+
+	for _, p := range r.Periods {
+		var row = []string{p.Period, itoa(p.Total)}
+		for _, gender := range Genders {
+			row = append(row, itoa(p.Gender[gender]))
+		}
+		for _, age := range AgeGroups {
+			row = append(row, itoa(p.Age[age]))
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
+
+func ftoa(f float64) string {
+	return fmt.Sprintf("%.2f", f)
+}
@@ -0,0 +1,12 @@
+package report
+
+import "be/repository/report"
+
+type Report interface {
+	ValidationQuery(req Req) (report.Query, error)
+	ValidationFormat(format string) error
+	Visits(q report.Query, rows []report.VisitRow) VisitsResp
+	Patients(q report.Query, rows []report.PatientRow) PatientsResp
+	Diagnoses(q report.Query, rows []report.DiagnoseRow) DiagnosesResp
+	Demographics(q report.Query, rows []report.DemographicRow) DemographicsResp
+}
@@ -0,0 +1,259 @@
+package report
+
+import (
+	"be/repository/report"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const layout = "02-01-2006"
+
+const defaultLimit = 10
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+// ValidationQuery checks the range of the report, without from and to the
+// report covers the last 30 days, 12 weeks or 12 months up to today
+
+func (l *Logic) ValidationQuery(req Req) (report.Query, error) {
+
+	var q = report.Query{Period: req.Period, Limit: defaultLimit}
+
+	if q.Period == "" {
+		q.Period = "month"
+	}
+
+	switch q.Period {
+	case "day", "week", "month":
+	default:
+		return report.Query{}, errors.New("invalid period input")
+	}
+
+	var now = time.Now()
+	q.To = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if req.To != "" {
+		var to, err = time.Parse(layout, req.To)
+		if err != nil {
+			return report.Query{}, errors.New("invalid to date format")
+		}
+		q.To = to
+	}
+
+	switch q.Period {
+	case "day":
+		q.From = q.To.AddDate(0, 0, -29)
+	case "week":
+		q.From = q.To.AddDate(0, 0, -7*11)
+	case "month":
+		q.From = time.Date(q.To.Year(), q.To.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	if req.From != "" {
+		var from, err = time.Parse(layout, req.From)
+		if err != nil {
+			return report.Query{}, errors.New("invalid from date format")
+		}
+		q.From = from
+	}
+
+	if q.From.After(q.To) {
+		return report.Query{}, errors.New("from is after to")
+	}
+
+	// a daily report of more than a year is not readable, neither is the csv
+
+	var max = q.From.AddDate(5, 0, 0)
+	if q.Period == "day" {
+		max = q.From.AddDate(1, 0, 0)
+	}
+
+	if q.To.After(max) {
+		return report.Query{}, errors.New("range is too long")
+	}
+
+	if req.Limit != "" {
+		var limit, err = strconv.Atoi(req.Limit)
+		if err != nil || limit < 1 || limit > 50 {
+			return report.Query{}, errors.New("invalid limit")
+		}
+		q.Limit = limit
+	}
+
+	return q, nil
+}
+
+func (l *Logic) ValidationFormat(format string) error {
+
+	switch format {
+	case "", "json", "csv":
+	default:
+		return errors.New("invalid format input")
+	}
+
+	return nil
+}
+
+// Label is the name of the period of the date, the same as the one the
+// repository groups the visits by
+
+func Label(period string, date time.Time) string {
+	switch period {
+	case "week":
+		var year, week = date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return date.Format("2006-01")
+	default:
+		return date.Format("2006-01-02")
+	}
+}
+
+// Periods lists every period of the range, the ones without visits are in
+// the report too so the charts have no gaps
+
+func Periods(q report.Query) []string {
+	var res []string
+
+	for date := q.From; !date.After(q.To); date = date.AddDate(0, 0, 1) {
+		var label = Label(q.Period, date)
+		if len(res) == 0 || res[len(res)-1] != label {
+			res = append(res, label)
+		}
+	}
+
+	return res
+}
+
+func rate(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
+
+func (l *Logic) Visits(q report.Query, rows []report.VisitRow) VisitsResp {
+	var periods = map[string]*VisitPeriod{}
+	var res = VisitsResp{From: q.From.Format(layout), To: q.To.Format(layout), Period: q.Period, Periods: []VisitPeriod{}, Summary: VisitPeriod{Period: "total"}}
+
+	for _, label := range Periods(q) {
+		res.Periods = append(res.Periods, VisitPeriod{Period: label})
+	}
+	for _, p := range ptrs(res.Periods) {
+		periods[p.Period] = p
+	}
+
+	for _, row := range rows {
+		var p, ok = periods[row.Period]
+		if !ok {
+			continue
+		}
+		for _, v := range []*VisitPeriod{p, &res.Summary} {
+			v.Total += row.Total
+			v.NoShow += row.NoShow
+			switch row.Status {
+			case "pending":
+				v.Pending += row.Total
+			case "ready":
+				v.Ready += row.Total
+			case "completed":
+				v.Completed += row.Total
+			case "cancelled":
+				v.Cancelled += row.Total
+			}
+		}
+	}
+
+	for _, v := range append(ptrs(res.Periods), &res.Summary) {
+		v.CancellationRate = rate(v.Cancelled, v.Total)
+		v.NoShowRate = rate(v.NoShow, v.Total)
+	}
+
+	return res
+}
+
+func ptrs(periods []VisitPeriod) []*VisitPeriod {
+	var res []*VisitPeriod
+	for i := range periods {
+		res = append(res, &periods[i])
+	}
+	return res
+}
+
+func (l *Logic) Patients(q report.Query, rows []report.PatientRow) PatientsResp {
+	var found = map[string]report.PatientRow{}
+	for _, row := range rows {
+		found[row.Period] = row
+	}
+
+	var res = PatientsResp{From: q.From.Format(layout), To: q.To.Format(layout), Period: q.Period, Periods: []PatientPeriod{}}
+
+	for _, label := range Periods(q) {
+		var row = found[label]
+		res.Periods = append(res.Periods, PatientPeriod{Period: label, Total: row.Total, New: row.New, Returning: row.Total - row.New})
+	}
+
+	return res
+}
+
+// Diagnoses keeps the most frequent diagnoses of every period, the rows come
+// sorted by total from the repository
+
+func (l *Logic) Diagnoses(q report.Query, rows []report.DiagnoseRow) DiagnosesResp {
+	var found = map[string][]Diagnose{}
+	for _, row := range rows {
+		if len(found[row.Period]) < q.Limit {
+			found[row.Period] = append(found[row.Period], Diagnose{Diagnose: row.Diagnose, Total: row.Total})
+		}
+	}
+
+	var res = DiagnosesResp{From: q.From.Format(layout), To: q.To.Format(layout), Period: q.Period, Periods: []DiagnosePeriod{}}
+
+	for _, label := range Periods(q) {
+		var diagnoses = found[label]
+		if diagnoses == nil {
+			diagnoses = []Diagnose{}
+		}
+		res.Periods = append(res.Periods, DiagnosePeriod{Period: label, Diagnoses: diagnoses})
+	}
+
+	return res
+}
+
+func (l *Logic) Demographics(q report.Query, rows []report.DemographicRow) DemographicsResp {
+	var periods = map[string]*DemographicPeriod{}
+	var res = DemographicsResp{From: q.From.Format(layout), To: q.To.Format(layout), Period: q.Period, Periods: []DemographicPeriod{}}
+
+	for _, label := range Periods(q) {
+		var p = DemographicPeriod{Period: label, Gender: map[string]int{}, Age: map[string]int{}}
+		for _, gender := range Genders {
+			p.Gender[gender] = 0
+		}
+		for _, age := range AgeGroups {
+			p.Age[age] = 0
+		}
+		res.Periods = append(res.Periods, p)
+	}
+	for i := range res.Periods {
+		periods[res.Periods[i].Period] = &res.Periods[i]
+	}
+
+	for _, row := range rows {
+		var p, ok = periods[row.Period]
+		if !ok {
+			continue
+		}
+		p.Total += row.Total
+		p.Gender[row.Gender] += row.Total
+		p.Age[row.AgeGroup] += row.Total
+	}
+
+	return res
+}
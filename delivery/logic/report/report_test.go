@@ -0,0 +1,125 @@
+package report
+
+import (
+	"be/repository/report"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationQuery(t *testing.T) {
+	t.Run("success default", func(t *testing.T) {
+		var l = New()
+		var q, err = l.ValidationQuery(Req{})
+		assert.Nil(t, err)
+		assert.Equal(t, "month", q.Period)
+		assert.Equal(t, 12, len(Periods(q)))
+		assert.Equal(t, 10, q.Limit)
+	})
+
+	t.Run("success range", func(t *testing.T) {
+		var l = New()
+		var q, err = l.ValidationQuery(Req{From: "01-03-2022", To: "31-03-2022", Period: "week", Limit: "3"})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"2022-W09", "2022-W10", "2022-W11", "2022-W12", "2022-W13"}, Periods(q))
+		assert.Equal(t, 3, q.Limit)
+	})
+
+	t.Run("error period", func(t *testing.T) {
+		var l = New()
+		var _, err = l.ValidationQuery(Req{Period: "year"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error date", func(t *testing.T) {
+		var l = New()
+		var _, err = l.ValidationQuery(Req{From: "2022-03-01"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error from after to", func(t *testing.T) {
+		var l = New()
+		var _, err = l.ValidationQuery(Req{From: "02-03-2022", To: "01-03-2022"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error range too long", func(t *testing.T) {
+		var l = New()
+		var _, err = l.ValidationQuery(Req{From: "01-01-2021", To: "01-03-2022", Period: "day"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error limit", func(t *testing.T) {
+		var l = New()
+		var _, err = l.ValidationQuery(Req{Limit: "0"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error format", func(t *testing.T) {
+		var l = New()
+		assert.Nil(t, l.ValidationFormat("csv"))
+		assert.NotNil(t, l.ValidationFormat("pdf"))
+	})
+}
+
+var q = report.Query{From: time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC), To: time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC), Period: "month", Limit: 1}
+
+func TestVisits(t *testing.T) {
+	var l = New()
+	var res = l.Visits(q, []report.VisitRow{
+		{Period: "2022-01", Status: "completed", Total: 3},
+		{Period: "2022-01", Status: "cancelled", Total: 1},
+		{Period: "2022-03", Status: "pending", Total: 2, NoShow: 1},
+	})
+
+	assert.Equal(t, 3, len(res.Periods))
+	assert.Equal(t, VisitPeriod{Period: "2022-01", Total: 4, Completed: 3, Cancelled: 1, CancellationRate: 25}, res.Periods[0])
+	assert.Equal(t, VisitPeriod{Period: "2022-02"}, res.Periods[1])
+	assert.Equal(t, 50.0, res.Periods[2].NoShowRate)
+	assert.Equal(t, VisitPeriod{Period: "total", Total: 6, Pending: 2, Completed: 3, Cancelled: 1, NoShow: 1, CancellationRate: 16.67, NoShowRate: 16.67}, res.Summary)
+
+	var rows = res.Rows()
+	assert.Equal(t, 5, len(rows))
+	assert.Equal(t, []string{"total", "6", "2", "0", "3", "1", "1", "16.67", "16.67"}, rows[4])
+}
+
+func TestPatients(t *testing.T) {
+	var l = New()
+	var res = l.Patients(q, []report.PatientRow{{Period: "2022-02", Total: 5, New: 2}})
+
+	assert.Equal(t, PatientPeriod{Period: "2022-02", Total: 5, New: 2, Returning: 3}, res.Periods[1])
+	assert.Equal(t, []string{"2022-01", "0", "0", "0"}, res.Rows()[1])
+}
+
+func TestDiagnoses(t *testing.T) {
+	var l = New()
+	var res = l.Diagnoses(q, []report.DiagnoseRow{
+		{Period: "2022-01", Diagnose: "flu", Total: 4},
+		{Period: "2022-01", Diagnose: "fever", Total: 2},
+	})
+
+	assert.Equal(t, []Diagnose{{Diagnose: "flu", Total: 4}}, res.Periods[0].Diagnoses)
+	assert.Equal(t, []Diagnose{}, res.Periods[1].Diagnoses)
+	assert.Equal(t, [][]string{{"period", "rank", "diagnose", "total"}, {"2022-01", "1", "flu", "4"}}, res.Rows())
+}
+
+func TestDemographics(t *testing.T) {
+	var l = New()
+	var res = l.Demographics(q, []report.DemographicRow{
+		{Period: "2022-03", Gender: "wanita", AgeGroup: "30-44", Total: 2},
+		{Period: "2022-03", Gender: "pria", AgeGroup: "unknown", Total: 1},
+	})
+
+	assert.Equal(t, 3, res.Periods[2].Total)
+	assert.Equal(t, 2, res.Periods[2].Gender["wanita"])
+	assert.Equal(t, 0, res.Periods[0].Age["60+"])
+	assert.Equal(t, []string{"period", "total", "gender_pria", "gender_wanita", "gender_lainnya", "age_0-17", "age_18-29", "age_30-44", "age_45-59", "age_60+", "age_unknown"}, res.Rows()[0])
+	assert.Equal(t, []string{"2022-03", "3", "1", "2", "0", "0", "0", "2", "0", "0", "1"}, res.Rows()[3])
+}
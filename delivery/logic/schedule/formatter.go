@@ -0,0 +1,45 @@
+package schedule
+
+import "be/entities"
+
+type Req struct {
+	Kind     string `json:"kind" form:"kind"`
+	Cron     string `json:"cron" form:"cron"`
+	Timezone string `json:"timezone" form:"timezone"`
+	Email    string `json:"email" form:"email"`
+	Active   *bool  `json:"active" form:"active"`
+}
+
+// the schedule of a kind when the request has no cron, in the clinic timezone
+
+var DefaultCron = map[string]string{
+	"appointments": "0 18 * * *",
+	"monthly":      "0 7 1 * *",
+}
+
+const DefaultTimezone = "Asia/Jakarta"
+
+func (r *Req) ToReportSchedule() *entities.ReportSchedule {
+	var cron = r.Cron
+	if cron == "" {
+		cron = DefaultCron[r.Kind]
+	}
+
+	var timezone = r.Timezone
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+
+	var active = true
+	if r.Active != nil {
+		active = *r.Active
+	}
+
+	return &entities.ReportSchedule{
+		Kind:     r.Kind,
+		Cron:     cron,
+		Timezone: timezone,
+		Email:    r.Email,
+		Active:   active,
+	}
+}
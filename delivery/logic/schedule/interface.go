@@ -0,0 +1,25 @@
+package schedule
+
+import (
+	"be/api/mail"
+	logicReport "be/delivery/logic/report"
+	"be/repository/schedule"
+	"time"
+)
+
+type Schedule interface {
+	ValidationRequest(req Req) error
+	Next(cron, timezone string, after time.Time) (time.Time, error)
+	Retry(attempts int, now time.Time) *time.Time
+	Appointments(clinic schedule.Clinic, date time.Time, appointments []schedule.Appointment) mail.Message
+	Monthly(clinic schedule.Clinic, month time.Time, report Monthly) (mail.Message, error)
+}
+
+// Monthly is the clinic report of one month, as built for the report endpoints
+
+type Monthly struct {
+	Visits       logicReport.VisitsResp
+	Patients     logicReport.PatientsResp
+	Diagnoses    logicReport.DiagnosesResp
+	Demographics logicReport.DemographicsResp
+}
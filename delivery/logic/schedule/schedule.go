@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"be/api/mail"
+	"be/repository/schedule"
+	"be/utils"
+	"be/utils/sheet"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	// the timezones of the clinics are known without the zoneinfo of the host
+
+	_ "time/tzdata"
+)
+
+// a run is tried this many times, the wait between attempts doubles from retryAfter
+
+const MaxAttempts = 5
+
+const retryAfter = 5 * time.Minute
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+func (l *Logic) ValidationRequest(req Req) error {
+
+	switch req.Kind {
+	case "appointments", "monthly":
+	default:
+		return errors.New("invalid kind input")
+	}
+
+	var schedule = req.ToReportSchedule()
+
+	if _, err := l.Next(schedule.Cron, schedule.Timezone, time.Now()); err != nil {
+		return err
+	}
+
+	if err := utils.EmailValid(req.Email); err != nil {
+		return err
+	}
+
+	// the address goes to the mail header as it is
+
+	if strings.ContainsAny(req.Email, "<>\r\n, ") {
+		return errors.New("invalid email format")
+	}
+
+	return nil
+}
+
+// Next is the first time after the given one the cron matches, read in the timezone
+
+func (l *Logic) Next(expression, timezone string, after time.Time) (time.Time, error) {
+
+	var location, err = time.LoadLocation(timezone)
+	if err != nil || timezone == "" || timezone == "Local" {
+		return time.Time{}, errors.New("invalid timezone")
+	}
+
+	if strings.Contains(expression, "TZ=") {
+		return time.Time{}, errors.New("invalid cron, use timezone")
+	}
+
+	parsed, err := cron.ParseStandard(expression)
+	if err != nil {
+		return time.Time{}, errors.New("invalid cron")
+	}
+
+	var next = parsed.Next(after.In(location))
+	if next.IsZero() {
+		return time.Time{}, errors.New("invalid cron")
+	}
+
+	return next, nil
+}
+
+// Retry is the time of the next attempt after the given number of attempts, nil after the last one
+
+func (l *Logic) Retry(attempts int, now time.Time) *time.Time {
+	if attempts >= MaxAttempts {
+		return nil
+	}
+
+	var retry = now.Add(retryAfter << (attempts - 1))
+	return &retry
+}
+
+// Appointments is the summary of the visits still to come on the date
+
+func (l *Logic) Appointments(clinic schedule.Clinic, date time.Time, appointments []schedule.Appointment) mail.Message {
+	var body strings.Builder
+	var day = date.Format("02-01-2006")
+
+	fmt.Fprintf(&body, "Appointments of %s on %s\n\n", clinic.Name, day)
+
+	if len(appointments) == 0 {
+		body.WriteString("There is no appointment.\n")
+	}
+
+	for i, appointment := range appointments {
+		fmt.Fprintf(&body, "%d. %s (%s)\n", i+1, appointment.PatientName, appointment.Status)
+		if complaint := strings.TrimSpace(appointment.Complaint); complaint != "" {
+			fmt.Fprintf(&body, "   %s\n", strings.ReplaceAll(complaint, "\n", " "))
+		}
+	}
+
+	fmt.Fprintf(&body, "\nTotal: %d\n", len(appointments))
+
+	return mail.Message{
+		Subject: fmt.Sprintf("%s: %d appointments on %s", clinic.Name, len(appointments), day),
+		Body:    body.String(),
+	}
+}
+
+// Monthly is the statistics of the month in the body with every report attached as csv
+
+func (l *Logic) Monthly(clinic schedule.Clinic, month time.Time, report Monthly) (mail.Message, error) {
+	var body strings.Builder
+	var name = month.Format("January 2006")
+	var visits = report.Visits.Summary
+
+	fmt.Fprintf(&body, "Statistics of %s for %s\n\n", clinic.Name, name)
+	fmt.Fprintf(&body, "Visits: %d\n", visits.Total)
+	fmt.Fprintf(&body, "Completed: %d\n", visits.Completed)
+	fmt.Fprintf(&body, "Cancelled: %d (%.2f%%)\n", visits.Cancelled, visits.CancellationRate)
+	fmt.Fprintf(&body, "No show: %d (%.2f%%)\n", visits.NoShow, visits.NoShowRate)
+
+	var patients, returning int
+	for _, p := range report.Patients.Periods {
+		patients += p.New
+		returning += p.Returning
+	}
+	fmt.Fprintf(&body, "New patients: %d\n", patients)
+	fmt.Fprintf(&body, "Returning patients: %d\n", returning)
+
+	var diagnoses = []string{}
+	for _, p := range report.Diagnoses.Periods {
+		for _, d := range p.Diagnoses {
+			diagnoses = append(diagnoses, fmt.Sprintf("%s (%d)", d.Diagnose, d.Total))
+		}
+	}
+	if len(diagnoses) != 0 {
+		fmt.Fprintf(&body, "\nTop diagnoses: %s\n", strings.Join(diagnoses, ", "))
+	}
+
+	body.WriteString("\nThe reports are attached as csv.\n")
+
+	var msg = mail.Message{
+		Subject: fmt.Sprintf("%s: statistics of %s", clinic.Name, name),
+		Body:    body.String(),
+	}
+
+	var tables = []struct {
+		name string
+		rows [][]string
+	}{
+		{"visits", report.Visits.Rows()},
+		{"patients", report.Patients.Rows()},
+		{"diagnoses", report.Diagnoses.Rows()},
+		{"demographics", report.Demographics.Rows()},
+	}
+
+	for _, table := range tables {
+		var csv, err = sheet.Csv(table.rows)
+		if err != nil {
+			return mail.Message{}, err
+		}
+		msg.Attachments = append(msg.Attachments, mail.Attachment{
+			Name:        fmt.Sprintf("%s-%s.csv", table.name, month.Format("2006-01")),
+			ContentType: "text/csv",
+			Body:        csv,
+		})
+	}
+
+	return msg, nil
+}
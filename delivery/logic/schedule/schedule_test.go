@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"be/repository/schedule"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationRequest(t *testing.T) {
+	t.Run("success default cron", func(t *testing.T) {
+		var l = New()
+		var req = Req{Kind: "appointments", Email: "owner@mail.com"}
+		assert.Nil(t, l.ValidationRequest(req))
+		assert.Equal(t, "0 18 * * *", req.ToReportSchedule().Cron)
+		assert.Equal(t, "Asia/Jakarta", req.ToReportSchedule().Timezone)
+	})
+
+	t.Run("error kind", func(t *testing.T) {
+		var l = New()
+		var err = l.ValidationRequest(Req{Kind: "yearly", Email: "owner@mail.com"})
+		assert.NotNil(t, err)
+		log.Info(err)
+	})
+
+	t.Run("error cron", func(t *testing.T) {
+		var l = New()
+		var err = l.ValidationRequest(Req{Kind: "monthly", Cron: "0 25 * * *", Email: "owner@mail.com"})
+		assert.Equal(t, "invalid cron", err.Error())
+	})
+
+	t.Run("error timezone", func(t *testing.T) {
+		var l = New()
+		var err = l.ValidationRequest(Req{Kind: "monthly", Timezone: "Asia/Bandung", Email: "owner@mail.com"})
+		assert.Equal(t, "invalid timezone", err.Error())
+	})
+
+	t.Run("error email", func(t *testing.T) {
+		var l = New()
+		var err = l.ValidationRequest(Req{Kind: "monthly", Email: "Owner <owner@mail.com>"})
+		assert.Equal(t, "invalid email format", err.Error())
+	})
+}
+
+func TestNext(t *testing.T) {
+	var l = New()
+	var now = time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var next, err = l.Next("0 18 * * *", "Asia/Jakarta", now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 3, 2, 11, 0, 0, 0, time.UTC), next.UTC())
+
+	_, err = l.Next("CRON_TZ=UTC 0 18 * * *", "Asia/Jakarta", now)
+	assert.NotNil(t, err)
+}
+
+func TestRetry(t *testing.T) {
+	var l = New()
+	var now = time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, now.Add(5*time.Minute), *l.Retry(1, now))
+	assert.Equal(t, now.Add(20*time.Minute), *l.Retry(3, now))
+	assert.Nil(t, l.Retry(MaxAttempts, now))
+}
+
+func TestAppointments(t *testing.T) {
+	var l = New()
+	var msg = l.Appointments(schedule.Clinic{Name: "klinik sehat"}, time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC), []schedule.Appointment{
+		{PatientName: "siti", Status: "ready", Complaint: "fever\nand cough"},
+	})
+
+	assert.Equal(t, "klinik sehat: 1 appointments on 02-03-2022", msg.Subject)
+	assert.True(t, strings.Contains(msg.Body, "1. siti (ready)\n   fever and cough\n"))
+}
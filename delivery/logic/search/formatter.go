@@ -0,0 +1,37 @@
+package search
+
+type Req struct {
+	Q      string
+	Type   string
+	Limit  string
+	Offset string
+}
+
+// the highlights are the fields containing the terms, html escaped with the
+// matching part of the words in <em>
+
+type PatientResp struct {
+	Patient_uid string            `json:"patient_uid"`
+	Nik         string            `json:"nik"`
+	Name        string            `json:"name"`
+	Phone       string            `json:"phone"`
+	Address     string            `json:"address"`
+	Gender      string            `json:"gender"`
+	Score       float64           `json:"score"`
+	Highlight   map[string]string `json:"highlight"`
+}
+
+type VisitResp struct {
+	Visit_uid        string            `json:"visit_uid"`
+	Date             string            `json:"date"`
+	Status           string            `json:"status"`
+	Complaint        string            `json:"complaint"`
+	MainDiagnose     string            `json:"mainDiagnose"`
+	AdditionDiagnose string            `json:"additionDiagnose"`
+	Doctor_uid       string            `json:"doctor_uid"`
+	DoctorName       string            `json:"doctorName"`
+	Patient_uid      string            `json:"patient_uid"`
+	PatientName      string            `json:"patientName"`
+	Score            float64           `json:"score"`
+	Highlight        map[string]string `json:"highlight"`
+}
@@ -0,0 +1,9 @@
+package search
+
+import "be/repository/search"
+
+type Search interface {
+	ValidationQuery(req Req) (search.Query, error)
+	Patients(q search.Query, rows []search.Patient) []PatientResp
+	Visits(q search.Query, rows []search.Visit) []VisitResp
+}
@@ -0,0 +1,166 @@
+package search
+
+import (
+	"be/repository/search"
+	"errors"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 50
+)
+
+type Logic struct{}
+
+func New() *Logic {
+	return &Logic{}
+}
+
+func (l *Logic) ValidationQuery(req Req) (search.Query, error) {
+
+	switch req.Type {
+	case "patient", "visit":
+	default:
+		return search.Query{}, errors.New("invalid type input")
+	}
+
+	var terms = search.Terms(req.Q)
+	if len(terms) == 0 {
+		return search.Query{}, errors.New("q needs a word of at least " + strconv.Itoa(search.MinTerm) + " letters or digits")
+	}
+
+	var q = search.Query{Terms: terms, Limit: DefaultLimit}
+
+	if req.Limit != "" {
+		var limit, err = strconv.Atoi(req.Limit)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return search.Query{}, errors.New("invalid limit")
+		}
+		q.Limit = limit
+	}
+
+	if req.Offset != "" {
+		var offset, err = strconv.Atoi(req.Offset)
+		if err != nil || offset < 0 {
+			return search.Query{}, errors.New("invalid offset")
+		}
+		q.Offset = offset
+	}
+
+	return q, nil
+}
+
+func (l *Logic) Patients(q search.Query, rows []search.Patient) []PatientResp {
+	var res = []PatientResp{}
+
+	for _, row := range rows {
+		res = append(res, PatientResp{
+			Patient_uid: row.Patient_uid,
+			Nik:         row.Nik,
+			Name:        row.Name,
+			Phone:       row.Phone,
+			Address:     row.Address,
+			Gender:      row.Gender,
+			Score:       round(row.Score),
+			Highlight:   highlights(q.Terms, map[string]string{"nik": row.Nik, "name": row.Name, "phone": row.Phone, "address": row.Address}),
+		})
+	}
+
+	return res
+}
+
+func (l *Logic) Visits(q search.Query, rows []search.Visit) []VisitResp {
+	var res = []VisitResp{}
+
+	for _, row := range rows {
+		res = append(res, VisitResp{
+			Visit_uid:        row.Visit_uid,
+			Date:             row.Date,
+			Status:           row.Status,
+			Complaint:        row.Complaint,
+			MainDiagnose:     row.MainDiagnose,
+			AdditionDiagnose: row.AdditionDiagnose,
+			Doctor_uid:       row.Doctor_uid,
+			DoctorName:       row.DoctorName,
+			Patient_uid:      row.Patient_uid,
+			PatientName:      row.PatientName,
+			Score:            round(row.Score),
+			Highlight:        highlights(q.Terms, map[string]string{"complaint": row.Complaint, "mainDiagnose": row.MainDiagnose, "additionDiagnose": row.AdditionDiagnose}),
+		})
+	}
+
+	return res
+}
+
+func round(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
+
+func highlights(terms []string, fields map[string]string) map[string]string {
+	var res = map[string]string{}
+
+	for name, text := range fields {
+		if marked, ok := Highlight(text, terms); ok {
+			res[name] = marked
+		}
+	}
+
+	return res
+}
+
+// Highlight escapes the text and puts the part of the words starting with one
+// of the terms in <em>, ok tells whether a word matched
+
+func Highlight(text string, terms []string) (string, bool) {
+	var b strings.Builder
+	var ok bool
+
+	var runes = []rune(text)
+	for i := 0; i < len(runes); {
+		var j = i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+
+		if j == i {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+
+		var word = runes[i:j]
+		var n = match(word, terms)
+		if n > 0 {
+			ok = true
+			b.WriteString("<em>" + html.EscapeString(string(word[:n])) + "</em>")
+		}
+		b.WriteString(html.EscapeString(string(word[n:])))
+		i = j
+	}
+
+	return b.String(), ok
+}
+
+// match is the length of the longest term the word starts with, in runes
+
+func match(word []rune, terms []string) int {
+	var lower = []rune(strings.ToLower(string(word)))
+	if len(lower) != len(word) {
+		lower = word
+	}
+
+	var longest int
+	for _, term := range terms {
+		var t = []rune(term)
+		if len(t) > longest && len(t) <= len(lower) && string(lower[:len(t)]) == term {
+			longest = len(t)
+		}
+	}
+
+	return longest
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"be/repository/search"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationQuery(t *testing.T) {
+	var l = New()
+
+	t.Run("success", func(t *testing.T) {
+		var q, err = l.ValidationQuery(Req{Q: "Budi  bogor", Type: "patient"})
+		assert.Nil(t, err)
+		assert.Equal(t, search.Query{Terms: []string{"budi", "bogor"}, Limit: DefaultLimit}, q)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		for message, req := range map[string]Req{
+			"invalid type input":                             {Q: "budi", Type: "doctor"},
+			"q needs a word of at least 3 letters or digits": {Q: "a b ' --", Type: "visit"},
+			"invalid limit":                                  {Q: "budi", Type: "visit", Limit: "51"},
+			"invalid offset":                                 {Q: "budi", Type: "visit", Offset: "-1"},
+		} {
+			var _, err = l.ValidationQuery(req)
+			assert.Equal(t, message, err.Error())
+		}
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	var res, ok = Highlight("Budiman <b>bin</b> Budi", []string{"budi", "bin"})
+	assert.True(t, ok)
+	assert.Equal(t, "<em>Budi</em>man &lt;b&gt;<em>bin</em>&lt;/b&gt; <em>Budi</em>", res)
+
+	_, ok = Highlight("Siti", []string{"budi"})
+	assert.False(t, ok)
+}
+
+func TestPatients(t *testing.T) {
+	var l = New()
+	var q = search.Query{Terms: []string{"0812", "mawar"}}
+
+	var res = l.Patients(q, []search.Patient{{Patient_uid: "budi", Name: "Budi", Phone: "081234567890", Address: "Jalan Mawar", Score: 1.23456}})
+	assert.Equal(t, 1.235, res[0].Score)
+	assert.Equal(t, map[string]string{"phone": "<em>0812</em>34567890", "address": "Jalan <em>Mawar</em>"}, res[0].Highlight)
+}
+
+func TestVisits(t *testing.T) {
+	var l = New()
+	var q = search.Query{Terms: []string{"demam"}}
+
+	var res = l.Visits(q, []search.Visit{{Visit_uid: "visit", Complaint: "demam tinggi", MainDiagnose: "Demam berdarah", AdditionDiagnose: "-"}})
+	assert.Equal(t, map[string]string{"complaint": "<em>demam</em> tinggi", "mainDiagnose": "<em>Demam</em> berdarah"}, res[0].Highlight)
+}
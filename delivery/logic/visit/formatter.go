@@ -2,7 +2,9 @@ package visit
 
 import (
 	"be/entities"
+	"be/repository/visit"
 	"errors"
+	"strings"
 	"time"
 
 	"gorm.io/datatypes"
@@ -57,3 +59,83 @@ func (r *Req) ToVisit() (*entities.Visit, error) {
 		Bmi:              r.Bmi,
 	}, nil
 }
+
+// Search is the query of the list of visits, kind and uid are kept for the
+// clients searching by one of them
+
+type Search struct {
+	Kind        string `query:"kind"`
+	Uid         string `query:"uid"`
+	Doctor_uid  string `query:"doctor_uid"`
+	Patient_uid string `query:"patient_uid"`
+	Nik         string `query:"nik"`
+	Status      string `query:"status"`
+	Date        string `query:"date"`
+	DateFrom    string `query:"date_from"`
+	DateTo      string `query:"date_to"`
+	Complaint   string `query:"complaint"`
+	Grouped     string `query:"grouped"`
+}
+
+func (s *Search) ToSearch() (visit.Search, error) {
+	var layout = "02-01-2006"
+
+	var res = visit.Search{Doctor_uid: s.Doctor_uid, Patient_uid: s.Patient_uid, Nik: s.Nik, Complaint: strings.TrimSpace(s.Complaint), Grouped: s.Grouped}
+
+	switch s.Kind {
+	case "":
+	case "patient":
+		res.Nik = s.Uid
+	case "doctor":
+		res.Doctor_uid = s.Uid
+	case "visit":
+		res.Visit_uid = s.Uid
+	default:
+		return visit.Search{}, errors.New("invalid kind input")
+	}
+
+	if s.Status != "" {
+		for _, status := range strings.Split(s.Status, ",") {
+			if _, ok := statueses[status]; !ok {
+				return visit.Search{}, errors.New("invalid status input")
+			}
+			res.Statuses = append(res.Statuses, status)
+		}
+	}
+
+	var dates = []struct {
+		value string
+		from  bool
+		to    bool
+	}{{s.Date, true, true}, {s.DateFrom, true, false}, {s.DateTo, false, true}}
+
+	for _, d := range dates {
+		if d.value == "" {
+			continue
+		}
+
+		var date, err = time.Parse(layout, d.value)
+		if err != nil {
+			return visit.Search{}, errors.New("invalid date format")
+		}
+
+		if d.from {
+			res.From = date
+		}
+		if d.to {
+			res.To = date
+		}
+	}
+
+	if !res.From.IsZero() && !res.To.IsZero() && res.From.After(res.To) {
+		return visit.Search{}, errors.New("date_from is after date_to")
+	}
+
+	switch s.Grouped {
+	case "", "patient", "doctor":
+	default:
+		return visit.Search{}, errors.New("invalid grouped input")
+	}
+
+	return res, nil
+}
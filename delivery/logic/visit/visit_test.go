@@ -1,6 +1,7 @@
 package visit
 
 import (
+	"be/repository/visit"
 	"testing"
 	"time"
 
@@ -90,3 +91,31 @@ func TestToVIsit(t *testing.T) {
 		log.Info(err)
 	})
 }
+
+func TestToSearch(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+
+		var req = Search{Kind: "patient", Uid: "3201010101010001", Status: "pending,ready", Date: "01-03-2022", Complaint: " batuk "}
+
+		res, err := req.ToSearch()
+
+		assert.Nil(t, err)
+		assert.Equal(t, visit.Search{Nik: "3201010101010001", Statuses: []string{"pending", "ready"}, From: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), Complaint: "batuk"}, res)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		for message, req := range map[string]Search{
+			"invalid kind input":         {Kind: "nurse"},
+			"invalid status input":       {Status: "pending,'ready'"},
+			"invalid date format":        {DateFrom: "2022-03-01"},
+			"date_from is after date_to": {DateFrom: "02-03-2022", DateTo: "01-03-2022"},
+			"invalid grouped input":      {Grouped: "nik"},
+		} {
+			_, err := req.ToSearch()
+
+			assert.Equal(t, message, err.Error())
+		}
+	})
+}
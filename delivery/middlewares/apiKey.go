@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// LabKeyMiddleware authenticates the lab staff with the shared key sent in
+// the X-Lab-Key header, an empty key rejects every request
+func LabKeyMiddleware(key string) echo.MiddlewareFunc {
+	return apiKey("X-Lab-Key", key)
+}
+
+// FhirKeyMiddleware authenticates the partner systems reading the FHIR api
+// with the key sent in the X-Fhir-Key header
+func FhirKeyMiddleware(key string) echo.MiddlewareFunc {
+	return apiKey("X-Fhir-Key", key)
+}
+
+// Hl7KeyMiddleware authenticates the lab analyser and the partner hospital
+// posting HL7 v2 messages over http with the key sent in the X-Hl7-Key header
+func Hl7KeyMiddleware(key string) echo.MiddlewareFunc {
+	return apiKey("X-Hl7-Key", key)
+}
+
+func apiKey(header, key string) echo.MiddlewareFunc {
+	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		KeyLookup: "header:" + header,
+		Validator: func(k string, c echo.Context) (bool, error) {
+			return key != "" && subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1, nil
+		},
+	})
+}
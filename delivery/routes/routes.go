@@ -1,10 +1,29 @@
 package routes
 
 import (
+	"be/configs"
+	"be/delivery/controllers/attachment"
 	"be/delivery/controllers/auth"
+	"be/delivery/controllers/bulk"
+	"be/delivery/controllers/calendar"
 	"be/delivery/controllers/doctor"
+	"be/delivery/controllers/document"
+	"be/delivery/controllers/export"
+	"be/delivery/controllers/fhir"
+	"be/delivery/controllers/files"
+	"be/delivery/controllers/freebusy"
 	"be/delivery/controllers/google"
+	"be/delivery/controllers/hl7"
+	"be/delivery/controllers/lab"
+	"be/delivery/controllers/note"
+	"be/delivery/controllers/notification"
 	"be/delivery/controllers/patient"
+	"be/delivery/controllers/reconcile"
+	"be/delivery/controllers/referral"
+	"be/delivery/controllers/report"
+	"be/delivery/controllers/schedule"
+	"be/delivery/controllers/search"
+	"be/delivery/controllers/upload"
 	"be/delivery/controllers/visit"
 	"be/delivery/middlewares"
 	"net/http"
@@ -13,8 +32,11 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
-func RoutesPath(e *echo.Echo, ac *auth.AuthController, dc *doctor.Controller, pc *patient.Controller, vc *visit.Controller, gc *google.Controller) {
-	e.Use(middleware.CORS())
+func RoutesPath(e *echo.Echo, ac *auth.AuthController, dc *doctor.Controller, pc *patient.Controller, vc *visit.Controller, gc *google.Controller, nc *note.Controller, atc *attachment.Controller, lc *lab.Controller, rc *referral.Controller, dcc *document.Controller, fc *fhir.Controller, ec *export.Controller, hc *hl7.Controller, bc *bulk.Controller, rpc *report.Controller, sc *schedule.Controller, sec *search.Controller, flc *files.Controller, upc *upload.Controller, occ *reconcile.Controller, cc *calendar.Controller, fbc *freebusy.Controller, ntc *notification.Controller) {
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		// the headers a tus client reads
+		ExposeHeaders: []string{"Location", "Tus-Resumable", "Upload-Offset", "Upload-Length"},
+	}))
 	e.Pre(middleware.RemoveTrailingSlash())
 	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
 		Format: "method=${method}, uri=${uri}, status=${status}",
@@ -38,10 +60,62 @@ func RoutesPath(e *echo.Echo, ac *auth.AuthController, dc *doctor.Controller, pc
 
 	e.POST("/patient", pc.Create())
 
-	// google
+	// google consent of a doctor comes back here, the state tells the doctor
+
+	e.GET("/google/callback", gc.GoogleCalendar())
+
+	// ics subscription of a doctor or patient, the token is the secret
+
+	e.GET("/calendar/feed/:token", cc.Feed())
+
+	// google pushes the changes of a connected calendar, the channel token
+	// is the secret
+
+	e.POST("/calendar/push", fbc.Push())
+
+	// document verification for employers
+
+	e.GET("/documents/verify/:code", dcc.Verify())
+
+	// files of the local storage, the private ones only with a signed url
+
+	e.GET("/files/private/*", flc.Private())
+	e.GET("/files/*", flc.Public())
+
+	// lab staff with lab key
+
+	var l = e.Group("/lab/orders")
+
+	l.Use(middlewares.LabKeyMiddleware(configs.GetConfig().LAB_API_KEY))
+
+	l.GET("", lc.GetByStatus())
+	l.PUT("/:order_uid/collected", lc.Collect())
+	l.POST("/:order_uid/results", lc.AddResults())
 
-	e.GET("/google/login", gc.GoogleLogin())
-	e.GET("google/callback", gc.GoogleCalendar())
+	// fhir read api for partner systems with fhir key
+
+	var fh = e.Group("/fhir")
+
+	fh.Use(middlewares.FhirKeyMiddleware(configs.GetConfig().FHIR_API_KEY))
+
+	fh.GET("/Patient", fc.SearchPatient())
+	fh.GET("/Patient/:id", fc.ReadPatient())
+	fh.GET("/Practitioner", fc.SearchPractitioner())
+	fh.GET("/Practitioner/:id", fc.ReadPractitioner())
+	fh.GET("/Encounter", fc.SearchEncounter())
+	fh.GET("/Encounter/:id", fc.ReadEncounter())
+	fh.GET("/Condition", fc.SearchCondition())
+	fh.GET("/Condition/:id", fc.ReadCondition())
+
+	// hl7 v2 http fallback for the lab analyser and partner hospital with hl7 key
+
+	var h = e.Group("/hl7")
+
+	h.Use(middlewares.Hl7KeyMiddleware(configs.GetConfig().HL7_API_KEY))
+
+	h.POST("", hc.Receive())
+	h.GET("/messages", hc.GetMessages())
+	h.POST("/messages/:message_uid/replay", hc.Replay())
 
 	// no jwt for check email or username
 
@@ -62,6 +136,7 @@ func RoutesPath(e *echo.Echo, ac *auth.AuthController, dc *doctor.Controller, pc
 	g.DELETE("/doctor", dc.Delete())
 	g.GET("/doctor/profile", dc.GetProfile())
 	g.GET("/doctor/all", dc.GetAll())
+	g.GET("/doctor/:doctor_uid/availability", fbc.Availability())
 
 	// patient ===================================
 
@@ -75,5 +150,113 @@ func RoutesPath(e *echo.Echo, ac *auth.AuthController, dc *doctor.Controller, pc
 	g.PUT("/visit/:visit_uid", vc.Update())
 	g.DELETE("/visit/:visit_uid", vc.Delete())
 	g.GET("/visit", vc.GetVisits())
+	g.GET("/visit/:visit_uid/calendar.ics", cc.Download())
+
+	// google calendar of the doctor
+
+	g.GET("/google/login", gc.GoogleLogin())
+	g.GET("/google/connection", gc.Status())
+	g.DELETE("/google/connection", gc.Disconnect())
+
+	// caldav calendar of the doctor and its busy times
+
+	g.POST("/calendar/caldav", fbc.ConnectCalDav())
+	g.POST("/calendar/busy/refresh", fbc.Refresh())
+
+	// calendar feed
+
+	g.POST("/calendar/feed", cc.CreateFeed())
+	g.DELETE("/calendar/feed", cc.DeleteFeed())
+
+	// calendar syncs for the admin
+
+	g.GET("/calendar/sync/dead", cc.GetDead())
+	g.POST("/visit/:visit_uid/calendar/sync", cc.Resync())
+
+	// notification channels of the user and the delivery log
+
+	g.GET("/notification/preference", ntc.GetPreference())
+	g.PUT("/notification/preference", ntc.SetPreference())
+	g.GET("/notification/deliveries", ntc.GetDeliveries())
+
+	// clinical note
+
+	g.POST("/visit/:visit_uid/note", nc.Create())
+	g.PUT("/visit/:visit_uid/note", nc.Update())
+	g.POST("/visit/:visit_uid/note/sign", nc.Sign())
+	g.POST("/visit/:visit_uid/note/amendment", nc.Amend())
+	g.GET("/visit/:visit_uid/note", nc.GetNote())
+
+	// attachment
+
+	g.POST("/visit/:visit_uid/attachments", atc.Create())
+	g.GET("/visit/:visit_uid/attachments", atc.GetAttachments())
+	g.GET("/visit/:visit_uid/attachments/:attachment_uid", atc.Download())
+	g.DELETE("/visit/:visit_uid/attachments/:attachment_uid", atc.Delete())
+	g.POST("/visit/:visit_uid/uploads", upc.Create())
+	g.HEAD("/visit/:visit_uid/uploads/:upload_uid", upc.Status())
+	g.PATCH("/visit/:visit_uid/uploads/:upload_uid", upc.Patch())
+	g.DELETE("/visit/:visit_uid/uploads/:upload_uid", upc.Delete())
+
+	// lab
+
+	g.POST("/visit/:visit_uid/lab", lc.Create())
+	g.GET("/visit/:visit_uid/lab", lc.GetOrders())
+	g.GET("/lab/results", lc.GetUnseen())
+	g.GET("/lab/results/:order_uid", lc.GetOrder())
+
+	// referral
+
+	g.POST("/visit/:visit_uid/referral", rc.Create())
+	g.GET("/referral", rc.GetReferrals())
+	g.GET("/referral/:referral_uid", rc.GetReferral())
+	g.PUT("/referral/:referral_uid/accept", rc.Accept())
+	g.PUT("/referral/:referral_uid/decline", rc.Decline())
+
+	// document
+
+	g.POST("/visit/:visit_uid/documents", dcc.Create())
+	g.GET("/visit/:visit_uid/documents", dcc.GetDocuments())
+	g.GET("/documents/:document_uid", dcc.Download())
+
+	// fhir import
+
+	g.POST("/import/fhir", fc.Import())
+
+	// medical record export
+
+	g.GET("/patient/:uid/export", ec.Export())
+	g.GET("/export/:export_uid", ec.Status())
+
+	// bulk import of patients and doctors
+
+	g.POST("/import/patients", bc.Preview("patient"))
+	g.POST("/import/doctors", bc.Preview("doctor"))
+	g.GET("/import/bulk/:bulk_uid", bc.GetBulk())
+	g.POST("/import/bulk/:bulk_uid/commit", bc.Commit())
+	g.GET("/import/bulk/:bulk_uid/report", bc.Report())
+
+	// clinic reports for the admin
+
+	g.GET("/report/visits", rpc.Visits())
+	g.GET("/report/patients", rpc.Patients())
+	g.GET("/report/diagnoses", rpc.Diagnoses())
+	g.GET("/report/demographics", rpc.Demographics())
+
+	// reports mailed on a schedule
+
+	g.POST("/report/schedules", sc.Create())
+	g.GET("/report/schedules", sc.GetSchedules())
+	g.PUT("/report/schedules/:schedule_uid", sc.Update())
+	g.DELETE("/report/schedules/:schedule_uid", sc.Delete())
+	g.GET("/report/schedules/:schedule_uid/runs", sc.GetRuns())
+
+	// full text search of patients and visits
+
+	g.GET("/search", sec.Search())
+
+	// stored files nothing refers to, a dry run for the admin
+
+	g.GET("/storage/orphans", occ.Orphans())
 
 }
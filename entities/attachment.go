@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Attachment struct {
+	ID             uint `gorm:"primaryKey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	Attachment_uid string         `gorm:"index;type:varchar(22)"`
+	Visit_uid      string         `gorm:"index;type:varchar(22)"`
+	Uploader_uid   string         `gorm:"index;type:varchar(22)"`
+	Type           string         `gorm:"type:enum('lab', 'imaging', 'referral', 'other');default:'other'"`
+	Description    string
+	FileName       string
+	ContentType    string `gorm:"type:varchar(100)"`
+	Size           int64
+	Checksum       string `gorm:"type:varchar(64)"`
+	Object_key     string `gorm:"type:varchar(255)"`
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Bulk struct {
+	ID            uint `gorm:"primaryKey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Bulk_uid      string         `gorm:"index;type:varchar(22)"`
+	Requester_uid string         `gorm:"index;type:varchar(22)"`
+	Kind          string         `gorm:"type:enum('patient', 'doctor');default:'patient'"`
+	FileName      string
+	Status        string `gorm:"type:enum('preview', 'committing', 'done');default:'preview'"`
+	Total         int
+	Valid         int
+	Invalid       int
+	Created       int
+	Failed        int
+	CommittedAt   *time.Time
+	Rows          []BulkRow `gorm:"foreignKey:Bulk_uid;references:Bulk_uid"`
+}
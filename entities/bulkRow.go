@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BulkRow struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	Bulk_uid   string         `gorm:"index;type:varchar(22)"`
+	Line       int
+	Data       string `gorm:"type:text"`
+	Status     string `gorm:"type:enum('valid', 'invalid', 'created', 'failed');default:'valid'"`
+	Error      string
+	Target_uid string `gorm:"type:varchar(22)"`
+}
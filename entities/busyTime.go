@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// BusyTime is a busy interval read from the calendar a doctor connected,
+// e.g. personal time, replaced on every import
+
+type BusyTime struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	Doctor_uid string    `gorm:"index;type:varchar(22)"`
+	StartAt    time.Time `gorm:"index"`
+	EndAt      time.Time
+}
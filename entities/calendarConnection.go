@@ -0,0 +1,40 @@
+package entities
+
+import "time"
+
+// CalendarConnection is the google account or caldav calendar a doctor
+// connected, the visits of the doctor are added to it and its busy times
+// block the booking. The oauth token or caldav password is sealed, an
+// expired one waits for the doctor to connect again. A google calendar
+// tells its changes to the push channel
+
+type CalendarConnection struct {
+	ID                 uint `gorm:"primaryKey"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Doctor_uid         string `gorm:"uniqueIndex;type:varchar(22)"`
+	Provider           string `gorm:"type:enum('google', 'caldav');default:'google'"`
+	Email              string
+	Calendar_id        string
+	Token              string `gorm:"type:text"`
+	Status             string `gorm:"type:enum('connected', 'expired');default:'connected'"`
+	ExpiredAt          *time.Time
+	Busy_synced_at     *time.Time
+	Channel_id         string `gorm:"index;type:varchar(64)"`
+	Channel_token      string `gorm:"type:varchar(64)"`
+	Channel_resource   string
+	Channel_expires_at *time.Time
+}
+
+// OauthState is a started google login of a doctor, used once by the
+// callback. Only the sha256 of the state is kept, the pkce verifier is
+// sealed
+
+type OauthState struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	State_hash string `gorm:"uniqueIndex;type:varchar(64)"`
+	Doctor_uid string `gorm:"type:varchar(22)"`
+	Verifier   string
+	ExpiresAt  time.Time `gorm:"index"`
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// CalendarFeed is the ics subscription of a doctor or patient, only the
+// sha256 of its token is kept so the url can't be read from the database
+
+type CalendarFeed struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Owner_uid  string `gorm:"uniqueIndex;type:varchar(22)"`
+	Kind       string `gorm:"type:enum('doctor', 'patient')"`
+	Token_hash string `gorm:"uniqueIndex;type:varchar(64)"`
+}
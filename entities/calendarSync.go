@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// CalendarSync is the outbox of the calendar, a visit change adds one in
+// the same transaction. The worker brings the event in line with the visit
+// as it is then, so running one twice does no harm. After the last attempt
+// it is dead until the admin syncs the visit again
+
+type CalendarSync struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Visit_uid   string `gorm:"index;type:varchar(22)"`
+	Status      string `gorm:"index;type:enum('pending', 'running', 'done', 'dead');default:'pending'"`
+	Attempts    int
+	Next_at     time.Time `gorm:"index"`
+	Last_error  string
+	Finished_at *time.Time
+}
@@ -16,7 +16,7 @@ type Doctor struct {
 	Email          string         `gorm:"index;not null;type:varchar(100)"`
 	Password       string         `gorm:"not null;type:varchar(100)"`
 	Name           string
-	Image          string `gorm:"default:'https://www.teralogistics.com/wp-content/uploads/2020/12/default.png'"`
+	Image          string // the key in the storage, empty for the default image
 	Address        string
 	Status         string `gorm:"type:enum('available', 'unAvailable');default:'available'"`
 	OpenDay        string `gorm:"type:enum('senin', 'selasa', 'rabu', 'kamis', 'jumat', 'sabtu', 'minggu');default:'senin'"`
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type Document struct {
+	ID            uint `gorm:"primaryKey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Document_uid  string         `gorm:"index;type:varchar(22)"`
+	Code          string         `gorm:"uniqueIndex;type:varchar(11)"`
+	Visit_uid     string         `gorm:"index;type:varchar(22)"`
+	Doctor_uid    string         `gorm:"index;type:varchar(22)"`
+	Patient_uid   string         `gorm:"index;type:varchar(22)"`
+	Kind          string         `gorm:"type:enum('sickLeave', 'fitness');default:'sickLeave'"`
+	StartDate     datatypes.Date
+	EndDate       datatypes.Date
+	Note          string
+	PatientName   string
+	Nik           string `gorm:"type:varchar(16)"`
+	Dob           datatypes.Date
+	DoctorName    string
+	DoctorAddress string
+	VisitDate     datatypes.Date
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Export struct {
+	ID            uint `gorm:"primaryKey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Export_uid    string         `gorm:"index;type:varchar(22)"`
+	Patient_uid   string         `gorm:"index;type:varchar(22)"`
+	Requester_uid string         `gorm:"index;type:varchar(22)"`
+	Format        string         `gorm:"type:enum('pdf', 'json', 'fhir');default:'pdf'"`
+	Status        string         `gorm:"type:enum('queued', 'running', 'done', 'failed');default:'queued'"`
+	FileName      string
+	ContentType   string
+	Size          int64
+	Checksum      string `gorm:"type:varchar(64)"`
+	Object_key    string
+	Error         string
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Hl7Message struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Message_uid string         `gorm:"index;type:varchar(22)"`
+	Control_id  string         `gorm:"index;type:varchar(50)"`
+	Sender      string         `gorm:"index;type:varchar(100)"`
+	Type        string         `gorm:"type:varchar(10)"`
+	Event       string         `gorm:"type:varchar(10)"`
+	Source      string         `gorm:"type:enum('mllp', 'http');default:'http'"`
+	Raw         string         `gorm:"type:mediumtext"`
+	Status      string         `gorm:"type:enum('received', 'processed', 'failed');default:'received'"`
+	Target      string
+	Error       string
+	Attempts    int
+	ProcessedAt *time.Time
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LabOrder struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Order_uid   string         `gorm:"index;type:varchar(22)"`
+	Visit_uid   string         `gorm:"index;type:varchar(22)"`
+	Doctor_uid  string         `gorm:"index;type:varchar(22)"`
+	Tests       string
+	Note        string
+	Status      string `gorm:"type:enum('ordered', 'collected', 'resulted', 'cancelled');default:'ordered'"`
+	Seen        bool   `gorm:"default:false"`
+	CollectedAt *time.Time
+	ResultedAt  *time.Time
+	Results     []LabResult `gorm:"foreignKey:Order_uid;references:Order_uid"`
+}
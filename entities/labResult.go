@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LabResult struct {
+	ID             uint `gorm:"primaryKey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	Order_uid      string         `gorm:"index;type:varchar(22)"`
+	Analyte        string         `gorm:"not null;type:varchar(100)"`
+	Value          string         `gorm:"type:varchar(100)"`
+	Unit           string         `gorm:"type:varchar(50)"`
+	ReferenceRange string         `gorm:"type:varchar(100)"`
+	Flag           string         `gorm:"type:enum('N', 'L', 'H', 'LL', 'HH', 'A');default:'N'"`
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Note struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	Note_uid   string         `gorm:"index;type:varchar(22)"`
+	Visit_uid  string         `gorm:"index;uniqueIndex:idx_note_version;type:varchar(22)"`
+	Author_uid string         `gorm:"index;type:varchar(22)"`
+	Version    int            `gorm:"uniqueIndex:idx_note_version"`
+	Kind       string         `gorm:"type:enum('original', 'amendment');default:'original'"`
+	Status     string         `gorm:"type:enum('draft', 'signed');default:'draft'"`
+	Subjective string         `gorm:"type:text"`
+	Objective  string         `gorm:"type:text"`
+	Assessment string         `gorm:"type:text"`
+	Plan       string         `gorm:"type:text"`
+	Reason     string
+	SignedAt   *time.Time
+}
@@ -0,0 +1,52 @@
+package entities
+
+import "time"
+
+// Notification is the outbox of the messages to a doctor or patient, a visit
+// or lab change adds one in the same transaction. The dedup key makes a message
+// sent once, e.g. one reminder of a visit. A channel already sent is not
+// sent again when a retry runs
+
+type Notification struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Dedup_key   string `gorm:"uniqueIndex;type:varchar(100)"`
+	Kind        string `gorm:"type:enum('booking_confirmed', 'reminder', 'cancelled', 'results_ready')"`
+	User_uid    string `gorm:"index;type:varchar(22)"`
+	Visit_uid   string `gorm:"type:varchar(22)"`
+	Status      string `gorm:"index;type:enum('pending', 'running', 'done', 'dead');default:'pending'"`
+	Attempts    int
+	Next_at     time.Time `gorm:"index"`
+	Last_error  string
+	Finished_at *time.Time
+}
+
+// NotificationPreference is the channels a user gets the messages on, the
+// phone is for sms and whatsapp, the phone of the patient when empty
+
+type NotificationPreference struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	User_uid  string `gorm:"uniqueIndex;type:varchar(22)"`
+	Email     bool
+	Sms       bool
+	Whatsapp  bool
+	Phone     string `gorm:"type:varchar(20)"`
+}
+
+// NotificationDelivery is an attempt to send a notification on a channel,
+// skipped when the user has no address for it
+
+type NotificationDelivery struct {
+	ID              uint `gorm:"primaryKey"`
+	CreatedAt       time.Time
+	Notification_id uint   `gorm:"index"`
+	User_uid        string `gorm:"index;type:varchar(22)"`
+	Kind            string `gorm:"type:varchar(30)"`
+	Channel         string `gorm:"type:enum('email', 'sms', 'whatsapp')"`
+	Recipient       string `gorm:"type:varchar(100)"`
+	Status          string `gorm:"type:enum('sent', 'failed', 'skipped')"`
+	Error           string
+}
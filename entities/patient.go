@@ -15,12 +15,13 @@ type Patient struct {
 	UserName    string         `gorm:"index;not null;type:varchar(100)"`
 	Email       string         `gorm:"index;not null;type:varchar(100)"`
 	Password    string         `gorm:"not null;type:varchar(100)"`
-	Nik         string         `gorm:"type:varchar(16)"`
-	Name        string
-	Image       string `gorm:"default:'https://www.teralogistics.com/wp-content/uploads/2020/12/default.png'"`
-	Gender      string `gorm:"type:enum('pria', 'wanita', 'lainnya');default:'lainnya'"`
-	Address     string `gorm:"not null"`
-	PlaceBirth  string `gorm:"type:varchar(100)"`
+	Nik         string         `gorm:"type:varchar(16);index:idx_patients_search,class:FULLTEXT"`
+	Name        string         `gorm:"index:idx_patients_search,class:FULLTEXT"`
+	Phone       string         `gorm:"type:varchar(20);index:idx_patients_search,class:FULLTEXT"`
+	Image       string         // the key in the storage, empty for the default image
+	Gender      string         `gorm:"type:enum('pria', 'wanita', 'lainnya');default:'lainnya'"`
+	Address     string         `gorm:"not null;index:idx_patients_search,class:FULLTEXT"`
+	PlaceBirth  string         `gorm:"type:varchar(100)"`
 	Dob         datatypes.Date
 	Job         string
 	Status      string  `gorm:"type:enum('belumKawin', 'kawin', 'ceraiHidup', 'ceraiMati', 'lainnya');default:'lainnya'"`
@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Referral struct {
+	ID               uint `gorm:"primaryKey"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+	Referral_uid     string         `gorm:"index;type:varchar(22)"`
+	Source_visit_uid string         `gorm:"index;type:varchar(22)"`
+	Target_visit_uid string         `gorm:"index;type:varchar(22)"`
+	From_doctor_uid  string         `gorm:"index;type:varchar(22)"`
+	To_doctor_uid    string         `gorm:"index;type:varchar(22)"`
+	Patient_uid      string         `gorm:"index;type:varchar(22)"`
+	Reason           string
+	Urgency          string `gorm:"type:enum('routine', 'urgent', 'emergency');default:'routine'"`
+	Status           string `gorm:"type:enum('sent', 'accepted', 'declined', 'completed');default:'sent'"`
+	Response         string
+	RespondedAt      *time.Time
+}
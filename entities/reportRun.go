@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// ReportRun is one delivery of a schedule, a schedule has one run per slot
+
+type ReportRun struct {
+	ID           uint `gorm:"primaryKey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Run_uid      string    `gorm:"index;type:varchar(22)"`
+	Schedule_uid string    `gorm:"uniqueIndex:idx_schedule_slot;type:varchar(22)"`
+	ScheduledAt  time.Time `gorm:"uniqueIndex:idx_schedule_slot"`
+	Status       string    `gorm:"type:enum('running', 'sent', 'failed');default:'running'"`
+	Attempts     int
+	Error        string
+	NextRetryAt  *time.Time `gorm:"index"`
+	FinishedAt   *time.Time
+}
@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReportSchedule is a report mailed to the clinic admin, Cron is read in the
+// Timezone of the clinic
+
+type ReportSchedule struct {
+	ID           uint `gorm:"primaryKey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	Schedule_uid string         `gorm:"index;type:varchar(22)"`
+	Admin_uid    string         `gorm:"index;type:varchar(22)"`
+	Doctor_uid   string         `gorm:"index;type:varchar(22)"`
+	Kind         string         `gorm:"type:enum('appointments', 'monthly');default:'appointments'"`
+	Cron         string         `gorm:"type:varchar(100)"`
+	Timezone     string         `gorm:"type:varchar(50);default:'Asia/Jakarta'"`
+	Email        string         `gorm:"type:varchar(100)"`
+	Active       bool           `gorm:"default:true"`
+	NextRunAt    time.Time      `gorm:"index"`
+	LastRunAt    *time.Time
+	Runs         []ReportRun `gorm:"foreignKey:Schedule_uid;references:Schedule_uid"`
+}
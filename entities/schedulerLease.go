@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// SchedulerLease is held by the one replica running the scheduler until it expires
+
+type SchedulerLease struct {
+	Name      string `gorm:"primaryKey;type:varchar(50)"`
+	Holder    string `gorm:"type:varchar(100)"`
+	ExpiresAt time.Time
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Upload is a resumable upload of an attachment, the file is sent in parts
+// and the attachment is made when the last one arrives
+
+type Upload struct {
+	ID                uint `gorm:"primaryKey"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+	Upload_uid        string         `gorm:"index;type:varchar(22)"`
+	Visit_uid         string         `gorm:"index;type:varchar(22)"`
+	Uploader_uid      string         `gorm:"index;type:varchar(22)"`
+	Type              string         `gorm:"type:enum('lab', 'imaging', 'referral', 'other');default:'other'"`
+	Description       string
+	FileName          string
+	ContentType       string `gorm:"type:varchar(100)"`
+	Length            int64
+	Received          int64
+	Checksum          string `gorm:"type:varchar(64)"`
+	Object_key        string `gorm:"type:varchar(255)"`
+	Storage_upload_id string `gorm:"type:varchar(255)"`
+	Parts             string `gorm:"type:text"`
+	Hash              []byte `gorm:"type:blob"`
+	Status            string `gorm:"type:enum('uploading', 'done', 'failed');default:'uploading'"`
+	Attachment_uid    string `gorm:"type:varchar(22)"`
+}
@@ -18,9 +18,9 @@ type Visit struct {
 	Patient_uid      string         `gorm:"index;type:varchar(22)"`
 	Date             datatypes.Date
 	Status           string `gorm:"type:enum('pending', 'ready', 'completed', 'cancelled');default:'pending'"`
-	Complaint        string
-	MainDiagnose     string
-	AdditionDiagnose string
+	Complaint        string `gorm:"index:idx_visits_search,class:FULLTEXT"`
+	MainDiagnose     string `gorm:"index:idx_visits_search,class:FULLTEXT"`
+	AdditionDiagnose string `gorm:"index:idx_visits_search,class:FULLTEXT"`
 	Action           string
 	Recipe           string
 	BloodPressure    string
@@ -2,27 +2,88 @@ package main
 
 import (
 	"be/api"
-	"be/api/aws"
-	"be/api/aws/s3"
+	"be/api/storage"
+	"be/api/mail"
+	"be/api/notify"
+	"be/api/scan"
 	googleApi "be/api/google"
-	"be/api/google/calendar"
+	"be/api/calendar"
 	"be/configs"
+	"be/delivery/controllers/attachment"
 	"be/delivery/controllers/auth"
+	"be/delivery/controllers/bulk"
+	calendarCont "be/delivery/controllers/calendar"
 	"be/delivery/controllers/doctor"
+	"be/delivery/controllers/document"
+	"be/delivery/controllers/export"
+	"be/delivery/controllers/fhir"
+	"be/delivery/controllers/freebusy"
+	"be/delivery/controllers/files"
 	"be/delivery/controllers/google"
+	"be/delivery/controllers/hl7"
+	"be/delivery/controllers/lab"
+	"be/delivery/controllers/note"
+	"be/delivery/controllers/notification"
 	"be/delivery/controllers/patient"
+	"be/delivery/controllers/reconcile"
+	"be/delivery/controllers/referral"
+	"be/delivery/controllers/report"
+	"be/delivery/controllers/schedule"
+	"be/delivery/controllers/search"
+	"be/delivery/controllers/upload"
 	"be/delivery/controllers/visit"
+	hl7Ingest "be/delivery/hl7"
+	bulkJob "be/delivery/jobs/bulk"
+	calendarJob "be/delivery/jobs/calendar"
+	freebusyJob "be/delivery/jobs/freebusy"
+	exportJob "be/delivery/jobs/export"
+	notificationJob "be/delivery/jobs/notification"
+	reconcileJob "be/delivery/jobs/reconcile"
+	scheduleJob "be/delivery/jobs/schedule"
 	"be/delivery/routes"
+	attachmentRepo "be/repository/attachment"
 	authRepo "be/repository/auth"
+	bulkRepo "be/repository/bulk"
+	calendarRepo "be/repository/calendar"
 	doctorRepo "be/repository/doctor"
+	documentRepo "be/repository/document"
+	exportRepo "be/repository/export"
+	hl7Repo "be/repository/hl7"
+	fhirRepo "be/repository/fhir"
+	labRepo "be/repository/lab"
+	noteRepo "be/repository/note"
+	notificationRepo "be/repository/notification"
+	reconcileRepo "be/repository/reconcile"
 	patientRepo "be/repository/patient"
+	referralRepo "be/repository/referral"
+	reportRepo "be/repository/report"
+	scheduleRepo "be/repository/schedule"
+	searchRepo "be/repository/search"
+	uploadRepo "be/repository/upload"
 	visitRepo "be/repository/visit"
+	logicAttachment "be/delivery/logic/attachment"
+	logicBulk "be/delivery/logic/bulk"
 	logicDoctor "be/delivery/logic/doctor"
+	logicDocument "be/delivery/logic/document"
+	logicExport "be/delivery/logic/export"
+	logicFhir "be/delivery/logic/fhir"
+	logicLab "be/delivery/logic/lab"
+	logicNote "be/delivery/logic/note"
 	logicPatient "be/delivery/logic/patient"
+	logicReferral "be/delivery/logic/referral"
+	logicReport "be/delivery/logic/report"
+	logicSchedule "be/delivery/logic/schedule"
+	logicSearch "be/delivery/logic/search"
 	logicVisit "be/delivery/logic/visit"
 
 	"be/utils"
+	"be/utils/crypt"
+	"be/utils/pdf"
+	"be/utils/photo"
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
@@ -31,36 +92,224 @@ import (
 func main() {
 	var config = configs.GetConfig()
 	var db = utils.InitDB(config)
-	var awsS3Conf = aws.InitS3(config.S3_REGION, config.S3_ID, config.S3_SECRET)
 
 	var googleConf = googleApi.SetupConfig(config.DB_USERNAME, config.CLIENT_ID, config.CLIENT_SECRET)
 
-	var awsS3 = s3.New(awsS3Conf)
+	// files are kept on s3, minio or a local directory served by the app
 
-	var b, token = api.TokenInit(configs.CredentialPath, configs.TokenPath, googleConf)
+	var storageConf = storage.Config{
+		Driver:    config.STORAGE_DRIVER,
+		Bucket:    config.S3_BUCKET,
+		Region:    config.S3_REGION,
+		Id:        config.S3_ID,
+		Secret:    config.S3_SECRET,
+		Endpoint:  config.S3_ENDPOINT,
+		PublicUrl: config.STORAGE_PUBLIC_URL,
+		Acl:       config.S3_ACL,
+		Dir:       config.STORAGE_DIR,
+		SignKey:   config.STORAGE_SIGN_KEY,
+	}
+	if storageConf.Driver == "local" && storageConf.PublicUrl == "" {
+		storageConf.PublicUrl = config.BASE_URL + "/files"
+	}
 
-	var srv = googleApi.InitCalendar(b, token)
+	var store, err = storage.New(storageConf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var server, _ = store.(storage.Server)
+
+	// uploads are scanned by clamd. SCAN=local only finds the eicar test file,
+	// for development, without either the uploads are refused
+
+	var scanner scan.Scanner = scan.NewNone()
+	switch {
+	case config.CLAMD_ADDRESS != "":
+		scanner = scan.NewClamd(config.CLAMD_ADDRESS, 30*time.Second)
+	case config.SCAN == "local":
+		log.Warn("SCAN=local, uploads are only scanned for the eicar test file")
+		scanner = scan.NewLocal()
+	default:
+		log.Warn("no CLAMD_ADDRESS, uploads are refused")
+	}
+	var guard = scan.NewGuard(scanner, store)
+	var photos = photo.New(store, guard)
+	var filesCont = files.New(server)
+
+	// the visits are kept in google calendar, a caldav server or only in the
+	// ics downloads and feeds
+
+	var provider calendar.Provider = calendar.NewNoop()
+	switch config.CALENDAR_PROVIDER {
+	case "caldav":
+		provider = calendar.NewCalDav(config.CALDAV_URL, config.CALDAV_USERNAME, config.CALDAV_PASSWORD)
+	case "none":
+	default:
+		var b, token = api.TokenInit(configs.CredentialPath, configs.TokenPath, googleConf)
+
+		if srv, err := googleApi.InitCalendar(b, token); err == nil {
+			provider = calendar.NewGoogle(srv, config.GOOGLE_CALENDAR_ID)
+		} else {
+			log.Warn("no google calendar, visits are only in the ics feeds: ", err)
+		}
+	}
+
+	// a doctor who connected google gets the visits in their own calendar,
+	// the token sealed with CALENDAR_TOKEN_KEY, required so the tokens are
+	// still read after a restart
+
+	var cipher, errCipher = crypt.New(config.CALENDAR_TOKEN_KEY)
+	if errCipher != nil {
+		log.Fatal("CALENDAR_TOKEN_KEY: ", errCipher)
+	}
+
+	var calendarRepo = calendarRepo.New(db)
+	var doctors = calendar.NewDoctors(calendarRepo, googleConf, cipher, provider)
+	if config.CALENDAR_PROVIDER != "caldav" && config.CALENDAR_PROVIDER != "none" {
+		provider = doctors
+	}
+
+	// the busy times of the connected calendars block the booking, imported
+	// by the holder of the lease and pushed by google
+
+	var scheduleRepo = scheduleRepo.New(db)
+	var freebusyJob = freebusyJob.New(calendarRepo, doctors, scheduleRepo, config.BASE_URL)
+	freebusyJob.Start()
+	var freebusyCont = freebusy.New(calendarRepo, freebusyJob, cipher)
 
 	var authRepo = authRepo.New(db)
 	var authCont = auth.New(authRepo)
 
 	var doctorRepo = doctorRepo.New(db)
 	var doctorLogic = logicDoctor.New()
-	var doctorCont = doctor.New(doctorRepo, awsS3, doctorLogic)
+	var doctorCont = doctor.New(doctorRepo, photos, doctorLogic)
 
 	var patientRepo = patientRepo.New(db)
 	var patientLogic = logicPatient.New()
-	var patientCont = patient.New(patientRepo, awsS3, patientLogic)
+	var patientCont = patient.New(patientRepo, photos, patientLogic)
 
 	var visitRepo = visitRepo.New(db)
-	var calendar = calendar.New(visitRepo, srv)
 	var visitLogic = logicVisit.New()
-	var visitCont = visit.New(visitRepo, calendar, visitLogic)
-	var googleCont = google.New(googleConf, visitRepo)
+
+	// the visit changes leave calendar syncs in the outbox, run and retried
+	// by the job
+
+	var calendarJob = calendarJob.New(calendarRepo, provider)
+	calendarJob.Start()
+	var visitCont = visit.New(visitRepo, calendarJob, visitLogic)
+	var calendarCont = calendarCont.New(calendarRepo, calendarJob, config.BASE_URL)
+	var googleCont = google.New(googleConf, calendarRepo, cipher, freebusyJob)
+
+	var noteRepo = noteRepo.New(db)
+	var noteLogic = logicNote.New()
+	var noteCont = note.New(noteRepo, noteLogic)
+
+	var attachmentRepo = attachmentRepo.New(db)
+	var attachmentLogic = logicAttachment.New()
+	var attachmentCont = attachment.New(attachmentRepo, store, guard, attachmentLogic)
+	var uploadRepo = uploadRepo.New(db)
+	var uploadCont = upload.New(uploadRepo, attachmentRepo, store, guard, attachmentLogic)
+
+	var labRepo = labRepo.New(db)
+	var labLogic = logicLab.New()
+	var labCont = lab.New(labRepo, labLogic)
+
+	var referralRepo = referralRepo.New(db)
+	var referralLogic = logicReferral.New()
+	var referralCont = referral.New(referralRepo, referralLogic)
+
+	var documentRepo = documentRepo.New(db)
+	var documentLogic = logicDocument.New()
+	var documentCont = document.New(documentRepo, pdf.New(), documentLogic)
+
+	var fhirRepo = fhirRepo.New(db)
+	var fhirLogic = logicFhir.New()
+	var fhirCont = fhir.New(fhirRepo, fhirLogic)
+
+	var exportRepo = exportRepo.New(db)
+	var exportLogic = logicExport.New(pdf.New(), config.BASE_URL+"/fhir")
+	var exportJob = exportJob.New(exportRepo, exportLogic, store)
+	exportJob.Start(2)
+	var exportCont = export.New(exportRepo, store, exportJob, exportLogic)
+
+	var hl7Repo = hl7Repo.New(db)
+	var hl7Handler = hl7Ingest.New(hl7Repo)
+	var hl7Cont = hl7.New(hl7Repo, hl7Handler)
+
+	// the mllp listener has no authentication, it only takes the senders of
+	// HL7_ALLOW, or of this host when none is allowed
+
+	if config.HL7_PORT != 0 {
+		var allow, errAllow = hl7Ingest.ParseAllow(config.HL7_ALLOW)
+		if errAllow != nil {
+			log.Fatal(errAllow)
+		}
+
+		go func() {
+			log.Error(hl7Ingest.NewServer(hl7Handler, allow).Listen(net.JoinHostPort(config.HL7_BIND, strconv.Itoa(config.HL7_PORT))))
+		}()
+	}
+
+	var bulkRepo = bulkRepo.New(db)
+	var bulkLogic = logicBulk.New()
+	var bulkJob = bulkJob.New(bulkRepo, bulkLogic, scheduleRepo)
+	bulkJob.Start()
+	var bulkCont = bulk.New(bulkRepo, bulkJob, bulkLogic)
+
+	var reportRepo = reportRepo.New(db)
+	var reportLogic = logicReport.New()
+	var reportCont = report.New(reportRepo, reportLogic)
+
+	// reports and notifications are mailed through smtp, only logged when no
+	// server is configured
+
+	var mailer mail.Mailer = mail.NewLog()
+	if config.SMTP_HOST != "" {
+		mailer = mail.New(config.SMTP_HOST, config.SMTP_PORT, config.SMTP_USERNAME, config.SMTP_PASSWORD, config.MAIL_FROM)
+	}
+
+	// the notifications of the visits and lab results go out on the channels
+	// each user chose, an sms or whatsapp api not configured is only logged
+
+	var channels = map[string]notify.Channel{"email": notify.NewEmail(mailer), "sms": notify.NewLog("sms"), "whatsapp": notify.NewLog("whatsapp")}
+	if config.SMS_URL != "" {
+		channels["sms"] = notify.NewSms(config.SMS_URL, config.SMS_API_KEY, config.SMS_FROM)
+	}
+	if config.WHATSAPP_URL != "" {
+		channels["whatsapp"] = notify.NewWhatsApp(config.WHATSAPP_URL, config.WHATSAPP_TOKEN, config.WHATSAPP_LANGUAGE)
+	}
+
+	var notificationRepo = notificationRepo.New(db)
+	var notificationJob = notificationJob.New(notificationRepo, channels, scheduleRepo)
+	notificationJob.Start()
+	var notificationCont = notification.New(notificationRepo)
+
+	var scheduleLogic = logicSchedule.New()
+	var scheduleJob = scheduleJob.New(scheduleRepo, reportRepo, scheduleLogic, reportLogic, mailer)
+	scheduleJob.Start()
+	var scheduleCont = schedule.New(scheduleRepo, scheduleLogic)
+
+	// stored files nothing refers to are reported, or with STORAGE_GC=delete
+	// removed, once they are older than the grace period
+
+	var grace, errGrace = time.ParseDuration(config.STORAGE_GC_GRACE)
+	if errGrace != nil || grace <= 0 {
+		grace = 72 * time.Hour
+	}
+	var reconcileJob = reconcileJob.New(reconcileRepo.New(db), store, scheduleRepo, grace, config.STORAGE_GC != "delete")
+	if config.STORAGE_GC != "off" {
+		reconcileJob.Start()
+	}
+	var reconcileCont = reconcile.New(reconcileJob)
+
+	var searchRepo = searchRepo.New(db)
+	var searchLogic = logicSearch.New()
+	var searchCont = search.New(searchRepo, searchLogic)
 
 	var e = echo.New()
 
-	routes.RoutesPath(e, authCont, doctorCont, patientCont, visitCont, googleCont)
+	routes.RoutesPath(e, authCont, doctorCont, patientCont, visitCont, googleCont, noteCont, attachmentCont, labCont, referralCont, documentCont, fhirCont, exportCont, hl7Cont, bulkCont, reportCont, scheduleCont, searchCont, filesCont, uploadCont, reconcileCont, calendarCont, freebusyCont, notificationCont)
 
 	log.Fatal(e.Start(fmt.Sprintf(":%d", config.PORT)))
 
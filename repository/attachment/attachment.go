@@ -0,0 +1,131 @@
+package attachment
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"be/utils/list"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) CheckAccess(visit_uid, user_uid string) error {
+	return visit.CheckAccess(r.db, visit_uid, user_uid)
+}
+
+func (r *Repo) CheckDoctor(visit_uid, doctor_uid string) error {
+	return visit.CheckDoctor(r.db, visit_uid, doctor_uid)
+}
+
+func (r *Repo) Create(req entities.Attachment) (entities.Attachment, error) {
+
+	var uid string
+
+	for {
+		uid = shortuuid.New()
+		var find = entities.Attachment{}
+		var res = r.db.Model(&entities.Attachment{}).Where("attachment_uid = ?", uid).Find(&find)
+		if res.RowsAffected == 0 {
+			break
+		}
+	}
+
+	req.Attachment_uid = uid
+
+	if res := r.db.Model(&entities.Attachment{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Attachment{}, res.Error
+	}
+
+	return req, nil
+}
+
+// Delete removes the attachment when the user uploaded it or is the doctor
+// of the visit, for others it is not found
+func (r *Repo) Delete(visit_uid, attachment_uid, user_uid string) (entities.Attachment, error) {
+
+	var resInit entities.Attachment
+
+	var doctor = r.db.Model(&entities.Visit{}).Where("visit_uid = ? and doctor_uid = ?", visit_uid, user_uid).Select("visit_uid")
+
+	if res := r.db.Model(&entities.Attachment{}).Where("visit_uid = ? and attachment_uid = ? and (uploader_uid = ? or visit_uid in (?))", visit_uid, attachment_uid, user_uid, doctor).Find(&resInit); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Attachment{}, gorm.ErrRecordNotFound
+	}
+
+	if res := r.db.Model(&entities.Attachment{}).Where("attachment_uid = ?", attachment_uid).Delete(&resInit); res.Error != nil || res.RowsAffected == 0 {
+		log.Warn(res.Error)
+		return entities.Attachment{}, gorm.ErrRecordNotFound
+	}
+
+	return resInit, nil
+}
+
+func (r *Repo) GetAttachment(visit_uid, attachment_uid string) (entities.Attachment, error) {
+
+	var attachment entities.Attachment
+
+	if res := r.db.Model(&entities.Attachment{}).Where("visit_uid = ? and attachment_uid = ?", visit_uid, attachment_uid).Find(&attachment); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Attachment{}, gorm.ErrRecordNotFound
+	}
+
+	return attachment, nil
+}
+
+// List is what the attachments of a visit can be sorted and filtered by
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"createdAt": {Column: "created_at", Type: list.Date},
+		"fileName":  {Column: "file_name"},
+		"size":      {Column: "size", Type: list.Int},
+	},
+	Sort: "-createdAt",
+	Key:  "id",
+	Filters: map[string]list.Field{
+		"type":         {Column: "type", Type: list.Enum, Values: []string{"lab", "imaging", "referral", "other"}},
+		"uploader_uid": {Column: "uploader_uid", Type: list.String},
+		"fileName":     {Column: "file_name", Type: list.Search},
+		"created":      {Column: "date(created_at)", Type: list.Date},
+	},
+}
+
+func (r *Repo) GetAttachments(visit_uid string, q list.Query) (Attachments, list.Page, error) {
+
+	var db = q.Filter(r.db.Model(&entities.Attachment{}).Where("visit_uid = ?", visit_uid))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return Attachments{}, list.Page{}, err
+	}
+
+	var attachments = Attachments{Attachments: []AttachmentResp{}}
+
+	if res := q.Page(db).Select("attachment_uid as Attachment_uid, visit_uid as Visit_uid, type as Type, description as Description, file_name as FileName, content_type as ContentType, size as Size, checksum as Checksum, uploader_uid as Uploader_uid, date_format(created_at, '%d-%m-%Y %H:%i') as CreatedAt" + q.Select()).Find(&attachments.Attachments); res.Error != nil {
+		log.Warn(res.Error)
+		return Attachments{}, list.Page{}, res.Error
+	}
+
+	var more = len(attachments.Attachments) > q.Limit
+	if more {
+		attachments.Attachments = attachments.Attachments[:q.Limit]
+	}
+
+	var last list.Row
+	if len(attachments.Attachments) != 0 {
+		last = attachments.Attachments[len(attachments.Attachments)-1].Row
+	}
+
+	return attachments, q.Result(total, more, last), nil
+}
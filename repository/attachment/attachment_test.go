@@ -0,0 +1,94 @@
+package attachment
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"be/utils/list"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachment(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Attachment{})
+	db.AutoMigrate(&entities.Attachment{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick"})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	t.Run("check access", func(t *testing.T) {
+		assert.Nil(t, r.CheckAccess(res2.Visit_uid, res.Doctor_uid))
+		assert.Nil(t, r.CheckAccess(res2.Visit_uid, res1.Patient_uid))
+		assert.NotNil(t, r.CheckAccess(res2.Visit_uid, shortuuid.New()))
+
+		assert.Nil(t, r.CheckDoctor(res2.Visit_uid, res.Doctor_uid))
+		assert.NotNil(t, r.CheckDoctor(res2.Visit_uid, res1.Patient_uid))
+	})
+
+	t.Run("success create, get and delete", func(t *testing.T) {
+		var res3, err3 = r.Create(entities.Attachment{Visit_uid: res2.Visit_uid, Uploader_uid: res.Doctor_uid, Type: "lab", Object_key: "attachments/key", Checksum: "abc"})
+		assert.Nil(t, err3)
+		assert.NotEqual(t, "", res3.Attachment_uid)
+
+		var q, _ = list.Parse(List, url.Values{"type": {"lab"}})
+		var res4, page, err4 = r.GetAttachments(res2.Visit_uid, q)
+		assert.Nil(t, err4)
+		assert.Equal(t, 1, len(res4.Attachments))
+		assert.Equal(t, int64(1), page.Total)
+
+		var res5, err5 = r.GetAttachment(res2.Visit_uid, res3.Attachment_uid)
+		assert.Nil(t, err5)
+		assert.Equal(t, "attachments/key", res5.Object_key)
+
+		var _, err7 = r.Delete(res2.Visit_uid, res3.Attachment_uid, res1.Patient_uid)
+		assert.NotNil(t, err7)
+
+		var res6, err6 = r.Delete(res2.Visit_uid, res3.Attachment_uid, res.Doctor_uid)
+		assert.Nil(t, err6)
+		assert.Equal(t, true, res6.DeletedAt.Valid)
+
+		_, err5 = r.GetAttachment(res2.Visit_uid, res3.Attachment_uid)
+		assert.NotNil(t, err5)
+	})
+
+	t.Run("delete by the uploader", func(t *testing.T) {
+		var res3, err3 = r.Create(entities.Attachment{Visit_uid: res2.Visit_uid, Uploader_uid: res1.Patient_uid, Type: "lab", Object_key: "attachments/key", Checksum: "abc"})
+		assert.Nil(t, err3)
+
+		var _, err4 = r.Delete(res2.Visit_uid, res3.Attachment_uid, shortuuid.New())
+		assert.NotNil(t, err4)
+
+		var _, err5 = r.Delete(res2.Visit_uid, res3.Attachment_uid, res1.Patient_uid)
+		assert.Nil(t, err5)
+	})
+
+	t.Run("error enum", func(t *testing.T) {
+		var _, err3 = r.Create(entities.Attachment{Visit_uid: res2.Visit_uid, Type: "selfie"})
+		assert.NotNil(t, err3)
+	})
+}
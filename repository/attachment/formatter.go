@@ -0,0 +1,21 @@
+package attachment
+
+import "be/utils/list"
+
+type AttachmentResp struct {
+	Attachment_uid string `json:"attachment_uid"`
+	Visit_uid      string `json:"visit_uid"`
+	Type           string `json:"type"`
+	Description    string `json:"description"`
+	FileName       string `json:"fileName"`
+	ContentType    string `json:"contentType"`
+	Size           int64  `json:"size"`
+	Checksum       string `json:"checksum"`
+	Uploader_uid   string `json:"uploader_uid"`
+	CreatedAt      string `json:"createdAt"`
+	list.Row
+}
+
+type Attachments struct {
+	Attachments []AttachmentResp `json:"attachments"`
+}
@@ -0,0 +1,15 @@
+package attachment
+
+import (
+	"be/entities"
+	"be/utils/list"
+)
+
+type Attachment interface {
+	CheckAccess(visit_uid, user_uid string) error
+	CheckDoctor(visit_uid, doctor_uid string) error
+	Create(req entities.Attachment) (entities.Attachment, error)
+	Delete(visit_uid, attachment_uid, user_uid string) (entities.Attachment, error)
+	GetAttachment(visit_uid, attachment_uid string) (entities.Attachment, error)
+	GetAttachments(visit_uid string, q list.Query) (Attachments, list.Page, error)
+}
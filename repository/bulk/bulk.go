@@ -0,0 +1,385 @@
+package bulk
+
+import (
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/utils"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+// rows are registered this many in one transaction
+
+const BatchSize = 100
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// Create stores the preview, valid rows clashing with a registered nik, user
+// name or email become invalid. Passwords are stored hashed, the invalid rows
+// are never committed and keep none
+
+func (r *Repo) Create(req entities.Bulk) (entities.Bulk, error) {
+
+	for i := range req.Rows {
+		var row = &req.Rows[i]
+		if row.Status != "valid" {
+			continue
+		}
+
+		var data map[string]string
+		if err := json.Unmarshal([]byte(row.Data), &data); err != nil {
+			return entities.Bulk{}, err
+		}
+
+		if reason := r.registered(req.Kind, data); reason != "" {
+			row.Status = "invalid"
+			row.Error = reason
+		}
+	}
+
+	req.Bulk_uid = shortuuid.New()
+	req.Status = "preview"
+	req.Total = len(req.Rows)
+	req.Valid, req.Invalid = 0, 0
+
+	for i := range req.Rows {
+		req.Rows[i].Bulk_uid = req.Bulk_uid
+		if req.Rows[i].Status == "valid" {
+			req.Valid++
+		} else {
+			req.Invalid++
+		}
+	}
+
+	if err := hashPasswords(req.Rows); err != nil {
+		log.Warn(err)
+		return entities.Bulk{}, err
+	}
+
+	var rows = req.Rows
+	req.Rows = nil
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(&entities.Bulk{}).Create(&req); res.Error != nil {
+			return res.Error
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Model(&entities.BulkRow{}).CreateInBatches(&rows, BatchSize).Error
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return entities.Bulk{}, err
+	}
+
+	req.Rows = rows
+
+	return req, nil
+}
+
+// hashPasswords hashes the rows side by side, a hash takes about a second
+
+func hashPasswords(rows []entities.BulkRow) error {
+	var wg sync.WaitGroup
+	var limit = make(chan struct{}, runtime.NumCPU())
+	var errs = make([]error, len(rows))
+
+	for i := range rows {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			errs[i] = hashPassword(&rows[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hashPassword(row *entities.BulkRow) error {
+	var data map[string]string
+	if err := json.Unmarshal([]byte(row.Data), &data); err != nil {
+		return err
+	}
+
+	if data["password"] == "" {
+		return nil
+	}
+
+	if row.Status != "valid" {
+		delete(data, "password")
+	} else {
+		var hash, err = utils.HashPassword(data["password"])
+		if err != nil {
+			return errors.New("error in hash password")
+		}
+		data["password"] = hash
+	}
+
+	var b, err = json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	row.Data = string(b)
+
+	return nil
+}
+
+func (r *Repo) registered(kind string, data map[string]string) string {
+
+	type userNameCheck struct {
+		UserName string
+	}
+
+	if userName := data["userName"]; userName != "" {
+		var check = r.db.Raw("? union all ? ", r.db.Model(&entities.Patient{}).Select("user_name").Where("user_name = ?", userName), r.db.Model(&entities.Doctor{}).Select("user_name").Where("user_name = ?", userName)).Scan(&userNameCheck{})
+		if check.RowsAffected != 0 {
+			return "user name is already exist"
+		}
+	}
+
+	var table interface{} = &entities.Patient{}
+	if kind == "doctor" {
+		table = &entities.Doctor{}
+	}
+
+	if email := data["email"]; email != "" {
+		if check := r.db.Model(table).Where("email = ?", email).Select("user_name as UserName").Scan(&userNameCheck{}); check.RowsAffected != 0 {
+			return "email is already exist"
+		}
+	}
+
+	if nik := data["nik"]; nik != "" && kind == "patient" {
+		if check := r.db.Model(&entities.Patient{}).Where("nik = ?", nik).Select("user_name as UserName").Scan(&userNameCheck{}); check.RowsAffected != 0 {
+			return "nik is already registered"
+		}
+	}
+
+	return ""
+}
+
+func (r *Repo) GetBulk(bulk_uid string) (BulkResp, error) {
+
+	var bulk entities.Bulk
+
+	if res := r.db.Model(&entities.Bulk{}).Where("bulk_uid = ?", bulk_uid).Find(&bulk); res.Error != nil || res.RowsAffected == 0 {
+		return BulkResp{}, gorm.ErrRecordNotFound
+	}
+
+	var res = BulkResp{
+		Bulk_uid:      bulk.Bulk_uid,
+		Requester_uid: bulk.Requester_uid,
+		Kind:          bulk.Kind,
+		FileName:      bulk.FileName,
+		Status:        bulk.Status,
+		Total:         bulk.Total,
+		Valid:         bulk.Valid,
+		Invalid:       bulk.Invalid,
+		Created:       bulk.Created,
+		Failed:        bulk.Failed,
+		Errors:        []RowResp{},
+	}
+
+	if err := r.db.Model(&entities.BulkRow{}).Select("line as Row, status as Status, error as Error, target_uid as Target_uid").Where("bulk_uid = ? and status in ('invalid', 'failed')", bulk_uid).Order("line").Find(&res.Errors).Error; err != nil {
+		log.Warn(err)
+		return BulkResp{}, err
+	}
+
+	return res, nil
+}
+
+func (r *Repo) GetRows(bulk_uid string) ([]entities.BulkRow, error) {
+
+	var rows []entities.BulkRow
+
+	if res := r.db.Model(&entities.BulkRow{}).Where("bulk_uid = ?", bulk_uid).Order("line").Find(&rows); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return rows, nil
+}
+
+// GetCommitting is the imports claimed and not done yet, committed by the job
+
+func (r *Repo) GetCommitting() ([]entities.Bulk, error) {
+
+	var bulks []entities.Bulk
+
+	if res := r.db.Model(&entities.Bulk{}).Where("status = 'committing'").Order("updated_at").Find(&bulks); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return bulks, nil
+}
+
+// Claim starts the commit of a preview, only once
+
+func (r *Repo) Claim(bulk_uid, requester_uid string) (entities.Bulk, error) {
+
+	var bulk entities.Bulk
+
+	if res := r.db.Model(&entities.Bulk{}).Where("bulk_uid = ? and requester_uid = ?", bulk_uid, requester_uid).Find(&bulk); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Bulk{}, gorm.ErrRecordNotFound
+	}
+
+	var res = r.db.Model(&entities.Bulk{}).Where("bulk_uid = ? and status = 'preview'", bulk_uid).Update("status", "committing")
+
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Bulk{}, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return entities.Bulk{}, errors.New("import is already " + bulk.Status)
+	}
+
+	bulk.Status = "committing"
+
+	return bulk, nil
+}
+
+// Commit registers the records in batches, a row that fails is rolled back
+// alone and the rest of its batch goes on. Rows already created by an earlier
+// commit are skipped
+
+func (r *Repo) Commit(bulk_uid string, records []Record) error {
+
+	for start := 0; start < len(records); start += BatchSize {
+		var end = start + BatchSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		var err = r.db.Transaction(func(tx *gorm.DB) error {
+			for _, record := range records[start:end] {
+				var row entities.BulkRow
+				if res := tx.Model(&entities.BulkRow{}).Where("bulk_uid = ? and line = ? and status = 'valid'", bulk_uid, record.Row).Find(&row); res.Error != nil || res.RowsAffected == 0 {
+					continue
+				}
+
+				var savepoint = "row" + strconv.Itoa(record.Row)
+				tx.SavePoint(savepoint)
+
+				var target_uid, err = create(tx, record)
+
+				var update = map[string]interface{}{"status": "created", "target_uid": target_uid, "error": ""}
+				if err != nil {
+					tx.RollbackTo(savepoint)
+					update = map[string]interface{}{"status": "failed", "error": err.Error()}
+				}
+
+				if res := tx.Model(&entities.BulkRow{}).Where("id = ?", row.ID).Updates(update); res.Error != nil {
+					return res.Error
+				}
+			}
+
+			return r.count(tx, bulk_uid)
+		})
+
+		if err != nil {
+			log.Warn(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Finish marks the import done once every record is committed
+
+func (r *Repo) Finish(bulk_uid string) (entities.Bulk, error) {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.Bulk{}).Where("bulk_uid = ?", bulk_uid).Updates(entities.Bulk{Status: "done", CommittedAt: &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Bulk{}, res.Error
+	}
+
+	var bulk entities.Bulk
+
+	if res := r.db.Model(&entities.Bulk{}).Where("bulk_uid = ?", bulk_uid).Find(&bulk); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Bulk{}, gorm.ErrRecordNotFound
+	}
+
+	return bulk, nil
+}
+
+// create registers the record, the password of the row was hashed at the
+// preview and replaces the hash Create made of it
+
+func create(tx *gorm.DB, record Record) (string, error) {
+	switch {
+	case record.Patient != nil:
+		var res, err = patient.New(tx).Create(*record.Patient)
+		if err != nil || record.Patient.Password == "" {
+			return res.Patient_uid, err
+		}
+		return res.Patient_uid, tx.Model(&entities.Patient{}).Where("patient_uid = ?", res.Patient_uid).Update("password", record.Patient.Password).Error
+	case record.Doctor != nil:
+		var res, err = doctor.New(tx).Create(*record.Doctor)
+		if err != nil {
+			return res.Doctor_uid, err
+		}
+		return res.Doctor_uid, tx.Model(&entities.Doctor{}).Where("doctor_uid_ref = ?", res.Doctor_uid).Update("password", record.Doctor.Password).Error
+	}
+	return "", errors.New("row is empty")
+}
+
+// count keeps the progress of the commit on the import
+
+func (r *Repo) count(tx *gorm.DB, bulk_uid string) error {
+	type counts struct {
+		Status string
+		Total  int
+	}
+
+	var res []counts
+
+	if err := tx.Model(&entities.BulkRow{}).Select("status as Status, count(*) as Total").Where("bulk_uid = ?", bulk_uid).Group("status").Find(&res).Error; err != nil {
+		return err
+	}
+
+	var update = map[string]interface{}{"created": 0, "failed": 0}
+	for _, c := range res {
+		switch c.Status {
+		case "created":
+			update["created"] = c.Total
+		case "failed":
+			update["failed"] = c.Total
+		}
+	}
+
+	return tx.Model(&entities.Bulk{}).Where("bulk_uid = ?", bulk_uid).Updates(update).Error
+}
@@ -0,0 +1,89 @@
+package bulk
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/utils"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulk(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Bulk{}, &entities.BulkRow{})
+	db.AutoMigrate(&entities.Bulk{}, &entities.BulkRow{})
+
+	var nik = fmt.Sprintf("8%015d", time.Now().UnixNano()%1e15)
+	var data, _ = json.Marshal(map[string]string{"nik": nik, "name": "siti rahayu", "password": "secret"})
+
+	var res, err = r.Create(entities.Bulk{Kind: "patient", Requester_uid: "admin", FileName: "patients.csv", Rows: []entities.BulkRow{
+		{Line: 2, Data: string(data), Status: "valid"},
+		{Line: 3, Data: `{"nik":"12345"}`, Status: "invalid", Error: "invalid length nik"},
+	}})
+
+	t.Run("success preview", func(t *testing.T) {
+		assert.Nil(t, err)
+		assert.Equal(t, 1, res.Valid)
+		assert.Equal(t, 1, res.Invalid)
+
+		var bulk, err = r.GetBulk(res.Bulk_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(bulk.Errors))
+		assert.Equal(t, 3, bulk.Errors[0].Row)
+
+		rows, err := r.GetRows(res.Bulk_uid)
+		assert.Nil(t, err)
+		assert.NotContains(t, rows[0].Data, "secret")
+	})
+
+	t.Run("error claim", func(t *testing.T) {
+		var _, err = r.Claim(res.Bulk_uid, "other")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success commit", func(t *testing.T) {
+		var _, err = r.Claim(res.Bulk_uid, "admin")
+		assert.Nil(t, err)
+
+		_, err = r.Claim(res.Bulk_uid, "admin")
+		assert.NotNil(t, err)
+
+		committing, err := r.GetCommitting()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(committing))
+
+		rows, err := r.GetRows(res.Bulk_uid)
+		assert.Nil(t, err)
+
+		var stored map[string]string
+		json.Unmarshal([]byte(rows[0].Data), &stored)
+
+		err = r.Commit(res.Bulk_uid, []Record{{Row: 2, Patient: &entities.Patient{Nik: nik, Name: "siti rahayu", Password: stored["password"]}}})
+		assert.Nil(t, err)
+
+		bulk, err := r.Finish(res.Bulk_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "done", bulk.Status)
+		assert.Equal(t, 1, bulk.Created)
+
+		rows, err = r.GetRows(res.Bulk_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "created", rows[0].Status)
+
+		var patient entities.Patient
+		db.Model(&entities.Patient{}).Where("patient_uid = ?", rows[0].Target_uid).Find(&patient)
+		assert.True(t, utils.CheckPasswordHash("secret", patient.Password))
+	})
+
+	t.Run("registered nik", func(t *testing.T) {
+		var res, err = r.Create(entities.Bulk{Kind: "patient", Requester_uid: "admin", Rows: []entities.BulkRow{{Line: 2, Data: string(data), Status: "valid"}}})
+		assert.Nil(t, err)
+		assert.Equal(t, "nik is already registered", res.Rows[0].Error)
+	})
+}
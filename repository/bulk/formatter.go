@@ -0,0 +1,32 @@
+package bulk
+
+import "be/entities"
+
+// Record is a valid row ready to be registered, either a patient or a doctor
+
+type Record struct {
+	Row     int
+	Patient *entities.Patient
+	Doctor  *entities.Doctor
+}
+
+type RowResp struct {
+	Row        int    `json:"row"`
+	Status     string `json:"status"`
+	Error      string `json:"error"`
+	Target_uid string `json:"target_uid"`
+}
+
+type BulkResp struct {
+	Bulk_uid      string    `json:"bulk_uid"`
+	Requester_uid string    `json:"-"`
+	Kind          string    `json:"kind"`
+	FileName      string    `json:"fileName"`
+	Status        string    `json:"status"`
+	Total         int       `json:"total"`
+	Valid         int       `json:"valid"`
+	Invalid       int       `json:"invalid"`
+	Created       int       `json:"created"`
+	Failed        int       `json:"failed"`
+	Errors        []RowResp `json:"errors"`
+}
@@ -0,0 +1,13 @@
+package bulk
+
+import "be/entities"
+
+type Bulk interface {
+	Create(req entities.Bulk) (entities.Bulk, error)
+	GetBulk(bulk_uid string) (BulkResp, error)
+	GetRows(bulk_uid string) ([]entities.BulkRow, error)
+	GetCommitting() ([]entities.Bulk, error)
+	Claim(bulk_uid, requester_uid string) (entities.Bulk, error)
+	Commit(bulk_uid string, records []Record) error
+	Finish(bulk_uid string) (entities.Bulk, error)
+}
@@ -0,0 +1,442 @@
+package calendar
+
+import (
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/visit"
+	"be/utils/list"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// the most visits in a feed
+
+const maxEvents = 1000
+
+// a sync running this long was left by a stopped worker and is run again
+
+const staleAfter = 10 * time.Minute
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// SetFeed gives the owner a new token, the url of the old one stops working
+func (r *Repo) SetFeed(owner_uid, kind, token_hash string) error {
+
+	var feed = entities.CalendarFeed{Owner_uid: owner_uid, Kind: kind, Token_hash: token_hash}
+
+	if res := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner_uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "token_hash", "updated_at"}),
+	}).Create(&feed); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteFeed(owner_uid string) error {
+
+	if res := r.db.Where("owner_uid = ?", owner_uid).Delete(&entities.CalendarFeed{}); res.Error != nil || res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) GetFeed(token_hash string) (entities.CalendarFeed, error) {
+
+	var feed entities.CalendarFeed
+
+	if res := r.db.Model(&entities.CalendarFeed{}).Where("token_hash = ?", token_hash).Find(&feed); res.Error != nil || res.RowsAffected == 0 {
+		return entities.CalendarFeed{}, gorm.ErrRecordNotFound
+	}
+
+	return feed, nil
+}
+
+func (r *Repo) GetEvent(visit_uid string) (visit.VisitCalendar, error) {
+
+	var event visit.VisitCalendar
+
+	if res := r.joined().Where("visits.visit_uid = ?", visit_uid).Select(visit.CalendarColumns).Find(&event); res.Error != nil || res.RowsAffected == 0 {
+		return visit.VisitCalendar{}, gorm.ErrRecordNotFound
+	}
+
+	return event, nil
+}
+
+// GetEvents is the visits of the doctor or patient from the date on
+func (r *Repo) GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error) {
+
+	var events []visit.VisitCalendar
+
+	var column = "visits.patient_uid"
+	if kind == "doctor" {
+		column = "visits.doctor_uid"
+	}
+
+	if res := r.joined().Where(column+" = ? and visits.date >= ?", owner_uid, from.Format("2006-01-02")).Select(visit.CalendarColumns).Order("visits.date").Limit(maxEvents).Find(&events); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return events, nil
+}
+
+// CreateState saves a started login, the ones never finished are removed
+func (r *Repo) CreateState(state entities.OauthState) error {
+
+	if res := r.db.Where("expires_at < ?", time.Now()).Delete(&entities.OauthState{}); res.Error != nil {
+		log.Warn(res.Error)
+	}
+
+	if res := r.db.Create(&state); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// TakeState is the login of the state once, a second callback with the same
+// state is not found
+func (r *Repo) TakeState(state_hash string) (entities.OauthState, error) {
+
+	var state entities.OauthState
+
+	if res := r.db.Model(&entities.OauthState{}).Where("state_hash = ? and expires_at > ?", state_hash, time.Now()).Find(&state); res.Error != nil || res.RowsAffected == 0 {
+		return entities.OauthState{}, gorm.ErrRecordNotFound
+	}
+
+	if res := r.db.Where("id = ?", state.ID).Delete(&entities.OauthState{}); res.Error != nil || res.RowsAffected == 0 {
+		return entities.OauthState{}, gorm.ErrRecordNotFound
+	}
+
+	return state, nil
+}
+
+// SetConnection connects the google account or caldav calendar to the
+// doctor, replacing an earlier or expired one and its push channel
+func (r *Repo) SetConnection(conn entities.CalendarConnection) error {
+
+	conn.Status = "connected"
+	conn.ExpiredAt = nil
+	conn.Channel_id, conn.Channel_token, conn.Channel_resource, conn.Channel_expires_at = "", "", "", nil
+
+	if res := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "doctor_uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "email", "calendar_id", "token", "status", "expired_at", "channel_id", "channel_token", "channel_resource", "channel_expires_at", "updated_at"}),
+	}).Create(&conn); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) GetConnection(doctor_uid string) (entities.CalendarConnection, error) {
+
+	var conn entities.CalendarConnection
+
+	if res := r.db.Model(&entities.CalendarConnection{}).Where("doctor_uid = ?", doctor_uid).Find(&conn); res.Error != nil || res.RowsAffected == 0 {
+		return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+	}
+
+	return conn, nil
+}
+
+// ExpireConnection marks the token as refused by google, the doctor has to
+// connect again. The busy times imported from it are removed, they are not
+// kept up to date any more
+func (r *Repo) ExpireConnection(doctor_uid string) error {
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(&entities.CalendarConnection{}).Where("doctor_uid = ? and status = 'connected'", doctor_uid).Updates(map[string]interface{}{"status": "expired", "expired_at": time.Now()}); res.Error != nil {
+			return res.Error
+		}
+
+		return tx.Where("doctor_uid = ?", doctor_uid).Delete(&entities.BusyTime{}).Error
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteConnection removes the connection and the busy times imported from
+// it
+func (r *Repo) DeleteConnection(doctor_uid string) error {
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Where("doctor_uid = ?", doctor_uid).Delete(&entities.CalendarConnection{}); res.Error != nil || res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Where("doctor_uid = ?", doctor_uid).Delete(&entities.BusyTime{}).Error
+	})
+}
+
+// GetConnections is the connected calendars, their busy times are imported
+func (r *Repo) GetConnections() ([]entities.CalendarConnection, error) {
+
+	var conns []entities.CalendarConnection
+
+	if res := r.db.Model(&entities.CalendarConnection{}).Where("status = 'connected'").Order("id").Find(&conns); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return conns, nil
+}
+
+// SetBusy replaces the busy times of the doctor from from to to with the
+// ones just imported
+func (r *Repo) SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error {
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Where("doctor_uid = ? and start_at < ? and end_at > ?", doctor_uid, to, from).Delete(&entities.BusyTime{}); res.Error != nil {
+			return res.Error
+		}
+
+		for i := range times {
+			times[i].ID = 0
+			times[i].Doctor_uid = doctor_uid
+		}
+
+		if len(times) != 0 {
+			if res := tx.CreateInBatches(&times, 100); res.Error != nil {
+				return res.Error
+			}
+		}
+
+		return tx.Model(&entities.CalendarConnection{}).Where("doctor_uid = ?", doctor_uid).Update("busy_synced_at", time.Now()).Error
+	})
+}
+
+// GetBusy is the busy times of the doctor overlapping from to to
+func (r *Repo) GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error) {
+
+	var times []entities.BusyTime
+
+	if res := r.db.Model(&entities.BusyTime{}).Where("doctor_uid = ? and start_at < ? and end_at > ?", doctor_uid, to, from).Order("start_at").Find(&times); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return times, nil
+}
+
+// SetChannel keeps the push channel google opened for the calendar of the
+// doctor, a push of the channel before is ignored
+func (r *Repo) SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error {
+
+	if res := r.db.Model(&entities.CalendarConnection{}).Where("doctor_uid = ?", doctor_uid).Updates(map[string]interface{}{"channel_id": channel_id, "channel_token": token_hash, "channel_resource": resource, "channel_expires_at": expires}); res.Error != nil || res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) GetChannel(channel_id string) (entities.CalendarConnection, error) {
+
+	var conn entities.CalendarConnection
+
+	if res := r.db.Model(&entities.CalendarConnection{}).Where("channel_id = ? and channel_id <> ''", channel_id).Find(&conn); res.Error != nil || res.RowsAffected == 0 {
+		return entities.CalendarConnection{}, gorm.ErrRecordNotFound
+	}
+
+	return conn, nil
+}
+
+// GetDue is the syncs to run now, oldest first
+func (r *Repo) GetDue(now time.Time, limit int) ([]entities.CalendarSync, error) {
+
+	var syncs []entities.CalendarSync
+
+	if res := r.db.Model(&entities.CalendarSync{}).Where("(status = 'pending' and next_at <= ?) or (status = 'running' and updated_at < ?)", now, now.Add(-staleAfter)).Order("id").Limit(limit).Find(&syncs); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return syncs, nil
+}
+
+// Claim counts an attempt of the sync, a sync claimed by another worker is
+// not found
+func (r *Repo) Claim(id uint, now time.Time) (entities.CalendarSync, error) {
+
+	if res := r.db.Model(&entities.CalendarSync{}).Where("id = ? and ((status = 'pending' and next_at <= ?) or (status = 'running' and updated_at < ?))", id, now, now.Add(-staleAfter)).Updates(map[string]interface{}{"status": "running", "attempts": gorm.Expr("attempts + 1"), "updated_at": now}); res.Error != nil || res.RowsAffected == 0 {
+		return entities.CalendarSync{}, gorm.ErrRecordNotFound
+	}
+
+	var sync entities.CalendarSync
+
+	if res := r.db.Model(&entities.CalendarSync{}).Where("id = ?", id).Find(&sync); res.Error != nil || res.RowsAffected == 0 {
+		return entities.CalendarSync{}, gorm.ErrRecordNotFound
+	}
+
+	return sync, nil
+}
+
+func (r *Repo) Finish(id uint) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.CalendarSync{}).Where("id = ?", id).Updates(map[string]interface{}{"status": "done", "last_error": "", "finished_at": &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// Retry runs the sync again at next_at
+func (r *Repo) Retry(id uint, next_at time.Time, message string) error {
+
+	if res := r.db.Model(&entities.CalendarSync{}).Where("id = ?", id).Updates(map[string]interface{}{"status": "pending", "next_at": next_at, "last_error": message}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// Bury gives up the sync, it waits in the dead letters
+func (r *Repo) Bury(id uint, message string) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.CalendarSync{}).Where("id = ?", id).Updates(map[string]interface{}{"status": "dead", "last_error": message, "finished_at": &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// GetClinic returns the doctor the admin account belongs to
+
+func (r *Repo) GetClinic(admin_uid string) (string, error) {
+
+	var clinic, err = doctor.GetClinic(r.db, admin_uid)
+	if err != nil {
+		return "", err
+	}
+
+	return clinic.Doctor_uid, nil
+}
+
+// Dead is what the list of dead syncs can be sorted and filtered by
+
+var Dead = list.Spec{
+	Sorts: map[string]list.Field{
+		"updatedAt": {Column: "updated_at", Type: list.Date},
+		"createdAt": {Column: "created_at", Type: list.Date},
+	},
+	Sort: "-updatedAt",
+	Key:  "id",
+	Filters: map[string]list.Field{
+		"visit_uid": {Column: "visit_uid", Type: list.String},
+		"updated":   {Column: "date(updated_at)", Type: list.Date},
+	},
+}
+
+// GetDead is the dead syncs of the visits of the doctor, also the deleted
+// ones
+func (r *Repo) GetDead(doctor_uid string, q list.Query) ([]DeadSync, list.Page, error) {
+
+	var visits = r.db.Unscoped().Model(&entities.Visit{}).Where("doctor_uid = ?", doctor_uid).Select("visit_uid")
+
+	var db = q.Filter(r.db.Model(&entities.CalendarSync{}).Where("status = 'dead' and visit_uid in (?)", visits))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return nil, list.Page{}, err
+	}
+
+	var syncs = []DeadSync{}
+
+	if res := q.Page(db).Select("calendar_syncs.*" + q.Select()).Find(&syncs); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, list.Page{}, res.Error
+	}
+
+	var more = len(syncs) > q.Limit
+	if more {
+		syncs = syncs[:q.Limit]
+	}
+
+	var last list.Row
+	if len(syncs) != 0 {
+		last = syncs[len(syncs)-1].Row
+	}
+
+	return syncs, q.Result(total, more, last), nil
+}
+
+// Resync runs the dead syncs of the visit of the doctor again, or adds a
+// sync when there is none
+func (r *Repo) Resync(doctor_uid, visit_uid string) error {
+
+	if res := r.db.Unscoped().Model(&entities.Visit{}).Where("visit_uid = ? and doctor_uid = ?", visit_uid, doctor_uid).Find(&[]entities.Visit{}); res.Error != nil || res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	var res = r.db.Model(&entities.CalendarSync{}).Where("visit_uid = ? and status = 'dead'", visit_uid).Updates(map[string]interface{}{"status": "pending", "attempts": 0, "next_at": time.Now(), "finished_at": nil})
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		if res := r.db.Create(&entities.CalendarSync{Visit_uid: visit_uid, Next_at: time.Now()}); res.Error != nil {
+			log.Warn(res.Error)
+			return res.Error
+		}
+	}
+
+	return nil
+}
+
+// GetSyncVisit is the visit as it is now, also a deleted one
+func (r *Repo) GetSyncVisit(visit_uid string) (SyncVisit, error) {
+
+	var res SyncVisit
+
+	if err := r.joined().Unscoped().Where("visits.visit_uid = ?", visit_uid).Select(visit.CalendarColumns + ", visits.deleted_at is not null as Deleted").Order("visits.id desc").Limit(1).Find(&res); err.Error != nil || err.RowsAffected == 0 {
+		return SyncVisit{}, gorm.ErrRecordNotFound
+	}
+
+	return res, nil
+}
+
+// SetEvent saves the event of the visit without a new sync
+func (r *Repo) SetEvent(visit_uid, event_uid string) error {
+
+	if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ?", visit_uid).Update("event_uid", event_uid); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) joined() *gorm.DB {
+	return r.db.Model(&entities.Visit{}).Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on visits.doctor_uid = doctors.doctor_uid")
+}
@@ -0,0 +1,275 @@
+package calendar
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"be/utils/list"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestCalendar(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.CalendarFeed{})
+	db.AutoMigrate(&entities.CalendarFeed{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456", Name: "siti"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(time.Now())})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	t.Run("success get event", func(t *testing.T) {
+		var event, err = r.GetEvent(res2.Visit_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, res.Doctor_uid, event.Doctor_uid)
+		assert.Equal(t, "siti", event.PatientName)
+	})
+
+	t.Run("success feed", func(t *testing.T) {
+		assert.Nil(t, r.SetFeed(res.Doctor_uid, "doctor", "old"))
+		assert.Nil(t, r.SetFeed(res.Doctor_uid, "doctor", "new"))
+
+		var _, err = r.GetFeed("old")
+		assert.NotNil(t, err)
+
+		feed, err := r.GetFeed("new")
+		assert.Nil(t, err)
+
+		events, err := r.GetEvents(feed.Kind, feed.Owner_uid, time.Now().AddDate(0, 0, -1))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(events))
+
+		events, err = r.GetEvents("patient", res1.Patient_uid, time.Now().AddDate(0, 0, 1))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(events))
+
+		assert.Nil(t, r.DeleteFeed(res.Doctor_uid))
+		assert.NotNil(t, r.DeleteFeed(res.Doctor_uid))
+	})
+
+	t.Run("error not found", func(t *testing.T) {
+		var _, err = r.GetEvent(shortuuid.New())
+		assert.NotNil(t, err)
+	})
+}
+
+func TestConnection(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.CalendarConnection{}, &entities.OauthState{})
+	db.AutoMigrate(&entities.CalendarConnection{}, &entities.OauthState{})
+
+	t.Run("success state once", func(t *testing.T) {
+		assert.Nil(t, r.CreateState(entities.OauthState{State_hash: "state", Doctor_uid: "doctor", ExpiresAt: time.Now().Add(time.Minute)}))
+
+		var state, err = r.TakeState("state")
+		assert.Nil(t, err)
+		assert.Equal(t, "doctor", state.Doctor_uid)
+
+		_, err = r.TakeState("state")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("error state expired", func(t *testing.T) {
+		assert.Nil(t, r.CreateState(entities.OauthState{State_hash: "old", Doctor_uid: "doctor", ExpiresAt: time.Now().Add(-time.Minute)}))
+
+		var _, err = r.TakeState("old")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success connection", func(t *testing.T) {
+		assert.Nil(t, r.SetConnection(entities.CalendarConnection{Doctor_uid: "doctor", Provider: "google", Email: "budi@gmail.com", Calendar_id: "primary", Token: "sealed"}))
+		assert.Nil(t, r.ExpireConnection("doctor"))
+
+		var conn, err = r.GetConnection("doctor")
+		assert.Nil(t, err)
+		assert.Equal(t, "expired", conn.Status)
+		assert.NotNil(t, conn.ExpiredAt)
+
+		// connecting again
+
+		assert.Nil(t, r.SetConnection(entities.CalendarConnection{Doctor_uid: "doctor", Provider: "google", Email: "budi@gmail.com", Calendar_id: "primary", Token: "new"}))
+		conn, _ = r.GetConnection("doctor")
+		assert.Equal(t, "connected", conn.Status)
+		assert.Equal(t, "new", conn.Token)
+
+		assert.Nil(t, r.DeleteConnection("doctor"))
+		_, err = r.GetConnection("doctor")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestBusy(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.CalendarConnection{}, &entities.BusyTime{})
+	db.AutoMigrate(&entities.CalendarConnection{}, &entities.BusyTime{})
+
+	var from = time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC)
+	var at = func(hour int) time.Time {
+		return from.Add(time.Duration(hour) * time.Hour)
+	}
+
+	assert.Nil(t, r.SetConnection(entities.CalendarConnection{Doctor_uid: "doctor", Provider: "google", Calendar_id: "primary", Token: "sealed"}))
+
+	t.Run("success replace busy times", func(t *testing.T) {
+		assert.Nil(t, r.SetBusy("doctor", from, at(48), []entities.BusyTime{{StartAt: at(9), EndAt: at(10)}, {StartAt: at(33), EndAt: at(35)}}))
+		assert.Nil(t, r.SetBusy("doctor", from, at(24), []entities.BusyTime{{StartAt: at(12), EndAt: at(13)}}))
+
+		var res, err = r.GetBusy("doctor", from, at(48))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, at(12), res[0].StartAt.UTC())
+
+		conn, _ := r.GetConnection("doctor")
+		assert.NotNil(t, conn.Busy_synced_at)
+	})
+
+	t.Run("success channel", func(t *testing.T) {
+		assert.Nil(t, r.SetChannel("doctor", "channel", "hash", "resource", at(24*7)))
+
+		var conn, err = r.GetChannel("channel")
+		assert.Nil(t, err)
+		assert.Equal(t, "doctor", conn.Doctor_uid)
+
+		_, err = r.GetChannel("")
+		assert.NotNil(t, err)
+
+		conns, err := r.GetConnections()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(conns))
+	})
+
+	t.Run("success expire removes busy times", func(t *testing.T) {
+		assert.Nil(t, r.ExpireConnection("doctor"))
+
+		var res, _ = r.GetBusy("doctor", from, at(48))
+		assert.Equal(t, 0, len(res))
+
+		assert.Nil(t, r.SetConnection(entities.CalendarConnection{Doctor_uid: "doctor", Provider: "google", Calendar_id: "primary", Token: "sealed"}))
+		assert.Nil(t, r.SetBusy("doctor", from, at(48), []entities.BusyTime{{StartAt: at(9), EndAt: at(10)}}))
+	})
+
+	t.Run("success disconnect removes busy times", func(t *testing.T) {
+		assert.Nil(t, r.DeleteConnection("doctor"))
+
+		var res, _ = r.GetBusy("doctor", from, at(48))
+		assert.Equal(t, 0, len(res))
+	})
+}
+
+func TestSync(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.CalendarSync{})
+	db.AutoMigrate(&entities.CalendarSync{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456", Name: "siti"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	// the visit leaves a sync in the same transaction
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(time.Now())})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	t.Run("success claim once", func(t *testing.T) {
+		var due, err = r.GetDue(time.Now(), 10)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, res2.Visit_uid, due[0].Visit_uid)
+
+		sync, err := r.Claim(due[0].ID, time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, sync.Attempts)
+
+		_, err = r.Claim(due[0].ID, time.Now())
+		assert.NotNil(t, err)
+
+		assert.Nil(t, r.Retry(sync.ID, time.Now().Add(time.Hour), "google: 503"))
+		due, _ = r.GetDue(time.Now(), 10)
+		assert.Equal(t, 0, len(due))
+
+		assert.Nil(t, r.Bury(sync.ID, "google: 503"))
+		var q, _ = list.Parse(Dead, url.Values{})
+
+		dead, page, err := r.GetDead(res.Doctor_uid, q)
+		assert.Nil(t, err)
+		assert.Equal(t, "google: 503", dead[0].Last_error)
+		assert.Equal(t, int64(1), page.Total)
+
+		dead, _, _ = r.GetDead("other", q)
+		assert.Equal(t, 0, len(dead))
+	})
+
+	t.Run("success resync", func(t *testing.T) {
+		assert.NotNil(t, r.Resync("other", res2.Visit_uid))
+		assert.Nil(t, r.Resync(res.Doctor_uid, res2.Visit_uid))
+
+		var q, _ = list.Parse(Dead, url.Values{})
+
+		var dead, _, _ = r.GetDead(res.Doctor_uid, q)
+		assert.Equal(t, 0, len(dead))
+
+		var due, _ = r.GetDue(time.Now(), 10)
+		assert.Equal(t, 1, len(due))
+		assert.Nil(t, r.Finish(due[0].ID))
+
+		assert.NotNil(t, r.Resync(res.Doctor_uid, shortuuid.New()))
+	})
+
+	t.Run("success sync visit", func(t *testing.T) {
+		assert.Nil(t, r.SetEvent(res2.Visit_uid, "event"))
+
+		var res, err = r.GetSyncVisit(res2.Visit_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "event", res.Event_uid)
+		assert.False(t, res.Deleted)
+
+		visit.New(db).Delete(res2.Visit_uid)
+
+		res, err = r.GetSyncVisit(res2.Visit_uid)
+		assert.Nil(t, err)
+		assert.True(t, res.Deleted)
+		assert.Equal(t, "event", res.Event_uid)
+	})
+}
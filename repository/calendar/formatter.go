@@ -0,0 +1,22 @@
+package calendar
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"be/utils/list"
+)
+
+// SyncVisit is the visit of a calendar sync, a deleted visit takes its
+// event out of the calendar
+
+type SyncVisit struct {
+	visit.VisitCalendar
+	Deleted bool
+}
+
+// DeadSync is a dead sync in the list of the admin
+
+type DeadSync struct {
+	entities.CalendarSync
+	list.Row
+}
@@ -0,0 +1,37 @@
+package calendar
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"be/utils/list"
+	"time"
+)
+
+type Calendar interface {
+	SetFeed(owner_uid, kind, token_hash string) error
+	DeleteFeed(owner_uid string) error
+	GetFeed(token_hash string) (entities.CalendarFeed, error)
+	GetEvent(visit_uid string) (visit.VisitCalendar, error)
+	GetEvents(kind, owner_uid string, from time.Time) ([]visit.VisitCalendar, error)
+	CreateState(state entities.OauthState) error
+	TakeState(state_hash string) (entities.OauthState, error)
+	SetConnection(conn entities.CalendarConnection) error
+	GetConnection(doctor_uid string) (entities.CalendarConnection, error)
+	ExpireConnection(doctor_uid string) error
+	DeleteConnection(doctor_uid string) error
+	GetDue(now time.Time, limit int) ([]entities.CalendarSync, error)
+	Claim(id uint, now time.Time) (entities.CalendarSync, error)
+	Finish(id uint) error
+	Retry(id uint, next_at time.Time, message string) error
+	Bury(id uint, message string) error
+	GetClinic(admin_uid string) (string, error)
+	GetDead(doctor_uid string, q list.Query) ([]DeadSync, list.Page, error)
+	Resync(doctor_uid, visit_uid string) error
+	GetSyncVisit(visit_uid string) (SyncVisit, error)
+	SetEvent(visit_uid, event_uid string) error
+	GetConnections() ([]entities.CalendarConnection, error)
+	SetBusy(doctor_uid string, from, to time.Time, times []entities.BusyTime) error
+	GetBusy(doctor_uid string, from, to time.Time) ([]entities.BusyTime, error)
+	SetChannel(doctor_uid, channel_id, token_hash, resource string, expires time.Time) error
+	GetChannel(channel_id string) (entities.CalendarConnection, error)
+}
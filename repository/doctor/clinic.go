@@ -0,0 +1,26 @@
+package doctor
+
+import (
+	"be/entities"
+
+	"gorm.io/gorm"
+)
+
+// GetClinic returns the doctor of the clinic the admin account belongs to,
+// an admin only sees the visits and patients of that doctor
+func GetClinic(db *gorm.DB, admin_uid string) (entities.Doctor, error) {
+
+	var admin entities.Doctor
+
+	if res := db.Model(&entities.Doctor{}).Where("doctor_uid = ? and type = 'admin'", admin_uid).Find(&admin); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Doctor{}, gorm.ErrRecordNotFound
+	}
+
+	var clinic entities.Doctor
+
+	if res := db.Model(&entities.Doctor{}).Where("doctor_uid = ?", admin.Doctor_uid_ref).Find(&clinic); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Doctor{}, gorm.ErrRecordNotFound
+	}
+
+	return clinic, nil
+}
@@ -3,6 +3,7 @@ package doctor
 import (
 	"be/entities"
 	"be/utils"
+	"be/utils/list"
 	"errors"
 
 	"github.com/labstack/gommon/log"
@@ -176,20 +177,24 @@ func (r *Repo) Delete(doctor_uid string) (entities.Doctor, error) {
 
 func (r *Repo) GetProfile(doctor_uid, userName, email string) (ProfileResp, error) {
 
+	var db = r.db.Model(&entities.Doctor{})
+
 	switch {
 	case doctor_uid != "":
-		doctor_uid = "doctor_uid = '" + doctor_uid + "'"
+		db = db.Where("doctor_uid = ?", doctor_uid)
 	case userName != "":
-		doctor_uid = "user_name = '" + userName + "'"
+		db = db.Where("user_name = ?", userName)
 	case email != "":
-		doctor_uid = "email = '" + email + "'"
+		db = db.Where("email = ?", email)
+	default:
+		return ProfileResp{}, gorm.ErrRecordNotFound
 	}
 
 	var profileResp ProfileResp
 
 	var query = "doctor_uid as Doctor_uid, user_name as UserName, email as Email, name as Name, image as Image, address as Address, status as Status, open_day as OpenDay, close_day as CloseDay, capacity as Capacity, doctor_uid_ref as Doctor_uid_ref "
 
-	if res := r.db.Model(&entities.Doctor{}).Where(doctor_uid).Select(query).Find(&profileResp); res.Error != nil || res.RowsAffected == 0 {
+	if res := db.Select(query).Find(&profileResp); res.Error != nil || res.RowsAffected == 0 {
 		log.Warn(res.Error)
 		return ProfileResp{}, gorm.ErrRecordNotFound
 	}
@@ -197,13 +202,49 @@ func (r *Repo) GetProfile(doctor_uid, userName, email string) (ProfileResp, erro
 	return profileResp, nil
 }
 
-func (r *Repo) GetAll() (All, error) {
-	var all All
+// List is what the list of doctors can be sorted and filtered by
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"name":      {Column: "doctors.name"},
+		"capacity":  {Column: "doctors.capacity", Type: list.Int},
+		"createdAt": {Column: "doctors.created_at", Type: list.Date},
+	},
+	Sort: "name",
+	Key:  "doctors.doctor_uid",
+	Filters: map[string]list.Field{
+		"name":     {Column: "doctors.name", Type: list.Search},
+		"address":  {Column: "doctors.address", Type: list.Search},
+		"status":   {Column: "doctors.status", Type: list.Enum, Values: []string{"available", "unAvailable"}},
+		"capacity": {Column: "doctors.capacity", Type: list.Int},
+	},
+}
+
+func (r *Repo) GetAll(q list.Query) (All, list.Page, error) {
+	var db = q.Filter(r.db.Model(&entities.Doctor{}).Where("type = 'doctor'"))
 
-	if res := r.db.Model(&entities.Doctor{}).Where("type = 'doctor'").Find(&all.Doctors); res.Error != nil {
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return All{}, list.Page{}, err
+	}
+
+	var all = All{Doctors: []AllResp{}}
+
+	if res := q.Page(db).Select("doctor_uid as Doctor_uid, name as Name, image as Image, address as Address, status as Status, capacity as Capacity" + q.Select()).Find(&all.Doctors); res.Error != nil {
 		log.Warn(res.Error)
-		return All{}, res.Error
+		return All{}, list.Page{}, res.Error
+	}
+
+	var more = len(all.Doctors) > q.Limit
+	if more {
+		all.Doctors = all.Doctors[:q.Limit]
+	}
+
+	var last list.Row
+	if len(all.Doctors) != 0 {
+		last = all.Doctors[len(all.Doctors)-1].Row
 	}
 
-	return all, nil
+	return all, q.Result(total, more, last), nil
 }
@@ -5,6 +5,8 @@ import (
 	"be/entities"
 	"be/repository/patient"
 	"be/utils"
+	"be/utils/list"
+	"net/url"
 	"testing"
 
 	"github.com/labstack/gommon/log"
@@ -255,6 +257,12 @@ func TestGetProfile(t *testing.T) {
 
 		var _, err1 = r.GetProfile(res.Status, "", "")
 		assert.NotNil(t, err1)
+
+		_, err1 = r.GetProfile("' or '1'='1", "", "")
+		assert.NotNil(t, err1)
+
+		_, err1 = r.GetProfile("", "", "")
+		assert.NotNil(t, err1)
 		// log.Info(res1)
 	})
 }
@@ -289,9 +297,43 @@ func TestGetAll(t *testing.T) {
 			t.Fatal()
 		}
 
-		var res1, err1 = r.GetAll()
+		var q, _ = list.Parse(List, url.Values{"limit": {"1"}})
+		var res1, page, err1 = r.GetAll(q)
 		assert.Nil(t, err1)
-		assert.NotNil(t, res1)
-		log.Info(res1)
+		assert.Equal(t, 1, len(res1.Doctors))
+		assert.Equal(t, int64(3), page.Total)
+		assert.NotEqual(t, "", page.Next_cursor)
+
+		q, _ = list.Parse(List, url.Values{"limit": {"5"}, "cursor": {page.Next_cursor}})
+		res1, page, err1 = r.GetAll(q)
+		assert.Nil(t, err1)
+		assert.Equal(t, 2, len(res1.Doctors))
+		assert.Equal(t, "", page.Next_cursor)
+	})
+}
+
+func TestGetClinic(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+
+	var res, err = New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "clinic", Name: "clinic"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	t.Run("success doctor of the admin", func(t *testing.T) {
+		var admin entities.Doctor
+		db.Model(&entities.Doctor{}).Where("doctor_uid_ref = ? and type = 'admin'", res.Doctor_uid).First(&admin)
+
+		var clinic, err = GetClinic(db, admin.Doctor_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, res.Doctor_uid, clinic.Doctor_uid)
+		assert.Equal(t, "clinic", clinic.Name)
+	})
+
+	t.Run("error not admin", func(t *testing.T) {
+		var _, err = GetClinic(db, res.Doctor_uid)
+		assert.NotNil(t, err)
 	})
 }
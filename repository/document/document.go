@@ -0,0 +1,221 @@
+package document
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"be/utils/list"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) Create(visit_uid, doctor_uid string, req entities.Document) (entities.Document, error) {
+
+	// letters are only issued by the doctor of a completed visit
+
+	var visit entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ? and doctor_uid = ?", visit_uid, doctor_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Document{}, gorm.ErrRecordNotFound
+	}
+
+	if visit.Status != "completed" {
+		return entities.Document{}, errors.New("visit is not completed yet")
+	}
+
+	if req.Kind == "sickLeave" && time.Time(req.StartDate).Before(time.Time(visit.Date)) {
+		return entities.Document{}, errors.New("start date is before visit date")
+	}
+
+	var patient entities.Patient
+
+	if res := r.db.Model(&entities.Patient{}).Where("patient_uid = ?", visit.Patient_uid).Find(&patient); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Document{}, gorm.ErrRecordNotFound
+	}
+
+	var doctor entities.Doctor
+
+	if res := r.db.Model(&entities.Doctor{}).Where("doctor_uid = ?", doctor_uid).Find(&doctor); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Document{}, gorm.ErrRecordNotFound
+	}
+
+	var uid, code string
+
+	for {
+		uid = shortuuid.New()
+		var find = entities.Document{}
+		var res = r.db.Model(&entities.Document{}).Where("document_uid = ?", uid).Find(&find)
+		if res.RowsAffected == 0 {
+			break
+		}
+	}
+
+	for {
+		var err error
+		code, err = verificationCode()
+		if err != nil {
+			return entities.Document{}, err
+		}
+		var find = entities.Document{}
+		var res = r.db.Model(&entities.Document{}).Where("code = ?", code).Find(&find)
+		if res.RowsAffected == 0 {
+			break
+		}
+	}
+
+	// the letter keeps the data as printed, later profile changes don't alter it
+
+	req.Document_uid = uid
+	req.Code = code
+	req.Visit_uid = visit_uid
+	req.Doctor_uid = doctor_uid
+	req.Patient_uid = visit.Patient_uid
+	req.PatientName = patient.Name
+	req.Nik = patient.Nik
+	req.Dob = patient.Dob
+	req.DoctorName = doctor.Name
+	req.DoctorAddress = doctor.Address
+	req.VisitDate = visit.Date
+
+	if req.Kind != "sickLeave" {
+		req.StartDate = visit.Date
+		req.EndDate = visit.Date
+	}
+
+	if res := r.db.Model(&entities.Document{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Document{}, res.Error
+	}
+
+	return req, nil
+}
+
+func (r *Repo) GetDocument(document_uid string) (entities.Document, error) {
+
+	var document entities.Document
+
+	if res := r.db.Model(&entities.Document{}).Where("document_uid = ?", document_uid).Find(&document); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Document{}, gorm.ErrRecordNotFound
+	}
+
+	return document, nil
+}
+
+// List is what the documents of a visit can be sorted and filtered by
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"createdAt": {Column: "created_at", Type: list.Date},
+		"startDate": {Column: "start_date", Type: list.Date},
+	},
+	Sort: "-createdAt",
+	Key:  "id",
+	Filters: map[string]list.Field{
+		"kind":    {Column: "kind", Type: list.Enum, Values: []string{"sickLeave", "fitness"}},
+		"created": {Column: "date(created_at)", Type: list.Date},
+	},
+}
+
+func (r *Repo) GetDocuments(visit_uid, user_uid string, q list.Query) (Documents, list.Page, error) {
+
+	if err := visit.CheckAccess(r.db, visit_uid, user_uid); err != nil {
+		return Documents{}, list.Page{}, err
+	}
+
+	var db = q.Filter(r.db.Model(&entities.Document{}).Where("visit_uid = ?", visit_uid))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return Documents{}, list.Page{}, err
+	}
+
+	var documents = Documents{Documents: []DocumentResp{}}
+
+	if res := q.Page(db).Select("document_uid as Document_uid, code as Code, kind as Kind, date_format(start_date, '%d-%m-%Y') as StartDate, date_format(end_date, '%d-%m-%Y') as EndDate, note as Note, date_format(created_at, '%d-%m-%Y %H:%i') as CreatedAt" + q.Select()).Find(&documents.Documents); res.Error != nil {
+		log.Warn(res.Error)
+		return Documents{}, list.Page{}, res.Error
+	}
+
+	var more = len(documents.Documents) > q.Limit
+	if more {
+		documents.Documents = documents.Documents[:q.Limit]
+	}
+
+	var last list.Row
+	if len(documents.Documents) != 0 {
+		last = documents.Documents[len(documents.Documents)-1].Row
+	}
+
+	return documents, q.Result(total, more, last), nil
+}
+
+func (r *Repo) Verify(code string) (VerifyResp, error) {
+
+	var document entities.Document
+
+	if res := r.db.Model(&entities.Document{}).Where("code = ?", strings.ToUpper(code)).Find(&document); res.Error != nil || res.RowsAffected == 0 {
+		return VerifyResp{}, gorm.ErrRecordNotFound
+	}
+
+	return VerifyResp{
+		Code:        document.Code,
+		Kind:        document.Kind,
+		PatientName: document.PatientName,
+		Nik:         MaskNik(document.Nik),
+		DoctorName:  document.DoctorName,
+		VisitDate:   format(document.VisitDate),
+		StartDate:   format(document.StartDate),
+		EndDate:     format(document.EndDate),
+		IssuedAt:    document.CreatedAt.Format("02-01-2006"),
+	}, nil
+}
+
+// MaskNik keeps only the first and last four digits
+
+func MaskNik(nik string) string {
+	if len(nik) <= 8 {
+		return strings.Repeat("*", len(nik))
+	}
+	return nik[:4] + strings.Repeat("*", len(nik)-8) + nik[len(nik)-4:]
+}
+
+func format(date datatypes.Date) string {
+	return time.Time(date).Format("02-01-2006")
+}
+
+// ambiguous characters (0, O, 1, I) are left out so the code is easy to type
+
+const alphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+func verificationCode() (string, error) {
+	var code = make([]byte, 0, 11)
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		var n, err = rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		code = append(code, alphabet[n.Int64()])
+	}
+	return string(code), nil
+}
@@ -0,0 +1,89 @@
+package document
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"be/utils/list"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestDocument(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Document{})
+	db.AutoMigrate(&entities.Document{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456", Name: "siti"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(time.Now())})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	var sickLeave = entities.Document{Kind: "sickLeave", StartDate: datatypes.Date(time.Now()), EndDate: datatypes.Date(time.Now().AddDate(0, 0, 2))}
+
+	t.Run("error visit not completed", func(t *testing.T) {
+		var _, err = r.Create(res2.Visit_uid, res.Doctor_uid, sickLeave)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success create and verify", func(t *testing.T) {
+		var _, err = visit.New(db).Update(res2.Visit_uid, entities.Visit{MainDiagnose: "influenza"})
+		assert.Nil(t, err)
+
+		document, err := r.Create(res2.Visit_uid, res.Doctor_uid, sickLeave)
+		assert.Nil(t, err)
+		assert.Equal(t, "siti", document.PatientName)
+
+		var verified, err1 = r.Verify(document.Code)
+		assert.Nil(t, err1)
+		assert.Equal(t, "1234********3456", verified.Nik)
+
+		var q, _ = list.Parse(List, url.Values{"kind": {"sickLeave"}})
+		var documents, page, err2 = r.GetDocuments(res2.Visit_uid, res1.Patient_uid, q)
+		assert.Nil(t, err2)
+		assert.Equal(t, 1, len(documents.Documents))
+		assert.Equal(t, int64(1), page.Total)
+
+		_, _, err2 = r.GetDocuments(res2.Visit_uid, shortuuid.New(), q)
+		assert.NotNil(t, err2)
+	})
+
+	t.Run("error not found", func(t *testing.T) {
+		var _, err = r.Verify("AAAAA-AAAAA")
+		assert.NotNil(t, err)
+
+		_, err = r.GetDocument(shortuuid.New())
+		assert.NotNil(t, err)
+	})
+}
+
+func TestVerificationCode(t *testing.T) {
+	var code, err = verificationCode()
+	assert.Nil(t, err)
+	assert.Equal(t, 11, len(code))
+	assert.Equal(t, byte('-'), code[5])
+}
@@ -0,0 +1,32 @@
+package document
+
+import "be/utils/list"
+
+type DocumentResp struct {
+	Document_uid string `json:"document_uid"`
+	Code         string `json:"code"`
+	Kind         string `json:"kind"`
+	StartDate    string `json:"startDate"`
+	EndDate      string `json:"endDate"`
+	Note         string `json:"note"`
+	CreatedAt    string `json:"createdAt"`
+	list.Row
+}
+
+type Documents struct {
+	Documents []DocumentResp `json:"documents"`
+}
+
+// public data shown to whoever holds the code, nik is masked
+
+type VerifyResp struct {
+	Code        string `json:"code"`
+	Kind        string `json:"kind"`
+	PatientName string `json:"patientName"`
+	Nik         string `json:"nik"`
+	DoctorName  string `json:"doctorName"`
+	VisitDate   string `json:"visitDate"`
+	StartDate   string `json:"startDate"`
+	EndDate     string `json:"endDate"`
+	IssuedAt    string `json:"issuedAt"`
+}
@@ -0,0 +1,13 @@
+package document
+
+import (
+	"be/entities"
+	"be/utils/list"
+)
+
+type Document interface {
+	Create(visit_uid, doctor_uid string, req entities.Document) (entities.Document, error)
+	GetDocument(document_uid string) (entities.Document, error)
+	GetDocuments(visit_uid, user_uid string, q list.Query) (Documents, list.Page, error)
+	Verify(code string) (VerifyResp, error)
+}
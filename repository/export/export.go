@@ -0,0 +1,188 @@
+package export
+
+import (
+	"be/entities"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+// running exports older than this are taken as abandoned by a crashed replica
+
+const staleAfter = 30 * time.Minute
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) CheckAccess(patient_uid, user_uid string) error {
+
+	var patient entities.Patient
+
+	if res := r.db.Model(&entities.Patient{}).Where("patient_uid = ?", patient_uid).Find(&patient); res.Error != nil || res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	if patient_uid == user_uid {
+		return nil
+	}
+
+	// doctors only see the record of their own patient
+
+	var visit entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("patient_uid = ? and doctor_uid = ?", patient_uid, user_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return errors.New("access denied")
+	}
+
+	return nil
+}
+
+func (r *Repo) CountVisits(patient_uid string) (int64, error) {
+
+	var count int64
+
+	if res := r.db.Model(&entities.Visit{}).Where("patient_uid = ?", patient_uid).Count(&count); res.Error != nil {
+		log.Warn(res.Error)
+		return 0, res.Error
+	}
+
+	return count, nil
+}
+
+func (r *Repo) GetRecord(patient_uid string) (Record, error) {
+
+	var record = Record{Doctors: map[string]entities.Doctor{}}
+
+	if res := r.db.Model(&entities.Patient{}).Where("patient_uid = ?", patient_uid).Find(&record.Patient); res.Error != nil || res.RowsAffected == 0 {
+		return Record{}, gorm.ErrRecordNotFound
+	}
+
+	if res := r.db.Model(&entities.Visit{}).Where("patient_uid = ?", patient_uid).Order("date, id").Find(&record.Visits); res.Error != nil {
+		log.Warn(res.Error)
+		return Record{}, res.Error
+	}
+
+	var uids = []string{}
+
+	for _, visit := range record.Visits {
+		uids = append(uids, visit.Doctor_uid)
+	}
+
+	var doctors []entities.Doctor
+
+	if res := r.db.Unscoped().Model(&entities.Doctor{}).Where("doctor_uid in ?", uids).Find(&doctors); res.Error != nil {
+		log.Warn(res.Error)
+		return Record{}, res.Error
+	}
+
+	for _, doctor := range doctors {
+		record.Doctors[doctor.Doctor_uid] = doctor
+	}
+
+	return record, nil
+}
+
+func (r *Repo) Create(req entities.Export) (entities.Export, error) {
+
+	// the same export asked twice while it is still in progress is not queued again
+
+	var check entities.Export
+
+	if res := r.db.Model(&entities.Export{}).Where("patient_uid = ? and requester_uid = ? and format = ? and status in ('queued', 'running')", req.Patient_uid, req.Requester_uid, req.Format).Find(&check); res.RowsAffected != 0 {
+		return check, nil
+	}
+
+	req.Export_uid = shortuuid.New()
+	req.Status = "queued"
+
+	if res := r.db.Model(&entities.Export{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Export{}, res.Error
+	}
+
+	return req, nil
+}
+
+// Claim moves a queued export to running, only one replica wins the update
+
+func (r *Repo) Claim(export_uid string) (entities.Export, error) {
+
+	var now = time.Now()
+
+	var res = r.db.Model(&entities.Export{}).Where("export_uid = ? and status = 'queued'", export_uid).Updates(entities.Export{Status: "running", StartedAt: &now})
+
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Export{}, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return entities.Export{}, errors.New("export is already claimed")
+	}
+
+	return r.GetExport(export_uid)
+}
+
+func (r *Repo) Finish(export_uid string, req entities.Export) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.Export{}).Where("export_uid = ?", export_uid).Updates(entities.Export{Status: "done", FileName: req.FileName, ContentType: req.ContentType, Size: req.Size, Checksum: req.Checksum, Object_key: req.Object_key, FinishedAt: &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) Fail(export_uid, message string) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.Export{}).Where("export_uid = ?", export_uid).Updates(entities.Export{Status: "failed", Error: message, FinishedAt: &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) GetExport(export_uid string) (entities.Export, error) {
+
+	var export entities.Export
+
+	if res := r.db.Model(&entities.Export{}).Where("export_uid = ?", export_uid).Find(&export); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Export{}, gorm.ErrRecordNotFound
+	}
+
+	return export, nil
+}
+
+// GetPending puts abandoned running exports back in the queue and returns every queued export
+
+func (r *Repo) GetPending() ([]entities.Export, error) {
+
+	if res := r.db.Model(&entities.Export{}).Where("status = 'running' and started_at < ?", time.Now().Add(-staleAfter)).Update("status", "queued"); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	var exports []entities.Export
+
+	if res := r.db.Model(&entities.Export{}).Where("status = 'queued'").Order("created_at").Find(&exports); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return exports, nil
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestExport(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Export{})
+	db.AutoMigrate(&entities.Export{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456", Name: "siti"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	t.Run("error access before visit", func(t *testing.T) {
+		assert.NotNil(t, r.CheckAccess(res1.Patient_uid, res.Doctor_uid))
+		assert.Nil(t, r.CheckAccess(res1.Patient_uid, res1.Patient_uid))
+	})
+
+	var _, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(time.Now())})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	t.Run("success record", func(t *testing.T) {
+		assert.Nil(t, r.CheckAccess(res1.Patient_uid, res.Doctor_uid))
+
+		var count, err = r.CountVisits(res1.Patient_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+
+		record, err := r.GetRecord(res1.Patient_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(record.Visits))
+		assert.Equal(t, "andi", record.Doctors[res.Doctor_uid].Name)
+	})
+
+	t.Run("success job", func(t *testing.T) {
+		var export, err = r.Create(entities.Export{Patient_uid: res1.Patient_uid, Requester_uid: res1.Patient_uid, Format: "pdf"})
+		assert.Nil(t, err)
+
+		again, err := r.Create(entities.Export{Patient_uid: res1.Patient_uid, Requester_uid: res1.Patient_uid, Format: "pdf"})
+		assert.Nil(t, err)
+		assert.Equal(t, export.Export_uid, again.Export_uid)
+
+		pending, err := r.GetPending()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(pending))
+
+		_, err = r.Claim(export.Export_uid)
+		assert.Nil(t, err)
+
+		_, err = r.Claim(export.Export_uid)
+		assert.NotNil(t, err)
+
+		assert.Nil(t, r.Finish(export.Export_uid, entities.Export{Object_key: "exports/key.pdf"}))
+
+		done, err := r.GetExport(export.Export_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "done", done.Status)
+	})
+}
@@ -0,0 +1,11 @@
+package export
+
+import "be/entities"
+
+// Record is everything of a patient that goes into the export
+
+type Record struct {
+	Patient entities.Patient
+	Visits  []entities.Visit
+	Doctors map[string]entities.Doctor
+}
@@ -0,0 +1,15 @@
+package export
+
+import "be/entities"
+
+type Export interface {
+	CheckAccess(patient_uid, user_uid string) error
+	CountVisits(patient_uid string) (int64, error)
+	GetRecord(patient_uid string) (Record, error)
+	Create(req entities.Export) (entities.Export, error)
+	Claim(export_uid string) (entities.Export, error)
+	Finish(export_uid string, res entities.Export) error
+	Fail(export_uid, message string) error
+	GetExport(export_uid string) (entities.Export, error)
+	GetPending() ([]entities.Export, error)
+}
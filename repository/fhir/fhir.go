@@ -0,0 +1,204 @@
+package fhir
+
+import (
+	"be/entities"
+
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var operators = map[string]string{
+	"eq": "=",
+	"lt": "<",
+	"le": "<=",
+	"gt": ">",
+	"ge": ">=",
+}
+
+func whereDate(db *gorm.DB, column string, params []DateParam) *gorm.DB {
+	for _, param := range params {
+		var op, ok = operators[param.Prefix]
+		if !ok {
+			op = "="
+		}
+		db = db.Where("date("+column+") "+op+" ?", param.Value.Format("2006-01-02"))
+	}
+	return db
+}
+
+func page(db *gorm.DB, search Search, dest interface{}) (int64, error) {
+	var total int64
+
+	// the session lets the same conditions be used for counting and paging
+	db = db.Session(&gorm.Session{})
+
+	if res := db.Count(&total); res.Error != nil {
+		return 0, res.Error
+	}
+
+	if res := db.Offset(search.Offset).Limit(search.Count).Find(dest); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return total, nil
+}
+
+func (r *Repo) GetPatient(id string) (entities.Patient, error) {
+
+	var patient entities.Patient
+
+	if res := r.db.Model(&entities.Patient{}).Where("patient_uid = ?", id).Find(&patient); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Patient{}, gorm.ErrRecordNotFound
+	}
+
+	return patient, nil
+}
+
+func (r *Repo) SearchPatients(search Search) ([]entities.Patient, int64, error) {
+
+	var db = r.db.Model(&entities.Patient{})
+
+	if search.Id != "" {
+		db = db.Where("patient_uid = ?", search.Id)
+	}
+
+	if search.Identifier != "" {
+		db = db.Where("nik = ?", search.Identifier)
+	}
+
+	if search.Name != "" {
+		db = db.Where("name like ?", "%"+search.Name+"%")
+	}
+
+	if search.Gender != "" {
+		db = db.Where("gender = ?", search.Gender)
+	}
+
+	db = whereDate(db, "dob", search.BirthDate)
+
+	var patients = []entities.Patient{}
+
+	var total, err = page(db.Order("created_at"), search, &patients)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return patients, total, nil
+}
+
+func (r *Repo) GetPractitioner(id string) (entities.Doctor, error) {
+
+	var doctor entities.Doctor
+
+	if res := r.db.Model(&entities.Doctor{}).Where("doctor_uid = ? and type = 'doctor'", id).Find(&doctor); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Doctor{}, gorm.ErrRecordNotFound
+	}
+
+	return doctor, nil
+}
+
+func (r *Repo) SearchPractitioners(search Search) ([]entities.Doctor, int64, error) {
+
+	var db = r.db.Model(&entities.Doctor{}).Where("type = 'doctor'")
+
+	if search.Id != "" {
+		db = db.Where("doctor_uid = ?", search.Id)
+	}
+
+	if search.Name != "" {
+		db = db.Where("name like ?", "%"+search.Name+"%")
+	}
+
+	var doctors = []entities.Doctor{}
+
+	var total, err = page(db.Order("created_at"), search, &doctors)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return doctors, total, nil
+}
+
+func (r *Repo) visits(search Search) *gorm.DB {
+
+	var db = r.db.Model(&entities.Visit{})
+
+	if search.Id != "" {
+		db = db.Where("visit_uid = ?", search.Id)
+	}
+
+	if search.Encounter != "" {
+		db = db.Where("visit_uid = ?", search.Encounter)
+	}
+
+	if search.Patient != "" {
+		db = db.Where("patient_uid = ?", search.Patient)
+	}
+
+	if search.Practitioner != "" {
+		db = db.Where("doctor_uid = ?", search.Practitioner)
+	}
+
+	if search.Status != "" {
+		db = db.Where("status = ?", search.Status)
+	}
+
+	return whereDate(db, "date", search.Date).Order("date DESC, id DESC")
+}
+
+func (r *Repo) GetEncounter(id string) (entities.Visit, error) {
+
+	var visit entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ?", id).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Visit{}, gorm.ErrRecordNotFound
+	}
+
+	return visit, nil
+}
+
+func (r *Repo) SearchEncounters(search Search) ([]entities.Visit, int64, error) {
+
+	var visits = []entities.Visit{}
+
+	var total, err = page(r.visits(search), search, &visits)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return visits, total, nil
+}
+
+// a condition is the main diagnose of a visit
+
+func (r *Repo) GetCondition(id string) (entities.Visit, error) {
+
+	var visit entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ? and main_diagnose != ''", id).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Visit{}, gorm.ErrRecordNotFound
+	}
+
+	return visit, nil
+}
+
+func (r *Repo) SearchConditions(search Search) ([]entities.Visit, int64, error) {
+
+	var visits = []entities.Visit{}
+
+	var total, err = page(r.visits(search).Where("main_diagnose != ''"), search, &visits)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return visits, total, nil
+}
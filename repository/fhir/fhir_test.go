@@ -0,0 +1,113 @@
+package fhir
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	fhirUtil "be/utils/fhir"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestFhir(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var nik = "3" + shortuuid.New()[:15]
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: nik, Name: "siti"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(time.Now())})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	t.Run("success patient", func(t *testing.T) {
+		var _, err = r.GetPatient(res1.Patient_uid)
+		assert.Nil(t, err)
+
+		var patients, total, err1 = r.SearchPatients(Search{Identifier: nik, Count: 10})
+		assert.Nil(t, err1)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, res1.Patient_uid, patients[0].Patient_uid)
+	})
+
+	t.Run("success practitioner", func(t *testing.T) {
+		var _, err = r.GetPractitioner(res.Doctor_uid)
+		assert.Nil(t, err)
+
+		var _, total, err1 = r.SearchPractitioners(Search{Id: res.Doctor_uid, Count: 10})
+		assert.Nil(t, err1)
+		assert.Equal(t, int64(1), total)
+	})
+
+	t.Run("success encounter and condition", func(t *testing.T) {
+		var visits, total, err = r.SearchEncounters(Search{Patient: res1.Patient_uid, Date: []DateParam{{Prefix: "ge", Value: time.Now().AddDate(0, 0, -1)}}, Count: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, res2.Visit_uid, visits[0].Visit_uid)
+
+		_, err = r.GetCondition(res2.Visit_uid)
+		assert.NotNil(t, err)
+
+		_, err = visit.New(db).Update(res2.Visit_uid, entities.Visit{MainDiagnose: "influenza"})
+		assert.Nil(t, err)
+
+		_, total, err = r.SearchConditions(Search{Patient: res1.Patient_uid, Count: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), total)
+	})
+
+	t.Run("error not found", func(t *testing.T) {
+		var _, err = r.GetPatient(shortuuid.New())
+		assert.NotNil(t, err)
+
+		_, err = r.GetEncounter(shortuuid.New())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success import twice without duplicate", func(t *testing.T) {
+		var body, err = ioutil.ReadFile("../../utils/fhir/testdata/bundle.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bundle, err := fhirUtil.ParseBundle(body)
+		assert.Nil(t, err)
+
+		report, err := r.Import(res.Doctor_uid, bundle)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, report.Rejected)
+
+		report, err = r.Import(res.Doctor_uid, bundle)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, report.Created)
+
+		var patients, _, _ = r.SearchPatients(Search{Identifier: "3171234567890001", Count: 10})
+		assert.Equal(t, 1, len(patients))
+
+		_, err = r.Import(shortuuid.New(), bundle)
+		assert.NotNil(t, err)
+	})
+}
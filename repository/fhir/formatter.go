@@ -0,0 +1,28 @@
+package fhir
+
+import "time"
+
+// DateParam is a date search parameter, Prefix is one of eq, lt, le, gt and ge
+
+type DateParam struct {
+	Prefix string
+	Value  time.Time
+}
+
+// Search holds the supported search parameters of every resource, empty
+// fields are not filtered
+
+type Search struct {
+	Id           string
+	Identifier   string
+	Name         string
+	Gender       string
+	BirthDate    []DateParam
+	Patient      string
+	Practitioner string
+	Encounter    string
+	Status       string
+	Date         []DateParam
+	Offset       int
+	Count        int
+}
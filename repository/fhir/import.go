@@ -0,0 +1,235 @@
+package fhir
+
+import (
+	"be/entities"
+	"be/repository/patient"
+	fhirUtil "be/utils/fhir"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Import writes the mapped bundle in one transaction, patients are matched
+// by nik and visits by patient, date and complaint so a bundle can be
+// imported again without duplicates
+
+func (r *Repo) Import(doctor_uid string, bundle fhirUtil.Import) (fhirUtil.Report, error) {
+
+	var doctor entities.Doctor
+
+	if res := r.db.Model(&entities.Doctor{}).Where("doctor_uid = ? and type = 'doctor'", doctor_uid).Find(&doctor); res.Error != nil || res.RowsAffected == 0 {
+		return fhirUtil.Report{}, errors.New("doctor is not found")
+	}
+
+	var report = fhirUtil.Report{Entries: []fhirUtil.ReportEntry{}}
+
+	tx := r.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Error; err != nil {
+		return fhirUtil.Report{}, err
+	}
+
+	// patients
+
+	var patients = map[string]string{}
+
+	for _, v := range bundle.Patients {
+		var entry, err = importPatient(tx, v.Patient)
+		if err != nil {
+			tx.Rollback()
+			return fhirUtil.Report{}, err
+		}
+		entry.ResourceType, entry.Id = "Patient", v.Id
+		patients[v.Key] = entry.Target
+		report.Add(entry)
+	}
+
+	// visits
+
+	var visits = map[string]string{}
+
+	for _, v := range bundle.Encounters {
+		var patient_uid, ok = patients[v.Patient]
+		if !ok {
+			report.Add(fhirUtil.Reject("Encounter", v.Id, "patient of the encounter is rejected"))
+			continue
+		}
+
+		var entry, err = importVisit(tx, doctor_uid, patient_uid, v.Visit)
+		if err != nil {
+			tx.Rollback()
+			return fhirUtil.Report{}, err
+		}
+		entry.ResourceType, entry.Id = "Encounter", v.Id
+		visits[v.Key] = entry.Target
+		report.Add(entry)
+	}
+
+	// diagnoses and vital signs only fill what the visit doesn't have yet
+
+	for _, v := range bundle.Conditions {
+		var visit_uid, ok = visits[v.Encounter]
+		if !ok {
+			report.Add(fhirUtil.Reject("Condition", v.Id, "encounter of the condition is rejected"))
+			continue
+		}
+
+		var visit entities.Visit
+		if res := tx.Model(&entities.Visit{}).Where("visit_uid = ?", visit_uid).Find(&visit); res.Error != nil {
+			tx.Rollback()
+			return fhirUtil.Report{}, res.Error
+		}
+
+		var entry = fhirUtil.ReportEntry{ResourceType: "Condition", Id: v.Id, Action: "created", Target: visit_uid}
+		var update = entities.Visit{}
+
+		switch {
+		case visit.MainDiagnose == "":
+			update.MainDiagnose = v.Diagnose
+		case visit.MainDiagnose == v.Diagnose || strings.Contains(visit.AdditionDiagnose, v.Diagnose):
+			entry.Action = "merged"
+		case visit.AdditionDiagnose == "":
+			update.AdditionDiagnose = v.Diagnose
+		default:
+			update.AdditionDiagnose = visit.AdditionDiagnose + ", " + v.Diagnose
+		}
+
+		if res := tx.Model(&entities.Visit{}).Where("visit_uid = ?", visit_uid).Updates(update); res.Error != nil {
+			tx.Rollback()
+			return fhirUtil.Report{}, res.Error
+		}
+		report.Add(entry)
+	}
+
+	for _, v := range bundle.Observations {
+		var visit_uid, ok = visits[v.Encounter]
+		if !ok {
+			report.Add(fhirUtil.Reject("Observation", v.Id, "encounter of the observation is rejected"))
+			continue
+		}
+
+		var visit entities.Visit
+		if res := tx.Model(&entities.Visit{}).Where("visit_uid = ?", visit_uid).Find(&visit); res.Error != nil {
+			tx.Rollback()
+			return fhirUtil.Report{}, res.Error
+		}
+
+		var update = vitals(visit, v.Vitals)
+		var entry = fhirUtil.ReportEntry{ResourceType: "Observation", Id: v.Id, Action: "created", Target: visit_uid}
+
+		if update == (entities.Visit{}) {
+			entry.Action = "merged"
+		} else if res := tx.Model(&entities.Visit{}).Where("visit_uid = ?", visit_uid).Updates(update); res.Error != nil {
+			tx.Rollback()
+			return fhirUtil.Report{}, res.Error
+		}
+		report.Add(entry)
+	}
+
+	for _, v := range bundle.Rejected {
+		report.Add(v)
+	}
+
+	return report, tx.Commit().Error
+}
+
+func importPatient(tx *gorm.DB, req entities.Patient) (fhirUtil.ReportEntry, error) {
+
+	var exist entities.Patient
+
+	if res := tx.Model(&entities.Patient{}).Where("nik = ?", req.Nik).Limit(1).Find(&exist); res.Error != nil {
+		return fhirUtil.ReportEntry{}, res.Error
+	} else if res.RowsAffected != 0 {
+
+		// the profile in the clinic wins, only the missing data is taken
+
+		var update = entities.Patient{}
+		if exist.Name == "" {
+			update.Name = req.Name
+		}
+		if time.Time(exist.Dob).IsZero() {
+			update.Dob = req.Dob
+		}
+		if exist.Gender == "lainnya" && req.Gender != "lainnya" {
+			update.Gender = req.Gender
+		}
+		if exist.Address == "" {
+			update.Address = req.Address
+		}
+
+		if res := tx.Model(&entities.Patient{}).Where("patient_uid = ?", exist.Patient_uid).Updates(update); res.Error != nil {
+			return fhirUtil.ReportEntry{}, res.Error
+		}
+
+		return fhirUtil.ReportEntry{Action: "merged", Target: exist.Patient_uid}, nil
+	}
+
+	// the account is made like a patient registered by the doctor
+
+	var res, err = patient.New(tx).Create(req)
+	if err != nil && err.Error() == "email is already exist" {
+		req.Email = ""
+		res, err = patient.New(tx).Create(req)
+	}
+	if err != nil {
+		return fhirUtil.ReportEntry{}, err
+	}
+
+	return fhirUtil.ReportEntry{Action: "created", Target: res.Patient_uid}, nil
+}
+
+func importVisit(tx *gorm.DB, doctor_uid, patient_uid string, req entities.Visit) (fhirUtil.ReportEntry, error) {
+
+	var exist entities.Visit
+
+	if res := tx.Model(&entities.Visit{}).Where("patient_uid = ? and date = ? and complaint = ?", patient_uid, time.Time(req.Date).Format("2006-01-02"), req.Complaint).Limit(1).Find(&exist); res.Error != nil {
+		return fhirUtil.ReportEntry{}, res.Error
+	} else if res.RowsAffected != 0 {
+		return fhirUtil.ReportEntry{Action: "merged", Target: exist.Visit_uid}, nil
+	}
+
+	var res = tx.Unscoped().Model(&entities.Visit{}).Where("patient_uid = ?", patient_uid).Scan(&[]entities.Visit{})
+	var uid string = patient_uid + "-" + strconv.Itoa(int(res.RowsAffected)+1)
+
+	req.Visit_uid = uid
+	req.Doctor_uid = doctor_uid
+	req.Patient_uid = patient_uid
+
+	if res := tx.Model(&entities.Visit{}).Create(&req); res.Error != nil {
+		return fhirUtil.ReportEntry{}, res.Error
+	}
+
+	return fhirUtil.ReportEntry{Action: "created", Target: uid}, nil
+}
+
+// vitals returns the vital signs of req the visit doesn't have yet
+
+func vitals(visit, req entities.Visit) entities.Visit {
+	var update = entities.Visit{}
+	var fields = []struct {
+		exist, req string
+		set        *string
+	}{
+		{visit.BloodPressure, req.BloodPressure, &update.BloodPressure},
+		{visit.HeartRate, req.HeartRate, &update.HeartRate},
+		{visit.RespiratoryRate, req.RespiratoryRate, &update.RespiratoryRate},
+		{visit.O2Saturate, req.O2Saturate, &update.O2Saturate},
+		{visit.Weight, req.Weight, &update.Weight},
+		{visit.Height, req.Height, &update.Height},
+		{visit.Bmi, req.Bmi, &update.Bmi},
+	}
+	for _, f := range fields {
+		if f.exist == "" && f.req != "" {
+			*f.set = f.req
+		}
+	}
+	return update
+}
@@ -0,0 +1,18 @@
+package fhir
+
+import (
+	"be/entities"
+	fhirUtil "be/utils/fhir"
+)
+
+type Fhir interface {
+	GetPatient(id string) (entities.Patient, error)
+	SearchPatients(search Search) ([]entities.Patient, int64, error)
+	GetPractitioner(id string) (entities.Doctor, error)
+	SearchPractitioners(search Search) ([]entities.Doctor, int64, error)
+	GetEncounter(id string) (entities.Visit, error)
+	SearchEncounters(search Search) ([]entities.Visit, int64, error)
+	GetCondition(id string) (entities.Visit, error)
+	SearchConditions(search Search) ([]entities.Visit, int64, error)
+	Import(doctor_uid string, bundle fhirUtil.Import) (fhirUtil.Report, error)
+}
@@ -0,0 +1,23 @@
+package hl7
+
+import "be/utils/list"
+
+type MessageResp struct {
+	Message_uid string `json:"message_uid"`
+	Control_id  string `json:"control_id"`
+	Sender      string `json:"sender"`
+	Type        string `json:"type"`
+	Event       string `json:"event"`
+	Source      string `json:"source"`
+	Status      string `json:"status"`
+	Target      string `json:"target"`
+	Error       string `json:"error"`
+	Attempts    int    `json:"attempts"`
+	ReceivedAt  string `json:"receivedAt"`
+	ProcessedAt string `json:"processedAt"`
+	list.Row
+}
+
+type Messages struct {
+	Messages []MessageResp `json:"messages"`
+}
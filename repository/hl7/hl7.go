@@ -0,0 +1,236 @@
+package hl7
+
+import (
+	"be/entities"
+	"be/repository/lab"
+	"be/repository/patient"
+	"be/utils/hl7"
+	"be/utils/list"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// Store keeps the raw message before it is processed, so a failed message can be replayed
+
+func (r *Repo) Store(req entities.Hl7Message) (entities.Hl7Message, error) {
+
+	req.Message_uid = shortuuid.New()
+	req.Status = "received"
+
+	if res := r.db.Model(&entities.Hl7Message{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Hl7Message{}, res.Error
+	}
+
+	return req, nil
+}
+
+// FindProcessed finds a message the sender already got an AA for, senders
+// resend a message when the ACK is lost
+
+func (r *Repo) FindProcessed(sender, control_id string) (entities.Hl7Message, error) {
+
+	var message entities.Hl7Message
+
+	if res := r.db.Model(&entities.Hl7Message{}).Where("sender = ? and control_id = ? and status = 'processed'", sender, control_id).Find(&message); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Hl7Message{}, gorm.ErrRecordNotFound
+	}
+
+	return message, nil
+}
+
+func (r *Repo) Processed(message_uid, target string) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.Hl7Message{}).Where("message_uid = ?", message_uid).Updates(map[string]interface{}{"status": "processed", "target": target, "error": "", "attempts": gorm.Expr("attempts + 1"), "processed_at": now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) Failed(message_uid, reason string) error {
+
+	if res := r.db.Model(&entities.Hl7Message{}).Where("message_uid = ?", message_uid).Updates(map[string]interface{}{"status": "failed", "error": reason, "attempts": gorm.Expr("attempts + 1")}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) GetMessage(message_uid string) (entities.Hl7Message, error) {
+
+	var message entities.Hl7Message
+
+	if res := r.db.Model(&entities.Hl7Message{}).Where("message_uid = ?", message_uid).Find(&message); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Hl7Message{}, gorm.ErrRecordNotFound
+	}
+
+	return message, nil
+}
+
+// List is what the list of messages can be sorted and filtered by
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"createdAt":   {Column: "created_at", Type: list.Date},
+		"processedAt": {Column: "processed_at", Type: list.Date},
+	},
+	Sort: "-createdAt",
+	Key:  "id",
+	Filters: map[string]list.Field{
+		"status":  {Column: "status", Type: list.Enum, Values: []string{"received", "processed", "failed"}},
+		"type":    {Column: "type", Type: list.String},
+		"sender":  {Column: "sender", Type: list.String},
+		"created": {Column: "date(created_at)", Type: list.Date},
+	},
+}
+
+func (r *Repo) GetMessages(q list.Query) (Messages, list.Page, error) {
+
+	var db = q.Filter(r.db.Model(&entities.Hl7Message{}))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return Messages{}, list.Page{}, err
+	}
+
+	var messages = Messages{Messages: []MessageResp{}}
+
+	if res := q.Page(db).Select("message_uid as Message_uid, control_id as Control_id, sender as Sender, type as Type, event as Event, source as Source, status as Status, target as Target, error as Error, attempts as Attempts, date_format(created_at, '%d-%m-%Y %H:%i:%s') as ReceivedAt, ifnull(date_format(processed_at, '%d-%m-%Y %H:%i:%s'), '') as ProcessedAt" + q.Select()).Find(&messages.Messages); res.Error != nil {
+		log.Warn(res.Error)
+		return Messages{}, list.Page{}, res.Error
+	}
+
+	var more = len(messages.Messages) > q.Limit
+	if more {
+		messages.Messages = messages.Messages[:q.Limit]
+	}
+
+	var last list.Row
+	if len(messages.Messages) != 0 {
+		last = messages.Messages[len(messages.Messages)-1].Row
+	}
+
+	return messages, q.Result(total, more, last), nil
+}
+
+// Adt creates the patient or updates the one with the same NIK, fields that
+// are not sent are left as they are
+
+func (r *Repo) Adt(req entities.Patient) (string, error) {
+
+	var find entities.Patient
+
+	if res := r.db.Model(&entities.Patient{}).Where("nik = ?", req.Nik).Find(&find); res.Error != nil {
+		log.Warn(res.Error)
+		return "", res.Error
+	}
+
+	var repo = patient.New(r.db)
+
+	if find.Patient_uid != "" {
+		if _, err := repo.Update(find.Patient_uid, entities.Patient{Name: req.Name, Gender: req.Gender, Address: req.Address, PlaceBirth: req.PlaceBirth, Dob: req.Dob, Status: req.Status}); err != nil && err != gorm.ErrRecordNotFound {
+			return "", err
+		}
+		return find.Patient_uid, nil
+	}
+
+	var res, err = repo.Create(req)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Patient_uid, nil
+}
+
+// Oru adds the results to the lab orders. A group without a known order but
+// with a visit number gets a new order on that visit, in the name of the
+// doctor of the visit. Every group is checked before anything is written
+
+func (r *Repo) Oru(orders []hl7.Oru) ([]string, error) {
+
+	var visits = map[int]entities.Visit{}
+
+	for i, order := range orders {
+		var find entities.LabOrder
+
+		if order.Order_uid != "" {
+			if res := r.db.Model(&entities.LabOrder{}).Where("order_uid = ?", order.Order_uid).Find(&find); res.Error != nil {
+				return nil, res.Error
+			}
+		}
+
+		if find.Order_uid != "" {
+			if find.Status == "cancelled" {
+				return nil, errors.New("order " + find.Order_uid + " is already cancelled")
+			}
+			continue
+		}
+
+		var visit entities.Visit
+
+		if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ?", order.Visit_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+			return nil, errors.New("order " + order.Order_uid + " and visit " + order.Visit_uid + " are not found")
+		}
+
+		if visit.Status == "cancelled" {
+			return nil, errors.New("visit " + visit.Visit_uid + " is cancelled")
+		}
+
+		visits[i] = visit
+	}
+
+	var uids []string
+
+	// the orders of the message are written all or none
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		var repo = lab.New(tx)
+
+		for i, order := range orders {
+			var order_uid = order.Order_uid
+
+			if visit, ok := visits[i]; ok {
+				var res, err = repo.Create(visit.Visit_uid, visit.Doctor_uid, entities.LabOrder{Tests: order.Test, Note: "received over hl7"})
+				if err != nil {
+					return err
+				}
+				order_uid = res.Order_uid
+			}
+
+			if _, err := repo.AddResults(order_uid, order.Results); err != nil {
+				return err
+			}
+
+			uids = append(uids, order_uid)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return nil, err
+	}
+
+	return uids, nil
+}
@@ -0,0 +1,91 @@
+package hl7
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/visit"
+	"be/utils"
+	"be/utils/hl7"
+	"be/utils/list"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestHl7(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Hl7Message{})
+	db.AutoMigrate(&entities.Hl7Message{})
+
+	var nik = fmt.Sprintf("9%015d", time.Now().UnixNano()%1e15)
+
+	t.Run("success store", func(t *testing.T) {
+		var message, err = r.Store(entities.Hl7Message{Control_id: "MSG0001", Sender: "SIMRS@RSUD", Type: "ADT", Event: "A04", Raw: "MSH|^~\\&|"})
+		assert.Nil(t, err)
+
+		_, err = r.FindProcessed("SIMRS@RSUD", "MSG0001")
+		assert.NotNil(t, err)
+
+		assert.Nil(t, r.Failed(message.Message_uid, "patient has no valid nik"))
+		assert.Nil(t, r.Processed(message.Message_uid, "patient"))
+
+		res, err := r.GetMessage(message.Message_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "processed", res.Status)
+		assert.Equal(t, 2, res.Attempts)
+
+		_, err = r.FindProcessed("SIMRS@RSUD", "MSG0001")
+		assert.Nil(t, err)
+
+		var q, _ = list.Parse(List, url.Values{"status": {"processed"}})
+		messages, _, err := r.GetMessages(q)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(messages.Messages))
+	})
+
+	t.Run("success adt", func(t *testing.T) {
+		var uid, err = r.Adt(entities.Patient{Nik: nik, Name: "siti", Gender: "wanita"})
+		assert.Nil(t, err)
+
+		again, err := r.Adt(entities.Patient{Nik: nik, Address: "jakarta"})
+		assert.Nil(t, err)
+		assert.Equal(t, uid, again)
+
+		var patient entities.Patient
+		db.Model(&entities.Patient{}).Where("patient_uid = ?", uid).Find(&patient)
+		assert.Equal(t, "siti", patient.Name)
+		assert.Equal(t, "jakarta", patient.Address)
+	})
+
+	t.Run("success oru", func(t *testing.T) {
+		var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+		if err != nil {
+			log.Info(err)
+			t.Fatal()
+		}
+
+		var patient_uid, _ = r.Adt(entities.Patient{Nik: nik})
+
+		res1, err := visit.New(db).CreateVal(res.Doctor_uid, patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(time.Now())})
+		if err != nil {
+			log.Info(err)
+			t.Fatal()
+		}
+
+		uids, err := r.Oru([]hl7.Oru{{Order_uid: "unknown", Visit_uid: res1.Visit_uid, Test: "CBC", Results: []entities.LabResult{{Analyte: "Hemoglobin", Value: "11", Flag: "L"}}}})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(uids))
+
+		_, err = r.Oru([]hl7.Oru{{Order_uid: "unknown", Visit_uid: "unknown", Results: []entities.LabResult{{Analyte: "Hemoglobin", Value: "11", Flag: "L"}}}})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,18 @@
+package hl7
+
+import (
+	"be/entities"
+	"be/utils/hl7"
+	"be/utils/list"
+)
+
+type Hl7 interface {
+	Store(req entities.Hl7Message) (entities.Hl7Message, error)
+	FindProcessed(sender, control_id string) (entities.Hl7Message, error)
+	Processed(message_uid, target string) error
+	Failed(message_uid, reason string) error
+	GetMessage(message_uid string) (entities.Hl7Message, error)
+	GetMessages(q list.Query) (Messages, list.Page, error)
+	Adt(req entities.Patient) (string, error)
+	Oru(orders []hl7.Oru) ([]string, error)
+}
@@ -0,0 +1,31 @@
+package lab
+
+type ResultResp struct {
+	Analyte        string `json:"analyte"`
+	Value          string `json:"value"`
+	Unit           string `json:"unit"`
+	ReferenceRange string `json:"referenceRange"`
+	Flag           string `json:"flag"`
+	Abnormal       bool   `json:"abnormal"`
+}
+
+type OrderResp struct {
+	Order_uid   string       `json:"order_uid"`
+	Visit_uid   string       `json:"visit_uid"`
+	Doctor_uid  string       `json:"doctor_uid"`
+	DoctorName  string       `json:"doctorName"`
+	PatientName string       `json:"patientName"`
+	Nik         string       `json:"nik"`
+	Tests       []string     `json:"tests"`
+	Note        string       `json:"note"`
+	Status      string       `json:"status"`
+	Seen        bool         `json:"seen"`
+	OrderedAt   string       `json:"orderedAt"`
+	CollectedAt string       `json:"collectedAt"`
+	ResultedAt  string       `json:"resultedAt"`
+	Results     []ResultResp `json:"results"`
+}
+
+type Orders struct {
+	Orders []OrderResp `json:"orders"`
+}
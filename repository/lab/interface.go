@@ -0,0 +1,18 @@
+package lab
+
+import (
+	"be/entities"
+	"be/utils/list"
+)
+
+type Lab interface {
+	CheckAccess(visit_uid, user_uid string) error
+	Create(visit_uid, doctor_uid string, req entities.LabOrder) (entities.LabOrder, error)
+	Collect(order_uid string) (entities.LabOrder, error)
+	AddResults(order_uid string, results []entities.LabResult) (entities.LabOrder, error)
+	GetOrder(order_uid string) (OrderResp, error)
+	GetOrders(visit_uid string) (Orders, error)
+	GetByStatus(status string, q list.Query) (Orders, list.Page, error)
+	GetUnseen(doctor_uid string, q list.Query) (Orders, list.Page, error)
+	MarkSeen(order_uid, doctor_uid string) error
+}
@@ -0,0 +1,318 @@
+package lab
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"be/utils/list"
+	"be/utils/notice"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) CheckAccess(visit_uid, user_uid string) error {
+	return visit.CheckAccess(r.db, visit_uid, user_uid)
+}
+
+func (r *Repo) Create(visit_uid, doctor_uid string, req entities.LabOrder) (entities.LabOrder, error) {
+
+	// the ordering doctor is always the doctor of the visit
+
+	var visit entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ? and doctor_uid = ?", visit_uid, doctor_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return entities.LabOrder{}, gorm.ErrRecordNotFound
+	}
+
+	if visit.Status == "cancelled" {
+		return entities.LabOrder{}, errors.New("visit is cancelled")
+	}
+
+	var uid string
+
+	for {
+		uid = shortuuid.New()
+		var find = entities.LabOrder{}
+		var res = r.db.Model(&entities.LabOrder{}).Where("order_uid = ?", uid).Find(&find)
+		if res.RowsAffected == 0 {
+			break
+		}
+	}
+
+	req.Order_uid = uid
+	req.Visit_uid = visit_uid
+	req.Doctor_uid = doctor_uid
+	req.Status = "ordered"
+
+	if res := r.db.Model(&entities.LabOrder{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.LabOrder{}, res.Error
+	}
+
+	return req, nil
+}
+
+func (r *Repo) Collect(order_uid string) (entities.LabOrder, error) {
+
+	var order entities.LabOrder
+
+	if res := r.db.Model(&entities.LabOrder{}).Where("order_uid = ?", order_uid).Find(&order); res.Error != nil || res.RowsAffected == 0 {
+		return entities.LabOrder{}, gorm.ErrRecordNotFound
+	}
+
+	if order.Status != "ordered" {
+		return entities.LabOrder{}, errors.New("order is already " + order.Status)
+	}
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.LabOrder{}).Where("order_uid = ?", order_uid).Updates(entities.LabOrder{Status: "collected", CollectedAt: &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.LabOrder{}, res.Error
+	}
+
+	order.Status = "collected"
+	order.CollectedAt = &now
+
+	return order, nil
+}
+
+// AddResults runs in a transaction of its own, or in the one of the
+// repository when it is made with one
+func (r *Repo) AddResults(order_uid string, results []entities.LabResult) (entities.LabOrder, error) {
+
+	var order entities.LabOrder
+	var now = time.Now()
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(&entities.LabOrder{}).Where("order_uid = ?", order_uid).Find(&order); res.Error != nil || res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if order.Status == "cancelled" {
+			return errors.New("order is already cancelled")
+		}
+
+		for i := range results {
+			results[i].Order_uid = order_uid
+		}
+
+		if res := tx.Model(&entities.LabResult{}).Create(&results); res.Error != nil {
+			return res.Error
+		}
+
+		// a new result is unseen until the ordering doctor opens it
+
+		var update = map[string]interface{}{"status": "resulted", "resulted_at": now, "seen": false}
+		if order.CollectedAt == nil {
+			update["collected_at"] = now
+		}
+
+		if res := tx.Model(&entities.LabOrder{}).Where("order_uid = ?", order_uid).Updates(update); res.Error != nil {
+			return res.Error
+		}
+
+		// the patient is told, each time results are added
+
+		var visit entities.Visit
+
+		if res := tx.Model(&entities.Visit{}).Where("visit_uid = ?", order.Visit_uid).Find(&visit); res.Error != nil {
+			return res.Error
+		}
+
+		return notice.Enqueue(tx, notice.ResultsReady, order_uid+"-"+strconv.FormatInt(now.UnixNano(), 36), order.Visit_uid, visit.Patient_uid)
+	})
+
+	if err != nil {
+		return entities.LabOrder{}, err
+	}
+
+	order.Status = "resulted"
+	order.ResultedAt = &now
+	order.Seen = false
+	order.Results = results
+
+	return order, nil
+}
+
+var orderQuery = "lab_orders.order_uid as Order_uid, lab_orders.visit_uid as Visit_uid, lab_orders.doctor_uid as Doctor_uid, doctors.name as DoctorName, patients.name as PatientName, patients.nik as Nik, lab_orders.tests as Tests, lab_orders.note as Note, lab_orders.status as Status, lab_orders.seen as Seen, date_format(lab_orders.created_at, '%d-%m-%Y %H:%i') as OrderedAt, ifnull(date_format(lab_orders.collected_at, '%d-%m-%Y %H:%i'), '') as CollectedAt, ifnull(date_format(lab_orders.resulted_at, '%d-%m-%Y %H:%i'), '') as ResultedAt"
+
+type orderRow struct {
+	Order_uid   string
+	Visit_uid   string
+	Doctor_uid  string
+	DoctorName  string
+	PatientName string
+	Nik         string
+	Tests       string
+	Note        string
+	Status      string
+	Seen        bool
+	OrderedAt   string
+	CollectedAt string
+	ResultedAt  string
+	list.Row
+}
+
+func (r *Repo) orders(condition string, args ...interface{}) *gorm.DB {
+	return r.db.Model(&entities.LabOrder{}).Joins("inner join visits on lab_orders.visit_uid = visits.visit_uid and visits.deleted_at is null").Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on lab_orders.doctor_uid = doctors.doctor_uid").Where(condition, args...)
+}
+
+func (r *Repo) find(condition string, args ...interface{}) (Orders, error) {
+	var rows []orderRow
+
+	if res := r.orders(condition, args...).Order("lab_orders.created_at DESC").Select(orderQuery).Find(&rows); res.Error != nil {
+		log.Warn(res.Error)
+		return Orders{}, res.Error
+	}
+
+	return r.results(rows)
+}
+
+// List is what the lists of orders by status and of unseen results can be
+// sorted and filtered by
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"orderedAt":  {Column: "lab_orders.created_at", Type: list.Date},
+		"resultedAt": {Column: "lab_orders.resulted_at", Type: list.Date},
+	},
+	Sort: "-orderedAt",
+	Key:  "lab_orders.id",
+	Filters: map[string]list.Field{
+		"visit_uid": {Column: "lab_orders.visit_uid", Type: list.String},
+		"nik":       {Column: "patients.nik", Type: list.String},
+		"patient":   {Column: "patients.name", Type: list.Search},
+		"ordered":   {Column: "date(lab_orders.created_at)", Type: list.Date},
+	},
+}
+
+func (r *Repo) page(q list.Query, condition string, args ...interface{}) (Orders, list.Page, error) {
+
+	var db = q.Filter(r.orders(condition, args...))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return Orders{}, list.Page{}, err
+	}
+
+	var rows = []orderRow{}
+
+	if res := q.Page(db).Select(orderQuery + q.Select()).Find(&rows); res.Error != nil {
+		log.Warn(res.Error)
+		return Orders{}, list.Page{}, res.Error
+	}
+
+	var more = len(rows) > q.Limit
+	if more {
+		rows = rows[:q.Limit]
+	}
+
+	var last list.Row
+	if len(rows) != 0 {
+		last = rows[len(rows)-1].Row
+	}
+
+	orders, err := r.results(rows)
+	if err != nil {
+		return Orders{}, list.Page{}, err
+	}
+
+	return orders, q.Result(total, more, last), nil
+}
+
+// results adds the results to the orders of the rows
+
+func (r *Repo) results(rows []orderRow) (Orders, error) {
+	var orders = Orders{Orders: []OrderResp{}}
+
+	for _, row := range rows {
+		var order = OrderResp{
+			Order_uid:   row.Order_uid,
+			Visit_uid:   row.Visit_uid,
+			Doctor_uid:  row.Doctor_uid,
+			DoctorName:  row.DoctorName,
+			PatientName: row.PatientName,
+			Nik:         row.Nik,
+			Tests:       strings.Split(row.Tests, ","),
+			Note:        row.Note,
+			Status:      row.Status,
+			Seen:        row.Seen,
+			OrderedAt:   row.OrderedAt,
+			CollectedAt: row.CollectedAt,
+			ResultedAt:  row.ResultedAt,
+			Results:     []ResultResp{},
+		}
+
+		var results []entities.LabResult
+		if res := r.db.Model(&entities.LabResult{}).Where("order_uid = ?", row.Order_uid).Order("id ASC").Find(&results); res.Error != nil {
+			log.Warn(res.Error)
+			return Orders{}, res.Error
+		}
+
+		for _, result := range results {
+			order.Results = append(order.Results, ResultResp{
+				Analyte:        result.Analyte,
+				Value:          result.Value,
+				Unit:           result.Unit,
+				ReferenceRange: result.ReferenceRange,
+				Flag:           result.Flag,
+				Abnormal:       result.Flag != "N" && result.Flag != "",
+			})
+		}
+
+		orders.Orders = append(orders.Orders, order)
+	}
+
+	return orders, nil
+}
+
+func (r *Repo) GetOrder(order_uid string) (OrderResp, error) {
+	var orders, err = r.find("lab_orders.order_uid = ?", order_uid)
+	if err != nil {
+		return OrderResp{}, err
+	}
+
+	if len(orders.Orders) == 0 {
+		return OrderResp{}, gorm.ErrRecordNotFound
+	}
+
+	return orders.Orders[0], nil
+}
+
+func (r *Repo) GetOrders(visit_uid string) (Orders, error) {
+	return r.find("lab_orders.visit_uid = ?", visit_uid)
+}
+
+func (r *Repo) GetByStatus(status string, q list.Query) (Orders, list.Page, error) {
+	return r.page(q, "lab_orders.status = ?", status)
+}
+
+func (r *Repo) GetUnseen(doctor_uid string, q list.Query) (Orders, list.Page, error) {
+	return r.page(q, "visits.doctor_uid = ? and lab_orders.status = 'resulted' and lab_orders.seen = false", doctor_uid)
+}
+
+func (r *Repo) MarkSeen(order_uid, doctor_uid string) error {
+	if res := r.db.Model(&entities.LabOrder{}).Where("order_uid = ? and doctor_uid = ? and status = 'resulted'", order_uid, doctor_uid).Update("seen", true); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package lab
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"be/utils/list"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLab(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.LabResult{})
+	db.Migrator().DropTable(&entities.LabOrder{})
+	db.AutoMigrate(&entities.LabOrder{})
+	db.AutoMigrate(&entities.LabResult{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick"})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	var query, _ = list.Parse(List, url.Values{})
+
+	t.Run("success order until resulted", func(t *testing.T) {
+		var order, err = r.Create(res2.Visit_uid, res.Doctor_uid, entities.LabOrder{Tests: "hemoglobin,glucose"})
+		assert.Nil(t, err)
+
+		var pending, _, err1 = r.GetByStatus("ordered", query)
+		assert.Nil(t, err1)
+		assert.Equal(t, 1, len(pending.Orders))
+
+		_, err = r.Collect(order.Order_uid)
+		assert.Nil(t, err)
+
+		_, err = r.Collect(order.Order_uid)
+		assert.NotNil(t, err)
+
+		_, err = r.AddResults(order.Order_uid, []entities.LabResult{{Analyte: "glucose", Value: "160", Unit: "mg/dL", ReferenceRange: "70-100", Flag: "H"}})
+		assert.Nil(t, err)
+
+		var unseen, page, err2 = r.GetUnseen(res.Doctor_uid, query)
+		assert.Nil(t, err2)
+		assert.Equal(t, 1, len(unseen.Orders))
+		assert.Equal(t, int64(1), page.Total)
+		assert.Equal(t, "", page.Next_cursor)
+		assert.Equal(t, true, unseen.Orders[0].Results[0].Abnormal)
+		assert.Equal(t, []string{"hemoglobin", "glucose"}, unseen.Orders[0].Tests)
+
+		assert.Nil(t, r.MarkSeen(order.Order_uid, res.Doctor_uid))
+
+		unseen, _, _ = r.GetUnseen(res.Doctor_uid, query)
+		assert.Equal(t, 0, len(unseen.Orders))
+
+		var res3, err3 = r.GetOrder(order.Order_uid)
+		assert.Nil(t, err3)
+		assert.Equal(t, "resulted", res3.Status)
+	})
+
+	t.Run("other doctor can't order", func(t *testing.T) {
+		var _, err = r.Create(res2.Visit_uid, shortuuid.New(), entities.LabOrder{Tests: "hemoglobin"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("only the doctor and patient of the visit read", func(t *testing.T) {
+		assert.Nil(t, r.CheckAccess(res2.Visit_uid, res.Doctor_uid))
+		assert.Nil(t, r.CheckAccess(res2.Visit_uid, res1.Patient_uid))
+		assert.NotNil(t, r.CheckAccess(res2.Visit_uid, shortuuid.New()))
+	})
+
+	t.Run("invalid order", func(t *testing.T) {
+		var _, err = r.AddResults(shortuuid.New(), []entities.LabResult{{Analyte: "glucose", Value: "90"}})
+		assert.NotNil(t, err)
+
+		_, err = r.GetOrder(shortuuid.New())
+		assert.NotNil(t, err)
+	})
+}
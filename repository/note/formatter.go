@@ -0,0 +1,33 @@
+package note
+
+// Revision is the request of an update or an amendment, a nil section
+// keeps the previous version and an empty one clears it
+type Revision struct {
+	Subjective *string
+	Objective  *string
+	Assessment *string
+	Plan       *string
+	Reason     string
+}
+
+type VersionResp struct {
+	Version    int    `json:"version"`
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Subjective string `json:"subjective"`
+	Objective  string `json:"objective"`
+	Assessment string `json:"assessment"`
+	Plan       string `json:"plan"`
+	Reason     string `json:"reason"`
+	Author_uid string `json:"author_uid"`
+	AuthorName string `json:"authorName"`
+	CreatedAt  string `json:"createdAt"`
+	SignedAt   string `json:"signedAt"`
+}
+
+type NoteResp struct {
+	Note_uid  string        `json:"note_uid"`
+	Visit_uid string        `json:"visit_uid"`
+	Current   VersionResp   `json:"current"`
+	Versions  []VersionResp `json:"versions"`
+}
@@ -0,0 +1,12 @@
+package note
+
+import "be/entities"
+
+type Note interface {
+	CheckAccess(visit_uid, user_uid string) error
+	Create(visit_uid, author_uid string, req entities.Note) (entities.Note, error)
+	Update(visit_uid, author_uid string, req Revision) (entities.Note, error)
+	Sign(visit_uid, author_uid string) (entities.Note, error)
+	Amend(visit_uid, author_uid string, req Revision) (entities.Note, error)
+	GetNote(visit_uid string) (NoteResp, error)
+}
@@ -0,0 +1,271 @@
+package note
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) CheckAccess(visit_uid, user_uid string) error {
+	return visit.CheckAccess(r.db, visit_uid, user_uid)
+}
+
+// visit of the doctor, only the doctor of the visit writes the note
+func (r *Repo) visit(visit_uid, doctor_uid string) (entities.Visit, error) {
+	var visit entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ? and doctor_uid = ?", visit_uid, doctor_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Visit{}, gorm.ErrRecordNotFound
+	}
+
+	return visit, nil
+}
+
+// latest is read with the locking clause of the caller, so the next
+// version is counted and written in the same transaction
+func latest(db *gorm.DB, visit_uid string) (entities.Note, error) {
+	var note entities.Note
+
+	if res := db.Model(&entities.Note{}).Where("visit_uid = ?", visit_uid).Order("version DESC").Limit(1).Find(&note); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Note{}, gorm.ErrRecordNotFound
+	}
+
+	return note, nil
+}
+
+func locked(tx *gorm.DB) *gorm.DB {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// revise copies the previous version and overwrites only the sections
+// sent in the request, so every saved version stays complete
+func revise(prev entities.Note, req Revision) entities.Note {
+	var next = entities.Note{
+		Note_uid:   prev.Note_uid,
+		Visit_uid:  prev.Visit_uid,
+		Version:    prev.Version + 1,
+		Subjective: prev.Subjective,
+		Objective:  prev.Objective,
+		Assessment: prev.Assessment,
+		Plan:       prev.Plan,
+	}
+
+	if req.Subjective != nil {
+		next.Subjective = *req.Subjective
+	}
+	if req.Objective != nil {
+		next.Objective = *req.Objective
+	}
+	if req.Assessment != nil {
+		next.Assessment = *req.Assessment
+	}
+	if req.Plan != nil {
+		next.Plan = *req.Plan
+	}
+
+	return next
+}
+
+func (r *Repo) Create(visit_uid, author_uid string, req entities.Note) (entities.Note, error) {
+
+	// check visit
+
+	var visit, err = r.visit(visit_uid, author_uid)
+	if err != nil {
+		return entities.Note{}, err
+	}
+
+	switch visit.Status {
+	case "completed":
+		return entities.Note{}, errors.New("note is locked")
+	case "cancelled":
+		return entities.Note{}, errors.New("visit is cancelled")
+	}
+
+	req.Note_uid = shortuuid.New()
+	req.Visit_uid = visit_uid
+	req.Author_uid = author_uid
+	req.Version = 1
+	req.Kind = "original"
+	req.Status = "draft"
+	req.Reason = ""
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+
+		// check note
+
+		if _, err := latest(locked(tx), visit_uid); err == nil {
+			return errors.New("note is already exist")
+		}
+
+		if res := tx.Model(&entities.Note{}).Create(&req); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return entities.Note{}, err
+	}
+
+	return req, nil
+}
+
+func (r *Repo) Update(visit_uid, author_uid string, req Revision) (entities.Note, error) {
+
+	if _, err := r.visit(visit_uid, author_uid); err != nil {
+		return entities.Note{}, err
+	}
+
+	var next entities.Note
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		var prev, err = latest(locked(tx), visit_uid)
+		if err != nil {
+			return err
+		}
+
+		if prev.Status == "signed" {
+			return errors.New("note is locked")
+		}
+
+		next = revise(prev, req)
+		next.Author_uid = author_uid
+		next.Kind = prev.Kind
+		next.Status = "draft"
+
+		if res := tx.Model(&entities.Note{}).Create(&next); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return entities.Note{}, err
+	}
+
+	return next, nil
+}
+
+func (r *Repo) Sign(visit_uid, author_uid string) (entities.Note, error) {
+
+	if _, err := r.visit(visit_uid, author_uid); err != nil {
+		return entities.Note{}, err
+	}
+
+	var prev entities.Note
+	var now = time.Now()
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if prev, err = latest(locked(tx), visit_uid); err != nil {
+			return err
+		}
+
+		if prev.Status == "signed" {
+			return errors.New("note is already signed")
+		}
+
+		if prev.Author_uid != author_uid {
+			return errors.New("note can only signed by the author")
+		}
+
+		if res := tx.Model(&entities.Note{}).Where("visit_uid = ? and status = 'draft'", visit_uid).Updates(entities.Note{Status: "signed", SignedAt: &now}); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return entities.Note{}, err
+	}
+
+	prev.Status = "signed"
+	prev.SignedAt = &now
+
+	return prev, nil
+}
+
+func (r *Repo) Amend(visit_uid, author_uid string, req Revision) (entities.Note, error) {
+
+	if _, err := r.visit(visit_uid, author_uid); err != nil {
+		return entities.Note{}, err
+	}
+
+	var next entities.Note
+	var now = time.Now()
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		var prev, err = latest(locked(tx), visit_uid)
+		if err != nil {
+			return err
+		}
+
+		if prev.Status != "signed" {
+			return errors.New("note is not signed yet")
+		}
+
+		next = revise(prev, req)
+		next.Author_uid = author_uid
+		next.Kind = "amendment"
+		next.Status = "signed"
+		next.Reason = req.Reason
+		next.SignedAt = &now
+
+		if res := tx.Model(&entities.Note{}).Create(&next); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return entities.Note{}, err
+	}
+
+	return next, nil
+}
+
+func (r *Repo) GetNote(visit_uid string) (NoteResp, error) {
+
+	var versions []VersionResp
+
+	if res := r.db.Model(&entities.Note{}).Joins("left join doctors on notes.author_uid = doctors.doctor_uid").Where("notes.visit_uid = ?", visit_uid).Order("notes.version ASC").Select("notes.version as Version, notes.kind as Kind, notes.status as Status, notes.subjective as Subjective, notes.objective as Objective, notes.assessment as Assessment, notes.plan as Plan, notes.reason as Reason, notes.author_uid as Author_uid, doctors.name as AuthorName, date_format(notes.created_at, '%d-%m-%Y %H:%i') as CreatedAt, ifnull(date_format(notes.signed_at, '%d-%m-%Y %H:%i'), '') as SignedAt").Find(&versions); res.Error != nil || res.RowsAffected == 0 {
+		return NoteResp{}, gorm.ErrRecordNotFound
+	}
+
+	var prev, err = latest(r.db, visit_uid)
+	if err != nil {
+		return NoteResp{}, err
+	}
+
+	return NoteResp{
+		Note_uid:  prev.Note_uid,
+		Visit_uid: visit_uid,
+		Current:   versions[len(versions)-1],
+		Versions:  versions,
+	}, nil
+}
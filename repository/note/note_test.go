@@ -0,0 +1,162 @@
+package note
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func text(s string) *string {
+	return &s
+}
+
+func TestNote(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Note{})
+	db.AutoMigrate(&entities.Note{})
+
+	var setup = func(t *testing.T) (string, string) {
+		var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor"})
+		if err != nil {
+			log.Info(err)
+			t.Fatal()
+		}
+
+		var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient"})
+		if err1 != nil {
+			log.Info(err1)
+			t.Fatal()
+		}
+
+		var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick"})
+		if err2 != nil {
+			log.Info(err2)
+			t.Fatal()
+		}
+
+		return res2.Visit_uid, res.Doctor_uid
+	}
+
+	t.Run("success keep every version", func(t *testing.T) {
+		var visit_uid, doctor_uid = setup(t)
+
+		var res, err = r.Create(visit_uid, doctor_uid, entities.Note{Subjective: "headache"})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, res.Version)
+
+		_, err = r.Create(visit_uid, doctor_uid, entities.Note{Subjective: "headache"})
+		assert.NotNil(t, err)
+
+		res, err = r.Update(visit_uid, doctor_uid, Revision{Plan: text("rest")})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, res.Version)
+		assert.Equal(t, "headache", res.Subjective)
+
+		_, err = r.Amend(visit_uid, doctor_uid, Revision{Plan: text("rest"), Reason: "update"})
+		assert.NotNil(t, err)
+
+		res, err = r.Sign(visit_uid, doctor_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "signed", res.Status)
+
+		_, err = r.Update(visit_uid, doctor_uid, Revision{Plan: text("rest")})
+		assert.NotNil(t, err)
+
+		res, err = r.Amend(visit_uid, doctor_uid, Revision{Assessment: text("migraine"), Reason: "lab result"})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, res.Version)
+		assert.Equal(t, "amendment", res.Kind)
+
+		res, err = r.Amend(visit_uid, doctor_uid, Revision{Plan: text(""), Reason: "plan is not needed"})
+		assert.Nil(t, err)
+		assert.Equal(t, 4, res.Version)
+		assert.Equal(t, "", res.Plan)
+		assert.Equal(t, "headache", res.Subjective)
+
+		var res1, err1 = r.GetNote(visit_uid)
+		assert.Nil(t, err1)
+		assert.Equal(t, 4, len(res1.Versions))
+		assert.Equal(t, "migraine", res1.Current.Assessment)
+	})
+
+	t.Run("locked after visit completed", func(t *testing.T) {
+		var visit_uid, doctor_uid = setup(t)
+
+		if _, err := r.Create(visit_uid, doctor_uid, entities.Note{Subjective: "cough"}); err != nil {
+			log.Info(err)
+			t.Fatal()
+		}
+
+		if _, err := visit.New(db).Update(visit_uid, entities.Visit{MainDiagnose: "common cold"}); err != nil {
+			log.Info(err)
+			t.Fatal()
+		}
+
+		var _, err = r.Update(visit_uid, doctor_uid, Revision{Plan: text("rest")})
+		assert.NotNil(t, err)
+
+		var res, err1 = r.GetNote(visit_uid)
+		assert.Nil(t, err1)
+		assert.Equal(t, "signed", res.Current.Status)
+	})
+
+	t.Run("doctor and patient of another visit", func(t *testing.T) {
+		var visit_uid, doctor_uid = setup(t)
+		var other_visit, other_doctor = setup(t)
+
+		var _, err = r.Create(visit_uid, other_doctor, entities.Note{Subjective: "headache"})
+		assert.NotNil(t, err)
+
+		if _, err := r.Create(visit_uid, doctor_uid, entities.Note{Subjective: "headache"}); err != nil {
+			log.Info(err)
+			t.Fatal()
+		}
+
+		_, err = r.Update(visit_uid, other_doctor, Revision{Plan: text("rest")})
+		assert.NotNil(t, err)
+
+		_, err = r.Sign(visit_uid, other_doctor)
+		assert.NotNil(t, err)
+
+		if _, err := r.Sign(visit_uid, doctor_uid); err != nil {
+			log.Info(err)
+			t.Fatal()
+		}
+
+		_, err = r.Amend(visit_uid, other_doctor, Revision{Assessment: text("migraine"), Reason: "lab result"})
+		assert.NotNil(t, err)
+
+		res, err := r.GetNote(visit_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, doctor_uid, res.Current.Author_uid)
+
+		// the visit uid starts with the patient uid
+
+		var patient_uid = visit_uid[:strings.LastIndex(visit_uid, "-")]
+		var other_patient = other_visit[:strings.LastIndex(other_visit, "-")]
+
+		assert.Nil(t, r.CheckAccess(visit_uid, doctor_uid))
+		assert.Nil(t, r.CheckAccess(visit_uid, patient_uid))
+		assert.NotNil(t, r.CheckAccess(visit_uid, other_doctor))
+		assert.NotNil(t, r.CheckAccess(visit_uid, other_patient))
+	})
+
+	t.Run("invalid visit", func(t *testing.T) {
+		var _, err = r.Create(shortuuid.New(), shortuuid.New(), entities.Note{Subjective: "cough"})
+		assert.NotNil(t, err)
+
+		_, err = r.GetNote(shortuuid.New())
+		assert.NotNil(t, err)
+	})
+}
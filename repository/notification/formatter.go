@@ -0,0 +1,32 @@
+package notification
+
+import (
+	"be/entities"
+	"be/repository/visit"
+	"be/utils/list"
+)
+
+// Recipient is the doctor or patient a notification goes to, only a patient
+// has a phone, a doctor sets one in the preference
+
+type Recipient struct {
+	Kind  string
+	Name  string
+	Email string
+	Phone string
+}
+
+// Visit is the visit of a notification as it is now, a reminder of a
+// deleted or cancelled visit is not sent
+
+type Visit struct {
+	visit.VisitCalendar
+	Deleted bool
+}
+
+// Delivery is an attempt in the list of the deliveries of the user
+
+type Delivery struct {
+	entities.NotificationDelivery
+	list.Row
+}
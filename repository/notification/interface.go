@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"be/entities"
+	"be/utils/list"
+	"time"
+)
+
+type Notification interface {
+	GetPreference(user_uid string) (entities.NotificationPreference, error)
+	SetPreference(pref entities.NotificationPreference) (entities.NotificationPreference, error)
+	GetRecipient(user_uid string) (Recipient, error)
+	GetVisit(visit_uid string) (Visit, error)
+	EnqueueReminders(date, next_at time.Time) (int, error)
+	GetDue(now time.Time, limit int) ([]entities.Notification, error)
+	Claim(id uint, now time.Time) (entities.Notification, error)
+	Finish(id uint) error
+	Retry(id uint, next_at time.Time, message string) error
+	Bury(id uint, message string) error
+	GetDone(notification_id uint) ([]string, error)
+	LogDelivery(delivery entities.NotificationDelivery) error
+	GetClinic(admin_uid string) (string, error)
+	InClinic(clinic_uid, user_uid string) error
+	GetDeliveries(user_uid string, q list.Query) ([]Delivery, list.Page, error)
+}
@@ -0,0 +1,289 @@
+package notification
+
+import (
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/visit"
+	"be/utils/list"
+	"be/utils/notice"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// a notification running this long was left by a stopped worker and is run
+// again
+
+const staleAfter = 10 * time.Minute
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// GetPreference is the channels of the user, email only until the user sets
+// them
+func (r *Repo) GetPreference(user_uid string) (entities.NotificationPreference, error) {
+
+	var pref entities.NotificationPreference
+
+	if res := r.db.Model(&entities.NotificationPreference{}).Where("user_uid = ?", user_uid).Find(&pref); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.NotificationPreference{}, res.Error
+	} else if res.RowsAffected == 0 {
+		return entities.NotificationPreference{User_uid: user_uid, Email: true}, nil
+	}
+
+	return pref, nil
+}
+
+func (r *Repo) SetPreference(pref entities.NotificationPreference) (entities.NotificationPreference, error) {
+
+	if res := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email", "sms", "whatsapp", "phone", "updated_at"}),
+	}).Create(&pref); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.NotificationPreference{}, res.Error
+	}
+
+	return r.GetPreference(pref.User_uid)
+}
+
+// GetRecipient finds the user in the patients, then the doctors
+func (r *Repo) GetRecipient(user_uid string) (Recipient, error) {
+
+	var res Recipient
+
+	if err := r.db.Model(&entities.Patient{}).Where("patient_uid = ?", user_uid).Select("'patient' as Kind, name as Name, email as Email, phone as Phone").Limit(1).Find(&res); err.Error != nil {
+		return Recipient{}, err.Error
+	} else if err.RowsAffected != 0 {
+		return res, nil
+	}
+
+	if err := r.db.Model(&entities.Doctor{}).Where("doctor_uid = ?", user_uid).Select("'doctor' as Kind, name as Name, email as Email").Limit(1).Find(&res); err.Error != nil || err.RowsAffected == 0 {
+		return Recipient{}, gorm.ErrRecordNotFound
+	}
+
+	return res, nil
+}
+
+// GetVisit is the visit as it is now, also a deleted one
+func (r *Repo) GetVisit(visit_uid string) (Visit, error) {
+
+	var res Visit
+
+	if err := r.db.Model(&entities.Visit{}).Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on visits.doctor_uid = doctors.doctor_uid").Unscoped().Where("visits.visit_uid = ?", visit_uid).Select(visit.CalendarColumns + ", visits.deleted_at is not null as Deleted").Order("visits.id desc").Limit(1).Find(&res); err.Error != nil || err.RowsAffected == 0 {
+		return Visit{}, gorm.ErrRecordNotFound
+	}
+
+	return res, nil
+}
+
+// EnqueueReminders adds a reminder to the patient of each visit on the date
+// yet to come, sent at next_at. A visit already reminded is skipped
+func (r *Repo) EnqueueReminders(date, next_at time.Time) (int, error) {
+
+	var visits []entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("date = ? and status in ('pending', 'ready')", date.Format("2006-01-02")).Select("visit_uid, patient_uid").Find(&visits); res.Error != nil {
+		log.Warn(res.Error)
+		return 0, res.Error
+	}
+
+	var notifications = []entities.Notification{}
+	for _, v := range visits {
+		notifications = append(notifications, entities.Notification{
+			Dedup_key: notice.Key(notice.Reminder, v.Visit_uid, v.Patient_uid),
+			Kind:      notice.Reminder,
+			User_uid:  v.Patient_uid,
+			Visit_uid: v.Visit_uid,
+			Next_at:   next_at,
+		})
+	}
+
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+
+	var res = r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&notifications, 100)
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return 0, res.Error
+	}
+
+	return int(res.RowsAffected), nil
+}
+
+// GetDue is the notifications to send now, oldest first
+func (r *Repo) GetDue(now time.Time, limit int) ([]entities.Notification, error) {
+
+	var notifications []entities.Notification
+
+	if res := r.db.Model(&entities.Notification{}).Where("(status = 'pending' and next_at <= ?) or (status = 'running' and updated_at < ?)", now, now.Add(-staleAfter)).Order("id").Limit(limit).Find(&notifications); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return notifications, nil
+}
+
+// Claim counts an attempt of the notification, one claimed by another worker
+// is not found
+func (r *Repo) Claim(id uint, now time.Time) (entities.Notification, error) {
+
+	if res := r.db.Model(&entities.Notification{}).Where("id = ? and ((status = 'pending' and next_at <= ?) or (status = 'running' and updated_at < ?))", id, now, now.Add(-staleAfter)).Updates(map[string]interface{}{"status": "running", "attempts": gorm.Expr("attempts + 1"), "updated_at": now}); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Notification{}, gorm.ErrRecordNotFound
+	}
+
+	var notification entities.Notification
+
+	if res := r.db.Model(&entities.Notification{}).Where("id = ?", id).Find(&notification); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Notification{}, gorm.ErrRecordNotFound
+	}
+
+	return notification, nil
+}
+
+func (r *Repo) Finish(id uint) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.Notification{}).Where("id = ?", id).Updates(map[string]interface{}{"status": "done", "last_error": "", "finished_at": &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// Retry sends the notification again at next_at, on the channels not sent
+func (r *Repo) Retry(id uint, next_at time.Time, message string) error {
+
+	if res := r.db.Model(&entities.Notification{}).Where("id = ?", id).Updates(map[string]interface{}{"status": "pending", "next_at": next_at, "last_error": message}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) Bury(id uint, message string) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.Notification{}).Where("id = ?", id).Updates(map[string]interface{}{"status": "dead", "last_error": message, "finished_at": &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// GetDone is the channels the notification was sent or skipped on, a retry
+// doesn't try them again
+func (r *Repo) GetDone(notification_id uint) ([]string, error) {
+
+	var channels []string
+
+	if res := r.db.Model(&entities.NotificationDelivery{}).Where("notification_id = ? and status in ('sent', 'skipped')", notification_id).Distinct().Pluck("channel", &channels); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return channels, nil
+}
+
+func (r *Repo) LogDelivery(delivery entities.NotificationDelivery) error {
+
+	if res := r.db.Create(&delivery); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// GetDeliveries is the last attempts to notify the user, newest first
+// GetClinic returns the doctor the admin account belongs to
+
+func (r *Repo) GetClinic(admin_uid string) (string, error) {
+
+	var clinic, err = doctor.GetClinic(r.db, admin_uid)
+	if err != nil {
+		return "", err
+	}
+
+	return clinic.Doctor_uid, nil
+}
+
+// InClinic tells whether the user is the doctor of the clinic or a patient
+// with a visit to it
+func (r *Repo) InClinic(clinic_uid, user_uid string) error {
+
+	if user_uid == clinic_uid {
+		return nil
+	}
+
+	if res := r.db.Model(&entities.Visit{}).Where("doctor_uid = ? and patient_uid = ?", clinic_uid, user_uid).Limit(1).Find(&[]entities.Visit{}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	} else if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Deliveries is what the list of deliveries can be sorted and filtered by
+
+var Deliveries = list.Spec{
+	Sorts: map[string]list.Field{
+		"createdAt": {Column: "created_at", Type: list.Date},
+	},
+	Sort: "-createdAt",
+	Key:  "id",
+	Filters: map[string]list.Field{
+		"kind":    {Column: "kind", Type: list.String},
+		"channel": {Column: "channel", Type: list.Enum, Values: []string{"email", "sms", "whatsapp"}},
+		"status":  {Column: "status", Type: list.Enum, Values: []string{"sent", "failed", "skipped"}},
+		"created": {Column: "date(created_at)", Type: list.Date},
+	},
+}
+
+func (r *Repo) GetDeliveries(user_uid string, q list.Query) ([]Delivery, list.Page, error) {
+
+	var db = q.Filter(r.db.Model(&entities.NotificationDelivery{}).Where("user_uid = ?", user_uid))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return nil, list.Page{}, err
+	}
+
+	var deliveries = []Delivery{}
+
+	if res := q.Page(db).Select("notification_deliveries.*" + q.Select()).Find(&deliveries); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, list.Page{}, res.Error
+	}
+
+	var more = len(deliveries) > q.Limit
+	if more {
+		deliveries = deliveries[:q.Limit]
+	}
+
+	var last list.Row
+	if len(deliveries) != 0 {
+		last = deliveries[len(deliveries)-1].Row
+	}
+
+	return deliveries, q.Result(total, more, last), nil
+}
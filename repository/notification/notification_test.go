@@ -0,0 +1,157 @@
+package notification
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"be/utils/list"
+	"be/utils/notice"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestNotification(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Notification{}, &entities.NotificationPreference{}, &entities.NotificationDelivery{})
+	db.AutoMigrate(&entities.Notification{}, &entities.NotificationPreference{}, &entities.NotificationDelivery{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456", Name: "siti", Phone: "08123456789"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var tomorrow = time.Now().AddDate(0, 0, 1)
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(tomorrow)})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	t.Run("booking confirmed to the patient and the doctor", func(t *testing.T) {
+		var due, err = r.GetDue(time.Now().Add(time.Second), 10)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(due))
+		assert.Equal(t, notice.BookingConfirmed, due[0].Kind)
+		assert.Equal(t, res2.Visit_uid, due[0].Visit_uid)
+	})
+
+	t.Run("success preference", func(t *testing.T) {
+		var pref, err = r.GetPreference(res.Doctor_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, true, pref.Email)
+		assert.Equal(t, false, pref.Sms)
+
+		pref, err = r.SetPreference(entities.NotificationPreference{User_uid: res.Doctor_uid, Whatsapp: true, Phone: "0811111111"})
+		assert.Nil(t, err)
+		pref, err = r.SetPreference(entities.NotificationPreference{User_uid: res.Doctor_uid, Sms: true, Phone: "0822222222"})
+		assert.Nil(t, err)
+		assert.Equal(t, false, pref.Email)
+		assert.Equal(t, true, pref.Sms)
+		assert.Equal(t, false, pref.Whatsapp)
+		assert.Equal(t, "0822222222", pref.Phone)
+	})
+
+	t.Run("success recipient and visit", func(t *testing.T) {
+		var recipient, err = r.GetRecipient(res1.Patient_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, Recipient{Kind: "patient", Name: "siti", Email: res1.Email, Phone: "08123456789"}, recipient)
+
+		recipient, err = r.GetRecipient(res.Doctor_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "doctor", recipient.Kind)
+
+		_, err = r.GetRecipient(shortuuid.New())
+		assert.NotNil(t, err)
+
+		v, err := r.GetVisit(res2.Visit_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, "andi", v.DoctorName)
+		assert.Equal(t, false, v.Deleted)
+	})
+
+	t.Run("reminders are enqueued once", func(t *testing.T) {
+		var count, err = r.EnqueueReminders(tomorrow, time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+
+		count, err = r.EnqueueReminders(tomorrow, time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("claim, deliveries and retry", func(t *testing.T) {
+		var now = time.Now().Add(time.Second)
+		var due, _ = r.GetDue(now, 10)
+
+		var notification, err = r.Claim(due[0].ID, now)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, notification.Attempts)
+
+		_, err = r.Claim(due[0].ID, now)
+		assert.NotNil(t, err)
+
+		assert.Nil(t, r.LogDelivery(entities.NotificationDelivery{Notification_id: notification.ID, User_uid: notification.User_uid, Kind: notification.Kind, Channel: "email", Status: "sent"}))
+		assert.Nil(t, r.LogDelivery(entities.NotificationDelivery{Notification_id: notification.ID, User_uid: notification.User_uid, Kind: notification.Kind, Channel: "sms", Status: "failed", Error: "down"}))
+
+		channels, err := r.GetDone(notification.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"email"}, channels)
+
+		assert.Nil(t, r.Retry(notification.ID, now.Add(time.Hour), "down"))
+		_, err = r.Claim(notification.ID, now)
+		assert.NotNil(t, err)
+
+		var q, _ = list.Parse(Deliveries, url.Values{"limit": {"1"}})
+
+		deliveries, page, err := r.GetDeliveries(notification.User_uid, q)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(deliveries))
+		assert.Equal(t, int64(2), page.Total)
+		assert.NotEqual(t, "", page.Next_cursor)
+		assert.Equal(t, "sms", deliveries[0].Channel)
+
+		assert.Nil(t, r.InClinic(res.Doctor_uid, res.Doctor_uid))
+		assert.Nil(t, r.InClinic(res.Doctor_uid, res1.Patient_uid))
+		assert.NotNil(t, r.InClinic(res.Doctor_uid, shortuuid.New()))
+
+		assert.Nil(t, r.Bury(notification.ID, "down"))
+		assert.Nil(t, r.Finish(due[1].ID))
+	})
+
+	t.Run("cancel of the visit", func(t *testing.T) {
+		var _, err = visit.New(db).Delete(res2.Visit_uid)
+		assert.Nil(t, err)
+
+		due, err := r.GetDue(time.Now().Add(time.Second), 10)
+		assert.Nil(t, err)
+
+		var kinds = []string{}
+		for _, notification := range due {
+			kinds = append(kinds, notification.Kind)
+		}
+		assert.Contains(t, kinds, notice.Cancelled)
+
+		v, err := r.GetVisit(res2.Visit_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, true, v.Deleted)
+	})
+}
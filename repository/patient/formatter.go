@@ -1,19 +1,26 @@
 package patient
 
+import (
+	"be/utils/list"
+	"be/utils/photo"
+)
+
 type Profile struct {
-	Patient_uid string `json:"patient_uid"`
-	UserName    string `json:"userName"`
-	Email       string `json:"email"`
-	Nik         string `json:"nik"`
-	Name        string `json:"name"`
-	Image       string `json:"image"`
-	Gender      string `json:"gender"`
-	Address     string `json:"address"`
-	PlaceBirth  string `json:"placeBirth"`
-	Dob         string `json:"dob"`
-	Religion    string `json:"religion"`
-	Status      string `json:"status"`
-	Job         string `json:"job"`
+	Patient_uid string     `json:"patient_uid"`
+	UserName    string     `json:"userName"`
+	Email       string     `json:"email"`
+	Nik         string     `json:"nik"`
+	Name        string     `json:"name"`
+	Phone       string     `json:"phone"`
+	Image       string     `json:"image"`
+	Images      photo.Urls `json:"images" gorm:"-"`
+	Gender      string     `json:"gender"`
+	Address     string     `json:"address"`
+	PlaceBirth  string     `json:"placeBirth"`
+	Dob         string     `json:"dob"`
+	Religion    string     `json:"religion"`
+	Status      string     `json:"status"`
+	Job         string     `json:"job"`
 }
 
 type Apppoinment struct {
@@ -28,6 +35,7 @@ type PatientAll struct {
 	Nik         string `json:"nik"`
 	Name        string `json:"name"`
 	Gender      string `json:"gender"`
+	list.Row
 }
 
 type All struct {
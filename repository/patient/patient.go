@@ -3,6 +3,7 @@ package patient
 import (
 	"be/entities"
 	"be/utils"
+	"be/utils/list"
 	"errors"
 	"strconv"
 
@@ -134,6 +135,7 @@ func (r *Repo) Update(patient_uid string, req entities.Patient) (entities.Patien
 		Password:   req.Password,
 		Nik:        req.Nik,
 		Name:       req.Name,
+		Phone:      req.Phone,
 		Image:      req.Image,
 		Gender:     req.Gender,
 		Address:    req.Address,
@@ -166,18 +168,22 @@ func (r *Repo) Delete(patient_uid string) (entities.Patient, error) {
 
 func (r *Repo) GetProfile(patient_uid, userName, email string) (Profile, error) {
 
+	var db = r.db.Model(&entities.Patient{})
+
 	switch {
 	case patient_uid != "":
-		patient_uid = "patient_uid = '" + patient_uid + "'"
+		db = db.Where("patient_uid = ?", patient_uid)
 	case userName != "":
-		patient_uid = "user_name = '" + userName + "'"
+		db = db.Where("user_name = ?", userName)
 	case email != "":
-		patient_uid = "email = '" + email + "'"
+		db = db.Where("email = ?", email)
+	default:
+		return Profile{}, gorm.ErrRecordNotFound
 	}
 
 	var profileResp Profile
 
-	if res := r.db.Model(&entities.Patient{}).Where(patient_uid).Select("patient_uid as Patient_uid, user_name as UserName, email as Email, nik as Nik, name as Name, image as Image, gender as Gender, address as Address, place_birth as PlaceBirth, date_format(dob, '%d-%m-%Y') as Dob, religion as Religion, status as Status, job as Job").Find(&profileResp); res.Error != nil || res.RowsAffected == 0 {
+	if res := db.Select("patient_uid as Patient_uid, user_name as UserName, email as Email, nik as Nik, name as Name, phone as Phone, image as Image, gender as Gender, address as Address, place_birth as PlaceBirth, date_format(dob, '%d-%m-%Y') as Dob, religion as Religion, status as Status, job as Job").Find(&profileResp); res.Error != nil || res.RowsAffected == 0 {
 		return Profile{}, gorm.ErrRecordNotFound
 	}
 
@@ -193,13 +199,49 @@ func (r *Repo) GetProfile(patient_uid, userName, email string) (Profile, error)
 	return profileResp, nil
 }
 
-func (r *Repo) GetAll() (All, error) {
+// List is what the list of patients can be sorted and filtered by, the rows
+// are grouped by nik
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"name":      {Column: "patients.name"},
+		"nik":       {Column: "patients.nik"},
+		"createdAt": {Column: "patients.created_at", Type: list.Date},
+	},
+	Sort: "name",
+	Key:  "patients.nik",
+	Filters: map[string]list.Field{
+		"name":   {Column: "patients.name", Type: list.Search},
+		"nik":    {Column: "patients.nik", Type: list.String},
+		"gender": {Column: "patients.gender", Type: list.Enum, Values: []string{"pria", "wanita", "lainnya"}},
+	},
+}
+
+func (r *Repo) GetAll(q list.Query) (All, list.Page, error) {
+	var db = q.Filter(r.db.Model(&entities.Patient{}).Group("nik"))
 
-	var patientAll All
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return All{}, list.Page{}, err
+	}
+
+	var patientAll = All{Patients: []PatientAll{}}
+
+	if res := q.Page(db).Select("patient_uid as Patient_uid, nik as Nik, name as Name, gender as Gender" + q.Select()).Find(&patientAll.Patients); res.Error != nil {
+		log.Warn(res.Error)
+		return All{}, list.Page{}, res.Error
+	}
+
+	var more = len(patientAll.Patients) > q.Limit
+	if more {
+		patientAll.Patients = patientAll.Patients[:q.Limit]
+	}
 
-	if res := r.db.Model(&entities.Patient{}).Group("nik").Find(&patientAll.Patients); res.Error != nil || res.RowsAffected == 0 {
-		return All{}, gorm.ErrRecordNotFound
+	var last list.Row
+	if len(patientAll.Patients) != 0 {
+		last = patientAll.Patients[len(patientAll.Patients)-1].Row
 	}
 
-	return patientAll, nil
+	return patientAll, q.Result(total, more, last), nil
 }
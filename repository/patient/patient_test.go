@@ -5,6 +5,8 @@ import (
 	"be/entities"
 	"be/repository/doctor"
 	"be/utils"
+	"be/utils/list"
+	"net/url"
 	"testing"
 	"time"
 
@@ -281,6 +283,12 @@ func TestGetProfile(t *testing.T) {
 
 		var _, err1 = r.GetProfile(shortuuid.New(), "", "")
 		assert.NotNil(t, err1)
+
+		_, err1 = r.GetProfile("' or '1'='1", "", "")
+		assert.NotNil(t, err1)
+
+		_, err1 = r.GetProfile("", "", "")
+		assert.NotNil(t, err1)
 		// log.Info(res1)
 	})
 }
@@ -321,9 +329,16 @@ func TestGetAll(t *testing.T) {
 			log.Fatal()
 		}
 
-		res, err := r.GetAll()
+		var q, _ = list.Parse(List, url.Values{"limit": {"2"}})
+		res, page, err := r.GetAll(q)
 		assert.Nil(t, err)
-		assert.NotNil(t, res)
-		log.Info(res)
+		assert.Equal(t, 2, len(res.Patients))
+		assert.Equal(t, int64(3), page.Total)
+
+		q, _ = list.Parse(List, url.Values{"cursor": {page.Next_cursor}})
+		res, page, err = r.GetAll(q)
+		assert.Nil(t, err)
+		assert.Equal(t, "nik 3", res.Patients[0].Nik)
+		assert.Equal(t, "", page.Next_cursor)
 	})
 }
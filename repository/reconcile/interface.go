@@ -0,0 +1,12 @@
+package reconcile
+
+import (
+	"be/entities"
+	"time"
+)
+
+type Reconcile interface {
+	GetKeys() (map[string]bool, error)
+	GetStaleUploads(before time.Time) ([]entities.Upload, error)
+	FailUpload(upload_uid string) error
+}
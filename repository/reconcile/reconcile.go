@@ -0,0 +1,77 @@
+package reconcile
+
+import (
+	"be/entities"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// GetKeys is every storage key still referred to: the images of the doctors
+// and patients, the attachments, the exports and the uploads going on. Soft
+// deleted rows don't count, their files are left over
+func (r *Repo) GetKeys() (map[string]bool, error) {
+
+	var sources = []struct {
+		model  interface{}
+		column string
+		where  string
+	}{
+		{&entities.Doctor{}, "image", "image <> ''"},
+		{&entities.Patient{}, "image", "image <> ''"},
+		{&entities.Attachment{}, "object_key", "object_key <> ''"},
+		{&entities.Export{}, "object_key", "object_key <> ''"},
+		{&entities.Upload{}, "object_key", "status = 'uploading'"},
+	}
+
+	var keys = map[string]bool{}
+
+	for _, source := range sources {
+		var values []string
+
+		if res := r.db.Model(source.model).Where(source.where).Pluck(source.column, &values); res.Error != nil {
+			log.Warn(res.Error)
+			return nil, res.Error
+		}
+
+		for _, value := range values {
+			keys[value] = true
+		}
+	}
+
+	return keys, nil
+}
+
+// GetStaleUploads is the uploads going on without a part since before
+func (r *Repo) GetStaleUploads(before time.Time) ([]entities.Upload, error) {
+
+	var uploads []entities.Upload
+
+	if res := r.db.Model(&entities.Upload{}).Where("status = 'uploading' and updated_at < ?", before).Find(&uploads); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return uploads, nil
+}
+
+func (r *Repo) FailUpload(upload_uid string) error {
+
+	if res := r.db.Model(&entities.Upload{}).Where("upload_uid = ? and status = 'uploading'", upload_uid).Update("status", "failed"); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
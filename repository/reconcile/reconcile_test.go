@@ -0,0 +1,50 @@
+package reconcile
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/utils"
+	"testing"
+	"time"
+
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+
+	var doctor = entities.Doctor{Doctor_uid: shortuuid.New(), UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Image: "photos/" + shortuuid.New()}
+	var deleted = entities.Doctor{Doctor_uid: shortuuid.New(), UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Image: "photos/" + shortuuid.New()}
+	db.Create(&doctor)
+	db.Create(&deleted)
+	db.Delete(&deleted)
+
+	var attachment = entities.Attachment{Attachment_uid: shortuuid.New(), Object_key: "attachments/" + shortuuid.New()}
+	db.Create(&attachment)
+
+	var upload = entities.Upload{Upload_uid: shortuuid.New(), Object_key: "attachments/" + shortuuid.New(), Status: "uploading"}
+	db.Create(&upload)
+
+	t.Run("success get keys", func(t *testing.T) {
+		var keys, err = r.GetKeys()
+		assert.Nil(t, err)
+		assert.True(t, keys[doctor.Image])
+		assert.False(t, keys[deleted.Image])
+		assert.True(t, keys[attachment.Object_key])
+		assert.True(t, keys[upload.Object_key])
+		assert.False(t, keys[""])
+	})
+
+	t.Run("success stale uploads", func(t *testing.T) {
+		var res, err = r.GetStaleUploads(time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.NotEqual(t, 0, len(res))
+
+		assert.Nil(t, r.FailUpload(upload.Upload_uid))
+		var keys, _ = r.GetKeys()
+		assert.False(t, keys[upload.Object_key])
+	})
+}
@@ -0,0 +1,34 @@
+package referral
+
+import "be/utils/list"
+
+type ReferralResp struct {
+	Referral_uid     string `json:"referral_uid"`
+	Source_visit_uid string `json:"source_visit_uid"`
+	Target_visit_uid string `json:"target_visit_uid"`
+	From_doctor_uid  string `json:"from_doctor_uid"`
+	FromDoctorName   string `json:"fromDoctorName"`
+	To_doctor_uid    string `json:"to_doctor_uid"`
+	ToDoctorName     string `json:"toDoctorName"`
+	Patient_uid      string `json:"patient_uid"`
+	PatientName      string `json:"patientName"`
+	Reason           string `json:"reason"`
+	Urgency          string `json:"urgency"`
+	Status           string `json:"status"`
+	Response         string `json:"response"`
+	CreatedAt        string `json:"createdAt"`
+	RespondedAt      string `json:"respondedAt"`
+
+	// outcome of the visit with the target doctor
+
+	TargetDate         string `json:"targetDate"`
+	TargetStatus       string `json:"targetStatus"`
+	TargetMainDiagnose string `json:"targetMainDiagnose"`
+	TargetAction       string `json:"targetAction"`
+
+	list.Row
+}
+
+type Referrals struct {
+	Referrals []ReferralResp `json:"referrals"`
+}
@@ -0,0 +1,14 @@
+package referral
+
+import (
+	"be/entities"
+	"be/utils/list"
+)
+
+type Referral interface {
+	Create(visit_uid, doctor_uid string, req entities.Referral) (entities.Referral, error)
+	Accept(referral_uid, doctor_uid string, req entities.Visit) (entities.Referral, error)
+	Decline(referral_uid, doctor_uid, response string) (entities.Referral, error)
+	GetReferral(referral_uid string) (ReferralResp, error)
+	GetReferrals(kind, doctor_uid string, q list.Query) (Referrals, list.Page, error)
+}
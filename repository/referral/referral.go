@@ -0,0 +1,268 @@
+package referral
+
+import (
+	"be/entities"
+	"be/utils/busy"
+	"be/utils/list"
+	"be/utils/notice"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) Create(visit_uid, doctor_uid string, req entities.Referral) (entities.Referral, error) {
+
+	// check source visit
+
+	var visit entities.Visit
+
+	if res := r.db.Model(&entities.Visit{}).Where("visit_uid = ? and doctor_uid = ?", visit_uid, doctor_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Referral{}, gorm.ErrRecordNotFound
+	}
+
+	if visit.Status == "cancelled" {
+		return entities.Referral{}, errors.New("visit is cancelled")
+	}
+
+	// check target doctor
+
+	if req.To_doctor_uid == doctor_uid {
+		return entities.Referral{}, errors.New("can't refer to yourself")
+	}
+
+	var target entities.Doctor
+
+	if res := r.db.Model(&entities.Doctor{}).Where("doctor_uid = ? and type = 'doctor'", req.To_doctor_uid).Find(&target); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Referral{}, errors.New("target doctor is not found")
+	}
+
+	if target.Status == "unAvailable" {
+		return entities.Referral{}, errors.New("target doctor is unavailable")
+	}
+
+	// check referral
+
+	var check entities.Referral
+
+	if res := r.db.Model(&entities.Referral{}).Where("source_visit_uid = ? and to_doctor_uid = ? and status in ('sent', 'accepted')", visit_uid, req.To_doctor_uid).Find(&check); res.RowsAffected != 0 {
+		return entities.Referral{}, errors.New("referral is already exist")
+	}
+
+	req.Referral_uid = shortuuid.New()
+	req.Source_visit_uid = visit_uid
+	req.From_doctor_uid = doctor_uid
+	req.Patient_uid = visit.Patient_uid
+	req.Status = "sent"
+
+	if res := r.db.Model(&entities.Referral{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Referral{}, res.Error
+	}
+
+	return req, nil
+}
+
+func (r *Repo) Accept(referral_uid, doctor_uid string, req entities.Visit) (entities.Referral, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Error; err != nil {
+		return entities.Referral{}, err
+	}
+
+	var referral entities.Referral
+
+	if res := tx.Model(&entities.Referral{}).Where("referral_uid = ? and to_doctor_uid = ?", referral_uid, doctor_uid).Find(&referral); res.Error != nil || res.RowsAffected == 0 {
+		tx.Rollback()
+		return entities.Referral{}, gorm.ErrRecordNotFound
+	}
+
+	if referral.Status != "sent" {
+		tx.Rollback()
+		return entities.Referral{}, errors.New("referral is already " + referral.Status)
+	}
+
+	// check if there's appoinment
+
+	var checkVisit entities.Visit
+
+	if res := tx.Model(&entities.Visit{}).Where("patient_uid = ? and status = 'pending'", referral.Patient_uid).Find(&checkVisit); res.Error != nil || res.RowsAffected != 0 {
+		tx.Rollback()
+		return entities.Referral{}, errors.New("there's another appoinment in pending")
+	}
+
+	// the doctor blocked the day in their own calendar
+
+	if blocked, err := busy.Blocked(tx, doctor_uid, time.Time(req.Date)); err != nil {
+		tx.Rollback()
+		return entities.Referral{}, err
+	} else if blocked {
+		tx.Rollback()
+		return entities.Referral{}, errors.New("doctor is busy on the date")
+	}
+
+	// the complaint of the new visit is taken from the source visit
+
+	var source entities.Visit
+
+	if res := tx.Model(&entities.Visit{}).Where("visit_uid = ?", referral.Source_visit_uid).Find(&source); res.Error != nil || res.RowsAffected == 0 {
+		tx.Rollback()
+		return entities.Referral{}, gorm.ErrRecordNotFound
+	}
+
+	var res = tx.Unscoped().Model(&entities.Visit{}).Where("patient_uid = ?", referral.Patient_uid).Scan(&[]entities.Visit{})
+	var uid string = referral.Patient_uid + "-" + strconv.Itoa(int(res.RowsAffected)+1)
+
+	var visit = entities.Visit{
+		Visit_uid:   uid,
+		Doctor_uid:  doctor_uid,
+		Patient_uid: referral.Patient_uid,
+		Date:        req.Date,
+		Status:      "pending",
+		Complaint:   "referral: " + referral.Reason + "\n" + source.Complaint,
+	}
+
+	if res := tx.Model(&entities.Visit{}).Create(&visit); res.Error != nil {
+		tx.Rollback()
+		return entities.Referral{}, res.Error
+	}
+
+	var now = time.Now()
+
+	if res := tx.Create(&entities.CalendarSync{Visit_uid: uid, Next_at: now}); res.Error != nil {
+		tx.Rollback()
+		return entities.Referral{}, res.Error
+	}
+
+	if err := notice.Enqueue(tx, notice.BookingConfirmed, uid, uid, referral.Patient_uid, doctor_uid); err != nil {
+		tx.Rollback()
+		return entities.Referral{}, err
+	}
+
+	if res := tx.Model(&entities.Referral{}).Where("referral_uid = ?", referral_uid).Updates(entities.Referral{Status: "accepted", Target_visit_uid: uid, RespondedAt: &now}); res.Error != nil {
+		tx.Rollback()
+		return entities.Referral{}, res.Error
+	}
+
+	referral.Status = "accepted"
+	referral.Target_visit_uid = uid
+	referral.RespondedAt = &now
+
+	return referral, tx.Commit().Error
+}
+
+func (r *Repo) Decline(referral_uid, doctor_uid, response string) (entities.Referral, error) {
+
+	var referral entities.Referral
+
+	if res := r.db.Model(&entities.Referral{}).Where("referral_uid = ? and to_doctor_uid = ?", referral_uid, doctor_uid).Find(&referral); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Referral{}, gorm.ErrRecordNotFound
+	}
+
+	if referral.Status != "sent" {
+		return entities.Referral{}, errors.New("referral is already " + referral.Status)
+	}
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.Referral{}).Where("referral_uid = ?", referral_uid).Updates(entities.Referral{Status: "declined", Response: response, RespondedAt: &now}); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Referral{}, res.Error
+	}
+
+	referral.Status = "declined"
+	referral.Response = response
+	referral.RespondedAt = &now
+
+	return referral, nil
+}
+
+var query = "referrals.referral_uid as Referral_uid, referrals.source_visit_uid as Source_visit_uid, referrals.target_visit_uid as Target_visit_uid, referrals.from_doctor_uid as From_doctor_uid, source.name as FromDoctorName, referrals.to_doctor_uid as To_doctor_uid, target.name as ToDoctorName, referrals.patient_uid as Patient_uid, patients.name as PatientName, referrals.reason as Reason, referrals.urgency as Urgency, referrals.status as Status, referrals.response as Response, date_format(referrals.created_at, '%d-%m-%Y %H:%i') as CreatedAt, ifnull(date_format(referrals.responded_at, '%d-%m-%Y %H:%i'), '') as RespondedAt, ifnull(date_format(visits.date, '%d-%m-%Y'), '') as TargetDate, ifnull(visits.status, '') as TargetStatus, ifnull(visits.main_diagnose, '') as TargetMainDiagnose, ifnull(visits.action, '') as TargetAction"
+
+func (r *Repo) find() *gorm.DB {
+	return r.db.Model(&entities.Referral{}).Joins("inner join doctors source on referrals.from_doctor_uid = source.doctor_uid").Joins("inner join doctors target on referrals.to_doctor_uid = target.doctor_uid").Joins("inner join patients on referrals.patient_uid = patients.patient_uid").Joins("left join visits on referrals.target_visit_uid = visits.visit_uid and visits.deleted_at is null").Select(query)
+}
+
+func (r *Repo) GetReferral(referral_uid string) (ReferralResp, error) {
+
+	var referral ReferralResp
+
+	if res := r.find().Where("referrals.referral_uid = ?", referral_uid).Find(&referral); res.Error != nil || res.RowsAffected == 0 {
+		return ReferralResp{}, gorm.ErrRecordNotFound
+	}
+
+	return referral, nil
+}
+
+// List is what the list of referrals can be sorted and filtered by, the most
+// urgent come first
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"urgency":   {Column: "field(referrals.urgency, 'emergency', 'urgent', 'routine')", Type: list.Int},
+		"createdAt": {Column: "referrals.created_at", Type: list.Date},
+	},
+	Sort: "urgency",
+	Key:  "referrals.id",
+	Filters: map[string]list.Field{
+		"status":  {Column: "referrals.status", Type: list.Enum, Values: []string{"sent", "accepted", "declined", "completed"}},
+		"urgency": {Column: "referrals.urgency", Type: list.Enum, Values: []string{"routine", "urgent", "emergency"}},
+	},
+}
+
+func (r *Repo) GetReferrals(kind, doctor_uid string, q list.Query) (Referrals, list.Page, error) {
+
+	var db = r.find()
+
+	switch kind {
+	case "received":
+		db = db.Where("referrals.to_doctor_uid = ?", doctor_uid)
+	default:
+		db = db.Where("referrals.from_doctor_uid = ?", doctor_uid)
+	}
+
+	db = q.Filter(db)
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return Referrals{}, list.Page{}, err
+	}
+
+	var referrals = Referrals{Referrals: []ReferralResp{}}
+
+	if res := q.Page(db).Select(query + q.Select()).Find(&referrals.Referrals); res.Error != nil {
+		log.Warn(res.Error)
+		return Referrals{}, list.Page{}, res.Error
+	}
+
+	var more = len(referrals.Referrals) > q.Limit
+	if more {
+		referrals.Referrals = referrals.Referrals[:q.Limit]
+	}
+
+	var last list.Row
+	if len(referrals.Referrals) != 0 {
+		last = referrals.Referrals[len(referrals.Referrals)-1].Row
+	}
+
+	return referrals, q.Result(total, more, last), nil
+}
@@ -0,0 +1,101 @@
+package referral
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/repository/visit"
+	"be/utils"
+	"be/utils/list"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestReferral(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Referral{})
+	db.AutoMigrate(&entities.Referral{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var target, errTarget = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor"})
+	if errTarget != nil {
+		log.Info(errTarget)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var res2, err2 = visit.New(db).CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick"})
+	if err2 != nil {
+		log.Info(err2)
+		t.Fatal()
+	}
+
+	t.Run("error refer to yourself", func(t *testing.T) {
+		var _, err = r.Create(res2.Visit_uid, res.Doctor_uid, entities.Referral{To_doctor_uid: res.Doctor_uid, Reason: "second opinion", Urgency: "routine"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success refer, accept and complete", func(t *testing.T) {
+		var referral, err = r.Create(res2.Visit_uid, res.Doctor_uid, entities.Referral{To_doctor_uid: target.Doctor_uid, Reason: "second opinion", Urgency: "urgent"})
+		assert.Nil(t, err)
+
+		_, err = r.Create(res2.Visit_uid, res.Doctor_uid, entities.Referral{To_doctor_uid: target.Doctor_uid, Reason: "second opinion", Urgency: "urgent"})
+		assert.NotNil(t, err)
+
+		var q, _ = list.Parse(List, url.Values{"status": {"sent"}})
+		var received, page, err1 = r.GetReferrals("received", target.Doctor_uid, q)
+		assert.Nil(t, err1)
+		assert.Equal(t, 1, len(received.Referrals))
+		assert.Equal(t, int64(1), page.Total)
+
+		// the source visit is still pending
+
+		_, err = r.Accept(referral.Referral_uid, target.Doctor_uid, entities.Visit{Date: datatypes.Date(time.Now())})
+		assert.NotNil(t, err)
+
+		_, err = visit.New(db).Update(res2.Visit_uid, entities.Visit{MainDiagnose: "fracture"})
+		assert.Nil(t, err)
+
+		accepted, err := r.Accept(referral.Referral_uid, target.Doctor_uid, entities.Visit{Date: datatypes.Date(time.Now())})
+		assert.Nil(t, err)
+		assert.NotEqual(t, "", accepted.Target_visit_uid)
+
+		_, err = r.Decline(referral.Referral_uid, target.Doctor_uid, "fully booked")
+		assert.NotNil(t, err)
+
+		_, err = visit.New(db).Update(accepted.Target_visit_uid, entities.Visit{MainDiagnose: "closed fracture"})
+		assert.Nil(t, err)
+
+		var resp, err3 = r.GetReferral(referral.Referral_uid)
+		assert.Nil(t, err3)
+		assert.Equal(t, "completed", resp.Status)
+		assert.Equal(t, "closed fracture", resp.TargetMainDiagnose)
+	})
+
+	t.Run("error not found", func(t *testing.T) {
+		var _, err = r.GetReferral(shortuuid.New())
+		assert.NotNil(t, err)
+
+		_, err = r.Decline(shortuuid.New(), target.Doctor_uid, "fully booked")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,42 @@
+package report
+
+import "time"
+
+// Query is the clinic and the range of a report, period is day, week or month
+
+type Query struct {
+	Doctor_uid string
+	From       time.Time
+	To         time.Time
+	Period     string
+
+	// the number of diagnoses kept for each period
+
+	Limit int
+}
+
+type VisitRow struct {
+	Period string
+	Status string
+	Total  int
+	NoShow int
+}
+
+type PatientRow struct {
+	Period string
+	Total  int
+	New    int
+}
+
+type DiagnoseRow struct {
+	Period   string
+	Diagnose string
+	Total    int
+}
+
+type DemographicRow struct {
+	Period   string
+	Gender   string
+	AgeGroup string
+	Total    int
+}
@@ -0,0 +1,9 @@
+package report
+
+type Report interface {
+	GetClinic(admin_uid string) (string, error)
+	GetVisits(q Query) ([]VisitRow, error)
+	GetPatients(q Query) ([]PatientRow, error)
+	GetDiagnoses(q Query) ([]DiagnoseRow, error)
+	GetDemographics(q Query) ([]DemographicRow, error)
+}
@@ -0,0 +1,131 @@
+package report
+
+import (
+	"be/entities"
+	"be/repository/doctor"
+	"fmt"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+)
+
+const layout = "2006-01-02"
+
+// the label of a period, weeks follow iso 8601 like time.ISOWeek
+
+var periods = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%x-W%v",
+	"month": "%Y-%m",
+}
+
+// age of the patient on the day of the visit
+
+var ageGroup = "case when patients.dob is null or patients.dob < '1900-01-01' then 'unknown' " +
+	"when timestampdiff(year, patients.dob, visits.date) < 18 then '0-17' " +
+	"when timestampdiff(year, patients.dob, visits.date) < 30 then '18-29' " +
+	"when timestampdiff(year, patients.dob, visits.date) < 45 then '30-44' " +
+	"when timestampdiff(year, patients.dob, visits.date) < 60 then '45-59' " +
+	"else '60+' end"
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// GetClinic returns the doctor the admin account belongs to
+
+func (r *Repo) GetClinic(admin_uid string) (string, error) {
+
+	var clinic, err = doctor.GetClinic(r.db, admin_uid)
+	if err != nil {
+		return "", err
+	}
+
+	return clinic.Doctor_uid, nil
+}
+
+func format(period string) string {
+	if f, ok := periods[period]; ok {
+		return f
+	}
+	return periods["day"]
+}
+
+func (r *Repo) visits(q Query) *gorm.DB {
+	return r.db.Model(&entities.Visit{}).Where("visits.doctor_uid = ? and visits.date between ? and ?", q.Doctor_uid, q.From.Format(layout), q.To.Format(layout))
+}
+
+// GetVisits counts the visits of every status, a pending or ready visit whose day has passed is a no show
+
+func (r *Repo) GetVisits(q Query) ([]VisitRow, error) {
+
+	var query = fmt.Sprintf("date_format(visits.date, '%s') as Period, visits.status as Status, count(*) as Total, sum(visits.status in ('pending', 'ready') and visits.date < curdate()) as NoShow", format(q.Period))
+
+	var rows []VisitRow
+
+	if res := r.visits(q).Select(query).Group("Period, visits.status").Order("Period").Scan(&rows); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return rows, nil
+}
+
+// GetPatients counts the patients seen in every period, a patient is new in
+// the period of their first visit to the clinic
+
+func (r *Repo) GetPatients(q Query) ([]PatientRow, error) {
+
+	var f = format(q.Period)
+
+	var first = r.db.Model(&entities.Visit{}).Select("patient_uid, min(date) as date").Where("doctor_uid = ? and status <> 'cancelled'", q.Doctor_uid).Group("patient_uid")
+
+	var query = fmt.Sprintf("date_format(visits.date, '%s') as Period, count(distinct visits.patient_uid) as Total, count(distinct case when date_format(first.date, '%s') = date_format(visits.date, '%s') then visits.patient_uid end) as New", f, f, f)
+
+	var rows []PatientRow
+
+	if res := r.visits(q).Joins("inner join (?) first on first.patient_uid = visits.patient_uid", first).Where("visits.status <> 'cancelled'").Select(query).Group("Period").Order("Period").Scan(&rows); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return rows, nil
+}
+
+// GetDiagnoses counts the main diagnose of the completed visits, the most frequent first
+
+func (r *Repo) GetDiagnoses(q Query) ([]DiagnoseRow, error) {
+
+	var query = fmt.Sprintf("date_format(visits.date, '%s') as Period, lower(trim(visits.main_diagnose)) as Diagnose, count(*) as Total", format(q.Period))
+
+	var rows []DiagnoseRow
+
+	if res := r.visits(q).Where("visits.status = 'completed' and trim(visits.main_diagnose) <> ''").Select(query).Group("Period, Diagnose").Order("Period, Total desc, Diagnose").Scan(&rows); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return rows, nil
+}
+
+// GetDemographics counts the patients seen in every period by gender and age group
+
+func (r *Repo) GetDemographics(q Query) ([]DemographicRow, error) {
+
+	var query = fmt.Sprintf("date_format(visits.date, '%s') as Period, patients.gender as Gender, %s as AgeGroup, count(distinct visits.patient_uid) as Total", format(q.Period), ageGroup)
+
+	var rows []DemographicRow
+
+	if res := r.visits(q).Joins("inner join patients on patients.patient_uid = visits.patient_uid").Where("visits.status <> 'cancelled'").Select(query).Group("Period, Gender, AgeGroup").Order("Period").Scan(&rows); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return rows, nil
+}
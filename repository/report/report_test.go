@@ -0,0 +1,95 @@
+package report
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/utils"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestReport(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "1234567890123456", Gender: "wanita", Dob: datatypes.Date(time.Date(1990, 5, 5, 0, 0, 0, 0, time.UTC))})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	var day = func(d int) datatypes.Date {
+		return datatypes.Date(time.Date(2022, 3, d, 0, 0, 0, 0, time.UTC))
+	}
+
+	var visits = []entities.Visit{
+		{Visit_uid: shortuuid.New(), Doctor_uid: res.Doctor_uid, Patient_uid: res1.Patient_uid, Date: day(1), Status: "completed", MainDiagnose: "Flu "},
+		{Visit_uid: shortuuid.New(), Doctor_uid: res.Doctor_uid, Patient_uid: res1.Patient_uid, Date: day(2), Status: "cancelled"},
+		{Visit_uid: shortuuid.New(), Doctor_uid: res.Doctor_uid, Patient_uid: res1.Patient_uid, Date: day(15), Status: "pending"},
+	}
+
+	if res := db.Create(&visits); res.Error != nil {
+		log.Info(res.Error)
+		t.Fatal()
+	}
+
+	var q = Query{Doctor_uid: res.Doctor_uid, From: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC), Period: "month"}
+
+	t.Run("error clinic of a doctor", func(t *testing.T) {
+		var _, err = r.GetClinic(res.Doctor_uid)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success clinic of the admin", func(t *testing.T) {
+		var admin entities.Doctor
+		db.Model(&entities.Doctor{}).Where("doctor_uid_ref = ? and type = 'admin'", res.Doctor_uid).First(&admin)
+
+		var clinic, err = r.GetClinic(admin.Doctor_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, res.Doctor_uid, clinic)
+	})
+
+	t.Run("success visits", func(t *testing.T) {
+		var rows, err = r.GetVisits(q)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(rows))
+		for _, row := range rows {
+			assert.Equal(t, "2022-03", row.Period)
+			if row.Status == "pending" {
+				assert.Equal(t, 1, row.NoShow)
+			}
+		}
+	})
+
+	t.Run("success patients", func(t *testing.T) {
+		var rows, err = r.GetPatients(q)
+		assert.Nil(t, err)
+		assert.Equal(t, []PatientRow{{Period: "2022-03", Total: 1, New: 1}}, rows)
+	})
+
+	t.Run("success diagnoses", func(t *testing.T) {
+		var rows, err = r.GetDiagnoses(q)
+		assert.Nil(t, err)
+		assert.Equal(t, []DiagnoseRow{{Period: "2022-03", Diagnose: "flu", Total: 1}}, rows)
+	})
+
+	t.Run("success demographics", func(t *testing.T) {
+		var rows, err = r.GetDemographics(q)
+		assert.Nil(t, err)
+		assert.Equal(t, []DemographicRow{{Period: "2022-03", Gender: "wanita", AgeGroup: "30-44", Total: 1}}, rows)
+	})
+}
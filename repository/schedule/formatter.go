@@ -0,0 +1,25 @@
+package schedule
+
+import (
+	"be/entities"
+	"be/utils/list"
+)
+
+type Clinic struct {
+	Doctor_uid string
+	Name       string
+}
+
+type Appointment struct {
+	Visit_uid   string
+	PatientName string
+	Status      string
+	Complaint   string
+}
+
+// Run is a run in the run log of the schedule
+
+type Run struct {
+	entities.ReportRun
+	list.Row
+}
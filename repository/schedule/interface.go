@@ -0,0 +1,28 @@
+package schedule
+
+import (
+	"be/entities"
+	"be/utils/list"
+	"time"
+)
+
+type Schedule interface {
+	GetClinic(admin_uid string) (Clinic, error)
+	Create(req entities.ReportSchedule) (entities.ReportSchedule, error)
+	Update(schedule_uid, admin_uid string, req entities.ReportSchedule) (entities.ReportSchedule, error)
+	Delete(schedule_uid, admin_uid string) error
+	GetSchedule(schedule_uid string) (entities.ReportSchedule, error)
+	GetSchedules(admin_uid string) ([]entities.ReportSchedule, error)
+	GetRuns(schedule_uid string, q list.Query) ([]Run, list.Page, error)
+	GetAppointments(doctor_uid string, date time.Time) ([]Appointment, error)
+
+	// used by the scheduler
+
+	Lease(name, holder string, ttl time.Duration) (bool, error)
+	GetDue(now time.Time) ([]entities.ReportSchedule, error)
+	CreateRun(schedule entities.ReportSchedule, next time.Time) (entities.ReportRun, error)
+	GetRetries(now time.Time) ([]entities.ReportRun, error)
+	Claim(run_uid string, now time.Time) (entities.ReportRun, error)
+	Sent(run_uid string) error
+	Fail(run_uid, message string, retry *time.Time) error
+}
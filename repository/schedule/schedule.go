@@ -0,0 +1,314 @@
+package schedule
+
+import (
+	"be/entities"
+	"be/repository/doctor"
+	"be/utils/list"
+	"errors"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+// a run still running after this long was left by a replica that went down
+
+const staleAfter = 10 * time.Minute
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// GetClinic returns the doctor the admin account belongs to
+
+func (r *Repo) GetClinic(admin_uid string) (Clinic, error) {
+
+	var clinic, err = doctor.GetClinic(r.db, admin_uid)
+	if err != nil {
+		return Clinic{}, err
+	}
+
+	return Clinic{Doctor_uid: clinic.Doctor_uid, Name: clinic.Name}, nil
+}
+
+func (r *Repo) Create(req entities.ReportSchedule) (entities.ReportSchedule, error) {
+
+	req.Schedule_uid = shortuuid.New()
+	req.Active = true
+
+	if res := r.db.Model(&entities.ReportSchedule{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.ReportSchedule{}, res.Error
+	}
+
+	return req, nil
+}
+
+// Update takes the next run computed for the new cron, a paused schedule keeps it
+
+func (r *Repo) Update(schedule_uid, admin_uid string, req entities.ReportSchedule) (entities.ReportSchedule, error) {
+
+	var res = r.db.Model(&entities.ReportSchedule{}).Where("schedule_uid = ? and admin_uid = ?", schedule_uid, admin_uid).Select("cron", "timezone", "email", "active", "next_run_at").Updates(&req)
+
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return entities.ReportSchedule{}, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return entities.ReportSchedule{}, gorm.ErrRecordNotFound
+	}
+
+	return r.GetSchedule(schedule_uid)
+}
+
+func (r *Repo) Delete(schedule_uid, admin_uid string) error {
+
+	var res = r.db.Where("schedule_uid = ? and admin_uid = ?", schedule_uid, admin_uid).Delete(&entities.ReportSchedule{})
+
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) GetSchedule(schedule_uid string) (entities.ReportSchedule, error) {
+
+	var schedule entities.ReportSchedule
+
+	if res := r.db.Model(&entities.ReportSchedule{}).Where("schedule_uid = ?", schedule_uid).Find(&schedule); res.Error != nil || res.RowsAffected == 0 {
+		return entities.ReportSchedule{}, gorm.ErrRecordNotFound
+	}
+
+	return schedule, nil
+}
+
+func (r *Repo) GetSchedules(admin_uid string) ([]entities.ReportSchedule, error) {
+
+	var schedules = []entities.ReportSchedule{}
+
+	if res := r.db.Model(&entities.ReportSchedule{}).Where("admin_uid = ?", admin_uid).Order("created_at").Find(&schedules); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return schedules, nil
+}
+
+// Runs is what the run log can be sorted and filtered by
+
+var Runs = list.Spec{
+	Sorts: map[string]list.Field{
+		"scheduledAt": {Column: "scheduled_at", Type: list.Date},
+	},
+	Sort: "-scheduledAt",
+	Key:  "id",
+	Filters: map[string]list.Field{
+		"status":    {Column: "status", Type: list.Enum, Values: []string{"running", "sent", "failed"}},
+		"scheduled": {Column: "date(scheduled_at)", Type: list.Date},
+	},
+}
+
+// GetRuns is the run log of the schedule, the latest runs first
+
+func (r *Repo) GetRuns(schedule_uid string, q list.Query) ([]Run, list.Page, error) {
+
+	var db = q.Filter(r.db.Model(&entities.ReportRun{}).Where("schedule_uid = ?", schedule_uid))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Warn(err)
+		return nil, list.Page{}, err
+	}
+
+	var runs = []Run{}
+
+	if res := q.Page(db).Select("report_runs.*" + q.Select()).Find(&runs); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, list.Page{}, res.Error
+	}
+
+	var more = len(runs) > q.Limit
+	if more {
+		runs = runs[:q.Limit]
+	}
+
+	var last list.Row
+	if len(runs) != 0 {
+		last = runs[len(runs)-1].Row
+	}
+
+	return runs, q.Result(total, more, last), nil
+}
+
+// GetAppointments lists the visits of the day that are still to come, in queue order
+
+func (r *Repo) GetAppointments(doctor_uid string, date time.Time) ([]Appointment, error) {
+
+	var appointments = []Appointment{}
+
+	if res := r.db.Model(&entities.Visit{}).Joins("inner join patients on patients.patient_uid = visits.patient_uid").Where("visits.doctor_uid = ? and visits.date = ? and visits.status in ('pending', 'ready')", doctor_uid, date.Format("2006-01-02")).Select("visits.visit_uid as Visit_uid, patients.name as PatientName, visits.status as Status, visits.complaint as Complaint").Order("visits.created_at").Scan(&appointments); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return appointments, nil
+}
+
+// Lease takes or renews the lease of the name, only one holder has it until it expires
+
+func (r *Repo) Lease(name, holder string, ttl time.Duration) (bool, error) {
+
+	var now = time.Now()
+
+	var res = r.db.Model(&entities.SchedulerLease{}).Where("name = ? and (holder = ? or expires_at < ?)", name, holder, now).Updates(map[string]interface{}{"holder": holder, "expires_at": now.Add(ttl)})
+
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return false, res.Error
+	}
+
+	if res.RowsAffected != 0 {
+		return true, nil
+	}
+
+	var count int64
+
+	if res := r.db.Model(&entities.SchedulerLease{}).Where("name = ?", name).Count(&count); res.Error != nil || count != 0 {
+		return false, res.Error
+	}
+
+	// nobody held the lease yet, the replica inserting it first gets it
+
+	if res := r.db.Create(&entities.SchedulerLease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}); res.Error != nil {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (r *Repo) GetDue(now time.Time) ([]entities.ReportSchedule, error) {
+
+	var schedules []entities.ReportSchedule
+
+	if res := r.db.Model(&entities.ReportSchedule{}).Where("active = true and next_run_at <= ?", now).Order("next_run_at").Find(&schedules); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return schedules, nil
+}
+
+// CreateRun starts the run of the slot of the schedule and moves the schedule
+// to the next slot, a slot that was already started is an error
+
+func (r *Repo) CreateRun(schedule entities.ReportSchedule, next time.Time) (entities.ReportRun, error) {
+
+	var run = entities.ReportRun{
+		Run_uid:      shortuuid.New(),
+		Schedule_uid: schedule.Schedule_uid,
+		ScheduledAt:  schedule.NextRunAt,
+		Status:       "running",
+		Attempts:     1,
+	}
+
+	var err = r.db.Transaction(func(tx *gorm.DB) error {
+		var res = tx.Model(&entities.ReportSchedule{}).Where("schedule_uid = ? and next_run_at = ?", schedule.Schedule_uid, schedule.NextRunAt).Updates(map[string]interface{}{"next_run_at": next, "last_run_at": schedule.NextRunAt})
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return errors.New("schedule is already run")
+		}
+
+		return tx.Model(&entities.ReportRun{}).Create(&run).Error
+	})
+
+	if err != nil {
+		log.Warn(err)
+		return entities.ReportRun{}, err
+	}
+
+	return run, nil
+}
+
+func (r *Repo) GetRetries(now time.Time) ([]entities.ReportRun, error) {
+
+	var runs []entities.ReportRun
+
+	if res := r.db.Model(&entities.ReportRun{}).Where("(status = 'failed' and next_retry_at <= ?) or (status = 'running' and updated_at < ?)", now, now.Add(-staleAfter)).Order("scheduled_at").Find(&runs); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return runs, nil
+}
+
+// Claim starts the next attempt of a failed or stale run
+
+func (r *Repo) Claim(run_uid string, now time.Time) (entities.ReportRun, error) {
+
+	var res = r.db.Model(&entities.ReportRun{}).Where("run_uid = ? and ((status = 'failed' and next_retry_at <= ?) or (status = 'running' and updated_at < ?))", run_uid, now, now.Add(-staleAfter)).Updates(map[string]interface{}{"status": "running", "attempts": gorm.Expr("attempts + 1"), "next_retry_at": nil})
+
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return entities.ReportRun{}, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return entities.ReportRun{}, errors.New("run is already claimed")
+	}
+
+	var run entities.ReportRun
+
+	if res := r.db.Model(&entities.ReportRun{}).Where("run_uid = ?", run_uid).Find(&run); res.Error != nil || res.RowsAffected == 0 {
+		return entities.ReportRun{}, gorm.ErrRecordNotFound
+	}
+
+	return run, nil
+}
+
+func (r *Repo) Sent(run_uid string) error {
+
+	var now = time.Now()
+
+	if res := r.db.Model(&entities.ReportRun{}).Where("run_uid = ?", run_uid).Updates(map[string]interface{}{"status": "sent", "error": "", "finished_at": now}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+// Fail keeps the run for a retry at the given time, without it the run has failed for good
+
+func (r *Repo) Fail(run_uid, message string, retry *time.Time) error {
+
+	var values = map[string]interface{}{"status": "failed", "error": message, "next_retry_at": retry}
+
+	if retry == nil {
+		values["finished_at"] = time.Now()
+	}
+
+	if res := r.db.Model(&entities.ReportRun{}).Where("run_uid = ?", run_uid).Updates(values); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
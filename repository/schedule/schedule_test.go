@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/utils"
+	"be/utils/list"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.ReportSchedule{}, &entities.ReportRun{}, &entities.SchedulerLease{})
+	db.AutoMigrate(&entities.ReportSchedule{}, &entities.ReportRun{}, &entities.SchedulerLease{})
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "klinik sehat"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var admin entities.Doctor
+	db.Model(&entities.Doctor{}).Where("doctor_uid_ref = ? and type = 'admin'", res.Doctor_uid).First(&admin)
+
+	var now = time.Now().Truncate(time.Minute)
+
+	t.Run("success clinic", func(t *testing.T) {
+		var clinic, err = r.GetClinic(admin.Doctor_uid)
+		assert.Nil(t, err)
+		assert.Equal(t, Clinic{Doctor_uid: res.Doctor_uid, Name: "klinik sehat"}, clinic)
+
+		_, err = r.GetClinic(res.Doctor_uid)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success lease", func(t *testing.T) {
+		var leader, err = r.Lease("test", "a", time.Minute)
+		assert.Nil(t, err)
+		assert.True(t, leader)
+
+		leader, _ = r.Lease("test", "b", time.Minute)
+		assert.False(t, leader)
+
+		leader, _ = r.Lease("test", "a", time.Minute)
+		assert.True(t, leader)
+	})
+
+	var schedule, errCreate = r.Create(entities.ReportSchedule{Admin_uid: admin.Doctor_uid, Doctor_uid: res.Doctor_uid, Kind: "appointments", Cron: "0 18 * * *", Timezone: "Asia/Jakarta", Email: "owner@mail.com", NextRunAt: now.Add(-time.Minute)})
+	if errCreate != nil {
+		log.Info(errCreate)
+		t.Fatal()
+	}
+
+	t.Run("success run once per slot", func(t *testing.T) {
+		var due, err = r.GetDue(now)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(due))
+
+		run, err := r.CreateRun(due[0], now.Add(24*time.Hour))
+		assert.Nil(t, err)
+
+		_, err = r.CreateRun(due[0], now.Add(24*time.Hour))
+		assert.NotNil(t, err)
+
+		due, _ = r.GetDue(now)
+		assert.Equal(t, 0, len(due))
+
+		var retry = now.Add(-time.Second)
+		assert.Nil(t, r.Fail(run.Run_uid, "connection refused", &retry))
+
+		retries, err := r.GetRetries(now)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(retries))
+
+		claimed, err := r.Claim(run.Run_uid, now)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, claimed.Attempts)
+
+		_, err = r.Claim(run.Run_uid, now)
+		assert.NotNil(t, err)
+
+		assert.Nil(t, r.Sent(run.Run_uid))
+
+		var q, _ = list.Parse(Runs, url.Values{})
+
+		runs, page, err := r.GetRuns(schedule.Schedule_uid, q)
+		assert.Nil(t, err)
+		assert.Equal(t, "sent", runs[0].Status)
+		assert.Equal(t, int64(len(runs)), page.Total)
+	})
+
+	t.Run("success pause and delete", func(t *testing.T) {
+		var updated, err = r.Update(schedule.Schedule_uid, admin.Doctor_uid, entities.ReportSchedule{Cron: "0 17 * * *", Timezone: "Asia/Jakarta", Email: "owner@mail.com", Active: false, NextRunAt: now})
+		assert.Nil(t, err)
+		assert.False(t, updated.Active)
+
+		due, _ := r.GetDue(now)
+		assert.Equal(t, 0, len(due))
+
+		assert.NotNil(t, r.Delete(schedule.Schedule_uid, res.Doctor_uid))
+		assert.Nil(t, r.Delete(schedule.Schedule_uid, admin.Doctor_uid))
+	})
+}
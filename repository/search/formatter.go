@@ -0,0 +1,38 @@
+package search
+
+// Query is a search of the words in Terms, the rows have to contain every
+// term as a word or the start of a word
+
+type Query struct {
+	Terms []string
+	// only the patients visiting the doctor, or the visits to the doctor
+	Doctor_uid string
+	// only the visits of the patient
+	Patient_uid string
+	Limit       int
+	Offset      int
+}
+
+type Patient struct {
+	Patient_uid string
+	Nik         string
+	Name        string
+	Phone       string
+	Address     string
+	Gender      string
+	Score       float64
+}
+
+type Visit struct {
+	Visit_uid        string
+	Date             string
+	Status           string
+	Complaint        string
+	MainDiagnose     string
+	AdditionDiagnose string
+	Doctor_uid       string
+	DoctorName       string
+	Patient_uid      string
+	PatientName      string
+	Score            float64
+}
@@ -0,0 +1,7 @@
+package search
+
+type Search interface {
+	GetClinic(admin_uid string) (string, error)
+	Patients(q Query) ([]Patient, error)
+	Visits(q Query) ([]Visit, error)
+}
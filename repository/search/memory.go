@@ -0,0 +1,154 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Memory keeps the patients and visits in memory and searches them like Repo,
+// a term matches the words starting with it. It backs the tests of the search
+
+type Memory struct {
+	mu       sync.RWMutex
+	clinics  map[string]string
+	patients []Patient
+	visits   []Visit
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		clinics: map[string]string{},
+	}
+}
+
+func (m *Memory) AddClinic(admin_uid, doctor_uid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clinics[admin_uid] = doctor_uid
+}
+
+func (m *Memory) AddPatient(p Patient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.patients = append(m.patients, p)
+}
+
+func (m *Memory) AddVisit(v Visit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.visits = append(m.visits, v)
+}
+
+func (m *Memory) GetClinic(admin_uid string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if doctor_uid, ok := m.clinics[admin_uid]; ok {
+		return doctor_uid, nil
+	}
+	return "", gorm.ErrRecordNotFound
+}
+
+func (m *Memory) Patients(q Query) ([]Patient, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var patients = []Patient{}
+
+	for _, p := range m.patients {
+		if q.Doctor_uid != "" && !m.visited(p.Patient_uid, q.Doctor_uid) {
+			continue
+		}
+
+		if p.Score = score(q.Terms, p.Nik, p.Name, p.Phone, p.Address); p.Score > 0 {
+			patients = append(patients, p)
+		}
+	}
+
+	sort.SliceStable(patients, func(i, j int) bool {
+		if patients[i].Score != patients[j].Score {
+			return patients[i].Score > patients[j].Score
+		}
+		return patients[i].Name < patients[j].Name
+	})
+
+	var from, to = bounds(len(patients), q)
+	return patients[from:to], nil
+}
+
+func (m *Memory) Visits(q Query) ([]Visit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var visits = []Visit{}
+
+	for _, v := range m.visits {
+		if (q.Doctor_uid != "" && v.Doctor_uid != q.Doctor_uid) || (q.Patient_uid != "" && v.Patient_uid != q.Patient_uid) {
+			continue
+		}
+
+		if v.Score = score(q.Terms, v.Complaint, v.MainDiagnose, v.AdditionDiagnose); v.Score > 0 {
+			visits = append(visits, v)
+		}
+	}
+
+	sort.SliceStable(visits, func(i, j int) bool {
+		return visits[i].Score > visits[j].Score
+	})
+
+	var from, to = bounds(len(visits), q)
+	return visits[from:to], nil
+}
+
+func (m *Memory) visited(patient_uid, doctor_uid string) bool {
+	for _, v := range m.visits {
+		if v.Patient_uid == patient_uid && v.Doctor_uid == doctor_uid {
+			return true
+		}
+	}
+	return false
+}
+
+// score counts the words of the fields starting with the terms, a whole word
+// counts twice. It is zero when one of the terms is not found
+
+func score(terms []string, fields ...string) float64 {
+	var total float64
+
+	for _, term := range terms {
+		var found float64
+		for _, field := range fields {
+			for _, word := range Words(field) {
+				switch {
+				case word == term:
+					found += 2
+				case strings.HasPrefix(word, term):
+					found++
+				}
+			}
+		}
+
+		if found == 0 {
+			return 0
+		}
+		total += found
+	}
+
+	return total
+}
+
+// bounds are the rows of the offset and limit of the query, from n sorted rows
+
+func bounds(n int, q Query) (int, int) {
+	var from, to = q.Offset, n
+	if from > n {
+		from = n
+	}
+	if q.Limit > 0 && from+q.Limit < to {
+		to = from + q.Limit
+	}
+	return from, to
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"budi", "3201", "jalan"}, Terms("Budi, 3201 +jalan* budi -- ab"))
+	assert.Nil(t, Terms("' or 1=1"))
+}
+
+func TestMemory(t *testing.T) {
+	var m = NewMemory()
+	m.AddClinic("admin", "doctor")
+	m.AddPatient(Patient{Patient_uid: "budi", Nik: "3201010101010001", Name: "Budi Santoso", Phone: "081234567890", Address: "Jalan Mawar 1, Bogor"})
+	m.AddPatient(Patient{Patient_uid: "budiman", Nik: "3201010101010002", Name: "Budiman", Address: "Jalan Melati 2, Bogor"})
+	m.AddPatient(Patient{Patient_uid: "siti", Nik: "3174010101010003", Name: "Siti", Address: "Jalan Budi Utomo, Jakarta"})
+	m.AddVisit(Visit{Visit_uid: "v1", Doctor_uid: "doctor", Patient_uid: "budi", Complaint: "batuk dan demam", MainDiagnose: "influenza"})
+	m.AddVisit(Visit{Visit_uid: "v2", Doctor_uid: "other", Patient_uid: "siti", Complaint: "demam tinggi", MainDiagnose: "demam berdarah", AdditionDiagnose: "demam"})
+
+	t.Run("success patients ranked", func(t *testing.T) {
+		var res, err = m.Patients(Query{Terms: Terms("budi")})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(res))
+		assert.Equal(t, "budi", res[0].Patient_uid)
+		assert.Equal(t, float64(2), res[0].Score)
+	})
+
+	t.Run("success every term", func(t *testing.T) {
+		var res, _ = m.Patients(Query{Terms: Terms("budi bogor")})
+		assert.Equal(t, 2, len(res))
+
+		res, _ = m.Patients(Query{Terms: Terms("0812")})
+		assert.Equal(t, "budi", res[0].Patient_uid)
+	})
+
+	t.Run("success patients of doctor", func(t *testing.T) {
+		var res, _ = m.Patients(Query{Terms: Terms("jalan"), Doctor_uid: "doctor"})
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "budi", res[0].Patient_uid)
+	})
+
+	t.Run("success visits", func(t *testing.T) {
+		var res, _ = m.Visits(Query{Terms: Terms("demam")})
+		assert.Equal(t, "v2", res[0].Visit_uid)
+
+		res, _ = m.Visits(Query{Terms: Terms("demam"), Patient_uid: "budi"})
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "v1", res[0].Visit_uid)
+	})
+
+	t.Run("success limit and offset", func(t *testing.T) {
+		var res, _ = m.Patients(Query{Terms: Terms("jalan"), Limit: 2, Offset: 2})
+		assert.Equal(t, 1, len(res))
+
+		res, _ = m.Patients(Query{Terms: Terms("jalan"), Offset: 5})
+		assert.Equal(t, 0, len(res))
+	})
+
+	t.Run("clinic not found", func(t *testing.T) {
+		var _, err = m.GetClinic("doctor")
+		assert.Equal(t, "record not found", err.Error())
+	})
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"be/entities"
+	"be/repository/doctor"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+)
+
+// the columns of the FULLTEXT indexes of patients and visits, in the order of
+// the index
+
+const patientMatch = "match(patients.nik, patients.name, patients.phone, patients.address) against (? in boolean mode)"
+const visitMatch = "match(visits.complaint, visits.main_diagnose, visits.addition_diagnose) against (? in boolean mode)"
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) GetClinic(admin_uid string) (string, error) {
+
+	var clinic, err = doctor.GetClinic(r.db, admin_uid)
+	if err != nil {
+		return "", err
+	}
+
+	return clinic.Doctor_uid, nil
+}
+
+// against is the boolean mode search requiring every term, as a word or the
+// start of one
+
+func against(terms []string) string {
+	var words []string
+	for _, term := range terms {
+		words = append(words, "+"+term+"*")
+	}
+	return strings.Join(words, " ")
+}
+
+func (r *Repo) Patients(q Query) ([]Patient, error) {
+
+	var patients = []Patient{}
+
+	if len(q.Terms) == 0 {
+		return patients, nil
+	}
+
+	var terms = against(q.Terms)
+
+	var db = r.db.Model(&entities.Patient{}).Where(patientMatch, terms)
+
+	if q.Doctor_uid != "" {
+		db = db.Where("exists (select 1 from visits where visits.patient_uid = patients.patient_uid and visits.doctor_uid = ? and visits.deleted_at is null)", q.Doctor_uid)
+	}
+
+	if res := db.Select("patients.patient_uid as Patient_uid, patients.nik as Nik, patients.name as Name, patients.phone as Phone, patients.address as Address, patients.gender as Gender, "+patientMatch+" as Score", terms).Order("Score DESC, patients.name").Offset(q.Offset).Limit(q.Limit).Find(&patients); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return patients, nil
+}
+
+func (r *Repo) Visits(q Query) ([]Visit, error) {
+
+	var visits = []Visit{}
+
+	if len(q.Terms) == 0 {
+		return visits, nil
+	}
+
+	var terms = against(q.Terms)
+
+	var db = r.db.Model(&entities.Visit{}).Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on visits.doctor_uid = doctors.doctor_uid").Where(visitMatch, terms)
+
+	if q.Doctor_uid != "" {
+		db = db.Where("visits.doctor_uid = ?", q.Doctor_uid)
+	}
+
+	if q.Patient_uid != "" {
+		db = db.Where("visits.patient_uid = ?", q.Patient_uid)
+	}
+
+	if res := db.Select("visits.visit_uid as Visit_uid, date_format(visits.date, '%d-%m-%Y') as Date, visits.status as Status, visits.complaint as Complaint, visits.main_diagnose as MainDiagnose, visits.addition_diagnose as AdditionDiagnose, visits.doctor_uid as Doctor_uid, doctors.name as DoctorName, visits.patient_uid as Patient_uid, patients.name as PatientName, "+visitMatch+" as Score", terms).Order("Score DESC, visits.date DESC").Offset(q.Offset).Limit(q.Limit).Find(&visits); res.Error != nil {
+		log.Warn(res.Error)
+		return nil, res.Error
+	}
+
+	return visits, nil
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/repository/doctor"
+	"be/repository/patient"
+	"be/utils"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestSearch(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+
+	var res, err = doctor.New(db).Create(entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Name: "andi"})
+	if err != nil {
+		log.Info(err)
+		t.Fatal()
+	}
+
+	var word = "zq" + shortuuid.New()[:6]
+
+	var res1, err1 = patient.New(db).Create(entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient", Nik: "3201010101010001", Name: word + " santoso", Phone: "081234567890", Address: "jalan mawar"})
+	if err1 != nil {
+		log.Info(err1)
+		t.Fatal()
+	}
+
+	if res := db.Create(&entities.Visit{Visit_uid: shortuuid.New(), Doctor_uid: res.Doctor_uid, Patient_uid: res1.Patient_uid, Date: datatypes.Date(time.Now()), Complaint: word + " batuk", MainDiagnose: "influenza"}); res.Error != nil {
+		log.Info(res.Error)
+		t.Fatal()
+	}
+
+	t.Run("success patients", func(t *testing.T) {
+		var patients, err = r.Patients(Query{Terms: Terms(word + " sant"), Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(patients))
+		assert.Equal(t, res1.Patient_uid, patients[0].Patient_uid)
+		assert.True(t, patients[0].Score > 0)
+
+		patients, _ = r.Patients(Query{Terms: Terms(word), Doctor_uid: shortuuid.New(), Limit: 10})
+		assert.Equal(t, 0, len(patients))
+	})
+
+	t.Run("success visits", func(t *testing.T) {
+		var visits, err = r.Visits(Query{Terms: Terms(word + " influ"), Patient_uid: res1.Patient_uid, Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(visits))
+		assert.Equal(t, "andi", visits[0].DoctorName)
+	})
+
+	t.Run("success no terms", func(t *testing.T) {
+		var visits, err = r.Visits(Query{Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(visits))
+	})
+}
@@ -0,0 +1,37 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MinTerm is the shortest word searched, shorter ones are not in the
+// FULLTEXT index of MySQL
+
+const MinTerm = 3
+
+// Terms splits the text to the lower case words to search, the other
+// characters separate the words and the words shorter than MinTerm are left out
+
+func Terms(text string) []string {
+	var terms []string
+	var seen = map[string]bool{}
+
+	for _, word := range Words(text) {
+		if len([]rune(word)) < MinTerm || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+
+	return terms
+}
+
+// Words is the text split to lower case words of letters and digits
+
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,12 @@
+package upload
+
+import "be/entities"
+
+type Upload interface {
+	Create(req entities.Upload) (entities.Upload, error)
+	GetUpload(visit_uid, upload_uid string) (entities.Upload, error)
+	Update(upload_uid string, received int64, req entities.Upload) error
+	Finish(upload_uid, attachment_uid string) error
+	Fail(upload_uid string) error
+	Delete(upload_uid string) error
+}
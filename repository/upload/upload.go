@@ -0,0 +1,108 @@
+package upload
+
+import (
+	"be/entities"
+	"errors"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+	"gorm.io/gorm"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) Create(req entities.Upload) (entities.Upload, error) {
+
+	var uid string
+
+	for {
+		uid = shortuuid.New()
+		var find = entities.Upload{}
+		var res = r.db.Model(&entities.Upload{}).Where("upload_uid = ?", uid).Find(&find)
+		if res.RowsAffected == 0 {
+			break
+		}
+	}
+
+	req.Upload_uid = uid
+	req.Status = "uploading"
+
+	if res := r.db.Model(&entities.Upload{}).Create(&req); res.Error != nil {
+		log.Warn(res.Error)
+		return entities.Upload{}, res.Error
+	}
+
+	return req, nil
+}
+
+func (r *Repo) GetUpload(visit_uid, upload_uid string) (entities.Upload, error) {
+
+	var upload entities.Upload
+
+	if res := r.db.Model(&entities.Upload{}).Where("visit_uid = ? and upload_uid = ?", visit_uid, upload_uid).Find(&upload); res.Error != nil || res.RowsAffected == 0 {
+		return entities.Upload{}, gorm.ErrRecordNotFound
+	}
+
+	return upload, nil
+}
+
+// Update saves a new part, only while the upload still has the received
+// bytes the part was sent after, so two requests with the same part don't
+// both count
+func (r *Repo) Update(upload_uid string, received int64, req entities.Upload) error {
+
+	var res = r.db.Model(&entities.Upload{}).Where("upload_uid = ? and received = ? and status = 'uploading'", upload_uid, received).Updates(map[string]interface{}{
+		"received":          req.Received,
+		"content_type":      req.ContentType,
+		"storage_upload_id": req.Storage_upload_id,
+		"parts":             req.Parts,
+		"hash":              req.Hash,
+	})
+	if res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("upload is changed")
+	}
+
+	return nil
+}
+
+func (r *Repo) Finish(upload_uid, attachment_uid string) error {
+
+	if res := r.db.Model(&entities.Upload{}).Where("upload_uid = ?", upload_uid).Updates(entities.Upload{Status: "done", Attachment_uid: attachment_uid}); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) Fail(upload_uid string) error {
+
+	if res := r.db.Model(&entities.Upload{}).Where("upload_uid = ?", upload_uid).Update("status", "failed"); res.Error != nil {
+		log.Warn(res.Error)
+		return res.Error
+	}
+
+	return nil
+}
+
+func (r *Repo) Delete(upload_uid string) error {
+
+	if res := r.db.Model(&entities.Upload{}).Where("upload_uid = ?", upload_uid).Delete(&entities.Upload{}); res.Error != nil || res.RowsAffected == 0 {
+		log.Warn(res.Error)
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package upload
+
+import (
+	"be/configs"
+	"be/entities"
+	"be/utils"
+	"testing"
+
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpload(t *testing.T) {
+	var config = configs.GetConfig()
+	var db = utils.InitDB(config)
+	var r = New(db)
+	db.Migrator().DropTable(&entities.Upload{})
+	db.AutoMigrate(&entities.Upload{})
+
+	var visit_uid = shortuuid.New()
+
+	t.Run("success create, update and finish", func(t *testing.T) {
+		var res, err = r.Create(entities.Upload{Visit_uid: visit_uid, Uploader_uid: "doctor", Type: "imaging", Length: 10, Object_key: "attachments/key"})
+		assert.Nil(t, err)
+		assert.Equal(t, "uploading", res.Status)
+
+		assert.Nil(t, r.Update(res.Upload_uid, 0, entities.Upload{Received: 6, Storage_upload_id: "id", Parts: `[{"number":1}]`, Hash: []byte("state")}))
+
+		// the same part again
+		var err1 = r.Update(res.Upload_uid, 0, entities.Upload{Received: 6})
+		assert.Equal(t, "upload is changed", err1.Error())
+
+		var res1, err2 = r.GetUpload(visit_uid, res.Upload_uid)
+		assert.Nil(t, err2)
+		assert.Equal(t, int64(6), res1.Received)
+		assert.Equal(t, []byte("state"), res1.Hash)
+
+		assert.Nil(t, r.Finish(res.Upload_uid, "attachment"))
+		var res2, _ = r.GetUpload(visit_uid, res.Upload_uid)
+		assert.Equal(t, "done", res2.Status)
+		assert.NotNil(t, r.Update(res.Upload_uid, 6, entities.Upload{Received: 10}))
+	})
+
+	t.Run("success fail and delete", func(t *testing.T) {
+		var res, _ = r.Create(entities.Upload{Visit_uid: visit_uid, Uploader_uid: "doctor", Length: 10})
+
+		assert.Nil(t, r.Fail(res.Upload_uid))
+		var res1, _ = r.GetUpload(visit_uid, res.Upload_uid)
+		assert.Equal(t, "failed", res1.Status)
+
+		assert.Nil(t, r.Delete(res.Upload_uid))
+		var _, err = r.GetUpload(visit_uid, res.Upload_uid)
+		assert.NotNil(t, err)
+	})
+}
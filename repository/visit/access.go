@@ -0,0 +1,33 @@
+package visit
+
+import (
+	"be/entities"
+
+	"gorm.io/gorm"
+)
+
+// CheckAccess makes sure the user is the doctor or the patient of the visit,
+// the notes, lab orders, attachments and documents of a visit are only theirs
+func CheckAccess(db *gorm.DB, visit_uid, user_uid string) error {
+
+	var visit entities.Visit
+
+	if res := db.Model(&entities.Visit{}).Where("visit_uid = ? and (doctor_uid = ? or patient_uid = ?)", visit_uid, user_uid, user_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// CheckDoctor makes sure the user is the doctor of the visit, only the
+// doctor adds to the record of a visit
+func CheckDoctor(db *gorm.DB, visit_uid, doctor_uid string) error {
+
+	var visit entities.Visit
+
+	if res := db.Model(&entities.Visit{}).Where("visit_uid = ? and doctor_uid = ?", visit_uid, doctor_uid).Find(&visit); res.Error != nil || res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
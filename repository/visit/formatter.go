@@ -1,5 +1,7 @@
 package visit
 
+import "be/utils/list"
+
 type VisitResp struct {
 	Visit_uid        string `json:"visit_uid"`
 	Date             string `json:"date" form:"date" validate:"required"`
@@ -25,13 +27,24 @@ type VisitResp struct {
 	PatientName string `json:"patientName"`
 	Gender      string `json:"gender"`
 	Nik         string `json:"nik"`
+
+	list.Row
 }
 
 type Visits struct {
 	Visits []VisitResp `json:"visits"`
 }
 
+// CalendarColumns selects a VisitCalendar from the visits joined with the
+// patients and doctors
+
+const CalendarColumns = "visits.visit_uid as Visit_uid, visits.doctor_uid as Doctor_uid, visits.patient_uid as Patient_uid, visits.status as Status, doctors.address as Address, complaint as Complaint, date_format(visits.date, '%d-%m-%Y') as Date, doctors.name as DoctorName, patients.name as PatientName, doctors.email as DoctorEmail, patients.email as PatientEmail, event_uid as Event_uid"
+
 type VisitCalendar struct {
+	Visit_uid    string `json:"visit_uid"`
+	Doctor_uid   string `json:"doctor_uid"`
+	Patient_uid  string `json:"patient_uid"`
+	Status       string `json:"status"`
 	Address      string `json:"address"`
 	Complaint    string `json:"complaint"`
 	Date         string `json:"date"`
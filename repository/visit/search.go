@@ -0,0 +1,73 @@
+package visit
+
+import (
+	"be/utils/list"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Search is what the visits are searched by, the empty fields are left out and
+// the others are all matched
+
+type Search struct {
+	Visit_uid   string
+	Doctor_uid  string
+	Patient_uid string
+	// the nik of the patient, matching the visits of all of their accounts
+	Nik      string
+	Statuses []string
+	// days of the visit, from and to are included
+	From time.Time
+	To   time.Time
+	// part of the complaint
+	Complaint string
+	// "patient" or "doctor" lists one visit of every patient or doctor
+	Grouped string
+}
+
+// Where adds the search to the query of the visits joined with patients and
+// doctors, the values are only passed as parameters
+
+func (s Search) Where(db *gorm.DB) *gorm.DB {
+	if s.Visit_uid != "" {
+		db = db.Where("visits.visit_uid = ?", s.Visit_uid)
+	}
+
+	if s.Doctor_uid != "" {
+		db = db.Where("visits.doctor_uid = ?", s.Doctor_uid)
+	}
+
+	if s.Patient_uid != "" {
+		db = db.Where("visits.patient_uid = ?", s.Patient_uid)
+	}
+
+	if s.Nik != "" {
+		db = db.Where("patients.nik = ?", s.Nik)
+	}
+
+	if len(s.Statuses) != 0 {
+		db = db.Where("visits.status in ?", s.Statuses)
+	}
+
+	if !s.From.IsZero() {
+		db = db.Where("visits.date >= ?", s.From.Format("2006-01-02"))
+	}
+
+	if !s.To.IsZero() {
+		db = db.Where("visits.date <= ?", s.To.Format("2006-01-02"))
+	}
+
+	if s.Complaint != "" {
+		db = db.Where("visits.complaint like ?", list.Like(s.Complaint))
+	}
+
+	switch s.Grouped {
+	case "patient":
+		db = db.Group("patients.nik")
+	case "doctor":
+		db = db.Group("doctors.doctor_uid")
+	}
+
+	return db
+}
@@ -0,0 +1,37 @@
+package visit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestSearch(t *testing.T) {
+	var db, err = gorm.Open(mysql.New(mysql.Config{DSN: "root@tcp(localhost:3306)/test", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var toSQL = func(s Search) string {
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return s.Where(tx.Table("visits")).Select("visits.visit_uid").Find(&[]VisitResp{})
+		})
+	}
+
+	t.Run("success empty", func(t *testing.T) {
+		assert.Equal(t, "SELECT visits.visit_uid FROM `visits`", toSQL(Search{}))
+	})
+
+	t.Run("success all", func(t *testing.T) {
+		var s = Search{Doctor_uid: "doctor", Nik: "3201010101010001", Statuses: []string{"pending", "ready"}, From: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC), Complaint: "batuk", Grouped: "patient"}
+		assert.Equal(t, "SELECT visits.visit_uid FROM `visits` WHERE visits.doctor_uid = 'doctor' AND patients.nik = '3201010101010001' AND visits.status in ('pending','ready') AND visits.date >= '2022-03-01' AND visits.date <= '2022-03-31' AND visits.complaint like '%batuk%' GROUP BY `patients`.`nik`", toSQL(s))
+	})
+
+	t.Run("success values are parameters", func(t *testing.T) {
+		var s = Search{Visit_uid: "x' or '1'='1", Complaint: "100%"}
+		assert.Equal(t, "SELECT visits.visit_uid FROM `visits` WHERE visits.visit_uid = 'x\\' or \\'1\\'=\\'1' AND visits.complaint like '%100\\%%'", toSQL(s))
+	})
+}
@@ -2,6 +2,9 @@ package visit
 
 import (
 	"be/entities"
+	"be/utils/busy"
+	"be/utils/list"
+	"be/utils/notice"
 	"errors"
 	"strconv"
 	"time"
@@ -56,6 +59,10 @@ func (r *Repo) Create(doctor_uid, patient_uid, date string, req entities.Visit)
 		return entities.Visit{}, res.Error
 	}
 
+	if res := tx.Create(&entities.CalendarSync{Visit_uid: uid, Next_at: time.Now()}); res.Error != nil {
+		tx.Rollback()
+		return entities.Visit{}, res.Error
+	}
 
 	return req, tx.Commit().Error
 
@@ -71,6 +78,14 @@ func (r *Repo) CreateVal(doctor_uid, patient_uid string, req entities.Visit) (en
 		return entities.Visit{}, errors.New("there's another appoinment in pending")
 	}
 
+	// the doctor blocked the day in their own calendar
+
+	if blocked, err := busy.Blocked(r.db, doctor_uid, time.Time(req.Date)); err != nil {
+		return entities.Visit{}, err
+	} else if blocked {
+		return entities.Visit{}, errors.New("doctor is busy on the date")
+	}
+
 	var res = r.db.Unscoped().Model(&entities.Visit{}).Where("patient_uid = ?", patient_uid).Scan(&[]entities.Visit{})
 	var uid string = patient_uid + "-" + strconv.Itoa(int(res.RowsAffected)+1)
 
@@ -94,6 +109,16 @@ func (r *Repo) CreateVal(doctor_uid, patient_uid string, req entities.Visit) (en
 		return entities.Visit{}, res.Error
 	}
 
+	if res := tx.Create(&entities.CalendarSync{Visit_uid: uid, Next_at: time.Now()}); res.Error != nil {
+		tx.Rollback()
+		return entities.Visit{}, res.Error
+	}
+
+	if err := notice.Enqueue(tx, notice.BookingConfirmed, uid, uid, patient_uid, doctor_uid); err != nil {
+		tx.Rollback()
+		return entities.Visit{}, err
+	}
+
 	return req, tx.Commit().Error
 }
 
@@ -159,6 +184,39 @@ func (r *Repo) Update(visit_uid string, req entities.Visit) (entities.Visit, err
 		}
 	}
 
+	// the event shows the status and complaint
+
+	if req.Status != "" || req.Complaint != "" {
+		if res := tx.Create(&entities.CalendarSync{Visit_uid: visit_uid, Next_at: time.Now()}); res.Error != nil {
+			tx.Rollback()
+			return entities.Visit{}, res.Error
+		}
+	}
+
+	// the patient and the doctor are told of a cancel
+
+	if req.Status == "cancelled" && resInit.Status != "cancelled" {
+		if err := notice.Enqueue(tx, notice.Cancelled, visit_uid, visit_uid, resInit.Patient_uid, resInit.Doctor_uid); err != nil {
+			tx.Rollback()
+			return entities.Visit{}, err
+		}
+	}
+
+	// lock clinical notes and close the referral once the visit is completed
+
+	if req.Status == "completed" {
+		var now = time.Now()
+		if res := tx.Model(&entities.Note{}).Where("visit_uid = ? and status = 'draft'", visit_uid).Updates(entities.Note{Status: "signed", SignedAt: &now}); res.Error != nil {
+			tx.Rollback()
+			return entities.Visit{}, res.Error
+		}
+
+		if res := tx.Model(&entities.Referral{}).Where("target_visit_uid = ? and status = 'accepted'", visit_uid).Update("status", "completed"); res.Error != nil {
+			tx.Rollback()
+			return entities.Visit{}, res.Error
+		}
+	}
+
 	return resInit, tx.Commit().Error
 }
 
@@ -188,68 +246,71 @@ func (r *Repo) Delete(visit_uid string) (entities.Visit, error) {
 		return entities.Visit{}, gorm.ErrRecordNotFound
 	}
 
+	if res := tx.Create(&entities.CalendarSync{Visit_uid: visit_uid, Next_at: time.Now()}); res.Error != nil {
+		tx.Rollback()
+		return entities.Visit{}, res.Error
+	}
+
+	// a visit yet to come is cancelled for the patient and the doctor
+
+	if resInit.Status == "pending" || resInit.Status == "ready" {
+		if err := notice.Enqueue(tx, notice.Cancelled, visit_uid, visit_uid, resInit.Patient_uid, resInit.Doctor_uid); err != nil {
+			tx.Rollback()
+			return entities.Visit{}, err
+		}
+	}
+
 	return resInit, tx.Commit().Error
 }
 
 func (r *Repo) GetVisitList(visit_uid string) (VisitCalendar, error) {
 	var visits VisitCalendar
 
-	if res := r.db.Model(&entities.Visit{}).Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on visits.doctor_uid = doctors.doctor_uid").Where("visits.visit_uid = ?", visit_uid).Select("doctors.address as Address, complaint as Complaint, date_format(visits.date, '%d-%m-%Y') as Date, doctors.name as DoctorName, patients.name as PatientName, doctors.email as DoctorEmail, patients.email as PatientEmail, event_uid as Event_uid").Last(&visits); res.Error != nil || res.RowsAffected == 0 {
+	if res := r.db.Model(&entities.Visit{}).Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on visits.doctor_uid = doctors.doctor_uid").Where("visits.visit_uid = ?", visit_uid).Select(CalendarColumns).Last(&visits); res.Error != nil || res.RowsAffected == 0 {
 		return VisitCalendar{}, gorm.ErrRecordNotFound
 	}
 
 	return visits, nil
 }
 
-func (r *Repo) GetVisitsVer1(kind, uid, status, date, grouped string) (Visits, error) {
-
-	switch kind {
-	case "":
-		kind = "visits.doctor_uid != '" + "uid" + "'"
-	case "patient":
-		kind = "patients.nik = '" + uid + "'"
-	case "doctor":
-		kind = "visits.doctor_uid = '" + uid + "'"
-	case "visit":
-		kind = "visits.visit_uid = '" + uid + "'"
-	}
-
-	switch status {
-	case "":
-		status = "and visits.status != '" + "status" + "'"
-	default:
-		status = "and visits.status = '" + status + "'"
-	}
-
-	switch date {
-	case "":
-		date = "and date(visits.date) != date('1000-01-01')"
-	default:
-		var layout = "02-01-2006"
-		var dateConv, err = time.Parse(layout, date)
-		if err != nil {
-			return Visits{}, errors.New("error in time parse date")
-		}
-		date = "and date(visits.date) = date('" + dateConv.Format("2006-01-02") + "')"
-	}
-	// log.Info(date)
-	switch grouped {
-	case "":
-		grouped = "id"
-	case "patient":
-		grouped = "patients.nik"
-	case "doctor":
-		grouped = "doctors.doctor_uid"
+// List is what the list of visits can be sorted by
+
+var List = list.Spec{
+	Sorts: map[string]list.Field{
+		"date":      {Column: "visits.date", Type: list.Date},
+		"createdAt": {Column: "visits.created_at", Type: list.Date},
+		"updatedAt": {Column: "visits.updated_at", Type: list.Date},
+	},
+	Sort: "-date",
+	Key:  "visits.id",
+}
+
+func (r *Repo) GetVisitsVer1(s Search, q list.Query) (Visits, list.Page, error) {
+
+	var db = q.Filter(s.Where(r.db.Model(&entities.Visit{}).Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on visits.doctor_uid = doctors.doctor_uid")))
+
+	total, err := list.Total(db)
+	if err != nil {
+		log.Info(err)
+		return Visits{}, list.Page{}, err
 	}
 
-	var visits Visits
+	var visits = Visits{Visits: []VisitResp{}}
 
-	var condition = kind + status + date
-	if res := r.db.Model(&entities.Visit{}).Joins("inner join patients on visits.patient_uid = patients.patient_uid").Joins("inner join doctors on visits.doctor_uid = doctors.doctor_uid").Group(grouped).Where(condition).Order("date DESC, visits.updated_at DESC").Select("visit_uid as Visit_uid,  date_format(visits.date, '%d-%m-%Y') as Date, visits.status as Status, complaint as Complaint, main_diagnose as MainDiagnose, addition_diagnose as AdditionDiagnose, action as Action, recipe as Recipe, blood_pressure as BloodPressure, heart_rate as HeartRate, respiratory_rate as RespiratoryRate ,o2_saturate as O2Saturate, weight as Weight, height as Height, bmi as Bmi, visits.doctor_uid as Doctor_uid, doctors.name as DoctorName, doctors.address as DoctorAddress, visits.patient_uid as Patient_uid, patients.name as PatientName, patients.gender as Gender, patients.nik as Nik").Find(&visits.Visits); res.Error != nil {
+	if res := q.Page(db).Select("visit_uid as Visit_uid,  date_format(visits.date, '%d-%m-%Y') as Date, visits.status as Status, complaint as Complaint, main_diagnose as MainDiagnose, addition_diagnose as AdditionDiagnose, action as Action, recipe as Recipe, blood_pressure as BloodPressure, heart_rate as HeartRate, respiratory_rate as RespiratoryRate ,o2_saturate as O2Saturate, weight as Weight, height as Height, bmi as Bmi, visits.doctor_uid as Doctor_uid, doctors.name as DoctorName, doctors.address as DoctorAddress, visits.patient_uid as Patient_uid, patients.name as PatientName, patients.gender as Gender, patients.nik as Nik" + q.Select()).Find(&visits.Visits); res.Error != nil {
 		log.Info(res.Error)
-		log.Info(condition)
-		return Visits{}, res.Error
+		return Visits{}, list.Page{}, res.Error
 	}
 
-	return visits, nil
+	var more = len(visits.Visits) > q.Limit
+	if more {
+		visits.Visits = visits.Visits[:q.Limit]
+	}
+
+	var last list.Row
+	if len(visits.Visits) != 0 {
+		last = visits.Visits[len(visits.Visits)-1].Row
+	}
+
+	return visits, q.Result(total, more, last), nil
 }
@@ -6,12 +6,15 @@ import (
 	"be/repository/doctor"
 	"be/repository/patient"
 	"be/utils"
+	"be/utils/list"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/labstack/gommon/log"
 	"github.com/lithammer/shortuuid"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
 )
 
 func TestCreate(t *testing.T) {
@@ -260,6 +263,33 @@ func TestCreateVal(t *testing.T) {
 		log.Info(err3)
 	})
 
+	t.Run("error doctor busy", func(t *testing.T) {
+		db.AutoMigrate(&entities.BusyTime{})
+
+		var mock = entities.Doctor{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "doctor", Capacity: 10}
+		res, err := doctor.New(db).Create(mock)
+		if err != nil {
+			t.Log()
+			t.Fatal()
+		}
+
+		var mock1 = entities.Patient{UserName: shortuuid.New(), Email: shortuuid.New(), Password: "patient"}
+		var res1, err1 = patient.New(db).Create(mock1)
+		if err1 != nil {
+			t.Log()
+			t.Fatal()
+		}
+
+		var date = time.Date(2022, 3, 10, 0, 0, 0, 0, time.Local)
+		db.Create(&entities.BusyTime{Doctor_uid: res.Doctor_uid, StartAt: date.Add(7 * time.Hour), EndAt: date.Add(18 * time.Hour)})
+
+		var _, err3 = r.CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(date)})
+		assert.Equal(t, "doctor is busy on the date", err3.Error())
+
+		_, err3 = r.CreateVal(res.Doctor_uid, res1.Patient_uid, entities.Visit{Complaint: "sick", Date: datatypes.Date(date.AddDate(0, 0, 1))})
+		assert.Nil(t, err3)
+	})
+
 }
 
 func TestUpdate(t *testing.T) {
@@ -594,27 +624,38 @@ func TestGetVisitsVer1(t *testing.T) {
 			t.Fatal()
 		}
 
-		var res3, err3 = r.GetVisitsVer1("", "", "", "", "")
+		var q, _ = list.Parse(List, url.Values{"limit": {"1"}})
+		var res3, page, err3 = r.GetVisitsVer1(Search{}, q)
 		assert.Nil(t, err3)
-		assert.NotNil(t, res3)
+		assert.Equal(t, 1, len(res3.Visits))
+		assert.Equal(t, int64(8), page.Total)
+
+		q, _ = list.Parse(List, url.Values{"cursor": {page.Next_cursor}, "limit": {"100"}})
+		var next, nextPage, _ = r.GetVisitsVer1(Search{}, q)
+		assert.Equal(t, 7, len(next.Visits))
+		assert.NotEqual(t, res3.Visits[0].Visit_uid, next.Visits[0].Visit_uid)
+		assert.Equal(t, "", nextPage.Next_cursor)
+
+		q, _ = list.Parse(List, nil)
 		// log.Info(res3.Visits[0].RespiratoryRate)
 		log.Info(res3)
 		log.Info(len(res3.Visits))
 
-		res3, err3 = r.GetVisitsVer1("doctor", res.Doctor_uid, "pending", "", "patient")
+		res3, _, err3 = r.GetVisitsVer1(Search{Doctor_uid: res.Doctor_uid, Statuses: []string{"pending"}, Grouped: "patient"}, q)
 		assert.Nil(t, err3)
 		assert.NotNil(t, res3)
 		// log.Info(res3)
 		log.Info(len(res3.Visits))
 
-		res3, err3 = r.GetVisitsVer1("patient", res2.Patient_uid, "", time.Now().Format(layDate), "doctor")
+		res3, _, err3 = r.GetVisitsVer1(Search{Patient_uid: res2.Patient_uid, From: time.Now(), To: time.Now(), Grouped: "doctor"}, q)
 		assert.Nil(t, err3)
 		assert.NotNil(t, res3)
 
 		log.Info(len(res3.Visits))
 
-		_, err3 = r.GetVisitsVer1("patient", res2.Patient_uid, "", "date error", "")
-		assert.NotNil(t, err3)
+		res3, _, err3 = r.GetVisitsVer1(Search{Complaint: "complain2", Statuses: []string{"pending", "ready"}}, q)
+		assert.Nil(t, err3)
+		assert.Equal(t, 3, len(res3.Visits))
 	})
 
 }
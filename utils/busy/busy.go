@@ -0,0 +1,89 @@
+package busy
+
+import (
+	"be/entities"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the doctors see patients from 08:00 to 17:00, a day whose clinic hours
+// are all busy in the calendar of the doctor can't be booked
+
+const (
+	Open  = 8 * time.Hour
+	Close = 17 * time.Hour
+)
+
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Merge sorts the intervals and joins the ones overlapping or touching
+func Merge(intervals []Interval) []Interval {
+	var sorted = make([]Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		if interval.End.After(interval.Start) {
+			sorted = append(sorted, interval)
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var res = []Interval{}
+	for _, interval := range sorted {
+		var last = len(res) - 1
+		if last >= 0 && !interval.Start.After(res[last].End) {
+			if interval.End.After(res[last].End) {
+				res[last].End = interval.End
+			}
+			continue
+		}
+		res = append(res, interval)
+	}
+
+	return res
+}
+
+// Covers tells whether the intervals leave no free time from start to end
+func Covers(intervals []Interval, start, end time.Time) bool {
+	for _, interval := range Merge(intervals) {
+		if !interval.Start.After(start) && !interval.End.Before(end) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Hours are the clinic hours of the day of date, in the local time
+func Hours(date time.Time) (time.Time, time.Time) {
+	var day = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	return day.Add(Open), day.Add(Close)
+}
+
+// Blocked tells whether the busy times imported from the calendar of the
+// doctor cover the clinic hours of date
+func Blocked(db *gorm.DB, doctor_uid string, date time.Time) (bool, error) {
+	var open, close = Hours(date)
+
+	var times []entities.BusyTime
+	if res := db.Model(&entities.BusyTime{}).Where("doctor_uid = ? and start_at < ? and end_at > ?", doctor_uid, close, open).Find(&times); res.Error != nil {
+		return false, res.Error
+	}
+
+	return Covers(FromTimes(times), open, close), nil
+}
+
+func FromTimes(times []entities.BusyTime) []Interval {
+	var res = make([]Interval, 0, len(times))
+	for _, t := range times {
+		res = append(res, Interval{Start: t.StartAt, End: t.EndAt})
+	}
+
+	return res
+}
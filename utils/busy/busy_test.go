@@ -0,0 +1,46 @@
+package busy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2022, 3, 10, hour, minute, 0, 0, time.Local)
+}
+
+func TestMerge(t *testing.T) {
+	t.Run("overlapping and touching", func(t *testing.T) {
+		var res = Merge([]Interval{
+			{Start: at(13, 0), End: at(17, 0)},
+			{Start: at(8, 0), End: at(10, 0)},
+			{Start: at(9, 0), End: at(12, 0)},
+			{Start: at(12, 0), End: at(12, 30)},
+		})
+		assert.Equal(t, []Interval{{Start: at(8, 0), End: at(12, 30)}, {Start: at(13, 0), End: at(17, 0)}}, res)
+	})
+
+	t.Run("empty intervals are dropped", func(t *testing.T) {
+		assert.Equal(t, []Interval{}, Merge([]Interval{{Start: at(9, 0), End: at(9, 0)}}))
+	})
+}
+
+func TestCovers(t *testing.T) {
+	var open, close = Hours(at(12, 0))
+
+	t.Run("clinic hours", func(t *testing.T) {
+		assert.Equal(t, at(8, 0), open)
+		assert.Equal(t, at(17, 0), close)
+	})
+
+	t.Run("covered by several intervals", func(t *testing.T) {
+		assert.True(t, Covers([]Interval{{Start: at(7, 0), End: at(12, 0)}, {Start: at(11, 0), End: at(18, 0)}}, open, close))
+	})
+
+	t.Run("free time left", func(t *testing.T) {
+		assert.False(t, Covers([]Interval{{Start: at(7, 0), End: at(12, 0)}, {Start: at(13, 0), End: at(18, 0)}}, open, close))
+		assert.False(t, Covers(nil, open, close))
+	})
+}
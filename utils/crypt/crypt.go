@@ -0,0 +1,62 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// Aes seals secrets kept in the database, e.g. the calendar tokens of the
+// doctors, with aes-256-gcm. The sealed value is the base64 of the nonce
+// and the cipher text
+
+type Aes struct {
+	aead cipher.AEAD
+}
+
+// New derives the aes key from the sha256 of key. A key is required, the
+// values sealed with a random one could not be read after a restart
+func New(key string) (*Aes, error) {
+	if key == "" {
+		return nil, errors.New("no encryption key")
+	}
+
+	var sum = sha256.Sum256([]byte(key))
+
+	var block, err = aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Aes{aead: aead}, nil
+}
+
+func (a *Aes) Encrypt(plain []byte) (string, error) {
+	var nonce = make([]byte, a.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(a.aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+func (a *Aes) Decrypt(sealed string) ([]byte, error) {
+	var b, err = base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < a.aead.NonceSize() {
+		return nil, errors.New("sealed value is too short")
+	}
+
+	return a.aead.Open(nil, b[:a.aead.NonceSize()], b[a.aead.NonceSize():], nil)
+}
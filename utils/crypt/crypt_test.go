@@ -0,0 +1,47 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAes(t *testing.T) {
+	var a, _ = New("secret")
+
+	t.Run("success", func(t *testing.T) {
+		var sealed, err = a.Encrypt([]byte("refresh token"))
+		assert.Nil(t, err)
+		assert.NotContains(t, sealed, "refresh")
+
+		plain, err := a.Decrypt(sealed)
+		assert.Nil(t, err)
+		assert.Equal(t, "refresh token", string(plain))
+
+		// a new nonce every time
+
+		var sealed1, _ = a.Encrypt([]byte("refresh token"))
+		assert.NotEqual(t, sealed, sealed1)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		var sealed, _ = a.Encrypt([]byte("refresh token"))
+		var other, _ = New("other")
+
+		var _, err = other.Decrypt(sealed)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		var _, err = New("")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var _, err = a.Decrypt("not base64!")
+		assert.NotNil(t, err)
+
+		_, err = a.Decrypt("AAAA")
+		assert.NotNil(t, err)
+	})
+}
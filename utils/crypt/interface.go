@@ -0,0 +1,6 @@
+package crypt
+
+type Cipher interface {
+	Encrypt(plain []byte) (string, error)
+	Decrypt(sealed string) ([]byte, error)
+}
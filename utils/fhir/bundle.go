@@ -0,0 +1,100 @@
+package fhir
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Page is the window of a search, Offset is the index of the first match
+
+type Page struct {
+	Offset int
+	Count  int
+}
+
+// SearchSet wraps the matches of a search in a searchset bundle, base is the
+// full url of the fhir endpoint and query the search parameters of the request
+
+func SearchSet(base, resourceType string, query url.Values, page Page, total int64, resources []Resource) Bundle {
+	var bundle = Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Total:        &total,
+		Link:         []BundleLink{{Relation: "self", Url: pageUrl(base, resourceType, query, page.Offset, page.Count)}},
+		Entry:        []BundleEntry{},
+	}
+
+	if page.Offset > 0 {
+		var prev = page.Offset - page.Count
+		if prev < 0 {
+			prev = 0
+		}
+		bundle.Link = append(bundle.Link, BundleLink{Relation: "previous", Url: pageUrl(base, resourceType, query, prev, page.Count)})
+	}
+
+	if int64(page.Offset+page.Count) < total {
+		bundle.Link = append(bundle.Link, BundleLink{Relation: "next", Url: pageUrl(base, resourceType, query, page.Offset+page.Count, page.Count)})
+	}
+
+	for _, resource := range resources {
+		bundle.Entry = append(bundle.Entry, BundleEntry{
+			FullUrl:  base + "/" + resourceType + "/" + resource.ResourceId(),
+			Resource: resource,
+			Search:   &BundleSearch{Mode: "match"},
+		})
+	}
+
+	return bundle
+}
+
+// Collection wraps resources that belong together, e.g. the record of a patient
+
+func Collection(base string, resources []Resource) Bundle {
+	var bundle = Bundle{
+		ResourceType: "Bundle",
+		Type:         "collection",
+		Entry:        []BundleEntry{},
+	}
+
+	for _, resource := range resources {
+		bundle.Entry = append(bundle.Entry, BundleEntry{
+			FullUrl:  base + "/" + resourceType(resource) + "/" + resource.ResourceId(),
+			Resource: resource,
+		})
+	}
+
+	return bundle
+}
+
+func resourceType(resource Resource) string {
+	switch resource.(type) {
+	case Patient:
+		return "Patient"
+	case Practitioner:
+		return "Practitioner"
+	case Encounter:
+		return "Encounter"
+	case Condition:
+		return "Condition"
+	case MedicationRequest:
+		return "MedicationRequest"
+	}
+	return ""
+}
+
+func pageUrl(base, resourceType string, query url.Values, offset, count int) string {
+	var values = url.Values{}
+	for k, v := range query {
+		values[k] = v
+	}
+	values.Set("_offset", strconv.Itoa(offset))
+	values.Set("_count", strconv.Itoa(count))
+	return base + "/" + resourceType + "?" + values.Encode()
+}
+
+func Outcome(severity, code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []OperationOutcomeIssue{{Severity: severity, Code: code, Diagnostics: diagnostics}},
+	}
+}
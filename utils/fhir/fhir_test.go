@@ -0,0 +1,91 @@
+package fhir
+
+import (
+	"be/entities"
+	"be/utils/fhir/fhirtest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+var patient = entities.Patient{Patient_uid: "patient", UpdatedAt: time.Now(), Nik: "1234567890123456", Name: "siti", Email: "siti@mail.com", Gender: "wanita", Address: "jakarta", Dob: datatypes.Date(time.Date(1990, 5, 5, 0, 0, 0, 0, time.UTC))}
+var doctor = entities.Doctor{Doctor_uid: "doctor", UpdatedAt: time.Now(), Name: "andi", Email: "andi@mail.com", Address: "jakarta", Status: "available"}
+var visit = entities.Visit{Visit_uid: "patient-1", UpdatedAt: time.Now(), Doctor_uid: "doctor", Patient_uid: "patient", Date: datatypes.Date(time.Now()), Status: "completed", Complaint: "fever", MainDiagnose: "influenza", AdditionDiagnose: "dehydration"}
+
+func TestMapper(t *testing.T) {
+	t.Run("patient", func(t *testing.T) {
+		var res = FromPatient(patient)
+		fhirtest.ValidateResource(t, res)
+		assert.Equal(t, "female", res.Gender)
+		assert.Equal(t, "1990-05-05", res.BirthDate)
+		assert.Equal(t, NikSystem, res.Identifier[0].System)
+	})
+
+	t.Run("patient without profile", func(t *testing.T) {
+		fhirtest.ValidateResource(t, FromPatient(entities.Patient{Patient_uid: "patient"}))
+	})
+
+	t.Run("practitioner", func(t *testing.T) {
+		fhirtest.ValidateResource(t, FromDoctor(doctor))
+	})
+
+	t.Run("encounter", func(t *testing.T) {
+		var res = FromVisit(visit)
+		fhirtest.ValidateResource(t, res)
+		assert.Equal(t, "finished", res.Status)
+		assert.Equal(t, "Condition/patient-1", res.Diagnosis[0].Condition.Reference)
+	})
+
+	t.Run("condition", func(t *testing.T) {
+		var res = FromDiagnose(visit)
+		fhirtest.ValidateResource(t, res)
+		assert.Equal(t, "dehydration", res.Note[0].Text)
+	})
+
+	t.Run("reverse code", func(t *testing.T) {
+		assert.Equal(t, "pria", Gender("male"))
+		assert.Equal(t, "", Gender("unknown"))
+		assert.Equal(t, "pending", VisitStatus("planned"))
+	})
+}
+
+func TestSearchSet(t *testing.T) {
+	var query = url.Values{"name": []string{"siti"}}
+
+	t.Run("first page", func(t *testing.T) {
+		var bundle = SearchSet("http://localhost/fhir", "Patient", query, Page{Offset: 0, Count: 1}, 3, []Resource{FromPatient(patient)})
+		fhirtest.ValidateResource(t, bundle)
+		assert.Equal(t, 2, len(bundle.Link))
+		assert.Equal(t, "next", bundle.Link[1].Relation)
+		assert.Equal(t, "http://localhost/fhir/Patient?_count=1&_offset=1&name=siti", bundle.Link[1].Url)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		var bundle = SearchSet("http://localhost/fhir", "Encounter", query, Page{Offset: 2, Count: 1}, 3, []Resource{FromVisit(visit)})
+		fhirtest.ValidateResource(t, bundle)
+		assert.Equal(t, "previous", bundle.Link[1].Relation)
+		assert.Equal(t, 2, len(bundle.Link))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		fhirtest.ValidateResource(t, SearchSet("http://localhost/fhir", "Condition", query, Page{Count: 20}, 0, nil))
+	})
+
+	t.Run("outcome", func(t *testing.T) {
+		fhirtest.ValidateResource(t, Outcome("error", "not-found", "Patient/x is not found"))
+	})
+
+	t.Run("invalid resource is rejected", func(t *testing.T) {
+		assert.NotNil(t, fhirtest.Check([]byte(`{"resourceType": "Encounter", "status": "done", "class": {}}`)))
+		assert.NotNil(t, fhirtest.Check([]byte(`{"resourceType": "Patient", "gender": "pria"}`)))
+	})
+}
+
+func TestCollection(t *testing.T) {
+	var bundle = Collection("http://localhost/fhir", []Resource{FromPatient(patient), FromDoctor(doctor), FromVisit(visit), FromDiagnose(visit), FromRecipe(entities.Visit{Visit_uid: "patient-1", Patient_uid: "patient", Doctor_uid: "doctor", Recipe: "paracetamol 3x500mg"})})
+	fhirtest.ValidateResource(t, bundle)
+	assert.Equal(t, "http://localhost/fhir/MedicationRequest/patient-1", bundle.Entry[4].FullUrl)
+}
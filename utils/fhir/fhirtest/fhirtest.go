@@ -0,0 +1,53 @@
+// Package fhirtest checks in tests that the json produced by the api is valid
+// against the bundled FHIR R4 schema.
+package fhirtest
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed fhir.schema.json
+var schemaJson []byte
+
+var schema = jsonschema.MustCompileString("fhir.schema.json", string(schemaJson))
+
+// Check returns the validation error of body, nil when it is a valid resource
+
+func Check(body []byte) error {
+	var v interface{}
+	var decoder = json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return err
+	}
+
+	return schema.Validate(v)
+}
+
+// Validate fails the test when body is not a valid FHIR resource
+
+func Validate(t *testing.T, body []byte) {
+	t.Helper()
+
+	if err := Check(body); err != nil {
+		t.Errorf("invalid fhir resource: %#v", err)
+	}
+}
+
+// ValidateResource marshals the resource before validating it
+
+func ValidateResource(t *testing.T, resource interface{}) {
+	t.Helper()
+
+	var body, err = json.Marshal(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Validate(t, body)
+}
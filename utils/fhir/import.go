@@ -0,0 +1,423 @@
+package fhir
+
+import (
+	"be/entities"
+	"be/utils"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Import is a bundle mapped to the entities of the clinic, references between
+// resources are kept as keys of the bundle entries
+
+type Import struct {
+	Patients     []ImportPatient
+	Encounters   []ImportEncounter
+	Conditions   []ImportCondition
+	Observations []ImportObservation
+	Rejected     []ReportEntry
+}
+
+type ImportPatient struct {
+	Key     string
+	Id      string
+	Patient entities.Patient
+}
+
+type ImportEncounter struct {
+	Key     string
+	Id      string
+	Patient string
+	Visit   entities.Visit
+}
+
+type ImportCondition struct {
+	Id        string
+	Encounter string
+	Diagnose  string
+}
+
+// Vitals only holds the vital sign fields of the visit
+
+type ImportObservation struct {
+	Id        string
+	Encounter string
+	Vitals    entities.Visit
+}
+
+type ReportEntry struct {
+	ResourceType string `json:"resourceType"`
+	Id           string `json:"id"`
+	Action       string `json:"action"`
+	Target       string `json:"target,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+type Report struct {
+	Created  int           `json:"created"`
+	Merged   int           `json:"merged"`
+	Rejected int           `json:"rejected"`
+	Entries  []ReportEntry `json:"entries"`
+}
+
+func (r *Report) Add(entry ReportEntry) {
+	switch entry.Action {
+	case "created":
+		r.Created++
+	case "merged":
+		r.Merged++
+	default:
+		r.Rejected++
+	}
+	r.Entries = append(r.Entries, entry)
+}
+
+func Reject(resourceType, id, reason string) ReportEntry {
+	return ReportEntry{ResourceType: resourceType, Id: id, Action: "rejected", Reason: reason}
+}
+
+type rawEntry struct {
+	FullUrl  string          `json:"fullUrl"`
+	Resource json.RawMessage `json:"resource"`
+}
+
+type rawBundle struct {
+	ResourceType string     `json:"resourceType"`
+	Type         string     `json:"type"`
+	Entry        []rawEntry `json:"entry"`
+}
+
+type rawResource struct {
+	ResourceType string `json:"resourceType"`
+	Id           string `json:"id"`
+}
+
+// ParseBundle maps a FHIR R4 bundle, resources that can't be imported are
+// listed in Rejected and the others are left for the repository
+
+func ParseBundle(body []byte) (Import, error) {
+	var bundle rawBundle
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return Import{}, errors.New("invalid json")
+	}
+
+	if bundle.ResourceType != "Bundle" {
+		return Import{}, errors.New("resource is not a bundle")
+	}
+
+	var res = Import{}
+
+	// every entry can be referenced by its relative reference or its full url
+
+	var keys = map[string]string{}
+	var resources = []rawResource{}
+
+	for _, entry := range bundle.Entry {
+		var resource rawResource
+		if err := json.Unmarshal(entry.Resource, &resource); err != nil || resource.ResourceType == "" {
+			res.Rejected = append(res.Rejected, Reject("", "", "invalid resource"))
+			resources = append(resources, rawResource{})
+			continue
+		}
+		var key = entry.FullUrl
+		if resource.Id != "" {
+			key = resource.ResourceType + "/" + resource.Id
+		}
+		keys[key] = key
+		if entry.FullUrl != "" {
+			keys[entry.FullUrl] = key
+		}
+		resources = append(resources, resource)
+	}
+
+	var resolve = func(ref *Reference) string {
+		if ref == nil {
+			return ""
+		}
+		if key, ok := keys[ref.Reference]; ok {
+			return key
+		}
+		// absolute reference to the server of the other clinic
+		var parts = strings.Split(ref.Reference, "/")
+		if len(parts) >= 2 {
+			return keys[strings.Join(parts[len(parts)-2:], "/")]
+		}
+		return ""
+	}
+
+	for i, entry := range bundle.Entry {
+		var resource = resources[i]
+		var key = resource.ResourceType + "/" + resource.Id
+		if resource.Id == "" {
+			key = entry.FullUrl
+		}
+
+		switch resource.ResourceType {
+		case "":
+		case "Patient":
+			var patient Patient
+			if err := json.Unmarshal(entry.Resource, &patient); err != nil {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "invalid resource"))
+				continue
+			}
+			var mapped, err = ToPatient(patient)
+			if err != nil {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, err.Error()))
+				continue
+			}
+			res.Patients = append(res.Patients, ImportPatient{Key: key, Id: resource.Id, Patient: mapped})
+		case "Encounter":
+			var encounter Encounter
+			if err := json.Unmarshal(entry.Resource, &encounter); err != nil {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "invalid resource"))
+				continue
+			}
+			var patient = resolve(encounter.Subject)
+			if patient == "" {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "subject is not a patient of the bundle"))
+				continue
+			}
+			var mapped, err = ToVisit(encounter)
+			if err != nil {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, err.Error()))
+				continue
+			}
+			res.Encounters = append(res.Encounters, ImportEncounter{Key: key, Id: resource.Id, Patient: patient, Visit: mapped})
+		case "Condition":
+			var condition Condition
+			if err := json.Unmarshal(entry.Resource, &condition); err != nil {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "invalid resource"))
+				continue
+			}
+			var encounter = resolve(condition.Encounter)
+			if encounter == "" {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "condition is not linked to an encounter of the bundle"))
+				continue
+			}
+			var diagnose = text(condition.Code)
+			if diagnose == "" {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "condition has no code"))
+				continue
+			}
+			res.Conditions = append(res.Conditions, ImportCondition{Id: resource.Id, Encounter: encounter, Diagnose: diagnose})
+		case "Observation":
+			var observation Observation
+			if err := json.Unmarshal(entry.Resource, &observation); err != nil {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "invalid resource"))
+				continue
+			}
+			var encounter = resolve(observation.Encounter)
+			if encounter == "" {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "observation is not linked to an encounter of the bundle"))
+				continue
+			}
+			var vitals, err = ToVitals(observation)
+			if err != nil {
+				res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, err.Error()))
+				continue
+			}
+			res.Observations = append(res.Observations, ImportObservation{Id: resource.Id, Encounter: encounter, Vitals: vitals})
+		default:
+			res.Rejected = append(res.Rejected, Reject(resource.ResourceType, resource.Id, "resource type is not supported"))
+		}
+	}
+
+	return res, nil
+}
+
+// ToPatient requires the nik, it's the key to find the patient in the clinic
+
+func ToPatient(patient Patient) (entities.Patient, error) {
+	var res = entities.Patient{Gender: "lainnya"}
+
+	for _, identifier := range patient.Identifier {
+		if identifier.System == NikSystem {
+			res.Nik = identifier.Value
+		}
+	}
+
+	if res.Nik == "" {
+		return entities.Patient{}, errors.New("patient has no nik identifier")
+	}
+
+	if err := utils.NikValid(res.Nik); err != nil {
+		return entities.Patient{}, err
+	}
+
+	for _, name := range patient.Name {
+		var text = name.Text
+		if text == "" {
+			text = strings.TrimSpace(strings.Join(append(name.Given, name.Family), " "))
+		}
+		if text != "" && (res.Name == "" || name.Use == "official") {
+			res.Name = text
+		}
+	}
+
+	if gender := Gender(patient.Gender); gender != "" {
+		res.Gender = gender
+	}
+
+	if dob, err := time.Parse("2006-01-02", patient.BirthDate); err == nil {
+		res.Dob = datatypes.Date(dob)
+	}
+
+	for _, telecom := range patient.Telecom {
+		if telecom.System == "email" && utils.EmailValid(telecom.Value) == nil {
+			res.Email = telecom.Value
+			break
+		}
+	}
+
+	if len(patient.Address) != 0 {
+		var address = patient.Address[0]
+		res.Address = address.Text
+		if res.Address == "" {
+			res.Address = strings.Join(append(address.Line, address.City), ", ")
+		}
+	}
+
+	return res, nil
+}
+
+// ToVisit only maps past encounters, a planned encounter of another clinic
+// would block new appointments of the patient
+
+func ToVisit(encounter Encounter) (entities.Visit, error) {
+	var res = entities.Visit{}
+
+	switch encounter.Status {
+	case "finished":
+		res.Status = "completed"
+	case "cancelled", "entered-in-error":
+		res.Status = "cancelled"
+	default:
+		return entities.Visit{}, errors.New("only finished or cancelled encounters are imported")
+	}
+
+	if encounter.Period == nil || len(encounter.Period.Start) < 10 {
+		return entities.Visit{}, errors.New("encounter has no start date")
+	}
+
+	var date, err = time.Parse("2006-01-02", encounter.Period.Start[:10])
+	if err != nil {
+		return entities.Visit{}, errors.New("invalid encounter start date")
+	}
+	res.Date = datatypes.Date(date)
+
+	var complaints = []string{}
+	for _, reason := range encounter.ReasonCode {
+		if t := text(&reason); t != "" {
+			complaints = append(complaints, t)
+		}
+	}
+	res.Complaint = strings.Join(complaints, ", ")
+
+	return res, nil
+}
+
+// loinc codes of the vital signs kept on the visit
+
+const (
+	LoincBloodPressure   = "85354-9"
+	LoincSystolic        = "8480-6"
+	LoincDiastolic       = "8462-4"
+	LoincHeartRate       = "8867-4"
+	LoincRespiratoryRate = "9279-1"
+	LoincO2Saturation    = "2708-6"
+	LoincPulseOximetry   = "59408-5"
+	LoincWeight          = "29463-7"
+	LoincHeight          = "8302-2"
+	LoincBmi             = "39156-5"
+)
+
+func ToVitals(observation Observation) (entities.Visit, error) {
+	var res = entities.Visit{}
+
+	switch code(observation.Code, "http://loinc.org") {
+	case LoincBloodPressure:
+		var systolic, diastolic string
+		for _, component := range observation.Component {
+			switch code(component.Code, "http://loinc.org") {
+			case LoincSystolic:
+				systolic = quantity(component.ValueQuantity)
+			case LoincDiastolic:
+				diastolic = quantity(component.ValueQuantity)
+			}
+		}
+		if systolic == "" || diastolic == "" {
+			return entities.Visit{}, errors.New("blood pressure needs systolic and diastolic")
+		}
+		res.BloodPressure = systolic + "/" + diastolic
+		return res, nil
+	case LoincHeartRate:
+		res.HeartRate = quantity(observation.ValueQuantity)
+	case LoincRespiratoryRate:
+		res.RespiratoryRate = quantity(observation.ValueQuantity)
+	case LoincO2Saturation, LoincPulseOximetry:
+		res.O2Saturate = quantity(observation.ValueQuantity)
+	case LoincWeight:
+		res.Weight = scaled(observation.ValueQuantity, "g", 0.001)
+	case LoincHeight:
+		res.Height = scaled(observation.ValueQuantity, "m", 100)
+	case LoincBmi:
+		res.Bmi = quantity(observation.ValueQuantity)
+	default:
+		return entities.Visit{}, errors.New("observation code is not supported")
+	}
+
+	if res == (entities.Visit{}) {
+		return entities.Visit{}, errors.New("observation has no value")
+	}
+
+	return res, nil
+}
+
+func code(concept CodeableConcept, system string) string {
+	for _, coding := range concept.Coding {
+		if coding.System == system {
+			return coding.Code
+		}
+	}
+	return ""
+}
+
+func text(concept *CodeableConcept) string {
+	if concept == nil {
+		return ""
+	}
+	if concept.Text != "" {
+		return concept.Text
+	}
+	for _, coding := range concept.Coding {
+		if coding.Display != "" {
+			return coding.Display
+		}
+	}
+	return ""
+}
+
+func quantity(q *Quantity) string {
+	if q == nil || q.Value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*q.Value, 'f', -1, 64)
+}
+
+// scaled converts the value when it's sent in the given unit, e.g. weight in g
+
+func scaled(q *Quantity, unit string, factor float64) string {
+	if q == nil || q.Value == nil {
+		return ""
+	}
+	if q.Code == unit || q.Unit == unit {
+		var v = *q.Value * factor
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return quantity(q)
+}
@@ -0,0 +1,61 @@
+package fhir
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBundle(t *testing.T) {
+	var body, err = ioutil.ReadFile("testdata/bundle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var res, err = ParseBundle(body)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1, len(res.Patients))
+		assert.Equal(t, "Siti Rahayu", res.Patients[0].Patient.Name)
+		assert.Equal(t, "wanita", res.Patients[0].Patient.Gender)
+		assert.Equal(t, "Jl. Melati 5, Jakarta", res.Patients[0].Patient.Address)
+
+		assert.Equal(t, 1, len(res.Encounters))
+		assert.Equal(t, res.Patients[0].Key, res.Encounters[0].Patient)
+		assert.Equal(t, "completed", res.Encounters[0].Visit.Status)
+		assert.Equal(t, "fever", res.Encounters[0].Visit.Complaint)
+
+		assert.Equal(t, 2, len(res.Conditions))
+		assert.Equal(t, "Influenza", res.Conditions[0].Diagnose)
+		assert.Equal(t, "Encounter/enc-1", res.Conditions[1].Encounter)
+
+		assert.Equal(t, 2, len(res.Observations))
+		assert.Equal(t, "120/80", res.Observations[0].Vitals.BloodPressure)
+		assert.Equal(t, "55.5", res.Observations[1].Vitals.Weight)
+
+		// patient without nik, planned encounter, unknown observation and medication
+
+		assert.Equal(t, 4, len(res.Rejected))
+	})
+
+	t.Run("error not a bundle", func(t *testing.T) {
+		var _, err = ParseBundle([]byte(`{"resourceType": "Patient"}`))
+		assert.NotNil(t, err)
+
+		_, err = ParseBundle([]byte(`not json`))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestReport(t *testing.T) {
+	var report = Report{}
+	report.Add(ReportEntry{Action: "created"})
+	report.Add(ReportEntry{Action: "merged"})
+	report.Add(Reject("Patient", "1", "patient has no nik identifier"))
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Merged)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Equal(t, 3, len(report.Entries))
+}
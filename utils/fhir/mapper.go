@@ -0,0 +1,183 @@
+package fhir
+
+import (
+	"be/entities"
+	"time"
+)
+
+const (
+	// identifier system of the national id used by the national health platform
+	NikSystem = "https://fhir.kemkes.go.id/id/nik"
+
+	ActCodeSystem  = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+	CategorySystem = "http://terminology.hl7.org/CodeSystem/condition-category"
+)
+
+var genders = map[string]string{
+	"pria":    "male",
+	"wanita":  "female",
+	"lainnya": "other",
+}
+
+var encounterStatus = map[string]string{
+	"pending":   "planned",
+	"ready":     "arrived",
+	"completed": "finished",
+	"cancelled": "cancelled",
+}
+
+// Gender returns the gender of the patient as stored from a FHIR gender
+
+func Gender(gender string) string {
+	for k, v := range genders {
+		if v == gender {
+			return k
+		}
+	}
+	return ""
+}
+
+// VisitStatus returns the status of the visit from a FHIR encounter status
+
+func VisitStatus(status string) string {
+	for k, v := range encounterStatus {
+		if v == status {
+			return k
+		}
+	}
+	return ""
+}
+
+func FromPatient(patient entities.Patient) Patient {
+	var res = Patient{
+		ResourceType: "Patient",
+		Id:           patient.Patient_uid,
+		Meta:         &Meta{LastUpdated: instant(patient.UpdatedAt)},
+		Active:       true,
+		Gender:       genders[patient.Gender],
+		BirthDate:    date(time.Time(patient.Dob)),
+	}
+
+	if patient.Nik != "" {
+		res.Identifier = []Identifier{{Use: "official", System: NikSystem, Value: patient.Nik}}
+	}
+
+	if patient.Name != "" {
+		res.Name = []HumanName{{Use: "official", Text: patient.Name}}
+	}
+
+	if patient.Email != "" {
+		res.Telecom = []ContactPoint{{System: "email", Value: patient.Email}}
+	}
+
+	if patient.Address != "" {
+		res.Address = []Address{{Use: "home", Text: patient.Address}}
+	}
+
+	return res
+}
+
+func FromDoctor(doctor entities.Doctor) Practitioner {
+	var res = Practitioner{
+		ResourceType: "Practitioner",
+		Id:           doctor.Doctor_uid,
+		Meta:         &Meta{LastUpdated: instant(doctor.UpdatedAt)},
+		Active:       doctor.Status != "unAvailable",
+	}
+
+	if doctor.Name != "" {
+		res.Name = []HumanName{{Use: "official", Text: doctor.Name}}
+	}
+
+	if doctor.Email != "" {
+		res.Telecom = []ContactPoint{{System: "email", Value: doctor.Email, Use: "work"}}
+	}
+
+	if doctor.Address != "" {
+		res.Address = []Address{{Use: "work", Text: doctor.Address}}
+	}
+
+	return res
+}
+
+func FromVisit(visit entities.Visit) Encounter {
+	var res = Encounter{
+		ResourceType: "Encounter",
+		Id:           visit.Visit_uid,
+		Meta:         &Meta{LastUpdated: instant(visit.UpdatedAt)},
+		Status:       encounterStatus[visit.Status],
+		Class:        Coding{System: ActCodeSystem, Code: "AMB", Display: "ambulatory"},
+		Subject:      &Reference{Reference: "Patient/" + visit.Patient_uid},
+		Participant:  []EncounterParticipant{{Individual: &Reference{Reference: "Practitioner/" + visit.Doctor_uid}}},
+		Period:       &Period{Start: date(time.Time(visit.Date))},
+	}
+
+	if res.Status == "" {
+		res.Status = "unknown"
+	}
+
+	if visit.Complaint != "" {
+		res.ReasonCode = []CodeableConcept{{Text: visit.Complaint}}
+	}
+
+	if visit.MainDiagnose != "" {
+		res.Diagnosis = []EncounterDiagnosis{{Condition: Reference{Reference: "Condition/" + visit.Visit_uid}, Rank: 1}}
+	}
+
+	return res
+}
+
+// FromDiagnose maps the diagnosis of a visit to a condition with the same id,
+// the additional diagnose is kept as note
+
+func FromDiagnose(visit entities.Visit) Condition {
+	var res = Condition{
+		ResourceType: "Condition",
+		Id:           visit.Visit_uid,
+		Meta:         &Meta{LastUpdated: instant(visit.UpdatedAt)},
+		Category:     []CodeableConcept{{Coding: []Coding{{System: CategorySystem, Code: "encounter-diagnosis", Display: "Encounter Diagnosis"}}}},
+		Code:         &CodeableConcept{Text: visit.MainDiagnose},
+		Subject:      Reference{Reference: "Patient/" + visit.Patient_uid},
+		Encounter:    &Reference{Reference: "Encounter/" + visit.Visit_uid},
+		RecordedDate: date(time.Time(visit.Date)),
+		Asserter:     &Reference{Reference: "Practitioner/" + visit.Doctor_uid},
+	}
+
+	if visit.AdditionDiagnose != "" {
+		res.Note = []Annotation{{Text: visit.AdditionDiagnose}}
+	}
+
+	return res
+}
+
+// FromRecipe maps the prescription written on the visit, the recipe is free
+// text so it's kept as the text of the medication
+
+func FromRecipe(visit entities.Visit) MedicationRequest {
+	return MedicationRequest{
+		ResourceType:              "MedicationRequest",
+		Id:                        visit.Visit_uid,
+		Meta:                      &Meta{LastUpdated: instant(visit.UpdatedAt)},
+		Status:                    "completed",
+		Intent:                    "order",
+		MedicationCodeableConcept: &CodeableConcept{Text: visit.Recipe},
+		Subject:                   Reference{Reference: "Patient/" + visit.Patient_uid},
+		Encounter:                 &Reference{Reference: "Encounter/" + visit.Visit_uid},
+		AuthoredOn:                date(time.Time(visit.Date)),
+		Requester:                 &Reference{Reference: "Practitioner/" + visit.Doctor_uid},
+	}
+}
+
+func instant(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,201 @@
+package fhir
+
+// subset of the FHIR R4 data types and resources served by the api,
+// see https://hl7.org/fhir/R4/resourcelist.html
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Address struct {
+	Use  string   `json:"use,omitempty"`
+	Text string   `json:"text,omitempty"`
+	Line []string `json:"line,omitempty"`
+	City string   `json:"city,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type Quantity struct {
+	Value *float64 `json:"value,omitempty"`
+	Unit  string   `json:"unit,omitempty"`
+	Code  string   `json:"code,omitempty"`
+}
+
+type Annotation struct {
+	Text string `json:"text"`
+}
+
+type Patient struct {
+	ResourceType string         `json:"resourceType"`
+	Id           string         `json:"id,omitempty"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Identifier   []Identifier   `json:"identifier,omitempty"`
+	Active       bool           `json:"active"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Gender       string         `json:"gender,omitempty"`
+	BirthDate    string         `json:"birthDate,omitempty"`
+	Address      []Address      `json:"address,omitempty"`
+}
+
+type Practitioner struct {
+	ResourceType string         `json:"resourceType"`
+	Id           string         `json:"id,omitempty"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Active       bool           `json:"active"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Address      []Address      `json:"address,omitempty"`
+}
+
+type EncounterParticipant struct {
+	Individual *Reference `json:"individual,omitempty"`
+}
+
+type EncounterDiagnosis struct {
+	Condition Reference `json:"condition"`
+	Rank      int       `json:"rank,omitempty"`
+}
+
+type Encounter struct {
+	ResourceType string                 `json:"resourceType"`
+	Id           string                 `json:"id,omitempty"`
+	Meta         *Meta                  `json:"meta,omitempty"`
+	Status       string                 `json:"status"`
+	Class        Coding                 `json:"class"`
+	Subject      *Reference             `json:"subject,omitempty"`
+	Participant  []EncounterParticipant `json:"participant,omitempty"`
+	Period       *Period                `json:"period,omitempty"`
+	ReasonCode   []CodeableConcept      `json:"reasonCode,omitempty"`
+	Diagnosis    []EncounterDiagnosis   `json:"diagnosis,omitempty"`
+}
+
+type Condition struct {
+	ResourceType string            `json:"resourceType"`
+	Id           string            `json:"id,omitempty"`
+	Meta         *Meta             `json:"meta,omitempty"`
+	Category     []CodeableConcept `json:"category,omitempty"`
+	Code         *CodeableConcept  `json:"code,omitempty"`
+	Subject      Reference         `json:"subject"`
+	Encounter    *Reference        `json:"encounter,omitempty"`
+	RecordedDate string            `json:"recordedDate,omitempty"`
+	Asserter     *Reference        `json:"asserter,omitempty"`
+	Note         []Annotation      `json:"note,omitempty"`
+}
+
+type ObservationComponent struct {
+	Code          CodeableConcept `json:"code"`
+	ValueQuantity *Quantity       `json:"valueQuantity,omitempty"`
+}
+
+type Observation struct {
+	ResourceType      string                 `json:"resourceType"`
+	Id                string                 `json:"id,omitempty"`
+	Status            string                 `json:"status"`
+	Code              CodeableConcept        `json:"code"`
+	Subject           *Reference             `json:"subject,omitempty"`
+	Encounter         *Reference             `json:"encounter,omitempty"`
+	EffectiveDateTime string                 `json:"effectiveDateTime,omitempty"`
+	ValueQuantity     *Quantity              `json:"valueQuantity,omitempty"`
+	Component         []ObservationComponent `json:"component,omitempty"`
+}
+
+type MedicationRequest struct {
+	ResourceType              string           `json:"resourceType"`
+	Id                        string           `json:"id,omitempty"`
+	Meta                      *Meta            `json:"meta,omitempty"`
+	Status                    string           `json:"status"`
+	Intent                    string           `json:"intent"`
+	MedicationCodeableConcept *CodeableConcept `json:"medicationCodeableConcept,omitempty"`
+	Subject                   Reference        `json:"subject"`
+	Encounter                 *Reference       `json:"encounter,omitempty"`
+	AuthoredOn                string           `json:"authoredOn,omitempty"`
+	Requester                 *Reference       `json:"requester,omitempty"`
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	Url      string `json:"url"`
+}
+
+type BundleSearch struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+type BundleEntry struct {
+	FullUrl  string        `json:"fullUrl,omitempty"`
+	Resource interface{}   `json:"resource,omitempty"`
+	Search   *BundleSearch `json:"search,omitempty"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Id           string        `json:"id,omitempty"`
+	Meta         *Meta         `json:"meta,omitempty"`
+	Type         string        `json:"type"`
+	Total        *int64        `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+// Resource is implemented by every resource that can be an entry of a bundle
+
+type Resource interface {
+	ResourceId() string
+}
+
+func (r Patient) ResourceId() string           { return r.Id }
+func (r Practitioner) ResourceId() string      { return r.Id }
+func (r Encounter) ResourceId() string         { return r.Id }
+func (r Condition) ResourceId() string         { return r.Id }
+func (r MedicationRequest) ResourceId() string { return r.Id }
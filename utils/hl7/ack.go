@@ -0,0 +1,63 @@
+package hl7
+
+import (
+	"strings"
+	"time"
+)
+
+// acknowledgment codes of MSA-1
+
+const (
+	Accept = "AA"
+	Error  = "AE"
+	Reject = "AR"
+)
+
+// Ack answers the message with an ACK, sending and receiving application of
+// the original header are swapped. A message that could not be parsed is
+// answered with an empty header
+
+func Ack(m Message, code, text string) string {
+	if len(m.Segments) == 0 {
+		m = Message{field: '|', comp: '^', rep: '~', escape: '\\', sub: '&'}
+	}
+
+	var f = string(m.field)
+	var version = m.Version()
+	if version == "" {
+		version = "2.5"
+	}
+
+	var msh = []string{
+		"MSH",
+		string(m.comp) + string(m.rep) + string(m.escape) + string(m.sub),
+		raw(m, "MSH", 5), raw(m, "MSH", 6),
+		raw(m, "MSH", 3), raw(m, "MSH", 4),
+		time.Now().Format("20060102150405"),
+		"",
+		"ACK" + string(m.comp) + m.Event() + string(m.comp) + "ACK",
+		"ACK" + m.ControlId(),
+		"P",
+		version,
+	}
+
+	var segments = []string{
+		strings.Join(msh, f),
+		strings.Join([]string{"MSA", code, m.Escape(m.ControlId()), m.Escape(text)}, f),
+	}
+
+	if code != Accept {
+		// ERR-3 is the hl7 error code, 207 application internal error
+		segments = append(segments, strings.Join([]string{"ERR", "", "", "207" + string(m.comp) + "Application internal error" + string(m.comp) + "HL70357", "E", "", "", "", m.Escape(text)}, f))
+	}
+
+	return strings.Join(segments, "\r") + "\r"
+}
+
+func raw(m Message, name string, field int) string {
+	var segment, ok = m.Segment(name)
+	if !ok || field > len(segment.Fields) {
+		return ""
+	}
+	return segment.Fields[field-1]
+}
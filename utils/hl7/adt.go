@@ -0,0 +1,93 @@
+package hl7
+
+import (
+	"be/entities"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+var nikRegex = regexp.MustCompile(`^[0-9]{16}$`)
+
+// the admission and person events all carry the full PID, each of them is
+// applied as create or update of the patient
+
+var AdtEvents = map[string]bool{"A01": true, "A04": true, "A05": true, "A08": true, "A28": true, "A31": true}
+
+var genders = map[string]string{"M": "pria", "F": "wanita"}
+
+var maritalStatus = map[string]string{"S": "belumKawin", "M": "kawin", "D": "ceraiHidup", "W": "ceraiMati"}
+
+// ToPatient reads the PID segment, the NIK is the identifier with type code
+// NIK or, when no type is sent, the first identifier of 16 digits
+
+func ToPatient(m Message) (entities.Patient, error) {
+	var pid, ok = m.Segment("PID")
+	if !ok {
+		return entities.Patient{}, errors.New("PID segment is not found")
+	}
+
+	var patient = entities.Patient{Nik: nik(m, pid)}
+
+	if patient.Nik == "" {
+		return entities.Patient{}, errors.New("patient has no valid nik")
+	}
+
+	var name = []string{}
+	for _, part := range []string{m.Value(pid, 5, 2), m.Value(pid, 5, 3), m.Value(pid, 5, 1)} {
+		if part != "" {
+			name = append(name, part)
+		}
+	}
+	patient.Name = strings.Join(name, " ")
+
+	if dob := m.Value(pid, 7, 1); len(dob) >= 8 {
+		var date, err = time.Parse("20060102", dob[:8])
+		if err != nil {
+			return entities.Patient{}, errors.New("invalid date of birth")
+		}
+		patient.Dob = datatypes.Date(date)
+	}
+
+	if sex := m.Value(pid, 8, 1); sex != "" {
+		patient.Gender = genders[sex]
+		if patient.Gender == "" {
+			patient.Gender = "lainnya"
+		}
+	}
+
+	var address = []string{}
+	for _, part := range []int{1, 2, 3} {
+		if value := m.Value(pid, 11, part); value != "" {
+			address = append(address, value)
+		}
+	}
+	patient.Address = strings.Join(address, ", ")
+
+	patient.Status = maritalStatus[m.Value(pid, 16, 1)]
+	patient.PlaceBirth = m.Value(pid, 23, 1)
+
+	return patient, nil
+}
+
+func nik(m Message, pid Segment) string {
+	var first string
+
+	for _, rep := range m.Repetitions(pid, 3) {
+		var id = m.Component(rep, 1)
+		if !nikRegex.MatchString(id) {
+			continue
+		}
+		if strings.EqualFold(m.Component(rep, 5), "NIK") || strings.EqualFold(m.Component(rep, 4), "NIK") {
+			return id
+		}
+		if first == "" && m.Component(rep, 5) == "" {
+			first = id
+		}
+	}
+
+	return first
+}
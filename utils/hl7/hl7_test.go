@@ -0,0 +1,167 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var adt = strings.Join([]string{
+	`MSH|^~\&|SIMRS|RSUD|MRCLINIC|CLINIC|20220601101500||ADT^A04^ADT_A01|MSG0001|P|2.5`,
+	`EVN|A04|20220601101500`,
+	`PID|1||RM-991^^^RSUD^MR~3171234567890123^^^DUKCAPIL^NIK||Rahayu^Siti^Dewi||19900505|F|||Jl. Melati 5^RT 02^Jakarta|||||M|||||||Bandung`,
+	`PV1|1|O`,
+}, "\r")
+
+var oru = strings.Join([]string{
+	`MSH|^~\&|ANALYZER|LAB|MRCLINIC|CLINIC|20220601120000||ORU^R01|MSG0002|P|2.5.1`,
+	`PID|1||3171234567890123^^^^NIK||Rahayu^Siti`,
+	`PV1|1|O|||||||||||||||||patient-1`,
+	`ORC|RE|order-1`,
+	`OBR|1||LAB-77|CBC^Complete blood count`,
+	`OBX|1|NM|718-7^Hemoglobin^LN||11.2|g/dL|12-16|L|||F`,
+	`OBX|2|NM|6690-2^Leukocytes^LN||7.5|10\S\3/uL|4-11||||F`,
+	`OBX|3|ST|X^Note||cancelled||||||X`,
+	`OBR|2|order-2||GLU^Glucose`,
+	`OBX|1|NM|GLU^^LN||250|mg/dL|70-140|>|||F`,
+}, "\r")
+
+func TestParse(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var m, err = Parse(adt)
+		assert.Nil(t, err)
+		assert.Equal(t, "ADT", m.Type())
+		assert.Equal(t, "A04", m.Event())
+		assert.Equal(t, "MSG0001", m.ControlId())
+		assert.Equal(t, "2.5", m.Version())
+		assert.Equal(t, "SIMRS@RSUD", m.Sender())
+		assert.Equal(t, `^~\&`, m.Get("MSH", 2, 1))
+		assert.Equal(t, 4, len(m.Segments))
+	})
+
+	t.Run("success newline", func(t *testing.T) {
+		var m, err = Parse(strings.ReplaceAll(adt, "\r", "\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(m.Segments))
+	})
+
+	t.Run("unescape", func(t *testing.T) {
+		var m, _ = Parse(oru)
+		var obx = m.All("OBX")
+		assert.Equal(t, "10^3/uL", m.Value(obx[1], 6, 1))
+		assert.Equal(t, `a|b\c`, m.Unescape(m.Escape(`a|b\c`)))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var _, err = Parse("PID|1")
+		assert.NotNil(t, err)
+
+		_, err = Parse(`MSH|^~\&|A|B`)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestAck(t *testing.T) {
+	var m, _ = Parse(adt)
+
+	t.Run("accept", func(t *testing.T) {
+		var ack, err = Parse(Ack(m, Accept, ""))
+		assert.Nil(t, err)
+		assert.Equal(t, "ACK", ack.Type())
+		assert.Equal(t, "AA", ack.Get("MSA", 1, 1))
+		assert.Equal(t, "MSG0001", ack.Get("MSA", 2, 1))
+		assert.Equal(t, "MRCLINIC", ack.Get("MSH", 3, 1))
+		assert.Equal(t, "SIMRS", ack.Get("MSH", 5, 1))
+		_, ok := ack.Segment("ERR")
+		assert.False(t, ok)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var ack, _ = Parse(Ack(m, Error, "patient has no valid nik"))
+		assert.Equal(t, "AE", ack.Get("MSA", 1, 1))
+		assert.Equal(t, "patient has no valid nik", ack.Get("ERR", 8, 1))
+	})
+
+	t.Run("unparsed", func(t *testing.T) {
+		var ack, err = Parse(Ack(Message{}, Reject, "message doesn't start with MSH"))
+		assert.Nil(t, err)
+		assert.Equal(t, "AR", ack.Get("MSA", 1, 1))
+	})
+}
+
+func TestFrame(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteFrame(&buf, adt))
+	assert.Nil(t, WriteFrame(&buf, oru))
+
+	var r = bufio.NewReader(bytes.NewReader(append([]byte("noise"), buf.Bytes()...)))
+
+	var first, err = ReadFrame(r)
+	assert.Nil(t, err)
+	assert.Equal(t, adt, first)
+
+	second, err := ReadFrame(r)
+	assert.Nil(t, err)
+	assert.Equal(t, oru, second)
+
+	_, err = ReadFrame(bufio.NewReader(bytes.NewReader([]byte("\x0bMSH|unfinished"))))
+	assert.NotNil(t, err)
+}
+
+func TestToPatient(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var m, _ = Parse(adt)
+		var patient, err = ToPatient(m)
+		assert.Nil(t, err)
+		assert.Equal(t, "3171234567890123", patient.Nik)
+		assert.Equal(t, "Siti Dewi Rahayu", patient.Name)
+		assert.Equal(t, "wanita", patient.Gender)
+		assert.Equal(t, "kawin", patient.Status)
+		assert.Equal(t, "Jl. Melati 5, RT 02, Jakarta", patient.Address)
+		assert.Equal(t, "Bandung", patient.PlaceBirth)
+		assert.Equal(t, "05-05-1990", time.Time(patient.Dob).Format("02-01-2006"))
+	})
+
+	t.Run("error nik", func(t *testing.T) {
+		var m, _ = Parse(strings.Replace(adt, "3171234567890123", "12345", 1))
+		var _, err = ToPatient(m)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestToResults(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var m, _ = Parse(oru)
+		var res, err = ToResults(m)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(res))
+
+		assert.Equal(t, "order-1", res[0].Order_uid)
+		assert.Equal(t, "patient-1", res[0].Visit_uid)
+		assert.Equal(t, "Complete blood count", res[0].Test)
+		assert.Equal(t, 2, len(res[0].Results))
+		assert.Equal(t, "Hemoglobin", res[0].Results[0].Analyte)
+		assert.Equal(t, "L", res[0].Results[0].Flag)
+		assert.Equal(t, "", res[0].Results[1].Flag)
+
+		assert.Equal(t, "order-2", res[1].Order_uid)
+		assert.Equal(t, "GLU", res[1].Results[0].Analyte)
+		assert.Equal(t, "A", res[1].Results[0].Flag)
+	})
+
+	t.Run("error no order", func(t *testing.T) {
+		var m, _ = Parse(strings.Join([]string{`MSH|^~\&|A|B|C|D|20220601||ORU^R01|1|P|2.5`, `OBR|1||LAB-77|CBC`, `OBX|1|NM|HB||11|g/dL`}, "\r"))
+		var _, err = ToResults(m)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("error obx first", func(t *testing.T) {
+		var m, _ = Parse(strings.Join([]string{`MSH|^~\&|A|B|C|D|20220601||ORU^R01|1|P|2.5`, `OBX|1|NM|HB||11|g/dL`}, "\r"))
+		var _, err = ToResults(m)
+		assert.NotNil(t, err)
+	})
+}
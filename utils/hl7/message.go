@@ -0,0 +1,186 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+)
+
+// Message is an HL7 v2 message in the pipe delimited (ER7) encoding. Fields are
+// kept escaped and only unescaped when a value is read
+
+type Message struct {
+	Segments []Segment
+	field    byte
+	comp     byte
+	rep      byte
+	escape   byte
+	sub      byte
+}
+
+type Segment struct {
+	Name   string
+	Fields []string
+}
+
+// Parse splits the message in segments, MSH has to come first and gives the separators
+
+func Parse(raw string) (Message, error) {
+	raw = strings.Trim(raw, "\r\n\x0b\x1c")
+
+	if len(raw) < 8 || !strings.HasPrefix(raw, "MSH") {
+		return Message{}, errors.New("message doesn't start with MSH")
+	}
+
+	var msg = Message{field: raw[3], comp: raw[4], rep: raw[5], escape: raw[6], sub: raw[7]}
+
+	// segments end with a carriage return, newlines are accepted from hand made messages
+
+	var lines = strings.FieldsFunc(strings.ReplaceAll(raw, "\r\n", "\r"), func(r rune) bool { return r == '\r' || r == '\n' })
+
+	for i, line := range lines {
+		if len(line) < 3 {
+			continue
+		}
+
+		var fields = strings.Split(line, string(msg.field))
+		var segment = Segment{Name: fields[0]}
+
+		// MSH-1 is the field separator itself, so field numbers of MSH are shifted by one
+
+		if i == 0 {
+			segment.Fields = append([]string{string(msg.field)}, fields[1:]...)
+		} else {
+			segment.Fields = fields[1:]
+		}
+
+		msg.Segments = append(msg.Segments, segment)
+	}
+
+	if msg.Type() == "" {
+		return Message{}, errors.New("message type is empty")
+	}
+
+	return msg, nil
+}
+
+// Segment returns the first segment with the name
+
+func (m Message) Segment(name string) (Segment, bool) {
+	for _, segment := range m.Segments {
+		if segment.Name == name {
+			return segment, true
+		}
+	}
+	return Segment{}, false
+}
+
+func (m Message) All(name string) []Segment {
+	var res []Segment
+	for _, segment := range m.Segments {
+		if segment.Name == name {
+			res = append(res, segment)
+		}
+	}
+	return res
+}
+
+// Get reads SEG-field.component (both 1 based) of the first segment with the
+// name, the first repetition only, unescaped
+
+func (m Message) Get(name string, field, component int) string {
+	var segment, ok = m.Segment(name)
+	if !ok {
+		return ""
+	}
+	return m.Value(segment, field, component)
+}
+
+func (m Message) Value(segment Segment, field, component int) string {
+
+	// MSH-1 and MSH-2 hold the separators and are never split
+
+	if segment.Name == "MSH" && field <= 2 && field <= len(segment.Fields) {
+		return segment.Fields[field-1]
+	}
+
+	var reps = m.Repetitions(segment, field)
+	if len(reps) == 0 {
+		return ""
+	}
+	return m.Component(reps[0], component)
+}
+
+// Repetitions of a field, still with their components
+
+func (m Message) Repetitions(segment Segment, field int) []string {
+	if field < 1 || field > len(segment.Fields) {
+		return nil
+	}
+
+	return strings.Split(segment.Fields[field-1], string(m.rep))
+}
+
+func (m Message) Component(value string, component int) string {
+	var components = strings.Split(value, string(m.comp))
+	if component < 1 || component > len(components) {
+		return ""
+	}
+	return m.Unescape(strings.Split(components[component-1], string(m.sub))[0])
+}
+
+func (m Message) Unescape(value string) string {
+	var esc = string(m.escape)
+	if !strings.Contains(value, esc) {
+		return value
+	}
+
+	var replacer = strings.NewReplacer(
+		esc+"F"+esc, string(m.field),
+		esc+"S"+esc, string(m.comp),
+		esc+"R"+esc, string(m.rep),
+		esc+"T"+esc, string(m.sub),
+		esc+"E"+esc, esc,
+		esc+".br"+esc, "\n",
+	)
+	return replacer.Replace(value)
+}
+
+func (m Message) Escape(value string) string {
+	var esc = string(m.escape)
+	var replacer = strings.NewReplacer(
+		esc, esc+"E"+esc,
+		string(m.field), esc+"F"+esc,
+		string(m.comp), esc+"S"+esc,
+		string(m.rep), esc+"R"+esc,
+		string(m.sub), esc+"T"+esc,
+		"\r", " ",
+		"\n", esc+".br"+esc,
+	)
+	return replacer.Replace(value)
+}
+
+// header fields
+
+func (m Message) Type() string {
+	return m.Get("MSH", 9, 1)
+}
+
+func (m Message) Event() string {
+	return m.Get("MSH", 9, 2)
+}
+
+func (m Message) ControlId() string {
+	return m.Get("MSH", 10, 1)
+}
+
+func (m Message) Version() string {
+	return m.Get("MSH", 12, 1)
+}
+
+func (m Message) Sender() string {
+	var app, facility = m.Get("MSH", 3, 1), m.Get("MSH", 4, 1)
+	if facility == "" {
+		return app
+	}
+	return app + "@" + facility
+}
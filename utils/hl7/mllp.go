@@ -0,0 +1,70 @@
+package hl7
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// MLLP frames a message with a vertical tab in front and a file separator
+// plus carriage return at the end
+
+const (
+	startBlock = 0x0b
+	endBlock   = 0x1c
+	cr         = 0x0d
+)
+
+// MaxFrame is the largest message accepted over MLLP
+
+const MaxFrame = 1 << 20
+
+func ReadFrame(r *bufio.Reader) (string, error) {
+
+	// anything before the start block is noise between frames
+
+	for {
+		var b, err = r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b == startBlock {
+			break
+		}
+	}
+
+	var frame []byte
+
+	for {
+		var b, err = r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+
+		if b == endBlock {
+			if next, err := r.ReadByte(); err == nil && next != cr {
+				r.UnreadByte()
+			}
+			return string(frame), nil
+		}
+
+		frame = append(frame, b)
+
+		if len(frame) > MaxFrame {
+			return "", errors.New("message is too large")
+		}
+	}
+}
+
+func WriteFrame(w io.Writer, message string) error {
+	var frame = make([]byte, 0, len(message)+3)
+	frame = append(frame, startBlock)
+	frame = append(frame, message...)
+	frame = append(frame, endBlock, cr)
+
+	var _, err = w.Write(frame)
+	return err
+}
@@ -0,0 +1,100 @@
+package hl7
+
+import (
+	"be/entities"
+	"errors"
+	"strings"
+)
+
+// Oru is one OBR group of an ORU^R01, the results of one lab order
+
+type Oru struct {
+	Order_uid string
+	Visit_uid string
+	Test      string
+	Results   []entities.LabResult
+}
+
+var flags = map[string]string{"N": "N", "L": "L", "H": "H", "LL": "LL", "HH": "HH", "A": "A"}
+
+// ToResults groups the OBX segments under the OBR before them. The order is
+// the placer order number of OBR-2 or ORC-2, the visit is the visit number of
+// PV1-19. A result without flag gets an empty flag, it is derived by the caller
+
+func ToResults(m Message) ([]Oru, error) {
+	var visit_uid = m.Get("PV1", 19, 1)
+	var placer string
+	var res []Oru
+
+	for _, segment := range m.Segments {
+		switch segment.Name {
+		case "ORC":
+			placer = m.Value(segment, 2, 1)
+		case "OBR":
+			var order = Oru{Order_uid: m.Value(segment, 2, 1), Visit_uid: visit_uid, Test: text(m, segment, 4)}
+			if order.Order_uid == "" {
+				order.Order_uid = placer
+			}
+			res = append(res, order)
+		case "OBX":
+			if len(res) == 0 {
+				return nil, errors.New("OBX segment before OBR")
+			}
+
+			// results that could not be obtained or were deleted are skipped
+
+			switch m.Value(segment, 11, 1) {
+			case "X", "D", "W":
+				continue
+			}
+
+			var value = m.Value(segment, 5, 1)
+			switch m.Value(segment, 2, 1) {
+			case "CE", "CWE":
+				if text := m.Value(segment, 5, 2); text != "" {
+					value = text
+				}
+			}
+
+			var flag = m.Value(segment, 8, 1)
+			if flag != "" {
+				if flags[flag] == "" {
+					flag = "A"
+				}
+			}
+
+			var last = &res[len(res)-1]
+			last.Results = append(last.Results, entities.LabResult{
+				Analyte:        text(m, segment, 3),
+				Value:          value,
+				Unit:           m.Value(segment, 6, 1),
+				ReferenceRange: m.Value(segment, 7, 1),
+				Flag:           flag,
+			})
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("OBR segment is not found")
+	}
+
+	for _, order := range res {
+		if order.Order_uid == "" && order.Visit_uid == "" {
+			return nil, errors.New("result has no order or visit number")
+		}
+		if len(order.Results) == 0 {
+			return nil, errors.New("OBR " + order.Test + " has no result")
+		}
+	}
+
+	return res, nil
+}
+
+// text of a coded element, the identifier when there's no text
+
+func text(m Message, segment Segment, field int) string {
+	if text := m.Value(segment, field, 2); text != "" {
+		return strings.TrimSpace(text)
+	}
+	return strings.TrimSpace(m.Value(segment, field, 1))
+}
@@ -0,0 +1,341 @@
+package list
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+const layout = "02-01-2006"
+
+type Type int
+
+const (
+	// String matches the value as it is
+	String Type = iota
+	// Search matches the columns containing the value
+	Search
+	// Int matches the number, or a range with <name>_from and <name>_to
+	Int
+	// Date matches the day in dd-mm-yyyy, or a range with <name>_from and <name>_to
+	Date
+	// Enum matches one of Values, several are separated by comma
+	Enum
+)
+
+type Field struct {
+	Column string
+	Type   Type
+	Values []string
+}
+
+// Spec is what a list endpoint allows, only the sorts and filters named here
+// reach the query
+
+type Spec struct {
+	// column or expression of every sort name, of Type Int or Date when it
+	// is compared as a number or a time, a null sorts before any value
+	Sorts map[string]Field
+	// sort without the sort param, a "-" prefix sorts descending
+	Sort string
+	// unique column breaking the ties of the sort, part of the cursor
+	Key     string
+	Filters map[string]Field
+}
+
+type condition struct {
+	query string
+	args  []interface{}
+}
+
+// Query is a page of a list as asked in the query params
+
+type Query struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+
+	sort       string
+	column     string
+	kind       Type
+	desc       bool
+	key        string
+	conditions []condition
+}
+
+// Cursor is the sort value and key of the last row of a page, the value
+// is nil when the row sorts on a null
+
+type Cursor struct {
+	Sort  string  `json:"o"`
+	Value *string `json:"s"`
+	Key   string  `json:"k"`
+}
+
+// Row is embedded in the rows of a list, holding the values the next cursor is made of
+
+type Row struct {
+	List_sort *string `json:"-"`
+	List_key  string `json:"-"`
+}
+
+type Page struct {
+	Total       int64  `json:"total"`
+	Limit       int    `json:"limit"`
+	Offset      int    `json:"offset"`
+	Next_cursor string `json:"next_cursor"`
+}
+
+// Parse reads limit, offset, cursor, sort and the filters of the spec, other
+// params are left to the endpoint
+
+func Parse(spec Spec, params url.Values) (Query, error) {
+	var q = Query{Limit: DefaultLimit, sort: spec.Sort, key: spec.Key}
+
+	if limit := params.Get("limit"); limit != "" {
+		var n, err = strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return Query{}, errors.New("invalid limit")
+		}
+		q.Limit = n
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		var n, err = strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return Query{}, errors.New("invalid offset")
+		}
+		q.Offset = n
+	}
+
+	if s := params.Get("sort"); s != "" {
+		q.sort = s
+	}
+
+	var column, ok = spec.Sorts[strings.TrimPrefix(q.sort, "-")]
+	if !ok {
+		return Query{}, errors.New("invalid sort " + q.sort)
+	}
+	q.column = column.Column
+	q.kind = column.Type
+	q.desc = strings.HasPrefix(q.sort, "-")
+
+	if cursor := params.Get("cursor"); cursor != "" {
+		if q.Offset != 0 {
+			return Query{}, errors.New("cursor and offset can't be used together")
+		}
+
+		var decoded, err = Decode(cursor)
+		if err != nil {
+			return Query{}, err
+		}
+
+		if decoded.Sort != q.sort {
+			return Query{}, errors.New("cursor is not of the sort " + q.sort)
+		}
+
+		q.Cursor = &decoded
+	}
+
+	var names []string
+	for name := range spec.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := q.filter(name, spec.Filters[name], params); err != nil {
+			return Query{}, err
+		}
+	}
+
+	return q, nil
+}
+
+func (q *Query) filter(name string, field Field, params url.Values) error {
+	var value = params.Get(name)
+
+	switch field.Type {
+	case String:
+		if value != "" {
+			q.where(field.Column+" = ?", value)
+		}
+	case Search:
+		if value != "" {
+			q.where(field.Column+" like ?", Like(value))
+		}
+	case Enum:
+		if value == "" {
+			return nil
+		}
+		var values = strings.Split(value, ",")
+		for _, v := range values {
+			if !contains(field.Values, v) {
+				return errors.New("invalid " + name + " input")
+			}
+		}
+		q.where(field.Column+" in ?", values)
+	case Int, Date:
+		var ops = []struct {
+			param string
+			op    string
+		}{{name, " = ?"}, {name + "_from", " >= ?"}, {name + "_to", " <= ?"}}
+
+		for _, op := range ops {
+			var value = params.Get(op.param)
+			if value == "" {
+				continue
+			}
+
+			var arg interface{}
+			var err error
+			if field.Type == Int {
+				arg, err = strconv.Atoi(value)
+			} else {
+				var date time.Time
+				date, err = time.Parse(layout, value)
+				arg = date.Format("2006-01-02")
+			}
+			if err != nil {
+				return errors.New("invalid " + op.param + " format")
+			}
+
+			q.where(field.Column+op.op, arg)
+		}
+	}
+
+	return nil
+}
+
+// Like is the pattern of a like matching the columns containing the value,
+// the wildcards in the value are matched as they are
+
+func Like(value string) string {
+	return "%" + strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value) + "%"
+}
+
+func (q *Query) where(query string, args ...interface{}) {
+	q.conditions = append(q.conditions, condition{query: query, args: args})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter adds the filters to the query, the result can be counted and paged
+// without the one changing the other
+
+func (q Query) Filter(db *gorm.DB) *gorm.DB {
+	for _, c := range q.conditions {
+		db = db.Where(c.query, c.args...)
+	}
+	return db.Session(&gorm.Session{})
+}
+
+// Total counts the rows, or the groups of a grouped query
+
+func Total(db *gorm.DB) (int64, error) {
+	var total int64
+	var res = db.Session(&gorm.Session{NewDB: true}).Table("(?) as list", db.Select("1")).Count(&total)
+	return total, res.Error
+}
+
+// Page sorts the query and takes one row more than the limit, telling whether
+// there is a next page
+
+func (q Query) Page(db *gorm.DB) *gorm.DB {
+	var op, order = " > ", " ASC"
+	if q.desc {
+		op, order = " < ", " DESC"
+	}
+
+	if q.Cursor != nil {
+		var query, args = q.after(op)
+		db = db.Where(query, args...)
+	}
+
+	return db.Order(q.column + order).Order(q.key + order).Offset(q.Offset).Limit(q.Limit + 1)
+}
+
+// after is the condition of the rows after the cursor, the nulls come first
+// ascending and last descending as mysql sorts them
+
+func (q Query) after(op string) (string, []interface{}) {
+	if q.Cursor.Value == nil {
+		var query = "(" + q.column + " is null and " + q.key + op + "?)"
+		if !q.desc {
+			query = "(" + query + " or " + q.column + " is not null)"
+		}
+		return query, []interface{}{q.Cursor.Key}
+	}
+
+	var value = "?"
+	switch q.kind {
+	case Int:
+		value = "cast(? as signed)"
+	case Date:
+		value = "cast(? as datetime(6))"
+	}
+
+	var query = q.column + op + value + " or (" + q.column + " = " + value + " and " + q.key + op + "?)"
+	if q.desc {
+		query += " or " + q.column + " is null"
+	}
+	return "(" + query + ")", []interface{}{*q.Cursor.Value, *q.Cursor.Value, q.Cursor.Key}
+}
+
+// Select is added to the select of the rows, filling their Row
+
+func (q Query) Select() string {
+	return ", cast(" + q.column + " as char) as List_sort, cast(" + q.key + " as char) as List_key"
+}
+
+// Result is the page of the rows, more tells whether the query returned more
+// rows than the limit and last is the last row kept
+
+func (q Query) Result(total int64, more bool, last Row) Page {
+	var page = Page{Total: total, Limit: q.Limit, Offset: q.Offset}
+
+	if more {
+		page.Next_cursor = Encode(Cursor{Sort: q.sort, Value: last.List_sort, Key: last.List_key})
+	}
+
+	return page
+}
+
+func Encode(c Cursor) string {
+	var b, _ = json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func Decode(s string) (Cursor, error) {
+	var c Cursor
+
+	var b, err = base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil || c.Key == "" {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	return c, nil
+}
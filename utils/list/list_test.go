@@ -0,0 +1,145 @@
+package list
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var spec = Spec{
+	Sorts:   map[string]Field{"name": {Column: "doctors.name"}, "capacity": {Column: "doctors.capacity", Type: Int}, "leftAt": {Column: "doctors.left_at", Type: Date}},
+	Sort:    "name",
+	Key:     "doctors.doctor_uid",
+	Filters: map[string]Field{"name": {Column: "doctors.name", Type: Search}, "status": {Column: "doctors.status", Type: Enum, Values: []string{"available", "unAvailable"}}, "capacity": {Column: "doctors.capacity", Type: Int}},
+}
+
+func dryRun(t *testing.T) *gorm.DB {
+	var db, err = gorm.Open(mysql.New(mysql.Config{DSN: "root@tcp(localhost:3306)/test", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func text(s string) *string {
+	return &s
+}
+
+func params(query string) url.Values {
+	var values, _ = url.ParseQuery(query)
+	return values
+}
+
+func TestParse(t *testing.T) {
+	t.Run("success default", func(t *testing.T) {
+		var q, err = Parse(spec, params(""))
+		assert.Nil(t, err)
+		assert.Equal(t, DefaultLimit, q.Limit)
+		assert.Nil(t, q.Cursor)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"limit=0":                       "invalid limit",
+			"limit=101":                     "invalid limit",
+			"offset=-1":                     "invalid offset",
+			"sort=password":                 "invalid sort password",
+			"cursor=abc":                    "invalid cursor",
+			"status=away":                   "invalid status input",
+			"capacity_from=many":            "invalid capacity_from format",
+			"offset=20&cursor=eyJrIjoiYSJ9": "cursor and offset can't be used together",
+		} {
+			var _, err = Parse(spec, params(query))
+			assert.Equal(t, message, err.Error(), query)
+		}
+	})
+
+	t.Run("error cursor of another sort", func(t *testing.T) {
+		var cursor = Encode(Cursor{Sort: "name", Value: text("andi"), Key: "a"})
+		var _, err = Parse(spec, params("sort=-capacity&cursor="+cursor))
+		assert.Equal(t, "cursor is not of the sort -capacity", err.Error())
+	})
+}
+
+func TestQuery(t *testing.T) {
+	var db = dryRun(t)
+
+	t.Run("success filter and page", func(t *testing.T) {
+		var q, err = Parse(spec, params("name=an_di&status=available,unAvailable&capacity_from=10&limit=5&offset=10"))
+		assert.Nil(t, err)
+
+		var sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return q.Page(q.Filter(tx.Table("doctors"))).Select("name" + q.Select()).Find(&[]map[string]interface{}{})
+		})
+		assert.Equal(t, "SELECT name, cast(doctors.name as char) as List_sort, cast(doctors.doctor_uid as char) as List_key FROM `doctors` WHERE doctors.capacity >= 10 AND doctors.name like '%an\\_di%' AND doctors.status in ('available','unAvailable') ORDER BY doctors.name ASC,doctors.doctor_uid ASC LIMIT 6 OFFSET 10", sql)
+	})
+
+	t.Run("success cursor", func(t *testing.T) {
+		var cursor = Encode(Cursor{Sort: "-capacity", Value: text("20"), Key: "abc"})
+		var q, err = Parse(spec, params("sort=-capacity&cursor="+cursor))
+		assert.Nil(t, err)
+
+		var sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return q.Page(q.Filter(tx.Table("doctors"))).Find(&[]map[string]interface{}{})
+		})
+		assert.Equal(t, "SELECT * FROM `doctors` WHERE (doctors.capacity < cast('20' as signed) or (doctors.capacity = cast('20' as signed) and doctors.doctor_uid < 'abc') or doctors.capacity is null) ORDER BY doctors.capacity DESC,doctors.doctor_uid DESC LIMIT 21", sql)
+	})
+
+	t.Run("success cursor of a time", func(t *testing.T) {
+		var cursor = Encode(Cursor{Sort: "leftAt", Value: text("2022-03-01 08:00:00.000"), Key: "abc"})
+		var q, err = Parse(spec, params("sort=leftAt&cursor="+cursor))
+		assert.Nil(t, err)
+
+		var sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return q.Page(q.Filter(tx.Table("doctors"))).Find(&[]map[string]interface{}{})
+		})
+		assert.Equal(t, "SELECT * FROM `doctors` WHERE (doctors.left_at > cast('2022-03-01 08:00:00.000' as datetime(6)) or (doctors.left_at = cast('2022-03-01 08:00:00.000' as datetime(6)) and doctors.doctor_uid > 'abc')) ORDER BY doctors.left_at ASC,doctors.doctor_uid ASC LIMIT 21", sql)
+	})
+
+	t.Run("success cursor of a null", func(t *testing.T) {
+		var cursor = Encode(Cursor{Sort: "leftAt", Key: "abc"})
+		var q, err = Parse(spec, params("sort=leftAt&cursor="+cursor))
+		assert.Nil(t, err)
+
+		var sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return q.Page(q.Filter(tx.Table("doctors"))).Find(&[]map[string]interface{}{})
+		})
+		assert.Equal(t, "SELECT * FROM `doctors` WHERE ((doctors.left_at is null and doctors.doctor_uid > 'abc') or doctors.left_at is not null) ORDER BY doctors.left_at ASC,doctors.doctor_uid ASC LIMIT 21", sql)
+
+		cursor = Encode(Cursor{Sort: "-leftAt", Key: "abc"})
+		q, err = Parse(spec, params("sort=-leftAt&cursor="+cursor))
+		assert.Nil(t, err)
+
+		sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return q.Page(q.Filter(tx.Table("doctors"))).Find(&[]map[string]interface{}{})
+		})
+		assert.Equal(t, "SELECT * FROM `doctors` WHERE (doctors.left_at is null and doctors.doctor_uid < 'abc') ORDER BY doctors.left_at DESC,doctors.doctor_uid DESC LIMIT 21", sql)
+	})
+
+	t.Run("success total of groups", func(t *testing.T) {
+		var sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			var total int64
+			return tx.Table("(?) as list", tx.Table("patients").Group("nik").Select("1")).Count(&total)
+		})
+		assert.Equal(t, "SELECT count(*) FROM (SELECT 1 FROM `patients` GROUP BY `nik`) as list", sql)
+	})
+}
+
+func TestResult(t *testing.T) {
+	var q, _ = Parse(spec, params("limit=2"))
+
+	assert.Equal(t, Page{Total: 2, Limit: 2}, q.Result(2, false, Row{}))
+
+	var page = q.Result(5, true, Row{List_sort: text("budi"), List_key: "b"})
+	var cursor, err = Decode(page.Next_cursor)
+	assert.Nil(t, err)
+	assert.Equal(t, Cursor{Sort: "name", Value: text("budi"), Key: "b"}, cursor)
+
+	page = q.Result(5, true, Row{List_key: "c"})
+	cursor, err = Decode(page.Next_cursor)
+	assert.Nil(t, err)
+	assert.Nil(t, cursor.Value)
+}
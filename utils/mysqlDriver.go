@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"be/api/storage"
 	"be/configs"
 	"be/entities"
 	"fmt"
@@ -14,6 +15,40 @@ func autoMigrate(db *gorm.DB) {
 	db.AutoMigrate(&entities.Patient{})
 	db.AutoMigrate(&entities.Doctor{})
 	db.AutoMigrate(&entities.Visit{})
+	db.AutoMigrate(&entities.Note{})
+	db.AutoMigrate(&entities.Attachment{})
+	db.AutoMigrate(&entities.Upload{})
+	db.AutoMigrate(&entities.LabOrder{})
+	db.AutoMigrate(&entities.LabResult{})
+	db.AutoMigrate(&entities.Referral{})
+	db.AutoMigrate(&entities.Document{})
+	db.AutoMigrate(&entities.Export{})
+	db.AutoMigrate(&entities.Hl7Message{})
+	db.AutoMigrate(&entities.Bulk{})
+	db.AutoMigrate(&entities.BulkRow{})
+	db.AutoMigrate(&entities.ReportSchedule{})
+	db.AutoMigrate(&entities.ReportRun{})
+	db.AutoMigrate(&entities.SchedulerLease{})
+	db.AutoMigrate(&entities.CalendarFeed{})
+	db.AutoMigrate(&entities.CalendarConnection{})
+	db.AutoMigrate(&entities.OauthState{})
+	db.AutoMigrate(&entities.CalendarSync{})
+	db.AutoMigrate(&entities.BusyTime{})
+	db.AutoMigrate(&entities.Notification{})
+	db.AutoMigrate(&entities.NotificationPreference{})
+	db.AutoMigrate(&entities.NotificationDelivery{})
+}
+
+// migrateImages turns the image urls saved before the storage keys into the
+// keys, and the default image into an empty key
+
+func migrateImages(db *gorm.DB) {
+	const bucket = "https://karen-givi-bucket.s3.ap-southeast-1.amazonaws.com/"
+
+	for _, model := range []interface{}{&entities.Doctor{}, &entities.Patient{}} {
+		db.Unscoped().Model(model).Where("image = ?", storage.DefaultImage).UpdateColumn("image", "")
+		db.Unscoped().Model(model).Where("image like ?", bucket+"%").UpdateColumn("image", gorm.Expr("substring(image, ?)", len(bucket)+1))
+	}
 }
 
 func InitDB(config *configs.AppConfig) *gorm.DB {
@@ -35,5 +70,6 @@ func InitDB(config *configs.AppConfig) *gorm.DB {
 	}
 
 	autoMigrate(DB)
+	migrateImages(DB)
 	return DB
 }
@@ -0,0 +1,50 @@
+package notice
+
+import (
+	"be/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// the kinds of the notifications, the names of the templates
+
+const (
+	BookingConfirmed = "booking_confirmed"
+	Reminder         = "reminder"
+	Cancelled        = "cancelled"
+	ResultsReady     = "results_ready"
+)
+
+// Enqueue adds a notification of the kind to each user in the transaction
+// of the change. The ref makes the key, a notification with the same kind,
+// ref and user is added once
+func Enqueue(tx *gorm.DB, kind, ref, visit_uid string, user_uids ...string) error {
+	var now = time.Now()
+
+	var notifications = []entities.Notification{}
+	for _, user_uid := range user_uids {
+		if user_uid == "" {
+			continue
+		}
+
+		notifications = append(notifications, entities.Notification{
+			Dedup_key: Key(kind, ref, user_uid),
+			Kind:      kind,
+			User_uid:  user_uid,
+			Visit_uid: visit_uid,
+			Next_at:   now,
+		})
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&notifications).Error
+}
+
+func Key(kind, ref, user_uid string) string {
+	return kind + ":" + ref + ":" + user_uid
+}
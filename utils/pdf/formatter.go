@@ -0,0 +1,43 @@
+package pdf
+
+type Letter struct {
+	Kind          string
+	Code          string
+	VerifyPath    string
+	PatientName   string
+	Nik           string
+	Dob           string
+	DoctorName    string
+	DoctorAddress string
+	VisitDate     string
+	StartDate     string
+	EndDate       string
+	Days          int
+	Note          string
+	IssuedAt      string
+}
+
+type Record struct {
+	PatientName string
+	Nik         string
+	Gender      string
+	PlaceBirth  string
+	Dob         string
+	Address     string
+	Job         string
+	Religion    string
+	GeneratedAt string
+	Visits      []RecordVisit
+}
+
+type RecordVisit struct {
+	Date             string
+	DoctorName       string
+	Status           string
+	Complaint        string
+	MainDiagnose     string
+	AdditionDiagnose string
+	Action           string
+	Recipe           string
+	Vitals           string
+}
@@ -0,0 +1,6 @@
+package pdf
+
+type Pdf interface {
+	Generate(letter Letter) ([]byte, error)
+	Record(record Record) ([]byte, error)
+}
@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type Template struct{}
+
+func New() *Template {
+	return &Template{}
+}
+
+func (t *Template) Generate(letter Letter) ([]byte, error) {
+	var tmpl, ok = templates[letter.Kind]
+	if !ok {
+		return nil, errors.New("document template is not found")
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.body.Execute(&body, letter); err != nil {
+		return nil, err
+	}
+
+	var doc = gofpdf.New("P", "mm", "A4", "")
+	// core fonts only know cp1252, names may contain other characters
+	var tr = doc.UnicodeTranslatorFromDescriptor("")
+
+	doc.SetTitle(tmpl.title, true)
+	doc.SetMargins(20, 20, 20)
+	doc.AddPage()
+
+	// letterhead
+
+	doc.SetFont("Helvetica", "B", 14)
+	doc.CellFormat(0, 8, tr(letter.DoctorName), "", 1, "C", false, 0, "")
+	doc.SetFont("Helvetica", "", 10)
+	doc.CellFormat(0, 5, tr(letter.DoctorAddress), "", 1, "C", false, 0, "")
+	doc.Ln(2)
+	doc.Line(20, doc.GetY(), 190, doc.GetY())
+	doc.Ln(8)
+
+	doc.SetFont("Helvetica", "BU", 13)
+	doc.CellFormat(0, 8, tmpl.title, "", 1, "C", false, 0, "")
+	doc.SetFont("Helvetica", "", 10)
+	doc.CellFormat(0, 5, "No. "+letter.Code, "", 1, "C", false, 0, "")
+	doc.Ln(8)
+
+	// patient identity
+
+	doc.SetFont("Helvetica", "", 11)
+	for _, row := range [][2]string{
+		{"Name", letter.PatientName},
+		{"NIK", letter.Nik},
+		{"Date of birth", letter.Dob},
+	} {
+		doc.CellFormat(40, 7, row[0], "", 0, "L", false, 0, "")
+		doc.CellFormat(0, 7, ": "+tr(row[1]), "", 1, "L", false, 0, "")
+	}
+	doc.Ln(5)
+
+	for _, paragraph := range strings.Split(body.String(), "\n") {
+		doc.MultiCell(0, 6, tr(paragraph), "", "J", false)
+		doc.Ln(2)
+	}
+	doc.Ln(10)
+
+	// signature
+
+	doc.SetX(120)
+	doc.CellFormat(0, 6, letter.IssuedAt, "", 1, "C", false, 0, "")
+	doc.SetX(120)
+	doc.CellFormat(0, 6, "Examining doctor,", "", 1, "C", false, 0, "")
+	doc.Ln(20)
+	doc.SetX(120)
+	doc.SetFont("Helvetica", "BU", 11)
+	doc.CellFormat(0, 6, tr(letter.DoctorName), "", 1, "C", false, 0, "")
+
+	// verification footer
+
+	doc.SetY(-35)
+	doc.SetFont("Helvetica", "I", 9)
+	doc.MultiCell(0, 5, "Verification code: "+letter.Code+"\nThe authenticity of this document can be checked at "+letter.VerifyPath, "T", "L", false)
+
+	var res bytes.Buffer
+	if err := doc.Output(&res); err != nil {
+		return nil, err
+	}
+
+	return res.Bytes(), nil
+}
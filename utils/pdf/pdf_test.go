@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	var letter = Letter{Code: "ABCDE-12345", VerifyPath: "/documents/verify/ABCDE-12345", PatientName: "Siti Rahayu", Nik: "1234567890123456", Dob: "05-05-1990", DoctorName: "dr. Andi", DoctorAddress: "jakarta", VisitDate: "01-06-2022", StartDate: "01-06-2022", EndDate: "03-06-2022", Days: 3, IssuedAt: "01-06-2022"}
+
+	t.Run("success sick leave", func(t *testing.T) {
+		letter.Kind = "sickLeave"
+		var res, err = New().Generate(letter)
+		assert.Nil(t, err)
+		assert.True(t, bytes.HasPrefix(res, []byte("%PDF")))
+	})
+
+	t.Run("success fitness", func(t *testing.T) {
+		letter.Kind = "fitness"
+		letter.Note = "job application"
+		var res, err = New().Generate(letter)
+		assert.Nil(t, err)
+		assert.True(t, bytes.HasPrefix(res, []byte("%PDF")))
+	})
+
+	t.Run("error template", func(t *testing.T) {
+		letter.Kind = "unknown"
+		var _, err = New().Generate(letter)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRecord(t *testing.T) {
+	var record = Record{PatientName: "Siti Rahayu", Nik: "1234567890123456", Gender: "wanita", PlaceBirth: "bandung", Dob: "05-05-1990", GeneratedAt: "01-06-2022 10:00"}
+
+	t.Run("success without visit", func(t *testing.T) {
+		var res, err = New().Record(record)
+		assert.Nil(t, err)
+		assert.True(t, bytes.HasPrefix(res, []byte("%PDF")))
+	})
+
+	t.Run("success with visits", func(t *testing.T) {
+		for i := 0; i < 40; i++ {
+			record.Visits = append(record.Visits, RecordVisit{Date: "01-06-2022", DoctorName: "dr. Andi", Status: "completed", Complaint: "demam", MainDiagnose: "influenza", Recipe: "paracetamol 3x500mg", Vitals: "bp 120/80, hr 80"})
+		}
+		var res, err = New().Record(record)
+		assert.Nil(t, err)
+		assert.True(t, bytes.HasPrefix(res, []byte("%PDF")))
+	})
+}
@@ -0,0 +1,81 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func (t *Template) Record(record Record) ([]byte, error) {
+	var doc = gofpdf.New("P", "mm", "A4", "")
+	var tr = doc.UnicodeTranslatorFromDescriptor("")
+
+	doc.SetTitle("MEDICAL RECORD", true)
+	doc.SetMargins(20, 20, 20)
+	doc.SetAutoPageBreak(true, 20)
+	doc.SetFooterFunc(func() {
+		doc.SetY(-15)
+		doc.SetFont("Helvetica", "I", 8)
+		doc.CellFormat(0, 5, "Generated at "+record.GeneratedAt+" - page "+strconv.Itoa(doc.PageNo()), "T", 0, "C", false, 0, "")
+	})
+	doc.AddPage()
+
+	doc.SetFont("Helvetica", "BU", 14)
+	doc.CellFormat(0, 8, "MEDICAL RECORD", "", 1, "C", false, 0, "")
+	doc.Ln(6)
+
+	// patient profile
+
+	doc.SetFont("Helvetica", "", 11)
+	for _, row := range [][2]string{
+		{"Name", record.PatientName},
+		{"NIK", record.Nik},
+		{"Gender", record.Gender},
+		{"Place, date of birth", record.PlaceBirth + ", " + record.Dob},
+		{"Address", record.Address},
+		{"Job", record.Job},
+		{"Religion", record.Religion},
+	} {
+		doc.CellFormat(45, 7, row[0], "", 0, "L", false, 0, "")
+		doc.MultiCell(0, 7, ": "+tr(row[1]), "", "L", false)
+	}
+	doc.Ln(4)
+
+	if len(record.Visits) == 0 {
+		doc.SetFont("Helvetica", "I", 11)
+		doc.CellFormat(0, 7, "There is no visit recorded.", "", 1, "L", false, 0, "")
+	}
+
+	// one block per visit, oldest first
+
+	for i, visit := range record.Visits {
+		doc.SetFont("Helvetica", "B", 11)
+		doc.SetFillColor(230, 230, 230)
+		doc.CellFormat(0, 7, tr(strconv.Itoa(i+1)+". "+visit.Date+" - "+visit.DoctorName+" ("+visit.Status+")"), "", 1, "L", true, 0, "")
+
+		doc.SetFont("Helvetica", "", 10)
+		for _, row := range [][2]string{
+			{"Complaint", visit.Complaint},
+			{"Vital signs", visit.Vitals},
+			{"Main diagnosis", visit.MainDiagnose},
+			{"Other diagnosis", visit.AdditionDiagnose},
+			{"Action", visit.Action},
+			{"Prescription", visit.Recipe},
+		} {
+			if row[1] == "" {
+				continue
+			}
+			doc.CellFormat(35, 6, row[0], "", 0, "L", false, 0, "")
+			doc.MultiCell(0, 6, ": "+tr(row[1]), "", "L", false)
+		}
+		doc.Ln(3)
+	}
+
+	var res bytes.Buffer
+	if err := doc.Output(&res); err != nil {
+		return nil, err
+	}
+
+	return res.Bytes(), nil
+}
@@ -0,0 +1,25 @@
+package pdf
+
+import "text/template"
+
+type letterTemplate struct {
+	title string
+	body  *template.Template
+}
+
+// the body is filled with Letter, one paragraph per line
+
+var templates = map[string]letterTemplate{
+	"sickLeave": {
+		title: "SICK LEAVE LETTER",
+		body: template.Must(template.New("sickLeave").Parse(`The undersigned doctor declares that the patient above was examined on {{.VisitDate}} and, for health reasons, needs to rest for {{.Days}} day(s), from {{.StartDate}} until {{.EndDate}}.
+{{if .Note}}Note: {{.Note}}
+{{end}}This letter is made truthfully to be used as necessary.`)),
+	},
+	"fitness": {
+		title: "MEDICAL FITNESS CERTIFICATE",
+		body: template.Must(template.New("fitness").Parse(`The undersigned doctor declares that the patient above was examined on {{.VisitDate}} and, at the time of examination, was found to be in good health and physically fit.
+{{if .Note}}Purpose: {{.Note}}
+{{end}}This certificate is made truthfully to be used as necessary.`)),
+	},
+}
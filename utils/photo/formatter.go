@@ -0,0 +1,24 @@
+package photo
+
+type Size struct {
+	Name string
+	// the longest side in pixels
+	Max int
+}
+
+// Image is a processed size of the photo, a jpeg
+
+type Image struct {
+	Size   string
+	Width  int
+	Height int
+	Body   []byte
+}
+
+// Urls are the sizes of the photo sent in the profiles
+
+type Urls struct {
+	Large  string `json:"large"`
+	Medium string `json:"medium"`
+	Small  string `json:"small"`
+}
@@ -0,0 +1,11 @@
+package photo
+
+import "mime/multipart"
+
+// Photo keeps the profile photos in every size under one key
+
+type Photo interface {
+	Upload(fileHeader multipart.FileHeader) (string, error)
+	Delete(key string) error
+	Urls(key string) Urls
+}
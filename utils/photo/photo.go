@@ -0,0 +1,100 @@
+package photo
+
+import (
+	"be/api/scan"
+	"be/api/storage"
+	"bytes"
+	"mime/multipart"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+	"github.com/lithammer/shortuuid"
+)
+
+// prefix marks the keys of the photos kept in sizes, older keys are a single
+// file served for every size
+
+const prefix = "photos/"
+
+type Store struct {
+	store storage.Public
+	guard *scan.Guard
+}
+
+func New(store storage.Public, guard *scan.Guard) *Store {
+	return &Store{
+		store: store,
+		guard: guard,
+	}
+}
+
+// Upload scans the upload for malware, processes the photo and keeps every
+// size under a new key. The errors of Process and of an infected file mean
+// the upload is refused
+func (s *Store) Upload(fileHeader multipart.FileHeader) (string, error) {
+	var src, err = fileHeader.Open()
+	if err != nil {
+		log.Warn(err)
+		return "", err
+	}
+	defer src.Close()
+
+	data, err := read(src)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.guard.Check(fileHeader.Filename, data); err != nil {
+		return "", err
+	}
+
+	images, err := process(data)
+	if err != nil {
+		return "", err
+	}
+
+	var key = prefix + shortuuid.New()
+
+	for i, image := range images {
+		if err := s.store.Put(sizeKey(key, image.Size), "image/jpeg", bytes.NewReader(image.Body)); err != nil {
+			for _, image := range images[:i] {
+				s.store.Delete(sizeKey(key, image.Size))
+			}
+			return "", err
+		}
+	}
+
+	return key, nil
+}
+
+func (s *Store) Delete(key string) error {
+	if !strings.HasPrefix(key, prefix) {
+		return s.store.Delete(key)
+	}
+
+	var failed error
+	for _, size := range Sizes {
+		if err := s.store.Delete(sizeKey(key, size.Name)); err != nil {
+			failed = err
+		}
+	}
+
+	return failed
+}
+
+func (s *Store) Urls(key string) Urls {
+	if !strings.HasPrefix(key, prefix) {
+		var url = s.store.Url(key)
+		return Urls{Large: url, Medium: url, Small: url}
+	}
+
+	return Urls{
+		Large:  s.store.Url(sizeKey(key, "large")),
+		Medium: s.store.Url(sizeKey(key, "medium")),
+		Small:  s.store.Url(sizeKey(key, "small")),
+	}
+}
+
+func sizeKey(key, size string) string {
+	return key + "/" + size + ".jpg"
+}
@@ -0,0 +1,177 @@
+package photo
+
+import (
+	"be/api/scan"
+	"be/api/storage"
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withOrientation adds an exif segment with the orientation and a gps note to
+// the jpeg
+func withOrientation(jpg []byte, orientation byte) []byte {
+	var tiff = []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, 0, 0, 0, 0}
+	var payload = append(append([]byte("Exif\x00\x00"), tiff...), []byte("GPS -6.2,106.8")...)
+	var length = len(payload) + 2
+
+	var out = []byte{0xFF, 0xD8, 0xFF, 0xE1, byte(length >> 8), byte(length)}
+	out = append(out, payload...)
+	return append(out, jpg[2:]...)
+}
+
+func encode(t *testing.T, img image.Image, format string) []byte {
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// landscape is wide with a red left half, turned by orientation 6 the red half
+// is on top
+func landscape(w, h int) image.Image {
+	var img = image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestOrientation(t *testing.T) {
+	var jpg = encode(t, landscape(8, 4), "jpeg")
+
+	assert.Equal(t, 1, Orientation(jpg))
+	assert.Equal(t, 6, Orientation(withOrientation(jpg, 6)))
+	assert.Equal(t, 1, Orientation([]byte("%PDF-1.4")))
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("success sizes", func(t *testing.T) {
+		var images, err = Process(bytes.NewReader(encode(t, landscape(2000, 1000), "jpeg")))
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(images))
+		assert.Equal(t, []int{1024, 512}, []int{images[0].Width, images[0].Height})
+		assert.Equal(t, []int{320, 160}, []int{images[1].Width, images[1].Height})
+		assert.Equal(t, []int{96, 48}, []int{images[2].Width, images[2].Height})
+	})
+
+	t.Run("success small photo is not scaled up", func(t *testing.T) {
+		var images, _ = Process(bytes.NewReader(encode(t, landscape(200, 100), "png")))
+		assert.Equal(t, []int{200, 100}, []int{images[0].Width, images[0].Height})
+		assert.Equal(t, []int{96, 48}, []int{images[2].Width, images[2].Height})
+	})
+
+	t.Run("success turned upright without exif", func(t *testing.T) {
+		var images, err = Process(bytes.NewReader(withOrientation(encode(t, landscape(200, 100), "jpeg"), 6)))
+		assert.Nil(t, err)
+		assert.Equal(t, []int{100, 200}, []int{images[0].Width, images[0].Height})
+		assert.False(t, bytes.Contains(images[0].Body, []byte("Exif")))
+		assert.False(t, bytes.Contains(images[0].Body, []byte("GPS")))
+
+		var img, _ = jpeg.Decode(bytes.NewReader(images[0].Body))
+		var r, _, b, _ = img.At(50, 10).RGBA()
+		assert.True(t, r > b)
+	})
+
+	t.Run("success transparent on white", func(t *testing.T) {
+		var images, _ = Process(bytes.NewReader(encode(t, image.NewNRGBA(image.Rect(0, 0, 10, 10)), "png")))
+		var img, _ = jpeg.Decode(bytes.NewReader(images[0].Body))
+		var r, g, b, _ = img.At(5, 5).RGBA()
+		assert.True(t, r > 0xf000 && g > 0xf000 && b > 0xf000)
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		var _, err = Process(strings.NewReader("%PDF-1.4 not a photo"))
+		assert.Equal(t, "file is not an image", err.Error())
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		var _, err = Process(bytes.NewReader(make([]byte, MaxBytes+1)))
+		assert.Equal(t, "image is larger than 5 MB", err.Error())
+	})
+}
+
+func fileHeader(t *testing.T, content []byte) multipart.FileHeader {
+	var body = new(bytes.Buffer)
+	var writer = multipart.NewWriter(body)
+	var part, _ = writer.CreateFormFile("file", "photo.jpg")
+	part.Write(content)
+	writer.Close()
+
+	var req = httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var _, file, err = req.FormFile("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *file
+}
+
+func TestStore(t *testing.T) {
+	var dir = t.TempDir()
+	var local, err = storage.NewLocal(dir, "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var store = New(local, scan.NewGuard(scan.NewLocal(), local))
+
+	t.Run("success", func(t *testing.T) {
+		var key, err = store.Upload(fileHeader(t, encode(t, landscape(400, 200), "jpeg")))
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(key, "photos/"))
+
+		for _, size := range Sizes {
+			var _, err = os.Stat(filepath.Join(dir, "public", key, size.Name+".jpg"))
+			assert.Nil(t, err)
+		}
+
+		assert.Equal(t, "http://localhost:8080/files/"+key+"/small.jpg", store.Urls(key).Small)
+
+		assert.Nil(t, store.Delete(key))
+		var _, err1 = os.Stat(filepath.Join(dir, "public", key, "large.jpg"))
+		assert.True(t, os.IsNotExist(err1))
+	})
+
+	t.Run("success single file and default", func(t *testing.T) {
+		assert.Equal(t, "http://localhost:8080/files/old", store.Urls("old").Small)
+		assert.Equal(t, storage.DefaultImage, store.Urls("").Large)
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		var _, err = store.Upload(fileHeader(t, []byte("%PDF-1.4")))
+		assert.Equal(t, "file is not an image", err.Error())
+	})
+
+	t.Run("infected", func(t *testing.T) {
+		var _, err = store.Upload(fileHeader(t, []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)))
+		assert.Equal(t, "file is infected with Eicar-Test-Signature", err.Error())
+
+		var quarantined, _ = filepath.Glob(filepath.Join(dir, "private", "quarantine", "*", "*"))
+		assert.Equal(t, 1, len(quarantined))
+	})
+}
@@ -0,0 +1,135 @@
+package photo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxBytes is the largest upload and MaxPixels the largest image decoded, a
+// small file may still hold a huge image
+
+const MaxBytes = 5 << 20
+const MaxPixels = 40_000_000
+
+// Sizes are the boxes the photo is fitted in, largest first, smaller photos
+// are never scaled up
+
+var Sizes = []Size{
+	{Name: "large", Max: 1024},
+	{Name: "medium", Max: 320},
+	{Name: "small", Max: 96},
+}
+
+var formats = map[string]bool{"jpeg": true, "png": true, "webp": true}
+
+// Process checks the upload is a jpeg, png or webp image and makes a jpeg of
+// every size. The jpegs are encoded again from the pixels, so no metadata of
+// the upload (exif, gps) is kept, and they are turned upright first
+func Process(body io.Reader) ([]Image, error) {
+	var data, err = read(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return process(data)
+}
+
+// read takes the upload up to MaxBytes
+func read(body io.Reader) ([]byte, error) {
+	var data, err = io.ReadAll(io.LimitReader(body, MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > MaxBytes {
+		return nil, fmt.Errorf("image is larger than %d MB", MaxBytes>>20)
+	}
+
+	return data, nil
+}
+
+func process(data []byte) ([]Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || !formats[format] {
+		return nil, errors.New("file is not an image")
+	}
+
+	if config.Width == 0 || config.Height == 0 || config.Width*config.Height > MaxPixels {
+		return nil, errors.New("image has too many pixels")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("file is not an image")
+	}
+
+	var orientation = 1
+	if format == "jpeg" {
+		orientation = Orientation(data)
+	}
+
+	// scaling first makes turning the pixels cheap, the boxes are square so
+	// the turn doesn't change the fit
+
+	img = Orient(fit(img, Sizes[0].Max), orientation)
+
+	var images []Image
+	for _, size := range Sizes {
+		img = fit(img, size.Max)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+
+		images = append(images, Image{
+			Size:   size.Name,
+			Width:  img.Bounds().Dx(),
+			Height: img.Bounds().Dy(),
+			Body:   buf.Bytes(),
+		})
+	}
+
+	return images, nil
+}
+
+// fit scales the image down into a max by max box, on white so transparent
+// parts don't turn black in the jpeg
+func fit(img image.Image, max int) image.Image {
+	var b = img.Bounds()
+	var w, h = b.Dx(), b.Dy()
+
+	if w > max || h > max {
+		if w >= h {
+			w, h = max, h*max/w
+		} else {
+			w, h = w*max/h, max
+		}
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	var dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	}
+
+	return dst
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/mail"
 	"regexp"
+	"strings"
 )
 
 func UserNameRegex(s string) (string, error) {
@@ -91,6 +92,17 @@ func NikValid(s string) error {
 	return nil
 }
 
+func PhoneValid(s string) error {
+	if len(s) < 8 || len(s) > 15 {
+		return errors.New("invalid length phone")
+	} else {
+		if !DigitRegex(strings.TrimPrefix(s, "+")) {
+			return errors.New("invalid phone format")
+		}
+	}
+	return nil
+}
+
 func EmailValid(s string) error {
 	_, err := mail.ParseAddress(s)
 	// log.Info(res)
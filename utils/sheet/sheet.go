@@ -0,0 +1,133 @@
+package sheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// MaxRows is the most rows read from one file, the header included
+
+const MaxRows = 5001
+
+// Read returns the rows of a csv file or of the first sheet of a xlsx file,
+// the kind is taken from the file name. Cells are trimmed and empty rows are
+// dropped. Cells of xlsx are read raw, a date is its serial number
+
+func Read(name string, r io.Reader) ([][]string, error) {
+	var rows [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		rows, err = readCsv(r)
+	case ".xlsx":
+		rows, err = readXlsx(r)
+	default:
+		return nil, errors.New("file must be csv or xlsx")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res [][]string
+
+	for _, row := range rows {
+		var empty = true
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+			if row[i] != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+		res = append(res, row)
+		if len(res) > MaxRows {
+			return nil, errors.New("file has too many rows")
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	return res, nil
+}
+
+// spreadsheets saved as csv with an indonesian locale use semicolons
+
+func readCsv(r io.Reader) ([][]string, error) {
+	var reader = bufio.NewReader(r)
+
+	if bom, err := reader.Peek(3); err == nil && bytes.Equal(bom, []byte{0xef, 0xbb, 0xbf}) {
+		reader.Discard(3)
+	}
+
+	var head, _ = reader.Peek(4096)
+	if line := strings.SplitN(string(head), "\n", 2)[0]; strings.Count(line, ";") > strings.Count(line, ",") {
+		var c = csv.NewReader(reader)
+		c.Comma = ';'
+		c.FieldsPerRecord = -1
+		return c.ReadAll()
+	}
+
+	var c = csv.NewReader(reader)
+	c.FieldsPerRecord = -1
+	return c.ReadAll()
+}
+
+func readXlsx(r io.Reader) ([][]string, error) {
+	var f, err = excelize.OpenReader(r)
+	if err != nil {
+		return nil, errors.New("invalid xlsx file")
+	}
+	defer f.Close()
+
+	var sheets = f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	return f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+}
+
+// Csv writes the rows, a cell a spreadsheet would run as a formula is
+// prefixed with a quote so it is shown as text
+
+func Csv(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w = csv.NewWriter(&buf)
+
+	for _, row := range rows {
+		var record = make([]string, len(row))
+		for i, cell := range row {
+			record[i] = escape(cell)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func escape(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,60 @@
+package sheet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRead(t *testing.T) {
+	t.Run("success csv", func(t *testing.T) {
+		var rows, err = Read("patients.csv", strings.NewReader("\xef\xbb\xbfnik,name\n1234567890123456, siti \n\n,\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"nik", "name"}, {"1234567890123456", "siti"}}, rows)
+	})
+
+	t.Run("success csv semicolon", func(t *testing.T) {
+		var rows, err = Read("patients.CSV", strings.NewReader("nik;name;address\n1234567890123456;siti;jl. melati, jakarta\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, "jl. melati, jakarta", rows[1][2])
+	})
+
+	t.Run("success xlsx", func(t *testing.T) {
+		var f = excelize.NewFile()
+		f.SetSheetRow("Sheet1", "A1", &[]interface{}{"nik", "name", "dob"})
+		f.SetSheetRow("Sheet1", "A2", &[]interface{}{"1234567890123456", "siti", 32998})
+		var buf, _ = f.WriteToBuffer()
+
+		var rows, err = Read("patients.xlsx", bytes.NewReader(buf.Bytes()))
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"nik", "name", "dob"}, {"1234567890123456", "siti", "32998"}}, rows)
+	})
+
+	t.Run("error kind", func(t *testing.T) {
+		var _, err = Read("patients.xls", strings.NewReader(""))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("error xlsx", func(t *testing.T) {
+		var _, err = Read("patients.xlsx", strings.NewReader("nik,name"))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("error empty", func(t *testing.T) {
+		var _, err = Read("patients.csv", strings.NewReader("\n\n"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCsv(t *testing.T) {
+	var res, err = Csv([][]string{{"row", "error"}, {"2", "invalid nik, too short"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "row,error\n2,\"invalid nik, too short\"\n", string(res))
+
+	res, err = Csv([][]string{{"=HYPERLINK(\"http://evil\")", "+1", "-1", "@sum", "siti"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "\"'=HYPERLINK(\"\"http://evil\"\")\",'+1,'-1,'@sum,siti\n", string(res))
+}